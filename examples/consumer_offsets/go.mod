@@ -0,0 +1,19 @@
+module consumer_offsets
+
+go 1.17
+
+replace (
+	github.com/twmb/franz-go => ../../
+	github.com/twmb/franz-go/pkg/kmsg => ../../pkg/kmsg
+)
+
+require (
+	github.com/twmb/franz-go v1.0.0
+	github.com/twmb/franz-go/pkg/kmsg v0.0.0-20211127185622-3b34db0c6d1e
+)
+
+require (
+	github.com/klauspost/compress v1.13.6 // indirect
+	github.com/pierrec/lz4/v4 v4.1.11 // indirect
+	github.com/twmb/go-rbtree v1.0.0 // indirect
+)
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+var (
+	seedBrokers = flag.String("brokers", "localhost:9092", "comma delimited list of seed brokers")
+	group       = flag.String("group", "", "if non-empty, only print records for this group")
+)
+
+func die(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg, args...)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Parse()
+
+	// Internal topics can only be consumed if they are explicitly
+	// specified (not via regex). We consume from the start to see all
+	// commits and group metadata that have not yet been compacted away.
+	cl, err := kgo.NewClient(
+		kgo.SeedBrokers(strings.Split(*seedBrokers, ",")...),
+		kgo.ConsumeTopics("__consumer_offsets"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	if err != nil {
+		die("unable to create client: %v", err)
+	}
+	defer cl.Close()
+
+	for {
+		fetches := cl.PollFetches(context.Background())
+		if errs := fetches.Errors(); len(errs) > 0 {
+			die("fetch errors: %v", errs)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			// The format of __consumer_offsets is internal to
+			// Kafka and versioned; kmsg decodes every version
+			// Kafka has used.
+			rec, err := kmsg.ReadConsumerOffsetsRecord(r.Key, r.Value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "unable to decode record at offset %d: %v\n", r.Offset, err)
+				return
+			}
+
+			switch {
+			case rec.OffsetCommitKey != nil:
+				k := rec.OffsetCommitKey
+				if *group != "" && k.Group != *group {
+					return
+				}
+				if rec.OffsetCommitValue == nil {
+					fmt.Printf("group %s: deleted commit for %s[%d]\n", k.Group, k.Topic, k.Partition)
+					return
+				}
+				v := rec.OffsetCommitValue
+				fmt.Printf("group %s: committed %s[%d] offset %d (leader epoch %d, metadata %q)\n",
+					k.Group, k.Topic, k.Partition, v.Offset, v.LeaderEpoch, v.Metadata)
+
+			case rec.GroupMetadataKey != nil:
+				k := rec.GroupMetadataKey
+				if *group != "" && k.Group != *group {
+					return
+				}
+				if rec.GroupMetadataValue == nil {
+					fmt.Printf("group %s: deleted\n", k.Group)
+					return
+				}
+				v := rec.GroupMetadataValue
+				fmt.Printf("group %s: generation %d, protocol type %s, %d members\n",
+					k.Group, v.Generation, v.ProtocolType, len(v.Members))
+			}
+		})
+	}
+}
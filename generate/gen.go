@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -24,6 +25,7 @@ func (VarintBytes) TypeName() string           { return "[]byte" }
 func (a Array) TypeName() string               { return "[]" + a.Inner.TypeName() }
 func (Throttle) TypeName() string              { return "int32" }
 func (s Struct) TypeName() string              { return s.Name }
+func (s NullableStruct) TypeName() string      { return "*" + s.Inner.Name }
 func (FieldLengthMinusBytes) TypeName() string { return "[]byte" }
 
 func (e Enum) TypeName() string { return e.Name }
@@ -100,11 +102,7 @@ func (FieldLengthMinusBytes) WriteAppend(l *LineWriter) {
 func (a Array) WriteAppend(l *LineWriter) {
 	writeNullable := func() {
 		if a.FromFlexible {
-			l.Write("if isFlexible {")
-			l.Write("dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)")
-			l.Write("} else {")
-			l.Write("dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)")
-			l.Write("}")
+			l.Write("dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)")
 		} else {
 			l.Write("dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)")
 		}
@@ -112,11 +110,7 @@ func (a Array) WriteAppend(l *LineWriter) {
 
 	writeNormal := func() {
 		if a.FromFlexible {
-			l.Write("if isFlexible {")
-			l.Write("dst = kbin.AppendCompactArrayLen(dst, len(v))")
-			l.Write("} else {")
-			l.Write("dst = kbin.AppendArrayLen(dst, len(v))")
-			l.Write("}")
+			l.Write("dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)")
 		} else {
 			l.Write("dst = kbin.AppendArrayLen(dst, len(v))")
 		}
@@ -264,6 +258,15 @@ func (s Struct) WriteAppend(l *LineWriter) {
 	}
 }
 
+func (s NullableStruct) WriteAppend(l *LineWriter) {
+	l.Write("if v == nil {")
+	l.Write("dst = append(dst, 255)")
+	l.Write("} else {")
+	l.Write("dst = append(dst, 1)")
+	s.Inner.WriteAppend(l)
+	l.Write("}")
+}
+
 // writeBeginAndTag begins a struct field encode/decode and adds the field to
 // the tags map if necessary. If this field is only tagged, this returns true.
 func (f StructField) writeBeginAndTag(l *LineWriter, tags map[int]StructField) (onlyTag bool) {
@@ -388,11 +391,7 @@ func (a Array) WriteDecode(l *LineWriter) {
 		l.Write("l = b.VarintArrayLen()")
 	} else {
 		if a.FromFlexible {
-			l.Write("if isFlexible {")
-			l.Write("l = b.CompactArrayLen()")
-			l.Write("} else {")
-			l.Write("l = b.ArrayLen()")
-			l.Write("}")
+			l.Write("l = b.FlexibleArrayLen(isFlexible)")
 		} else {
 			l.Write("l = b.ArrayLen()")
 		}
@@ -438,6 +437,17 @@ func (a Array) WriteDecode(l *LineWriter) {
 	l.Write("v = a")
 }
 
+func (s NullableStruct) WriteDecode(l *LineWriter) {
+	l.Write("var v *%s", s.Inner.Name)
+	l.Write("if present := b.Int8(); present != -1 && b.Ok() {")
+	l.Write("v = new(%s)", s.Inner.Name)
+	l.Write("v.Default()")
+	l.Write("{") // scope the inner struct's s so that it does not shadow ours
+	s.Inner.WriteDecode(l)
+	l.Write("}")
+	l.Write("}")
+}
+
 func (f StructField) WriteDecode(l *LineWriter) {
 	switch f.Type.(type) {
 	case Struct:
@@ -676,9 +686,45 @@ func (s Struct) WriteAppendFunc(l *LineWriter) {
 }
 
 func (s Struct) WriteDecodeFunc(l *LineWriter) {
+	if s.TopLevel {
+		s.writeTopLevelDecodeFunc(l)
+		return
+	}
 	l.Write("func (v *%s) ReadFrom(src []byte) error {", s.Name)
 	l.Write("v.Default()")
 	l.Write("b := kbin.Reader{Src: src}")
+	s.writeDecodeBody(l, false)
+	l.Write("}")
+}
+
+// writeTopLevelDecodeFunc writes ReadFrom and ReadFromLenient for requests
+// and responses, which both decode through a shared readFrom.
+func (s Struct) writeTopLevelDecodeFunc(l *LineWriter) {
+	l.Write("func (v *%s) ReadFrom(src []byte) error {", s.Name)
+	l.Write("return v.readFrom(src, nil)")
+	l.Write("}")
+	l.Write("")
+	l.Write("// ReadFromLenient parses src into v and returns the number of bytes")
+	l.Write("// consumed. As with ReadFrom, trailing bytes after the known fields are")
+	l.Write("// not an error; unlike ReadFrom, the caller can compare the returned count")
+	l.Write("// against len(src) to detect them. Too little data is an error, in which")
+	l.Write("// case this returns 0.")
+	l.Write("func (v *%s) ReadFromLenient(src []byte) (int, error) {", s.Name)
+	l.Write("var rest []byte")
+	l.Write("if err := v.readFrom(src, &rest); err != nil {")
+	l.Write("return 0, err")
+	l.Write("}")
+	l.Write("return len(src) - len(rest), nil")
+	l.Write("}")
+	l.Write("")
+	l.Write("func (v *%s) readFrom(src []byte, rest *[]byte) error {", s.Name)
+	l.Write("v.Default()")
+	l.Write("b := kbin.Reader{Src: src}")
+	s.writeDecodeBody(l, true)
+	l.Write("}")
+}
+
+func (s Struct) writeDecodeBody(l *LineWriter, saveRest bool) {
 	if s.WithVersionField {
 		l.Write("v.Version = b.Int16()")
 	}
@@ -691,8 +737,12 @@ func (s Struct) WriteDecodeFunc(l *LineWriter) {
 		l.Write("_ = isFlexible")
 	}
 	s.WriteDecode(l)
+	if saveRest {
+		l.Write("if rest != nil {")
+		l.Write("*rest = b.Src")
+		l.Write("}")
+	}
 	l.Write("return b.Complete()")
-	l.Write("}")
 }
 
 func (s Struct) WriteRequestWithFunc(l *LineWriter) {
@@ -847,3 +897,91 @@ func (e Enum) WriteConsts(l *LineWriter) {
 		l.Write("%s%s %s = %d", e.Name, sb.String(), e.Name, v.Value)
 	}
 }
+
+// hasErrorCodes returns whether s, or any struct nested within s, has an
+// int16 field ending in ErrorCode.
+func (s Struct) hasErrorCodes() bool {
+	for _, f := range s.Fields {
+		switch t := f.Type.(type) {
+		case Int16:
+			if strings.HasSuffix(f.FieldName, "ErrorCode") {
+				return true
+			}
+		case Struct:
+			if t.hasErrorCodes() {
+				return true
+			}
+		case Array:
+			if inner, ok := t.Inner.(Struct); ok && inner.hasErrorCodes() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Struct) WriteErrorsFunc(l *LineWriter) {
+	l.Write("func (v *%s) Errors() []ResponseError {", s.Name)
+	l.Write("var errs []ResponseError")
+	s.writeErrors(l, "v", "", 0, "", "-1")
+	l.Write("return errs")
+	l.Write("}")
+}
+
+// writeErrors writes the checks for all error codes in s, which is accessed
+// through expr and is at path within the top level struct. Topic and
+// partition track the closest enclosing Topic and
+// Partition fields so that each error can be reported with its context.
+func (s Struct) writeErrors(l *LineWriter, expr, path string, depth int, topic, partition string) {
+	for _, f := range s.Fields {
+		switch {
+		case f.FieldName == "Topic":
+			switch f.Type.(type) {
+			case String:
+				topic = expr + ".Topic"
+			case NullableString:
+				topic = "derefString(" + expr + ".Topic)"
+			}
+		case f.FieldName == "Partition":
+			if _, ok := f.Type.(Int32); ok {
+				partition = expr + ".Partition"
+			}
+		}
+	}
+	for _, f := range s.Fields {
+		switch t := f.Type.(type) {
+		case Int16:
+			if !strings.HasSuffix(f.FieldName, "ErrorCode") {
+				continue
+			}
+			var extra string
+			msgName := strings.TrimSuffix(f.FieldName, "Code") + "Message"
+			for _, m := range s.Fields {
+				if _, ok := m.Type.(NullableString); ok && m.FieldName == msgName {
+					extra = fmt.Sprintf("Message: %s.%s, ", expr, msgName)
+				}
+			}
+			l.Write("if %s.%s != 0 {", expr, f.FieldName)
+			if topic != "" {
+				extra += fmt.Sprintf("Topic: %s, ", topic)
+			}
+			l.Write("errs = append(errs, ResponseError{Code: %[1]s.%[2]s, %[3]sField: %[4]q, Partition: %[5]s})",
+				expr, f.FieldName, extra, path+f.FieldName, partition)
+			l.Write("}")
+		case Struct:
+			if t.hasErrorCodes() {
+				t.writeErrors(l, expr+"."+f.FieldName, path+f.FieldName+".", depth, topic, partition)
+			}
+		case Array:
+			inner, ok := t.Inner.(Struct)
+			if !ok || !inner.hasErrorCodes() {
+				continue
+			}
+			elem := fmt.Sprintf("v%d", depth+1)
+			l.Write("for i := range %s.%s {", expr, f.FieldName)
+			l.Write("%s := &%s.%s[i]", elem, expr, f.FieldName)
+			inner.writeErrors(l, elem, path+f.FieldName+".", depth+1, topic, partition)
+			l.Write("}")
+		}
+	}
+}
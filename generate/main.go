@@ -117,6 +117,12 @@ type (
 		Type       Type
 	}
 
+	// NullableStruct is a struct that can be null on the wire: a single
+	// int8 precedes the struct, -1 if the struct is null and 1 if not.
+	NullableStruct struct {
+		Inner Struct
+	}
+
 	Throttle struct {
 		Switchup int
 	}
@@ -454,6 +460,9 @@ func main() {
 			}
 			if s.RequestKind != "" {
 				s.WriteRequestKindFunc(l)
+				if s.hasErrorCodes() {
+					s.WriteErrorsFunc(l)
+				}
 			}
 
 			l.Write("") // newline before append/decode func
@@ -522,6 +531,23 @@ func main() {
 	l.Write("// Int16 is an alias for int16(k).")
 	l.Write("func (k Key) Int16() int16 { return int16(k) }")
 
+	l.Write("// APIKey describes a request key that this package supports.")
+	l.Write("type APIKey struct {")
+	l.Write("Key        int16  // Key is the request key.")
+	l.Write("Name       string // Name is the name of the request, e.g. \"Fetch\".")
+	l.Write("MinVersion int16  // MinVersion is the minimum version this package supports, which is always 0.")
+	l.Write("MaxVersion int16  // MaxVersion is the maximum version this package supports.")
+	l.Write("}")
+	l.Write("// APIKeys returns every request key that this package supports, in key order.")
+	l.Write("// The returned slice is new on every call and can be freely modified.")
+	l.Write("func APIKeys() []APIKey {")
+	l.Write("return []APIKey{")
+	for _, key2struct := range name2structs {
+		l.Write("{%d, \"%s\", 0, %d},", key2struct.Key, strings.TrimSuffix(key2struct.Name, "Request"), key2struct.MaxVersion)
+	}
+	l.Write("}")
+	l.Write("}")
+
 	for _, e := range newEnums {
 		e.WriteDefn(l)
 		e.WriteStringFunc(l)
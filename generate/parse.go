@@ -197,6 +197,14 @@ func (s *Struct) BuildFrom(scanner *LineScanner, key int, level int) (done bool)
 			f.Comment += "// This field has a default of " + def + "."
 		}
 
+		// A nullable nested struct is prefixed with an int8 on the
+		// wire indicating whether the struct is present.
+		var isNullableStruct bool
+		if strings.HasPrefix(typ, "nullable=>") {
+			isNullableStruct = true
+			typ = typ[len("nullable"):]
+		}
+
 		switch {
 		case strings.HasPrefix(typ, "=>"): // nested struct; recurse
 			newS := Struct{FromFlexible: s.FromFlexible, FlexibleAt: s.FlexibleAt}
@@ -212,6 +220,9 @@ func (s *Struct) BuildFrom(scanner *LineScanner, key int, level int) (done bool)
 			}
 			done = newS.BuildFrom(scanner, key, level+1)
 			f.Type = newS
+			if isNullableStruct {
+				f.Type = NullableStruct{Inner: newS}
+			}
 			newStructs = append(newStructs, newS)
 
 		case strings.HasPrefix(typ, "length-field-minus => "): // special bytes referencing another field
@@ -259,10 +270,11 @@ func (s *Struct) BuildFrom(scanner *LineScanner, key int, level int) (done bool)
 				// ordered by that request when generating code.
 				//
 				// The default key is -1, so if we still have they key, we fix
-				// it and also fix the key in the newStructs slice.
+				// it and also fix the key in the newStructs slice, as well
+				// as the keys of any anonymous structs nested within it.
 				if s.WithNoEncoding && s.Key == -1 {
 					for i := range newStructs {
-						if newStructs[i].Name == s.Name {
+						if strings.HasPrefix(newStructs[i].Name, s.Name) && newStructs[i].Key == -1 {
 							newStructs[i].Key = key
 						}
 					}
@@ -2,6 +2,7 @@ package kadm
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/twmb/franz-go/pkg/kerr"
@@ -21,6 +22,7 @@ type Config struct {
 	Value     *string           // Value is the config value, if any.
 	Sensitive bool              // Sensitive is if this config is sensitive (if so, Value is nil).
 	Source    kmsg.ConfigSource // Source is where this config is defined from.
+	Type      kmsg.ConfigType   // Type is the config's data type, if known (Kafka 2.6+).
 
 	// Synonyms contains fallback key/value pairs for this same
 	// configuration key in order or preference. That is, if a config entry
@@ -133,6 +135,7 @@ func (cl *Client) describeConfigs(
 					Value:     c.Value,
 					Sensitive: c.IsSensitive,
 					Source:    c.Source,
+					Type:      c.ConfigType,
 				}
 				for _, syn := range c.ConfigSynonyms {
 					rcv.Synonyms = append(rcv.Synonyms, ConfigSynonym{
@@ -181,10 +184,20 @@ type AlterConfig struct {
 	Value *string       // Value is the value to use when altering, if any.
 }
 
+// AlterConfigsResource is a set of incremental config alterations for a
+// single resource.
+type AlterConfigsResource struct {
+	Type    kmsg.ConfigResourceType // Type is the type of resource to alter (topic, broker, or broker logger).
+	Name    string                  // Name is the topic name or broker number; an empty broker name alters cluster-wide broker configs.
+	Configs []AlterConfig           // Configs are the alterations to perform.
+}
+
 // AlteredConfigsResponse contains the response for an individual alteration.
 type AlterConfigsResponse struct {
-	Name string // Name is the name of this resource (topic name or broker number).
-	Err  error  // Err is non-nil if the config could not be altered.
+	Name       string                  // Name is the name of this resource (topic name or broker number).
+	Type       kmsg.ConfigResourceType // Type is the type of this resource.
+	Err        error                   // Err is non-nil if the config could not be altered.
+	ErrMessage string                  // ErrMessage is an optional additional message on error.
 }
 
 // AlterConfigsResponses contains responses for many alterations.
@@ -223,7 +236,7 @@ func (rs AlterConfigsResponses) On(name string, fn func(*AlterConfigsResponse) e
 // This may return *ShardErrors. You may consider checking
 // ValidateAlterTopicConfigs before using this method.
 func (cl *Client) AlterTopicConfigs(ctx context.Context, configs []AlterConfig, topics ...string) (AlterConfigsResponses, error) {
-	return cl.alterConfigs(ctx, false, configs, kmsg.ConfigResourceTypeTopic, topics)
+	return cl.alterConfigs(ctx, false, resourcesFor(kmsg.ConfigResourceTypeTopic, configs, topics))
 }
 
 // ValidateAlterTopicConfigs validates an incremental alter config for the given
@@ -232,7 +245,7 @@ func (cl *Client) AlterTopicConfigs(ctx context.Context, configs []AlterConfig,
 // This returns exactly what AlterTopicConfigs returns, but does not actually
 // alter configurations.
 func (cl *Client) ValidateAlterTopicConfigs(ctx context.Context, configs []AlterConfig, topics ...string) (AlterConfigsResponses, error) {
-	return cl.alterConfigs(ctx, true, configs, kmsg.ConfigResourceTypeTopic, topics)
+	return cl.alterConfigs(ctx, true, resourcesFor(kmsg.ConfigResourceTypeTopic, configs, topics))
 }
 
 // AlterBrokerConfigs incrementally alters broker configuration values. If
@@ -251,14 +264,7 @@ func (cl *Client) ValidateAlterTopicConfigs(ctx context.Context, configs []Alter
 // This may return *ShardErrors. You may consider checking
 // ValidateAlterBrokerConfigs before using this method.
 func (cl *Client) AlterBrokerConfigs(ctx context.Context, configs []AlterConfig, brokers ...int32) (AlterConfigsResponses, error) {
-	var names []string
-	if len(brokers) == 0 {
-		names = append(names, "")
-	}
-	for _, broker := range brokers {
-		names = append(names, strconv.Itoa(int(broker)))
-	}
-	return cl.alterConfigs(ctx, false, configs, kmsg.ConfigResourceTypeBroker, names)
+	return cl.alterConfigs(ctx, false, resourcesFor(kmsg.ConfigResourceTypeBroker, configs, brokerNames(brokers)))
 }
 
 // ValidateAlterBrokerConfigs validates an incremental alter config for the given
@@ -267,6 +273,51 @@ func (cl *Client) AlterBrokerConfigs(ctx context.Context, configs []AlterConfig,
 // This returns exactly what AlterBrokerConfigs returns, but does not actually
 // alter configurations.
 func (cl *Client) ValidateAlterBrokerConfigs(ctx context.Context, configs []AlterConfig, brokers ...int32) (AlterConfigsResponses, error) {
+	return cl.alterConfigs(ctx, true, resourcesFor(kmsg.ConfigResourceTypeBroker, configs, brokerNames(brokers)))
+}
+
+// AlterConfigs incrementally alters configuration values for any mix of
+// resources, with each resource having its own alterations.
+//
+// AppendConfig and SubtractConfig are only valid for list type configs. If any
+// resource uses them, the configs are first described; a resource that
+// appends to or subtracts from a config that the broker reports is not a list
+// fails with kerr.InvalidConfig and is not altered. Brokers before Kafka 2.6
+// do not report config types, in which case the broker itself validates.
+//
+// This method requires talking to a cluster that supports
+// IncrementalAlterConfigs. If the cluster does not support it, this returns an
+// error that the broker is too old; this never falls back to the original,
+// destructive, AlterConfigs request.
+//
+// This may return *ShardErrors. You may consider checking
+// ValidateAlterConfigs before using this method.
+func (cl *Client) AlterConfigs(ctx context.Context, resources []AlterConfigsResource) (AlterConfigsResponses, error) {
+	return cl.alterConfigs(ctx, false, resources)
+}
+
+// ValidateAlterConfigs validates an incremental alter config for the given
+// resources.
+//
+// This returns exactly what AlterConfigs returns, but does not actually alter
+// configurations.
+func (cl *Client) ValidateAlterConfigs(ctx context.Context, resources []AlterConfigsResource) (AlterConfigsResponses, error) {
+	return cl.alterConfigs(ctx, true, resources)
+}
+
+func resourcesFor(kind kmsg.ConfigResourceType, configs []AlterConfig, names []string) []AlterConfigsResource {
+	rs := make([]AlterConfigsResource, 0, len(names))
+	for _, name := range names {
+		rs = append(rs, AlterConfigsResource{
+			Type:    kind,
+			Name:    name,
+			Configs: configs,
+		})
+	}
+	return rs
+}
+
+func brokerNames(brokers []int32) []string {
 	var names []string
 	if len(brokers) == 0 {
 		names = append(names, "")
@@ -274,23 +325,32 @@ func (cl *Client) ValidateAlterBrokerConfigs(ctx context.Context, configs []Alte
 	for _, broker := range brokers {
 		names = append(names, strconv.Itoa(int(broker)))
 	}
-	return cl.alterConfigs(ctx, true, configs, kmsg.ConfigResourceTypeBroker, names)
+	return names
 }
 
 func (cl *Client) alterConfigs(
 	ctx context.Context,
 	dry bool,
-	configs []AlterConfig,
-	kind kmsg.ConfigResourceType,
-	names []string,
+	resources []AlterConfigsResource,
 ) (AlterConfigsResponses, error) {
+	var rs []AlterConfigsResponse
+	invalid := cl.validateListOps(ctx, resources)
+
 	req := kmsg.NewPtrIncrementalAlterConfigsRequest()
 	req.ValidateOnly = dry
-	for _, name := range names {
+	for _, r := range resources {
+		if err, ok := invalid[r.Type][r.Name]; ok {
+			rs = append(rs, AlterConfigsResponse{
+				Name: r.Name,
+				Type: r.Type,
+				Err:  err,
+			})
+			continue
+		}
 		rr := kmsg.NewIncrementalAlterConfigsRequestResource()
-		rr.ResourceType = kind
-		rr.ResourceName = name
-		for _, config := range configs {
+		rr.ResourceType = r.Type
+		rr.ResourceName = r.Name
+		for _, config := range r.Configs {
 			rc := kmsg.NewIncrementalAlterConfigsRequestResourceConfig()
 			rc.Name = config.Name
 			rc.Value = config.Value
@@ -308,18 +368,74 @@ func (cl *Client) alterConfigs(
 		}
 		req.Resources = append(req.Resources, rr)
 	}
+	if len(req.Resources) == 0 {
+		return rs, nil
+	}
 
 	shards := cl.cl.RequestSharded(ctx, req)
 
-	var rs []AlterConfigsResponse
 	return rs, shardErrEach(req, shards, func(kr kmsg.Response) error {
 		resp := kr.(*kmsg.IncrementalAlterConfigsResponse)
 		for _, r := range resp.Resources {
-			rs = append(rs, AlterConfigsResponse{
+			a := AlterConfigsResponse{
 				Name: r.ResourceName,
+				Type: r.ResourceType,
 				Err:  kerr.ErrorForCode(r.ErrorCode),
-			})
+			}
+			if r.ErrorMessage != nil {
+				a.ErrMessage = *r.ErrorMessage
+			}
+			rs = append(rs, a)
 		}
 		return nil
 	})
 }
+
+// validateListOps returns, per resource type and name, an error for resources
+// that append to or subtract from a config that is not a list. This issues a
+// describe only if any resource appends or subtracts, and is best effort: if
+// the describe fails or the broker does not return config types, the broker
+// validates the alteration itself.
+func (cl *Client) validateListOps(ctx context.Context, resources []AlterConfigsResource) map[kmsg.ConfigResourceType]map[string]error {
+	req := kmsg.NewPtrDescribeConfigsRequest()
+	for _, r := range resources {
+		rr := kmsg.NewDescribeConfigsRequestResource()
+		rr.ResourceType = r.Type
+		rr.ResourceName = r.Name
+		for _, c := range r.Configs {
+			if c.Op == AppendConfig || c.Op == SubtractConfig {
+				rr.ConfigNames = append(rr.ConfigNames, c.Name)
+			}
+		}
+		if len(rr.ConfigNames) > 0 {
+			req.Resources = append(req.Resources, rr)
+		}
+	}
+	if len(req.Resources) == 0 {
+		return nil
+	}
+
+	invalid := make(map[kmsg.ConfigResourceType]map[string]error)
+	for _, shard := range cl.cl.RequestSharded(ctx, req) {
+		if shard.Err != nil {
+			continue
+		}
+		resp := shard.Resp.(*kmsg.DescribeConfigsResponse)
+		for _, r := range resp.Resources {
+			for _, c := range r.Configs {
+				if c.ConfigType == kmsg.ConfigTypeUnknown || c.ConfigType == kmsg.ConfigTypeList {
+					continue
+				}
+				ti := invalid[r.ResourceType]
+				if ti == nil {
+					ti = make(map[string]error)
+					invalid[r.ResourceType] = ti
+				}
+				if _, exists := ti[r.ResourceName]; !exists {
+					ti[r.ResourceName] = fmt.Errorf("%w: config %q is type %s, not LIST, and cannot be appended to or subtracted from", kerr.InvalidConfig, c.Name, c.ConfigType)
+				}
+			}
+		}
+	}
+	return invalid
+}
@@ -0,0 +1,123 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestAlterConfigsListOps(t *testing.T) {
+	var (
+		described []string
+		altered   []string
+	)
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.DescribeConfigsRequest:
+			resp := req.ResponseKind().(*kmsg.DescribeConfigsResponse)
+			for _, rr := range req.Resources {
+				described = append(described, rr.ResourceName)
+				sr := kmsg.NewDescribeConfigsResponseResource()
+				sr.ResourceType, sr.ResourceName = rr.ResourceType, rr.ResourceName
+				for _, name := range rr.ConfigNames {
+					c := kmsg.NewDescribeConfigsResponseResourceConfig()
+					c.Name = name
+					switch name {
+					case "cleanup.policy":
+						c.ConfigType = kmsg.ConfigTypeList
+					case "retention.ms":
+						c.ConfigType = kmsg.ConfigTypeLong
+					}
+					sr.Configs = append(sr.Configs, c)
+				}
+				resp.Resources = append(resp.Resources, sr)
+			}
+			return resp
+
+		case *kmsg.IncrementalAlterConfigsRequest:
+			resp := req.ResponseKind().(*kmsg.IncrementalAlterConfigsResponse)
+			for _, rr := range req.Resources {
+				altered = append(altered, rr.ResourceName)
+				sr := kmsg.NewIncrementalAlterConfigsResponseResource()
+				sr.ResourceType, sr.ResourceName = rr.ResourceType, rr.ResourceName
+				if rr.ResourceName == "denied" {
+					sr.ErrorCode = kerr.PolicyViolation.Code
+					sr.ErrorMessage = kmsg.StringPtr("no")
+				}
+				resp.Resources = append(resp.Resources, sr)
+			}
+			return resp
+		}
+		return nil
+	})
+
+	v := func(s string) *string { return &s }
+	topic := func(name string, configs ...AlterConfig) AlterConfigsResource {
+		return AlterConfigsResource{Type: kmsg.ConfigResourceTypeTopic, Name: name, Configs: configs}
+	}
+	for _, test := range []struct {
+		name      string
+		resources []AlterConfigsResource
+		described []string
+		altered   []string
+		errs      map[string]error
+	}{
+		{
+			name: "set only, no describe",
+			resources: []AlterConfigsResource{
+				topic("a", AlterConfig{Name: "retention.ms", Value: v("1")}),
+				topic("denied", AlterConfig{Name: "retention.ms", Value: v("1")}),
+			},
+			altered: []string{"a", "denied"},
+			errs:    map[string]error{"a": nil, "denied": kerr.PolicyViolation},
+		},
+		{
+			name: "list and non-list ops",
+			resources: []AlterConfigsResource{
+				topic("a", AlterConfig{Op: AppendConfig, Name: "cleanup.policy", Value: v("compact")}),
+				topic("b", AlterConfig{Name: "cleanup.policy", Value: v("delete")}, AlterConfig{Op: SubtractConfig, Name: "retention.ms", Value: v("1")}),
+				// The broker validates configs without a known type.
+				topic("c", AlterConfig{Op: AppendConfig, Name: "unknown.config", Value: v("x")}),
+			},
+			described: []string{"a", "b", "c"},
+			altered:   []string{"a", "c"},
+			errs:      map[string]error{"a": nil, "b": kerr.InvalidConfig, "c": nil},
+		},
+		{
+			name: "all rejected",
+			resources: []AlterConfigsResource{
+				topic("b", AlterConfig{Op: AppendConfig, Name: "retention.ms", Value: v("1")}),
+			},
+			described: []string{"b"},
+			errs:      map[string]error{"b": kerr.InvalidConfig},
+		},
+	} {
+		described, altered = nil, nil
+		rs, err := adm.AlterConfigs(context.Background(), test.resources)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		sort.Strings(described)
+		sort.Strings(altered)
+		if !reflect.DeepEqual(described, test.described) || !reflect.DeepEqual(altered, test.altered) {
+			t.Errorf("%s: got described %v, altered %v != exp %v, %v", test.name, described, altered, test.described, test.altered)
+		}
+		if len(rs) != len(test.errs) {
+			t.Errorf("%s: got %d responses != exp %d", test.name, len(rs), len(test.errs))
+		}
+		for _, r := range rs {
+			exp, ok := test.errs[r.Name]
+			if !ok || !errors.Is(r.Err, exp) || (exp == nil) != (r.Err == nil) {
+				t.Errorf("%s: %s: got err %v != exp %v", test.name, r.Name, r.Err, exp)
+			}
+			if r.Name == "denied" && r.ErrMessage != "no" {
+				t.Errorf("%s: got error message %q != exp %q", test.name, r.ErrMessage, "no")
+			}
+		}
+	}
+}
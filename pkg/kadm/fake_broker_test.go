@@ -0,0 +1,147 @@
+package kadm
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// newFakeBrokerClient returns an admin client whose every dial connects to an
+// in-memory fake broker. The fake broker replies to ApiVersions itself,
+// advertising kmsg's max version for every key unless overridden in
+// maxVersions, and passes every other request to handle, which returns the
+// response to write. handle is never called concurrently.
+//
+// If handle returns nil, metadata requests are answered with three brokers,
+// nodes 0 through 2, with node 0 the controller, and other requests are
+// answered with an empty response. Any opts are passed to the kgo client.
+func newFakeBrokerClient(t *testing.T, maxVersions map[int16]int16, handle func(kmsg.Request) kmsg.Response, opts ...kgo.Opt) *Client {
+	t.Helper()
+	var mu sync.Mutex
+	dial := func(context.Context, string, string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+
+			resps := make(chan []byte, 64)
+			defer close(resps)
+			go func() {
+				var err error
+				for buf := range resps {
+					if err == nil {
+						_, err = server.Write(buf)
+					}
+				}
+			}()
+
+			for {
+				req, corrID, err := readFakeRequest(server)
+				if err != nil {
+					return
+				}
+				var resp kmsg.Response
+				if _, ok := req.(*kmsg.ApiVersionsRequest); ok {
+					resp = fakeApiVersions(maxVersions)
+				} else {
+					mu.Lock()
+					resp = handle(req)
+					mu.Unlock()
+					if meta, ok := req.(*kmsg.MetadataRequest); ok && resp == nil {
+						resp = fakeMetadata(meta)
+					} else if resp == nil {
+						resp = req.ResponseKind()
+					}
+				}
+				resp.SetVersion(req.GetVersion())
+				resps <- appendFakeResponse(corrID, req, resp)
+			}
+		}()
+		return client, nil
+	}
+	cl, err := kgo.NewClient(append(opts, kgo.Dialer(dial))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cl.Close)
+	return NewClient(cl)
+}
+
+func readFakeRequest(r io.Reader) (kmsg.Request, int32, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, 0, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, 0, err
+	}
+	b := kbin.Reader{Src: buf}
+	key, version, corrID := b.Int16(), b.Int16(), b.Int32()
+	b.NullableString() // client ID
+	req := kmsg.RequestForKey(key)
+	req.SetVersion(version)
+	if req.IsFlexible() {
+		for n := b.Uvarint(); n > 0; n-- { // header tags
+			b.Uvarint()
+			b.Span(int(b.Uvarint()))
+		}
+	}
+	if err := b.Complete(); err != nil {
+		return nil, 0, err
+	}
+	return req, corrID, req.ReadFrom(b.Src)
+}
+
+func appendFakeResponse(corrID int32, req kmsg.Request, resp kmsg.Response) []byte {
+	buf := make([]byte, 8, 64)
+	binary.BigEndian.PutUint32(buf[4:], uint32(corrID))
+	if req.IsFlexible() && req.Key() != 18 { // ApiVersions responses never have header tags
+		buf = append(buf, 0)
+	}
+	buf = resp.AppendTo(buf)
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	return buf
+}
+
+func fakeApiVersions(maxVersions map[int16]int16) kmsg.Response {
+	resp := kmsg.NewPtrApiVersionsResponse()
+	for key := int16(0); key <= kmsg.MaxKey; key++ {
+		req := kmsg.RequestForKey(key)
+		if req == nil {
+			continue
+		}
+		k := kmsg.NewApiVersionsResponseApiKey()
+		k.ApiKey = key
+		k.MaxVersion = req.MaxVersion()
+		if max, ok := maxVersions[key]; ok {
+			k.MaxVersion = max
+		}
+		resp.ApiKeys = append(resp.ApiKeys, k)
+	}
+	return resp
+}
+
+func fakeMetadata(req *kmsg.MetadataRequest) kmsg.Response {
+	resp := kmsg.NewPtrMetadataResponse()
+	for id := int32(0); id < 3; id++ {
+		b := kmsg.NewMetadataResponseBroker()
+		b.NodeID = id
+		b.Host = "127.0.0.1"
+		b.Port = 9092 + id
+		resp.Brokers = append(resp.Brokers, b)
+	}
+	resp.ControllerID = 0
+	for _, rt := range req.Topics {
+		t := kmsg.NewMetadataResponseTopic()
+		t.Topic = rt.Topic
+		resp.Topics = append(resp.Topics, t)
+	}
+	return resp
+}
@@ -0,0 +1,158 @@
+package kadm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// FeatureUpdate is an update to a cluster-wide finalized feature, such as
+// "metadata.version".
+type FeatureUpdate struct {
+	Feature string // Feature is the name of the feature to update.
+
+	// MaxVersionLevel is the new finalized max version level of the
+	// feature. A level below 1 deletes the finalized feature, which is
+	// only allowed as a downgrade.
+	MaxVersionLevel int16
+
+	// Unsafe, for downgrades, allows a downgrade that may lose metadata.
+	// Without this, Kafka rejects downgrades that cannot be done safely.
+	// Brokers before Kafka 3.2 do not distinguish safe and unsafe
+	// downgrades. This is ignored for upgrades.
+	Unsafe bool
+}
+
+// UpdatedFeature is the result of updating a single feature.
+type UpdatedFeature struct {
+	Feature    string // Feature is the feature that was updated.
+	Err        error  // Err is non-nil if the update failed, such as with kerr.FeatureUpdateFailed.
+	ErrMessage string // ErrMessage is an optional additional message describing Err.
+}
+
+// UpdatedFeatures contains results for updating features, keyed by feature.
+type UpdatedFeatures map[string]UpdatedFeature
+
+// Sorted returns the updated features sorted by feature name.
+func (fs UpdatedFeatures) Sorted() []UpdatedFeature {
+	s := make([]UpdatedFeature, 0, len(fs))
+	for _, f := range fs {
+		s = append(s, f)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Feature < s[j].Feature })
+	return s
+}
+
+// Error iterates over all updated features and returns the first error
+// encountered, if any.
+func (fs UpdatedFeatures) Error() error {
+	for _, f := range fs.Sorted() {
+		if f.Err != nil {
+			return f.Err
+		}
+	}
+	return nil
+}
+
+// UpdateFeatures upgrades and downgrades cluster-wide finalized features. The
+// request is issued to the controller. This can be used to finalize a new
+// metadata.version after all brokers in a cluster are upgraded.
+//
+// If the whole request fails, for example with FEATURE_UPDATE_FAILED, every
+// requested feature is returned with the failure.
+//
+// This method requires talking to Kafka v2.7+.
+func (cl *Client) UpdateFeatures(ctx context.Context, upgrades, downgrades []FeatureUpdate) (UpdatedFeatures, error) {
+	return cl.updateFeatures(ctx, false, upgrades, downgrades)
+}
+
+// ValidateUpdateFeatures validates an update of features.
+//
+// This returns exactly what UpdateFeatures returns, but does not actually
+// update features. Version 0 of the request has no way to only validate, so
+// this requires UpdateFeatures v1: Kafka v3.2+, and a client whose max
+// versions allow v1, such as kgo.MaxVersions(kversion.Tip()) (the default,
+// kversion.Stable, pins v0). Before validating, this checks the version the
+// client negotiated with the controller (from the API versions cached when
+// connecting), and if it is below v1, fails with kerr.UnsupportedVersion
+// without updating anything.
+func (cl *Client) ValidateUpdateFeatures(ctx context.Context, upgrades, downgrades []FeatureUpdate) (UpdatedFeatures, error) {
+	return cl.updateFeatures(ctx, true, upgrades, downgrades)
+}
+
+func (cl *Client) updateFeatures(ctx context.Context, dry bool, upgrades, downgrades []FeatureUpdate) (UpdatedFeatures, error) {
+	req := kmsg.NewPtrUpdateFeaturesRequest()
+	if dry {
+		// ValidateOnly is v1+; a v0 request would drop it and apply
+		// the updates. The request goes to the controller, so we
+		// check the version negotiated with the controller.
+		v, err := cl.cl.ControllerBroker().NegotiatedVersion(ctx, req.Key())
+		if err != nil {
+			return nil, err
+		}
+		if v < 1 {
+			return nil, fmt.Errorf("validating feature updates requires UpdateFeatures v1+, but the client negotiated v%d: %w", v, kerr.UnsupportedVersion)
+		}
+	}
+	req.TimeoutMillis = cl.timeoutMillis
+	req.ValidateOnly = dry
+	for _, u := range upgrades {
+		ru := kmsg.NewUpdateFeaturesRequestFeatureUpdate()
+		ru.Feature = u.Feature
+		ru.MaxVersionLevel = u.MaxVersionLevel
+		req.FeatureUpdates = append(req.FeatureUpdates, ru)
+	}
+	for _, d := range downgrades {
+		rd := kmsg.NewUpdateFeaturesRequestFeatureUpdate()
+		rd.Feature = d.Feature
+		rd.MaxVersionLevel = d.MaxVersionLevel
+		rd.AllowDowngrade = true // v0
+		rd.UpgradeType = 2       // safe downgrade
+		if d.Unsafe {
+			rd.UpgradeType = 3
+		}
+		req.FeatureUpdates = append(req.FeatureUpdates, rd)
+	}
+
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	rs := make(UpdatedFeatures)
+	for _, r := range resp.Results {
+		u := UpdatedFeature{
+			Feature: r.Feature,
+			Err:     kerr.ErrorForCode(r.ErrorCode),
+		}
+		if u.Err != nil && r.ErrorMessage != nil {
+			u.ErrMessage = *r.ErrorMessage
+		}
+		rs[r.Feature] = u
+	}
+
+	// A top level error applies to every feature that does not have its
+	// own result.
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		var msg string
+		if resp.ErrorMessage != nil {
+			msg = *resp.ErrorMessage
+		}
+		for _, u := range req.FeatureUpdates {
+			if _, exists := rs[u.Feature]; !exists {
+				rs[u.Feature] = UpdatedFeature{
+					Feature:    u.Feature,
+					Err:        err,
+					ErrMessage: msg,
+				}
+			}
+		}
+	}
+	return rs, nil
+}
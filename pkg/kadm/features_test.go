@@ -0,0 +1,120 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/kversion"
+)
+
+func TestValidateUpdateFeaturesRequiresV1(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		brokerMax int16
+		clientMax *kversion.Versions
+		expErr    error
+	}{
+		{"old broker", 0, kversion.Tip(), kerr.UnsupportedVersion},
+		{"default client versions", 1, kversion.Stable(), kerr.UnsupportedVersion},
+		{"v1", 1, kversion.Tip(), nil},
+	} {
+		var updates []*kmsg.UpdateFeaturesRequest
+		adm := newFakeBrokerClient(t, map[int16]int16{kmsg.UpdateFeatures.Int16(): test.brokerMax}, func(kreq kmsg.Request) kmsg.Response {
+			if req, ok := kreq.(*kmsg.UpdateFeaturesRequest); ok {
+				updates = append(updates, req)
+			}
+			return nil
+		}, kgo.MaxVersions(test.clientMax))
+
+		_, err := adm.ValidateUpdateFeatures(context.Background(), []FeatureUpdate{{Feature: "metadata.version", MaxVersionLevel: 7}}, nil)
+		if test.expErr != nil && !errors.Is(err, test.expErr) || test.expErr == nil && err != nil {
+			t.Errorf("%s: got err %v, exp %v", test.name, err, test.expErr)
+		}
+		// A v0 request would drop ValidateOnly and apply the update, so
+		// we must not issue any update at all. The version is checked
+		// from the cached API versions, not by probing.
+		switch {
+		case test.expErr != nil && len(updates) != 0:
+			t.Errorf("%s: got %d update features requests, exp 0", test.name, len(updates))
+		case test.expErr == nil && (len(updates) != 1 || !updates[0].ValidateOnly || updates[0].Version != 1):
+			t.Errorf("%s: got %d update features requests, exp one v1 validate only request", test.name, len(updates))
+		}
+	}
+}
+
+func TestUpdateFeatures(t *testing.T) {
+	var topErr int16
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.UpdateFeaturesRequest)
+		if !ok {
+			return nil
+		}
+		if req.ValidateOnly {
+			t.Error("unexpected validate only request")
+		}
+		resp := req.ResponseKind().(*kmsg.UpdateFeaturesResponse)
+		resp.ErrorCode = topErr
+		if topErr != 0 {
+			resp.ErrorMessage = kmsg.StringPtr("nope")
+		}
+		for _, u := range req.FeatureUpdates {
+			if topErr != 0 && u.Feature != "own" {
+				continue
+			}
+			r := kmsg.NewUpdateFeaturesResponseResult()
+			r.Feature = u.Feature
+			if u.Feature == "down" {
+				if u.UpgradeType != 2 {
+					t.Errorf("got upgrade type %d for a safe downgrade", u.UpgradeType)
+				}
+				r.ErrorCode = kerr.InvalidUpdateVersion.Code
+				r.ErrorMessage = kmsg.StringPtr("downgrade")
+			}
+			resp.Results = append(resp.Results, r)
+		}
+		return resp
+	}, kgo.MaxVersions(kversion.Tip()))
+
+	upgrades := []FeatureUpdate{{Feature: "up", MaxVersionLevel: 2}}
+	downgrades := []FeatureUpdate{{Feature: "down", MaxVersionLevel: 1}}
+	rs, err := adm.UpdateFeatures(context.Background(), upgrades, downgrades)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := UpdatedFeatures{
+		"up":   {Feature: "up"},
+		"down": {Feature: "down", Err: kerr.InvalidUpdateVersion, ErrMessage: "downgrade"},
+	}
+	if !reflect.DeepEqual(rs, exp) {
+		t.Errorf("got %v != exp %v", rs, exp)
+	}
+	if !errors.Is(rs.Error(), kerr.InvalidUpdateVersion) {
+		t.Errorf("got Error %v, exp %v", rs.Error(), kerr.InvalidUpdateVersion)
+	}
+
+	// A top level error applies to every feature without its own result.
+	topErr = kerr.FeatureUpdateFailed.Code
+	rs, err = adm.UpdateFeatures(context.Background(), append(upgrades, FeatureUpdate{Feature: "own", MaxVersionLevel: 2}), downgrades)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = UpdatedFeatures{
+		"up":   {Feature: "up", Err: kerr.FeatureUpdateFailed, ErrMessage: "nope"},
+		"down": {Feature: "down", Err: kerr.FeatureUpdateFailed, ErrMessage: "nope"},
+		"own":  {Feature: "own"},
+	}
+	if !reflect.DeepEqual(rs, exp) {
+		t.Errorf("got %v != exp %v", rs, exp)
+	}
+
+	// Auth errors fail the whole request.
+	topErr = kerr.ClusterAuthorizationFailed.Code
+	if _, err := adm.UpdateFeatures(context.Background(), upgrades, nil); !errors.Is(err, kerr.ClusterAuthorizationFailed) {
+		t.Errorf("got err %v, exp %v", err, kerr.ClusterAuthorizationFailed)
+	}
+}
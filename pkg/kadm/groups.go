@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/twmb/franz-go/pkg/kerr"
@@ -180,6 +181,7 @@ type ListedGroup struct {
 	Group        string // Group is the name of this group.
 	ProtocolType string // ProtocolType is the type of protocol the group is using, "consumer" for normal consumers, "connect" for Kafka connect.
 	State        string // State is the state this group is in (Empty, Dead, Stable, etc.; only if talking to Kafka 2.6+).
+	Type         string // Type is the type of this group, "classic" or "consumer" (always "classic" if talking to Kafka before 3.8).
 }
 
 // ListedGroups contains information from a list groups response.
@@ -212,8 +214,25 @@ func (ls ListedGroups) Groups() []string {
 //
 // This may return *ShardErrors.
 func (cl *Client) ListGroups(ctx context.Context, filterStates ...string) (ListedGroups, error) {
+	return cl.ListGroupsByType(ctx, nil, filterStates...)
+}
+
+// ListGroupsByType returns all groups in the cluster of the given types
+// ("classic" or "consumer"), optionally also filtered to the given states. If
+// no types are given, groups of all types are returned.
+//
+// Filters are sent to brokers that support them, and are also applied
+// client-side to every response. Brokers before Kafka 3.8 only have classic
+// groups, so consumer groups are never returned from them. Brokers before
+// Kafka 2.6 do not return group states, so groups from them cannot be filtered
+// by state and are returned with an empty State regardless of the state
+// filter.
+//
+// This may return *ShardErrors.
+func (cl *Client) ListGroupsByType(ctx context.Context, types []string, filterStates ...string) (ListedGroups, error) {
 	req := kmsg.NewPtrListGroupsRequest()
 	req.StatesFilter = append(req.StatesFilter, filterStates...)
+	req.TypesFilter = append(req.TypesFilter, types...)
 	shards := cl.cl.RequestSharded(ctx, req)
 	list := make(ListedGroups)
 	return list, shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
@@ -225,17 +244,39 @@ func (cl *Client) ListGroups(ctx context.Context, filterStates ...string) (Liste
 			return err
 		}
 		for _, g := range resp.Groups {
+			typ := g.GroupType
+			if resp.Version < 5 {
+				typ = "classic"
+			}
+			if !listFilterMatches(types, typ) || g.GroupState != "" && !listFilterMatches(filterStates, g.GroupState) {
+				continue
+			}
 			list[g.Group] = ListedGroup{
 				Coordinator:  b.NodeID,
 				Group:        g.Group,
 				ProtocolType: g.ProtocolType,
 				State:        g.GroupState,
+				Type:         typ,
 			}
 		}
 		return nil
 	})
 }
 
+// listFilterMatches returns whether v matches the filter, which matches
+// everything if empty. Like Kafka, this matching is case insensitive.
+func listFilterMatches(filter []string, v string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if strings.EqualFold(f, v) {
+			return true
+		}
+	}
+	return false
+}
+
 // DescribeGroups describes either all groups specified, or all groups in the
 // cluster if none are specified.
 //
@@ -348,6 +389,261 @@ func (cl *Client) DescribeGroups(ctx context.Context, groups ...string) (Describ
 	}
 }
 
+// DescribedConsumerGroupMember is a member of a consumer group as returned by
+// DescribeConsumerGroups.
+type DescribedConsumerGroupMember struct {
+	MemberID    string  // MemberID is the member ID of this group member.
+	InstanceID  *string // InstanceID is a potential user assigned instance ID of this group member (KIP-345).
+	RackID      *string // RackID is the rack of this member, if known; this is always nil for classic groups.
+	MemberEpoch int32   // MemberEpoch is the epoch of this member, or -1 for classic groups.
+	ClientID    string  // ClientID is the Kafka client given ClientID of this group member.
+	ClientHost  string  // ClientHost is the host this member is running on.
+
+	SubscribedTopics     []string // SubscribedTopics are the topics this member is subscribed to.
+	SubscribedTopicRegex *string  // SubscribedTopicRegex is the regular expression this member subscribes with, if any; this is always nil for classic groups.
+
+	Assigned       TopicsSet // Assigned is what is currently assigned to this member.
+	TargetAssigned TopicsSet // TargetAssigned is the assignment this member is converging to; for classic groups, this is the same as Assigned.
+}
+
+// DescribedConsumerGroup contains data for a single consumer group as returned
+// by DescribeConsumerGroups.
+type DescribedConsumerGroup struct {
+	Group string // Group is the name of the described group.
+
+	// Classic is true if the group uses the classic consumer group
+	// protocol and was described with a DescribeGroups request, and false
+	// if the group uses the KIP-848 protocol.
+	Classic bool
+
+	Coordinator     BrokerDetail                   // Coordinator is the coordinator broker for this group.
+	State           string                         // State is the state this group is in (Empty, Stable, Reconciling, etc.).
+	Epoch           int32                          // Epoch is the group epoch, or -1 for classic groups.
+	AssignmentEpoch int32                          // AssignmentEpoch is the epoch of the group's target assignment, or -1 for classic groups.
+	Assignor        string                         // Assignor is the server side assignor the group uses or, for classic groups, the client side assignor (protocol).
+	Members         []DescribedConsumerGroupMember // Members contains the members of this group sorted first by InstanceID, or if nil, by MemberID.
+
+	Err error // Err is non-nil if the group could not be described.
+}
+
+// DescribedConsumerGroups contains data for multiple groups from
+// DescribeConsumerGroups.
+type DescribedConsumerGroups map[string]DescribedConsumerGroup
+
+// AssignedPartitions returns the set of unique topics and partitions that are
+// currently assigned across all members in all groups.
+func (ds DescribedConsumerGroups) AssignedPartitions() TopicsSet {
+	s := make(TopicsSet)
+	for _, g := range ds {
+		for _, m := range g.Members {
+			m.Assigned.Each(func(t string, p int32) { s.Add(t, p) })
+		}
+	}
+	return s
+}
+
+// Sorted returns all groups sorted by group name.
+func (ds DescribedConsumerGroups) Sorted() []DescribedConsumerGroup {
+	s := make([]DescribedConsumerGroup, 0, len(ds))
+	for _, d := range ds {
+		s = append(s, d)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Group < s[j].Group })
+	return s
+}
+
+// On calls fn for the group if it exists, returning the group and the error
+// returned from fn. If fn is nil, this simply returns the group.
+//
+// The fn is given a shallow copy of the group. This function returns the copy
+// as well; any modifications within fn are modifications on the returned copy.
+//
+// If the group does not exist, this returns kerr.GroupIDNotFound.
+func (ds DescribedConsumerGroups) On(group string, fn func(*DescribedConsumerGroup) error) (DescribedConsumerGroup, error) {
+	if len(ds) > 0 {
+		d, ok := ds[group]
+		if ok {
+			if fn == nil {
+				return d, nil
+			}
+			return d, fn(&d)
+		}
+	}
+	return DescribedConsumerGroup{}, kerr.GroupIDNotFound
+}
+
+// Names returns a sorted list of all group names.
+func (ds DescribedConsumerGroups) Names() []string {
+	all := make([]string, 0, len(ds))
+	for g := range ds {
+		all = append(all, g)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// DescribeConsumerGroups describes either all groups specified, or all groups
+// in the cluster if none are specified, using the KIP-848 ConsumerGroupDescribe
+// request where possible.
+//
+// Groups are first described with ConsumerGroupDescribe. Groups that cannot be
+// described that way are described with the classic DescribeGroups request
+// and converted, with Classic set to true: this is the case for groups using
+// the classic protocol, for which Kafka returns GROUP_ID_NOT_FOUND, and for
+// all groups if the cluster does not support the new protocol. Note that the
+// client must be configured with max versions that include the
+// ConsumerGroupDescribe key to issue the request at all (for example,
+// kversion.Tip() with SetMaxKeyVersion(kmsg.ConsumerGroupDescribe.Int16(), 0));
+// if it cannot be issued, every group falls back to DescribeGroups.
+//
+// This may return *ShardErrors; see DescribeGroups for how errors from
+// listing groups are returned.
+func (cl *Client) DescribeConsumerGroups(ctx context.Context, groups ...string) (DescribedConsumerGroups, error) {
+	var seList *ShardErrors
+	if len(groups) == 0 {
+		listed, err := cl.ListGroups(ctx)
+		switch {
+		case err == nil:
+		case errors.As(err, &seList):
+		default:
+			return nil, err
+		}
+		groups = listed.Groups()
+		if len(groups) == 0 {
+			return nil, err
+		}
+	}
+
+	req := kmsg.NewPtrConsumerGroupDescribeRequest()
+	req.Groups = groups
+
+	described := make(DescribedConsumerGroups)
+	var classic []string
+	for _, shard := range cl.cl.RequestSharded(ctx, req) {
+		// If the shard failed with a response, the groups that were
+		// described are kept and only the groups in error fall back.
+		if shard.Resp == nil {
+			classic = append(classic, shard.Req.(*kmsg.ConsumerGroupDescribeRequest).Groups...)
+			continue
+		}
+		resp := shard.Resp.(*kmsg.ConsumerGroupDescribeResponse)
+		for _, rg := range resp.Groups {
+			if err := maybeAuthErr(rg.ErrorCode); err != nil {
+				return nil, err
+			}
+			err := kerr.ErrorForCode(rg.ErrorCode)
+			if err == kerr.GroupIDNotFound || err != nil && shard.Err != nil {
+				classic = append(classic, rg.Group)
+				continue
+			}
+			g := DescribedConsumerGroup{
+				Group:           rg.Group,
+				Coordinator:     shard.Meta,
+				State:           rg.State,
+				Epoch:           rg.Epoch,
+				AssignmentEpoch: rg.AssignmentEpoch,
+				Assignor:        rg.AssignorName,
+				Err:             err,
+			}
+			for _, rm := range rg.Members {
+				gm := DescribedConsumerGroupMember{
+					MemberID:             rm.MemberID,
+					InstanceID:           rm.InstanceID,
+					RackID:               rm.RackID,
+					MemberEpoch:          rm.MemberEpoch,
+					ClientID:             rm.ClientID,
+					ClientHost:           rm.ClientHost,
+					SubscribedTopics:     rm.SubscribedTopicNames,
+					SubscribedTopicRegex: rm.SubscribedTopicRegex,
+					Assigned:             make(TopicsSet),
+					TargetAssigned:       make(TopicsSet),
+				}
+				for _, t := range rm.Assignment.TopicPartitions {
+					gm.Assigned.Add(t.Topic, t.Partitions...)
+				}
+				for _, t := range rm.TargetAssignment.TopicPartitions {
+					gm.TargetAssigned.Add(t.Topic, t.Partitions...)
+				}
+				g.Members = append(g.Members, gm)
+			}
+			sortConsumerGroupMembers(g.Members)
+			described[g.Group] = g
+		}
+	}
+
+	var err error
+	if len(classic) > 0 {
+		var dgs DescribedGroups
+		dgs, err = cl.DescribeGroups(ctx, classic...)
+		for _, dg := range dgs {
+			described[dg.Group] = classicConsumerGroup(dg)
+		}
+		if err == nil {
+			return described, seList.into()
+		}
+		var seDesc *ShardErrors
+		if !errors.As(err, &seDesc) {
+			return nil, err
+		}
+		if seList != nil {
+			seDesc.Errs = append(seList.Errs, seDesc.Errs...)
+		}
+		return described, seDesc.into()
+	}
+	return described, seList.into()
+}
+
+// classicConsumerGroup converts a group described with DescribeGroups.
+func classicConsumerGroup(dg DescribedGroup) DescribedConsumerGroup {
+	g := DescribedConsumerGroup{
+		Group:           dg.Group,
+		Classic:         true,
+		Coordinator:     dg.Coordinator,
+		State:           dg.State,
+		Epoch:           -1,
+		AssignmentEpoch: -1,
+		Assignor:        dg.Protocol,
+		Err:             dg.Err,
+	}
+	for _, dm := range dg.Members {
+		gm := DescribedConsumerGroupMember{
+			MemberID:    dm.MemberID,
+			InstanceID:  dm.InstanceID,
+			MemberEpoch: -1,
+			ClientID:    dm.ClientID,
+			ClientHost:  dm.ClientHost,
+			Assigned:    make(TopicsSet),
+		}
+		if m, ok := dm.Join.AsConsumer(); ok {
+			gm.SubscribedTopics = m.Topics
+		}
+		if a, ok := dm.Assigned.AsConsumer(); ok {
+			for _, t := range a.Topics {
+				gm.Assigned.Add(t.Topic, t.Partitions...)
+			}
+		}
+		gm.TargetAssigned = gm.Assigned
+		g.Members = append(g.Members, gm)
+	}
+	sortConsumerGroupMembers(g.Members)
+	return g
+}
+
+func sortConsumerGroupMembers(ms []DescribedConsumerGroupMember) {
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].InstanceID != nil {
+			if ms[j].InstanceID == nil {
+				return true
+			}
+			return *ms[i].InstanceID < *ms[j].InstanceID
+		}
+		if ms[j].InstanceID != nil {
+			return false
+		}
+		return ms[i].MemberID < ms[j].MemberID
+	})
+}
+
 // DeleteGroupResponse contains the response for an individual deleted group.
 type DeleteGroupResponse struct {
 	Group string // Group is the group this response is for.
@@ -387,12 +683,30 @@ func (rs DeleteGroupResponses) On(group string, fn func(*DeleteGroupResponse) er
 	return DeleteGroupResponse{}, kerr.GroupIDNotFound
 }
 
+// NonEmpty returns all groups, in sorted order, that failed to be deleted with
+// NON_EMPTY_GROUP. Kafka only deletes groups that have no active members;
+// these groups must be emptied (all members must leave) before they can be
+// deleted.
+func (rs DeleteGroupResponses) NonEmpty() []string {
+	var groups []string
+	for _, r := range rs.Sorted() {
+		if r.Err == kerr.NonEmptyGroup {
+			groups = append(groups, r.Group)
+		}
+	}
+	return groups
+}
+
 // DeleteGroups deletes all groups specified.
 //
 // The purpose of this request is to allow operators a way to delete groups
 // after Kafka 1.1, which removed RetentionTimeMillis from offset commits. See
 // KIP-229 for more details.
 //
+// Groups are split into one request per group coordinator, and the responses
+// are merged. Groups that still have members fail with kerr.NonEmptyGroup, and
+// can be found with NonEmpty.
+//
 // This may return *ShardErrors. This does not return on authorization
 // failures, instead, authorization failures are included in the responses.
 func (cl *Client) DeleteGroups(ctx context.Context, groups ...string) (DeleteGroupResponses, error) {
@@ -831,6 +1145,20 @@ func (ds DeleteOffsetsResponses) EachError(fn func(string, int32, error)) {
 	}
 }
 
+// Subscribed returns all partitions that failed with GROUP_SUBSCRIBED_TO_TOPIC.
+// Kafka does not allow deleting offsets for topics that a group is actively
+// consuming; these offsets can only be deleted once the group stops
+// subscribing to the topics (or the group is empty).
+func (ds DeleteOffsetsResponses) Subscribed() TopicsSet {
+	var s TopicsSet
+	ds.EachError(func(t string, p int32, err error) {
+		if err == kerr.GroupSubscribedToTopic {
+			s.Add(t, p)
+		}
+	})
+	return s
+}
+
 // DeleteOffsets deletes offsets for the given group.
 //
 // Originally, offset commits were persisted in Kafka for some retention time.
@@ -843,6 +1171,12 @@ func (ds DeleteOffsetsResponses) EachError(fn func(string, int32, error)) {
 // request to allow admins to manually delete offsets for no longer consumed
 // topics.
 //
+// The request is issued to the group's coordinator. Offsets for topics that
+// the group is actively subscribed to cannot be deleted; these partitions fail
+// with kerr.GroupSubscribedToTopic, and can be found with Subscribed. Deleting
+// offsets does not touch any other partition, so this can be used to reset a
+// group's position on specific partitions.
+//
 // This method requires talking to Kafka v2.4+. This returns an *AuthErr if the
 // user is not authorized to delete offsets in the group at all. This does not
 // return on per-topic authorization failures, instead, per-topic authorization
@@ -971,7 +1305,8 @@ func (l GroupLag) IsEmpty() bool {
 // If assigned partitions are missing in the listed end offsets listed end
 // offsets, the partition will have an error indicating it is missing. A
 // missing topic or partition in the commits is assumed to be nothing
-// committing yet.
+// committing yet: the partition's Commit has its topic and partition with an
+// At and LeaderEpoch of -1, and its lag is the partition's end offset.
 func CalculateGroupLag(
 	group DescribedGroup,
 	commit OffsetResponses,
@@ -1004,14 +1339,13 @@ func CalculateGroupLag(
 					ok      bool
 				)
 
-				if tcommit != nil {
-					if pcommit, ok = tcommit[p]; !ok {
-						pcommit = OffsetResponse{Offset: Offset{
-							Topic:     t.Topic,
-							Partition: p,
-							At:        -1,
-						}}
-					}
+				if pcommit, ok = tcommit[p]; !ok {
+					pcommit = OffsetResponse{Offset: Offset{
+						Topic:       t.Topic,
+						Partition:   p,
+						At:          -1,
+						LeaderEpoch: -1,
+					}}
 				}
 				if tend == nil {
 					perr = errListMissing
@@ -1129,3 +1463,139 @@ func calculateEmptyLag(commit OffsetResponses, offsets ListedOffsets) GroupLag {
 }
 
 var errListMissing = errors.New("missing from list offsets")
+
+// Total returns the total lag across all partitions, ignoring partitions with
+// unknown lag.
+func (l GroupLag) Total() int64 {
+	var tot int64
+	for _, ps := range l {
+		for _, m := range ps {
+			if m.Lag > 0 {
+				tot += m.Lag
+			}
+		}
+	}
+	return tot
+}
+
+// UncommittedLag is how GroupLag calculates the lag of partitions that a group
+// has not committed to.
+type UncommittedLag int8
+
+const (
+	// UncommittedLagFromZero calculates the lag of uncommitted partitions
+	// as the end offset, which is the lag as if the group were to start
+	// consuming from offset zero. This matches CalculateGroupLag.
+	UncommittedLagFromZero UncommittedLag = iota
+
+	// UncommittedLagFromStart calculates the lag of uncommitted
+	// partitions from the partition's log start offset, which is the lag
+	// as if the group were to reset to the earliest offset. This requires
+	// listing start offsets.
+	UncommittedLagFromStart
+
+	// UncommittedLagFromEnd calculates the lag of uncommitted partitions
+	// as zero, which is the lag as if the group were to reset to the
+	// latest offset.
+	UncommittedLagFromEnd
+
+	// UncommittedLagUnknown marks the lag of uncommitted partitions as
+	// unknown, with a Lag of -1 and a nil Err.
+	UncommittedLagUnknown
+)
+
+// GroupLag describes a group, fetches all of its committed offsets, lists the
+// end offsets of every topic the group is assigned (or, if the group is
+// Empty, has committed to), and returns the group's per-partition lag. Total
+// lag can be calculated with the returned lag's Total method.
+//
+// Offsets are fetched with one request to the group coordinator, and end
+// offsets are listed with one request per partition leader. A partition is
+// uncommitted if the group has no commit for it, and its lag is calculated
+// according to uncommitted.
+//
+// This returns an error if the group could not be described, its offsets
+// could not be fetched, or if listing end offsets failed entirely. Partitions
+// that individually fail to list have their Err field set.
+func (cl *Client) GroupLag(ctx context.Context, group string, uncommitted UncommittedLag) (GroupLag, error) {
+	described, err := cl.DescribeGroups(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	dg, ok := described[group]
+	if !ok {
+		return nil, kerr.GroupIDNotFound
+	}
+	if dg.Err != nil {
+		return nil, dg.Err
+	}
+
+	commit, err := cl.FetchOffsets(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var topics []string
+	if dg.State == "Empty" {
+		for t := range commit {
+			topics = append(topics, t)
+		}
+	} else {
+		topics = dg.AssignedPartitions().Topics()
+	}
+	if len(topics) == 0 {
+		return make(GroupLag), nil
+	}
+	ends, err := cl.ListEndOffsets(ctx, topics...)
+	if err != nil && len(ends) == 0 {
+		return nil, err
+	}
+
+	lag := CalculateGroupLag(dg, commit, ends)
+
+	var uncommittedTopics []string
+	for t, ps := range lag {
+		for _, m := range ps {
+			if m.Err == nil && m.Commit.At < 0 {
+				uncommittedTopics = append(uncommittedTopics, t)
+				break
+			}
+		}
+	}
+	if len(uncommittedTopics) == 0 || uncommitted == UncommittedLagFromZero {
+		return lag, nil
+	}
+
+	var starts ListedOffsets
+	if uncommitted == UncommittedLagFromStart {
+		if starts, err = cl.ListStartOffsets(ctx, uncommittedTopics...); err != nil && len(starts) == 0 {
+			return nil, err
+		}
+	}
+	for _, t := range uncommittedTopics {
+		ps := lag[t]
+		for p, m := range ps {
+			if m.Err != nil || m.Commit.At >= 0 {
+				continue
+			}
+			switch uncommitted {
+			case UncommittedLagFromStart:
+				start, ok := starts.Lookup(t, p)
+				switch {
+				case !ok:
+					m.Lag, m.Err = -1, errListMissing
+				case start.Err != nil:
+					m.Lag, m.Err = -1, start.Err
+				default:
+					m.Lag = m.End.Offset - start.Offset
+				}
+			case UncommittedLagFromEnd:
+				m.Lag = 0
+			default:
+				m.Lag = -1
+			}
+			ps[p] = m
+		}
+	}
+	return lag, nil
+}
@@ -0,0 +1,426 @@
+package kadm
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/kversion"
+)
+
+// fakeFindCoordinator replies that each key is coordinated by the node in
+// nodes, or node 0 if the key is not in nodes.
+func fakeFindCoordinator(req *kmsg.FindCoordinatorRequest, nodes map[string]int32) kmsg.Response {
+	resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+	for _, key := range req.CoordinatorKeys {
+		c := kmsg.NewFindCoordinatorResponseCoordinator()
+		c.Key, c.NodeID = key, nodes[key]
+		c.Host, c.Port = "127.0.0.1", 9092+c.NodeID
+		resp.Coordinators = append(resp.Coordinators, c)
+	}
+	return resp
+}
+
+func TestDescribeConsumerGroupsFallsBackPerGroup(t *testing.T) {
+	var classic [][]string
+	vs := kversion.Tip()
+	vs.SetMaxKeyVersion(kmsg.ConsumerGroupDescribe.Int16(), 0)
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.FindCoordinatorRequest:
+			return fakeFindCoordinator(req, nil)
+		case *kmsg.ConsumerGroupDescribeRequest:
+			resp := req.ResponseKind().(*kmsg.ConsumerGroupDescribeResponse)
+			for _, group := range req.Groups {
+				g := kmsg.NewConsumerGroupDescribeResponseGroup()
+				g.Group = group
+				g.State = "Stable"
+				if group == "classic" {
+					g.ErrorCode = kerr.GroupIDNotFound.Code
+				}
+				resp.Groups = append(resp.Groups, g)
+			}
+			return resp
+		case *kmsg.DescribeGroupsRequest:
+			classic = append(classic, req.Groups)
+			resp := req.ResponseKind().(*kmsg.DescribeGroupsResponse)
+			for _, group := range req.Groups {
+				g := kmsg.NewDescribeGroupsResponseGroup()
+				g.Group = group
+				g.State = "Stable"
+				resp.Groups = append(resp.Groups, g)
+			}
+			return resp
+		}
+		return nil
+	}, kgo.MaxVersions(vs))
+
+	// A classic group must not cause the consumer group described
+	// alongside it to be described again with DescribeGroups.
+	described, err := adm.DescribeConsumerGroups(context.Background(), "consumer", "classic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(classic, [][]string{{"classic"}}) {
+		t.Errorf("got classic describes %q, exp only [[classic]]", classic)
+	}
+	if g := described["consumer"]; g.Classic || g.State != "Stable" {
+		t.Errorf("got consumer group %+v, exp a non-classic stable group", g)
+	}
+	if g := described["classic"]; !g.Classic {
+		t.Errorf("got classic group %+v, exp it described as classic", g)
+	}
+}
+
+func consumerGroupAssigned(state string, assigned map[string][]int32) DescribedGroup {
+	a := kmsg.NewConsumerMemberAssignment()
+	for t, ps := range assigned {
+		at := kmsg.NewConsumerMemberAssignmentTopic()
+		at.Topic = t
+		at.Partitions = ps
+		a.Topics = append(a.Topics, at)
+	}
+	return DescribedGroup{
+		Group:        "g",
+		State:        state,
+		ProtocolType: "consumer",
+		Members:      []DescribedGroupMember{{MemberID: "m", Assigned: GroupMemberAssignment{&a}}},
+	}
+}
+
+func TestCalculateGroupLagUncommitted(t *testing.T) {
+	group := consumerGroupAssigned("Stable", map[string][]int32{"t1": {0, 1}, "t2": {0}})
+	commit := OffsetResponses{"t1": {0: {Offset: Offset{Topic: "t1", Partition: 0, At: 5, LeaderEpoch: 2}}}}
+	ends := ListedOffsets{
+		"t1": {0: {Topic: "t1", Partition: 0, Offset: 10}, 1: {Topic: "t1", Partition: 1, Offset: 8}},
+		"t2": {0: {Topic: "t2", Partition: 0, Offset: 4}},
+	}
+
+	lag := CalculateGroupLag(group, commit, ends)
+	for _, exp := range []struct {
+		t      string
+		p      int32
+		commit Offset
+		lag    int64
+	}{
+		{"t1", 0, Offset{Topic: "t1", Partition: 0, At: 5, LeaderEpoch: 2}, 5},
+		{"t1", 1, Offset{Topic: "t1", Partition: 1, At: -1, LeaderEpoch: -1}, 8},
+		// A topic with no commits at all is uncommitted the same way
+		// as a partition with no commit.
+		{"t2", 0, Offset{Topic: "t2", Partition: 0, At: -1, LeaderEpoch: -1}, 4},
+	} {
+		m, ok := lag.Lookup(exp.t, exp.p)
+		if !ok {
+			t.Errorf("%s/%d: missing lag", exp.t, exp.p)
+			continue
+		}
+		if m.Err != nil || m.Lag != exp.lag || !reflect.DeepEqual(m.Commit, exp.commit) {
+			t.Errorf("%s/%d: got commit %+v, lag %d, err %v != exp commit %+v, lag %d", exp.t, exp.p, m.Commit, m.Lag, m.Err, exp.commit, exp.lag)
+		}
+	}
+	if total := lag.Total(); total != 17 {
+		t.Errorf("got total lag %d != exp 17", total)
+	}
+}
+
+func TestGroupLag(t *testing.T) {
+	group := consumerGroupAssigned("Stable", map[string][]int32{"t": {0, 1}})
+	assigned := group.Members[0].Assigned.i.(*kmsg.ConsumerMemberAssignment).AppendTo(nil)
+
+	var listed []int64
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadata(req).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				for p := int32(0); p < 2; p++ {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Replicas = []int32{0}
+					rp.ISR = []int32{0}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+				}
+			}
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			return fakeFindCoordinator(req, nil)
+		case *kmsg.DescribeGroupsRequest:
+			resp := req.ResponseKind().(*kmsg.DescribeGroupsResponse)
+			for _, g := range req.Groups {
+				rg := kmsg.NewDescribeGroupsResponseGroup()
+				rg.Group, rg.State, rg.ProtocolType = g, "Stable", "consumer"
+				m := kmsg.NewDescribeGroupsResponseGroupMember()
+				m.MemberID = "m"
+				m.MemberAssignment = assigned
+				rg.Members = append(rg.Members, m)
+				resp.Groups = append(resp.Groups, rg)
+			}
+			return resp
+		case *kmsg.OffsetFetchRequest:
+			resp := req.ResponseKind().(*kmsg.OffsetFetchResponse)
+			for _, g := range req.Groups {
+				rg := kmsg.NewOffsetFetchResponseGroup()
+				rg.Group = g.Group
+				rt := kmsg.NewOffsetFetchResponseGroupTopic()
+				rt.Topic = "t"
+				rp := kmsg.NewOffsetFetchResponseGroupTopicPartition()
+				rp.Partition, rp.Offset, rp.LeaderEpoch = 0, 5, 1
+				rt.Partitions = append(rt.Partitions, rp)
+				rg.Topics = append(rg.Topics, rt)
+				resp.Groups = append(resp.Groups, rg)
+			}
+			return resp
+		case *kmsg.ListOffsetsRequest:
+			resp := req.ResponseKind().(*kmsg.ListOffsetsResponse)
+			for _, t := range req.Topics {
+				rt := kmsg.NewListOffsetsResponseTopic()
+				rt.Topic = t.Topic
+				for _, p := range t.Partitions {
+					listed = append(listed, p.Timestamp)
+					rp := kmsg.NewListOffsetsResponseTopicPartition()
+					rp.Partition = p.Partition
+					rp.Offset = 20 // end
+					if p.Timestamp == -2 {
+						rp.Offset = 12 // start
+					}
+					rt.Partitions = append(rt.Partitions, rp)
+				}
+				resp.Topics = append(resp.Topics, rt)
+			}
+			return resp
+		}
+		return nil
+	})
+
+	// Partition 0 is committed at 5 with an end offset of 20; partition 1
+	// is uncommitted with a start offset of 12 and an end offset of 20.
+	for _, test := range []struct {
+		name        string
+		uncommitted UncommittedLag
+		lag         int64
+		listedStart bool
+	}{
+		{"zero", UncommittedLagFromZero, 20, false},
+		{"start", UncommittedLagFromStart, 8, true},
+		{"end", UncommittedLagFromEnd, 0, false},
+		{"unknown", UncommittedLagUnknown, -1, false},
+	} {
+		listed = nil
+		lag, err := adm.GroupLag(context.Background(), "g", test.uncommitted)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if m, _ := lag.Lookup("t", 0); m.Err != nil || m.Lag != 15 {
+			t.Errorf("%s: got committed partition lag %d, err %v != exp 15", test.name, m.Lag, m.Err)
+		}
+		m, _ := lag.Lookup("t", 1)
+		if m.Err != nil || m.Lag != test.lag || m.Commit.At != -1 || m.Commit.LeaderEpoch != -1 {
+			t.Errorf("%s: got uncommitted partition lag %d, commit %+v, err %v != exp lag %d", test.name, m.Lag, m.Commit, m.Err, test.lag)
+		}
+		var exp int64 = 15
+		if test.lag > 0 {
+			exp += test.lag
+		}
+		if total := lag.Total(); total != exp {
+			t.Errorf("%s: got total %d != exp %d", test.name, total, exp)
+		}
+		var listedStart bool
+		for _, ts := range listed {
+			listedStart = listedStart || ts == -2
+		}
+		if listedStart != test.listedStart {
+			t.Errorf("%s: got listed start offsets %v != exp %v", test.name, listedStart, test.listedStart)
+		}
+	}
+}
+
+func TestListGroupsByType(t *testing.T) {
+	var types, states []string
+	handle := func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.ListGroupsRequest)
+		if !ok {
+			return nil
+		}
+		types, states = req.TypesFilter, req.StatesFilter
+		// The broker ignores filters, so that we test the client side
+		// filtering.
+		resp := req.ResponseKind().(*kmsg.ListGroupsResponse)
+		for _, g := range []struct{ group, typ, state string }{
+			{"classic-stable", "classic", "Stable"},
+			{"classic-empty", "classic", "Empty"},
+			{"consumer-stable", "consumer", "Stable"},
+		} {
+			rg := kmsg.NewListGroupsResponseGroup()
+			rg.Group, rg.GroupType, rg.GroupState = g.group, g.typ, g.state
+			resp.Groups = append(resp.Groups, rg)
+		}
+		return resp
+	}
+
+	for _, test := range []struct {
+		name       string
+		maxVersion int16
+		types      []string
+		states     []string
+		exp        map[string]string // group => type
+	}{
+		{"v5 all", 5, nil, nil, map[string]string{"classic-stable": "classic", "classic-empty": "classic", "consumer-stable": "consumer"}},
+		{"v5 types", 5, []string{"Consumer"}, nil, map[string]string{"consumer-stable": "consumer"}},
+		{"v5 types and states", 5, []string{"classic"}, []string{"stable"}, map[string]string{"classic-stable": "classic"}},
+		// Before v5, every group is classic.
+		{"v4 consumer", 4, []string{"consumer"}, nil, map[string]string{}},
+		{"v4 classic and states", 4, []string{"classic"}, []string{"Stable"}, map[string]string{"classic-stable": "classic", "consumer-stable": "classic"}},
+		// Before v4, groups have no state and cannot be state filtered.
+		{"v3 states", 3, nil, []string{"Empty"}, map[string]string{"classic-stable": "classic", "classic-empty": "classic", "consumer-stable": "classic"}},
+	} {
+		adm := newFakeBrokerClient(t, map[int16]int16{kmsg.ListGroups.Int16(): test.maxVersion}, handle, kgo.MaxVersions(kversion.Tip()))
+		listed, err := adm.ListGroupsByType(context.Background(), test.types, test.states...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		got := make(map[string]string)
+		for g, l := range listed {
+			got[g] = l.Type
+		}
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("%s: got groups %v != exp %v", test.name, got, test.exp)
+		}
+
+		expTypes, expStates := test.types, test.states
+		if test.maxVersion < 5 {
+			expTypes = nil
+		}
+		if test.maxVersion < 4 {
+			expStates = nil
+		}
+		if !reflect.DeepEqual(types, expTypes) || !reflect.DeepEqual(states, expStates) {
+			t.Errorf("%s: got sent types %v, states %v != exp %v, %v", test.name, types, states, expTypes, expStates)
+		}
+	}
+}
+
+func TestListFilterMatches(t *testing.T) {
+	for _, test := range []struct {
+		filter []string
+		v      string
+		exp    bool
+	}{
+		{nil, "Stable", true},
+		{[]string{"stable"}, "Stable", true},
+		{[]string{"Empty", "STABLE"}, "Stable", true},
+		{[]string{"Empty"}, "Stable", false},
+		{[]string{"Empty"}, "", false},
+	} {
+		if got := listFilterMatches(test.filter, test.v); got != test.exp {
+			t.Errorf("listFilterMatches(%v, %q) = %v != exp %v", test.filter, test.v, got, test.exp)
+		}
+	}
+}
+
+func TestDeleteOffsetsSubscribed(t *testing.T) {
+	var deleted []*kmsg.OffsetDeleteRequest
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.FindCoordinatorRequest:
+			return fakeFindCoordinator(req, nil)
+		case *kmsg.OffsetDeleteRequest:
+			deleted = append(deleted, req)
+			resp := req.ResponseKind().(*kmsg.OffsetDeleteResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewOffsetDeleteResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewOffsetDeleteResponseTopicPartition()
+					sp.Partition = rp.Partition
+					if rt.Topic == "subscribed" {
+						sp.ErrorCode = kerr.GroupSubscribedToTopic.Code
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return nil
+	})
+
+	var s TopicsSet
+	s.Add("stale", 0, 1)
+	s.Add("subscribed", 2)
+	rs, err := adm.DeleteOffsets(context.Background(), "g", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].Group != "g" {
+		t.Fatalf("got %d offset delete requests, exp one for group g", len(deleted))
+	}
+	exp := DeleteOffsetsResponses{
+		"stale":      {0: nil, 1: nil},
+		"subscribed": {2: kerr.GroupSubscribedToTopic},
+	}
+	if !reflect.DeepEqual(rs, exp) {
+		t.Errorf("got %v != exp %v", rs, exp)
+	}
+	var expSubscribed TopicsSet
+	expSubscribed.Add("subscribed", 2)
+	if got := rs.Subscribed(); !reflect.DeepEqual(got, expSubscribed) {
+		t.Errorf("got subscribed %v != exp %v", got, expSubscribed)
+	}
+
+	// Without any subscribed failures, Subscribed is empty.
+	delete(rs, "subscribed")
+	if got := rs.Subscribed(); len(got) != 0 {
+		t.Errorf("got subscribed %v, exp none", got)
+	}
+}
+
+func TestDeleteGroups(t *testing.T) {
+	var deleted [][]string
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.FindCoordinatorRequest:
+			return fakeFindCoordinator(req, map[string]int32{"b": 1, "c": 1})
+		case *kmsg.DeleteGroupsRequest:
+			groups := append([]string(nil), req.Groups...)
+			sort.Strings(groups)
+			deleted = append(deleted, groups)
+			resp := req.ResponseKind().(*kmsg.DeleteGroupsResponse)
+			for _, group := range req.Groups {
+				g := kmsg.NewDeleteGroupsResponseGroup()
+				g.Group = group
+				if group != "a" {
+					g.ErrorCode = kerr.NonEmptyGroup.Code
+				}
+				resp.Groups = append(resp.Groups, g)
+			}
+			return resp
+		}
+		return nil
+	})
+
+	rs, err := adm.DeleteGroups(context.Background(), "c", "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Groups on different coordinators are split into one request per
+	// coordinator.
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i][0] < deleted[j][0] })
+	if exp := [][]string{{"a"}, {"b", "c"}}; !reflect.DeepEqual(deleted, exp) {
+		t.Errorf("got delete requests for %q != exp %q", deleted, exp)
+	}
+	exp := DeleteGroupResponses{
+		"a": {Group: "a"},
+		"b": {Group: "b", Err: kerr.NonEmptyGroup},
+		"c": {Group: "c", Err: kerr.NonEmptyGroup},
+	}
+	if !reflect.DeepEqual(rs, exp) {
+		t.Errorf("got %v != exp %v", rs, exp)
+	}
+	if got, exp := rs.NonEmpty(), []string{"b", "c"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("got non empty %v != exp %v", got, exp)
+	}
+}
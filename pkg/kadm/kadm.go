@@ -296,6 +296,24 @@ func (os Offsets) Into() map[string]map[int32]kgo.Offset {
 	return tskgo
 }
 
+// EpochOffsets returns these offsets as a kgo epoch offset map, which can be
+// used to seek a group consumer with kgo's SetOffsets. Each leader epoch is
+// kept so that the consumer can detect log truncation.
+func (os Offsets) EpochOffsets() map[string]map[int32]kgo.EpochOffset {
+	tskgo := make(map[string]map[int32]kgo.EpochOffset)
+	for t, ps := range os {
+		pskgo := make(map[int32]kgo.EpochOffset)
+		for p, o := range ps {
+			pskgo[p] = kgo.EpochOffset{
+				Epoch:  o.LeaderEpoch,
+				Offset: o.At,
+			}
+		}
+		tskgo[t] = pskgo
+	}
+	return tskgo
+}
+
 // Sorted returns the offsets sorted by topic and partition.
 func (os Offsets) Sorted() []Offset {
 	var s []Offset
@@ -0,0 +1,218 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DescribedLogDirPartition is the information for a single partition in a
+// described log directory.
+type DescribedLogDirPartition struct {
+	Broker    int32  // Broker is the broker this partition is on.
+	Dir       string // Dir is the directory this partition is in.
+	Topic     string // Topic is the topic for this partition.
+	Partition int32  // Partition is this partition.
+	Size      int64  // Size is the total size of the log segments of this partition, in bytes.
+
+	// OffsetLag is how far behind the log end offset this partition is.
+	// If this is a future replica (IsFuture is true), this is the lag of
+	// the future replica behind the current replica; otherwise, this is
+	// the lag behind the high watermark.
+	OffsetLag int64
+	// IsFuture is true if this replica was created by an
+	// AlterReplicaLogDirsRequest and will replace the current replica in
+	// the future.
+	IsFuture bool
+}
+
+// DescribedLogDir is a described log directory.
+type DescribedLogDir struct {
+	Broker int32                                         // Broker is the broker being described.
+	Dir    string                                        // Dir is the described directory.
+	Err    error                                         // Err is non-nil if this directory could not be described, e.g. KAFKA_STORAGE_ERROR if the directory is offline.
+	Topics map[string]map[int32]DescribedLogDirPartition // Topics contains all partitions that were described in this directory.
+}
+
+// Size returns the total size of all partitions in this directory.
+func (ds DescribedLogDir) Size() int64 {
+	var tot int64
+	for _, ps := range ds.Topics {
+		for _, p := range ps {
+			tot += p.Size
+		}
+	}
+	return tot
+}
+
+// Sorted returns all partitions in this directory sorted by topic, then
+// partition.
+func (ds DescribedLogDir) Sorted() []DescribedLogDirPartition {
+	var s []DescribedLogDirPartition
+	for _, ps := range ds.Topics {
+		for _, p := range ps {
+			s = append(s, p)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if l.Topic < r.Topic {
+			return true
+		}
+		if l.Topic > r.Topic {
+			return false
+		}
+		return l.Partition < r.Partition
+	})
+	return s
+}
+
+// DescribedLogDirs contains per-directory responses to a describe log dirs
+// request for a single broker.
+type DescribedLogDirs map[string]DescribedLogDir
+
+// Size returns the total size of all directories.
+func (ds DescribedLogDirs) Size() int64 {
+	var tot int64
+	for _, d := range ds {
+		tot += d.Size()
+	}
+	return tot
+}
+
+// Sorted returns all directories sorted by directory name.
+func (ds DescribedLogDirs) Sorted() []DescribedLogDir {
+	var s []DescribedLogDir
+	for _, d := range ds {
+		s = append(s, d)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Dir < s[j].Dir })
+	return s
+}
+
+// DescribedAllLogDirs contains per-broker responses to a describe log dirs
+// request.
+type DescribedAllLogDirs map[int32]DescribedLogDirs
+
+// Sorted returns all directories sorted by broker, then directory name.
+func (ds DescribedAllLogDirs) Sorted() []DescribedLogDir {
+	var s []DescribedLogDir
+	for _, bdirs := range ds {
+		for _, d := range bdirs {
+			s = append(s, d)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if l.Broker < r.Broker {
+			return true
+		}
+		if l.Broker > r.Broker {
+			return false
+		}
+		return l.Dir < r.Dir
+	})
+	return s
+}
+
+// DescribeLogDirs describes the log directories for the requested partitions,
+// returning the directories, their per-partition sizes, and offset lag.
+//
+// If brokers is empty, each requested partition is described on every broker
+// that hosts a replica of it, and if s is also nil, every log directory on
+// every broker is described. If brokers is non-empty, the request is issued
+// to only those brokers, and a nil s describes everything on them. A non-nil
+// s with no partitions describes nothing.
+//
+// A directory that could not be described (for example, an offline directory
+// on a broker with a failed disk) is still returned, with its Err set.
+//
+// This may return *ShardErrors or *AuthError.
+func (cl *Client) DescribeLogDirs(ctx context.Context, brokers []int32, s TopicsSet) (DescribedAllLogDirs, error) {
+	req := kmsg.NewPtrDescribeLogDirsRequest()
+	for t, ps := range s {
+		if len(ps) == 0 {
+			continue
+		}
+		rt := kmsg.NewDescribeLogDirsRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+	// A nil request topics means all partitions, which we only want if s
+	// itself is nil.
+	if s != nil && len(req.Topics) == 0 {
+		return make(DescribedAllLogDirs), nil
+	}
+
+	var shards []kgo.ResponseShard
+	if len(brokers) == 0 {
+		shards = cl.cl.RequestSharded(ctx, req)
+	} else {
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+		for _, b := range brokers {
+			b := b
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// The client sets the version on the request it
+				// issues; each broker needs its own copy.
+				dup := *req
+				resp, err := cl.cl.Broker(int(b)).RetriableRequest(ctx, &dup)
+				mu.Lock()
+				defer mu.Unlock()
+				shards = append(shards, kgo.ResponseShard{
+					Meta: BrokerDetail{NodeID: b},
+					Req:  &dup,
+					Resp: resp,
+					Err:  err,
+				})
+			}()
+		}
+		wg.Wait()
+	}
+
+	all := make(DescribedAllLogDirs)
+	return all, shardErrEachBroker(req, shards, func(b BrokerDetail, kr kmsg.Response) error {
+		resp := kr.(*kmsg.DescribeLogDirsResponse)
+		bdirs := make(DescribedLogDirs)
+		all[b.NodeID] = bdirs
+		for _, rd := range resp.Dirs {
+			if err := maybeAuthErr(rd.ErrorCode); err != nil {
+				return err
+			}
+			d := DescribedLogDir{
+				Broker: b.NodeID,
+				Dir:    rd.Dir,
+				Err:    kerr.ErrorForCode(rd.ErrorCode),
+				Topics: make(map[string]map[int32]DescribedLogDirPartition),
+			}
+			for _, rt := range rd.Topics {
+				ps := make(map[int32]DescribedLogDirPartition)
+				d.Topics[rt.Topic] = ps
+				for _, rp := range rt.Partitions {
+					ps[rp.Partition] = DescribedLogDirPartition{
+						Broker:    b.NodeID,
+						Dir:       rd.Dir,
+						Topic:     rt.Topic,
+						Partition: rp.Partition,
+						Size:      rp.Size,
+						OffsetLag: rp.OffsetLag,
+						IsFuture:  rp.IsFuture,
+					}
+				}
+			}
+			bdirs[rd.Dir] = d
+		}
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package kadm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestDescribeLogDirsBrokers(t *testing.T) {
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.DescribeLogDirsRequest)
+		if !ok {
+			return nil
+		}
+		resp := req.ResponseKind().(*kmsg.DescribeLogDirsResponse)
+		d := kmsg.NewDescribeLogDirsResponseDir()
+		d.Dir = "/data"
+		for _, rt := range req.Topics {
+			st := kmsg.NewDescribeLogDirsResponseDirTopic()
+			st.Topic = rt.Topic
+			for _, p := range rt.Partitions {
+				sp := kmsg.NewDescribeLogDirsResponseDirTopicPartition()
+				sp.Partition = p
+				sp.Size = 100
+				st.Partitions = append(st.Partitions, sp)
+			}
+			d.Topics = append(d.Topics, st)
+		}
+		resp.Dirs = append(resp.Dirs, d)
+		return resp
+	})
+
+	// Requests to many brokers are issued concurrently; under -race, this
+	// checks each broker is issued its own request.
+	all, err := adm.DescribeLogDirs(context.Background(), []int32{0, 1, 2}, TopicsSet{"t": {0: {}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for b := int32(0); b < 3; b++ {
+		p, ok := all[b]["/data"].Topics["t"][0]
+		if !ok || p.Size != 100 || p.Broker != b {
+			t.Errorf("got partition %+v (exists %v), exp size 100 on broker %d", p, ok, b)
+		}
+	}
+}
+
+func TestDescribeLogDirsEmptySet(t *testing.T) {
+	var reqs []*kmsg.DescribeLogDirsRequest
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		if req, ok := kreq.(*kmsg.DescribeLogDirsRequest); ok {
+			reqs = append(reqs, req)
+		}
+		return nil
+	})
+
+	// A non-nil empty set, or one with only empty topics, must not issue
+	// a request: a null topic list describes all partitions.
+	for _, brokers := range [][]int32{nil, {0}} {
+		for _, s := range []TopicsSet{{}, {"t": {}}} {
+			all, err := adm.DescribeLogDirs(context.Background(), brokers, s)
+			if err != nil || all == nil || len(all) != 0 {
+				t.Errorf("got dirs %v, err %v; exp none", all, err)
+			}
+		}
+	}
+	if len(reqs) != 0 {
+		t.Fatalf("got %d describe log dirs requests, exp 0", len(reqs))
+	}
+
+	if _, err := adm.DescribeLogDirs(context.Background(), []int32{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].Topics != nil {
+		t.Errorf("got requests %v, exp one with null topics for all partitions", reqs)
+	}
+}
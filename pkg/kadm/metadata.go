@@ -161,6 +161,113 @@ func (cl *Client) ListBrokers(ctx context.Context) (BrokerDetails, error) {
 	return m.Brokers, nil
 }
 
+// FindCoordinatorResponse contains information for the coordinator for a group
+// or transactional ID.
+type FindCoordinatorResponse struct {
+	Name       string // Name is the coordinator key this response is for.
+	NodeID     int32  // NodeID is the node ID of the coordinator for this key.
+	Host       string // Host is the host of the coordinator for this key.
+	Port       int32  // Port is the port of the coordinator for this key.
+	Err        error  // Err is any error encountered when requesting the coordinator.
+	ErrMessage string // ErrMessage is an optional additional message describing Err.
+}
+
+// FindCoordinatorResponses contains responses to finding coordinators for
+// groups or transactions.
+type FindCoordinatorResponses map[string]FindCoordinatorResponse
+
+// AllFailed returns whether all responses are errored.
+func (rs FindCoordinatorResponses) AllFailed() bool {
+	var n int
+	rs.EachError(func(FindCoordinatorResponse) { n++ })
+	return len(rs) > 0 && n == len(rs)
+}
+
+// Sorted returns all coordinator responses sorted by name.
+func (rs FindCoordinatorResponses) Sorted() []FindCoordinatorResponse {
+	s := make([]FindCoordinatorResponse, 0, len(rs))
+	for _, r := range rs {
+		s = append(s, r)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	return s
+}
+
+// EachError calls fn for every response that has a non-nil error.
+func (rs FindCoordinatorResponses) EachError(fn func(FindCoordinatorResponse)) {
+	for _, r := range rs {
+		if r.Err != nil {
+			fn(r)
+		}
+	}
+}
+
+// FindGroupCoordinators returns the coordinator for all requested group names.
+//
+// This issues a single batched FindCoordinator request if the cluster supports
+// it (Kafka 3.0+), and one request per group otherwise. If the request fails
+// entirely, or if an individual group could not be looked up, that error is
+// returned in the per-group response; groups can fail and succeed
+// independently.
+func (cl *Client) FindGroupCoordinators(ctx context.Context, groups ...string) FindCoordinatorResponses {
+	return cl.findCoordinators(ctx, 0, groups...)
+}
+
+// FindTxnCoordinators returns the coordinator for all requested transactional
+// IDs.
+//
+// This issues a single batched FindCoordinator request if the cluster supports
+// it (Kafka 3.0+), and one request per ID otherwise. If the request fails
+// entirely, or if an individual ID could not be looked up, that error is
+// returned in the per-ID response; IDs can fail and succeed independently.
+func (cl *Client) FindTxnCoordinators(ctx context.Context, txnIDs ...string) FindCoordinatorResponses {
+	return cl.findCoordinators(ctx, 1, txnIDs...)
+}
+
+func (cl *Client) findCoordinators(ctx context.Context, kind int8, names ...string) FindCoordinatorResponses {
+	rs := make(FindCoordinatorResponses)
+	if len(names) == 0 {
+		return rs
+	}
+
+	req := kmsg.NewPtrFindCoordinatorRequest()
+	req.CoordinatorType = kind
+	req.CoordinatorKeys = names
+
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if resp != nil {
+		for _, c := range resp.Coordinators {
+			r := FindCoordinatorResponse{
+				Name:   c.Key,
+				NodeID: c.NodeID,
+				Host:   c.Host,
+				Port:   c.Port,
+				Err:    kerr.ErrorForCode(c.ErrorCode),
+			}
+			if c.ErrorMessage != nil {
+				r.ErrMessage = *c.ErrorMessage
+			}
+			rs[c.Key] = r
+		}
+	}
+
+	// Any name missing from the response failed to be requested; we
+	// use the request error if there is one.
+	for _, name := range names {
+		if _, exists := rs[name]; !exists {
+			missingErr := err
+			if missingErr == nil {
+				missingErr = fmt.Errorf("coordinator for %q was missing from the response", name)
+			}
+			rs[name] = FindCoordinatorResponse{
+				Name: name,
+				Err:  missingErr,
+			}
+		}
+	}
+	return rs
+}
+
 // MetadataWithoutTopics issues a metadata request and returns it, and does not
 // ask for any topics. This request is the counterpart to requesting all topics
 // by default with the Metadata method.
@@ -258,7 +365,7 @@ type ListedOffset struct {
 
 	Timestamp   int64 // Timestamp is the millisecond of the offset if listing after a time, otherwise -1.
 	Offset      int64 // Offset is the record offset, or -1 if one could not be found.
-	LeaderEpoch int32 // LeaderEpoch is the leader epoch at this offset, if any, otherwise -1.
+	LeaderEpoch int32 // LeaderEpoch is the leader epoch at this offset, if any, otherwise -1 (always -1 before Kafka 2.1).
 
 	Err error // Err is non-nil if the partition has a load error.
 }
@@ -307,7 +414,8 @@ func (l ListedOffsets) Error() error {
 	return nil
 }
 
-// Into returns these listed offsets as offsets.
+// Into returns these listed offsets as offsets. Leader epochs are kept, such
+// that consuming from the returned offsets detects log truncation.
 func (l ListedOffsets) Into() Offsets {
 	o := make(Offsets)
 	l.Each(func(l ListedOffset) {
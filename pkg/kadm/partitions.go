@@ -0,0 +1,405 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// ElectionType is the type of leader election to perform.
+type ElectionType int8
+
+const (
+	// ElectPreferredReplica triggers the election of the preferred
+	// replica (the first replica in the replica list) as leader, if it is
+	// in sync.
+	ElectPreferredReplica ElectionType = 0
+
+	// ElectLiveReplica triggers an unclean election of the first live
+	// replica as leader if there are no in-sync replicas. This can result
+	// in data loss, and is only supported in Kafka 2.4.0+ (KIP-460).
+	ElectLiveReplica ElectionType = 1
+)
+
+// ElectLeadersResult is the result for a single partition in an elect leaders
+// request.
+type ElectLeadersResult struct {
+	Topic      string       // Topic is the topic this result is for.
+	Partition  int32        // Partition is the partition this result is for.
+	How        ElectionType // How is the type of election that was performed.
+	Err        error        // Err is non-nil if the election could not be performed.
+	ErrMessage string       // ErrMessage is an optional additional message describing Err.
+}
+
+// ElectLeadersResults contains per-partition results for an elect leaders
+// request.
+type ElectLeadersResults map[string]map[int32]ElectLeadersResult
+
+// Lookup returns the result at t and p and whether it exists.
+func (rs ElectLeadersResults) Lookup(t string, p int32) (ElectLeadersResult, bool) {
+	if len(rs) == 0 {
+		return ElectLeadersResult{}, false
+	}
+	ps := rs[t]
+	if len(ps) == 0 {
+		return ElectLeadersResult{}, false
+	}
+	r, exists := ps[p]
+	return r, exists
+}
+
+// Each calls fn for every elect leaders result.
+func (rs ElectLeadersResults) Each(fn func(ElectLeadersResult)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// Sorted returns all elect leaders results sorted first by topic, then by
+// partition.
+func (rs ElectLeadersResults) Sorted() []ElectLeadersResult {
+	var s []ElectLeadersResult
+	for _, ps := range rs {
+		for _, r := range ps {
+			s = append(s, r)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if l.Topic < r.Topic {
+			return true
+		}
+		if l.Topic > r.Topic {
+			return false
+		}
+		return l.Partition < r.Partition
+	})
+	return s
+}
+
+// ElectLeaders issues an elect leaders request for the given partitions,
+// performing the given type of election. If s is nil, Kafka performs the
+// election for all partitions in the cluster and the results contain every
+// partition that the election applied to. If s is non-nil but contains no
+// partitions, no request is issued and no results are returned.
+//
+// If a partition's leader is already the replica that would be elected (for
+// example, the preferred replica is already leader), Kafka replies with
+// ELECTION_NOT_NEEDED; this is not a failure, and the result for that
+// partition has a nil Err.
+//
+// This may return *AuthError if you are not authorized to elect leaders. Other
+// per-partition failures are included in the results. This request requires
+// Kafka 2.2.0+.
+func (cl *Client) ElectLeaders(ctx context.Context, how ElectionType, s TopicsSet) (ElectLeadersResults, error) {
+	req := kmsg.NewPtrElectLeadersRequest()
+	req.ElectionType = int8(how)
+	req.TimeoutMillis = cl.timeoutMillis
+	for t, ps := range s {
+		if len(ps) == 0 {
+			continue
+		}
+		rt := kmsg.NewElectLeadersRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+	// A nil request topics means all partitions, which we only want if s
+	// itself is nil.
+	if s != nil && len(req.Topics) == 0 {
+		return make(ElectLeadersResults), nil
+	}
+
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err = maybeAuthErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err = kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	rs := make(ElectLeadersResults)
+	for _, t := range resp.Topics {
+		rt := make(map[int32]ElectLeadersResult)
+		rs[t.Topic] = rt
+		for _, p := range t.Partitions {
+			r := ElectLeadersResult{
+				Topic:     t.Topic,
+				Partition: p.Partition,
+				How:       how,
+				Err:       kerr.ErrorForCode(p.ErrorCode),
+			}
+			if r.Err == kerr.ElectionNotNeeded {
+				r.Err = nil
+			}
+			if r.Err != nil && p.ErrorMessage != nil {
+				r.ErrMessage = *p.ErrorMessage
+			}
+			rt[p.Partition] = r
+		}
+	}
+	return rs, nil
+}
+
+// AlterPartitionAssignmentsReq is the input for a request to alter partition
+// assignments. The keys are topics and partitions, and the final value is the
+// list of brokers to place the partition's replicas on.
+type AlterPartitionAssignmentsReq map[string]map[int32][]int32
+
+// Assign specifies brokers that a partition should be placed on. Using the
+// same partition more than once overrides the prior assignment.
+func (r *AlterPartitionAssignmentsReq) Assign(t string, p int32, brokers []int32) {
+	if *r == nil {
+		*r = make(map[string]map[int32][]int32)
+	}
+	ps := (*r)[t]
+	if ps == nil {
+		ps = make(map[int32][]int32)
+		(*r)[t] = ps
+	}
+	ps[p] = brokers
+}
+
+// CancelAssign cancels a reassignment of the given partition.
+func (r *AlterPartitionAssignmentsReq) CancelAssign(t string, p int32) {
+	r.Assign(t, p, nil)
+}
+
+// AlterPartitionAssignmentsResponse contains a response for an individual
+// partition that was assigned.
+type AlterPartitionAssignmentsResponse struct {
+	Topic      string // Topic is the topic that was assigned.
+	Partition  int32  // Partition is the partition that was assigned.
+	Err        error  // Err is non-nil if this assignment errored.
+	ErrMessage string // ErrMessage is an optional additional message describing Err.
+}
+
+// AlterPartitionAssignmentsResponses contains responses to all partitions in an
+// alter assignment request.
+type AlterPartitionAssignmentsResponses map[string]map[int32]AlterPartitionAssignmentsResponse
+
+// Lookup returns the response at t and p and whether it exists.
+func (rs AlterPartitionAssignmentsResponses) Lookup(t string, p int32) (AlterPartitionAssignmentsResponse, bool) {
+	if len(rs) == 0 {
+		return AlterPartitionAssignmentsResponse{}, false
+	}
+	ps := rs[t]
+	if len(ps) == 0 {
+		return AlterPartitionAssignmentsResponse{}, false
+	}
+	r, exists := ps[p]
+	return r, exists
+}
+
+// Each calls fn for every response.
+func (rs AlterPartitionAssignmentsResponses) Each(fn func(AlterPartitionAssignmentsResponse)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// Sorted returns the responses sorted by topic and partition.
+func (rs AlterPartitionAssignmentsResponses) Sorted() []AlterPartitionAssignmentsResponse {
+	var s []AlterPartitionAssignmentsResponse
+	rs.Each(func(r AlterPartitionAssignmentsResponse) {
+		s = append(s, r)
+	})
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if l.Topic < r.Topic {
+			return true
+		}
+		if l.Topic > r.Topic {
+			return false
+		}
+		return l.Partition < r.Partition
+	})
+	return s
+}
+
+// AlterPartitionAssignments alters partition assignments for the requested
+// partitions, returning an error if the response could not be issued or if
+// you do not have permissions.
+//
+// Assigning a partition to new brokers begins a reassignment, which Kafka
+// completes in the background; use ListPartitionReassignments to check the
+// progress. Assigning a nil or empty list of brokers cancels any in-progress
+// reassignment for the partition. If the partition has no reassignment in
+// progress, Kafka replies with NO_REASSIGNMENT_IN_PROGRESS; the goal of the
+// cancel is met, so the response for that partition has a nil Err.
+//
+// This requires Kafka 2.4.0+.
+func (cl *Client) AlterPartitionAssignments(ctx context.Context, req AlterPartitionAssignmentsReq) (AlterPartitionAssignmentsResponses, error) {
+	if len(req) == 0 {
+		return make(AlterPartitionAssignmentsResponses), nil
+	}
+
+	kreq := kmsg.NewPtrAlterPartitionAssignmentsRequest()
+	kreq.TimeoutMillis = cl.timeoutMillis
+	for t, ps := range req {
+		rt := kmsg.NewAlterPartitionAssignmentsRequestTopic()
+		rt.Topic = t
+		for p, rs := range ps {
+			rp := kmsg.NewAlterPartitionAssignmentsRequestTopicPartition()
+			rp.Partition = p
+			if len(rs) > 0 {
+				rp.Replicas = rs
+			}
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+		kreq.Topics = append(kreq.Topics, rt)
+	}
+
+	kresp, err := kreq.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err = maybeAuthErr(kresp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err = kerr.ErrorForCode(kresp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	a := make(AlterPartitionAssignmentsResponses)
+	for _, kt := range kresp.Topics {
+		ps := make(map[int32]AlterPartitionAssignmentsResponse)
+		a[kt.Topic] = ps
+		for _, kp := range kt.Partitions {
+			r := AlterPartitionAssignmentsResponse{
+				Topic:     kt.Topic,
+				Partition: kp.Partition,
+				Err:       kerr.ErrorForCode(kp.ErrorCode),
+			}
+			if r.Err == kerr.NoReassignmentInProgress && len(req[kt.Topic][kp.Partition]) == 0 {
+				r.Err = nil
+			}
+			if r.Err != nil && kp.ErrorMessage != nil {
+				r.ErrMessage = *kp.ErrorMessage
+			}
+			ps[kp.Partition] = r
+		}
+	}
+	return a, nil
+}
+
+// ListPartitionReassignmentsResponse contains a response for an individual
+// partition that is being reassigned.
+type ListPartitionReassignmentsResponse struct {
+	Topic            string  // Topic is the topic that was assigned.
+	Partition        int32   // Partition is the partition that was assigned.
+	Replicas         []int32 // Replicas contains the partition's current replicas.
+	AddingReplicas   []int32 // AddingReplicas contains replicas that are being added to the partition.
+	RemovingReplicas []int32 // RemovingReplicas contains replicas that are being removed from the partition.
+}
+
+// ListPartitionReassignmentsResponses contains responses to all partitions in
+// a list reassignment request.
+type ListPartitionReassignmentsResponses map[string]map[int32]ListPartitionReassignmentsResponse
+
+// Lookup returns the response at t and p and whether it exists.
+func (rs ListPartitionReassignmentsResponses) Lookup(t string, p int32) (ListPartitionReassignmentsResponse, bool) {
+	if len(rs) == 0 {
+		return ListPartitionReassignmentsResponse{}, false
+	}
+	ps := rs[t]
+	if len(ps) == 0 {
+		return ListPartitionReassignmentsResponse{}, false
+	}
+	r, exists := ps[p]
+	return r, exists
+}
+
+// Each calls fn for every response.
+func (rs ListPartitionReassignmentsResponses) Each(fn func(ListPartitionReassignmentsResponse)) {
+	for _, ps := range rs {
+		for _, r := range ps {
+			fn(r)
+		}
+	}
+}
+
+// Sorted returns the responses sorted by topic and partition.
+func (rs ListPartitionReassignmentsResponses) Sorted() []ListPartitionReassignmentsResponse {
+	var s []ListPartitionReassignmentsResponse
+	rs.Each(func(r ListPartitionReassignmentsResponse) {
+		s = append(s, r)
+	})
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if l.Topic < r.Topic {
+			return true
+		}
+		if l.Topic > r.Topic {
+			return false
+		}
+		return l.Partition < r.Partition
+	})
+	return s
+}
+
+// ListPartitionReassignments lists the state of any active reassignments for
+// the requested partitions, or for all partitions if s is nil. A non-nil s
+// with no partitions lists nothing. Partitions that are not being reassigned
+// are not included in the response.
+//
+// This returns an error if the request could not be issued or if you do not
+// have permissions. This requires Kafka 2.4.0+.
+func (cl *Client) ListPartitionReassignments(ctx context.Context, s TopicsSet) (ListPartitionReassignmentsResponses, error) {
+	kreq := kmsg.NewPtrListPartitionReassignmentsRequest()
+	kreq.TimeoutMillis = cl.timeoutMillis
+	for t, ps := range s {
+		if len(ps) == 0 {
+			continue
+		}
+		rt := kmsg.NewListPartitionReassignmentsRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rt.Partitions = append(rt.Partitions, p)
+		}
+		kreq.Topics = append(kreq.Topics, rt)
+	}
+	// A nil request topics means all partitions, which we only want if s
+	// itself is nil.
+	if s != nil && len(kreq.Topics) == 0 {
+		return make(ListPartitionReassignmentsResponses), nil
+	}
+
+	kresp, err := kreq.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err = maybeAuthErr(kresp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err = kerr.ErrorForCode(kresp.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	a := make(ListPartitionReassignmentsResponses)
+	for _, kt := range kresp.Topics {
+		ps := make(map[int32]ListPartitionReassignmentsResponse)
+		a[kt.Topic] = ps
+		for _, kp := range kt.Partitions {
+			ps[kp.Partition] = ListPartitionReassignmentsResponse{
+				Topic:            kt.Topic,
+				Partition:        kp.Partition,
+				Replicas:         kp.Replicas,
+				AddingReplicas:   kp.AddingReplicas,
+				RemovingReplicas: kp.RemovingReplicas,
+			}
+		}
+	}
+	return a, nil
+}
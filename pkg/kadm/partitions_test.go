@@ -0,0 +1,89 @@
+package kadm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestElectLeadersEmptySet(t *testing.T) {
+	var reqs []*kmsg.ElectLeadersRequest
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		if req, ok := kreq.(*kmsg.ElectLeadersRequest); ok {
+			reqs = append(reqs, req)
+		}
+		return nil
+	})
+
+	// A non-nil empty set, or one with only empty topics, must not issue
+	// a request: a null topic list elects leaders for all partitions.
+	for _, s := range []TopicsSet{{}, {"t": {}}} {
+		rs, err := adm.ElectLeaders(context.Background(), ElectPreferredReplica, s)
+		if err != nil || len(rs) != 0 {
+			t.Errorf("got results %v, err %v; exp none", rs, err)
+		}
+	}
+	if len(reqs) != 0 {
+		t.Fatalf("got %d elect leaders requests, exp 0", len(reqs))
+	}
+
+	if _, err := adm.ElectLeaders(context.Background(), ElectPreferredReplica, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].Topics != nil {
+		t.Errorf("got requests %v, exp one with null topics for all partitions", reqs)
+	}
+}
+
+func TestListPartitionReassignmentsEmptySet(t *testing.T) {
+	var reqs []*kmsg.ListPartitionReassignmentsRequest
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.ListPartitionReassignmentsRequest)
+		if !ok {
+			return nil
+		}
+		reqs = append(reqs, req)
+		resp := req.ResponseKind().(*kmsg.ListPartitionReassignmentsResponse)
+		rt := kmsg.NewListPartitionReassignmentsResponseTopic()
+		rt.Topic = "t"
+		rp := kmsg.NewListPartitionReassignmentsResponseTopicPartition()
+		rp.Partition = 0
+		rp.Replicas = []int32{0, 1}
+		rp.AddingReplicas = []int32{1}
+		rt.Partitions = append(rt.Partitions, rp)
+		resp.Topics = append(resp.Topics, rt)
+		return resp
+	})
+
+	// A non-nil empty set, or one with only empty topics, must not issue
+	// a request: a null topic list lists all reassignments.
+	for _, s := range []TopicsSet{{}, {"t": {}}} {
+		rs, err := adm.ListPartitionReassignments(context.Background(), s)
+		if err != nil || rs == nil || len(rs) != 0 {
+			t.Errorf("got responses %v, err %v; exp none", rs, err)
+		}
+	}
+	if len(reqs) != 0 {
+		t.Fatalf("got %d list reassignments requests, exp 0", len(reqs))
+	}
+
+	rs, err := adm.ListPartitionReassignments(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].Topics != nil {
+		t.Errorf("got requests %v, exp one with null topics for all partitions", reqs)
+	}
+	r, ok := rs["t"][0]
+	if !ok || len(r.Replicas) != 2 || len(r.AddingReplicas) != 1 || r.AddingReplicas[0] != 1 {
+		t.Errorf("got reassignment %+v (exists %v), exp replicas [0 1] adding [1]", r, ok)
+	}
+
+	if _, err := adm.ListPartitionReassignments(context.Background(), TopicsSet{"t": {0: {}}, "empty": {}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 || len(reqs[1].Topics) != 1 || reqs[1].Topics[0].Topic != "t" {
+		t.Errorf("got request %v, exp only topic t", reqs[len(reqs)-1])
+	}
+}
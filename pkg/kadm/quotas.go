@@ -0,0 +1,199 @@
+package kadm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Quota entity types, for use in QuotaEntityComponent and
+// DescribeClientQuotaComponent.
+const (
+	QuotaEntityUser     = "user"      // QuotaEntityUser is the user entity type.
+	QuotaEntityClientID = "client-id" // QuotaEntityClientID is the client ID entity type.
+	QuotaEntityIP       = "ip"        // QuotaEntityIP is the IP entity type.
+)
+
+// Quota keys, for use in QuotaValue and AlterClientQuotaOp.
+const (
+	QuotaProducerByteRate       = "producer_byte_rate"       // QuotaProducerByteRate is the produce bytes per second quota.
+	QuotaConsumerByteRate       = "consumer_byte_rate"       // QuotaConsumerByteRate is the fetch bytes per second quota.
+	QuotaRequestPercentage      = "request_percentage"       // QuotaRequestPercentage is the percentage of request handler and network thread time quota.
+	QuotaConnectionCreationRate = "connection_creation_rate" // QuotaConnectionCreationRate is the connections per second quota, for IP entities.
+	QuotaControllerMutationRate = "controller_mutation_rate" // QuotaControllerMutationRate is the partition mutations per second quota.
+)
+
+// QuotaEntityComponent is a single component of a quota entity, for example,
+// the user of a user and client ID entity.
+type QuotaEntityComponent struct {
+	Type string  // Type is the entity type, e.g. QuotaEntityUser.
+	Name *string // Name is the entity name, or nil for the default entity of this type.
+}
+
+// QuotaEntity is a quota entity, made up of one or more components.
+type QuotaEntity []QuotaEntityComponent
+
+// QuotaValue is a quota key and its value.
+type QuotaValue struct {
+	Key   string  // Key is the quota key, e.g. QuotaProducerByteRate.
+	Value float64 // Value is the quota value.
+}
+
+// DescribeClientQuotaComponent is a filter component for describing client
+// quotas.
+type DescribeClientQuotaComponent struct {
+	Type string // Type is the entity type to match, e.g. QuotaEntityUser.
+
+	// MatchType is how to match entities of this type: exactly by
+	// MatchName, only the default entity, or any entity.
+	MatchType kmsg.QuotasMatchType
+
+	// MatchName is the name to match if MatchType is
+	// kmsg.QuotasMatchTypeExact.
+	MatchName *string
+}
+
+// DescribedClientQuota is the quotas for a single described entity.
+type DescribedClientQuota struct {
+	Entity QuotaEntity  // Entity is the entity these quotas are for.
+	Values []QuotaValue // Values are the quotas for this entity, sorted by key.
+}
+
+// DescribedClientQuotas contains all entities matched when describing client
+// quotas.
+type DescribedClientQuotas []DescribedClientQuota
+
+// DescribeClientQuotas describes client quotas for entities matching all of the
+// given filter components. If strict is true, only entities that have exactly
+// the filtered component types are returned; otherwise, entities that have
+// additional component types are returned as well. The request is issued to
+// the controller.
+//
+// This method requires talking to Kafka v2.6+.
+func (cl *Client) DescribeClientQuotas(ctx context.Context, strict bool, components []DescribeClientQuotaComponent) (DescribedClientQuotas, error) {
+	req := kmsg.NewPtrDescribeClientQuotasRequest()
+	req.Strict = strict
+	for _, c := range components {
+		rc := kmsg.NewDescribeClientQuotasRequestComponent()
+		rc.EntityType = c.Type
+		rc.MatchType = c.MatchType
+		rc.Match = c.MatchName
+		req.Components = append(req.Components, rc)
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeAuthErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	var qs DescribedClientQuotas
+	for _, e := range resp.Entries {
+		var q DescribedClientQuota
+		for _, c := range e.Entity {
+			q.Entity = append(q.Entity, QuotaEntityComponent{
+				Type: c.Type,
+				Name: c.Name,
+			})
+		}
+		for _, v := range e.Values {
+			q.Values = append(q.Values, QuotaValue{
+				Key:   v.Key,
+				Value: v.Value,
+			})
+		}
+		sort.Slice(q.Values, func(i, j int) bool { return q.Values[i].Key < q.Values[j].Key })
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+// AlterClientQuotaOp sets or removes a single quota for an entity.
+type AlterClientQuotaOp struct {
+	Key    string  // Key is the quota key to alter, e.g. QuotaConsumerByteRate.
+	Value  float64 // Value is the quota value to set; this is ignored if Remove is true.
+	Remove bool    // Remove is whether the quota should be removed rather than set.
+}
+
+// AlterClientQuotaEntry alters quotas for a single entity.
+type AlterClientQuotaEntry struct {
+	Entity QuotaEntity          // Entity is the entity to alter quotas for.
+	Ops    []AlterClientQuotaOp // Ops are the alterations to apply.
+}
+
+// AlteredClientQuota is the result of altering quotas for a single entity.
+type AlteredClientQuota struct {
+	Entity     QuotaEntity // Entity is the entity that was altered.
+	Err        error       // Err is non-nil if the alteration failed.
+	ErrMessage string      // ErrMessage is an optional additional message on error.
+}
+
+// AlteredClientQuotas contains results for altering quotas for many entities.
+type AlteredClientQuotas []AlteredClientQuota
+
+// AlterClientQuotas alters quotas for the given entities. The request is
+// issued to the controller.
+//
+// This method requires talking to Kafka v2.6+.
+func (cl *Client) AlterClientQuotas(ctx context.Context, entries []AlterClientQuotaEntry) (AlteredClientQuotas, error) {
+	return cl.alterClientQuotas(ctx, false, entries)
+}
+
+// ValidateAlterClientQuotas validates an alter of client quotas.
+//
+// This returns exactly what AlterClientQuotas returns, but does not actually
+// alter quotas.
+func (cl *Client) ValidateAlterClientQuotas(ctx context.Context, entries []AlterClientQuotaEntry) (AlteredClientQuotas, error) {
+	return cl.alterClientQuotas(ctx, true, entries)
+}
+
+func (cl *Client) alterClientQuotas(ctx context.Context, dry bool, entries []AlterClientQuotaEntry) (AlteredClientQuotas, error) {
+	req := kmsg.NewPtrAlterClientQuotasRequest()
+	req.ValidateOnly = dry
+	for _, entry := range entries {
+		re := kmsg.NewAlterClientQuotasRequestEntry()
+		for _, c := range entry.Entity {
+			rec := kmsg.NewAlterClientQuotasRequestEntryEntity()
+			rec.Type = c.Type
+			rec.Name = c.Name
+			re.Entity = append(re.Entity, rec)
+		}
+		for _, op := range entry.Ops {
+			reo := kmsg.NewAlterClientQuotasRequestEntryOp()
+			reo.Key = op.Key
+			reo.Value = op.Value
+			reo.Remove = op.Remove
+			re.Ops = append(re.Ops, reo)
+		}
+		req.Entries = append(req.Entries, re)
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	var rs AlteredClientQuotas
+	for _, e := range resp.Entries {
+		if err := maybeAuthErr(e.ErrorCode); err != nil {
+			return nil, err
+		}
+		r := AlteredClientQuota{
+			Err: kerr.ErrorForCode(e.ErrorCode),
+		}
+		if e.ErrorMessage != nil {
+			r.ErrMessage = *e.ErrorMessage
+		}
+		for _, c := range e.Entity {
+			r.Entity = append(r.Entity, QuotaEntityComponent{
+				Type: c.Type,
+				Name: c.Name,
+			})
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
@@ -0,0 +1,56 @@
+package kadm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestDescribeClientQuotas(t *testing.T) {
+	var got *kmsg.DescribeClientQuotasRequest
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.DescribeClientQuotasRequest)
+		if !ok {
+			return nil
+		}
+		got = req
+		resp := req.ResponseKind().(*kmsg.DescribeClientQuotasResponse)
+		e := kmsg.NewDescribeClientQuotasResponseEntry()
+		ec := kmsg.NewDescribeClientQuotasResponseEntryEntity()
+		ec.Type, ec.Name = QuotaEntityUser, kmsg.StringPtr("u")
+		e.Entity = append(e.Entity, ec)
+		for _, kv := range []struct {
+			k string
+			v float64
+		}{{QuotaProducerByteRate, 2}, {QuotaConsumerByteRate, 1}} {
+			v := kmsg.NewDescribeClientQuotasResponseEntryValue()
+			v.Key, v.Value = kv.k, kv.v
+			e.Values = append(e.Values, v)
+		}
+		resp.Entries = append(resp.Entries, e)
+		return resp
+	})
+
+	qs, err := adm.DescribeClientQuotas(context.Background(), true, []DescribeClientQuotaComponent{{
+		Type:      QuotaEntityUser,
+		MatchType: kmsg.QuotasMatchTypeExact,
+		MatchName: kmsg.StringPtr("u"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Like AlterClientQuotas, describing is routed to the controller.
+	if _, ok := interface{}(got).(kmsg.AdminRequest); !ok {
+		t.Error("DescribeClientQuotasRequest is not routed to the controller")
+	}
+	if !got.Strict || len(got.Components) != 1 || got.Components[0].EntityType != QuotaEntityUser ||
+		got.Components[0].MatchType != kmsg.QuotasMatchTypeExact || got.Components[0].Match == nil || *got.Components[0].Match != "u" {
+		t.Errorf("got request %+v, exp strict with an exact user u component", got)
+	}
+	if len(qs) != 1 || len(qs[0].Entity) != 1 || *qs[0].Entity[0].Name != "u" ||
+		len(qs[0].Values) != 2 || qs[0].Values[0].Key != QuotaConsumerByteRate || qs[0].Values[1].Key != QuotaProducerByteRate {
+		t.Errorf("got quotas %+v, exp user u with values sorted by key", qs)
+	}
+}
@@ -0,0 +1,213 @@
+package kadm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// DelegationToken is a delegation token (KIP-48), as created or described.
+//
+// Delegation tokens allow clients to authenticate with SASL/SCRAM using a
+// short lived token rather than distributing long lived credentials. A token
+// has the same ACLs as the principal that created it.
+type DelegationToken struct {
+	OwnerType string    // OwnerType is the principal type of who created this token; this is "User" with the simple authorizer.
+	Owner     string    // Owner is the principal name of who created this token.
+	TokenID   string    // TokenID is the ID of this token, used as the SCRAM username.
+	HMAC      []byte    // HMAC is the secret of this token, used (base64 encoded) as the SCRAM password.
+	Renewers  []string  // Renewers are the users that can renew this token, sorted.
+	Issued    time.Time // Issued is when this token was issued.
+	Expiry    time.Time // Expiry is when this token expires, unless renewed.
+	MaxExpiry time.Time // MaxExpiry is the time past which this token cannot be renewed.
+}
+
+// ScramAuth returns SCRAM credentials that authenticate with this token. The
+// returned Auth can be used with scram.Sha256 or scram.Sha512, matching the
+// mechanism the cluster has enabled, for example:
+//
+//     kgo.SASL(token.ScramAuth().AsSha512Mechanism())
+//
+func (t DelegationToken) ScramAuth() scram.Auth {
+	return scram.Auth{
+		User:    t.TokenID,
+		Pass:    base64.StdEncoding.EncodeToString(t.HMAC),
+		IsToken: true,
+	}
+}
+
+// DelegationTokens contains described delegation tokens.
+type DelegationTokens []DelegationToken
+
+// Sorted returns all tokens sorted by owner, then token ID.
+func (ts DelegationTokens) Sorted() []DelegationToken {
+	s := append([]DelegationToken(nil), ts...)
+	sort.Slice(s, func(i, j int) bool {
+		l, r := s[i], s[j]
+		if l.Owner < r.Owner {
+			return true
+		}
+		if l.Owner > r.Owner {
+			return false
+		}
+		return l.TokenID < r.TokenID
+	})
+	return s
+}
+
+// tokenErr converts a delegation token response error code into an error,
+// explaining the common case of delegation tokens being disabled.
+func tokenErr(code int16) error {
+	if err := maybeAuthErr(code); err != nil {
+		return err
+	}
+	switch err := kerr.ErrorForCode(code); err {
+	case nil:
+		return nil
+	case kerr.DelegationTokenAuthDisabled:
+		return fmt.Errorf("delegation tokens are not enabled on the cluster, which requires delegation.token.secret.key on every broker: %w", err)
+	case kerr.DelegationTokenRequestNotAllowed:
+		return fmt.Errorf("delegation token requests must be issued over an authenticated connection that is not itself authenticated with a delegation token: %w", err)
+	default:
+		return err
+	}
+}
+
+func millisTime(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond))
+}
+
+// CreateDelegationToken creates a delegation token for the authenticated
+// user. The token is valid for maxLifetime, or the broker's
+// delegation.token.max.lifetime.ms if maxLifetime is non-positive. The given
+// renewers are user names that can renew the token; if empty, only the
+// creator can renew it.
+//
+// The connection must be authenticated with SASL or mTLS, and not with a
+// delegation token itself.
+//
+// This method requires talking to Kafka v1.1+.
+func (cl *Client) CreateDelegationToken(ctx context.Context, maxLifetime time.Duration, renewers ...string) (DelegationToken, error) {
+	req := kmsg.NewPtrCreateDelegationTokenRequest()
+	req.MaxLifetimeMillis = -1
+	if maxLifetime > 0 {
+		req.MaxLifetimeMillis = maxLifetime.Milliseconds()
+	}
+	for _, r := range renewers {
+		rr := kmsg.NewCreateDelegationTokenRequestRenewer()
+		rr.PrincipalType = "User"
+		rr.PrincipalName = r
+		req.Renewers = append(req.Renewers, rr)
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return DelegationToken{}, err
+	}
+	if err := tokenErr(resp.ErrorCode); err != nil {
+		return DelegationToken{}, err
+	}
+	sorted := append([]string(nil), renewers...)
+	sort.Strings(sorted)
+	return DelegationToken{
+		OwnerType: resp.PrincipalType,
+		Owner:     resp.PrincipalName,
+		TokenID:   resp.TokenID,
+		HMAC:      resp.HMAC,
+		Renewers:  sorted,
+		Issued:    millisTime(resp.IssueTimestamp),
+		Expiry:    millisTime(resp.ExpiryTimestamp),
+		MaxExpiry: millisTime(resp.MaxTimestamp),
+	}, nil
+}
+
+// RenewDelegationToken renews the delegation token with the given HMAC for
+// renewPeriod, or the broker's delegation.token.expiry.time.ms if renewPeriod
+// is non-positive, returning the new expiry time. A token cannot be renewed
+// past its MaxExpiry.
+//
+// This method requires talking to Kafka v1.1+.
+func (cl *Client) RenewDelegationToken(ctx context.Context, hmac []byte, renewPeriod time.Duration) (time.Time, error) {
+	req := kmsg.NewPtrRenewDelegationTokenRequest()
+	req.HMAC = hmac
+	req.RenewTimeMillis = -1
+	if renewPeriod > 0 {
+		req.RenewTimeMillis = renewPeriod.Milliseconds()
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := tokenErr(resp.ErrorCode); err != nil {
+		return time.Time{}, err
+	}
+	return millisTime(resp.ExpiryTimestamp), nil
+}
+
+// ExpireDelegationToken changes the expiry of the delegation token with the
+// given HMAC to now plus expiryPeriod, returning the new expiry time. A
+// non-positive expiryPeriod expires the token immediately. This can only
+// shorten a token's lifetime, not extend it.
+//
+// This method requires talking to Kafka v1.1+.
+func (cl *Client) ExpireDelegationToken(ctx context.Context, hmac []byte, expiryPeriod time.Duration) (time.Time, error) {
+	req := kmsg.NewPtrExpireDelegationTokenRequest()
+	req.HMAC = hmac
+	req.ExpiryPeriodMillis = -1
+	if expiryPeriod > 0 {
+		req.ExpiryPeriodMillis = expiryPeriod.Milliseconds()
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := tokenErr(resp.ErrorCode); err != nil {
+		return time.Time{}, err
+	}
+	return millisTime(resp.ExpiryTimestamp), nil
+}
+
+// DescribeDelegationTokens describes delegation tokens created by any of the
+// given users, or all tokens the authenticated user can describe if no owners
+// are given.
+//
+// This method requires talking to Kafka v1.1+.
+func (cl *Client) DescribeDelegationTokens(ctx context.Context, owners ...string) (DelegationTokens, error) {
+	req := kmsg.NewPtrDescribeDelegationTokenRequest()
+	for _, o := range owners {
+		ro := kmsg.NewDescribeDelegationTokenRequestOwner()
+		ro.PrincipalType = "User"
+		ro.PrincipalName = o
+		req.Owners = append(req.Owners, ro)
+	}
+	resp, err := req.RequestWith(ctx, cl.cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := tokenErr(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	var ts DelegationTokens
+	for _, d := range resp.TokenDetails {
+		t := DelegationToken{
+			OwnerType: d.PrincipalType,
+			Owner:     d.PrincipalName,
+			TokenID:   d.TokenID,
+			HMAC:      d.HMAC,
+			Issued:    millisTime(d.IssueTimestamp),
+			Expiry:    millisTime(d.ExpiryTimestamp),
+			MaxExpiry: millisTime(d.MaxTimestamp),
+		}
+		for _, r := range d.Renewers {
+			t.Renewers = append(t.Renewers, r.PrincipalName)
+		}
+		sort.Strings(t.Renewers)
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
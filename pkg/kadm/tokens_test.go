@@ -0,0 +1,102 @@
+package kadm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCreateDelegationToken(t *testing.T) {
+	var got *kmsg.CreateDelegationTokenRequest
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.CreateDelegationTokenRequest)
+		if !ok {
+			return nil
+		}
+		got = req
+		resp := req.ResponseKind().(*kmsg.CreateDelegationTokenResponse)
+		resp.PrincipalType, resp.PrincipalName = "User", "alice"
+		resp.TokenID, resp.HMAC = "id", []byte("secret")
+		resp.IssueTimestamp, resp.ExpiryTimestamp, resp.MaxTimestamp = 1000, 2000, 3000
+		return resp
+	})
+
+	tok, err := adm.CreateDelegationToken(context.Background(), 0, "z", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxLifetimeMillis != -1 || len(got.Renewers) != 2 || got.Renewers[0].PrincipalType != "User" || got.Renewers[0].PrincipalName != "z" {
+		t.Errorf("got request %+v, exp the broker default lifetime and User renewers z and a", got)
+	}
+	exp := DelegationToken{
+		OwnerType: "User",
+		Owner:     "alice",
+		TokenID:   "id",
+		HMAC:      []byte("secret"),
+		Renewers:  []string{"a", "z"},
+		Issued:    time.Unix(1, 0),
+		Expiry:    time.Unix(2, 0),
+		MaxExpiry: time.Unix(3, 0),
+	}
+	if !reflect.DeepEqual(tok, exp) {
+		t.Errorf("got token %+v, exp %+v", tok, exp)
+	}
+	if auth := tok.ScramAuth(); auth.User != "id" || auth.Pass != base64.StdEncoding.EncodeToString([]byte("secret")) || !auth.IsToken {
+		t.Errorf("got scram auth %+v, exp the token ID and base64 HMAC as a token", auth)
+	}
+
+	if _, err := adm.CreateDelegationToken(context.Background(), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxLifetimeMillis != time.Hour.Milliseconds() {
+		t.Errorf("got max lifetime %d, exp one hour", got.MaxLifetimeMillis)
+	}
+}
+
+func TestDescribeDelegationTokens(t *testing.T) {
+	var got *kmsg.DescribeDelegationTokenRequest
+	code := kerr.DelegationTokenAuthDisabled.Code
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.DescribeDelegationTokenRequest)
+		if !ok {
+			return nil
+		}
+		got = req
+		resp := req.ResponseKind().(*kmsg.DescribeDelegationTokenResponse)
+		resp.ErrorCode = code
+		for _, id := range []string{"t2", "t1"} {
+			d := kmsg.NewDescribeDelegationTokenResponseTokenDetail()
+			d.PrincipalType, d.PrincipalName, d.TokenID = "User", "alice", id
+			for _, r := range []string{"z", "a"} {
+				dr := kmsg.NewDescribeDelegationTokenResponseTokenDetailRenewer()
+				dr.PrincipalType, dr.PrincipalName = "User", r
+				d.Renewers = append(d.Renewers, dr)
+			}
+			resp.TokenDetails = append(resp.TokenDetails, d)
+		}
+		return resp
+	})
+
+	if _, err := adm.DescribeDelegationTokens(context.Background()); !errors.Is(err, kerr.DelegationTokenAuthDisabled) {
+		t.Errorf("got err %v, exp %v", err, kerr.DelegationTokenAuthDisabled)
+	}
+
+	code = 0
+	ts, err := adm.DescribeDelegationTokens(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Owners) != 1 || got.Owners[0].PrincipalType != "User" || got.Owners[0].PrincipalName != "alice" {
+		t.Errorf("got owners %+v, exp User alice", got.Owners)
+	}
+	sorted := ts.Sorted()
+	if len(sorted) != 2 || sorted[0].TokenID != "t1" || sorted[1].TokenID != "t2" || !reflect.DeepEqual(sorted[0].Renewers, []string{"a", "z"}) {
+		t.Errorf("got tokens %+v, exp t1 and t2 with sorted renewers", sorted)
+	}
+}
@@ -387,8 +387,9 @@ func (cl *Client) DeleteRecords(ctx context.Context, os Offsets) (DeleteRecordsR
 // CreatePartitionsResponse contains the response for an individual topic from
 // a create partitions request.
 type CreatePartitionsResponse struct {
-	Topic string // Topic is the topic this response is for.
-	Err   error  // Err is non-nil if partitions were unable to be added to this topic.
+	Topic      string // Topic is the topic this response is for.
+	Err        error  // Err is non-nil if partitions were unable to be added to this topic.
+	ErrMessage string // ErrMessage is an optional additional message describing Err, such as why a partition count is invalid.
 }
 
 // CreatePartitionsResponses contains per-topic responses for a create
@@ -438,7 +439,7 @@ func (rs CreatePartitionsResponses) On(topic string, fn func(*CreatePartitionsRe
 // (rather than adding to the current count). You may consider checking
 // ValidateCreatePartitions before using this method.
 func (cl *Client) CreatePartitions(ctx context.Context, add int, topics ...string) (CreatePartitionsResponses, error) {
-	return cl.createPartitions(ctx, false, add, -1, topics)
+	return cl.createPartitions(ctx, false, add, -1, nil, topics)
 }
 
 // UpdatePartitions issues a create partitions request for the given topics,
@@ -455,7 +456,7 @@ func (cl *Client) CreatePartitions(ctx context.Context, add int, topics ...strin
 // current partitions). You may consider checking ValidateUpdatePartitions
 // before using this method.
 func (cl *Client) UpdatePartitions(ctx context.Context, set int, topics ...string) (CreatePartitionsResponses, error) {
-	return cl.createPartitions(ctx, false, -1, set, topics)
+	return cl.createPartitions(ctx, false, -1, set, nil, topics)
 }
 
 // ValidateCreatePartitions validates a create partitions request for adding
@@ -465,7 +466,7 @@ func (cl *Client) UpdatePartitions(ctx context.Context, set int, topics ...strin
 // ValidateOnly field set to true. The response is the same response you would
 // receive from CreatePartitions, but no partitions are actually added.
 func (cl *Client) ValidateCreatePartitions(ctx context.Context, add int, topics ...string) (CreatePartitionsResponses, error) {
-	return cl.createPartitions(ctx, true, add, -1, topics)
+	return cl.createPartitions(ctx, true, add, -1, nil, topics)
 }
 
 // ValidateUpdatePartitions validates a create partitions request for setting
@@ -475,10 +476,42 @@ func (cl *Client) ValidateCreatePartitions(ctx context.Context, add int, topics
 // ValidateOnly field set to true. The response is the same response you would
 // receive from UpdatePartitions, but no partitions are actually added.
 func (cl *Client) ValidateUpdatePartitions(ctx context.Context, set int, topics ...string) (CreatePartitionsResponses, error) {
-	return cl.createPartitions(ctx, true, -1, set, topics)
+	return cl.createPartitions(ctx, true, -1, set, nil, topics)
 }
 
-func (cl *Client) createPartitions(ctx context.Context, dry bool, add, set int, topics []string) (CreatePartitionsResponses, error) {
+// UpdatePartitionsWithAssignments issues a create partitions request for a
+// single topic, setting the final partition count to "set" and explicitly
+// choosing the replicas for each new partition.
+//
+// Each element in assignments is the list of broker IDs that should host
+// replicas for a new partition, with the first broker being the preferred
+// leader. The number of assignments must equal the number of new partitions
+// (set minus the current count), and each assignment must have the same
+// number of replicas as the existing partitions; otherwise Kafka replies with
+// an INVALID_REPLICA_ASSIGNMENT error. If assignments is empty, this is
+// equivalent to UpdatePartitions.
+//
+// Kafka does not allow reducing the number of partitions in a topic. If set is
+// less than the current count, the response contains an INVALID_PARTITIONS
+// error with an ErrMessage describing the current count.
+//
+// As with UpdatePartitions, this does not return an error on authorization
+// failures for the request itself; these are included in the response.
+func (cl *Client) UpdatePartitionsWithAssignments(ctx context.Context, topic string, set int, assignments ...[]int32) (CreatePartitionsResponses, error) {
+	return cl.createPartitions(ctx, false, -1, set, assignments, []string{topic})
+}
+
+// ValidateUpdatePartitionsWithAssignments validates a create partitions
+// request for setting the partition count on the given topic to "set" with
+// explicit replica assignments for the new partitions.
+//
+// This uses the same logic as UpdatePartitionsWithAssignments, but with the
+// request's ValidateOnly field set to true.
+func (cl *Client) ValidateUpdatePartitionsWithAssignments(ctx context.Context, topic string, set int, assignments ...[]int32) (CreatePartitionsResponses, error) {
+	return cl.createPartitions(ctx, true, -1, set, assignments, []string{topic})
+}
+
+func (cl *Client) createPartitions(ctx context.Context, dry bool, add, set int, assignments [][]int32, topics []string) (CreatePartitionsResponses, error) {
 	if len(topics) == 0 {
 		return make(CreatePartitionsResponses), nil
 	}
@@ -503,6 +536,11 @@ func (cl *Client) createPartitions(ctx context.Context, dry bool, add, set int,
 		} else {
 			rt.Count = int32(len(td[t].Partitions) + add)
 		}
+		for _, replicas := range assignments {
+			ra := kmsg.NewCreatePartitionsRequestTopicAssignment()
+			ra.Replicas = replicas
+			rt.Assignment = append(rt.Assignment, ra)
+		}
 		req.Topics = append(req.Topics, rt)
 	}
 
@@ -512,11 +550,24 @@ func (cl *Client) createPartitions(ctx context.Context, dry bool, add, set int,
 	}
 
 	rs := make(CreatePartitionsResponses)
+	var anyAdded bool
 	for _, t := range resp.Topics {
-		rs[t.Topic] = CreatePartitionsResponse{
+		r := CreatePartitionsResponse{
 			Topic: t.Topic,
 			Err:   kerr.ErrorForCode(t.ErrorCode),
 		}
+		if t.ErrorMessage != nil {
+			r.ErrMessage = *t.ErrorMessage
+		}
+		anyAdded = anyAdded || r.Err == nil
+		rs[t.Topic] = r
+	}
+
+	// New partitions are not produced to or consumed from until the
+	// client discovers them in a metadata refresh; we trigger one now
+	// rather than waiting for the periodic refresh.
+	if !dry && anyAdded {
+		cl.cl.ForceMetadataRefresh()
 	}
 	return rs, nil
 }
@@ -0,0 +1,90 @@
+package kadm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestUpdatePartitionsWithAssignments(t *testing.T) {
+	var (
+		metas int32
+		reqs  []*kmsg.CreatePartitionsRequest
+	)
+	adm := newFakeBrokerClient(t, nil, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			atomic.AddInt32(&metas, 1)
+		case *kmsg.CreatePartitionsRequest:
+			reqs = append(reqs, req)
+			resp := req.ResponseKind().(*kmsg.CreatePartitionsResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewCreatePartitionsResponseTopic()
+				st.Topic = rt.Topic
+				if rt.Count < 2 {
+					st.ErrorCode = kerr.InvalidPartitions.Code
+					st.ErrorMessage = kmsg.StringPtr("topic currently has 2 partitions")
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	rs, err := adm.ValidateUpdatePartitionsWithAssignments(ctx, "t", 4, []int32{0, 1}, []int32{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rs["t"]; r.Err != nil {
+		t.Errorf("validate: got err %v", r.Err)
+	}
+	req := reqs[0]
+	if !req.ValidateOnly || len(req.Topics) != 1 || req.Topics[0].Topic != "t" || req.Topics[0].Count != 4 {
+		t.Fatalf("validate: got request %+v, exp a validating request setting t to 4 partitions", req)
+	}
+	var replicas [][]int32
+	for _, a := range req.Topics[0].Assignment {
+		replicas = append(replicas, a.Replicas)
+	}
+	if exp := [][]int32{{0, 1}, {1, 2}}; !reflect.DeepEqual(replicas, exp) {
+		t.Errorf("validate: got assignments %v != exp %v", replicas, exp)
+	}
+
+	// A successful non-validating update refreshes metadata so that the
+	// client discovers the new partitions.
+	before := atomic.LoadInt32(&metas)
+	if rs, err = adm.UpdatePartitionsWithAssignments(ctx, "t", 3, []int32{2, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if r := rs["t"]; r.Err != nil {
+		t.Errorf("update: got err %v", r.Err)
+	}
+	if req := reqs[1]; req.ValidateOnly || req.Topics[0].Count != 3 || len(req.Topics[0].Assignment) != 1 {
+		t.Errorf("update: got request %+v, exp setting t to 3 partitions with one assignment", req)
+	}
+	for start := time.Now(); atomic.LoadInt32(&metas) == before; {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timed out waiting for a metadata refresh after adding partitions")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Shrinking fails with the broker's explanation.
+	if rs, err = adm.UpdatePartitionsWithAssignments(ctx, "t", 1); err != nil {
+		t.Fatal(err)
+	}
+	if r := rs["t"]; !errors.Is(r.Err, kerr.InvalidPartitions) || r.ErrMessage != "topic currently has 2 partitions" {
+		t.Errorf("shrink: got err %v, message %q", r.Err, r.ErrMessage)
+	}
+	if req := reqs[2]; len(req.Topics[0].Assignment) != 0 {
+		t.Errorf("shrink: got assignments %v, exp none", req.Topics[0].Assignment)
+	}
+}
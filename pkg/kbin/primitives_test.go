@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"testing"
 	"testing/quick"
 )
@@ -31,6 +32,119 @@ func TestUvarint(t *testing.T) {
 	}
 }
 
+func TestUvarlong(t *testing.T) {
+	if err := quick.Check(func(u uint64) bool {
+		var expPut [10]byte
+		n := binary.PutUvarint(expPut[:], u)
+
+		gotPut := AppendUvarlong(nil, u)
+		if !bytes.Equal(expPut[:n], gotPut) || UvarlongLen(u) != n {
+			return false
+		}
+
+		r := Reader{Src: gotPut}
+		return r.Uvarlong() == u && r.Complete() == nil
+	}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVarlong(t *testing.T) {
+	for _, i := range []int64{0, 1, -1, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64} {
+		var expPut [10]byte
+		n := binary.PutVarint(expPut[:], i)
+
+		gotPut := AppendVarlong(nil, i)
+		if !bytes.Equal(expPut[:n], gotPut) {
+			t.Errorf("%d: got %x != exp %x", i, gotPut, expPut[:n])
+		}
+		if l := VarlongLen(i); l != n {
+			t.Errorf("%d: got len %d != exp %d", i, l, n)
+		}
+
+		r := Reader{Src: gotPut}
+		if got := r.Varlong(); got != i || r.Complete() != nil {
+			t.Errorf("%d: got read %d (complete err %v)", i, got, r.Complete())
+		}
+	}
+
+	// A uvarlong longer than 10 bytes overflows.
+	r := Reader{Src: bytes.Repeat([]byte{0xff}, 11)}
+	if r.Varlong(); r.Ok() {
+		t.Error("expected overflowing varlong to be bad")
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	for _, f := range []float64{
+		0,
+		math.Copysign(0, -1),
+		1,
+		-1,
+		math.MaxFloat64,
+		-math.MaxFloat64,
+		math.SmallestNonzeroFloat64,
+		math.Inf(1),
+		math.Inf(-1),
+		math.NaN(),
+	} {
+		var exp [8]byte
+		binary.BigEndian.PutUint64(exp[:], math.Float64bits(f))
+
+		got := AppendFloat64(nil, f)
+		if !bytes.Equal(got, exp[:]) {
+			t.Errorf("%v: got %x != exp %x", f, got, exp)
+		}
+
+		r := Reader{Src: got}
+		if read := r.Float64(); math.Float64bits(read) != math.Float64bits(f) || r.Complete() != nil {
+			t.Errorf("%v: got read %v (complete err %v)", f, read, r.Complete())
+		}
+	}
+
+	r := Reader{Src: make([]byte, 7)}
+	if r.Float64(); r.Ok() {
+		t.Error("expected short float64 to be bad")
+	}
+}
+
+func TestFlexibleArrayLen(t *testing.T) {
+	for _, test := range []struct {
+		l        int
+		isNil    bool
+		flexible bool
+		exp      []byte
+	}{
+		{0, true, false, []byte{0xff, 0xff, 0xff, 0xff}}, // classic null: -1
+		{0, true, true, []byte{0}},                       // compact null: 0
+		{0, false, false, []byte{0, 0, 0, 0}},
+		{0, false, true, []byte{1}},
+		{3, false, false, []byte{0, 0, 0, 3}},
+		{3, false, true, []byte{4}},
+	} {
+		got := AppendFlexibleNullableArrayLen(nil, test.l, test.isNil, test.flexible)
+		if !bytes.Equal(got, test.exp) {
+			t.Errorf("len %d nil %v flexible %v: got %x != exp %x", test.l, test.isNil, test.flexible, got, test.exp)
+		}
+		if !test.isNil {
+			if got := AppendFlexibleArrayLen(nil, test.l, test.flexible); !bytes.Equal(got, test.exp) {
+				t.Errorf("len %d flexible %v: got non-nullable %x != exp %x", test.l, test.flexible, got, test.exp)
+			}
+		}
+
+		// Decoding a null length returns -1 regardless of encoding;
+		// we pad our source so the min-size check passes.
+		expRead := int32(test.l)
+		if test.isNil {
+			expRead = -1
+		}
+		r := Reader{Src: append(got, make([]byte, test.l)...)}
+		if read := r.FlexibleArrayLen(test.flexible); read != expRead || !r.Ok() {
+			t.Errorf("len %d nil %v flexible %v: got read %d != exp %d", test.l, test.isNil, test.flexible, read, expRead)
+		}
+	}
+}
+
 func BenchmarkUvarint(b *testing.B) {
 	for _, u := range []uint32{
 		0,         // len 1
@@ -132,6 +132,10 @@ type broker struct {
 
 	reapMu sync.Mutex // held when modifying a brokerCxn
 
+	// breaker stops connection attempts after repeated failures, if the
+	// client is configured with BrokerCircuitBreaker.
+	breaker breaker
+
 	// reqs manages incoming message requests.
 	reqs ringReq
 	// dead is an atomic so a backed up reqs cannot block broker stoppage.
@@ -141,19 +145,26 @@ type broker struct {
 // brokerVersions is loaded once (and potentially a few times concurrently if
 // multiple connections are opening at once) and then forever stored for a
 // broker.
+//
+// This always has room for every key kmsg knows of, and has room for any
+// larger key the broker replied with, so that requests for APIs that kmsg
+// does not model can still be version negotiated.
 type brokerVersions struct {
-	versions [kmsg.MaxKey + 1]int16
+	versions []int16
 }
 
-func newBrokerVersions() *brokerVersions {
-	var v brokerVersions
-	for i := range &v.versions {
+func newBrokerVersions(maxKey int16) *brokerVersions {
+	if maxKey < kmsg.MaxKey {
+		maxKey = kmsg.MaxKey
+	}
+	v := brokerVersions{versions: make([]int16, int(maxKey)+1)}
+	for i := range v.versions {
 		v.versions[i] = -1
 	}
 	return &v
 }
 
-func (v *brokerVersions) len() int { return kmsg.MaxKey + 1 }
+func (v *brokerVersions) len() int { return len(v.versions) }
 
 func (b *broker) loadVersions() *brokerVersions {
 	loaded := b.versions.Load()
@@ -240,89 +251,86 @@ func (b *broker) waitResp(ctx context.Context, req kmsg.Request) (kmsg.Response,
 	return resp, err
 }
 
+// handleReqs handles requests queued to the broker.
+//
+// Requests that are already queued behind one another for the same connection
+// are coalesced into as few writes as possible, which saves syscalls when many
+// small requests are issued at once. We never wait for more requests to
+// arrive, so a lone request is always written immediately.
 func (b *broker) handleReqs(pr promisedReq) {
-	var more, dead bool
-start:
-	if dead {
-		pr.promise(nil, errChosenBrokerDead)
-	} else {
-		b.handleReq(pr)
-	}
+	var (
+		more, dead bool
+		pending    []preparedReq
+	)
+	for {
+		if dead {
+			pr.promise(nil, errChosenBrokerDead)
+		} else if p, ok := b.prepareReq(pr); ok {
+			if len(pending) > 0 && p.cxn != pending[0].cxn {
+				b.writeReqs(pending)
+				pending = pending[:0]
+			}
+			pending = append(pending, p)
+		}
 
-	pr, more, dead = b.reqs.dropPeek()
-	if more {
-		goto start
+		// We must write everything pending before we drop the final
+		// queued request: once the queue is empty, a new push starts
+		// a new handleReqs goroutine, which could write concurrently.
+		if len(pending) > 0 {
+			if next, ok := b.reqs.peekNext(); !ok || !b.coalescable(pending[0].cxn, next.req) {
+				b.writeReqs(pending)
+				pending = pending[:0]
+			}
+		}
+
+		pr, more, dead = b.reqs.dropPeek()
+		if !more {
+			return
+		}
 	}
 }
 
-func (b *broker) handleReq(pr promisedReq) {
+// preparedReq is a request whose connection and version have been loaded and
+// that is ready to be written.
+type preparedReq struct {
+	pr       promisedReq
+	cxn      *brokerCxn
+	isNoResp bool
+	noResp   *kmsg.ProduceResponse
+	written  []byte // a copy of the written request, if recording
+}
+
+// coalescable returns whether req can be written along with requests pending
+// on cxn: req must be for the same live connection, and the connection must
+// not be throttled nor need to reauthenticate.
+func (b *broker) coalescable(cxn *brokerCxn, req kmsg.Request) bool {
+	now := time.Now()
+	return *b.cxnSlot(req) == cxn &&
+		atomic.LoadInt32(&cxn.dead) == 0 &&
+		(cxn.expiry.IsZero() || now.Before(cxn.expiry)) &&
+		atomic.LoadInt64(&cxn.throttleUntil) <= now.UnixNano()
+}
+
+// prepareReq loads the connection and version for a request, returning false
+// if the request cannot be issued, in which case its promise has been called.
+func (b *broker) prepareReq(pr promisedReq) (preparedReq, bool) {
 	req := pr.req
 	var cxn *brokerCxn
 	{
 		var err error
 		if cxn, err = b.loadConnection(pr.ctx, req); err != nil {
 			pr.promise(nil, err)
-			return
-		}
-	}
-
-	v := b.loadVersions()
-
-	if int(req.Key()) > v.len() || b.cl.cfg.maxVersions != nil && !b.cl.cfg.maxVersions.HasKey(req.Key()) {
-		pr.promise(nil, errUnknownRequestKey)
-		return
-	}
-
-	// If v.versions[0] is non-negative, then we loaded API
-	// versions. If the version for this request is negative, we
-	// know the broker cannot handle this request.
-	if v.versions[0] >= 0 && v.versions[req.Key()] < 0 {
-		pr.promise(nil, errBrokerTooOld)
-		return
-	}
-
-	ourMax := req.MaxVersion()
-	if b.cl.cfg.maxVersions != nil {
-		userMax, _ := b.cl.cfg.maxVersions.LookupMaxKeyVersion(req.Key()) // we validated HasKey above
-		if userMax < ourMax {
-			ourMax = userMax
+			return preparedReq{}, false
 		}
 	}
 
-	// If brokerMax is negative at this point, we have no api
-	// versions because the client is pinned pre 0.10.0 and we
-	// stick with our max.
-	version := ourMax
-	if brokerMax := v.versions[req.Key()]; brokerMax >= 0 && brokerMax < ourMax {
-		version = brokerMax
-	}
-
-	// If the version now (after potential broker downgrading) is
-	// lower than we desire, we fail the request for the broker is
-	// too old.
-	if b.cl.cfg.minVersions != nil {
-		minVersion, minVersionExists := b.cl.cfg.minVersions.LookupMaxKeyVersion(req.Key())
-		if minVersionExists && version < minVersion {
-			pr.promise(nil, errBrokerTooOld)
-			return
-		}
+	version, err := b.negotiateVersion(b.loadVersions(), req.Key(), req.MaxVersion())
+	if err != nil {
+		pr.promise(nil, err)
+		return preparedReq{}, false
 	}
-
 	req.SetVersion(version) // always go for highest version
 
-	if !cxn.expiry.IsZero() && time.Now().After(cxn.expiry) {
-		// If we are after the reauth time, try to reauth. We
-		// can only have an expiry if we went the authenticate
-		// flow, so we know we are authenticating again.
-		// For KIP-368.
-		cxn.cl.cfg.logger.Log(LogLevelDebug, "sasl expiry limit reached, reauthenticating", "broker", logID(cxn.b.meta.NodeID))
-		if err := cxn.sasl(); err != nil {
-			pr.promise(nil, err)
-			cxn.die()
-			return
-		}
-	}
-
 	// Juuuust before we issue the request, we check if it was
 	// canceled. We could have previously tried this request, which
 	// then failed and retried.
@@ -332,7 +340,7 @@ func (b *broker) handleReq(pr promisedReq) {
 	select {
 	case <-pr.ctx.Done():
 		pr.promise(nil, pr.ctx.Err())
-		return
+		return preparedReq{}, false
 	default:
 	}
 
@@ -366,17 +374,99 @@ func (b *broker) handleReq(pr promisedReq) {
 		noResp.Version = req.GetVersion()
 	}
 
-	corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(pr.ctx, pr.enqueue, req)
+	return preparedReq{pr: pr, cxn: cxn, isNoResp: isNoResp, noResp: noResp}, true
+}
+
+// negotiateVersion returns the version to issue a request for key at, given
+// the broker's loaded API versions and the request's max version.
+func (b *broker) negotiateVersion(v *brokerVersions, key, reqMax int16) (int16, error) {
+	// Max versions can only pin keys that kmsg knows of; any larger key is
+	// a request that kmsg does not model, which we allow through.
+	if key < 0 || int(key) >= v.len() || key <= kmsg.MaxKey && b.cl.cfg.maxVersions != nil && !b.cl.cfg.maxVersions.HasKey(key) {
+		return 0, errUnknownRequestKey
+	}
+
+	// If v.versions[0] is non-negative, then we loaded API
+	// versions. If the version for this request is negative, we
+	// know the broker cannot handle this request.
+	if v.versions[0] >= 0 && v.versions[key] < 0 {
+		return 0, errBrokerTooOld
+	}
+
+	ourMax := reqMax
+	if b.cl.cfg.maxVersions != nil {
+		userMax, exists := b.cl.cfg.maxVersions.LookupMaxKeyVersion(key)
+		if exists && userMax < ourMax {
+			ourMax = userMax
+		}
+	}
+
+	// If brokerMax is negative at this point, we have no api
+	// versions because the client is pinned pre 0.10.0 and we
+	// stick with our max.
+	version := ourMax
+	if brokerMax := v.versions[key]; brokerMax >= 0 && brokerMax < ourMax {
+		version = brokerMax
+	}
+
+	// If the version now (after potential broker downgrading) is
+	// lower than we desire, we fail the request for the broker is
+	// too old.
+	if b.cl.cfg.minVersions != nil {
+		minVersion, minVersionExists := b.cl.cfg.minVersions.LookupMaxKeyVersion(key)
+		if minVersionExists && version < minVersion {
+			return 0, errBrokerTooOld
+		}
+	}
+	return version, nil
+}
 
+// writeReqs writes prepared requests that all use the same connection.
+func (b *broker) writeReqs(ps []preparedReq) {
+	cxn := ps[0].cxn
+	if !cxn.expiry.IsZero() && time.Now().After(cxn.expiry) {
+		// If we are after the reauth time, try to reauth. We
+		// can only have an expiry if we went the authenticate
+		// flow, so we know we are authenticating again.
+		// For KIP-368.
+		cxn.cl.cfg.logger.Log(LogLevelDebug, "sasl expiry limit reached, reauthenticating", "broker", logID(cxn.b.meta.NodeID))
+		if err := cxn.sasl(); err != nil {
+			for _, p := range ps {
+				p.pr.promise(nil, err)
+			}
+			cxn.die()
+			return
+		}
+	}
+
+	if len(ps) > 1 {
+		cxn.writeCoalesced(ps)
+		return
+	}
+
+	p := &ps[0]
+	pr, req := p.pr, p.pr.req
+	corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(pr.ctx, pr.enqueue, req, p.recordWritten())
 	if writeErr != nil {
-		pr.promise(nil, writeErr)
 		cxn.die()
+	}
+	p.finish(corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue)
+}
+
+// finish calls the request's promise if writing failed or if there is no
+// response, and otherwise waits for the response.
+func (p *preparedReq) finish(corrID int32, bytesWritten int, writeErr error, writeWait, timeToWrite time.Duration, readEnqueue time.Time) {
+	pr, cxn, req := p.pr, p.cxn, p.pr.req
+	pr.promise = cxn.recordPromise(req, p.written, corrID, bytesWritten, pr.enqueue, pr.promise)
+
+	if writeErr != nil {
+		pr.promise(nil, writeErr)
 		cxn.hookWriteE2E(req.Key(), bytesWritten, writeWait, timeToWrite, writeErr)
 		return
 	}
 
-	if isNoResp {
-		pr.promise(noResp, nil)
+	if p.isNoResp {
+		pr.promise(p.noResp, nil)
 		cxn.hookWriteE2E(req.Key(), bytesWritten, writeWait, timeToWrite, writeErr)
 		return
 	}
@@ -424,31 +514,42 @@ func (p bufPool) put(b []byte) { p.p.Put(&b) }
 
 // loadConection returns the broker's connection, creating it if necessary
 // and returning an error of if that fails.
-func (b *broker) loadConnection(ctx context.Context, req kmsg.Request) (*brokerCxn, error) {
-	var (
-		pcxn         = &b.cxnNormal
-		isProduceCxn bool // see docs on brokerCxn.discard for why we do this
-		reqKey       = req.Key()
-		_, isTimeout = req.(kmsg.TimeoutRequest)
-	)
-	switch {
+// cxnSlot returns the connection slot that a request is issued on.
+func (b *broker) cxnSlot(req kmsg.Request) **brokerCxn {
+	_, isTimeout := req.(kmsg.TimeoutRequest)
+	switch reqKey := req.Key(); {
 	case reqKey == 0:
-		pcxn = &b.cxnProduce
-		isProduceCxn = true
+		return &b.cxnProduce
 	case reqKey == 1:
-		pcxn = &b.cxnFetch
+		return &b.cxnFetch
 	case reqKey == 11 || reqKey == 14: // join || sync
-		pcxn = &b.cxnGroup
+		return &b.cxnGroup
 	case isTimeout:
-		pcxn = &b.cxnSlow
+		return &b.cxnSlow
+	default:
+		return &b.cxnNormal
 	}
+}
+
+func (b *broker) loadConnection(ctx context.Context, req kmsg.Request) (*brokerCxn, error) {
+	var (
+		pcxn         = b.cxnSlot(req)
+		isProduceCxn = pcxn == &b.cxnProduce // see docs on brokerCxn.discard for why we do this
+	)
 
 	if *pcxn != nil && atomic.LoadInt32(&(*pcxn).dead) == 0 {
 		return *pcxn, nil
 	}
+	if cxn := b.cappedCxn(pcxn); cxn != nil {
+		return cxn, nil
+	}
 
+	if !b.circuitAllow() {
+		return nil, ErrBrokerCircuitOpen
+	}
 	conn, err := b.connect(ctx)
 	if err != nil {
+		b.circuitDone(err)
 		return nil, err
 	}
 
@@ -460,12 +561,15 @@ func (b *broker) loadConnection(ctx context.Context, req kmsg.Request) (*brokerC
 		conn:   conn,
 		deadCh: make(chan struct{}),
 	}
-	if err = cxn.init(isProduceCxn); err != nil {
+	err = cxn.init(isProduceCxn)
+	b.circuitDone(err)
+	if err != nil {
 		b.cl.cfg.logger.Log(LogLevelDebug, "connection initialization failed", "addr", b.addr, "broker", logID(b.meta.NodeID), "err", err)
 		cxn.closeConn()
 		return nil, err
 	}
 	b.cl.cfg.logger.Log(LogLevelDebug, "connection initialized successfully", "addr", b.addr, "broker", logID(b.meta.NodeID))
+	atomic.AddInt32(&b.cl.openCxns, 1)
 
 	b.reapMu.Lock()
 	defer b.reapMu.Unlock()
@@ -473,6 +577,63 @@ func (b *broker) loadConnection(ctx context.Context, req kmsg.Request) (*brokerC
 	return cxn, nil
 }
 
+// isUncappedSlot returns whether a connection slot is exempt from
+// MaxBrokerConnections: the group slot is exempt so that long blocking join
+// and sync requests cannot hold up other requests, and the produce slot is
+// exempt with acks=0 because nothing is read from that connection.
+func (b *broker) isUncappedSlot(pcxn **brokerCxn) bool {
+	return pcxn == &b.cxnGroup || pcxn == &b.cxnProduce && b.cl.cfg.acks.val == 0
+}
+
+// cappedCxn returns an existing live connection to pipeline a request on if
+// the broker is at MaxBrokerConnections and the request's connection slot is
+// capped, or nil if a new connection can be opened.
+//
+// This is only called from loadConnection, which is called serially in
+// handleReqs, so connections cannot be opened concurrently with counting.
+func (b *broker) cappedCxn(pcxn **brokerCxn) *brokerCxn {
+	max := b.cl.cfg.maxBrokerCxns
+	if max <= 0 || b.isUncappedSlot(pcxn) {
+		return nil
+	}
+
+	b.reapMu.Lock()
+	defer b.reapMu.Unlock()
+
+	var (
+		open  int
+		share *brokerCxn
+	)
+	// We prefer sharing the normal connection, which has the fastest
+	// requests, and the fetch connection least, since fetches long poll.
+	for _, slot := range []**brokerCxn{
+		&b.cxnNormal,
+		&b.cxnSlow,
+		&b.cxnProduce,
+		&b.cxnFetch,
+	} {
+		cxn := *slot
+		if b.isUncappedSlot(slot) || cxn == nil || atomic.LoadInt32(&cxn.dead) == 1 {
+			continue
+		}
+		open++
+		if share == nil {
+			share = cxn
+		}
+	}
+	if open < max {
+		return nil
+	}
+	return share
+}
+
+// OpenConnections returns the number of connections the client currently has
+// open across all brokers. This can be used as a gauge alongside
+// MaxBrokerConnections to monitor file descriptor usage.
+func (cl *Client) OpenConnections() int {
+	return int(atomic.LoadInt32(&cl.openCxns))
+}
+
 func (cl *Client) reapConnectionsLoop() {
 	idleTimeout := cl.cfg.connIdleTimeout
 	if idleTimeout < 0 { // impossible due to cfg.validate, but just in case
@@ -532,6 +693,9 @@ func (b *broker) reapConnections(idleTimeout time.Duration) (total int) {
 		//
 		// - produce can write but never read
 		// - fetch can hang for a while reading (infrequent writes)
+		//
+		// We never reap a connection that still has requests awaiting
+		// responses, even if it is between reads.
 
 		lastWrite := time.Unix(0, atomic.LoadInt64(&cxn.lastWrite))
 		lastRead := time.Unix(0, atomic.LoadInt64(&cxn.lastRead))
@@ -539,7 +703,7 @@ func (b *broker) reapConnections(idleTimeout time.Duration) (total int) {
 		writeIdle := time.Since(lastWrite) > idleTimeout && atomic.LoadUint32(&cxn.writing) == 0
 		readIdle := time.Since(lastRead) > idleTimeout && atomic.LoadUint32(&cxn.reading) == 0
 
-		if writeIdle && readIdle {
+		if writeIdle && readIdle && !cxn.resps.inflight() {
 			cxn.die()
 			total++
 		}
@@ -551,7 +715,7 @@ func (b *broker) reapConnections(idleTimeout time.Duration) (total int) {
 func (b *broker) connect(ctx context.Context) (net.Conn, error) {
 	b.cl.cfg.logger.Log(LogLevelDebug, "opening connection to broker", "addr", b.addr, "broker", logID(b.meta.NodeID))
 	start := time.Now()
-	conn, err := b.cl.cfg.dialFn(ctx, "tcp", b.addr)
+	conn, err := b.cl.dial(ctx, b.addr)
 	since := time.Since(start)
 	b.cl.cfg.hooks.each(func(h Hook) {
 		if h, ok := h.(HookBrokerConnect); ok {
@@ -569,6 +733,82 @@ func (b *broker) connect(ctx context.Context) (net.Conn, error) {
 	return conn, nil
 }
 
+// dialHostKey is a context key for the original host of a broker when dialing
+// a resolved address, so that DialTLSConfig can still use the host as the
+// TLS ServerName.
+type dialHostKey struct{}
+
+// dial dials addr. If the client is configured to resolve hosts itself, the
+// host is resolved on every dial and each address is tried in order until one
+// succeeds.
+func (cl *Client) dial(ctx context.Context, addr string) (net.Conn, error) {
+	cfg := &cl.cfg
+	if cfg.resolveFn == nil && cfg.addrPreference == PreferAnyAddress {
+		return cfg.dialFn(ctx, "tcp", addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to split host:port for dialing: %w", err)
+	}
+	if net.ParseIP(host) != nil {
+		return cfg.dialFn(ctx, "tcp", addr)
+	}
+
+	resolve := cfg.resolveFn
+	if resolve == nil {
+		resolve = net.DefaultResolver.LookupHost
+	}
+	ips, err := resolve(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("unable to resolve %s: no addresses returned", host)
+	}
+	ips = orderAddrs(ips, cfg.addrPreference)
+
+	ctx = context.WithValue(ctx, dialHostKey{}, host)
+	for _, ip := range ips {
+		var conn net.Conn
+		conn, err = cfg.dialFn(ctx, "tcp", net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		cfg.logger.Log(LogLevelDebug, "unable to dial resolved broker address", "host", host, "ip", ip, "err", err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// orderAddrs returns the addresses ordered by preference, keeping the
+// resolved order within each address family.
+func orderAddrs(ips []string, pref AddressPreference) []string {
+	if pref == PreferAnyAddress {
+		return ips
+	}
+	var v4, v6, other []string
+	for _, ip := range ips {
+		switch parsed := net.ParseIP(ip); {
+		case parsed == nil:
+			other = append(other, ip)
+		case parsed.To4() != nil:
+			v4 = append(v4, ip)
+		default:
+			v6 = append(v6, ip)
+		}
+	}
+	ordered := make([]string, 0, len(ips))
+	if pref == PreferIPv4 {
+		ordered = append(append(ordered, v4...), v6...)
+	} else {
+		ordered = append(append(ordered, v6...), v4...)
+	}
+	return append(ordered, other...)
+}
+
 // brokerCxn manages an actual connection to a Kafka broker. This is separate
 // the broker struct to allow lazy connection (re)creation.
 type brokerCxn struct {
@@ -617,7 +857,7 @@ func (cxn *brokerCxn) init(isProduceCxn bool) error {
 		} else {
 			// We have a max versions, and it indicates no support
 			// for ApiVersions. We just store a default -1 set.
-			cxn.b.storeVersions(newBrokerVersions())
+			cxn.b.storeVersions(newBrokerVersions(kmsg.MaxKey))
 		}
 	}
 
@@ -654,7 +894,7 @@ start:
 	req.ClientSoftwareName = cxn.cl.cfg.softwareName
 	req.ClientSoftwareVersion = cxn.cl.cfg.softwareVersion
 	cxn.cl.cfg.logger.Log(LogLevelDebug, "issuing api versions request", "broker", logID(cxn.b.meta.NodeID), "version", maxVersion)
-	corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(nil, time.Now(), req)
+	corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(nil, time.Now(), req, nil)
 	if writeErr != nil {
 		cxn.hookWriteE2E(req.Key(), bytesWritten, writeWait, timeToWrite, writeErr)
 		return writeErr
@@ -700,9 +940,15 @@ start:
 		return errors.New("ApiVersions response invalidly contained no ApiKeys")
 	}
 
-	v := newBrokerVersions()
+	var maxKey int16
+	for _, key := range resp.ApiKeys {
+		if key.ApiKey > maxKey {
+			maxKey = key.ApiKey
+		}
+	}
+	v := newBrokerVersions(maxKey)
 	for _, key := range resp.ApiKeys {
-		if key.ApiKey > kmsg.MaxKey || key.ApiKey < 0 {
+		if key.ApiKey < 0 {
 			continue
 		}
 		v.versions[key.ApiKey] = key.MaxVersion
@@ -726,7 +972,7 @@ start:
 		req.Mechanism = mechanism.Name()
 		req.Version = v.versions[req.Key()]
 		cxn.cl.cfg.logger.Log(LogLevelDebug, "issuing SASLHandshakeRequest", "broker", logID(cxn.b.meta.NodeID))
-		corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(nil, time.Now(), req)
+		corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(nil, time.Now(), req, nil)
 		if writeErr != nil {
 			cxn.hookWriteE2E(req.Key(), bytesWritten, writeWait, timeToWrite, writeErr)
 			return writeErr
@@ -814,7 +1060,7 @@ func (cxn *brokerCxn) doSasl(authenticate bool) error {
 			req.Version = cxn.b.loadVersions().versions[req.Key()]
 			cxn.cl.cfg.logger.Log(LogLevelDebug, "issuing SASLAuthenticate", "broker", logID(cxn.b.meta.NodeID), "version", req.Version, "step", step)
 
-			corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(nil, time.Now(), req)
+			corrID, bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeRequest(nil, time.Now(), req, nil)
 
 			// As mentioned above, we could have one final write
 			// without reading a response back (kerberos). If this
@@ -856,20 +1102,35 @@ func (cxn *brokerCxn) doSasl(authenticate bool) error {
 	}
 
 	if lifetimeMillis > 0 {
-		// If we have a lifetime, we take 1s off of it to account
-		// for some processing lag or whatever.
+		// If we have a lifetime, we reauthenticate a bit before it
+		// expires to account for some processing lag or whatever,
+		// and to give mechanisms with expiring credentials (i.e.,
+		// oauth tokens) time to refresh before the broker closes
+		// the connection.
 		// A better thing to return in the auth response would
 		// have been the deadline, but we are here now.
 		if lifetimeMillis < 5000 {
 			return fmt.Errorf("invalid short sasl lifetime millis %d", lifetimeMillis)
 		}
 		now := time.Now()
-		cxn.expiry = now.Add(time.Duration(lifetimeMillis)*time.Millisecond - time.Second)
+		cxn.expiry = now.Add(saslReauthIn(lifetimeMillis))
 		cxn.cl.cfg.logger.Log(LogLevelDebug, "sasl has a limited lifetime", "broker", logID(cxn.b.meta.NodeID), "reauthenticate_in", cxn.expiry.Sub(now))
 	}
 	return nil
 }
 
+// saslReauthIn returns how long from now we should reauthenticate for a sasl
+// session with the given lifetime: 10% of the lifetime early, but at least 1s
+// early.
+func saslReauthIn(lifetimeMillis int64) time.Duration {
+	lifetime := time.Duration(lifetimeMillis) * time.Millisecond
+	early := lifetime / 10
+	if early < time.Second {
+		early = time.Second
+	}
+	return lifetime - early
+}
+
 // Some internal requests use the client context to issue requests, so if the
 // client is closed, this select case can be selected. We want to return the
 // proper error.
@@ -884,7 +1145,9 @@ func maybeUpdateCtxErr(clientCtx, reqCtx context.Context, err *error) {
 
 // writeRequest writes a message request to the broker connection, bumping the
 // connection's correlation ID as appropriate for the next write.
-func (cxn *brokerCxn) writeRequest(ctx context.Context, enqueuedForWritingAt time.Time, req kmsg.Request) (corrID int32, bytesWritten int, writeErr error, writeWait, timeToWrite time.Duration, readEnqueue time.Time) {
+// writeRequest writes req, and if written is non-nil, saves a copy of the
+// written bytes to it.
+func (cxn *brokerCxn) writeRequest(ctx context.Context, enqueuedForWritingAt time.Time, req kmsg.Request, written *[]byte) (corrID int32, bytesWritten int, writeErr error, writeWait, timeToWrite time.Duration, readEnqueue time.Time) {
 	// A nil ctx means we cannot be throttled.
 	if ctx != nil {
 		throttleUntil := time.Unix(0, atomic.LoadInt64(&cxn.throttleUntil))
@@ -914,11 +1177,26 @@ func (cxn *brokerCxn) writeRequest(ctx context.Context, enqueuedForWritingAt tim
 		cxn.corrID,
 	)
 
+	if written != nil {
+		*written = append([]byte(nil), buf...)
+	}
+
 	_, wt := cxn.cl.connTimeouter.timeouts(req)
 	bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue = cxn.writeConn(ctx, buf, wt, enqueuedForWritingAt)
 
 	cxn.cl.bufPool.put(buf)
 
+	cxn.hookWrite(req, bytesWritten, writeWait, timeToWrite, writeErr)
+
+	if writeErr != nil {
+		return
+	}
+	corrID = cxn.corrID
+	cxn.corrID = nextCorrID(cxn.corrID)
+	return
+}
+
+func (cxn *brokerCxn) hookWrite(req kmsg.Request, bytesWritten int, writeWait, timeToWrite time.Duration, writeErr error) {
 	cxn.cl.cfg.hooks.each(func(h Hook) {
 		if h, ok := h.(HookBrokerWrite); ok {
 			h.OnBrokerWrite(cxn.b.meta, req.Key(), bytesWritten, writeWait, timeToWrite, writeErr)
@@ -927,13 +1205,95 @@ func (cxn *brokerCxn) writeRequest(ctx context.Context, enqueuedForWritingAt tim
 	if logger := cxn.cl.cfg.logger; logger.Level() >= LogLevelDebug {
 		logger.Log(LogLevelDebug, fmt.Sprintf("wrote %s v%d", kmsg.NameForKey(req.Key()), req.GetVersion()), "broker", logID(cxn.b.meta.NodeID), "bytes_written", bytesWritten, "write_wait", writeWait, "time_to_write", timeToWrite, "err", writeErr)
 	}
+}
 
-	if writeErr != nil {
-		return
+// maxCoalescedWriteBytes bounds how many bytes of requests are coalesced into
+// one write. Kafka does not read a connection's next request until it has
+// replied to the prior one, and we do not read replies until our write
+// completes, so a coalesced write must be small enough to always fit in
+// socket buffers. A single request larger than this is written alone.
+const maxCoalescedWriteBytes = 16 << 10
+
+// writeCoalesced writes many requests in as few writes as possible and then
+// finishes each request. Requests are serialized in order with increasing
+// correlation IDs, so responses are read in the order requests were queued.
+//
+// Callers must ensure the connection is not throttled; we do not wait here.
+func (cxn *brokerCxn) writeCoalesced(ps []preparedReq) {
+	buf := cxn.cl.bufPool.get()[:0]
+	defer func() { cxn.cl.bufPool.put(buf) }()
+
+	ends := make([]int, 0, len(ps))
+	for len(ps) > 0 {
+		var (
+			corrID  = cxn.corrID
+			timeout time.Duration
+		)
+		buf, ends = buf[:0], ends[:0]
+		for _, p := range ps {
+			start := len(buf)
+			// AppendRequest writes the request size at the front of
+			// the slice it is given, so we give it an empty slice.
+			buf = append(buf, cxn.cl.reqFormatter.AppendRequest(buf[start:start], p.pr.req, corrID)...)
+			if len(ends) > 0 && len(buf) > maxCoalescedWriteBytes {
+				buf = buf[:start]
+				break
+			}
+			ends = append(ends, len(buf))
+			corrID = nextCorrID(corrID)
+			if _, wt := cxn.cl.connTimeouter.timeouts(p.pr.req); wt > timeout {
+				timeout = wt
+			}
+		}
+
+		firstEnqueue := ps[0].pr.enqueue
+		bytesWritten, writeErr, writeWait, timeToWrite, readEnqueue := cxn.writeConn(nil, buf, timeout, firstEnqueue)
+		writeStart := firstEnqueue.Add(writeWait)
+		if writeErr != nil {
+			cxn.die()
+		}
+
+		var start int
+		for i, end := range ends {
+			p := &ps[i]
+			written := bytesWritten - start
+			if written > end-start {
+				written = end - start
+			} else if written < 0 {
+				written = 0
+			}
+			if w := p.recordWritten(); w != nil {
+				*w = append([]byte(nil), buf[start:end]...)
+			}
+			start = end
+
+			wait := writeStart.Sub(p.pr.enqueue)
+			cxn.hookWrite(p.pr.req, written, wait, timeToWrite, writeErr)
+			id := cxn.corrID
+			if writeErr == nil {
+				cxn.corrID = nextCorrID(cxn.corrID)
+			}
+			p.finish(id, written, writeErr, wait, timeToWrite, readEnqueue)
+		}
+		ps = ps[len(ends):]
+
+		if writeErr != nil {
+			for i := range ps {
+				ps[i].finish(0, 0, writeErr, time.Since(ps[i].pr.enqueue), 0, time.Time{})
+			}
+			return
+		}
 	}
-	corrID = cxn.corrID
-	cxn.corrID++
-	return
+}
+
+// nextCorrID returns the correlation ID to use after id. IDs increase
+// monotonically per connection and wrap back to zero rather than overflowing
+// to negative IDs.
+func nextCorrID(id int32) int32 {
+	if id == math.MaxInt32 {
+		return 0
+	}
+	return id + 1
 }
 
 func (cxn *brokerCxn) writeConn(ctx context.Context, buf []byte, timeout time.Duration, enqueuedForWritingAt time.Time) (bytesWritten int, writeErr error, writeWait, timeToWrite time.Duration, readEnqueue time.Time) {
@@ -1124,7 +1484,7 @@ func (cxn *brokerCxn) readResponse(
 	}
 	gotID := int32(binary.BigEndian.Uint32(buf))
 	if gotID != corrID {
-		return nil, errCorrelationIDMismatch
+		return nil, fmt.Errorf("%w: got %d, expected %d", errCorrelationIDMismatch, gotID, corrID)
 	}
 	// If the response header is flexible, we skip the tags at the end of
 	// it. They are currently unused.
@@ -1155,6 +1515,7 @@ func (cxn *brokerCxn) die() {
 	if cxn == nil || atomic.SwapInt32(&cxn.dead, 1) == 1 {
 		return
 	}
+	atomic.AddInt32(&cxn.cl.openCxns, -1)
 	cxn.closeConn()
 	cxn.resps.die()
 }
@@ -1326,15 +1687,24 @@ func (cxn *brokerCxn) handleResp(pr promisedResp) {
 	// Any response that can cause throttling satisfies the
 	// kmsg.ThrottleResponse interface. We check that here.
 	if readErr == nil {
+		// A response means the broker is reachable, even if new
+		// connections to it have been failing.
+		cxn.b.circuitDone(nil)
+
 		if throttleResponse, ok := pr.resp.(kmsg.ThrottleResponse); ok {
 			millis, throttlesAfterResp := throttleResponse.Throttle()
 			if millis > 0 {
+				// Throttling is per connection: only further
+				// requests on this connection wait, requests
+				// to other brokers (or on other connections to
+				// this broker) are unaffected.
 				if throttlesAfterResp {
 					throttleUntil := time.Now().Add(time.Millisecond * time.Duration(millis)).UnixNano()
-					if throttleUntil > cxn.throttleUntil {
+					if throttleUntil > atomic.LoadInt64(&cxn.throttleUntil) {
 						atomic.StoreInt64(&cxn.throttleUntil, throttleUntil)
 					}
 				}
+				cxn.cl.cfg.logger.Log(LogLevelDebug, "broker is throttling us in response", "broker", logID(cxn.b.meta.NodeID), "req", kmsg.NameForKey(pr.resp.Key()), "throttle_millis", millis, "throttled_after_response", throttlesAfterResp)
 				cxn.cl.cfg.hooks.each(func(h Hook) {
 					if h, ok := h.(HookBrokerThrottle); ok {
 						h.OnBrokerThrottle(cxn.b.meta, time.Duration(millis)*time.Millisecond, throttlesAfterResp)
@@ -0,0 +1,437 @@
+package kgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestNextCorrID(t *testing.T) {
+	for _, test := range []struct {
+		in, exp int32
+	}{
+		{0, 1},
+		{41, 42},
+		{math.MaxInt32 - 1, math.MaxInt32},
+		{math.MaxInt32, 0},
+	} {
+		if got := nextCorrID(test.in); got != test.exp {
+			t.Errorf("nextCorrID(%d): got %d != exp %d", test.in, got, test.exp)
+		}
+	}
+}
+
+func TestReadResponseCorrelationIDMismatch(t *testing.T) {
+	cl, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	cxn := &brokerCxn{
+		conn: client,
+		cl:   cl,
+		b:    &broker{cl: cl, meta: BrokerMetadata{NodeID: 1}},
+	}
+
+	// A misbehaving broker replies to correlation ID 3 with ID 7.
+	go func() {
+		resp := make([]byte, 8)
+		binary.BigEndian.PutUint32(resp, 4)
+		binary.BigEndian.PutUint32(resp[4:], 7)
+		server.Write(resp)
+		binary.BigEndian.PutUint32(resp[4:], 4)
+		server.Write(resp)
+	}()
+
+	_, err = cxn.readResponse(nil, 18, 0, 3, false, time.Second, 0, 0, 0, time.Now())
+	if !errors.Is(err, errCorrelationIDMismatch) {
+		t.Fatalf("got err %v, exp correlation ID mismatch", err)
+	}
+	if !isRetriableBrokerErr(err) {
+		t.Error("correlation ID mismatch should be retriable on a new connection")
+	}
+
+	if _, err = cxn.readResponse(nil, 18, 0, 4, false, time.Second, 0, 0, 0, time.Now()); err != nil {
+		t.Errorf("got unexpected err %v for matching correlation ID", err)
+	}
+}
+
+// Flexible responses have a response header with tagged fields, which must
+// be skipped before decoding the body, even if the broker sends unknown tags.
+// ApiVersions responses never have a flexible header.
+func TestReadResponseFlexibleHeader(t *testing.T) {
+	cl, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	cxn := &brokerCxn{
+		conn: client,
+		cl:   cl,
+		b:    &broker{cl: cl, meta: BrokerMetadata{NodeID: 1}},
+	}
+
+	meta := kmsg.NewPtrMetadataResponse()
+	meta.Version = 12
+	meta.ClusterID = kmsg.StringPtr("cluster")
+	b := kmsg.NewMetadataResponseBroker()
+	b.NodeID = 3
+	b.Host = "host"
+	b.Port = 9092
+	meta.Brokers = append(meta.Brokers, b)
+
+	versions := kmsg.NewPtrApiVersionsResponse()
+	versions.Version = 3
+	versions.FinalizedFeaturesEpoch = 5
+
+	write := func(corrID int32, tags []byte, body kmsg.Response) {
+		buf := make([]byte, 8, 64)
+		binary.BigEndian.PutUint32(buf[4:], uint32(corrID))
+		buf = append(buf, tags...)
+		buf = body.AppendTo(buf)
+		binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+		server.Write(buf)
+	}
+	go func() {
+		write(1, []byte{0}, meta)                                 // flexible, no tags
+		write(2, []byte{2, 0, 1, 'x', 9, 3, 'a', 'b', 'c'}, meta) // flexible, two unknown tags
+		write(3, nil, versions)                                   // ApiVersions: never a flexible header
+	}()
+
+	for _, test := range []struct {
+		corrID   int32
+		flexible bool
+		into     kmsg.Response
+		exp      kmsg.Response
+	}{
+		{1, true, &kmsg.MetadataResponse{Version: 12}, meta},
+		{2, true, &kmsg.MetadataResponse{Version: 12}, meta},
+		{3, false, &kmsg.ApiVersionsResponse{Version: 3}, versions},
+	} {
+		raw, err := cxn.readResponse(nil, test.exp.Key(), test.exp.GetVersion(), test.corrID, test.flexible, time.Second, 0, 0, 0, time.Now())
+		if err != nil {
+			t.Fatalf("corr %d: unexpected read err: %v", test.corrID, err)
+		}
+		if err := test.into.ReadFrom(raw); err != nil {
+			t.Fatalf("corr %d: unexpected decode err: %v", test.corrID, err)
+		}
+		if !reflect.DeepEqual(test.into, test.exp) {
+			t.Errorf("corr %d: got %#v != exp %#v", test.corrID, test.into, test.exp)
+		}
+	}
+}
+
+func TestDialResolvedAddrs(t *testing.T) {
+	var resolves int
+	var dialed []string
+	cl, err := NewClient(
+		DialResolver(func(_ context.Context, host string) ([]string, error) {
+			resolves++
+			if host != "kafka.local" {
+				t.Errorf("resolving unexpected host %q", host)
+			}
+			return []string{"10.0.0.1", "fd00::1", "10.0.0.2"}, nil
+		}),
+		DialAddressPreference(PreferIPv6),
+		Dialer(func(ctx context.Context, _, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			if host, _ := ctx.Value(dialHostKey{}).(string); host != "kafka.local" && addr != "10.0.0.9:9092" {
+				t.Errorf("got original host %q in dial context", host)
+			}
+			if addr != "10.0.0.2:9092" {
+				return nil, errors.New("refused")
+			}
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	for i := 0; i < 2; i++ {
+		dialed = nil
+		conn, err := cl.dial(context.Background(), "kafka.local:9092")
+		if err != nil {
+			t.Fatalf("unexpected dial err: %v", err)
+		}
+		conn.Close()
+		exp := []string{"[fd00::1]:9092", "10.0.0.1:9092", "10.0.0.2:9092"}
+		if !reflect.DeepEqual(dialed, exp) {
+			t.Errorf("got dial order %v != exp %v", dialed, exp)
+		}
+	}
+	if resolves != 2 {
+		t.Errorf("got %d resolves != exp 2, addresses should not be cached", resolves)
+	}
+
+	dialed = nil
+	if _, err := cl.dial(context.Background(), "10.0.0.9:9092"); err == nil || len(dialed) != 1 {
+		t.Errorf("IP addresses should be dialed directly, got err %v, dialed %v", err, dialed)
+	}
+}
+
+func TestOrderAddrs(t *testing.T) {
+	ips := []string{"fd00::1", "10.0.0.1", "fd00::2", "10.0.0.2"}
+	for _, test := range []struct {
+		pref AddressPreference
+		exp  []string
+	}{
+		{PreferAnyAddress, ips},
+		{PreferIPv4, []string{"10.0.0.1", "10.0.0.2", "fd00::1", "fd00::2"}},
+		{PreferIPv6, []string{"fd00::1", "fd00::2", "10.0.0.1", "10.0.0.2"}},
+	} {
+		if got := orderAddrs(ips, test.pref); !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("pref %d: got %v != exp %v", test.pref, got, test.exp)
+		}
+	}
+}
+
+func TestSASLReauthIn(t *testing.T) {
+	for _, test := range []struct {
+		lifetimeMillis int64
+		exp            time.Duration
+	}{
+		{5000, 4 * time.Second},
+		{10000, 9 * time.Second},
+		{3600000, 54 * time.Minute},
+	} {
+		if got := saslReauthIn(test.lifetimeMillis); got != test.exp {
+			t.Errorf("lifetime %dms: got %v != exp %v", test.lifetimeMillis, got, test.exp)
+		}
+	}
+}
+
+// Idle connections are reaped, but never while a request is awaiting its
+// response.
+func TestReapConnectionsSkipsInflight(t *testing.T) {
+	cl, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	b := cl.newBroker(0, "localhost", 9092, nil)
+	newCxn := func() *brokerCxn {
+		c1, c2 := net.Pipe()
+		t.Cleanup(func() { c2.Close() })
+		return &brokerCxn{cl: cl, b: b, conn: c1, deadCh: make(chan struct{})}
+	}
+	b.cxnNormal, b.cxnFetch = newCxn(), newCxn()
+	b.cxnFetch.resps.push(promisedResp{})
+
+	if reaped := b.reapConnections(time.Millisecond); reaped != 1 {
+		t.Errorf("got %d reaped connections, exp 1", reaped)
+	}
+	if atomic.LoadInt32(&b.cxnNormal.dead) != 1 {
+		t.Error("idle connection was not reaped")
+	}
+	if atomic.LoadInt32(&b.cxnFetch.dead) != 0 {
+		t.Error("connection with an inflight request was reaped")
+	}
+}
+
+// Requests that kmsg does not model can be issued directly to a broker, and
+// are version negotiated like any other request.
+func TestBrokerRequestUnmodeledKey(t *testing.T) {
+	t.Parallel()
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			resp.ControllerID = 0
+			return resp
+		case *rawMessage:
+			return &rawMessage{body: append([]byte{byte(req.version)}, req.body...)}
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	})
+	defer cl.Close()
+
+	for _, b := range []*Broker{
+		cl.Broker(0),
+		cl.ControllerBroker(),
+	} {
+		kresp, err := b.RetriableRequest(context.Background(), &rawMessage{body: []byte("foo")})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		resp := kresp.(*rawMessage)
+		if exp := "\x01foo"; string(resp.body) != exp {
+			t.Errorf("got response body %q, exp %q (version 1 negotiated, body echoed)", resp.body, exp)
+		}
+	}
+}
+
+// holdingConn blocks the write that contains hold until release is closed,
+// and counts every write.
+type holdingConn struct {
+	net.Conn
+	hold    []byte
+	held    chan struct{}
+	release chan struct{}
+	writes  *int32
+}
+
+func (c *holdingConn) Write(b []byte) (int, error) {
+	atomic.AddInt32(c.writes, 1)
+	if bytes.Contains(b, c.hold) {
+		close(c.held)
+		<-c.release
+	}
+	return c.Conn.Write(b)
+}
+
+// Requests that queue behind a write to the same connection are written
+// together in one write, and their responses are still matched in order.
+func TestCoalescedWrites(t *testing.T) {
+	t.Parallel()
+	var (
+		writes  int32
+		held    = make(chan struct{})
+		release = make(chan struct{})
+	)
+	cl := newFakeBrokerClientConn(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *rawMessage:
+			return &rawMessage{body: req.body}
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	}, func(c net.Conn) net.Conn {
+		return &holdingConn{c, []byte("hold"), held, release, &writes}
+	})
+	defer cl.Close()
+
+	ctx := context.Background()
+	b := cl.Broker(0)
+	if _, err := b.RetriableRequest(ctx, &rawMessage{body: []byte("warm")}); err != nil {
+		t.Fatalf("unable to issue first request: %v", err)
+	}
+
+	const n = 5
+	errs := make(chan error, n+1)
+	issue := func(body string) {
+		kresp, err := b.Request(ctx, &rawMessage{body: []byte(body)})
+		if err == nil && string(kresp.(*rawMessage).body) != body {
+			err = fmt.Errorf("got response %q for request %q", kresp.(*rawMessage).body, body)
+		}
+		errs <- err
+	}
+	go issue("hold")
+	<-held
+
+	var br *broker
+	cl.brokersMu.RLock()
+	for _, candidate := range cl.brokers {
+		if candidate.meta.NodeID == 0 {
+			br = candidate
+		}
+	}
+	cl.brokersMu.RUnlock()
+	for i := 0; i < n; i++ {
+		go issue(fmt.Sprintf("req%d", i))
+	}
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		br.reqs.mu.Lock()
+		l := br.reqs.l
+		br.reqs.mu.Unlock()
+		if l == n+1 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("timed out waiting for requests to queue, %d queued", l)
+		}
+	}
+
+	before := atomic.LoadInt32(&writes)
+	close(release)
+	for i := 0; i < n+1; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&writes) - before; got != 1 {
+		t.Errorf("got %d writes for %d queued requests, exp 1", got, n)
+	}
+}
+
+// With MaxBrokerConnections, requests that would open a new connection to a
+// broker at the cap are pipelined on an existing connection, except for group
+// requests, which always have their own connection.
+func TestMaxBrokerConnections(t *testing.T) {
+	t.Parallel()
+	var dials int32
+	cl := newFakeBrokerClientConn(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.CreateTopicsRequest, *kmsg.JoinGroupRequest:
+			return req.ResponseKind()
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	}, func(c net.Conn) net.Conn {
+		atomic.AddInt32(&dials, 1)
+		return c
+	},
+		MaxBrokerConnections(1),
+	)
+
+	ctx := context.Background()
+	b := cl.Broker(0)
+	if _, err := b.Request(ctx, kmsg.NewPtrMetadataRequest()); err != nil {
+		t.Fatalf("unexpected metadata err: %v", err)
+	}
+	before := atomic.LoadInt32(&dials)
+	if got := cl.OpenConnections(); got != int(before) {
+		t.Errorf("got %d open connections, exp %d", got, before)
+	}
+
+	if _, err := b.Request(ctx, kmsg.NewPtrCreateTopicsRequest()); err != nil {
+		t.Fatalf("unexpected create topics err: %v", err)
+	}
+	if after := atomic.LoadInt32(&dials); after != before {
+		t.Errorf("got %d new dials for a request at the connection cap, exp 0", after-before)
+	}
+
+	if _, err := b.Request(ctx, kmsg.NewPtrJoinGroupRequest()); err != nil {
+		t.Fatalf("unexpected join group err: %v", err)
+	}
+	if after := atomic.LoadInt32(&dials); after != before+1 {
+		t.Errorf("got %d new dials for a group request, exp 1 (group connections are not capped)", after-before)
+	}
+	if got := cl.OpenConnections(); got != int(before)+1 {
+		t.Errorf("got %d open connections, exp %d", got, before+1)
+	}
+
+	cl.Close()
+	if got := cl.OpenConnections(); got != 0 {
+		t.Errorf("got %d open connections after close, exp 0", got)
+	}
+}
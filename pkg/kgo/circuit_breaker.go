@@ -0,0 +1,170 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BrokerCircuitState is the state of a broker's connection circuit breaker;
+// see BrokerCircuitBreaker.
+type BrokerCircuitState int8
+
+const (
+	// BrokerCircuitClosed is the normal state: connections are attempted
+	// as needed.
+	BrokerCircuitClosed BrokerCircuitState = iota
+	// BrokerCircuitOpen is the state after too many consecutive connection
+	// failures: no connections are attempted until the cooldown elapses,
+	// and requests to the broker fail with ErrBrokerCircuitOpen.
+	BrokerCircuitOpen
+	// BrokerCircuitHalfOpen is the state after the cooldown elapses: one
+	// connection attempt is allowed to test whether the broker recovered.
+	BrokerCircuitHalfOpen
+)
+
+func (s BrokerCircuitState) String() string {
+	switch s {
+	case BrokerCircuitClosed:
+		return "closed"
+	case BrokerCircuitOpen:
+		return "open"
+	case BrokerCircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker is a per-broker circuit breaker for connection attempts.
+type breaker struct {
+	mu sync.Mutex
+
+	state     BrokerCircuitState
+	failures  int           // consecutive connection failures
+	opens     int           // consecutive opens, for exponential cooldown
+	openUntil time.Time     // when an open circuit becomes half open
+	cooldown  time.Duration // the current cooldown
+	testing   bool          // whether a half open attempt is in flight
+}
+
+// circuitAllow returns whether a connection attempt is allowed, transitioning
+// an open circuit to half open if the cooldown elapsed. If an attempt is
+// allowed, circuitDone must be called with the result of the attempt.
+func (b *broker) circuitAllow() bool {
+	cfg := &b.cl.cfg
+	if cfg.breakerFailures <= 0 {
+		return true
+	}
+	br := &b.breaker
+	br.mu.Lock()
+
+	allow, halfOpened := true, false
+	switch br.state {
+	case BrokerCircuitOpen:
+		if time.Now().Before(br.openUntil) {
+			allow = false
+			break
+		}
+		b.setCircuitState(BrokerCircuitHalfOpen)
+		br.testing = true
+		halfOpened = true
+	case BrokerCircuitHalfOpen:
+		if br.testing {
+			allow = false // another goroutine is testing recovery
+			break
+		}
+		br.testing = true
+	}
+
+	br.mu.Unlock()
+	if halfOpened {
+		b.hookCircuitState(BrokerCircuitHalfOpen)
+	}
+	return allow
+}
+
+// circuitDone records the result of a connection attempt allowed by
+// circuitAllow, or, with a nil error, that a request to the broker succeeded.
+//
+// A connection attempt that failed because its context was canceled or the
+// client closed says nothing about the broker and is not counted.
+func (b *broker) circuitDone(err error) {
+	cfg := &b.cl.cfg
+	if cfg.breakerFailures <= 0 {
+		return
+	}
+	br := &b.breaker
+	br.mu.Lock()
+
+	br.testing = false
+	changed := false
+	switch {
+	case err == nil:
+		br.failures = 0
+		br.opens = 0
+		if br.state != BrokerCircuitClosed {
+			b.setCircuitState(BrokerCircuitClosed)
+			changed = true
+		}
+
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrClientClosed):
+
+	default:
+		br.failures++
+		if br.state == BrokerCircuitClosed && br.failures < cfg.breakerFailures {
+			break
+		}
+
+		// We either failed too many times while closed, or failed our
+		// half open test: open (again) with an exponentially growing
+		// cooldown.
+		br.opens++
+		br.cooldown = cfg.breakerMinCooldown
+		for i := 1; i < br.opens && br.cooldown < cfg.breakerMaxCooldown; i++ {
+			br.cooldown *= 2
+		}
+		if br.cooldown > cfg.breakerMaxCooldown {
+			br.cooldown = cfg.breakerMaxCooldown
+		}
+		br.openUntil = time.Now().Add(br.cooldown)
+		b.setCircuitState(BrokerCircuitOpen)
+		changed = true
+	}
+
+	state := br.state
+	br.mu.Unlock()
+	if changed {
+		b.hookCircuitState(state)
+	}
+}
+
+// setCircuitState sets and logs the state; this must be called with the
+// breaker mutex held. Callers call hookCircuitState after unlocking.
+func (b *broker) setCircuitState(state BrokerCircuitState) {
+	br := &b.breaker
+	br.state = state
+	switch state {
+	case BrokerCircuitOpen:
+		b.cl.cfg.logger.Log(LogLevelWarn, "opening broker circuit after consecutive connection failures, not connecting until the cooldown elapses",
+			"broker", logID(b.meta.NodeID),
+			"failures", br.failures,
+			"cooldown", br.cooldown,
+		)
+	default:
+		b.cl.cfg.logger.Log(LogLevelInfo, "broker circuit state changed", "broker", logID(b.meta.NodeID), "state", state)
+	}
+}
+
+// hookCircuitState calls HookBrokerCircuitState hooks; this must be called
+// without the breaker mutex held so that hooks can use the client.
+func (b *broker) hookCircuitState(state BrokerCircuitState) {
+	b.cl.cfg.hooks.each(func(h Hook) {
+		if h, ok := h.(HookBrokerCircuitState); ok {
+			h.OnBrokerCircuitState(b.meta, state)
+		}
+	})
+}
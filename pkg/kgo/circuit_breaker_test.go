@@ -0,0 +1,207 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+type circuitHook struct {
+	mu     sync.Mutex
+	states []BrokerCircuitState
+	b      *broker
+}
+
+func (h *circuitHook) OnBrokerCircuitState(_ BrokerMetadata, state BrokerCircuitState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.states = append(h.states, state)
+	if h.b != nil {
+		// Hooks are called without the breaker locked.
+		h.b.breaker.mu.Lock()
+		h.b.breaker.mu.Unlock()
+	}
+}
+
+func (h *circuitHook) last() BrokerCircuitState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.states) == 0 {
+		return -1
+	}
+	return h.states[len(h.states)-1]
+}
+
+func TestBrokerCircuitBreaker(t *testing.T) {
+	hook := new(circuitHook)
+	cl, err := NewClient(
+		BrokerCircuitBreaker(2, 20*time.Millisecond, 30*time.Millisecond),
+		WithHooks(hook),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+	b := &broker{cl: cl, meta: BrokerMetadata{NodeID: 1}}
+	hook.mu.Lock()
+	hook.b = b
+	hook.mu.Unlock()
+	fail := errors.New("dial failure")
+
+	attempt := func(err error) bool {
+		if !b.circuitAllow() {
+			return false
+		}
+		b.circuitDone(err)
+		return true
+	}
+
+	// Two failures open the circuit.
+	if !attempt(fail) || !attempt(fail) {
+		t.Fatal("expected attempts to be allowed while closed")
+	}
+	if attempt(nil) {
+		t.Fatal("expected attempt to be rejected while open")
+	}
+
+	// After the cooldown, one half open test is allowed; failing reopens
+	// with a longer (capped) cooldown.
+	time.Sleep(25 * time.Millisecond)
+	if !b.circuitAllow() {
+		t.Fatal("expected half open attempt to be allowed")
+	}
+	if b.circuitAllow() {
+		t.Fatal("expected concurrent half open attempt to be rejected")
+	}
+	b.circuitDone(fail)
+	if b.breaker.cooldown != 30*time.Millisecond {
+		t.Errorf("got cooldown %v != exp capped 30ms", b.breaker.cooldown)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if attempt(nil) {
+		t.Fatal("expected attempt to be rejected during the longer cooldown")
+	}
+
+	// A success closes the circuit and resets the failure count.
+	time.Sleep(10 * time.Millisecond)
+	if !attempt(nil) {
+		t.Fatal("expected half open attempt to be allowed")
+	}
+	if !attempt(fail) || !attempt(nil) {
+		t.Fatal("expected attempts to be allowed after closing")
+	}
+
+	exp := []BrokerCircuitState{
+		BrokerCircuitOpen,
+		BrokerCircuitHalfOpen,
+		BrokerCircuitOpen,
+		BrokerCircuitHalfOpen,
+		BrokerCircuitClosed,
+	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if len(hook.states) != len(exp) {
+		t.Fatalf("got states %v != exp %v", hook.states, exp)
+	}
+	for i := range exp {
+		if hook.states[i] != exp[i] {
+			t.Errorf("state %d: got %v != exp %v", i, hook.states[i], exp[i])
+		}
+	}
+}
+
+// A request failing on an open circuit moves on to the next broker, so that
+// one unreachable seed does not block requests to the others.
+func TestBrokerCircuitOpenSkippable(t *testing.T) {
+	if !isSkippableBrokerErr(ErrBrokerCircuitOpen) {
+		t.Error("an open circuit is not skippable")
+	}
+	if isRetriableBrokerErr(ErrBrokerCircuitOpen) {
+		t.Error("an open circuit is retriable on the same broker")
+	}
+}
+
+// Connection attempts that fail because the caller's context ended or the
+// client closed do not count as failures.
+func TestBrokerCircuitIgnoresContextErrors(t *testing.T) {
+	cl, err := NewClient(BrokerCircuitBreaker(1, time.Minute, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+	b := &broker{cl: cl, meta: BrokerMetadata{NodeID: 1}}
+
+	for _, err := range []error{
+		context.Canceled,
+		context.DeadlineExceeded,
+		fmt.Errorf("dial: %w", context.DeadlineExceeded),
+		ErrClientClosed,
+	} {
+		if !b.circuitAllow() {
+			t.Fatalf("circuit opened before %v", err)
+		}
+		b.circuitDone(err)
+	}
+	if b.breaker.state != BrokerCircuitClosed || b.breaker.failures != 0 {
+		t.Errorf("got state %v with %d failures, exp closed with none", b.breaker.state, b.breaker.failures)
+	}
+
+	// A half open test that is canceled allows another test.
+	b.circuitAllow()
+	b.circuitDone(errors.New("dial failure"))
+	b.breaker.mu.Lock()
+	b.breaker.openUntil = time.Now()
+	b.breaker.mu.Unlock()
+	if !b.circuitAllow() {
+		t.Fatal("expected half open attempt to be allowed")
+	}
+	b.circuitDone(context.Canceled)
+	if !b.circuitAllow() {
+		t.Error("expected another half open attempt after the first was canceled")
+	}
+}
+
+// A successful request on an existing connection closes an open circuit.
+func TestBrokerCircuitClosedByRequest(t *testing.T) {
+	t.Parallel()
+	hook := new(circuitHook)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		if req, ok := kreq.(*kmsg.MetadataRequest); ok {
+			return fakeMetadataResponse(req, 0)
+		}
+		return kreq.ResponseKind()
+	},
+		BrokerCircuitBreaker(1, time.Minute, time.Minute),
+		WithHooks(hook),
+	)
+	defer cl.Close()
+
+	ctx := context.Background()
+	br := cl.Broker(0)
+	if _, err := br.Request(ctx, kmsg.NewPtrMetadataRequest()); err != nil {
+		t.Fatal(err)
+	}
+	b, err := cl.brokerOrErr(ctx, 0, errUnknownBroker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another connection slot failing to connect opens the circuit.
+	b.circuitAllow()
+	b.circuitDone(errors.New("dial failure"))
+	if got := hook.last(); got != BrokerCircuitOpen {
+		t.Fatalf("got last state %v, exp open", got)
+	}
+
+	if _, err := br.Request(ctx, kmsg.NewPtrMetadataRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got := hook.last(); got != BrokerCircuitClosed {
+		t.Errorf("got last state %v, exp closed after a successful request", got)
+	}
+}
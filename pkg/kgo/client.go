@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kerr"
@@ -61,6 +62,8 @@ type Client struct {
 	controllerIDMu sync.Mutex
 	controllerID   int32
 
+	openCxns int32 // number of initialized, not yet dead connections
+
 	// The following two ensure that we only have one fetchBrokerMetadata
 	// at once. This avoids unnecessary broker metadata requests and
 	// metadata trampling.
@@ -75,14 +78,21 @@ type Client struct {
 
 	compressor   *compressor
 	decompressor *decompressor
+	decodeSem    chan struct{} // non-nil if MaxConcurrentDecodes is set
 
 	coordinatorsMu sync.Mutex
 	coordinators   map[coordinatorKey]*coordinatorLoad
 
+	// topicMeta caches results for TopicMetadata.
+	topicMetaMu sync.Mutex
+	topicMeta   map[string]cachedTopicMetadata
+
 	updateMetadataCh    chan string
 	updateMetadataNowCh chan string // like above, but with high priority
 	metawait            metawait
 	metadone            chan struct{}
+
+	metaSnapshot atomic.Value // *MetadataSnapshot, stored after every successful metadata update
 }
 
 func (cl *Client) idempotent() bool { return !cl.cfg.disableIdempotency }
@@ -131,17 +141,39 @@ func NewClient(opts ...Opt) (*Client, error) {
 			switch key {
 			case ((*kmsg.JoinGroupRequest)(nil)).Key(),
 				((*kmsg.SyncGroupRequest)(nil)).Key(),
-				((*kmsg.HeartbeatRequest)(nil)).Key():
+				((*kmsg.HeartbeatRequest)(nil)).Key(),
+				((*kmsg.ConsumerGroupHeartbeatRequest)(nil)).Key():
 				return cfg.sessionTimeout
 			}
 			return 30 * time.Second
 		}
 	}
 
+	if cfg.discoveryBackoff == nil {
+		cfg.discoveryBackoff = func(tries int, err error) time.Duration {
+			backoff := cfg.retryBackoff(tries)
+			if errors.Is(err, kerr.CoordinatorLoadInProgress) && backoff < time.Second {
+				backoff = time.Second
+			}
+			return backoff
+		}
+	}
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
+	// With acks, the broker waits up to the produce timeout before
+	// replying; a shorter delivery timeout can fail records while the
+	// broker may still be writing them. This is allowed, but likely not
+	// intended. Without acks, the produce timeout is unused.
+	if cfg.recordTimeout > 0 && cfg.acks.val != 0 && cfg.recordTimeout < cfg.produceTimeout {
+		cfg.logger.Log(LogLevelWarn, "record delivery timeout is less than the produce request timeout; records may fail while brokers are still writing them",
+			"record_delivery_timeout", cfg.recordTimeout,
+			"produce_request_timeout", cfg.produceTimeout,
+		)
+	}
+
 	seeds := make([]hostport, 0, len(cfg.seedBrokers))
 	for _, seedBroker := range cfg.seedBrokers {
 		hp, err := parseBrokerAddr(seedBroker)
@@ -182,7 +214,16 @@ func NewClient(opts ...Opt) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	if compressor != nil {
+		compressor.minBytes = int(cfg.minCompressBytes)
+		compressor.zstd = cfg.zstd
+	}
 	cl.compressor = compressor
+	cl.decompressor.zstd = cfg.zstd
+
+	if cfg.maxConcurrentDecodes > 0 {
+		cl.decodeSem = make(chan struct{}, cfg.maxConcurrentDecodes)
+	}
 
 	// Before we start any goroutines below, we must notify any interested
 	// hooks of our existence.
@@ -495,6 +536,9 @@ func (cl *Client) Close() {
 	for _, broker := range cl.brokers {
 		broker.stopForever()
 	}
+	for _, broker := range cl.seeds {
+		broker.stopForever()
+	}
 	cl.brokersMu.Unlock()
 
 	// Wait for metadata to quit so we know no more erroring topic
@@ -539,6 +583,7 @@ func (cl *Client) Close() {
 //     ListGroups
 //     DeleteRecords
 //     OffsetForLeaderEpoch
+//     WriteTxnMarkers
 //     DescribeConfigs
 //     AlterConfigs
 //     AlterReplicaLogDirs
@@ -548,12 +593,16 @@ func (cl *Client) Close() {
 //     DescribeProducers
 //     DescribeTransactions
 //     ListTransactions
+//     ConsumerGroupDescribe
 //
 // Kafka 3.0 introduced batch OffsetFetch and batch FindCoordinator requests.
 // This function is forward-compatible for the old, singular OffsetFetch and
-// FindCoordinator requests, but is not backward-compatible for batched
-// requests. It is recommended to only use the old format unless you know you
-// are speaking to Kafka 3.0+.
+// FindCoordinator requests. Batched FindCoordinator requests are also
+// backward-compatible: if the broker is too old to support batching, the
+// request is split into one request per key and the responses are merged.
+// Batched OffsetFetch requests are not backward-compatible; it is recommended
+// to only use the old OffsetFetch format unless you know you are speaking to
+// Kafka 3.0+.
 //
 // In short, this method tries to do the correct thing depending on what type
 // of request is being issued.
@@ -587,6 +636,35 @@ func (cl *Client) retriableBrokerFn(fn func() (*broker, error)) *retriable {
 	return &retriable{cl: cl, br: fn}
 }
 
+// retryBackoff returns how long to backoff before retrying the request with
+// the given key, using the discovery backoff for discovery requests and for
+// errors that require rediscovering a coordinator.
+func (cl *Client) retryBackoff(key int16, tries int, err error) time.Duration {
+	if isDiscovery(key, err) {
+		return cl.cfg.discoveryBackoff(tries, err)
+	}
+	return cl.cfg.retryBackoff(tries)
+}
+
+// isDiscovery returns whether retrying a request with the given key that
+// failed with err is retrying discovery.
+func isDiscovery(key int16, err error) bool {
+	switch key {
+	case ((*kmsg.MetadataRequest)(nil)).Key(),
+		((*kmsg.FindCoordinatorRequest)(nil)).Key():
+		return true
+	}
+	return errors.Is(err, kerr.CoordinatorLoadInProgress) ||
+		errors.Is(err, kerr.CoordinatorNotAvailable) ||
+		errors.Is(err, kerr.NotCoordinator)
+}
+
+// retriableDiscovery returns whether err is a discovery error that would be
+// retried if not for the request's retry timeout.
+func (cl *Client) retriableDiscovery(key int16, tries int, err error) bool {
+	return isDiscovery(key, err) && (cl.shouldRetry(tries, err) || cl.shouldRetryNext(tries, err))
+}
+
 func (cl *Client) shouldRetry(tries int, err error) bool {
 	return (kerr.IsRetriable(err) || isRetriableBrokerErr(err)) && int64(tries) < cl.cfg.retries
 }
@@ -633,8 +711,16 @@ start:
 
 	if err != nil || retryErr != nil {
 		if r.limitRetries == 0 || tries < r.limitRetries {
-			backoff := r.cl.cfg.retryBackoff(tries)
-			if retryTimeout == 0 || time.Now().Add(backoff).Sub(tryStart) <= retryTimeout {
+			backoffErr := err
+			if backoffErr == nil {
+				backoffErr = retryErr
+			}
+			backoff := r.cl.retryBackoff(req.Key(), tries, backoffErr)
+			if retryTimeout > 0 && time.Now().Add(backoff).Sub(tryStart) > retryTimeout {
+				if r.cl.retriableDiscovery(req.Key(), tries, backoffErr) {
+					err = &ErrDiscoveryTimeout{time.Since(tryStart), tries, backoffErr}
+				}
+			} else {
 				// If this broker / request had a retriable error, we can
 				// just retry now. If the error is *not* retriable but
 				// is a broker-specific network error, and the next
@@ -754,6 +840,7 @@ func (cl *Client) shardedRequest(ctx context.Context, req kmsg.Request) ([]Respo
 		*kmsg.ListGroupsRequest,              // key 16
 		*kmsg.DeleteRecordsRequest,           // key 21
 		*kmsg.OffsetForLeaderEpochRequest,    // key 23
+		*kmsg.WriteTxnMarkersRequest,         // key 27
 		*kmsg.DescribeConfigsRequest,         // key 32
 		*kmsg.AlterConfigsRequest,            // key 33
 		*kmsg.AlterReplicaLogDirsRequest,     // key 34
@@ -762,7 +849,8 @@ func (cl *Client) shardedRequest(ctx context.Context, req kmsg.Request) ([]Respo
 		*kmsg.IncrementalAlterConfigsRequest, // key 44
 		*kmsg.DescribeProducersRequest,       // key 61
 		*kmsg.DescribeTransactionsRequest,    // key 65
-		*kmsg.ListTransactionsRequest:        // key 66
+		*kmsg.ListTransactionsRequest,        // key 66
+		*kmsg.ConsumerGroupDescribeRequest:   // key 69
 		return cl.handleShardedReq(ctx, req)
 
 	// We support being forward-compatible with FindCoordinator, so we need
@@ -909,9 +997,10 @@ type coordinatorKey struct {
 }
 
 type coordinatorLoad struct {
-	done chan struct{}
-	node int32
-	err  error
+	done   chan struct{}
+	node   int32
+	err    error
+	pinned bool // if from PinGroupCoordinator or PinTxnCoordinator
 }
 
 // findCoordinator is allows FindCoordinator request to be forward compatible,
@@ -922,6 +1011,8 @@ func (cl *Client) findCoordinator(ctx context.Context, req *kmsg.FindCoordinator
 	if len(req.CoordinatorKeys) == 0 {
 		req.CoordinatorKeys = []string{req.CoordinatorKey}
 		compat = true
+	} else if !cl.supportsBatchedFindCoordinator() {
+		return cl.findCoordinatorSplit(ctx, req)
 	}
 	r := cl.retriable()
 	resp, err := req.RequestWith(ctx, r)
@@ -938,10 +1029,104 @@ func (cl *Client) findCoordinator(ctx context.Context, req *kmsg.FindCoordinator
 			resp.Host = first.Host
 			resp.Port = first.Port
 		}
+
+		// If the request was batched but the broker is too old to
+		// understand batching, the broker saw only the (unset)
+		// singular key. This only happens if we did not yet know
+		// the broker's versions; we fall back to one request per
+		// key and upconvert the results.
+		if !compat && resp.Version < 4 {
+			return cl.findCoordinatorSplit(ctx, req)
+		}
 	}
 	return r.last, resp, err
 }
 
+// supportsBatchedFindCoordinator returns whether a batched FindCoordinator
+// request (v4+) can be issued. Brokers we have not yet talked to are assumed
+// to support batching; findCoordinator falls back if the response proves
+// otherwise.
+func (cl *Client) supportsBatchedFindCoordinator() bool {
+	return cl.supportsKeyVersion(kmsg.FindCoordinator.Int16(), 4)
+}
+
+// supportsKeyVersion returns whether a request can be issued at version or
+// higher. This returns false if the user pinned the request below version, or
+// if any broker we have negotiated versions with does not support version.
+func (cl *Client) supportsKeyVersion(key, version int16) bool {
+	if cl.cfg.maxVersions != nil {
+		if max, exists := cl.cfg.maxVersions.LookupMaxKeyVersion(key); exists && max < version {
+			return false
+		}
+	}
+
+	cl.brokersMu.RLock()
+	defer cl.brokersMu.RUnlock()
+
+	for _, bs := range [][]*broker{cl.brokers, cl.seeds} {
+		for _, b := range bs {
+			if v := b.loadVersions(); v != nil && v.versions[0] >= 0 && v.versions[key] < version {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findCoordinatorSplit issues one FindCoordinator request per unique key in
+// a batched request, merging the results into a batched response. If any key
+// fails to be requested entirely, the response only contains the successful
+// keys and the first error is returned.
+func (cl *Client) findCoordinatorSplit(ctx context.Context, req *kmsg.FindCoordinatorRequest) (*broker, *kmsg.FindCoordinatorResponse, error) {
+	uniq := make(map[string]struct{}, len(req.CoordinatorKeys))
+	for _, key := range req.CoordinatorKeys {
+		uniq[key] = struct{}{}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		last   *broker
+		merged = kmsg.NewPtrFindCoordinatorResponse()
+		first  error
+	)
+	for key := range uniq {
+		sreq := kmsg.NewPtrFindCoordinatorRequest()
+		sreq.CoordinatorKey = key
+		sreq.CoordinatorType = req.CoordinatorType
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			br, resp, err := cl.findCoordinator(ctx, sreq)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if br != nil {
+				last = br
+			}
+			if err != nil {
+				if first == nil {
+					first = err
+				}
+				return
+			}
+			merged.Version = resp.Version
+			merged.ThrottleMillis = resp.ThrottleMillis
+			c := kmsg.NewFindCoordinatorResponseCoordinator()
+			c.Key = sreq.CoordinatorKey
+			c.NodeID = resp.NodeID
+			c.Host = resp.Host
+			c.Port = resp.Port
+			c.ErrorCode = resp.ErrorCode
+			c.ErrorMessage = resp.ErrorMessage
+			merged.Coordinators = append(merged.Coordinators, c)
+		}()
+	}
+	wg.Wait()
+
+	return last, merged, first
+}
+
 func (cl *Client) deleteStaleCoordinatorIfEqual(key coordinatorKey, current *coordinatorLoad) {
 	cl.coordinatorsMu.Lock()
 	defer cl.coordinatorsMu.Unlock()
@@ -986,7 +1171,14 @@ start:
 		// that coordinator. We delete the stale coordinator here and
 		// retry once. The retry will force a coordinator reload, and
 		// everything will be fresh. Any errors after that we keep.
-		b, err := cl.brokerOrErr(nil, c.node, &errUnknownCoordinator{c.node, key})
+		//
+		// A pinned coordinator may have been pinned before metadata
+		// has loaded, so we allow a metadata load for it.
+		var brokerCtx context.Context
+		if c.pinned {
+			brokerCtx = ctx
+		}
+		b, err := cl.brokerOrErr(brokerCtx, c.node, &errUnknownCoordinator{c.node, key})
 		if err != nil && !restarted {
 			restarted = true
 			cl.deleteStaleCoordinatorIfEqual(key, c)
@@ -1013,10 +1205,52 @@ start:
 	return b, c.err
 }
 
+// PinGroupCoordinator uses the given broker as the coordinator for the group,
+// skipping FindCoordinator. This is an advanced option meant for testing and
+// for static deployments where the coordinator is known ahead of time; most
+// users should never need this.
+//
+// The pin is used in place of discovery until it is proven wrong: if the
+// broker replies NOT_COORDINATOR or COORDINATOR_NOT_AVAILABLE, or if the
+// broker does not exist in the cluster's metadata, the pin is dropped and the
+// client discovers the coordinator with FindCoordinator as usual. Pinning
+// replaces any coordinator already discovered for the group.
+func (cl *Client) PinGroupCoordinator(group string, node int32) {
+	cl.pinCoordinator(coordinatorKey{group, coordinatorTypeGroup}, node)
+}
+
+// PinTxnCoordinator uses the given broker as the coordinator for the
+// transactional ID, skipping FindCoordinator. This is the transactional
+// counterpart of PinGroupCoordinator; see its documentation for more details.
+func (cl *Client) PinTxnCoordinator(txnID string, node int32) {
+	cl.pinCoordinator(coordinatorKey{txnID, coordinatorTypeTxn}, node)
+}
+
+func (cl *Client) pinCoordinator(key coordinatorKey, node int32) {
+	c := &coordinatorLoad{
+		done:   make(chan struct{}),
+		node:   node,
+		pinned: true,
+	}
+	close(c.done)
+
+	cl.coordinatorsMu.Lock()
+	defer cl.coordinatorsMu.Unlock()
+	cl.coordinators[key] = c
+}
+
+// maybeDeleteStaleCoordinator returns whether err is a coordinator error that
+// should be retried, and deletes the cached coordinator if the error implies
+// the coordinator moved and must be rediscovered.
 func (cl *Client) maybeDeleteStaleCoordinator(name string, typ int8, err error) bool {
 	switch err {
+	case kerr.CoordinatorLoadInProgress:
+		// The coordinator is correct, it is just loading the group or
+		// txn state after an election. We retry against the same
+		// broker rather than rediscovering.
+		return true
+
 	case kerr.CoordinatorNotAvailable,
-		kerr.CoordinatorLoadInProgress,
 		kerr.NotCoordinator:
 
 		cl.coordinatorsMu.Lock()
@@ -1171,6 +1405,8 @@ func (cl *Client) handleCoordinatorReq(ctx context.Context, req kmsg.Request, ty
 		return cl.handleCoordinatorReqSimple(ctx, coordinatorTypeGroup, t.Group, req)
 	case *kmsg.HeartbeatRequest:
 		return cl.handleCoordinatorReqSimple(ctx, coordinatorTypeGroup, t.Group, req)
+	case *kmsg.ConsumerGroupHeartbeatRequest:
+		return cl.handleCoordinatorReqSimple(ctx, coordinatorTypeGroup, t.Group, req)
 	case *kmsg.LeaveGroupRequest:
 		return cl.handleCoordinatorReqSimple(ctx, coordinatorTypeGroup, t.Group, req)
 	case *kmsg.SyncGroupRequest:
@@ -1237,6 +1473,8 @@ func (cl *Client) handleReqWithCoordinator(
 			code = t.ErrorCode
 		case *kmsg.HeartbeatResponse:
 			code = t.ErrorCode
+		case *kmsg.ConsumerGroupHeartbeatResponse:
+			code = t.ErrorCode
 		case *kmsg.LeaveGroupResponse:
 			code = t.ErrorCode
 		case *kmsg.SyncGroupResponse:
@@ -1244,8 +1482,9 @@ func (cl *Client) handleReqWithCoordinator(
 
 		}
 
-		// ListGroups, OffsetFetch, DeleteGroups, DescribeGroups, and
-		// DescribeTransactions handled in sharding.
+		// ListGroups, OffsetFetch, DeleteGroups, DescribeGroups,
+		// ConsumerGroupDescribe, and DescribeTransactions handled in
+		// sharding.
 
 		if err := kerr.ErrorForCode(code); cl.maybeDeleteStaleCoordinator(name, typ, err) {
 			return err
@@ -1285,6 +1524,45 @@ func (cl *Client) DiscoveredBrokers() []*Broker {
 	return bs
 }
 
+// ControllerBroker returns a handle to the cluster controller to directly
+// issue requests to. The controller is looked up (and loaded if necessary)
+// each time a request is issued, so the handle remains valid if the
+// controller moves.
+func (cl *Client) ControllerBroker() *Broker {
+	return &Broker{
+		id:   -1,
+		cl:   cl,
+		load: cl.controller,
+	}
+}
+
+// GroupCoordinatorBroker returns a handle to the coordinator of the given
+// group to directly issue requests to. The coordinator is looked up (and
+// loaded if necessary) each time a request is issued.
+func (cl *Client) GroupCoordinatorBroker(group string) *Broker {
+	return cl.coordinatorBroker(coordinatorTypeGroup, group)
+}
+
+// TxnCoordinatorBroker returns a handle to the coordinator of the given
+// transactional ID to directly issue requests to. The coordinator is looked
+// up (and loaded if necessary) each time a request is issued.
+func (cl *Client) TxnCoordinatorBroker(txnID string) *Broker {
+	return cl.coordinatorBroker(coordinatorTypeTxn, txnID)
+}
+
+func (cl *Client) coordinatorBroker(typ int8, name string) *Broker {
+	return &Broker{
+		id: -1,
+		cl: cl,
+		load: func(ctx context.Context) (*broker, error) {
+			return cl.loadCoordinator(ctx, coordinatorKey{
+				name: name,
+				typ:  typ,
+			})
+		},
+	}
+}
+
 // SeedBrokers returns the all seed brokers.
 func (cl *Client) SeedBrokers() []*Broker {
 	cl.brokersMu.RLock()
@@ -1297,16 +1575,82 @@ func (cl *Client) SeedBrokers() []*Broker {
 	return bs
 }
 
+// FeatureVersions is the range of versions of a cluster feature.
+type FeatureVersions struct {
+	Min int16
+	Max int16
+}
+
+// ClusterFeatures contains the feature flags (KIP-584) a broker reports in
+// its ApiVersions response, such as "metadata.version" on KRaft clusters.
+type ClusterFeatures struct {
+	// Supported is the range of versions of each feature that the
+	// responding broker supports.
+	Supported map[string]FeatureVersions
+
+	// FinalizedEpoch is the epoch of the finalized features, or -1 if the
+	// broker does not know the finalized features.
+	FinalizedEpoch int64
+
+	// Finalized is the cluster-wide finalized range of versions of each
+	// feature. Tooling can check this to see whether the cluster as a
+	// whole has enabled a feature level.
+	Finalized map[string]FeatureVersions
+}
+
+// ClusterFeatures issues an ApiVersions request to a broker and returns the
+// features it reports. Brokers before Kafka 2.7 do not report features; for
+// these, the returned maps are empty and FinalizedEpoch is -1.
+func (cl *Client) ClusterFeatures(ctx context.Context) (ClusterFeatures, error) {
+	kresp, err := cl.Request(ctx, kmsg.NewPtrApiVersionsRequest())
+	if err != nil {
+		return ClusterFeatures{}, err
+	}
+	resp := kresp.(*kmsg.ApiVersionsResponse)
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return ClusterFeatures{}, err
+	}
+	return newClusterFeatures(resp), nil
+}
+
+func newClusterFeatures(resp *kmsg.ApiVersionsResponse) ClusterFeatures {
+	fs := ClusterFeatures{
+		Supported:      make(map[string]FeatureVersions, len(resp.SupportedFeatures)),
+		FinalizedEpoch: resp.FinalizedFeaturesEpoch,
+		Finalized:      make(map[string]FeatureVersions, len(resp.FinalizedFeatures)),
+	}
+	for _, f := range resp.SupportedFeatures {
+		fs.Supported[f.Name] = FeatureVersions{f.MinVersion, f.MaxVersion}
+	}
+	if fs.FinalizedEpoch >= 0 { // finalized features are only valid with a known epoch
+		for _, f := range resp.FinalizedFeatures {
+			fs.Finalized[f.Name] = FeatureVersions{f.MinVersionLevel, f.MaxVersionLevel}
+		}
+	}
+	return fs
+}
+
 // Broker pairs a broker ID with a client to directly issue requests to a
 // specific broker.
 type Broker struct {
 	id int32
 	cl *Client
+
+	// load, if non-nil, looks up the broker to use for every request,
+	// rather than using id.
+	load func(context.Context) (*broker, error)
 }
 
 // Request issues a request to a broker. If the broker does not exist in the
 // client, this returns an unknown broker error. Requests are not retried.
 //
+// Unlike Client.Request, this does not inspect the request type at all: the
+// request is simply version negotiated against the broker, written, and its
+// response read. This can be used to issue requests that kmsg does not yet
+// model (or vendor specific requests) by implementing kmsg.Request and
+// kmsg.Response yourself. The request is issued at the highest version that
+// both the request's MaxVersion and the broker support.
+//
 // The passed context can be used to cancel a request and return early.
 // Note that if the request is not canceled before it is written to Kafka,
 // you may just end up canceling and not receiving the response to what Kafka
@@ -1324,6 +1668,40 @@ func (b *Broker) RetriableRequest(ctx context.Context, req kmsg.Request) (kmsg.R
 	return b.request(true, ctx, req)
 }
 
+// NegotiatedVersion returns the version that a request for key would be
+// issued at to this broker: the highest version that kmsg, the client's max
+// versions, and the broker all support. The broker's API versions are cached
+// when the client first connects to it; if the client has not yet connected,
+// this connects first (issuing an ApiVersions request).
+//
+// This returns an error if the broker does not support the request at all,
+// or if the client's min versions are above what the broker supports.
+func (b *Broker) NegotiatedVersion(ctx context.Context, key int16) (int16, error) {
+	req := kmsg.RequestForKey(key)
+	if req == nil {
+		return 0, errUnknownRequestKey
+	}
+	br, err := b.loadBroker(ctx)
+	if err != nil {
+		return 0, err
+	}
+	v := br.loadVersions()
+	if v == nil {
+		if _, err := br.waitResp(ctx, kmsg.NewPtrApiVersionsRequest()); err != nil {
+			return 0, err
+		}
+		v = br.loadVersions()
+	}
+	return br.negotiateVersion(v, key, req.MaxVersion())
+}
+
+func (b *Broker) loadBroker(ctx context.Context) (*broker, error) {
+	if b.load != nil {
+		return b.load(ctx)
+	}
+	return b.cl.brokerOrErr(ctx, b.id, errUnknownBroker)
+}
+
 func (b *Broker) request(retry bool, ctx context.Context, req kmsg.Request) (kmsg.Response, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -1331,19 +1709,19 @@ func (b *Broker) request(retry bool, ctx context.Context, req kmsg.Request) (kms
 	var err error
 	done := make(chan struct{})
 
+	load := func() (*broker, error) { return b.loadBroker(ctx) }
+
 	go func() {
 		defer close(done)
 
 		if !retry {
 			var br *broker
-			br, err = b.cl.brokerOrErr(ctx, b.id, errUnknownBroker)
+			br, err = load()
 			if err == nil {
 				resp, err = br.waitResp(ctx, req)
 			}
 		} else {
-			resp, err = b.cl.retriableBrokerFn(func() (*broker, error) {
-				return b.cl.brokerOrErr(ctx, b.id, errUnknownBroker)
-			}).Request(ctx, req)
+			resp, err = b.cl.retriableBrokerFn(load).Request(ctx, req)
 		}
 	}()
 
@@ -1442,6 +1820,8 @@ func (cl *Client) handleShardedReq(ctx context.Context, req kmsg.Request) ([]Res
 		sharder = &deleteRecordsSharder{cl}
 	case *kmsg.OffsetForLeaderEpochRequest:
 		sharder = &offsetForLeaderEpochSharder{cl}
+	case *kmsg.WriteTxnMarkersRequest:
+		sharder = &writeTxnMarkersSharder{cl}
 	case *kmsg.DescribeConfigsRequest:
 		sharder = &describeConfigsSharder{cl}
 	case *kmsg.AlterConfigsRequest:
@@ -1460,6 +1840,8 @@ func (cl *Client) handleShardedReq(ctx context.Context, req kmsg.Request) ([]Res
 		sharder = &describeTransactionsSharder{cl}
 	case *kmsg.ListTransactionsRequest:
 		sharder = &listTransactionsSharder{cl}
+	case *kmsg.ConsumerGroupDescribeRequest:
+		sharder = &consumerGroupDescribeSharder{cl}
 	}
 
 	// If a request fails, we re-shard it (in case it needs to be split
@@ -1559,8 +1941,12 @@ func (cl *Client) handleShardedReq(ctx context.Context, req kmsg.Request) ([]Res
 				// If we failed to issue the request, we *maybe* will retry.
 				// We could have failed to even issue the request or receive
 				// a response, which is retriable.
-				backoff := cl.cfg.retryBackoff(tries)
-				if err != nil && (retryTimeout == 0 || time.Now().Add(backoff).Sub(start) < retryTimeout) && cl.shouldRetry(tries, err) && cl.waitTries(ctx, backoff) {
+				var backoff time.Duration
+				if err != nil {
+					backoff = cl.retryBackoff(myIssue.req.Key(), tries, err)
+				}
+				timedOut := retryTimeout > 0 && time.Now().Add(backoff).Sub(start) >= retryTimeout
+				if err != nil && !timedOut && cl.shouldRetry(tries, err) && cl.waitTries(ctx, backoff) {
 					// Non-reshardable re-requests just jump back to the
 					// top where the broker is loaded. This is the case on
 					// requests where the original request is split to
@@ -1574,6 +1960,9 @@ func (cl *Client) handleShardedReq(ctx context.Context, req kmsg.Request) ([]Res
 					return
 				}
 
+				if timedOut && cl.retriableDiscovery(myIssue.req.Key(), tries, err) {
+					err = &ErrDiscoveryTimeout{time.Since(start), tries, err}
+				}
 				addShard(shard(broker, myIssue.req, resp, err)) // the error was not retriable
 			}()
 		}
@@ -2415,6 +2804,141 @@ func (cl *offsetForLeaderEpochSharder) merge(sresps []ResponseShard) (kmsg.Respo
 	return merged, firstErr
 }
 
+// handle sharding WriteTxnMarkersRequest
+type writeTxnMarkersSharder struct{ *Client }
+
+func (cl *writeTxnMarkersSharder) shard(ctx context.Context, kreq kmsg.Request) ([]issueShard, bool, error) {
+	req := kreq.(*kmsg.WriteTxnMarkersRequest)
+
+	var need []string
+	for _, marker := range req.Markers {
+		for _, topic := range marker.Topics {
+			need = append(need, topic.Topic)
+		}
+	}
+	mapping, err := cl.fetchMappedMetadata(ctx, need)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Each marker is split by partition leader. We keep the markers in
+	// order per broker so that a broker writes markers in the same order
+	// as they were requested.
+	brokerReqs := make(map[int32]map[int]map[string][]int32) // broker => marker index => topic => partitions
+	var issues []issueShard
+
+	for i, marker := range req.Markers {
+		var unknowns unknownErrShards
+		for _, topic := range marker.Topics {
+			t := topic.Topic
+			tmapping, exists := mapping[t]
+			if err := missingOrCodeT(t, exists, tmapping.topic.ErrorCode); err != nil {
+				unknowns.errs(err, t, topic.Partitions)
+				continue
+			}
+			for _, partition := range topic.Partitions {
+				p, exists := tmapping.mapping[partition]
+				if err := missingOrCodeP(t, partition, exists, p.ErrorCode); err != nil {
+					unknowns.err(err, t, partition)
+					continue
+				}
+				if err := noLeader(t, p.Partition, p.Leader); err != nil {
+					unknowns.err(err, t, partition)
+					continue
+				}
+
+				brokerReq := brokerReqs[p.Leader]
+				if brokerReq == nil {
+					brokerReq = make(map[int]map[string][]int32)
+					brokerReqs[p.Leader] = brokerReq
+				}
+				markerReq := brokerReq[i]
+				if markerReq == nil {
+					markerReq = make(map[string][]int32)
+					brokerReq[i] = markerReq
+				}
+				markerReq[t] = append(markerReq[t], partition)
+			}
+		}
+
+		marker := marker
+		mkreq := func() *kmsg.WriteTxnMarkersRequest {
+			r := kmsg.NewPtrWriteTxnMarkersRequest()
+			m := marker
+			m.Topics = nil
+			r.Markers = append(r.Markers, m)
+			return r
+		}
+		issues = append(issues, unknowns.collect(mkreq, func(r *kmsg.WriteTxnMarkersRequest, topic string, parts []int32) {
+			reqTopic := kmsg.NewWriteTxnMarkersRequestMarkerTopic()
+			reqTopic.Topic = topic
+			reqTopic.Partitions = parts
+			r.Markers[0].Topics = append(r.Markers[0].Topics, reqTopic)
+		})...)
+	}
+
+	for brokerID, brokerReq := range brokerReqs {
+		r := kmsg.NewPtrWriteTxnMarkersRequest()
+		for i, marker := range req.Markers {
+			topics, exists := brokerReq[i]
+			if !exists {
+				continue
+			}
+			marker.Topics = nil
+			for topic, parts := range topics {
+				reqTopic := kmsg.NewWriteTxnMarkersRequestMarkerTopic()
+				reqTopic.Topic = topic
+				reqTopic.Partitions = parts
+				marker.Topics = append(marker.Topics, reqTopic)
+			}
+			r.Markers = append(r.Markers, marker)
+		}
+		issues = append(issues, issueShard{
+			req:    r,
+			broker: brokerID,
+		})
+	}
+
+	return issues, true, nil // this is reshardable
+}
+
+func (cl *writeTxnMarkersSharder) onResp(kmsg.Request, kmsg.Response) error { return nil } // topic / partitions: not retried
+
+func (cl *writeTxnMarkersSharder) merge(sresps []ResponseShard) (kmsg.Response, error) {
+	merged := kmsg.NewPtrWriteTxnMarkersResponse()
+	markers := make(map[int64]map[string][]kmsg.WriteTxnMarkersResponseMarkerTopicPartition) // producer ID => topic => partitions
+
+	var order []int64
+	firstErr := firstErrMerger(sresps, func(kresp kmsg.Response) {
+		resp := kresp.(*kmsg.WriteTxnMarkersResponse)
+		merged.Version = resp.Version
+
+		for _, marker := range resp.Markers {
+			topics := markers[marker.ProducerID]
+			if topics == nil {
+				topics = make(map[string][]kmsg.WriteTxnMarkersResponseMarkerTopicPartition)
+				markers[marker.ProducerID] = topics
+				order = append(order, marker.ProducerID)
+			}
+			for _, topic := range marker.Topics {
+				topics[topic.Topic] = append(topics[topic.Topic], topic.Partitions...)
+			}
+		}
+	})
+	for _, id := range order {
+		respMarker := kmsg.NewWriteTxnMarkersResponseMarker()
+		respMarker.ProducerID = id
+		for topic, partitions := range markers[id] {
+			respTopic := kmsg.NewWriteTxnMarkersResponseMarkerTopic()
+			respTopic.Topic = topic
+			respTopic.Partitions = partitions
+			respMarker.Topics = append(respMarker.Topics, respTopic)
+		}
+		merged.Markers = append(merged.Markers, respMarker)
+	}
+	return merged, firstErr
+}
+
 // handle sharding DescribeConfigsRequest
 type describeConfigsSharder struct{ *Client }
 
@@ -2877,8 +3401,12 @@ func (cl *deleteGroupsSharder) onResp(_ kmsg.Request, kresp kmsg.Response) error
 	for i := range resp.Groups {
 		group := &resp.Groups[i]
 		err := kerr.ErrorForCode(group.ErrorCode)
-		cl.maybeDeleteStaleCoordinator(group.Group, coordinatorTypeGroup, err)
-		onRespShardErr(&retErr, err)
+		// Only coordinator errors are retried; any other group error
+		// (e.g. NON_EMPTY_GROUP) is left in the response rather than
+		// failing every group in the shard.
+		if cl.maybeDeleteStaleCoordinator(group.Group, coordinatorTypeGroup, err) {
+			onRespShardErr(&retErr, err)
+		}
 	}
 	return retErr
 }
@@ -3176,3 +3704,95 @@ func (cl *listTransactionsSharder) merge(sresps []ResponseShard) (kmsg.Response,
 
 	return merged, firstErr
 }
+
+// handles sharding ConsumerGroupDescribeRequest
+type consumerGroupDescribeSharder struct{ *Client }
+
+func (cl *consumerGroupDescribeSharder) shard(ctx context.Context, kreq kmsg.Request) ([]issueShard, bool, error) {
+	req := kreq.(*kmsg.ConsumerGroupDescribeRequest)
+
+	coordinators := cl.loadCoordinators(coordinatorTypeGroup, req.Groups...)
+	type unkerr struct {
+		err   error
+		group string
+	}
+	var (
+		brokerReqs = make(map[int32]*kmsg.ConsumerGroupDescribeRequest)
+		kerrs      = make(map[*kerr.Error][]string)
+		unkerrs    []unkerr
+	)
+
+	newReq := func(groups ...string) *kmsg.ConsumerGroupDescribeRequest {
+		newReq := kmsg.NewPtrConsumerGroupDescribeRequest()
+		newReq.IncludeAuthorizedOperations = req.IncludeAuthorizedOperations
+		newReq.Groups = groups
+		return newReq
+	}
+
+	for _, group := range req.Groups {
+		berr := coordinators[group]
+		var ke *kerr.Error
+		switch {
+		case berr.err == nil:
+			brokerReq := brokerReqs[berr.b.meta.NodeID]
+			if brokerReq == nil {
+				brokerReq = newReq()
+				brokerReqs[berr.b.meta.NodeID] = brokerReq
+			}
+			brokerReq.Groups = append(brokerReq.Groups, group)
+		case errors.As(berr.err, &ke):
+			kerrs[ke] = append(kerrs[ke], group)
+		default:
+			unkerrs = append(unkerrs, unkerr{berr.err, group})
+		}
+	}
+
+	var issues []issueShard
+	for id, req := range brokerReqs {
+		issues = append(issues, issueShard{
+			req:    req,
+			broker: id,
+		})
+	}
+	for _, unkerr := range unkerrs {
+		issues = append(issues, issueShard{
+			req: newReq(unkerr.group),
+			err: unkerr.err,
+		})
+	}
+	for kerr, groups := range kerrs {
+		issues = append(issues, issueShard{
+			req: newReq(groups...),
+			err: kerr,
+		})
+	}
+
+	return issues, true, nil // reshardable to load correct coordinators
+}
+
+// Only coordinator errors are retried; any other group error is returned in
+// the response for the caller to handle, since it commonly means the group
+// uses the classic protocol.
+func (cl *consumerGroupDescribeSharder) onResp(_ kmsg.Request, kresp kmsg.Response) error { // cleanup any stale groups
+	resp := kresp.(*kmsg.ConsumerGroupDescribeResponse)
+	var retErr error
+	for i := range resp.Groups {
+		group := &resp.Groups[i]
+		err := kerr.ErrorForCode(group.ErrorCode)
+		if cl.maybeDeleteStaleCoordinator(group.Group, coordinatorTypeGroup, err) {
+			onRespShardErr(&retErr, err)
+		}
+	}
+	return retErr
+}
+
+func (cl *consumerGroupDescribeSharder) merge(sresps []ResponseShard) (kmsg.Response, error) {
+	merged := kmsg.NewPtrConsumerGroupDescribeResponse()
+
+	return merged, firstErrMerger(sresps, func(kresp kmsg.Response) {
+		resp := kresp.(*kmsg.ConsumerGroupDescribeResponse)
+		merged.Version = resp.Version
+		merged.ThrottleMillis = resp.ThrottleMillis
+		merged.Groups = append(merged.Groups, resp.Groups...)
+	})
+}
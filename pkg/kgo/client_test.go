@@ -1,7 +1,17 @@
 package kgo
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/kversion"
 )
 
 func TestParseBrokerAddr(t *testing.T) {
@@ -93,3 +103,501 @@ func TestParseBrokerAddrErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestMaybeDeleteStaleCoordinator(t *testing.T) {
+	for _, test := range []struct {
+		err        error
+		retry      bool
+		rediscover bool
+	}{
+		{kerr.NotCoordinator, true, true},
+		{kerr.CoordinatorNotAvailable, true, true},
+		{kerr.CoordinatorLoadInProgress, true, false},
+		{kerr.UnknownMemberID, false, false},
+		{nil, false, false},
+	} {
+		key := coordinatorKey{"group", coordinatorTypeGroup}
+		cl := &Client{coordinators: map[coordinatorKey]*coordinatorLoad{key: {node: 1}}}
+
+		retry := cl.maybeDeleteStaleCoordinator(key.name, key.typ, test.err)
+		_, kept := cl.coordinators[key]
+		if retry != test.retry {
+			t.Errorf("%v: got retry %v != exp %v", test.err, retry, test.retry)
+		}
+		if kept == test.rediscover {
+			t.Errorf("%v: got coordinator kept %v, expected rediscovery %v", test.err, kept, test.rediscover)
+		}
+	}
+}
+
+// A batched FindCoordinator request to a cluster that cannot batch is split
+// up front, rather than first sending the batch with an unset singular key.
+func TestFindCoordinatorSplitsOldVersions(t *testing.T) {
+	t.Parallel()
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	vs := kversion.Tip()
+	vs.SetMaxKeyVersion(kmsg.FindCoordinator.Int16(), 3)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			keys = append(keys, req.CoordinatorKey)
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			resp.Host, resp.Port = "127.0.0.1", 9092
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	}, MaxVersions(vs))
+	defer cl.Close()
+
+	req := kmsg.NewPtrFindCoordinatorRequest()
+	req.CoordinatorKeys = []string{"a", "b", "a"}
+	resp, err := req.RequestWith(context.Background(), cl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("got requested singular keys %q, exp [a b]", keys)
+	}
+	sort.Slice(resp.Coordinators, func(i, j int) bool { return resp.Coordinators[i].Key < resp.Coordinators[j].Key })
+	if len(resp.Coordinators) != 2 || resp.Coordinators[0].Key != "a" || resp.Coordinators[1].Key != "b" {
+		t.Errorf("got coordinators %+v, exp a and b", resp.Coordinators)
+	}
+}
+
+func TestClusterFeatures(t *testing.T) {
+	t.Parallel()
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		if req, ok := kreq.(*kmsg.MetadataRequest); ok {
+			return fakeMetadataResponse(req, 0)
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	})
+	defer cl.Close()
+
+	fs, err := cl.ClusterFeatures(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	exp := ClusterFeatures{
+		Supported:      map[string]FeatureVersions{"metadata.version": {1, 20}},
+		FinalizedEpoch: 3,
+		Finalized:      map[string]FeatureVersions{"metadata.version": {1, 14}},
+	}
+	if !reflect.DeepEqual(fs, exp) {
+		t.Errorf("got %+v != exp %+v", fs, exp)
+	}
+
+	// Older brokers do not return features, and finalized features are
+	// ignored if their epoch is unknown.
+	old := kmsg.NewPtrApiVersionsResponse()
+	old.FinalizedFeatures = append(old.FinalizedFeatures, kmsg.NewApiVersionsResponseFinalizedFeature())
+	exp = ClusterFeatures{
+		Supported:      map[string]FeatureVersions{},
+		FinalizedEpoch: -1,
+		Finalized:      map[string]FeatureVersions{},
+	}
+	if fs := newClusterFeatures(old); !reflect.DeepEqual(fs, exp) {
+		t.Errorf("old broker: got %+v != exp %+v", fs, exp)
+	}
+}
+
+func TestPinGroupCoordinator(t *testing.T) {
+	t.Parallel()
+	var (
+		mu          sync.Mutex
+		finds       int
+		notCoord    bool
+		describeErr int16
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			finds++
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.DescribeGroupsRequest:
+			resp := req.ResponseKind().(*kmsg.DescribeGroupsResponse)
+			for _, g := range req.Groups {
+				rg := kmsg.NewDescribeGroupsResponseGroup()
+				rg.Group = g
+				if notCoord {
+					notCoord = false
+					rg.ErrorCode = kerr.NotCoordinator.Code
+				}
+				describeErr = rg.ErrorCode
+				resp.Groups = append(resp.Groups, rg)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	})
+	defer cl.Close()
+
+	cl.PinGroupCoordinator("g", 0)
+
+	describe := func() {
+		t.Helper()
+		req := kmsg.NewPtrDescribeGroupsRequest()
+		req.Groups = []string{"g"}
+		if _, err := req.RequestWith(context.Background(), cl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	describe()
+	mu.Lock()
+	if finds != 0 || describeErr != 0 {
+		t.Errorf("got %d FindCoordinator requests and err %d with a pinned coordinator, exp 0 and 0", finds, describeErr)
+	}
+	notCoord = true
+	mu.Unlock()
+
+	// A pin proven wrong falls back to discovery.
+	describe()
+	mu.Lock()
+	defer mu.Unlock()
+	if finds != 1 || describeErr != 0 {
+		t.Errorf("got %d FindCoordinator requests and err %d after NOT_COORDINATOR, exp 1 and 0", finds, describeErr)
+	}
+}
+
+func TestDiscoveryBackoff(t *testing.T) {
+	t.Parallel()
+
+	cl, err := NewClient(RetryBackoffFn(func(int) time.Duration { return time.Millisecond }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.Close()
+	if got := cl.cfg.discoveryBackoff(1, kerr.CoordinatorLoadInProgress); got != time.Second {
+		t.Errorf("got default backoff %v for COORDINATOR_LOAD_IN_PROGRESS, exp 1s", got)
+	}
+	if got := cl.cfg.discoveryBackoff(1, kerr.NotCoordinator); got != time.Millisecond {
+		t.Errorf("got default backoff %v for NOT_COORDINATOR, exp the retry backoff 1ms", got)
+	}
+
+	var (
+		mu        sync.Mutex
+		loading   = true
+		discovery []error
+		retries   int
+	)
+	cl = newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.DescribeGroupsRequest:
+			resp := req.ResponseKind().(*kmsg.DescribeGroupsResponse)
+			for _, g := range req.Groups {
+				rg := kmsg.NewDescribeGroupsResponseGroup()
+				rg.Group = g
+				if loading {
+					loading = false
+					rg.ErrorCode = kerr.CoordinatorLoadInProgress.Code
+				}
+				resp.Groups = append(resp.Groups, rg)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		RetryBackoffFn(func(int) time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			retries++
+			return time.Millisecond
+		}),
+		DiscoveryBackoffFn(func(_ int, err error) time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			discovery = append(discovery, err)
+			return time.Millisecond
+		}),
+	)
+	defer cl.Close()
+
+	req := kmsg.NewPtrDescribeGroupsRequest()
+	req.Groups = []string{"g"}
+	if _, err := req.RequestWith(context.Background(), cl); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(discovery) != 1 || discovery[0] != kerr.CoordinatorLoadInProgress {
+		t.Errorf("got discovery backoffs for %v, exp one for %v", discovery, kerr.CoordinatorLoadInProgress)
+	}
+	if retries != 0 {
+		t.Errorf("got %d normal retry backoffs, exp 0", retries)
+	}
+}
+
+// Discovery that is still failing when the retry timeout is reached fails
+// with ErrDiscoveryTimeout, wrapping the last discovery error, for both
+// coordinator and sharded requests.
+func TestDiscoveryTimeout(t *testing.T) {
+	t.Parallel()
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			resp.ControllerID = 0
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key = k
+				c.ErrorCode = kerr.CoordinatorNotAvailable.Code
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.ListGroupsRequest:
+			resp := req.ResponseKind().(*kmsg.ListGroupsResponse)
+			resp.ErrorCode = kerr.CoordinatorLoadInProgress.Code
+			return resp
+		case *kmsg.AlterPartitionAssignmentsRequest:
+			resp := req.ResponseKind().(*kmsg.AlterPartitionAssignmentsResponse)
+			resp.ErrorCode = kerr.RequestTimedOut.Code
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		RetryBackoffFn(func(int) time.Duration { return 5 * time.Millisecond }),
+		DiscoveryBackoffFn(func(int, error) time.Duration { return 5 * time.Millisecond }),
+		RetryTimeoutFn(func(int16) time.Duration { return 30 * time.Millisecond }),
+	)
+	defer cl.Close()
+
+	commit := kmsg.NewPtrOffsetCommitRequest()
+	commit.Group = "g"
+	_, commitErr := cl.Request(context.Background(), commit)
+
+	// A broker that is still loading a coordinator returns its error in
+	// the response; this is also discovery.
+	list := kmsg.NewPtrListGroupsRequest()
+	listErr := cl.RequestSharded(context.Background(), list)[0].Err
+
+	for _, test := range []struct {
+		name string
+		err  error
+		exp  error
+	}{
+		{"commit", commitErr, kerr.CoordinatorNotAvailable},
+		{"list", listErr, kerr.CoordinatorLoadInProgress},
+	} {
+		var dte *ErrDiscoveryTimeout
+		if !errors.As(test.err, &dte) || !errors.Is(test.err, test.exp) || dte.Tries < 2 {
+			t.Errorf("%s: got err %v, exp a discovery timeout after retrying %v", test.name, test.err, test.exp)
+		}
+	}
+
+	// A retriable error that is not about discovery is still only in the
+	// response once retries time out.
+	if _, err := cl.Request(context.Background(), kmsg.NewPtrAlterPartitionAssignmentsRequest()); err != nil {
+		t.Errorf("alter partition assignments: got err %v, exp the error only in the response", err)
+	}
+}
+
+// WriteTxnMarkers is split by partition leader, with each marker's producer
+// fields kept on every split, and the responses are merged by producer ID.
+func TestWriteTxnMarkersSharded(t *testing.T) {
+	t.Parallel()
+	var reqs []*kmsg.WriteTxnMarkersRequest
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			b := kmsg.NewMetadataResponseBroker()
+			b.NodeID, b.Host, b.Port = 1, "127.0.0.1", 9092
+			resp.Brokers = append(resp.Brokers, b)
+			for i := range resp.Topics {
+				for p := int32(0); p < 2; p++ {
+					sp := kmsg.NewMetadataResponseTopicPartition()
+					sp.Partition = p
+					sp.Leader = p // partition 0 on broker 0, 1 on broker 1
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, sp)
+				}
+			}
+			return resp
+		case *kmsg.WriteTxnMarkersRequest:
+			reqs = append(reqs, req)
+			resp := req.ResponseKind().(*kmsg.WriteTxnMarkersResponse)
+			for _, m := range req.Markers {
+				rm := kmsg.NewWriteTxnMarkersResponseMarker()
+				rm.ProducerID = m.ProducerID
+				for _, rt := range m.Topics {
+					st := kmsg.NewWriteTxnMarkersResponseMarkerTopic()
+					st.Topic = rt.Topic
+					for _, p := range rt.Partitions {
+						sp := kmsg.NewWriteTxnMarkersResponseMarkerTopicPartition()
+						sp.Partition = p
+						st.Partitions = append(st.Partitions, sp)
+					}
+					rm.Topics = append(rm.Topics, st)
+				}
+				resp.Markers = append(resp.Markers, rm)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	})
+	defer cl.Close()
+
+	req := kmsg.NewPtrWriteTxnMarkersRequest()
+	m := kmsg.NewWriteTxnMarkersRequestMarker()
+	m.ProducerID, m.ProducerEpoch, m.Committed, m.CoordinatorEpoch = 7, 3, true, 9
+	mt := kmsg.NewWriteTxnMarkersRequestMarkerTopic()
+	mt.Topic = "t"
+	mt.Partitions = []int32{0, 1}
+	m.Topics = append(m.Topics, mt)
+	req.Markers = append(req.Markers, m)
+
+	resp, err := req.RequestWith(context.Background(), cl)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(reqs) != 2 {
+		t.Fatalf("got %d WriteTxnMarkers requests, exp 2 (one per leader)", len(reqs))
+	}
+	var got []int32
+	for _, r := range reqs {
+		if len(r.Markers) != 1 || len(r.Markers[0].Topics) != 1 || len(r.Markers[0].Topics[0].Partitions) != 1 {
+			t.Fatalf("got split request %+v, exp one marker with one partition", r)
+		}
+		rm := r.Markers[0]
+		if rm.ProducerID != 7 || rm.ProducerEpoch != 3 || !rm.Committed || rm.CoordinatorEpoch != 9 {
+			t.Errorf("got split marker %d/%d/%v/%d, exp 7/3/true/9", rm.ProducerID, rm.ProducerEpoch, rm.Committed, rm.CoordinatorEpoch)
+		}
+		got = append(got, rm.Topics[0].Partitions[0])
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if !reflect.DeepEqual(got, []int32{0, 1}) {
+		t.Errorf("got split partitions %v, exp [0 1]", got)
+	}
+
+	if len(resp.Markers) != 1 || resp.Markers[0].ProducerID != 7 || len(resp.Markers[0].Topics) != 1 ||
+		len(resp.Markers[0].Topics[0].Partitions) != 2 {
+		t.Errorf("got merged response %+v, exp producer 7 with both partitions", resp)
+	}
+}
+
+// ConsumerGroupDescribe is split by group coordinator like DescribeGroups, and
+// its nested assignments round trip.
+func TestConsumerGroupDescribeSharded(t *testing.T) {
+	t.Parallel()
+	var (
+		mu       sync.Mutex
+		notCoord = true
+		finds    int
+	)
+	vs := kversion.Tip()
+	vs.SetMaxKeyVersion(kmsg.ConsumerGroupDescribe.Int16(), 0)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			finds++
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.ConsumerGroupDescribeRequest:
+			resp := req.ResponseKind().(*kmsg.ConsumerGroupDescribeResponse)
+			for _, g := range req.Groups {
+				rg := kmsg.NewConsumerGroupDescribeResponseGroup()
+				rg.Group = g
+				if g == "b" && notCoord {
+					notCoord = false
+					rg.ErrorCode = kerr.NotCoordinator.Code
+					resp.Groups = append(resp.Groups, rg)
+					continue
+				}
+				rg.State = "Stable"
+				rg.Epoch = 3
+				m := kmsg.NewConsumerGroupDescribeResponseGroupMember()
+				m.MemberID = g + "-m"
+				m.SubscribedTopicNames = []string{"t"}
+				at := kmsg.NewConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition()
+				at.Topic, at.Partitions = "t", []int32{0, 1}
+				m.Assignment.TopicPartitions = append(m.Assignment.TopicPartitions, at)
+				at.Partitions = []int32{0}
+				m.TargetAssignment.TopicPartitions = append(m.TargetAssignment.TopicPartitions, at)
+				rg.Members = append(rg.Members, m)
+				resp.Groups = append(resp.Groups, rg)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	}, MaxVersions(vs))
+	defer cl.Close()
+
+	req := kmsg.NewPtrConsumerGroupDescribeRequest()
+	req.Groups = []string{"a", "b"}
+	kresp, err := cl.Request(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := kresp.(*kmsg.ConsumerGroupDescribeResponse)
+	sort.Slice(resp.Groups, func(i, j int) bool { return resp.Groups[i].Group < resp.Groups[j].Group })
+	if len(resp.Groups) != 2 {
+		t.Fatalf("got %d groups, exp 2", len(resp.Groups))
+	}
+	for _, g := range resp.Groups {
+		if g.ErrorCode != 0 || g.State != "Stable" || g.Epoch != 3 || len(g.Members) != 1 {
+			t.Fatalf("group %s: got err %d state %s epoch %d with %d members, exp 0 Stable 3 with 1", g.Group, g.ErrorCode, g.State, g.Epoch, len(g.Members))
+		}
+		m := g.Members[0]
+		if m.MemberID != g.Group+"-m" ||
+			len(m.Assignment.TopicPartitions) != 1 || !reflect.DeepEqual(m.Assignment.TopicPartitions[0].Partitions, []int32{0, 1}) ||
+			len(m.TargetAssignment.TopicPartitions) != 1 || !reflect.DeepEqual(m.TargetAssignment.TopicPartitions[0].Partitions, []int32{0}) {
+			t.Errorf("group %s: got member %+v, exp assigned t[0 1] and target t[0]", g.Group, m)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if finds < 2 {
+		t.Errorf("got %d FindCoordinator requests, exp a rediscovery after NOT_COORDINATOR", finds)
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"compress/gzip"
 	"encoding/binary"
 	"errors"
-	"io/ioutil"
+	"io"
+	"math/bits"
 	"runtime"
 	"sync"
 
@@ -50,11 +51,34 @@ func GzipCompression() CompressionCodec { return CompressionCodec{1, gzip.Defaul
 func SnappyCompression() CompressionCodec { return CompressionCodec{2, 0} }
 
 // Lz4Compression enables lz4 compression with the fastest compression level.
+//
+// Lz4 uses the standard lz4 frame format. Kafka before 0.10 (message set v0)
+// computed the frame header checksum incorrectly; the client writes the
+// broken checksum when producing message set v0 and accepts either checksum
+// when consuming.
 func Lz4Compression() CompressionCodec { return CompressionCodec{3, 0} }
 
 // ZstdCompression enables zstd compression with the default compression level.
+//
+// Zstd is implemented in pure Go and does not require cgo; a faster (e.g. cgo)
+// implementation can be used with WithZstdCodec. Zstd requires Kafka 2.1+
+// (produce request v7+); if producing to an older broker, the next compression
+// codec in preference order is used.
 func ZstdCompression() CompressionCodec { return CompressionCodec{4, 0} }
 
+// ZstdCodec is a zstd implementation that can be used in place of the
+// client's default pure Go implementation; see WithZstdCodec. The methods
+// match those of github.com/klauspost/compress/zstd's Encoder and Decoder,
+// and must be safe for concurrent use.
+type ZstdCodec interface {
+	// EncodeAll appends the zstd compressed form of src to dst and
+	// returns the result.
+	EncodeAll(src, dst []byte) []byte
+	// DecodeAll appends the decompressed form of the zstd frames in
+	// src to dst and returns the result.
+	DecodeAll(src, dst []byte) ([]byte, error)
+}
+
 // WithLevel changes the compression codec's "level", effectively allowing for
 // higher or lower compression ratios at the expense of CPU speed.
 //
@@ -72,6 +96,8 @@ func (c CompressionCodec) WithLevel(level int) CompressionCodec {
 
 type compressor struct {
 	options  []int8
+	minBytes int       // batches smaller than this are not compressed
+	zstd     ZstdCodec // if non-nil, used rather than zstdPool
 	gzPool   sync.Pool
 	lz4Pool  sync.Pool
 	zstdPool sync.Pool
@@ -172,6 +198,12 @@ type zstdEncoder struct {
 func (c *compressor) compress(dst *sliceWriter, src []byte, produceRequestVersion int16) ([]byte, int8) {
 	dst.inner = dst.inner[:0]
 
+	// Tiny inputs generally do not compress well and can even grow; we
+	// avoid spending the CPU on them entirely.
+	if len(src) < c.minBytes {
+		return src, 0
+	}
+
 	var use int8
 	for _, option := range c.options {
 		if option == 4 && produceRequestVersion < 7 {
@@ -208,7 +240,14 @@ func (c *compressor) compress(dst *sliceWriter, src []byte, produceRequestVersio
 		if err := lz.Close(); err != nil {
 			return nil, -1
 		}
+		if produceRequestVersion < 2 {
+			lz4BreakV0HeaderChecksum(dst.inner)
+		}
 	case 4:
+		if c.zstd != nil {
+			dst.inner = c.zstd.EncodeAll(src, dst.inner)
+			break
+		}
 		zstdEnc := c.zstdPool.Get().(*zstdEncoder)
 		defer c.zstdPool.Put(zstdEnc)
 		dst.inner = zstdEnc.inner.EncodeAll(src, dst.inner)
@@ -221,6 +260,7 @@ type decompressor struct {
 	ungzPool   sync.Pool
 	unlz4Pool  sync.Pool
 	unzstdPool sync.Pool
+	zstd       ZstdCodec // if non-nil, used rather than unzstdPool
 }
 
 func newDecompressor() *decompressor {
@@ -252,7 +292,18 @@ type zstdDecoder struct {
 	inner *zstd.Decoder
 }
 
+// decompressBufs pools the buffers that record batches are decompressed into
+// when processing fetches. Records reference these buffers directly, so a
+// buffer is only returned to the pool through Fetches.Release.
+var decompressBufs = sync.Pool{New: func() interface{} { return new([]byte) }}
+
 func (d *decompressor) decompress(src []byte, codec byte) ([]byte, error) {
+	return d.decompressTo(nil, src, codec)
+}
+
+// decompressTo decompresses src into dst[:0], growing dst if necessary, and
+// returns the decompressed slice.
+func (d *decompressor) decompressTo(dst, src []byte, codec byte) ([]byte, error) {
 	switch codec {
 	case 0:
 		return src, nil
@@ -262,37 +313,164 @@ func (d *decompressor) decompress(src []byte, codec byte) ([]byte, error) {
 		if err := ungz.Reset(bytes.NewReader(src)); err != nil {
 			return nil, err
 		}
-		return ioutil.ReadAll(ungz)
+		return readAllTo(dst, ungz)
 	case 2:
 		if len(src) > 16 && bytes.HasPrefix(src, xerialPfx) {
-			return xerialDecode(src)
+			return xerialDecode(dst, src)
 		}
-		return s2.Decode(nil, src)
+		return s2.Decode(dst[:cap(dst)], src)
 	case 3:
 		unlz4 := d.unlz4Pool.Get().(*lz4.Reader)
 		defer d.unlz4Pool.Put(unlz4)
-		unlz4.Reset(bytes.NewReader(src))
-		return ioutil.ReadAll(unlz4)
+		// Message set v0 uses a broken header checksum; we fix it if
+		// necessary rather than threading the magic through.
+		unlz4.Reset(lz4FixV0HeaderChecksum(src))
+		return readAllTo(dst, unlz4)
 	case 4:
+		if d.zstd != nil {
+			return d.zstd.DecodeAll(src, dst[:0])
+		}
 		unzstd := d.unzstdPool.Get().(*zstdDecoder)
 		defer d.unzstdPool.Put(unzstd)
-		return unzstd.inner.DecodeAll(src, nil)
+		return unzstd.inner.DecodeAll(src, dst[:0])
 	default:
 		return nil, errors.New("unknown compression codec")
 	}
 }
 
+// readAllTo is ioutil.ReadAll, but reads into dst[:0].
+func readAllTo(dst []byte, r io.Reader) ([]byte, error) {
+	dst = dst[:0]
+	for {
+		if len(dst) == cap(dst) {
+			dst = append(dst, 0)[:len(dst)]
+		}
+		n, err := r.Read(dst[len(dst):cap(dst)])
+		dst = dst[:len(dst)+n]
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return dst, err
+		}
+	}
+}
+
+// Kafka's original lz4 implementation computed the frame descriptor's header
+// checksum incorrectly, hashing the frame's magic number along with the
+// descriptor. KIP-57 fixed this for message set v1+, but message set v0 (magic
+// 0) must still use the broken checksum: brokers expect it when receiving v0,
+// and write it when down converting to v0.
+//
+// The lz4 frame begins with a 4 byte magic number, a FLG byte, a BD byte, an
+// optional 8 byte content size, an optional 4 byte dictionary ID, and then a
+// single byte header checksum.
+
+// lz4HeaderChecksumAt returns the index of the header checksum byte in an lz4
+// frame, or -1 if src is too short.
+func lz4HeaderChecksumAt(src []byte) int {
+	if len(src) < 7 {
+		return -1
+	}
+	at := 6
+	flg := src[4]
+	if flg&0x08 != 0 { // content size
+		at += 8
+	}
+	if flg&0x01 != 0 { // dictionary ID
+		at += 4
+	}
+	if at >= len(src) {
+		return -1
+	}
+	return at
+}
+
+func lz4HeaderChecksum(b []byte) byte { return byte(xxh32(b) >> 8) }
+
+// lz4BreakV0HeaderChecksum rewrites, in place, the header checksum of an lz4
+// frame to the broken checksum Kafka expects in message set v0.
+func lz4BreakV0HeaderChecksum(src []byte) {
+	if at := lz4HeaderChecksumAt(src); at > 0 {
+		src[at] = lz4HeaderChecksum(src[:at])
+	}
+}
+
+// lz4FixV0HeaderChecksum returns a reader over src with the header checksum
+// corrected if src has the broken checksum Kafka uses in message set v0.
+// src itself is not modified.
+func lz4FixV0HeaderChecksum(src []byte) io.Reader {
+	at := lz4HeaderChecksumAt(src)
+	if at < 0 {
+		return bytes.NewReader(src)
+	}
+	correct := lz4HeaderChecksum(src[4:at])
+	if src[at] == correct || src[at] != lz4HeaderChecksum(src[:at]) {
+		return bytes.NewReader(src)
+	}
+	header := append([]byte(nil), src[:at+1]...)
+	header[at] = correct
+	return io.MultiReader(bytes.NewReader(header), bytes.NewReader(src[at+1:]))
+}
+
+// xxh32 returns the 32 bit xxHash of b with a zero seed, which is all that is
+// needed for lz4 header checksums.
+func xxh32(b []byte) uint32 {
+	const (
+		prime1 uint32 = 2654435761
+		prime2 uint32 = 2246822519
+		prime3 uint32 = 3266489917
+		prime4 uint32 = 668265263
+		prime5 uint32 = 374761393
+	)
+	round := func(acc, in uint32) uint32 {
+		return bits.RotateLeft32(acc+in*prime2, 13) * prime1
+	}
+
+	n := len(b)
+	var h uint32
+	if n >= 16 {
+		p1 := prime1 // avoid constant overflow below
+		v1, v2, v3, v4 := p1+prime2, prime2, uint32(0), -p1
+		for ; len(b) >= 16; b = b[16:] {
+			v1 = round(v1, binary.LittleEndian.Uint32(b[0:]))
+			v2 = round(v2, binary.LittleEndian.Uint32(b[4:]))
+			v3 = round(v3, binary.LittleEndian.Uint32(b[8:]))
+			v4 = round(v4, binary.LittleEndian.Uint32(b[12:]))
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = prime5
+	}
+	h += uint32(n)
+	for ; len(b) >= 4; b = b[4:] {
+		h += binary.LittleEndian.Uint32(b) * prime3
+		h = bits.RotateLeft32(h, 17) * prime4
+	}
+	for _, c := range b {
+		h += uint32(c) * prime5
+		h = bits.RotateLeft32(h, 11) * prime1
+	}
+	h ^= h >> 15
+	h *= prime2
+	h ^= h >> 13
+	h *= prime3
+	h ^= h >> 16
+	return h
+}
+
 var xerialPfx = []byte{130, 83, 78, 65, 80, 80, 89, 0}
 
 var errMalformedXerial = errors.New("malformed xerial framing")
 
-func xerialDecode(src []byte) ([]byte, error) {
+func xerialDecode(dst, src []byte) ([]byte, error) {
 	// bytes 0-8: xerial header
 	// bytes 8-16: xerial version
 	// everything after: uint32 chunk size, snappy chunk
 	// we come into this function knowing src is at least 16
 	src = src[16:]
-	var dst, chunk []byte
+	dst = dst[:0]
+	var chunk []byte
 	var err error
 	for len(src) > 0 {
 		if len(src) < 4 {
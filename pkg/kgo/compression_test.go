@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestNewCompressor(t *testing.T) {
@@ -94,6 +96,27 @@ func TestCompressDecompress(t *testing.T) {
 	wg.Wait()
 }
 
+func TestCompressMinBytes(t *testing.T) {
+	t.Parallel()
+	c, _ := newCompressor(CompressionCodec{codec: 2}) // snappy
+	c.minBytes = 100
+
+	small := bytes.Repeat([]byte("a"), 99)
+	large := bytes.Repeat([]byte("a"), 100)
+
+	w := sliceWriters.Get().(*sliceWriter)
+	defer sliceWriters.Put(w)
+
+	got, used := c.compress(w, small, 0)
+	if used != 0 || !bytes.Equal(got, small) {
+		t.Errorf("small input: got codec %d, exp no compression", used)
+	}
+	got, used = c.compress(w, large, 0)
+	if used != 2 || len(got) >= len(large) {
+		t.Errorf("large input: got codec %d len %d, exp snappy compressed smaller than %d", used, len(got), len(large))
+	}
+}
+
 func BenchmarkCompress(b *testing.B) {
 	c, _ := newCompressor(CompressionCodec{codec: 2}) // snappy
 	in := []byte("foo")
@@ -104,6 +127,133 @@ func BenchmarkCompress(b *testing.B) {
 	}
 }
 
+// The zstd inputs below were produced by the reference zstd CLI (libzstd
+// v1.5.6, the same library used by the Java client through zstd-jni).
+func TestZstdInterop(t *testing.T) {
+	t.Parallel()
+	d := newDecompressor()
+	want := []byte("the quick brown fox jumps over the lazy dog; the quick brown fox jumps over the lazy dog\n")
+	for _, test := range []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			"level 3 with content checksum",
+			"KLUv/SRZvQEA1AJ0aGUgcXVpY2sgYnJvd24gZm94IGp1bXBzIG92ZXIgdGhlIGxhenkgZG9nOwoCAKAqgCHMMsKqt8Q=",
+			false,
+		},
+		{
+			"level 19 without content checksum",
+			"KLUv/SBZtQEA5AJ0aGUgcXVpY2sgYnJvd24gZm94IGp1bXBzIG92ZXIgdGhlIGxhenkgZG9nOyAKAQAFmqoM",
+			false,
+		},
+		{
+			"bad content checksum",
+			"KLUv/SRZvQEA1AJ0aGUgcXVpY2sgYnJvd24gZm94IGp1bXBzIG92ZXIgdGhlIGxhenkgZG9nOwoCAKAqgCHMMsKqt8U=",
+			true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			in, _ := base64.StdEncoding.DecodeString(test.input)
+			got, err := d.decompress(in, 4)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("got err? %v (%v), exp err? %v", gotErr, err, test.wantErr)
+			}
+			if !test.wantErr && !bytes.Equal(got, want) {
+				t.Errorf("got %q != exp %q", got, want)
+			}
+		})
+	}
+}
+
+func TestXXH32(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		in  string
+		exp uint32
+	}{
+		{"", 0x02cc5d05},
+		{"a", 0x550d7456},
+		{"abc", 0x32d153ff},
+		{"Nobody inspects the spammish repetition", 0xe2293b2f},
+	} {
+		if got := xxh32([]byte(test.in)); got != test.exp {
+			t.Errorf("xxh32(%q): got %08x != exp %08x", test.in, got, test.exp)
+		}
+	}
+}
+
+// The lz4 inputs below were produced by the reference lz4 CLI (v1.9.4).
+func TestLz4Interop(t *testing.T) {
+	t.Parallel()
+	d := newDecompressor()
+	want := []byte("the quick brown fox jumps over the lazy dog; the quick brown fox jumps over the lazy dog\n")
+	for _, test := range []struct {
+		name  string
+		input string
+	}{
+		{
+			"default frame",
+			"BCJNGGRApzkAAADwEHRoZSBxdWljayBicm93biBmb3gganVtcHMgb3ZlciAfAJFsYXp5IGRvZzsOAA8tABBQIGRvZwoAAAAAteZROg==",
+		},
+		{
+			"frame with content size",
+			"BCJNGGxAWQAAAAAAAACdOQAAAPAQdGhlIHF1aWNrIGJyb3duIGZveCBqdW1wcyBvdmVyIB8AkWxhenkgZG9nOw4ADy0AEFAgZG9nCgAAAAC15lE6",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			in, _ := base64.StdEncoding.DecodeString(test.input)
+
+			at := lz4HeaderChecksumAt(in)
+			if at < 0 || in[at] != lz4HeaderChecksum(in[4:at]) {
+				t.Fatal("reference header checksum does not match our computed checksum")
+			}
+
+			got, err := d.decompress(in, 3)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Fatalf("got %q (err %v) != exp %q", got, err, want)
+			}
+
+			// Kafka's broken v0 checksum must also be readable,
+			// without modifying the input.
+			broken := append([]byte(nil), in...)
+			lz4BreakV0HeaderChecksum(broken)
+			if broken[at] == in[at] {
+				t.Fatal("breaking the header checksum did not change it")
+			}
+			brokenCopy := append([]byte(nil), broken...)
+			got, err = d.decompress(broken, 3)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("broken checksum: got %q (err %v) != exp %q", got, err, want)
+			}
+			if !bytes.Equal(broken, brokenCopy) {
+				t.Error("decompressing modified the input")
+			}
+		})
+	}
+
+	// Compressing for message set v0 (produce v0 and v1) must use the
+	// broken checksum, and v1+ the correct checksum.
+	c, _ := newCompressor(CompressionCodec{codec: 3})
+	for _, produceVersion := range []int16{0, 1, 2, 3} {
+		w := sliceWriters.Get().(*sliceWriter)
+		got, _ := c.compress(w, want, produceVersion)
+		at := lz4HeaderChecksumAt(got)
+		exp := lz4HeaderChecksum(got[4:at])
+		if produceVersion < 2 {
+			exp = lz4HeaderChecksum(got[:at])
+		}
+		if got[at] != exp {
+			t.Errorf("produce v%d: got header checksum %x != exp %x", produceVersion, got[at], exp)
+		}
+		if decompressed, err := d.decompress(got, 3); err != nil || !bytes.Equal(decompressed, want) {
+			t.Errorf("produce v%d: round trip failed: %v", produceVersion, err)
+		}
+		sliceWriters.Put(w)
+	}
+}
+
 func Test_xerialDecode(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -143,7 +293,7 @@ func Test_xerialDecode(t *testing.T) {
 				t.Errorf("base64 decode error = %v", err)
 				return
 			}
-			got, err := xerialDecode(data)
+			got, err := xerialDecode(nil, data)
 			if (err != nil) != test.wantErr {
 				t.Errorf("xerialDecode() error = %v, wantErr %v", err, test.wantErr)
 				return
@@ -154,3 +304,51 @@ func Test_xerialDecode(t *testing.T) {
 		})
 	}
 }
+
+type countingZstd struct {
+	enc              *zstd.Encoder
+	dec              *zstd.Decoder
+	encodes, decodes int
+}
+
+func (c *countingZstd) EncodeAll(src, dst []byte) []byte {
+	c.encodes++
+	return c.enc.EncodeAll(src, dst)
+}
+
+func (c *countingZstd) DecodeAll(src, dst []byte) ([]byte, error) {
+	c.decodes++
+	return c.dec.DecodeAll(src, dst)
+}
+
+func TestWithZstdCodec(t *testing.T) {
+	t.Parallel()
+	enc, _ := zstd.NewWriter(nil)
+	dec, _ := zstd.NewReader(nil)
+	codec := &countingZstd{enc: enc, dec: dec}
+	cl, err := NewClient(ProducerBatchCompression(ZstdCompression()), WithZstdCodec(codec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	in := bytes.Repeat([]byte("foo"), 100)
+	w := sliceWriters.Get().(*sliceWriter)
+	defer sliceWriters.Put(w)
+	compressed, used := cl.compressor.compress(w, in, 7)
+	if used != 4 {
+		t.Fatalf("got codec %d, exp zstd", used)
+	}
+	got, err := cl.decompressor.decompress(compressed, 4)
+	if err != nil || !bytes.Equal(got, in) {
+		t.Fatalf("got decompressed %q, err %v, exp the input", got, err)
+	}
+	if codec.encodes != 1 || codec.decodes != 1 {
+		t.Errorf("got %d encodes, %d decodes through the codec, exp 1 and 1", codec.encodes, codec.decodes)
+	}
+
+	// The default decompressor reads what the codec wrote.
+	if got, err := newDecompressor().decompress(compressed, 4); err != nil || !bytes.Equal(got, in) {
+		t.Errorf("default decompressor: got %q, err %v, exp the input", got, err)
+	}
+}
@@ -69,21 +69,32 @@ type cfg struct {
 
 	id                     *string // client ID
 	dialFn                 func(context.Context, string, string) (net.Conn, error)
+	resolveFn              func(context.Context, string) ([]string, error)
+	addrPreference         AddressPreference
 	requestTimeoutOverhead time.Duration
 	connIdleTimeout        time.Duration
+	maxBrokerCxns          int
+
+	breakerFailures    int
+	breakerMinCooldown time.Duration
+	breakerMaxCooldown time.Duration
 
 	softwareName    string // KIP-511
 	softwareVersion string // KIP-511
 
-	logger Logger
+	logger   Logger
+	recorder *Recorder
+
+	zstd ZstdCodec
 
 	seedBrokers []string
 	maxVersions *kversion.Versions
 	minVersions *kversion.Versions
 
-	retryBackoff func(int) time.Duration
-	retries      int64
-	retryTimeout func(int16) time.Duration
+	retryBackoff     func(int) time.Duration
+	discoveryBackoff func(int, error) time.Duration
+	retries          int64
+	retryTimeout     func(int16) time.Duration
 
 	maxBrokerWriteBytes int32
 	maxBrokerReadBytes  int32
@@ -105,13 +116,21 @@ type cfg struct {
 	txnTimeout         time.Duration
 	acks               Acks
 	disableIdempotency bool
+	maxBrokerInflight  int
+	maxPartInflight    int
 	compression        []CompressionCodec // order of preference
+	minCompressBytes   int32
 
 	defaultProduceTopic string
+	deadLetterTopic     string
 	maxRecordBatchBytes int32
 	maxBufferedRecords  int64
+	rateLimitBytes      int64
+	rateLimitRecords    int64
 	produceTimeout      time.Duration
 	recordRetries       int64
+	unknownTopicWindow  time.Duration
+	unavailable         UnavailablePartitionBehavior
 	linger              time.Duration
 	recordTimeout       time.Duration
 	manualFlushing      bool
@@ -132,14 +151,20 @@ type cfg struct {
 	resetOffset    Offset
 	isolationLevel int8
 	keepControl    bool
+	lazyDecoding   bool
 	rack           string
 
 	maxConcurrentFetches int
+	maxConcurrentDecodes int
+	maxBytesTotal        int64
 	disableFetchSessions bool
 
-	topics     map[string]*regexp.Regexp   // topics to consume; if regex is true, values are compiled regular expressions
-	partitions map[string]map[int32]Offset // partitions to directly consume from
-	regex      bool
+	onConsumeDataLoss func(*ErrDataLoss)
+
+	topics      map[string]*regexp.Regexp   // topics to consume; if regex is true, values are compiled regular expressions
+	partitions  map[string]map[int32]Offset // partitions to directly consume from
+	offsetStore OffsetStore                 // if non-nil, direct consuming loads offsets from and commits to this
+	regex       bool
 
 	////////////////////////////
 	// CONSUMER GROUP SECTION //
@@ -149,6 +174,7 @@ type cfg struct {
 	instanceID *string         // optional group instance ID
 	balancers  []GroupBalancer // balancers we can use
 	protocol   string          // "consumer" by default, expected to never be overridden
+	membership int8            // classic by default; see GroupMembershipProtocol
 
 	sessionTimeout    time.Duration
 	rebalanceTimeout  time.Duration
@@ -159,6 +185,8 @@ type cfg struct {
 	onRevoked  func(context.Context, *Client, map[string][]int32)
 	onLost     func(context.Context, *Client, map[string][]int32)
 
+	interruptPollOnRebalance bool
+
 	adjustOffsetsBeforeAssign func(ctx context.Context, offsets map[string]map[int32]Offset) (map[string]map[int32]Offset, error)
 
 	setAssigned       bool
@@ -169,6 +197,7 @@ type cfg struct {
 	autocommitDisable  bool // true if autocommit was disabled or we are transactional
 	autocommitGreedy   bool
 	autocommitMarks    bool
+	autocommitBefore   bool
 	autocommitInterval time.Duration
 	commitCallback     func(*Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error)
 }
@@ -194,6 +223,9 @@ func (cfg *cfg) validate() error {
 		cfg.maxPartBytes = cfg.maxBytes
 	}
 
+	if cfg.deadLetterTopic != "" && cfg.txnID != nil {
+		return errors.New("cannot use a dead letter topic with transactions")
+	}
 	if cfg.disableIdempotency && cfg.txnID != nil {
 		return errors.New("cannot both disable idempotent writes and use transactional IDs")
 	}
@@ -255,6 +287,12 @@ func (cfg *cfg) validate() error {
 		{name: "max record batch bytes", v: int64(cfg.maxRecordBatchBytes), allowed: 512, badcmp: i64lt},
 		{name: "max record batch bytes", v: int64(cfg.maxRecordBatchBytes), allowed: 268435454, badcmp: i64gt},
 
+		// Compressing is skipped for batches smaller than this; a
+		// threshold larger than the max batch size would never
+		// compress and is likely a mistake.
+		{name: "min batch compression bytes", v: int64(cfg.minCompressBytes), allowed: 0, badcmp: i64lt},
+		{v: int64(cfg.minCompressBytes), allowed: int64(cfg.maxRecordBatchBytes), badcmp: i64gt, fmt: "min batch compression bytes %v is erroneously larger than max record batch bytes %v"},
+
 		// We do not want the broker write bytes to be less than the
 		// record batch bytes, nor the read bytes to be less than what
 		// we indicate to fetch.
@@ -266,6 +304,15 @@ func (cfg *cfg) validate() error {
 
 		// 0 <= allowed concurrency
 		{name: "max concurrent fetches", v: int64(cfg.maxConcurrentFetches), allowed: 0, badcmp: i64lt},
+		{name: "max concurrent decodes", v: int64(cfg.maxConcurrentDecodes), allowed: 0, badcmp: i64lt},
+		{name: "fetch max bytes total", v: cfg.maxBytesTotal, allowed: 0, badcmp: i64lt},
+
+		// 1 <= produce inflight <= 255, and no more per partition than
+		// per broker.
+		{name: "max produce requests inflight per broker", v: int64(cfg.maxBrokerInflight), allowed: 1, badcmp: i64lt},
+		{name: "max produce requests inflight per broker", v: int64(cfg.maxBrokerInflight), allowed: math.MaxUint8, badcmp: i64gt},
+		{name: "max produce batches inflight per partition", v: int64(cfg.maxPartInflight), allowed: 1, badcmp: i64lt},
+		{v: int64(cfg.maxPartInflight), allowed: int64(cfg.maxBrokerInflight), badcmp: i64gt, fmt: "max produce batches inflight per partition %v is erroneously larger than max produce requests inflight per broker %v"},
 
 		// 1s <= request timeout overhead <= 15m
 		{name: "request timeout max overhead", v: int64(cfg.requestTimeoutOverhead), allowed: int64(15 * time.Minute), badcmp: i64gt, durs: true},
@@ -274,6 +321,7 @@ func (cfg *cfg) validate() error {
 		// 1s <= conn idle <= 15m
 		{name: "conn min idle timeout", v: int64(cfg.connIdleTimeout), allowed: int64(time.Second), badcmp: i64lt, durs: true},
 		{name: "conn max idle timeout", v: int64(cfg.connIdleTimeout), allowed: int64(15 * time.Minute), badcmp: i64gt, durs: true},
+		{name: "max broker connections", v: int64(cfg.maxBrokerCxns), allowed: 0, badcmp: i64lt},
 
 		// 10ms <= metadata <= 1hr
 		{name: "metadata max age", v: int64(cfg.metadataMaxAge), allowed: int64(time.Hour), badcmp: i64gt, durs: true},
@@ -282,6 +330,10 @@ func (cfg *cfg) validate() error {
 
 		// Some random producer settings.
 		{name: "max buffered records", v: int64(cfg.maxBufferedRecords), allowed: 1, badcmp: i64lt},
+		{name: "produce rate limit bytes per second", v: cfg.rateLimitBytes, allowed: 0, badcmp: i64lt},
+		{name: "produce rate limit records per second", v: cfg.rateLimitRecords, allowed: 0, badcmp: i64lt},
+		{name: "unknown topic retry window", v: int64(cfg.unknownTopicWindow), allowed: 0, badcmp: i64lt, durs: true},
+		{name: "unavailable partition wait timeout", v: int64(cfg.unavailable.timeout), allowed: 0, badcmp: i64lt, durs: true},
 		{name: "linger", v: int64(cfg.linger), allowed: int64(time.Minute), badcmp: i64gt, durs: true},
 		{name: "produce timeout", v: int64(cfg.produceTimeout), allowed: int64(100 * time.Millisecond), badcmp: i64lt, durs: true},
 		{name: "record timeout", v: int64(cfg.recordTimeout), allowed: int64(time.Second), badcmp: func(l, r int64) (bool, string) {
@@ -330,6 +382,14 @@ func (cfg *cfg) validate() error {
 		}
 	}
 
+	if cfg.breakerFailures > 0 && (cfg.breakerMinCooldown <= 0 || cfg.breakerMaxCooldown < cfg.breakerMinCooldown) {
+		return fmt.Errorf("invalid broker circuit breaker cooldowns min %v and max %v, min must be positive and at most max", cfg.breakerMinCooldown, cfg.breakerMaxCooldown)
+	}
+
+	if cfg.offsetStore != nil && len(cfg.group) > 0 {
+		return errors.New("invalid offset store option when consuming as a group")
+	}
+
 	if cfg.regex {
 		if len(cfg.partitions) != 0 {
 			return errors.New("invalid direct-partition consuming option when consuming as regex")
@@ -352,12 +412,24 @@ func (cfg *cfg) validate() error {
 	if cfg.autocommitGreedy && cfg.autocommitMarks {
 		return errors.New("cannot enable both greedy autocommitting and marked autocommitting")
 	}
-	if (cfg.autocommitGreedy || cfg.autocommitDisable || cfg.autocommitMarks || cfg.setCommitCallback) && len(cfg.group) == 0 {
+	if cfg.autocommitBefore && (cfg.autocommitDisable || cfg.autocommitGreedy || cfg.autocommitMarks) {
+		return errors.New("cannot combine committing before delivery with disabled, greedy, or marked autocommitting")
+	}
+	if cfg.lazyDecoding && (cfg.keepControl || cfg.autocommitBefore) {
+		return errors.New("cannot use lazy record decoding with kept control records nor with committing before delivery")
+	}
+	if cfg.autocommitBefore && cfg.txnID != nil {
+		return errors.New("cannot commit before delivery when consuming transactionally")
+	}
+	if (cfg.autocommitGreedy || cfg.autocommitDisable || cfg.autocommitMarks || cfg.autocommitBefore || cfg.setCommitCallback) && len(cfg.group) == 0 {
 		return errors.New("invalid autocommit options specified when a group was not specified")
 	}
 	if (cfg.setLost || cfg.setRevoked || cfg.setAssigned) && len(cfg.group) == 0 {
 		return errors.New("invalid group partition assigned/revoked/lost functions set when a group was not specified")
 	}
+	if cfg.interruptPollOnRebalance && len(cfg.group) == 0 {
+		return errors.New("invalid InterruptPollOnRebalance when a group was not specified")
+	}
 
 	return nil
 }
@@ -450,6 +522,8 @@ func defaultCfg() cfg {
 		maxRecordBatchBytes: 1000000, // Kafka max.message.bytes default is 1000012
 		maxBufferedRecords:  10000,
 		produceTimeout:      10 * time.Second,
+		maxBrokerInflight:   1,
+		maxPartInflight:     1,
 		recordRetries:       math.MaxInt64,             // effectively unbounded
 		partitioner:         StickyKeyPartitioner(nil), // default to how Kafka partitions
 
@@ -539,8 +613,39 @@ func RequestTimeoutOverhead(overhead time.Duration) Opt {
 	return clientOpt{func(cfg *cfg) { cfg.requestTimeoutOverhead = overhead }}
 }
 
+// BrokerCircuitBreaker enables a per-broker circuit breaker for connections,
+// which stops the client from repeatedly dialing a broker that is down.
+//
+// After failures consecutive failed connection attempts (dialing,
+// authenticating, or initializing the connection) to a broker, the broker's
+// circuit opens: no connections are attempted for a cooldown, and requests
+// that need a new connection to the broker fail immediately with
+// ErrBrokerCircuitOpen. Once the cooldown elapses, the circuit is half open
+// and a single connection attempt is allowed. If it succeeds, the circuit
+// closes; if it fails, the circuit opens again with double the cooldown,
+// starting at minCooldown and capped at maxCooldown. Any successful
+// connection or request resets the breaker. Connection attempts that fail
+// because the request's context was canceled or the client closed do not
+// count as failures.
+//
+// The circuit state is logged and passed to HookBrokerCircuitState hooks. By
+// default, there is no circuit breaker.
+func BrokerCircuitBreaker(failures int, minCooldown, maxCooldown time.Duration) Opt {
+	return clientOpt{func(cfg *cfg) {
+		cfg.breakerFailures = failures
+		cfg.breakerMinCooldown = minCooldown
+		cfg.breakerMaxCooldown = maxCooldown
+	}}
+}
+
 // ConnIdleTimeout is a rough amount of time to allow connections to idle
-// before they are closed, overriding the default 20.
+// before they are closed, overriding the default 20s. Closed connections are
+// reopened on demand the next time a request is issued to the broker.
+//
+// This is similar to Kafka's connections.max.idle.ms. Brokers close
+// connections that idle past their own connections.max.idle.ms (10m by
+// default); reaping connections first avoids requests failing on connections
+// the broker already closed.
 //
 // In the worst case, a connection can be allowed to idle for up to 2x this
 // time, while the average is expected to be 1.5x (essentially, a uniform
@@ -550,12 +655,41 @@ func RequestTimeoutOverhead(overhead time.Duration) Opt {
 // written to, but the client internally retries in these cases.
 //
 // Connections are not reaped if they are actively being written to or read
-// from; thus, a request can take a really long time itself and not be reaped
-// (however, this may lead to the RequestTimeoutOverhead).
+// from, or if any request on them is still awaiting a response; thus, a request
+// can take a really long time itself and not be reaped (however, this may lead
+// to the RequestTimeoutOverhead). Group coordinator connections are kept active
+// by heartbeats, so they are not reaped while the client is in a group.
 func ConnIdleTimeout(timeout time.Duration) Opt {
 	return clientOpt{func(cfg *cfg) { cfg.connIdleTimeout = timeout }}
 }
 
+// MaxBrokerConnections caps the number of connections the client opens to
+// each broker, overriding the default of no cap (0).
+//
+// By default, the client opens up to five connections per broker: one each
+// for produce requests, fetch requests, group join and sync requests,
+// requests that have a timeout field, and all other requests. This keeps slow
+// requests from delaying fast ones. With a cap, once a broker has this many
+// open connections, a request that would open a new connection is instead
+// pipelined on an existing connection to the broker. This bounds file
+// descriptor usage in large clusters at the cost of potentially higher
+// latency, because a broker processes requests on one connection in order:
+// a request queued behind a long polling fetch waits for that fetch.
+//
+// The group connection is never capped, so that join and sync requests,
+// which can block for an entire rebalance, cannot hold up other requests.
+// Other group requests, such as heartbeats, offset commits, and leaving the
+// group, use the normal connection and are subject to the cap: with a low
+// cap, a heartbeat may wait behind a long polling fetch, so the session
+// timeout should account for the fetch max wait. Similarly, produce requests
+// with acks=0 always use their own connection, since the broker does not
+// reply to them. These connections do not count against the cap.
+//
+// OpenConnections can be used to observe the number of open connections.
+func MaxBrokerConnections(n int) Opt {
+	return clientOpt{func(cfg *cfg) { cfg.maxBrokerCxns = n }}
+}
+
 // Dialer uses fn to dial addresses, overriding the default dialer that uses a
 // 10s dial timeout and no TLS.
 //
@@ -593,6 +727,9 @@ func DialTLSConfig(c *tls.Config) Opt {
 			if err != nil {
 				return nil, fmt.Errorf("unable to split host:port for dialing: %w", err)
 			}
+			if resolved, ok := ctx.Value(dialHostKey{}).(string); ok {
+				server = resolved
+			}
 			c.ServerName = server
 		}
 		return (&tls.Dialer{
@@ -602,6 +739,47 @@ func DialTLSConfig(c *tls.Config) Opt {
 	})
 }
 
+// AddressPreference is the order to dial addresses in when a broker host
+// resolves to multiple addresses; see DialAddressPreference.
+type AddressPreference int8
+
+const (
+	// PreferAnyAddress dials addresses in the order they are resolved.
+	PreferAnyAddress AddressPreference = iota
+	// PreferIPv4 dials IPv4 addresses before IPv6 addresses.
+	PreferIPv4
+	// PreferIPv6 dials IPv6 addresses before IPv4 addresses.
+	PreferIPv6
+)
+
+// DialResolver uses fn to resolve broker hosts to addresses before dialing,
+// overriding the default of letting the dialer resolve hosts itself.
+//
+// If a resolver or an address preference (see DialAddressPreference) is
+// specified, the client resolves a broker's host every time it opens a
+// connection (resolved addresses are never cached, so a broker that moves is
+// found on the next reconnect) and dials each resolved address in order until
+// one succeeds. Each address is passed to the dial function as ip:port, with
+// the dial function's own timeout applying per address. Hosts that are
+// already IP addresses are not resolved.
+//
+// This function has the same signature as net.Resolver's LookupHost. Note
+// that if you use the Dialer option with your own TLS dialer, you must set
+// the TLS config's ServerName yourself, since the dialed host is an IP
+// address. DialTLSConfig handles this automatically.
+func DialResolver(fn func(ctx context.Context, host string) ([]string, error)) Opt {
+	return clientOpt{func(cfg *cfg) { cfg.resolveFn = fn }}
+}
+
+// DialAddressPreference sets the order to dial addresses in when a broker
+// host resolves to multiple addresses, overriding the default
+// PreferAnyAddress. Setting a preference opts in to the client resolving hosts
+// itself, using net.DefaultResolver if DialResolver is not specified; see
+// DialResolver for more details.
+func DialAddressPreference(p AddressPreference) Opt {
+	return clientOpt{func(cfg *cfg) { cfg.addrPreference = p }}
+}
+
 // SeedBrokers sets the seed brokers for the client to use, overriding the
 // default 127.0.0.1:9092.
 //
@@ -649,6 +827,26 @@ func RetryBackoffFn(backoff func(int) time.Duration) Opt {
 	return clientOpt{func(cfg *cfg) { cfg.retryBackoff = backoff }}
 }
 
+// DiscoveryBackoffFn sets the backoff strategy for retrying discovery, which
+// is separate from the RetryBackoffFn used for normal request retries.
+// Discovery covers metadata requests (including bootstrapping), FindCoordinator
+// requests, and any request that fails because its coordinator is loading,
+// unavailable, or has moved. The function is called with the number of tries
+// so far and the error that is being retried.
+//
+// By default, discovery uses the RetryBackoffFn, but waits at least 1s when
+// retrying COORDINATOR_LOAD_IN_PROGRESS: a coordinator loading a large group or
+// transaction log can take a while, and retrying quickly only adds load.
+//
+// The total time spent retrying discovery is bounded by RetryTimeoutFn for
+// the request being retried; for example, a RetryTimeoutFn that returns a
+// small timeout for the FindCoordinator key bounds coordinator discovery. A
+// request that stops retrying discovery because of its retry timeout fails
+// with *ErrDiscoveryTimeout.
+func DiscoveryBackoffFn(backoff func(int, error) time.Duration) Opt {
+	return clientOpt{func(cfg *cfg) { cfg.discoveryBackoff = backoff }}
+}
+
 // RequestRetries sets the number of tries that retriable requests are allowed,
 // overriding the default of 20.
 //
@@ -704,6 +902,12 @@ func RetryTimeoutFn(t func(int16) time.Duration) Opt {
 
 // AllowAutoTopicCreation enables topics to be auto created if they do
 // not exist when fetching their metadata.
+//
+// By default, every metadata request the client issues sets
+// AllowAutoTopicCreation to false, so that looking up a topic that does not
+// exist does not create it, even if the cluster has auto.create.topics.enable
+// set. Note that brokers older than Kafka 0.11.0 do not support this flag and
+// always auto create topics if the cluster allows it.
 func AllowAutoTopicCreation() Opt {
 	return clientOpt{func(cfg *cfg) { cfg.allowAutoTopicCreation = true }}
 }
@@ -747,6 +951,12 @@ func MetadataMaxAge(age time.Duration) Opt {
 // Additionally, any connection failures causing backoff while producing or
 // consuming trigger metadata updates, because the client must assume that
 // maybe the connection died due to a broker dying.
+//
+// Concurrent triggers are coalesced into one metadata request. Some triggers,
+// such as a topic not being found or ForceMetadataRefresh, request an
+// immediate update; an immediate update can bypass the min age only once per
+// min age, so that a flapping cluster does not cause a storm of metadata
+// requests.
 func MetadataMinAge(age time.Duration) Opt {
 	return clientOpt{func(cfg *cfg) { cfg.metadataMinAge = age }}
 }
@@ -772,6 +982,16 @@ func WithHooks(hooks ...Hook) Opt {
 	return clientOpt{func(cfg *cfg) { cfg.hooks = append(cfg.hooks, hooks...) }}
 }
 
+// WithZstdCodec sets the zstd implementation to use for compressing and
+// decompressing batches, overriding the default pure Go implementation. This
+// allows using a faster implementation, such as one backed by cgo.
+//
+// The codec is used at whatever level it was created with; the level from
+// ZstdCompression().WithLevel is ignored.
+func WithZstdCodec(codec ZstdCodec) Opt {
+	return clientOpt{func(cfg *cfg) { cfg.zstd = codec }}
+}
+
 ////////////////////////////
 // PRODUCER CONFIGURATION //
 ////////////////////////////
@@ -785,19 +1005,42 @@ func DefaultProduceTopic(t string) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.defaultProduceTopic = t }}
 }
 
+// DeadLetterTopic sets a topic to produce records to if they permanently fail
+// to be produced, for example if they are too large or the topic they are
+// produced to does not exist.
+//
+// A record that fails is copied to the dead letter topic, keeping its key,
+// value, and headers, and adding the DeadLetterTopicHeader and
+// DeadLetterErrorHeader headers. The original record's promise is called once
+// the copy finishes: if the copy is produced, the promise receives an
+// *ErrDeadLettered wrapping the original error; otherwise, the promise
+// receives the original error. Records that fail in the dead letter topic
+// itself are never dead lettered again.
+//
+// Records are not dead lettered if they fail because the client is closing,
+// buffered records are being aborted, the produce context is canceled, or
+// the client is at its max buffered records with manual flushing.
+//
+// Dead lettering cannot be used with transactions.
+func DeadLetterTopic(topic string) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.deadLetterTopic = topic }}
+}
+
 // Acks represents the number of acks a broker leader must have before
 // a produce request is considered complete.
 //
 // This controls the durability of written records and corresponds to "acks" in
 // Kafka's Producer Configuration documentation.
 //
-// The default is LeaderAck.
+// The default is AllISRAcks.
 type Acks struct {
 	val int16
 }
 
 // NoAck considers records sent as soon as they are written on the wire.
-// The leader does not reply to records.
+// The leader does not reply to records, and the client does not wait for or
+// read any response: produce errors are never seen, and the offsets of
+// produced records are meaningless.
 func NoAck() Acks { return Acks{0} }
 
 // LeaderAck causes Kafka to reply that a record is written after only
@@ -807,10 +1050,19 @@ func LeaderAck() Acks { return Acks{1} }
 
 // AllISRAcks ensures that all in-sync replicas have acknowledged they
 // wrote a record before the leader replies success.
+//
+// If the partition has fewer in-sync replicas than the topic's
+// min.insync.replicas, Kafka replies NOT_ENOUGH_REPLICAS (or
+// NOT_ENOUGH_REPLICAS_AFTER_APPEND if the leader already wrote the records).
+// These errors are retried with backoff; if they persist past RecordRetries or
+// RecordDeliveryTimeout, records fail with the last such error.
 func AllISRAcks() Acks { return Acks{-1} }
 
 // RequiredAcks sets the required acks for produced records,
-// overriding the default RequireAllISRAcks.
+// overriding the default AllISRAcks.
+//
+// Idempotent production requires AllISRAcks; using any other acks requires
+// DisableIdempotentWrite.
 func RequiredAcks(acks Acks) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.acks = acks }}
 }
@@ -823,11 +1075,50 @@ func RequiredAcks(acks Acks) ProducerOpt {
 // IDEMPOTENT_WRITE permission on CLUSTER (pre Kafka 3.0), and not all clients
 // can have that permission.
 //
+// If the client cannot initialize a producer ID because the broker is too old
+// to support idempotency, the client logs a warning and continues producing
+// without idempotency. If initializing fails with CLUSTER_AUTHORIZATION_FAILED,
+// the client does not fall back: all buffered and future records fail with
+// kerr.ClusterAuthorizationFailed. Use this option to produce to clusters
+// where the client lacks the permission.
+//
 // This option is incompatible with specifying a transactional id.
 func DisableIdempotentWrite() ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.disableIdempotency = true }}
 }
 
+// MaxProduceRequestsInflightPerBroker changes the number of produce requests
+// that can be in flight to a single broker at once when idempotency is
+// disabled, overriding the default of 1.
+//
+// With idempotency, this option has no effect: Kafka v0.11 allows only one
+// idempotent produce request in flight, and Kafka v1.0+ allows five while
+// still guaranteeing ordering.
+//
+// Raising this allows batches for different partitions to be produced to the
+// same broker concurrently. By default, batches of a single partition are
+// still produced one at a time; see MaxProduceBatchesInflightPerPartition.
+func MaxProduceRequestsInflightPerBroker(n int) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.maxBrokerInflight = n }}
+}
+
+// MaxProduceBatchesInflightPerPartition changes the number of record batches
+// for a single partition that can be in flight at once when idempotency is
+// disabled, overriding the default of 1. This cannot be larger than
+// MaxProduceRequestsInflightPerBroker.
+//
+// With the default of 1, a batch is not produced while an earlier batch for
+// the same partition is in flight or being retried, so records within a
+// partition are always written in order. Raising this can increase
+// throughput, but if a batch fails and is retried, a later batch for the
+// same partition may be written before it, reordering records.
+//
+// With idempotency, this option has no effect: Kafka rejects any batch that
+// is out of sequence, and the client retries batches in order.
+func MaxProduceBatchesInflightPerPartition(n int) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.maxPartInflight = n }}
+}
+
 // ProducerBatchCompression sets the compression codec to use for producing
 // records.
 //
@@ -843,6 +1134,18 @@ func ProducerBatchCompression(preference ...CompressionCodec) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.compression = preference }}
 }
 
+// ProducerBatchCompressionMinBytes sets the minimum size, in bytes, that the
+// records in a batch must encode to before the batch is compressed,
+// overriding the default of 0 (always try compressing).
+//
+// Compressing a tiny batch wastes CPU and often grows the batch. Batches
+// smaller than this threshold are sent uncompressed even if a compression
+// codec is configured. Independent of this option, if compressing a batch
+// does not shrink it, the uncompressed form is used.
+func ProducerBatchCompressionMinBytes(v int32) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.minCompressBytes = v }}
+}
+
 // ProducerBatchMaxBytes upper bounds the size of a record batch, overriding
 // the default 1MB.
 //
@@ -882,8 +1185,15 @@ func RecordPartitioner(partitioner Partitioner) ProducerOpt {
 //
 // This somewhat corresponds to Kafka's request.timeout.ms setting, but only
 // applies to produce requests. This settings sets the TimeoutMillis field in
-// the produce request itself. The ConnTimeoutOverhead is applied as a write
-// limit and read limit in addition to this.
+// the produce request itself, which is how long the broker waits for the
+// required acks. The client side read deadline for a produce response is this
+// timeout plus the RequestTimeoutOverhead, so the client never times out a
+// connection before the broker has had the chance to reply.
+//
+// If a RecordDeliveryTimeout is set, it should be at least this timeout; a
+// shorter delivery timeout can fail records that the broker may still be
+// writing, and the client logs a warning on creation. With NoAck, the broker
+// does not reply to produce requests and this timeout is unused.
 func ProduceRequestTimeout(limit time.Duration) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.produceTimeout = limit }}
 }
@@ -902,8 +1212,9 @@ func ProduceRequestTimeout(limit time.Duration) ProducerOpt {
 // easier sequence number ordering internally.
 //
 // If a topic repeatedly fails to load with UNKNOWN_TOPIC_OR_PARTITION, it has
-// a different, internal retry limit. All records for a topic that repeatedly
-// cannot be loaded are failed when the internal limit is hit.
+// a different, internal retry limit (see UnknownTopicRetryWindow). All records
+// for a topic that repeatedly cannot be loaded are failed when the internal
+// limit is hit.
 //
 // This option is different from RequestRetries to allow finer grained control
 // of when to fail when producing records.
@@ -911,6 +1222,25 @@ func RecordRetries(n int) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.recordRetries = int64(n) }}
 }
 
+// UnknownTopicRetryWindow sets how long records to a topic whose metadata
+// loads with UNKNOWN_TOPIC_OR_PARTITION are retried before they are failed,
+// overriding the default of failing after the topic fails to load five times
+// in a row.
+//
+// A topic that was just created may briefly return UNKNOWN_TOPIC_OR_PARTITION
+// while its metadata propagates through the cluster. Records produced to the
+// topic are buffered while metadata is refreshed, and if the topic loads
+// within the window, the records are produced. A topic that genuinely does
+// not exist keeps failing to load: once the window has passed since the
+// first record to the topic was buffered, the next load that fails with
+// UNKNOWN_TOPIC_OR_PARTITION fails all buffered records for the topic.
+//
+// RecordDeliveryTimeout and RecordRetries still apply while waiting, so the
+// window should be less than RecordDeliveryTimeout if that is set.
+func UnknownTopicRetryWindow(window time.Duration) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.unknownTopicWindow = window }}
+}
+
 // StopProducerOnDataLossDetected sets the client to stop producing if data
 // loss is detected, overriding the default false.
 //
@@ -951,6 +1281,27 @@ func ProducerLinger(linger time.Duration) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.linger = linger }}
 }
 
+// ProduceRateLimit limits how quickly records can be produced, to at most
+// bytesPerSec bytes and recordsPerSec records per second, overriding the
+// default of no limit. A limit of zero disables that limit. Bytes are counted
+// as in BufferedProduceBytes: the size of a record's key, value, and headers.
+//
+// The limit is enforced with a token bucket that holds up to one second of
+// bytes and records, allowing short bursts. Produce blocks while the client is
+// over its limit, before the record is buffered. If the context passed to
+// Produce is canceled or would expire before the record is within the limit,
+// or if the client is closed, the promise is called with the appropriate
+// error and the record is not produced. A record larger than the byte limit
+// is allowed once the bucket is full, and later records wait longer to make
+// up for it.
+//
+// Because records wait before they are buffered, waiting for the rate limit
+// never holds room in MaxBufferedRecords, and Flush only waits for records
+// that have already passed the limit.
+func ProduceRateLimit(bytesPerSec, recordsPerSec int) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.rateLimitBytes, cfg.rateLimitRecords = int64(bytesPerSec), int64(recordsPerSec) }}
+}
+
 // ManualFlushing disables auto-flushing when producing. While you can still
 // set lingering, it would be useless to do so.
 //
@@ -960,6 +1311,57 @@ func ManualFlushing() ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.manualFlushing = true }}
 }
 
+// UnavailablePartitionBehavior controls how records are handled when their
+// partition has no leader, such as during a leader election.
+type UnavailablePartitionBehavior struct {
+	fail    bool
+	timeout time.Duration
+}
+
+// WaitForLeader (the default, with a zero timeout) is an unavailable
+// partition behavior that buffers records while metadata is refreshed until
+// the partition has a leader. If the timeout is non-zero, records are failed
+// once their partition has been without a leader for longer than the timeout.
+func WaitForLeader(timeout time.Duration) UnavailablePartitionBehavior {
+	return UnavailablePartitionBehavior{timeout: timeout}
+}
+
+// FailWithoutLeader is an unavailable partition behavior that immediately
+// fails records whose partition has no leader, rather than buffering them.
+func FailWithoutLeader() UnavailablePartitionBehavior {
+	return UnavailablePartitionBehavior{fail: true}
+}
+
+// expired returns whether records for a partition that has been without a
+// leader since the given time should be failed.
+func (b UnavailablePartitionBehavior) expired(since, now time.Time) bool {
+	return b.fail || b.timeout > 0 && now.Sub(since) >= b.timeout
+}
+
+// UnavailablePartitions sets how records are handled when their partition has
+// no leader, overriding the default WaitForLeader(0).
+//
+// Records to a partition without a leader can either wait for the partition to
+// elect a new leader or fail fast, trading resilience for latency. Failed
+// records are failed with the partition's metadata load error, which is
+// usually kerr.LeaderNotAvailable. Note that most partitioners only choose
+// from partitions that have a leader; this option mostly affects records that
+// were already buffered when their partition lost its leader, and records
+// partitioned with a key or manually.
+//
+// A partition is only known to be without a leader after a metadata refresh,
+// and a WaitForLeader timeout is only evaluated when metadata is refreshed or
+// when a new record is partitioned. RecordRetries and RecordDeliveryTimeout
+// still apply while waiting, so the wait timeout should be less than the
+// delivery timeout if that is set.
+//
+// If idempotency is enabled (as it is by default), buffered records are only
+// failed if it is safe to do so without creating invalid sequence numbers; see
+// RecordDeliveryTimeout for more details.
+func UnavailablePartitions(behavior UnavailablePartitionBehavior) ProducerOpt {
+	return producerOpt{func(cfg *cfg) { cfg.unavailable = behavior }}
+}
+
 // RecordDeliveryTimeout sets a rough time of how long a record can sit around
 // in a batch before timing out, overriding the unlimited default.
 //
@@ -982,6 +1384,9 @@ func ManualFlushing() ProducerOpt {
 // The timeout is only evaluated evaluated before writing a request or after a
 // produce response. Thus, a sink backoff may delay record timeout slightly.
 //
+// Unless producing with NoAck, this timeout should be at least the
+// ProduceRequestTimeout; see that option for why.
+//
 // This option is roughly equivalent to delivery.timeout.ms.
 func RecordDeliveryTimeout(timeout time.Duration) ProducerOpt {
 	return producerOpt{func(cfg *cfg) { cfg.recordTimeout = timeout }}
@@ -1048,6 +1453,12 @@ func FetchMaxWait(wait time.Duration) ConsumerOpt {
 // client sends a fetch to each broker concurrently, meaning the client will
 // buffer up to <brokers * max bytes> worth of memory.
 //
+// Every partition led by a broker is fetched in one request to that broker,
+// with each partition limited by FetchMaxPartitionBytes. The broker stops
+// filling the response once this limit is hit, so the client rotates which
+// partition is requested first on every fetch to avoid starving partitions
+// at the end of the request.
+//
 // This corresponds to the Java fetch.max.bytes setting.
 //
 // If bumping this, consider bumping BrokerMaxReadBytes.
@@ -1103,12 +1514,59 @@ func FetchMaxPartitionBytes(b int32) ConsumerOpt {
 // broker that has new data. For high throughput topics, or if the allowed
 // concurrent fetches is large enough, this should not be a concern.
 //
+// Each broker has at most one fetch in flight or buffered: while a buffered
+// fetch is being processed, fetches to other brokers continue, which overlaps
+// processing with fetching. Paused partitions are not fetched. In a group,
+// fetching stops only for partitions that are revoked: eager balancers revoke
+// everything when a rebalance begins, while cooperative balancers keep
+// fetching partitions that remain assigned. To bound buffered memory by bytes
+// rather than by requests, see FetchMaxBytesTotal.
+//
 // A value of 0 implies the allowed concurrency is unbounded and will be
 // limited only by the number of brokers in the cluster.
 func MaxConcurrentFetches(n int) ConsumerOpt {
 	return consumerOpt{func(cfg *cfg) { cfg.maxConcurrentFetches = n }}
 }
 
+// FetchMaxBytesTotal sets the maximum amount of fetched bytes the client
+// buffers across all partitions before pausing new fetches, overriding the
+// unbounded default. Buffered bytes are the raw record batch bytes in fetch
+// responses that have not yet been polled.
+//
+// While the client has at least this many bytes buffered, it does not issue
+// new fetch requests. Fetching resumes as the application polls: a fetch
+// response's bytes are released once every record from that response has been
+// polled, similar to how MaxConcurrentFetches tracks a fetch as complete.
+//
+// This is a soft limit: fetches already in flight when the limit is reached
+// are still buffered, so the client can buffer up to roughly this limit plus
+// FetchMaxBytes for every broker. If nothing is buffered, the client always
+// issues a fetch, so a single response larger than this limit is buffered
+// (with a warning log) rather than blocking consuming forever.
+//
+// A value of 0 implies no limit.
+func FetchMaxBytesTotal(b int64) ConsumerOpt {
+	return consumerOpt{func(cfg *cfg) { cfg.maxBytesTotal = b }}
+}
+
+// MaxConcurrentDecodes sets the maximum number of fetched partitions to decode
+// (and decompress) at once across all brokers, overriding the default of
+// decoding every fetch response serially in a goroutine per broker.
+//
+// When consuming many partitions across many brokers, decoding can be the
+// most CPU intensive part of consuming. Setting this option decodes the
+// partitions of each fetch response concurrently, with at most n partitions
+// decoding at once client wide, which bounds CPU used by decoding and spreads
+// large fetch responses across cores. A good value is often
+// runtime.GOMAXPROCS(0).
+//
+// Records within a partition are always decoded by a single goroutine and
+// are returned in order. With this option set, HookFetchBatchRead hooks may
+// be called concurrently for different partitions.
+func MaxConcurrentDecodes(n int) ConsumerOpt {
+	return consumerOpt{func(cfg *cfg) { cfg.maxConcurrentDecodes = n }}
+}
+
 // ConsumeResetOffset sets the offset to restart consuming from when a
 // partition has no commits (for groups) or when beginning to consume a
 // partition (for direct partition consuming), or when a fetch sees an
@@ -1151,11 +1609,34 @@ func FetchIsolationLevel(level IsolationLevel) ConsumerOpt {
 // KeepControlRecords sets the client to keep control messages and return
 // them with fetches, overriding the default that discards them.
 //
-// Generally, control messages are not useful.
+// Generally, control messages are not useful. Kept control records can be
+// identified and skipped with Record.Attrs.IsControl.
 func KeepControlRecords() ConsumerOpt {
 	return consumerOpt{func(cfg *cfg) { cfg.keepControl = true }}
 }
 
+// LazyRecordDecoding sets the client to not decompress or decode fetched
+// record batches, instead returning them in FetchPartition.LazyBatches. A
+// batch is decompressed only when its records are accessed, and a record's
+// value and headers are only read when accessed. This can save significant
+// CPU and memory when most records are filtered by key, or are skipped
+// entirely, without being fully used.
+//
+// Only v2 record batches are decoded lazily; records from older message set
+// formats are returned in FetchPartition.Records as usual. Lazy batches are
+// not passed to per-record fetch hooks, and PollRecords does not split lazy
+// batches: if a partition has lazy batches, all of its buffered batches are
+// returned at once, which can exceed the requested number of records.
+//
+// Lazy batches still have their CRC verified when fetched; see
+// ErrCorruptBatch.
+//
+// This option cannot be used with KeepControlRecords nor with
+// AutoCommitBeforeDelivery.
+func LazyRecordDecoding() ConsumerOpt {
+	return consumerOpt{func(cfg *cfg) { cfg.lazyDecoding = true }}
+}
+
 // ConsumeTopics adds topics to use for consuming.
 //
 // By default, consuming will start at the beginning of partitions. To change
@@ -1185,6 +1666,31 @@ func ConsumePartitions(partitions map[string]map[int32]Offset) ConsumerOpt {
 	return consumerOpt{func(cfg *cfg) { cfg.partitions = partitions }}
 }
 
+// ConsumeOffsetStore sets an external store to load starting offsets from and
+// to commit offsets to when consuming directly (i.e., not in a group).
+//
+// Whenever the client begins consuming new partitions, it loads offsets for
+// those partitions from the store. Partitions that the store returns offsets
+// for are consumed from the stored offset; all other partitions are consumed
+// from the offset specified in ConsumePartitions, or the ConsumeResetOffset.
+// If loading fails, the error is logged and loading is retried on the next
+// metadata update. The context passed to FetchOffsets is canceled after the
+// OffsetFetch RetryTimeout, or when the client is closed. New partitions are
+// not consumed until their offsets are loaded, but loading does not block
+// polling partitions that are already being consumed.
+//
+// Offsets are committed to the store with CommitRecordsToOffsetStore; the
+// client never commits to the store on its own.
+//
+// This option bypasses Kafka's group offset management entirely: nothing is
+// committed to or fetched from Kafka. This is useful for committing offsets
+// in the same transaction as the results of processing records (for example,
+// in a database), which allows exactly once processing with an external
+// transactional sink. This option is not compatible with group consuming.
+func ConsumeOffsetStore(store OffsetStore) ConsumerOpt {
+	return consumerOpt{func(cfg *cfg) { cfg.offsetStore = store }}
+}
+
 // ConsumeRegex sets the client to parse all topics passed to ConsumeTopics as
 // regular expressions.
 //
@@ -1225,6 +1731,23 @@ func DisableFetchSessions() ConsumerOpt {
 	return consumerOpt{func(cfg *cfg) { cfg.disableFetchSessions = true }}
 }
 
+// ConsumerOnDataLossDetected sets a function to call if the client detects
+// log truncation while consuming, which can happen after an unclean leader
+// election.
+//
+// When a partition's leader epoch changes, the client validates its position
+// with an OffsetForLeaderEpoch request (Kafka 2.1+). If the broker reports
+// that the epoch the client last consumed from ends before the client's
+// current offset, the records in between were lost and the client resets its
+// position to the returned end offset. The loss is always surfaced as an
+// *ErrDataLoss in a fetch; this function allows reacting to the loss as soon
+// as it is detected.
+//
+// This function is called serially and should not block.
+func ConsumerOnDataLossDetected(fn func(*ErrDataLoss)) ConsumerOpt {
+	return consumerOpt{func(cfg *cfg) { cfg.onConsumeDataLoss = fn }}
+}
+
 //////////////////////////////////
 // CONSUMER GROUP CONFIGURATION //
 //////////////////////////////////
@@ -1395,6 +1918,25 @@ func OnPartitionsLost(onLost func(context.Context, *Client, map[string][]int32))
 	return groupOpt{func(cfg *cfg) { cfg.onLost, cfg.setLost = onLost, true }}
 }
 
+// InterruptPollOnRebalance sets the client to return from any pending or
+// next poll as soon as the group heartbeat detects a rebalance, overriding
+// the default of rebalancing in the background without telling the poll
+// loop.
+//
+// The poll returns a fake fetch with a partition error of
+// kerr.RebalanceInProgress (and no topic), which can be checked with
+// Fetches.Errors. The signal is injected before OnPartitionsRevoked is
+// called, and the rebalance does not proceed until OnPartitionsRevoked
+// returns. Applications can use this to finish their current work and
+// checkpoint (commit) before partitions are reassigned, coordinating with
+// OnPartitionsRevoked if that callback must wait for the poll loop.
+//
+// The signal is injected once per rebalance. It is not injected when leaving
+// the group or when partitions are lost; see OnPartitionsLost for the latter.
+func InterruptPollOnRebalance() GroupOpt {
+	return groupOpt{func(cfg *cfg) { cfg.interruptPollOnRebalance = true }}
+}
+
 // DisableAutoCommit disable auto committing.
 //
 // If you disable autocommitting, you may want to use a custom
@@ -1446,6 +1988,31 @@ func AutoCommitMarks() GroupOpt {
 	return groupOpt{func(cfg *cfg) { cfg.autocommitMarks = true }}
 }
 
+// AutoCommitBeforeDelivery switches the group consumer to at-most-once
+// delivery: every poll synchronously commits the offsets of the records it is
+// about to return before returning them.
+//
+// By default, the group consumer is at-least-once: records are committed some
+// time after they are polled, so if your application crashes after polling
+// but before the commit, the records are consumed again after a restart or
+// rebalance. With this option, a crash after polling instead drops the polled
+// records: they were committed, and they will not be consumed again. Only use
+// this option if losing records is preferable to processing them twice.
+//
+// If the commit fails, the polled records are not returned. Instead, the
+// returned Fetches contain the commit error for each polled partition, and the
+// partitions are rewound so that the records are fetched (and committed)
+// again on a later poll. The commit is not canceled by canceling the poll's
+// context; it is instead bounded by the group's session timeout.
+//
+// Committing on every poll adds a round trip to every poll. This option cannot
+// be combined with DisableAutoCommit, GreedyAutoCommit, AutoCommitMarks, or a
+// transactional ID. Periodic autocommitting continues to run, which is
+// harmless since it commits what was already committed.
+func AutoCommitBeforeDelivery() GroupOpt {
+	return groupOpt{func(cfg *cfg) { cfg.autocommitBefore = true }}
+}
+
 // InstanceID sets the group consumer's instance ID, switching the group member
 // from "dynamic" to "static".
 //
@@ -1482,6 +2049,61 @@ func GroupProtocol(protocol string) GroupOpt {
 	return groupOpt{func(cfg *cfg) { cfg.protocol = protocol }}
 }
 
+// GroupMembershipProtocol controls which protocol a group member uses to join
+// and stay in its group.
+type GroupMembershipProtocol struct {
+	p int8
+}
+
+const (
+	membershipClassic int8 = iota
+	membershipConsumer
+	membershipAuto
+)
+
+// ClassicGroupMembership (the default) joins groups with JoinGroup and
+// SyncGroup and stays in them with Heartbeat requests. One member of the
+// group, the leader, balances partitions with the configured Balancers.
+func ClassicGroupMembership() GroupMembershipProtocol {
+	return GroupMembershipProtocol{membershipClassic}
+}
+
+// ConsumerGroupMembership uses the consumer group protocol introduced in
+// KIP-848. The member joins, stays in, and leaves the group solely with
+// ConsumerGroupHeartbeat requests. The broker computes assignments and
+// moves partitions between members incrementally, so there is no group wide
+// rebalance: a member only revokes the partitions it is losing, and only
+// once it acknowledges the revocation can those partitions be assigned to
+// another member.
+//
+// With this protocol, the group's balancing is done by the broker and the
+// Balancers option is ignored. The session timeout and heartbeat interval
+// are also configured on the broker and the SessionTimeout and
+// HeartbeatInterval options are ignored. OnPartitionsAssigned,
+// OnPartitionsRevoked, and OnPartitionsLost are called as with a
+// cooperative balancer: OnPartitionsRevoked is only called with partitions
+// that are moving to another member. CommitAndRelease is not supported.
+//
+// Using this protocol requires the client's MaxVersions to include the
+// ConsumerGroupHeartbeat request (key 68) and OffsetCommit v9, which the
+// default stable versions do not. Commits fail if OffsetCommit v9 cannot be
+// issued, because older versions do not understand the member epoch.
+func ConsumerGroupMembership() GroupMembershipProtocol {
+	return GroupMembershipProtocol{membershipConsumer}
+}
+
+// AutoGroupMembership uses ConsumerGroupMembership if the client's
+// MaxVersions and the group's coordinator support the ConsumerGroupHeartbeat
+// request and OffsetCommit v9, and otherwise falls back to
+// ClassicGroupMembership.
+func AutoGroupMembership() GroupMembershipProtocol { return GroupMembershipProtocol{membershipAuto} }
+
+// GroupMembership sets the protocol the group member uses to join and stay
+// in its group, overriding the default ClassicGroupMembership.
+func GroupMembership(protocol GroupMembershipProtocol) GroupOpt {
+	return groupOpt{func(cfg *cfg) { cfg.membership = protocol.p }}
+}
+
 // AutoCommitCallback sets the callback to use if autocommitting is enabled.
 // This overrides the default callback that logs errors and continues.
 func AutoCommitCallback(fn func(*Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error)) GroupOpt {
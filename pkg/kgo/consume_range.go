@@ -0,0 +1,384 @@
+package kgo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// ConsumeToEnd reads every partition of topic from its log start offset up to
+// the end offset captured when this function begins, calling fn for each
+// record, and then returns. This does not join a group, commit, or affect any
+// consuming the client is configured with; fetch requests are issued directly
+// to partition leaders.
+//
+// End offsets are the high watermarks when this function begins, or the last
+// stable offsets if the client is configured with the ReadCommitted isolation
+// level. Records produced after this function begins are not read. fn is never
+// called concurrently, and records within a partition are passed to fn in
+// offset order.
+//
+// This returns the first error encountered, which may be a request error, a
+// partition error (for example, if a partition's leader changes while
+// reading), or the context error if the context is canceled.
+func (cl *Client) ConsumeToEnd(ctx context.Context, topic string, fn func(*Record)) error {
+	return cl.consumeRanges(ctx, map[string]map[int32][2]Offset{topic: nil}, false, fn)
+}
+
+// ConsumeRange reads an explicit offset range per partition, calling fn for
+// each record, and returns once every range is exhausted. Each range is
+// [start, end): the record at the start offset is the first record passed to
+// fn, and the partition stops once it reaches the end offset. As with
+// ConsumeToEnd, this does not join a group, commit, or affect any consuming
+// the client is configured with, and fn is never called concurrently.
+//
+// Both offsets of a range are resolved when this function begins: AtStart is
+// the log start offset, AtEnd is the high watermark (or last stable offset),
+// AfterMilli lists the offset for the timestamp (or uses the high watermark
+// if no record is at or after the timestamp), and Relative adjusts any of
+// these. Epochs are ignored. For example, a range of NewOffset().AtEnd().
+// Relative(-100) to NewOffset().AtEnd() reads the last 100 records of a
+// partition.
+//
+// A start offset below a partition's log start offset (for example, if
+// retention has deleted the start of the range) is clamped to the log start
+// offset, with a warning logged. An end offset beyond a partition's current
+// high watermark (or last stable offset if reading committed) is clamped to
+// the high watermark if waitForEnd is false; if waitForEnd is true, this
+// function waits for records to be produced until the end offset is reached
+// or the context is canceled. Ranges with a start offset at or past their end
+// offset are skipped.
+//
+// This returns the first error encountered, which may be a request error, a
+// partition error, or the context error if the context is canceled.
+func (cl *Client) ConsumeRange(ctx context.Context, ranges map[string]map[int32][2]Offset, waitForEnd bool, fn func(*Record)) error {
+	// A nil partition map internally means to consume everything, so we
+	// drop any topics that have no partitions.
+	nonempty := make(map[string]map[int32][2]Offset, len(ranges))
+	for t, ps := range ranges {
+		if len(ps) > 0 {
+			nonempty[t] = ps
+		}
+	}
+	if len(nonempty) == 0 {
+		return nil
+	}
+	return cl.consumeRanges(ctx, nonempty, waitForEnd, fn)
+}
+
+// consumeRanges implements ConsumeToEnd and ConsumeRange. A nil partition map
+// for a topic consumes every partition from its log start to its end offset.
+func (cl *Client) consumeRanges(ctx context.Context, ranges map[string]map[int32][2]Offset, waitForEnd bool, fn func(*Record)) error {
+	metaReq := kmsg.NewPtrMetadataRequest()
+	for t := range ranges {
+		metaReqTopic := kmsg.NewMetadataRequestTopic()
+		metaReqTopic.Topic = kmsg.StringPtr(t)
+		metaReq.Topics = append(metaReq.Topics, metaReqTopic)
+	}
+	meta, err := metaReq.RequestWith(ctx, cl)
+	if err != nil {
+		return err
+	}
+
+	var (
+		topicIDs = make(map[string][16]byte)
+		leaders  = make(map[string]map[int32]int32)
+	)
+	for _, mt := range meta.Topics {
+		if mt.Topic == nil {
+			continue
+		}
+		if err := kerr.ErrorForCode(mt.ErrorCode); err != nil {
+			return fmt.Errorf("unable to load metadata for %s: %w", *mt.Topic, err)
+		}
+		topicIDs[*mt.Topic] = mt.TopicID
+		tleaders := make(map[int32]int32, len(mt.Partitions))
+		leaders[*mt.Topic] = tleaders
+		for _, p := range mt.Partitions {
+			tleaders[p.Partition] = p.Leader
+		}
+	}
+
+	// We only list offsets for the partitions we are consuming.
+	consuming := make(map[string]map[int32]int32)
+	for t, ps := range ranges {
+		tleaders, ok := leaders[t]
+		if !ok {
+			return fmt.Errorf("topic %s was missing from the metadata response", t)
+		}
+		if ps == nil {
+			consuming[t] = tleaders
+			continue
+		}
+		tconsuming := make(map[int32]int32, len(ps))
+		consuming[t] = tconsuming
+		for p := range ps {
+			leader, ok := tleaders[p]
+			if !ok {
+				return fmt.Errorf("partition %s[%d] does not exist", t, p)
+			}
+			tconsuming[p] = leader
+		}
+	}
+
+	starts, err := cl.listPartitionOffsets(ctx, consuming, -2)
+	if err != nil {
+		return err
+	}
+	ends, err := cl.listPartitionOffsets(ctx, consuming, -1)
+	if err != nil {
+		return err
+	}
+
+	// Any timestamp offsets are listed per timestamp.
+	byMilli := make(map[int64]map[string]map[int32]int32)
+	for t, ps := range ranges {
+		for p, r := range ps {
+			for _, o := range r {
+				if !o.afterMilli {
+					continue
+				}
+				if byMilli[o.at] == nil {
+					byMilli[o.at] = make(map[string]map[int32]int32)
+				}
+				if byMilli[o.at][t] == nil {
+					byMilli[o.at][t] = make(map[int32]int32)
+				}
+				byMilli[o.at][t][p] = consuming[t][p]
+			}
+		}
+	}
+	milliOffsets := make(map[int64]map[string]map[int32]int64, len(byMilli))
+	for milli, partitions := range byMilli {
+		if milliOffsets[milli], err = cl.listPartitionOffsets(ctx, partitions, milli); err != nil {
+			return err
+		}
+	}
+	resolve := func(t string, p int32, o Offset) int64 {
+		var at int64
+		switch {
+		case o.afterMilli:
+			at = milliOffsets[o.at][t][p]
+			if at < 0 {
+				at = ends[t][p]
+			}
+		case o.at == -2:
+			at = starts[t][p]
+		case o.at == -1:
+			at = ends[t][p]
+		default:
+			at = o.at
+		}
+		if at += o.relative; at < 0 {
+			at = 0
+		}
+		return at
+	}
+
+	// For each partition, we determine the range to consume and then
+	// group by leader. We overwrite ends with our end offset to stop at.
+	byLeader := make(map[int32]map[string]map[int32]int64) // leader => topic => partition => next offset
+	for t, ps := range consuming {
+		for p, leader := range ps {
+			start, end := starts[t][p], ends[t][p]
+			if r, ok := ranges[t][p]; ok {
+				rstart, rend := resolve(t, p, r[0]), resolve(t, p, r[1])
+				if rstart < start {
+					cl.cfg.logger.Log(LogLevelWarn, "range start offset is below the log start offset, clamping to the log start offset",
+						"topic", t,
+						"partition", p,
+						"start_offset", rstart,
+						"log_start_offset", start,
+					)
+				} else {
+					start = rstart
+				}
+				if rend <= end || waitForEnd {
+					end = rend
+				}
+			}
+			ends[t][p] = end
+			if start >= end {
+				continue
+			}
+			if byLeader[leader] == nil {
+				byLeader[leader] = make(map[string]map[int32]int64)
+			}
+			if byLeader[leader][t] == nil {
+				byLeader[leader][t] = make(map[int32]int64)
+			}
+			byLeader[leader][t][p] = start
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for leader, offsets := range byLeader {
+		leader, offsets := leader, offsets
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := cl.fetchToEnd(ctx, leader, topicIDs, offsets, ends, waitForEnd, func(r *Record) {
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr == nil {
+					fn(r)
+				}
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// listPartitionOffsets lists the start (-2) or end (-1) offsets for the
+// given partitions, or the offsets for a millisecond timestamp.
+func (cl *Client) listPartitionOffsets(ctx context.Context, partitions map[string]map[int32]int32, timestamp int64) (map[string]map[int32]int64, error) {
+	req := kmsg.NewPtrListOffsetsRequest()
+	req.IsolationLevel = cl.cfg.isolationLevel
+	for t, ps := range partitions {
+		rt := kmsg.NewListOffsetsRequestTopic()
+		rt.Topic = t
+		for p := range ps {
+			rp := kmsg.NewListOffsetsRequestTopicPartition()
+			rp.Partition = p
+			rp.Timestamp = timestamp
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+
+	offsets := make(map[string]map[int32]int64, len(partitions))
+	for _, shard := range cl.RequestSharded(ctx, req) {
+		if shard.Err != nil {
+			return nil, shard.Err
+		}
+		resp := shard.Resp.(*kmsg.ListOffsetsResponse)
+		for _, t := range resp.Topics {
+			toffsets := offsets[t.Topic]
+			if toffsets == nil {
+				toffsets = make(map[int32]int64)
+				offsets[t.Topic] = toffsets
+			}
+			for _, p := range t.Partitions {
+				if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+					return nil, fmt.Errorf("unable to list offsets for %s[%d]: %w", t.Topic, p.Partition, err)
+				}
+				offset := p.Offset
+				if resp.Version == 0 && len(p.OldStyleOffsets) > 0 {
+					offset = p.OldStyleOffsets[0]
+				}
+				toffsets[p.Partition] = offset
+			}
+		}
+	}
+	for t, ps := range partitions {
+		for p := range ps {
+			if _, ok := offsets[t][p]; !ok {
+				return nil, fmt.Errorf("partition %s[%d] was missing from list offsets responses", t, p)
+			}
+		}
+	}
+	return offsets, nil
+}
+
+// fetchToEnd fetches the given partitions from leader, starting at offsets,
+// until each partition reaches its end offset. If waitForEnd is false, a
+// partition also stops if its high watermark drops below its end offset (i.e.
+// the partition was truncated).
+func (cl *Client) fetchToEnd(
+	ctx context.Context,
+	leader int32,
+	topicIDs map[string][16]byte,
+	offsets map[string]map[int32]int64,
+	ends map[string]map[int32]int64,
+	waitForEnd bool,
+	fn func(*Record),
+) error {
+	for len(offsets) > 0 {
+		req := kmsg.NewPtrFetchRequest()
+		req.ReplicaID = -1
+		req.MaxWaitMillis = cl.cfg.maxWait
+		req.MinBytes = 1
+		req.MaxBytes = cl.cfg.maxBytes
+		req.IsolationLevel = cl.cfg.isolationLevel
+		for t, ps := range offsets {
+			rt := kmsg.NewFetchRequestTopic()
+			rt.Topic = t
+			rt.TopicID = topicIDs[t]
+			for p, offset := range ps {
+				rp := kmsg.NewFetchRequestTopicPartition()
+				rp.Partition = p
+				rp.FetchOffset = offset
+				rp.PartitionMaxBytes = cl.cfg.maxPartBytes
+				rt.Partitions = append(rt.Partitions, rp)
+			}
+			req.Topics = append(req.Topics, rt)
+		}
+
+		kresp, err := cl.Broker(int(leader)).Request(ctx, req)
+		if err != nil {
+			return err
+		}
+		resp := kresp.(*kmsg.FetchResponse)
+		if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+			return err
+		}
+		for _, t := range resp.Topics {
+			topic := t.Topic
+			if resp.Version >= 13 {
+				for name, id := range topicIDs {
+					if id == t.TopicID {
+						topic = name
+						break
+					}
+				}
+			}
+			ps := offsets[topic]
+			for i := range t.Partitions {
+				rp := &t.Partitions[i]
+				offset, ok := ps[rp.Partition]
+				if !ok {
+					continue
+				}
+				fp, next := partitionRecords(topic, offset, resp.Version, rp)
+				if fp.Err != nil {
+					return fmt.Errorf("unable to consume %s[%d]: %w", topic, rp.Partition, fp.Err)
+				}
+				end := ends[topic][rp.Partition]
+				for _, r := range fp.Records {
+					if r.Offset >= end {
+						break
+					}
+					fn(r)
+				}
+				if next >= end || !waitForEnd && rp.HighWatermark <= next {
+					delete(ps, rp.Partition)
+					if len(ps) == 0 {
+						delete(offsets, topic)
+					}
+				} else {
+					ps[rp.Partition] = next
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package kgo
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// fakeLog is a fake broker's partitions of topic "t", each with a log start
+// offset and a high watermark. Every offset in between has a record whose
+// value is its offset.
+type fakeLog struct {
+	starts []int64
+	hws    []int64
+
+	// onFetch, if non-nil, is called before each fetch is answered.
+	onFetch func()
+}
+
+func (l *fakeLog) handle(kreq kmsg.Request) kmsg.Response {
+	switch req := kreq.(type) {
+	case *kmsg.MetadataRequest:
+		resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+		for i := range resp.Topics {
+			resp.Topics[i].TopicID = [16]byte{1}
+			for p := range l.starts {
+				rp := kmsg.NewMetadataResponseTopicPartition()
+				rp.Partition = int32(p)
+				rp.Replicas = []int32{0}
+				rp.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+			}
+		}
+		return resp
+
+	case *kmsg.ListOffsetsRequest:
+		resp := req.ResponseKind().(*kmsg.ListOffsetsResponse)
+		for _, rt := range req.Topics {
+			st := kmsg.NewListOffsetsResponseTopic()
+			st.Topic = rt.Topic
+			for _, rp := range rt.Partitions {
+				sp := kmsg.NewListOffsetsResponseTopicPartition()
+				sp.Partition = rp.Partition
+				sp.Offset = l.hws[rp.Partition]
+				if rp.Timestamp == -2 {
+					sp.Offset = l.starts[rp.Partition]
+				}
+				st.Partitions = append(st.Partitions, sp)
+			}
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp
+
+	case *kmsg.FetchRequest:
+		if l.onFetch != nil {
+			l.onFetch()
+		}
+		resp := req.ResponseKind().(*kmsg.FetchResponse)
+		for _, rt := range req.Topics {
+			st := kmsg.NewFetchResponseTopic()
+			st.Topic = "t"
+			st.TopicID = rt.TopicID
+			for _, rp := range rt.Partitions {
+				sp := kmsg.NewFetchResponseTopicPartition()
+				sp.Partition = rp.Partition
+				sp.HighWatermark = l.hws[rp.Partition]
+				// We return at most two records per fetch so that
+				// consuming takes multiple fetches.
+				var rs []kmsg.Record
+				for o := rp.FetchOffset; o < sp.HighWatermark && len(rs) < 2; o++ {
+					rs = append(rs, kmsg.Record{
+						OffsetDelta: int32(o - rp.FetchOffset),
+						Value:       []byte(strconv.FormatInt(o, 10)),
+					})
+				}
+				if len(rs) > 0 {
+					batch := kmsg.RecordBatch{
+						FirstOffset:   rp.FetchOffset,
+						Magic:         2,
+						ProducerID:    -1,
+						ProducerEpoch: -1,
+						FirstSequence: -1,
+					}
+					batch.SetRecords(rs)
+					sp.RecordBatches = batch.AppendToRecomputed(nil)
+				}
+				st.Partitions = append(st.Partitions, sp)
+			}
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp
+	}
+	return kreq.ResponseKind()
+}
+
+// consumedOffsets returns the offsets consumed per partition, checking that
+// every record's value is its offset.
+func consumedOffsets(t *testing.T) (map[int32][]int64, func(*Record)) {
+	got := make(map[int32][]int64)
+	return got, func(r *Record) {
+		if string(r.Value) != strconv.FormatInt(r.Offset, 10) {
+			t.Errorf("%d: got value %s for offset %d", r.Partition, r.Value, r.Offset)
+		}
+		got[r.Partition] = append(got[r.Partition], r.Offset)
+	}
+}
+
+func offsetRange(start, end int64) []int64 {
+	var os []int64
+	for o := start; o < end; o++ {
+		os = append(os, o)
+	}
+	return os
+}
+
+func TestConsumeToEnd(t *testing.T) {
+	t.Parallel()
+	log := &fakeLog{
+		starts: []int64{2, 0, 4},
+		hws:    []int64{7, 0, 4},
+	}
+	// Records produced after ConsumeToEnd begins are not read: the end
+	// offsets are the high watermarks captured at the start.
+	log.onFetch = func() {
+		for p := range log.hws {
+			log.hws[p]++
+		}
+	}
+	cl := newFakeBrokerClient(t, log.handle)
+	defer cl.Close()
+
+	got, fn := consumedOffsets(t)
+	if err := cl.ConsumeToEnd(context.Background(), "t", fn); err != nil {
+		t.Fatal(err)
+	}
+	// Partition 1 is empty and partition 2 has had everything deleted, so
+	// neither is consumed.
+	if len(got) != 1 || !reflect.DeepEqual(got[0], offsetRange(2, 7)) {
+		t.Errorf("got consumed offsets %v, exp only partition 0 offsets [2, 7)", got)
+	}
+}
+
+func TestConsumeRange(t *testing.T) {
+	t.Parallel()
+	at := func(o int64) Offset { return NewOffset().At(o) }
+	for _, test := range []struct {
+		name       string
+		ranges     map[int32][2]Offset
+		waitForEnd bool
+		exp        map[int32][]int64
+		expErr     bool
+	}{
+		{
+			name:   "bounds",
+			ranges: map[int32][2]Offset{0: {at(3), at(6)}, 1: {NewOffset().AtEnd().Relative(-2), NewOffset().AtEnd()}},
+			exp:    map[int32][]int64{0: offsetRange(3, 6), 1: offsetRange(3, 5)},
+		},
+		{
+			name:   "start clamped to log start",
+			ranges: map[int32][2]Offset{0: {at(0), at(4)}},
+			exp:    map[int32][]int64{0: offsetRange(2, 4)},
+		},
+		{
+			name:   "end clamped to partition end",
+			ranges: map[int32][2]Offset{0: {at(8), at(20)}, 1: {NewOffset().AtStart(), NewOffset().AtEnd().Relative(5)}},
+			exp:    map[int32][]int64{0: offsetRange(8, 10), 1: offsetRange(0, 5)},
+		},
+		{
+			name:       "wait for end",
+			ranges:     map[int32][2]Offset{1: {at(4), at(8)}},
+			waitForEnd: true,
+			exp:        map[int32][]int64{1: offsetRange(4, 8)},
+		},
+		{
+			name:   "empty ranges",
+			ranges: map[int32][2]Offset{0: {at(5), at(5)}, 1: {at(4), at(2)}, 2: {NewOffset().AtStart(), NewOffset().AtEnd()}},
+			exp:    map[int32][]int64{},
+		},
+		{
+			name:   "missing partition",
+			ranges: map[int32][2]Offset{3: {at(0), at(1)}},
+			expErr: true,
+		},
+	} {
+		log := &fakeLog{
+			starts: []int64{2, 0, 4},
+			hws:    []int64{10, 5, 4},
+		}
+		if test.waitForEnd {
+			// Each fetch "produces" one record to every partition.
+			log.onFetch = func() {
+				for p := range log.hws {
+					log.hws[p]++
+				}
+			}
+		}
+		cl := newFakeBrokerClient(t, log.handle)
+
+		got, fn := consumedOffsets(t)
+		err := cl.ConsumeRange(context.Background(), map[string]map[int32][2]Offset{"t": test.ranges}, test.waitForEnd, fn)
+		cl.Close()
+		if gotErr := err != nil; gotErr != test.expErr {
+			t.Errorf("%s: got err %v, exp err? %v", test.name, err, test.expErr)
+			continue
+		}
+		if test.expErr {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("%s: got consumed offsets %v != exp %v", test.name, got, test.exp)
+		}
+	}
+
+	// Topics without ranges are skipped entirely, without any requests.
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	})
+	defer cl.Close()
+	if err := cl.ConsumeRange(context.Background(), map[string]map[int32][2]Offset{"t": {}}, false, func(*Record) {
+		t.Error("unexpected record")
+	}); err != nil {
+		t.Errorf("got err %v for empty ranges", err)
+	}
+}
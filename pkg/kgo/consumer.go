@@ -3,6 +3,7 @@ package kgo
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,14 +14,27 @@ import (
 )
 
 // Offset is a message offset in a partition.
+//
+// An offset is built from NewOffset and then one of AtStart, AtEnd, At, or
+// AfterMilli, optionally followed by Relative and WithEpoch. This avoids
+// passing raw int64 offsets with magic values to functions that accept an
+// offset: the broker's special values (-2 for the start of a partition, -1 for
+// the end) are handled internally.
 type Offset struct {
 	at           int64
 	relative     int64
 	epoch        int32
 	currentEpoch int32 // set by us when mapping offsets to brokers
+
+	// afterMilli is true if at is a millisecond timestamp to list an
+	// offset for, rather than an offset.
+	afterMilli bool
 }
 
 func (o Offset) MarshalJSON() ([]byte, error) {
+	if o.afterMilli {
+		return []byte(fmt.Sprintf(`{"AfterMilli":%d,"Relative":%d,"Epoch":%d,"CurrentEpoch":%d}`, o.at, o.relative, o.epoch, o.currentEpoch)), nil
+	}
 	if o.relative == 0 {
 		return []byte(fmt.Sprintf(`{"At":%d,"Epoch":%d,"CurrentEpoch":%d}`, o.at, o.epoch, o.currentEpoch)), nil
 	}
@@ -28,18 +42,32 @@ func (o Offset) MarshalJSON() ([]byte, error) {
 }
 
 // String returns the offset as a string; the purpose of this is for logs.
+//
+// The start and end of a partition are formatted as "start" and "end", and a
+// timestamp offset is formatted as "after:<millis>", followed by any relative
+// adjustment. If the offset has an epoch, the epoch follows a period.
 func (o Offset) String() string {
-	if o.relative == 0 {
-		return fmt.Sprintf("{%d.%d %d}", o.at, o.epoch, o.currentEpoch)
-	} else if o.relative > 0 {
-		return fmt.Sprintf("{%d+%d.%d %d}", o.at, o.relative, o.epoch, o.currentEpoch)
-	} else {
-		return fmt.Sprintf("{%d-%d.%d %d}", o.at, o.relative, o.epoch, o.currentEpoch)
+	var at string
+	switch {
+	case o.afterMilli:
+		at = fmt.Sprintf("after:%d", o.at)
+	case o.at == -2:
+		at = "start"
+	case o.at == -1:
+		at = "end"
+	default:
+		at = strconv.FormatInt(o.at, 10)
+	}
+	if o.relative > 0 {
+		at += "+" + strconv.FormatInt(o.relative, 10)
+	} else if o.relative < 0 {
+		at += strconv.FormatInt(o.relative, 10)
 	}
+	return fmt.Sprintf("{%s.%d %d}", at, o.epoch, o.currentEpoch)
 }
 
-// NewOffset creates and returns an offset to use in ConsumePartitions or
-// ConsumeResetOffset.
+// NewOffset creates and returns an offset to use in ConsumePartitions,
+// ConsumeResetOffset, or ConsumeRange.
 //
 // The default offset begins at the end.
 func NewOffset() Offset {
@@ -53,6 +81,7 @@ func NewOffset() Offset {
 // to begin at the beginning of a partition.
 func (o Offset) AtStart() Offset {
 	o.at = -2
+	o.afterMilli = false
 	return o
 }
 
@@ -60,6 +89,7 @@ func (o Offset) AtStart() Offset {
 // begin at the end of a partition.
 func (o Offset) AtEnd() Offset {
 	o.at = -1
+	o.afterMilli = false
 	return o
 }
 
@@ -96,13 +126,36 @@ func (o Offset) At(at int64) Offset {
 		at = -2
 	}
 	o.at = at
+	o.afterMilli = false
 	return o
 }
 
+// AfterMilli returns a copy of the calling offset, changing the returned
+// offset to begin at the first record with a timestamp at or after millisec,
+// a Unix timestamp in milliseconds. The offset is found by issuing a
+// ListOffsets request with the timestamp. If no record has such a timestamp,
+// the offset begins at the end of the partition.
+//
+// A negative millisec is bounded to 0, which begins at the first record in
+// the partition that has a non-negative timestamp.
+func (o Offset) AfterMilli(millisec int64) Offset {
+	if millisec < 0 {
+		millisec = 0
+	}
+	o.at = millisec
+	o.afterMilli = true
+	return o
+}
+
+// exact returns whether this offset is an exact offset, rather than the
+// start or end of a partition or a timestamp that must be listed.
+func (o Offset) exact() bool { return o.at >= 0 && !o.afterMilli }
+
 type consumer struct {
 	cl *Client
 
 	bufferedRecords int64
+	bufferedBytes   int64
 
 	pausedMu sync.Mutex   // grabbed when updating paused
 	paused   atomic.Value // loaded when issuing fetches
@@ -161,6 +214,13 @@ func (cl *Client) BufferedFetchRecords() int64 {
 	return atomic.LoadInt64(&cl.consumer.bufferedRecords)
 }
 
+// BufferedFetchBytes returns the number of raw record batch bytes the client
+// has fetched and buffered that have not yet been fully polled. See
+// FetchMaxBytesTotal for how these bytes are counted and released.
+func (cl *Client) BufferedFetchBytes() int64 {
+	return atomic.LoadInt64(&cl.consumer.bufferedBytes)
+}
+
 type usedCursors map[*cursor]struct{}
 
 func (u *usedCursors) use(c *cursor) {
@@ -229,6 +289,14 @@ func (c *consumer) addFakeReadyForDraining(topic string, partition int32, err er
 // has no topic, a partition of 0, and a partition error of ErrClientClosed.
 // This can be used to detect if the client is closing and to break out of a
 // poll loop.
+//
+// Records within a single partition are always returned in offset order,
+// within one poll and across polls, regardless of MaxConcurrentDecodes: a
+// partition is only fetched from one broker at a time and its fetch response
+// is decoded by a single goroutine. There is no ordering across partitions.
+// Because records are grouped by partition, applications can process
+// partitions in parallel (for example, a goroutine per partition with
+// EachPartition) and still process each partition in order.
 func (cl *Client) PollFetches(ctx context.Context) Fetches {
 	return cl.PollRecords(ctx, 0)
 }
@@ -239,7 +307,11 @@ func (cl *Client) PollFetches(ctx context.Context) Fetches {
 // return immediately with any currently buffered records.
 //
 // This returns a maximum of maxPollRecords total across all fetches, or
-// returns all buffered records if maxPollRecords is <= 0.
+// returns all buffered records if maxPollRecords is <= 0. Records that are
+// buffered but not returned are kept for the next poll, and the consume
+// position of a partition only advances past records actually returned. If
+// the context is canceled before any records are available, this returns
+// zero records, allowing this to be used with a timeout in an event loop.
 //
 // It is important to check all partition errors in the returned fetches. If
 // any partition has a fatal error and actually had no records, fake fetch will
@@ -257,7 +329,7 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 
 	c.g.undirtyUncommitted()
 
-	var fetches Fetches
+	var fetches, polled Fetches
 	fill := func() {
 		// A group can grab the consumer lock then the group mu and
 		// assign partitions. The group mu is grabbed to update its
@@ -315,11 +387,12 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 		if c.g != nil {
 			c.g.updateUncommitted(realFetches)
 		}
+		polled = realFetches
 	}
 
 	fill()
 	if len(fetches) > 0 || ctx == nil {
-		return fetches
+		return c.g.maybeCommitBeforeDelivery(fetches, polled)
 	}
 	select {
 	case <-ctx.Done():
@@ -334,7 +407,7 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 		defer c.sourcesReadyMu.Unlock()
 		defer close(done)
 
-		for !quit && len(c.sourcesReadyForDraining) == 0 {
+		for !quit && len(c.sourcesReadyForDraining) == 0 && len(c.fakeReadyForDraining) == 0 {
 			c.sourcesReadyCond.Wait()
 		}
 	}()
@@ -360,7 +433,7 @@ func (cl *Client) PollRecords(ctx context.Context, maxPollRecords int) Fetches {
 	}
 
 	fill()
-	return fetches
+	return c.g.maybeCommitBeforeDelivery(fetches, polled)
 }
 
 // PauseFetchTopics sets the client to no longer fetch the given topics and
@@ -643,7 +716,7 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 			// First, if the request is exact, get rid of the relative
 			// portion. We are modifying a copy of the offset, i.e. we
 			// are appropriately not modfying 'assignments' itself.
-			if offset.at >= 0 {
+			if offset.exact() {
 				offset.at = offset.at + offset.relative
 				if offset.at < 0 {
 					offset.at = 0
@@ -662,7 +735,7 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 			// fetch offsets only if the broker supports KIP-320,
 			// but we do not override the user manually specifying
 			// an epoch.
-			if offset.at >= 0 && offset.epoch >= 0 {
+			if offset.exact() && offset.epoch >= 0 {
 				loadOffsets.addLoad(topic, partition, loadTypeEpoch, offsetLoad{
 					replica: -1,
 					Offset:  offset,
@@ -677,7 +750,7 @@ func (c *consumer) assignPartitions(assignments map[string]map[int32]Offset, how
 			// If an offset is unspecified or we have not loaded
 			// the partition, we list offsets to find out what to
 			// use.
-			if offset.at >= 0 && partition >= 0 && partition < int32(len(topicPartitions.partitions)) {
+			if offset.exact() && partition >= 0 && partition < int32(len(topicPartitions.partitions)) {
 				part := topicPartitions.partitions[partition]
 				cursor := part.cursor
 				cursor.setOffset(cursorOffset{
@@ -714,7 +787,16 @@ func (c *consumer) doOnMetadataUpdate() {
 
 			switch {
 			case c.d != nil:
-				if new := c.d.findNewAssignments(); len(new) > 0 {
+				new := c.d.findNewAssignments()
+				if len(new) > 0 && c.cl.cfg.offsetStore != nil {
+					// The user's store can be slow; we do
+					// not block polling while it loads.
+					c.mu.Unlock()
+					stored, err := c.d.fetchStoredOffsets(c.cl, new)
+					c.mu.Lock()
+					new = c.d.applyStoredOffsets(c.cl, new, stored, err)
+				}
+				if len(new) > 0 {
 					c.assignPartitions(new, assignWithoutInvalidating, c.d.tps, "new assignments from direct consumer")
 				}
 			case c.g != nil:
@@ -764,6 +846,9 @@ func (o offsetLoad) MarshalJSON() ([]byte, error) {
 	if o.replica == -1 {
 		return o.Offset.MarshalJSON()
 	}
+	if o.afterMilli {
+		return []byte(fmt.Sprintf(`{"Replica":%d,"AfterMilli":%d,"Relative":%d,"Epoch":%d,"CurrentEpoch":%d}`, o.replica, o.at, o.relative, o.epoch, o.currentEpoch)), nil
+	}
 	if o.relative == 0 {
 		return []byte(fmt.Sprintf(`{"Replica":%d,"At":%d,"Epoch":%d,"CurrentEpoch":%d}`, o.replica, o.at, o.epoch, o.currentEpoch)), nil
 	}
@@ -927,6 +1012,8 @@ type consumerSession struct {
 	desireFetchCh       chan chan chan struct{}
 	cancelFetchCh       chan chan chan struct{}
 	allowedFetches      int
+	allowedBytes        int64  // 0 means unbounded
+	bufferedBytes       *int64 // atomic, the consumer's buffered bytes
 	fetchManagerStarted uint32 // atomic, once 1, we start the fetch manager
 
 	// Workers signify the number of fetch and list / epoch goroutines that
@@ -958,6 +1045,8 @@ func (c *consumer) newConsumerSession(tps *topicsPartitions) *consumerSession {
 		desireFetchCh:  make(chan chan chan struct{}, 8),
 		cancelFetchCh:  make(chan chan chan struct{}, 4),
 		allowedFetches: c.cl.cfg.maxConcurrentFetches,
+		allowedBytes:   c.cl.cfg.maxBytesTotal,
+		bufferedBytes:  &c.bufferedBytes,
 	}
 	session.workersCond = sync.NewCond(&session.workersMu)
 	return session
@@ -987,9 +1076,9 @@ func (c *consumerSession) manageFetchConcurrency() {
 			var found bool
 			for i, want := range wantFetch {
 				if want == cancel {
-					_ = append(wantFetch[i:], wantFetch[i+1:]...)
-					wantFetch = wantFetch[:len(wantFetch)-1]
+					wantFetch = append(wantFetch[:i], wantFetch[i+1:]...)
 					found = true
+					break
 				}
 			}
 			// If we did not find the channel, then we have already
@@ -1006,7 +1095,13 @@ func (c *consumerSession) manageFetchConcurrency() {
 			ctxCh = nil
 		}
 
-		if len(wantFetch) > 0 && (activeFetches < c.allowedFetches || c.allowedFetches == 0) { // 0 means unbounded
+		// If we are over our buffered bytes limit, we wait for a
+		// buffered fetch to be drained, which releases its bytes
+		// before signaling doneFetch. Anything buffered implies an
+		// active fetch, so we are always woken back up.
+		underBytes := c.allowedBytes == 0 || atomic.LoadInt64(c.bufferedBytes) < c.allowedBytes
+
+		if len(wantFetch) > 0 && underBytes && (activeFetches < c.allowedFetches || c.allowedFetches == 0) { // 0 means unbounded
 			wantFetch[0] <- doneFetch
 			wantFetch = wantFetch[1:]
 			activeFetches++
@@ -1325,8 +1420,12 @@ func (s *consumerSession) handleListOrEpochResults(loaded loadedOffsets) (reload
 			s.c.usingCursors.use(load.cursor)
 		}
 
-		switch load.err.(type) {
+		switch lost := load.err.(type) {
 		case *ErrDataLoss:
+			s.c.cl.cfg.logger.Log(LogLevelWarn, "detected log truncation while consuming, resetting to the last valid offset", "topic", lost.Topic, "partition", lost.Partition, "consumed_to", lost.ConsumedTo, "reset_to", lost.ResetTo)
+			if fn := s.c.cl.cfg.onConsumeDataLoss; fn != nil {
+				fn(lost)
+			}
 			s.c.addFakeReadyForDraining(load.topic, load.partition, load.err) // signal we lost data, but set the cursor to what we can
 			use()
 
@@ -1486,23 +1585,52 @@ func (cl *Client) listOffsetsForBrokerLoad(ctx context.Context, broker *broker,
 				delete(load, topic)
 			}
 
+			// If we listed by timestamp and no record has a
+			// timestamp at or after it, Kafka replies with -1. We
+			// reload to list the end offset.
+			if loadPart.afterMilli && rPartition.Offset == -1 && len(rPartition.OldStyleOffsets) == 0 {
+				loaded.add(loadedOffset{
+					topic:     topic,
+					partition: partition,
+					err:       kerr.OffsetNotAvailable,
+					request:   offsetLoad{replica: loadPart.replica, Offset: loadPart.Offset.AtEnd()},
+				})
+				continue
+			}
+
 			offset := rPartition.Offset + loadPart.relative
 			if len(rPartition.OldStyleOffsets) > 0 { // if we have any, we used list offsets v0
 				offset = rPartition.OldStyleOffsets[0] + loadPart.relative
 			}
-			if loadPart.at >= 0 {
+			if loadPart.exact() {
 				offset = loadPart.at + loadPart.relative // we obey exact requests, even if they end up past the end
 			}
 			if offset < 0 {
 				offset = 0
 			}
 
+			// ListOffsets v4+ returns the leader epoch of the listed
+			// offset, which we keep for truncation detection (older
+			// brokers leave it at -1). The epoch is only valid for
+			// offsets at or before what was listed: if we are
+			// consuming after it, the log could have moved to a new
+			// epoch in between, and we could falsely detect data
+			// loss when fenced.
+			leaderEpoch := rPartition.LeaderEpoch
+			listed := rPartition.Offset
+			if len(rPartition.OldStyleOffsets) > 0 {
+				listed = rPartition.OldStyleOffsets[0]
+			}
+			if offset > listed {
+				leaderEpoch = -1
+			}
+
 			loaded.add(loadedOffset{
 				topic:       topic,
 				partition:   partition,
 				cursor:      topicPartition.cursor,
 				offset:      offset,
-				leaderEpoch: rPartition.LeaderEpoch,
+				leaderEpoch: leaderEpoch,
 				request:     loadPart,
 			})
 		}
@@ -1597,13 +1725,13 @@ func (o offsetLoadMap) buildListReq(isolationLevel int8) *kmsg.ListOffsetsReques
 			// loaded by the client (due to metadata). We use -1
 			// just to ensure the partition is loaded.
 			timestamp := offset.at
-			if timestamp >= 0 {
+			if offset.exact() {
 				timestamp = -1
 			}
 			p := kmsg.NewListOffsetsRequestTopicPartition()
 			p.Partition = partition
 			p.CurrentLeaderEpoch = offset.currentEpoch // KIP-320
-			p.Timestamp = offset.at
+			p.Timestamp = timestamp
 			p.MaxNumOffsets = 1
 
 			parts = append(parts, p)
@@ -24,6 +24,11 @@ type groupConsumer struct {
 
 	cooperative bool // true if all config balancers are cooperative
 
+	// kip848 is true while the group is using the KIP-848 consumer group
+	// protocol. This is only set to false in the manage goroutine, if we
+	// fall back to the classic protocol.
+	kip848 atomicBool
+
 	// The data for topics that the user assigned. Metadata updates the
 	// atomic.Value in each pointer atomically. If we are consuming via
 	// regex, metadata grabs the lock to add new topics.
@@ -95,6 +100,12 @@ type groupConsumer struct {
 	// - read when getting uncommitted or committed
 	uncommitted uncommitted
 
+	// released contains partitions given up with CommitAndRelease. These
+	// are excluded from the owned partitions we send in our next join, are
+	// not re-assigned if an in-progress offset fetch completes, and are
+	// cleared from nowAssigned once we receive our next sync assignment.
+	released map[string][]int32
+
 	// memberID and generation are written to in the join and sync loop,
 	// and mostly read within that loop. The reason these two are under the
 	// mutex is because they are read during commits, which can happen at
@@ -165,6 +176,7 @@ func (c *consumer) initGroup() {
 		heartbeatForceCh: make(chan func(error)),
 		using:            make(map[string]int),
 	}
+	g.kip848.set(g.cfg.membership != membershipClassic)
 	c.g = g
 	if !g.cfg.setCommitCallback {
 		g.cfg.commitCallback = g.defaultCommitCallback
@@ -247,11 +259,21 @@ func (g *groupConsumer) manage() {
 
 	var consecutiveErrors int
 	for {
-		err := g.joinAndSync()
-		if err == nil {
-			if err = g.setupAssignedAndHeartbeat(); err != nil {
-				if err == kerr.RebalanceInProgress {
-					err = nil
+		var err error
+		if g.kip848.get() {
+			err = g.consumerGroupHeartbeat()
+			if err == errConsumerGroupUnsupported {
+				g.cfg.logger.Log(LogLevelInfo, "falling back to the classic group protocol", "group", g.cfg.group)
+				g.kip848.set(false)
+				continue
+			}
+		} else {
+			err = g.joinAndSync()
+			if err == nil {
+				if err = g.setupAssignedAndHeartbeat(); err != nil {
+					if err == kerr.RebalanceInProgress {
+						err = nil
+					}
 				}
 			}
 		}
@@ -363,7 +385,9 @@ func (g *groupConsumer) leave() (wait func()) {
 			return
 		}
 
-		if g.cfg.instanceID == nil {
+		if g.kip848.get() {
+			g.leaveConsumerGroup()
+		} else if g.cfg.instanceID == nil {
 			g.cfg.logger.Log(LogLevelInfo, "leaving group",
 				"group", g.cfg.group,
 				"member_id", g.memberID, // lock not needed now since nothing can change it (manageDone)
@@ -435,6 +459,13 @@ func (g *groupConsumer) diffAssigned() (added, lost map[string][]int32) {
 	return added, lost
 }
 
+// incremental returns whether partitions move incrementally between members:
+// either all balancers are cooperative, or the group is using the KIP-848
+// consumer group protocol, which is always incremental.
+func (g *groupConsumer) incremental() bool {
+	return g.cooperative || g.kip848.get()
+}
+
 type revokeStage int8
 
 const (
@@ -459,7 +490,7 @@ const (
 // Lastly, for cooperative consumers, this must selectively delete what was
 // lost from the uncommitted map.
 func (g *groupConsumer) revoke(stage revokeStage, lost map[string][]int32, leaving bool) {
-	if !g.cooperative || leaving { // stage == revokeThisSession if not cooperative
+	if !g.incremental() || leaving { // stage == revokeThisSession if not cooperative
 		// If we are an eager consumer, we stop fetching all of our
 		// current partitions as we will be revoking them.
 		g.c.mu.Lock()
@@ -470,7 +501,7 @@ func (g *groupConsumer) revoke(stage revokeStage, lost map[string][]int32, leavi
 		}
 		g.c.mu.Unlock()
 
-		if !g.cooperative {
+		if !g.incremental() {
 			g.cfg.logger.Log(LogLevelInfo, "eager consumer revoking prior assigned partitions", "group", g.cfg.group, "revoking", g.nowAssigned)
 		} else {
 			g.cfg.logger.Log(LogLevelInfo, "cooperative consumer revoking prior assigned partitions because leaving group", "group", g.cfg.group, "revoking", g.nowAssigned)
@@ -820,6 +851,12 @@ func (g *groupConsumer) heartbeat(fetchErrCh <-chan error, s *assignRevokeSessio
 				return err
 			}
 
+			// Before revoking, we tell the poll loop that we are
+			// rebalancing if the user asked us to.
+			if g.cfg.interruptPollOnRebalance && err == kerr.RebalanceInProgress {
+				g.c.addFakeReadyForDraining("", 0, kerr.RebalanceInProgress)
+			}
+
 			// Now we call the user provided revoke callback, even
 			// if cooperative: if cooperative, this only revokes
 			// partitions we no longer want to consume.
@@ -865,6 +902,157 @@ func (cl *Client) ForceRebalance() {
 	}
 }
 
+// CommitAndRelease commits offsets for the given partitions and gives them up
+// so that other members of a cooperative group can consume them, without
+// revoking any other partition this member owns.
+//
+// This stops fetching the partitions and drops any buffered or in-flight
+// fetches for them, so no record for a released partition is returned from a
+// poll after this function begins. The last polled offsets of the partitions
+// are then committed, and the member rejoins the group without claiming the
+// partitions it released. If OnPartitionsRevoked is set, it is called with the
+// released partitions before they are committed, and it is not called for
+// them again when the member rejoins.
+//
+// The group balancer decides where released partitions go. The cooperative
+// sticky balancer moves them to other members if it can, but if this member
+// is the only one consuming a topic, a partition may be assigned right back.
+// If the commit fails, the partitions are still released and the commit error
+// is returned; the next owner will resume from the prior committed offset.
+//
+// This returns an error if the client is not consuming in a group, the group
+// is not using a cooperative balancer, or the group is using the KIP-848
+// consumer group protocol, in which case nothing is released. Releasing a
+// partition that this member does not own is a no-op for that partition.
+func (cl *Client) CommitAndRelease(ctx context.Context, partitions map[string][]int32) error {
+	g := cl.consumer.g
+	if g == nil {
+		return errNotGroup
+	}
+	if g.kip848.get() {
+		return errReleaseConsumerGroup
+	}
+	if !g.cooperative {
+		return errReleaseNotCooperative
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	release := make(map[string]map[int32]Offset, len(partitions))
+	for topic, ps := range partitions {
+		if len(ps) == 0 {
+			continue
+		}
+		topicRelease := make(map[int32]Offset, len(ps))
+		for _, p := range ps {
+			topicRelease[p] = Offset{}
+		}
+		release[topic] = topicRelease
+	}
+
+	// We invalidate before grabbing what to commit, and we grab the
+	// group lock before unlocking the consumer, so that a concurrent poll
+	// cannot return or mark uncommitted anything for what we release.
+	//
+	// We record the partitions as released before committing: a rejoin
+	// or an offset fetch that completes while we commit must not claim
+	// or resume consuming what we are giving up.
+	c := &cl.consumer
+	c.mu.Lock()
+	c.assignPartitions(release, assignInvalidateMatching, g.tps, "releasing partitions in CommitAndRelease")
+	g.mu.Lock()
+	c.mu.Unlock()
+	if g.released == nil {
+		g.released = make(map[string][]int32)
+	}
+	revoked := make(map[string][]int32, len(release))
+	for topic, topicRelease := range release {
+		for p := range topicRelease {
+			g.released[topic] = append(g.released[topic], p)
+			revoked[topic] = append(revoked[topic], p)
+		}
+		sort.Slice(revoked[topic], func(i, j int) bool { return revoked[topic][i] < revoked[topic][j] })
+	}
+	g.mu.Unlock()
+
+	// Like a cooperative revoke, we call the user's OnPartitionsRevoked
+	// after the partitions stop being fetched and before we commit, so
+	// that the user can commit in it. Polls no longer see the released
+	// partitions, so nothing can mark them uncommitted while we are
+	// unlocked.
+	if g.cfg.setRevoked && g.cfg.onRevoked != nil {
+		g.cfg.onRevoked(g.cl.ctx, cl, revoked)
+	}
+
+	g.mu.Lock()
+	var commit map[string]map[int32]EpochOffset
+	for topic, topicRelease := range release {
+		for p := range topicRelease {
+			uncommit, ok := g.uncommitted[topic][p]
+			if !ok || uncommit.head == uncommit.committed {
+				continue
+			}
+			if commit == nil {
+				commit = make(map[string]map[int32]EpochOffset)
+			}
+			if commit[topic] == nil {
+				commit[topic] = make(map[int32]EpochOffset)
+			}
+			commit[topic][p] = uncommit.head
+		}
+	}
+	g.mu.Unlock()
+
+	var rerr error
+	cl.CommitOffsetsSync(ctx, commit, func(_ *Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, err error) {
+		if err != nil {
+			rerr = err
+			return
+		}
+		for _, topic := range resp.Topics {
+			for _, partition := range topic.Partitions {
+				if err := kerr.ErrorForCode(partition.ErrorCode); err != nil {
+					rerr = err
+					return
+				}
+			}
+		}
+	})
+
+	g.mu.Lock()
+	for topic, topicRelease := range release {
+		if topicUncommitted := g.uncommitted[topic]; topicUncommitted != nil {
+			for p := range topicRelease {
+				delete(topicUncommitted, p)
+			}
+			if len(topicUncommitted) == 0 {
+				delete(g.uncommitted, topic)
+			}
+		}
+	}
+	g.mu.Unlock()
+
+	g.cfg.logger.Log(LogLevelInfo, "released partitions, rejoining", "group", g.cfg.group, "released", partitions, "commit_err", rerr)
+	g.rejoin("rejoin from CommitAndRelease")
+	return rerr
+}
+
+// withoutPartitions returns a copy of ps without any partition in remove.
+func withoutPartitions(ps, remove []int32) []int32 {
+	var keep []int32
+outer:
+	for _, p := range ps {
+		for _, r := range remove {
+			if p == r {
+				continue outer
+			}
+		}
+		keep = append(keep, p)
+	}
+	return keep
+}
+
 // rejoin is called after a cooperative member revokes what it lost at the
 // beginning of a session, or if we are leader and detect new partitions to
 // consume.
@@ -1041,6 +1229,20 @@ func (g *groupConsumer) handleSyncResp(protocol string, resp *kmsg.SyncGroupResp
 
 	g.cfg.logger.Log(LogLevelInfo, "synced", "group", g.cfg.group, "assigned", tpsFmt(assigned))
 
+	// Anything we released was already invalidated and committed, so we
+	// drop it from what we own now: if it was not reassigned to us, we do
+	// not want to revoke it again, and if it was, we want to treat it as
+	// newly added so that we fetch its committed offset.
+	g.mu.Lock()
+	for topic, partitions := range g.released {
+		g.nowAssigned[topic] = withoutPartitions(g.nowAssigned[topic], partitions)
+		if len(g.nowAssigned[topic]) == 0 {
+			delete(g.nowAssigned, topic)
+		}
+	}
+	g.released = nil
+	g.mu.Unlock()
+
 	// Past this point, we will fall into the setupAssigned prerevoke code,
 	// meaning for cooperative, we will revoke what we need to.
 	if g.cooperative {
@@ -1061,6 +1263,12 @@ func (g *groupConsumer) joinGroupProtocols() []kmsg.JoinGroupRequestProtocol {
 	for topic, partitions := range g.nowAssigned {
 		nowDup[topic] = append([]int32(nil), partitions...)
 	}
+	for topic, partitions := range g.released {
+		nowDup[topic] = withoutPartitions(nowDup[topic], partitions)
+		if len(nowDup[topic]) == 0 {
+			delete(nowDup, topic)
+		}
+	}
 	gen := g.generation
 
 	g.mu.Unlock()
@@ -1150,7 +1358,7 @@ func (g *groupConsumer) fetchOffsets(ctx context.Context, added, lost map[string
 	// If cooperative consuming, we may have to resume fetches. See the
 	// comment on adjustCooperativeFetchOffsets. If we successfully fetch,
 	// we clear what we were fetching.
-	if g.cooperative {
+	if g.incremental() {
 		added = g.adjustCooperativeFetchOffsets(added, lost)
 		defer func() {
 			if err == nil {
@@ -1162,6 +1370,9 @@ func (g *groupConsumer) fetchOffsets(ctx context.Context, added, lost map[string
 	// Our client maps the v0 to v7 format to v8+ when sharding this
 	// request, if we are only requesting one group, as well as maps the
 	// response back, so we do not need to worry about v8+ here.
+	//
+	// With the KIP-848 protocol, we fetch as our member (v9+) so that the
+	// broker validates our member epoch; the response is still mapped back.
 start:
 	req := kmsg.NewPtrOffsetFetchRequest()
 	req.Group = g.cfg.group
@@ -1172,16 +1383,37 @@ start:
 		reqTopic.Partitions = partitions
 		req.Topics = append(req.Topics, reqTopic)
 	}
+	if g.kip848.get() {
+		if memberID, epoch := g.memberGen(); memberID != "" {
+			reqGroup := kmsg.NewOffsetFetchRequestGroup()
+			reqGroup.Group = req.Group
+			reqGroup.MemberID = &memberID
+			reqGroup.MemberEpoch = epoch
+			for _, t := range req.Topics {
+				reqTopic := kmsg.NewOffsetFetchRequestGroupTopic()
+				reqTopic.Topic = t.Topic
+				reqTopic.Partitions = t.Partitions
+				reqGroup.Topics = append(reqGroup.Topics, reqTopic)
+			}
+			req.Groups = append(req.Groups, reqGroup)
+		}
+	}
 
-	var resp *kmsg.OffsetFetchResponse
+	var (
+		resp     *kmsg.OffsetFetchResponse
+		fetchErr error
+	)
 
+	// The goroutine sets fetchErr rather than our named err, which we
+	// return below if our context is canceled before the fetch is done.
 	fetchDone := make(chan struct{})
 	go func() {
 		defer close(fetchDone)
-		resp, err = req.RequestWith(ctx, g.cl)
+		resp, fetchErr = req.RequestWith(ctx, g.cl)
 	}()
 	select {
 	case <-fetchDone:
+		err = fetchErr
 	case <-ctx.Done():
 		g.cfg.logger.Log(LogLevelInfo, "fetch offsets failed due to context cancelation", "group", g.cfg.group)
 		return ctx.Err()
@@ -1258,6 +1490,17 @@ start:
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	// If the user released partitions while we were fetching offsets, we
+	// must not begin consuming them again.
+	for topic, partitions := range g.released {
+		for _, partition := range partitions {
+			delete(offsets[topic], partition)
+		}
+		if len(offsets[topic]) == 0 {
+			delete(offsets, topic)
+		}
+	}
+
 	// Eager: we already invalidated everything; nothing to re-invalidate.
 	// Cooperative: assign without invalidating what we are consuming.
 	g.c.assignPartitions(offsets, assignWithoutInvalidating, g.tps, fmt.Sprintf("newly fetched offsets for group %s", g.cfg.group))
@@ -1274,7 +1517,7 @@ start:
 			g.uncommitted[topic] = topicUncommitted
 		}
 		for partition, offset := range partitions {
-			if offset.at < 0 {
+			if !offset.exact() {
 				continue // not yet committed
 			}
 			committed := EpochOffset{
@@ -1421,8 +1664,9 @@ func (g *groupConsumer) updateUncommitted(fetches Fetches) {
 
 	// We set the head offset if autocommitting is disabled (because we
 	// only use head / committed in that case), or if we are greedily
-	// autocommitting (so that the latest head is available to autocommit).
-	setHead := g.cfg.autocommitDisable || g.cfg.autocommitGreedy
+	// autocommitting (so that the latest head is available to autocommit),
+	// or if we are committing before delivery (we commit the head now).
+	setHead := g.cfg.autocommitDisable || g.cfg.autocommitGreedy || g.cfg.autocommitBefore
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -1436,10 +1680,10 @@ func (g *groupConsumer) updateUncommitted(fetches Fetches) {
 
 			var topicOffsets map[int32]uncommit
 			for _, partition := range topic.Partitions {
-				if len(partition.Records) == 0 {
+				finalEpoch, finalOffset, ok := partition.lastConsumed()
+				if !ok {
 					continue
 				}
-				final := partition.Records[len(partition.Records)-1]
 
 				if topicOffsets == nil {
 					if g.uncommitted == nil {
@@ -1455,8 +1699,8 @@ func (g *groupConsumer) updateUncommitted(fetches Fetches) {
 				// Our new head points just past the final consumed offset,
 				// that is, if we rejoin, this is the offset to begin at.
 				set := EpochOffset{
-					final.LeaderEpoch, // -1 if old message / unknown
-					final.Offset + 1,
+					finalEpoch, // -1 if old message / unknown
+					finalOffset + 1,
 				}
 				prior := topicOffsets[partition.Partition]
 
@@ -1491,6 +1735,66 @@ func (g *groupConsumer) updateUncommitted(fetches Fetches) {
 	}
 }
 
+// maybeCommitBeforeDelivery, if committing before delivery, commits the end
+// offsets of what was polled before returning the fetches. If the commit
+// fails, the polled records are not delivered: we return the commit error for
+// every polled partition and rewind to the first polled offsets so that the
+// records are fetched again.
+//
+// The commit does not use the poll context: canceling a poll after records
+// were taken from the sources must not drop them. We instead bound the commit
+// by the session timeout, after which we are likely no longer in the group.
+func (g *groupConsumer) maybeCommitBeforeDelivery(fetches, polled Fetches) Fetches {
+	if g == nil || !g.cfg.autocommitBefore || len(polled) == 0 {
+		return fetches
+	}
+
+	var finals []*Record
+	rewind := make(map[string]map[int32]EpochOffset)
+	polled.EachPartition(func(p FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		first := p.Records[0]
+		finals = append(finals, p.Records[len(p.Records)-1])
+		ps := rewind[p.Topic]
+		if ps == nil {
+			ps = make(map[int32]EpochOffset)
+			rewind[p.Topic] = ps
+		}
+		if _, exists := ps[p.Partition]; !exists {
+			ps[p.Partition] = EpochOffset{first.LeaderEpoch, first.Offset}
+		}
+	})
+	if len(finals) == 0 {
+		return fetches
+	}
+
+	ctx, cancel := context.WithTimeout(g.cl.ctx, g.cfg.sessionTimeout)
+	err := g.cl.CommitRecords(ctx, finals...)
+	cancel()
+	if err == nil {
+		return fetches
+	}
+
+	g.cfg.logger.Log(LogLevelWarn, "unable to commit before delivery, rewinding and not delivering polled records", "group", g.cfg.group, "err", err)
+	g.cl.setOffsets(rewind, false)
+
+	err = fmt.Errorf("unable to commit before delivering records, records will be refetched: %w", err)
+	undelivered := Fetch{}
+	for t, ps := range rewind {
+		ft := FetchTopic{Topic: t}
+		for p := range ps {
+			ft.Partitions = append(ft.Partitions, FetchPartition{
+				Partition: p,
+				Err:       err,
+			})
+		}
+		undelivered.Topics = append(undelivered.Topics, ft)
+	}
+	return append(Fetches{undelivered}, fetches[len(polled):]...)
+}
+
 // Called at the start of PollXyz only if autocommitting is enabled and we are
 // not committing greedily, this ensures that when we enter poll, everything
 // previously consumed is a candidate for autocommitting.
@@ -1699,6 +2003,9 @@ func (g *groupConsumer) loopCommit() {
 // the given epoch/offset. Partitions that are not specified are not set. It is
 // invalid to set topics that were not yet returned from a PollFetches.
 //
+// If an epoch is non-negative, such as the leader epoch returned when listing
+// offsets, the offset is validated for log truncation before fetching.
+//
 // If using transactions, it is advised to just use a GroupTransactSession and
 // avoid this function entirely.
 //
@@ -1821,6 +2128,26 @@ func (cl *Client) CommittedOffsets() map[string]map[int32]EpochOffset {
 	return g.getUncommittedLocked(false, false)
 }
 
+// GroupMetadata returns the current member ID and generation of this client
+// in its consumer group, or an empty member ID and -1 if the client is not
+// consuming in a group or has not yet joined.
+func (cl *Client) GroupMetadata() (string, int32) {
+	g := cl.consumer.g
+	if g == nil {
+		return "", -1
+	}
+	return g.memberGen()
+}
+
+func (g *groupConsumer) memberGen() (string, int32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.memberID == "" {
+		return "", -1
+	}
+	return g.memberID, g.generation
+}
+
 func (g *groupConsumer) getUncommitted(dirty bool) map[string]map[int32]EpochOffset {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -2207,6 +2534,13 @@ func (g *groupConsumer) commit(
 		return
 	}
 
+	// With the KIP-848 protocol, our generation is our member epoch,
+	// which only v9+ commits validate as such.
+	if g.kip848.get() && !g.cl.supportsKeyVersion(kmsg.OffsetCommit.Int16(), 9) {
+		go onDone(g.cl, kmsg.NewPtrOffsetCommitRequest(), nil, errConsumerGroupCommitUnsupported)
+		return
+	}
+
 	priorCancel := g.commitCancel
 	priorDone := g.commitDone
 
@@ -0,0 +1,315 @@
+package kgo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// consumerGroupHeartbeat joins and stays in a group using the KIP-848
+// consumer group protocol. This returns when the member is fenced, the group
+// errors, or the group is left.
+//
+// The broker computes our assignment and returns it in a heartbeat response
+// whenever it changes. We reconcile a new assignment in the background while
+// continuing to heartbeat: we revoke what we lost, then fetch offsets for and
+// assign what we gained, and finally acknowledge the assignment by sending
+// the partitions we own in our next heartbeat. The broker only moves
+// partitions we revoked to other members once we acknowledge the revoke.
+func (g *groupConsumer) consumerGroupHeartbeat() error {
+	// Committing with this protocol requires OffsetCommit v9; if we
+	// cannot commit, we fall back to the classic protocol if allowed.
+	if g.cfg.membership == membershipAuto && !g.cl.supportsKeyVersion(kmsg.OffsetCommit.Int16(), 9) {
+		return errConsumerGroupUnsupported
+	}
+
+	g.mu.Lock()
+	g.generation = 0 // the member epoch; epoch 0 joins the group
+	g.mu.Unlock()
+
+	g.cfg.logger.Log(LogLevelInfo, "joining consumer group", "group", g.cfg.group)
+
+	var (
+		joined     bool // false until our first successful heartbeat
+		subscribe  bool // true if we need to send our subscription
+		ack        bool // true if we need to send what we own
+		target     *kmsg.ConsumerGroupHeartbeatResponseAssignment
+		newTarget  bool       // true if target has not been reconciled
+		unresolved bool       // true if target has topic IDs we do not yet know
+		reconciled chan error // non-nil while reconciling
+	)
+
+	ctx, cancel := context.WithCancel(g.ctx)
+	defer func() {
+		cancel()
+		if reconciled != nil {
+			<-reconciled
+		}
+	}()
+
+	// If the group is left, we wait for any reconcile to finish and then
+	// revoke everything; leave then sends our leave heartbeat.
+	leaving := func() error {
+		cancel()
+		if reconciled != nil {
+			<-reconciled
+			reconciled = nil
+		}
+		g.revoke(revokeThisSession, nil, true)
+		return context.Canceled
+	}
+
+	timer := time.NewTimer(0) // we heartbeat immediately to join
+	defer timer.Stop()
+	stopTimer := func() {
+		if !timer.Stop() {
+			<-timer.C
+		}
+	}
+
+	for {
+		var force func(error)
+		select {
+		case <-timer.C:
+		case force = <-g.heartbeatForceCh:
+			stopTimer()
+		case why := <-g.rejoinCh:
+			// Our subscription may have changed (regex), so we
+			// heartbeat now and resend it.
+			g.cfg.logger.Log(LogLevelInfo, "heartbeating immediately", "group", g.cfg.group, "why", why)
+			subscribe = true
+			stopTimer()
+		case err := <-reconciled:
+			reconciled = nil
+			if g.ctx.Err() != nil {
+				return leaving()
+			}
+			if err != nil {
+				return err
+			}
+			ack = true // we heartbeat immediately to acknowledge the assignment
+			stopTimer()
+		case <-g.ctx.Done():
+			return leaving()
+		}
+
+		// We can only send what we own if we are not in the middle of
+		// changing what we own.
+		req := g.newConsumerGroupHeartbeatRequest(subscribe, ack && reconciled == nil)
+		g.cfg.logger.Log(LogLevelDebug, "heartbeating", "group", g.cfg.group, "member_epoch", req.MemberEpoch)
+		resp, err := req.RequestWith(g.ctx, g.cl)
+		if err == nil {
+			err = kerr.ErrorForCode(resp.ErrorCode)
+		}
+		g.cfg.logger.Log(LogLevelDebug, "heartbeat complete", "group", g.cfg.group, "err", err)
+		if force != nil {
+			force(err)
+		}
+
+		if err != nil {
+			if g.ctx.Err() != nil {
+				return leaving()
+			}
+			if !joined && g.cfg.membership == membershipAuto &&
+				(err == errUnknownRequestKey || err == errBrokerTooOld || err == kerr.UnsupportedVersion) {
+				return errConsumerGroupUnsupported
+			}
+			// If we are unknown, we must rejoin with a new member
+			// ID. If we are fenced, we rejoin with our current ID.
+			// Either way, we have lost everything we owned.
+			if err == kerr.UnknownMemberID {
+				g.mu.Lock()
+				g.memberID = ""
+				g.mu.Unlock()
+			}
+			g.cfg.logger.Log(LogLevelInfo, "heartbeat errored", "group", g.cfg.group, "err", err)
+			return err
+		}
+
+		if !joined {
+			g.cfg.logger.Log(LogLevelInfo, "joined consumer group", "group", g.cfg.group, "member_id", resp.MemberID, "member_epoch", resp.MemberEpoch)
+			joined = true
+		}
+		g.mu.Lock()
+		if resp.MemberID != nil {
+			g.memberID = *resp.MemberID
+		}
+		g.generation = resp.MemberEpoch
+		g.mu.Unlock()
+
+		if req.SubscribedTopicNames != nil {
+			subscribe = false
+		}
+		if req.Topics != nil {
+			ack = false
+		}
+		if resp.Assignment != nil {
+			target = resp.Assignment
+			newTarget = true
+		}
+
+		interval := time.Duration(resp.HeartbeatIntervalMillis) * time.Millisecond
+		if interval <= 0 {
+			interval = g.cfg.heartbeatInterval
+		}
+		timer.Reset(interval)
+
+		// If we have a new assignment, or if we could not previously
+		// map all topic IDs in our assignment to topics, we reconcile.
+		// If we are already reconciling, we wait to reconcile until
+		// the current one is done.
+		if reconciled != nil || !newTarget && !unresolved {
+			continue
+		}
+		assigned, resolved := g.resolveAssignment(target)
+		if !resolved {
+			g.cl.triggerUpdateMetadataNow("consumer group assignment contains unknown topic IDs")
+		}
+		if newTarget || unresolved && !assignmentsEqual(assigned, g.nowAssigned) {
+			ch := make(chan error, 1)
+			reconciled = ch
+			go func() { ch <- g.reconcileAssignment(ctx, assigned) }()
+		}
+		newTarget, unresolved = false, !resolved
+	}
+}
+
+// newConsumerGroupHeartbeatRequest returns a heartbeat to send, including our
+// subscription and what we own if requested or if we are joining.
+func (g *groupConsumer) newConsumerGroupHeartbeatRequest(subscribe, ack bool) *kmsg.ConsumerGroupHeartbeatRequest {
+	req := kmsg.NewPtrConsumerGroupHeartbeatRequest()
+	req.Group = g.cfg.group
+	req.InstanceID = g.cfg.instanceID
+
+	g.mu.Lock()
+	req.MemberID = g.memberID
+	req.MemberEpoch = g.generation
+	if req.MemberEpoch == 0 {
+		// When joining, we must send all fields.
+		if g.cfg.rack != "" {
+			req.RackID = &g.cfg.rack
+		}
+		req.RebalanceTimeoutMillis = int32(g.cfg.rebalanceTimeout.Milliseconds())
+		subscribe, ack = true, true
+	}
+	if subscribe {
+		req.SubscribedTopicNames = make([]string, 0, len(g.using))
+		for topic := range g.using {
+			req.SubscribedTopicNames = append(req.SubscribedTopicNames, topic)
+		}
+		sort.Strings(req.SubscribedTopicNames)
+	}
+	g.mu.Unlock()
+
+	if ack {
+		topics := g.tps.load()
+		req.Topics = make([]kmsg.ConsumerGroupHeartbeatRequestTopic, 0, len(g.nowAssigned))
+		for topic, partitions := range g.nowAssigned {
+			t := topics.loadTopic(topic)
+			if t == nil || len(t.partitions) == 0 {
+				continue // we cannot own a topic we never resolved
+			}
+			reqTopic := kmsg.NewConsumerGroupHeartbeatRequestTopic()
+			reqTopic.TopicID = t.partitions[0].cursor.topicID
+			reqTopic.Partitions = partitions
+			req.Topics = append(req.Topics, reqTopic)
+		}
+	}
+	return req
+}
+
+// resolveAssignment maps the topic IDs in an assignment to topic names using
+// our topic metadata, returning false if any topic ID is unknown.
+func (g *groupConsumer) resolveAssignment(a *kmsg.ConsumerGroupHeartbeatResponseAssignment) (map[string][]int32, bool) {
+	var noID [16]byte
+	id2t := make(map[[16]byte]string)
+	for topic, t := range g.tps.load() {
+		if ps := t.load().partitions; len(ps) > 0 && ps[0].cursor.topicID != noID {
+			id2t[ps[0].cursor.topicID] = topic
+		}
+	}
+
+	assigned := make(map[string][]int32, len(a.Topics))
+	resolved := true
+	for _, t := range a.Topics {
+		topic, ok := id2t[t.TopicID]
+		if !ok {
+			resolved = false
+			continue
+		}
+		assigned[topic] = append(assigned[topic], t.Partitions...)
+	}
+	return assigned, resolved
+}
+
+// reconcileAssignment moves the member to a new assignment: partitions we no
+// longer own are revoked, and partitions we gained are assigned and have their
+// offsets fetched.
+func (g *groupConsumer) reconcileAssignment(ctx context.Context, assigned map[string][]int32) error {
+	g.lastAssigned = g.nowAssigned
+	g.nowAssigned = assigned
+	added, lost := g.diffAssigned()
+	g.cfg.logger.Log(LogLevelInfo, "reconciling consumer group assignment", "group", g.cfg.group, "added", tpsFmt(added), "lost", tpsFmt(lost))
+
+	if len(lost) > 0 {
+		g.revoke(revokeLastSession, lost, false)
+	}
+	if g.cfg.onAssigned != nil {
+		g.cfg.onAssigned(g.cl.ctx, g.cl, added)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	return g.fetchOffsets(ctx, added, lost)
+}
+
+// leaveConsumerGroup leaves a KIP-848 group by heartbeating with a member epoch
+// of -1. With an instance ID, we use -2, which keeps our assignment until our
+// session expires so that we can restart and rejoin without moving partitions.
+func (g *groupConsumer) leaveConsumerGroup() {
+	if g.memberID == "" {
+		return // we never joined
+	}
+	epoch := int32(-1)
+	if g.cfg.instanceID != nil {
+		epoch = -2
+	}
+	g.cfg.logger.Log(LogLevelInfo, "leaving consumer group",
+		"group", g.cfg.group,
+		"member_id", g.memberID, // lock not needed now since nothing can change it (manageDone)
+		"member_epoch", epoch,
+	)
+	req := kmsg.NewPtrConsumerGroupHeartbeatRequest()
+	req.Group = g.cfg.group
+	req.MemberID = g.memberID
+	req.MemberEpoch = epoch
+	req.InstanceID = g.cfg.instanceID
+	req.RequestWith(g.cl.ctx, g.cl)
+}
+
+// assignmentsEqual returns whether two assignments contain the same
+// partitions, regardless of order.
+func assignmentsEqual(l, r map[string][]int32) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	for topic, lps := range l {
+		rps, ok := r[topic]
+		if !ok || len(lps) != len(rps) {
+			return false
+		}
+		seen := make(map[int32]struct{}, len(lps))
+		for _, p := range lps {
+			seen[p] = struct{}{}
+		}
+		for _, p := range rps {
+			if _, ok := seen[p]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
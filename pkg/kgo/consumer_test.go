@@ -0,0 +1,1086 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/kversion"
+)
+
+func TestManageFetchConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session := &consumerSession{
+		ctx:            ctx,
+		cancel:         cancel,
+		desireFetchCh:  make(chan chan chan struct{}, 8),
+		cancelFetchCh:  make(chan chan chan struct{}, 4),
+		allowedFetches: 1,
+	}
+
+	recvOrFail := func(ch chan chan struct{}, exp bool) chan<- struct{} {
+		t.Helper()
+		select {
+		case done := <-ch:
+			if !exp {
+				t.Fatal("unexpectedly allowed to fetch")
+			}
+			return done
+		case <-time.After(50 * time.Millisecond):
+			if exp {
+				t.Fatal("not allowed to fetch in time")
+			}
+			return nil
+		}
+	}
+
+	first, second, third := make(chan chan struct{}, 1), make(chan chan struct{}, 1), make(chan chan struct{}, 1)
+	session.desireFetch() <- first
+	done := recvOrFail(first, true)
+
+	// Only one fetch is allowed at once; further fetches wait in order.
+	session.desireFetch() <- second
+	session.desireFetch() <- third
+	recvOrFail(second, false)
+
+	// Canceling a waiting fetch removes only that fetch.
+	session.cancelFetchCh <- second
+	done <- struct{}{}
+	done = recvOrFail(third, true)
+	recvOrFail(second, false)
+	done <- struct{}{}
+}
+
+func TestCommitAndReleaseExcludesReleased(t *testing.T) {
+	g := &groupConsumer{
+		cfg:         &cfg{balancers: []GroupBalancer{CooperativeStickyBalancer()}},
+		using:       map[string]int{"a": 3, "b": 1},
+		nowAssigned: map[string][]int32{"a": {0, 1, 2}, "b": {0}},
+		released:    map[string][]int32{"a": {1}, "b": {0}},
+	}
+
+	protos := g.joinGroupProtocols()
+	if len(protos) != 1 {
+		t.Fatalf("got %d protocols, exp 1", len(protos))
+	}
+	var meta kmsg.ConsumerMemberMetadata
+	if err := meta.ReadFrom(protos[0].Metadata); err != nil {
+		t.Fatalf("unable to read join metadata: %v", err)
+	}
+	owned := make(map[string][]int32)
+	for _, o := range meta.OwnedPartitions {
+		owned[o.Topic] = o.Partitions
+	}
+	if exp := map[string][]int32{"a": {0, 2}}; !reflect.DeepEqual(owned, exp) {
+		t.Errorf("got owned %v, exp %v", owned, exp)
+	}
+
+	// Our current assignment must be untouched until we sync.
+	if exp := map[string][]int32{"a": {0, 1, 2}, "b": {0}}; !reflect.DeepEqual(g.nowAssigned, exp) {
+		t.Errorf("nowAssigned changed to %v, exp %v", g.nowAssigned, exp)
+	}
+}
+
+func TestCommitAndReleaseNotCooperative(t *testing.T) {
+	cl, err := NewClient(
+		SeedBrokers("127.0.0.1:1"),
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		Balancers(RangeBalancer()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	if err := cl.CommitAndRelease(context.Background(), map[string][]int32{"t": {0}}); err != errReleaseNotCooperative {
+		t.Errorf("got err %v, exp %v", err, errReleaseNotCooperative)
+	}
+}
+
+// Released partitions are recorded before the commit is issued, so that a
+// rejoin or offset fetch racing with the commit does not reclaim them.
+func TestCommitAndReleaseRecordsBeforeCommit(t *testing.T) {
+	t.Parallel()
+	var (
+		g        *groupConsumer
+		assigned = make(chan struct{}, 1)
+		released = make(chan []int32, 1)
+		revoked  = make(chan map[string][]int32, 1)
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.JoinGroupRequest:
+			resp := req.ResponseKind().(*kmsg.JoinGroupResponse)
+			resp.Generation = 1
+			resp.MemberID, resp.LeaderID = "m", "m"
+			resp.Protocol = &req.Protocols[0].Name
+			m := kmsg.NewJoinGroupResponseMember()
+			m.MemberID = "m"
+			m.ProtocolMetadata = req.Protocols[0].Metadata
+			resp.Members = append(resp.Members, m)
+			return resp
+		case *kmsg.SyncGroupRequest:
+			resp := req.ResponseKind().(*kmsg.SyncGroupResponse)
+			for _, a := range req.GroupAssignment {
+				resp.MemberAssignment = a.MemberAssignment
+			}
+			return resp
+		case *kmsg.OffsetCommitRequest:
+			g.mu.Lock()
+			select {
+			case released <- append([]int32(nil), g.released["t"]...):
+			default:
+			}
+			g.mu.Unlock()
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		DisableAutoCommit(),
+		OnPartitionsAssigned(func(context.Context, *Client, map[string][]int32) {
+			select {
+			case assigned <- struct{}{}:
+			default:
+			}
+		}),
+		OnPartitionsRevoked(func(_ context.Context, _ *Client, m map[string][]int32) {
+			// Released partitions are revoked before they are
+			// committed, which we check by no commit being issued.
+			if len(released) != 0 {
+				m = nil
+			}
+			select {
+			case revoked <- m:
+			default:
+			}
+		}),
+	)
+	defer cl.Close()
+	g = cl.consumer.g
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	select {
+	case <-assigned:
+	case <-ctx.Done():
+		t.Fatal("partitions were not assigned")
+	}
+
+	g.mu.Lock()
+	g.uncommitted = uncommitted{"t": {0: {head: EpochOffset{-1, 5}}}}
+	g.mu.Unlock()
+	if err := cl.CommitAndRelease(ctx, map[string][]int32{"t": {0}}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ps := <-released:
+		if !reflect.DeepEqual(ps, []int32{0}) {
+			t.Errorf("got released %v while committing, exp [0]", ps)
+		}
+	default:
+		t.Fatal("no offset commit was issued")
+	}
+	select {
+	case m := <-revoked:
+		if exp := map[string][]int32{"t": {0}}; !reflect.DeepEqual(m, exp) {
+			t.Errorf("got revoked %v before committing, exp %v", m, exp)
+		}
+	default:
+		t.Fatal("released partitions were not revoked")
+	}
+}
+
+func TestOffsetString(t *testing.T) {
+	for _, test := range []struct {
+		o   Offset
+		exp string
+	}{
+		{NewOffset(), "{end.-1 0}"},
+		{NewOffset().AtStart(), "{start.-1 0}"},
+		{NewOffset().AtEnd().Relative(-10), "{end-10.-1 0}"},
+		{NewOffset().At(5).Relative(2).WithEpoch(3), "{5+2.3 0}"},
+		{NewOffset().AfterMilli(1000), "{after:1000.-1 0}"},
+		{NewOffset().AfterMilli(1000).At(7), "{7.-1 0}"},
+	} {
+		if got := test.o.String(); got != test.exp {
+			t.Errorf("got %s != exp %s", got, test.exp)
+		}
+	}
+}
+
+func TestListOffsetsTimestamps(t *testing.T) {
+	load := offsetLoadMap{"t": {
+		0: {replica: -1, Offset: NewOffset().AtStart()},
+		1: {replica: -1, Offset: NewOffset().AtEnd()},
+		2: {replica: -1, Offset: NewOffset().At(10)},
+		3: {replica: -1, Offset: NewOffset().AfterMilli(1234)},
+	}}
+	req := load.buildListReq(0)
+	got := make(map[int32]int64)
+	for _, p := range req.Topics[0].Partitions {
+		got[p.Partition] = p.Timestamp
+	}
+	exp := map[int32]int64{0: -2, 1: -1, 2: -1, 3: 1234}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got timestamps %v != exp %v", got, exp)
+	}
+}
+
+// When we list offsets, we keep the listed leader epoch for truncation
+// detection if we are consuming at or before the listed offset; a later fenced
+// fetch then checks the listed epoch for truncation.
+func TestListedOffsetEpochDetectsTruncation(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		name      string
+		offset    Offset
+		listEpoch int32 // -1 mirrors brokers before ListOffsets v4
+		expEpoch  int32 // the OffsetForLeaderEpoch epoch, or -1 if none
+		expOffset int64 // where we fetch after being fenced
+	}{
+		{"listed", NewOffset().AtStart(), 5, 5, 8}, // listed 10, truncated to 8
+		{"before listed", NewOffset().AtEnd().Relative(-3), 5, 5, 7},
+		{"after listed", NewOffset().AtStart().Relative(3), 5, -1, 13},
+		{"no listed epoch", NewOffset().AtStart(), -1, -1, 10},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu         sync.Mutex
+				fenced     bool
+				epochs     []int32
+				afterFence []int64
+			)
+			cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+				switch req := kreq.(type) {
+				case *kmsg.MetadataRequest:
+					resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+					for i := range resp.Topics {
+						rp := kmsg.NewMetadataResponseTopicPartition()
+						rp.LeaderEpoch = 7
+						rp.Replicas = []int32{0}
+						rp.ISR = []int32{0}
+						resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+					}
+					return resp
+
+				case *kmsg.ListOffsetsRequest:
+					resp := req.ResponseKind().(*kmsg.ListOffsetsResponse)
+					for _, rt := range req.Topics {
+						st := kmsg.NewListOffsetsResponseTopic()
+						st.Topic = rt.Topic
+						for _, rp := range rt.Partitions {
+							sp := kmsg.NewListOffsetsResponseTopicPartition()
+							sp.Partition = rp.Partition
+							sp.Offset = 10
+							sp.LeaderEpoch = test.listEpoch
+							st.Partitions = append(st.Partitions, sp)
+						}
+						resp.Topics = append(resp.Topics, st)
+					}
+					return resp
+
+				case *kmsg.OffsetForLeaderEpochRequest:
+					resp := req.ResponseKind().(*kmsg.OffsetForLeaderEpochResponse)
+					for _, rt := range req.Topics {
+						st := kmsg.NewOffsetForLeaderEpochResponseTopic()
+						st.Topic = rt.Topic
+						for _, rp := range rt.Partitions {
+							mu.Lock()
+							epochs = append(epochs, rp.LeaderEpoch)
+							mu.Unlock()
+							sp := kmsg.NewOffsetForLeaderEpochResponseTopicPartition()
+							sp.Partition = rp.Partition
+							sp.LeaderEpoch = rp.LeaderEpoch
+							sp.EndOffset = 8
+							st.Partitions = append(st.Partitions, sp)
+						}
+						resp.Topics = append(resp.Topics, st)
+					}
+					return resp
+
+				case *kmsg.FetchRequest:
+					time.Sleep(5 * time.Millisecond)
+					resp := req.ResponseKind().(*kmsg.FetchResponse)
+					for _, rt := range req.Topics {
+						st := kmsg.NewFetchResponseTopic()
+						st.Topic = rt.Topic
+						st.TopicID = rt.TopicID
+						for _, rp := range rt.Partitions {
+							sp := kmsg.NewFetchResponseTopicPartition()
+							sp.Partition = rp.Partition
+							sp.HighWatermark = 20
+							mu.Lock()
+							if !fenced {
+								fenced = true
+								sp.ErrorCode = kerr.FencedLeaderEpoch.Code
+							} else {
+								afterFence = append(afterFence, rp.FetchOffset)
+							}
+							mu.Unlock()
+							st.Partitions = append(st.Partitions, sp)
+						}
+						resp.Topics = append(resp.Topics, st)
+					}
+					return resp
+				}
+				return kreq.ResponseKind()
+			},
+				ConsumePartitions(map[string]map[int32]Offset{"t": {0: test.offset}}),
+				FetchMaxWait(10*time.Millisecond),
+				MetadataMinAge(10*time.Millisecond),
+			)
+			defer cl.Close()
+
+			go func() {
+				for {
+					if fs := cl.PollFetches(context.Background()); fs.IsClientClosed() {
+						return
+					}
+				}
+			}()
+
+			deadline := time.Now().Add(10 * time.Second)
+			for {
+				mu.Lock()
+				fetched := len(afterFence)
+				mu.Unlock()
+				if fetched >= 3 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("timed out waiting for fetches after being fenced")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case test.expEpoch < 0 && len(epochs) > 0:
+				t.Errorf("got OffsetForLeaderEpoch with epochs %v, exp none", epochs)
+			case test.expEpoch >= 0 && (len(epochs) != 1 || epochs[0] != test.expEpoch):
+				t.Errorf("got OffsetForLeaderEpoch with epochs %v, exp [%d]", epochs, test.expEpoch)
+			}
+			if got := afterFence[len(afterFence)-1]; got != test.expOffset {
+				t.Errorf("got fetch offset %d after being fenced, exp %d", got, test.expOffset)
+			}
+		})
+	}
+}
+
+// Truncation detected when validating an offset with an epoch is passed to
+// ConsumerOnDataLossDetected and returned from a fetch.
+func TestConsumerOnDataLossDetected(t *testing.T) {
+	t.Parallel()
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				rp := kmsg.NewMetadataResponseTopicPartition()
+				rp.LeaderEpoch = 7
+				rp.Replicas = []int32{0}
+				rp.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+			}
+			return resp
+
+		case *kmsg.OffsetForLeaderEpochRequest:
+			resp := req.ResponseKind().(*kmsg.OffsetForLeaderEpochResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewOffsetForLeaderEpochResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewOffsetForLeaderEpochResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.LeaderEpoch = rp.LeaderEpoch
+					sp.EndOffset = 8
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			time.Sleep(5 * time.Millisecond)
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": {0: NewOffset().At(10).WithEpoch(5)}}),
+		ConsumerOnDataLossDetected(func(lost *ErrDataLoss) {
+			if lost.Topic != "t" || lost.Partition != 0 || lost.ConsumedTo != 10 || lost.ResetTo != 8 {
+				t.Errorf("got data loss %+v, exp t[0] consumed to 10 reset to 8", *lost)
+			}
+		}),
+		FetchMaxWait(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var lost *ErrDataLoss
+	for lost == nil && ctx.Err() == nil {
+		cl.PollFetches(ctx).EachError(func(_ string, _ int32, err error) {
+			errors.As(err, &lost)
+		})
+	}
+	if lost == nil {
+		t.Fatal("timed out waiting for data loss")
+	}
+}
+
+func TestCommitBeforeDelivery(t *testing.T) {
+	t.Parallel()
+	var (
+		failCommit bool
+		committed  map[int32]int64
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			resp.Host, resp.Port = "127.0.0.1", 9092
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.JoinGroupRequest:
+			resp := req.ResponseKind().(*kmsg.JoinGroupResponse)
+			resp.ErrorCode = kerr.GroupAuthorizationFailed.Code // keep the group from joining
+			return resp
+		case *kmsg.OffsetCommitRequest:
+			resp := req.ResponseKind().(*kmsg.OffsetCommitResponse)
+			committed = make(map[int32]int64)
+			for _, rt := range req.Topics {
+				st := kmsg.NewOffsetCommitResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					committed[rp.Partition] = rp.Offset
+					sp := kmsg.NewOffsetCommitResponseTopicPartition()
+					sp.Partition = rp.Partition
+					if failCommit {
+						sp.ErrorCode = kerr.GroupAuthorizationFailed.Code
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		AutoCommitBeforeDelivery(),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	polled := Fetches{{Topics: []FetchTopic{{
+		Topic: "t",
+		Partitions: []FetchPartition{
+			{Partition: 0, Records: []*Record{
+				{Topic: "t", Partition: 0, Offset: 3, LeaderEpoch: -1},
+				{Topic: "t", Partition: 0, Offset: 4, LeaderEpoch: -1},
+			}},
+			{Partition: 1, Records: []*Record{
+				{Topic: "t", Partition: 1, Offset: 9, LeaderEpoch: -1},
+			}},
+		},
+	}}}}
+	g := cl.consumer.g
+
+	fetches := g.maybeCommitBeforeDelivery(polled, polled)
+	if !reflect.DeepEqual(fetches, polled) {
+		t.Errorf("successful commit did not deliver the polled fetches")
+	}
+	if exp := map[int32]int64{0: 5, 1: 10}; !reflect.DeepEqual(committed, exp) {
+		t.Errorf("got committed %v, exp %v", committed, exp)
+	}
+
+	failCommit = true
+	fetches = g.maybeCommitBeforeDelivery(polled, polled)
+	if n := len(fetches.Records()); n != 0 {
+		t.Errorf("failed commit delivered %d records, exp 0", n)
+	}
+	errs := fetches.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("got %d fetch errors, exp 2", len(errs))
+	}
+	for _, err := range errs {
+		if !errors.Is(err.Err, kerr.GroupAuthorizationFailed) {
+			t.Errorf("got fetch error %v, exp the commit error", err.Err)
+		}
+	}
+}
+
+// Canceling a poll while it commits before delivery does not fail the commit:
+// the records were already taken and are delivered.
+func TestCommitBeforeDeliveryPollCanceled(t *testing.T) {
+	t.Parallel()
+	var (
+		cancelPoll context.CancelFunc
+		committed  = make(chan int64, 1)
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.JoinGroupRequest:
+			resp := req.ResponseKind().(*kmsg.JoinGroupResponse)
+			resp.Generation = 1
+			resp.MemberID, resp.LeaderID = "m", "m"
+			resp.Protocol = &req.Protocols[0].Name
+			m := kmsg.NewJoinGroupResponseMember()
+			m.MemberID = "m"
+			m.ProtocolMetadata = req.Protocols[0].Metadata
+			resp.Members = append(resp.Members, m)
+			return resp
+		case *kmsg.SyncGroupRequest:
+			resp := req.ResponseKind().(*kmsg.SyncGroupResponse)
+			for _, a := range req.GroupAssignment {
+				resp.MemberAssignment = a.MemberAssignment
+			}
+			return resp
+		case *kmsg.OffsetFetchRequest:
+			resp := req.ResponseKind().(*kmsg.OffsetFetchResponse)
+			for _, rg := range req.Groups {
+				sg := kmsg.NewOffsetFetchResponseGroup()
+				sg.Group = rg.Group
+				for _, rt := range rg.Topics {
+					st := kmsg.NewOffsetFetchResponseGroupTopic()
+					st.Topic = rt.Topic
+					for _, p := range rt.Partitions {
+						sp := kmsg.NewOffsetFetchResponseGroupTopicPartition()
+						sp.Partition = p
+						st.Partitions = append(st.Partitions, sp)
+					}
+					sg.Topics = append(sg.Topics, st)
+				}
+				resp.Groups = append(resp.Groups, sg)
+			}
+			for _, rt := range req.Topics {
+				st := kmsg.NewOffsetFetchResponseTopic()
+				st.Topic = rt.Topic
+				for _, p := range rt.Partitions {
+					sp := kmsg.NewOffsetFetchResponseTopicPartition()
+					sp.Partition = p
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		case *kmsg.FetchRequest:
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.HighWatermark = 2
+					if rp.FetchOffset == 0 {
+						batch := kmsg.RecordBatch{
+							Magic:         2,
+							ProducerID:    -1,
+							ProducerEpoch: -1,
+							FirstSequence: -1,
+						}
+						batch.SetRecords([]kmsg.Record{{Value: []byte("a")}, {OffsetDelta: 1, Value: []byte("b")}})
+						sp.RecordBatches = batch.AppendToRecomputed(nil)
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		case *kmsg.OffsetCommitRequest:
+			cancelPoll()
+			for _, rt := range req.Topics {
+				for _, rp := range rt.Partitions {
+					select {
+					case committed <- rp.Offset:
+					default:
+					}
+				}
+			}
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		AutoCommitBeforeDelivery(),
+		FetchMaxWait(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTimeout()
+	ctx, cancel := context.WithCancel(timeout)
+	cancelPoll = cancel
+
+	fetches := cl.PollFetches(ctx)
+	if errs := fetches.Errors(); len(errs) != 0 {
+		t.Fatalf("got poll errors %v, exp none", errs)
+	}
+	if n := len(fetches.Records()); n != 2 {
+		t.Errorf("got %d records, exp 2", n)
+	}
+	select {
+	case offset := <-committed:
+		if offset != 2 {
+			t.Errorf("got committed offset %d, exp 2", offset)
+		}
+	default:
+		t.Error("records were delivered without being committed")
+	}
+}
+
+// With InterruptPollOnRebalance, a heartbeat that detects a rebalance wakes a
+// blocked poll before partitions are revoked.
+func TestInterruptPollOnRebalance(t *testing.T) {
+	t.Parallel()
+	var heartbeats int
+	revoked := make(chan struct{}, 1)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.JoinGroupRequest:
+			resp := req.ResponseKind().(*kmsg.JoinGroupResponse)
+			resp.Generation = 1
+			resp.MemberID, resp.LeaderID = "m", "m"
+			resp.Protocol = &req.Protocols[0].Name
+			m := kmsg.NewJoinGroupResponseMember()
+			m.MemberID = "m"
+			m.ProtocolMetadata = req.Protocols[0].Metadata
+			resp.Members = append(resp.Members, m)
+			return resp
+		case *kmsg.SyncGroupRequest:
+			resp := req.ResponseKind().(*kmsg.SyncGroupResponse)
+			for _, a := range req.GroupAssignment {
+				resp.MemberAssignment = a.MemberAssignment
+			}
+			return resp
+		case *kmsg.HeartbeatRequest:
+			resp := req.ResponseKind().(*kmsg.HeartbeatResponse)
+			if heartbeats++; heartbeats == 2 {
+				resp.ErrorCode = kerr.RebalanceInProgress.Code
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		InterruptPollOnRebalance(),
+		HeartbeatInterval(50*time.Millisecond),
+		OnPartitionsRevoked(func(context.Context, *Client, map[string][]int32) {
+			select {
+			case revoked <- struct{}{}:
+			default:
+			}
+		}),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	fs := cl.PollFetches(ctx)
+	if ctx.Err() != nil {
+		t.Fatal("poll was not interrupted by the rebalance")
+	}
+	errs := fs.Errors()
+	if len(errs) != 1 || errs[0].Err != kerr.RebalanceInProgress {
+		t.Fatalf("got poll errors %v, exp one %v", errs, kerr.RebalanceInProgress)
+	}
+	select {
+	case <-revoked:
+	case <-ctx.Done():
+		t.Error("partitions were not revoked after the rebalance signal")
+	}
+}
+
+func TestConsumerGroupMembership(t *testing.T) {
+	t.Parallel()
+	id := [16]byte{1}
+	var (
+		epoch   int32
+		acks    [][]int32
+		lastReq *kmsg.ConsumerGroupHeartbeatRequest
+		done    = make(chan struct{})
+
+		mu                sync.Mutex
+		assigned, revoked []map[string][]int32
+	)
+	vs := kversion.Tip()
+	vs.SetMaxKeyVersion(kmsg.ConsumerGroupHeartbeat.Int16(), 0)
+	assignment := func(ps ...int32) *kmsg.ConsumerGroupHeartbeatResponseAssignment {
+		a := kmsg.NewConsumerGroupHeartbeatResponseAssignment()
+		at := kmsg.NewConsumerGroupHeartbeatResponseAssignmentTopic()
+		at.TopicID, at.Partitions = id, ps
+		a.Topics = append(a.Topics, at)
+		return &a
+	}
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				resp.Topics[i].TopicID = id
+				for p := int32(0); p < 3; p++ {
+					mp := kmsg.NewMetadataResponseTopicPartition()
+					mp.Partition, mp.Replicas, mp.ISR = p, []int32{0}, []int32{0}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, mp)
+				}
+			}
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.ConsumerGroupHeartbeatRequest:
+			lastReq = req
+			resp := req.ResponseKind().(*kmsg.ConsumerGroupHeartbeatResponse)
+			resp.HeartbeatIntervalMillis = 50
+			if req.MemberEpoch < 0 {
+				resp.MemberEpoch = req.MemberEpoch
+				return resp
+			}
+			if req.Topics != nil {
+				var owned []int32
+				for _, rt := range req.Topics {
+					if rt.TopicID == id {
+						owned = append(owned, rt.Partitions...)
+					}
+				}
+				sort.Slice(owned, func(i, j int) bool { return owned[i] < owned[j] })
+				acks = append(acks, owned)
+			}
+			switch {
+			case req.MemberEpoch == 0:
+				resp.Assignment = assignment(0, 1, 2)
+				epoch = 1
+			case len(acks) == 2 && req.Topics != nil:
+				resp.Assignment = assignment(0) // move 1 and 2 away
+				epoch = 2
+			case len(acks) == 3 && req.Topics != nil:
+				close(done)
+			}
+			mid := "m"
+			resp.MemberID, resp.MemberEpoch = &mid, epoch
+			return resp
+		case *kmsg.OffsetFetchRequest:
+			resp := req.ResponseKind().(*kmsg.OffsetFetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewOffsetFetchResponseTopic()
+				st.Topic = rt.Topic
+				for _, p := range rt.Partitions {
+					sp := kmsg.NewOffsetFetchResponseTopicPartition()
+					sp.Partition, sp.Offset = p, 5
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		GroupMembership(ConsumerGroupMembership()),
+		MaxVersions(vs),
+		OnPartitionsAssigned(func(_ context.Context, _ *Client, m map[string][]int32) {
+			mu.Lock()
+			defer mu.Unlock()
+			assigned = append(assigned, m)
+		}),
+		OnPartitionsRevoked(func(_ context.Context, _ *Client, m map[string][]int32) {
+			mu.Lock()
+			defer mu.Unlock()
+			revoked = append(revoked, m)
+		}),
+	)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the assignment to be reconciled")
+	}
+	cl.Close()
+
+	// We join owning nothing, acknowledge our first assignment, and then
+	// acknowledge the revoke.
+	if exp := [][]int32{nil, {0, 1, 2}, {0}}; !reflect.DeepEqual(acks, exp) {
+		t.Errorf("got acks %v, exp %v", acks, exp)
+	}
+	if lastReq.MemberEpoch != -1 || lastReq.MemberID != "m" {
+		t.Errorf("got last heartbeat member %s epoch %d, exp leaving m -1", lastReq.MemberID, lastReq.MemberEpoch)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range append(assigned, revoked...) {
+		for _, ps := range m {
+			sort.Slice(ps, func(i, j int) bool { return ps[i] < ps[j] })
+		}
+	}
+	if exp := []map[string][]int32{{"t": {0, 1, 2}}, {}}; !reflect.DeepEqual(assigned, exp) {
+		t.Errorf("got assigned %v, exp %v", assigned, exp)
+	}
+	if len(revoked) == 0 || !reflect.DeepEqual(revoked[0], map[string][]int32{"t": {1, 2}}) {
+		t.Errorf("got revoked %v, exp first revoke of t [1 2]", revoked)
+	}
+}
+
+// With the consumer group protocol, our generation is our member epoch, which
+// only OffsetCommit v9+ understands; we fetch offsets as our member, and we
+// do not commit at all if v9 cannot be issued.
+func TestConsumerGroupMembershipCommitVersion(t *testing.T) {
+	t.Parallel()
+	for _, commitMax := range []int16{9, 8} {
+		commitMax := commitMax
+		t.Run(fmt.Sprint(commitMax), func(t *testing.T) {
+			t.Parallel()
+			id := [16]byte{1}
+			var (
+				mu      sync.Mutex
+				commits []*kmsg.OffsetCommitRequest
+				fetches []*kmsg.OffsetFetchRequest
+				fetched = make(chan struct{})
+			)
+			vs := kversion.Tip()
+			vs.SetMaxKeyVersion(kmsg.ConsumerGroupHeartbeat.Int16(), 0)
+			vs.SetMaxKeyVersion(kmsg.OffsetCommit.Int16(), commitMax)
+			cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+				mu.Lock()
+				defer mu.Unlock()
+				switch req := kreq.(type) {
+				case *kmsg.MetadataRequest:
+					resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+					for i := range resp.Topics {
+						resp.Topics[i].TopicID = id
+						mp := kmsg.NewMetadataResponseTopicPartition()
+						mp.Replicas, mp.ISR = []int32{0}, []int32{0}
+						resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, mp)
+					}
+					return resp
+				case *kmsg.FindCoordinatorRequest:
+					resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+					for _, k := range req.CoordinatorKeys {
+						c := kmsg.NewFindCoordinatorResponseCoordinator()
+						c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+						resp.Coordinators = append(resp.Coordinators, c)
+					}
+					return resp
+				case *kmsg.ConsumerGroupHeartbeatRequest:
+					resp := req.ResponseKind().(*kmsg.ConsumerGroupHeartbeatResponse)
+					resp.HeartbeatIntervalMillis = 50
+					if req.MemberEpoch == 0 {
+						a := kmsg.NewConsumerGroupHeartbeatResponseAssignment()
+						at := kmsg.NewConsumerGroupHeartbeatResponseAssignmentTopic()
+						at.TopicID, at.Partitions = id, []int32{0}
+						a.Topics = append(a.Topics, at)
+						resp.Assignment = &a
+					}
+					mid := "m"
+					resp.MemberID, resp.MemberEpoch = &mid, 3
+					return resp
+				case *kmsg.OffsetFetchRequest:
+					if fetches = append(fetches, req); len(fetches) == 1 {
+						close(fetched)
+					}
+					resp := req.ResponseKind().(*kmsg.OffsetFetchResponse)
+					for _, rg := range req.Groups {
+						sg := kmsg.NewOffsetFetchResponseGroup()
+						sg.Group = rg.Group
+						for _, rt := range rg.Topics {
+							st := kmsg.NewOffsetFetchResponseGroupTopic()
+							st.Topic = rt.Topic
+							for _, p := range rt.Partitions {
+								sp := kmsg.NewOffsetFetchResponseGroupTopicPartition()
+								sp.Partition, sp.Offset = p, 5
+								st.Partitions = append(st.Partitions, sp)
+							}
+							sg.Topics = append(sg.Topics, st)
+						}
+						resp.Groups = append(resp.Groups, sg)
+					}
+					return resp
+				case *kmsg.OffsetCommitRequest:
+					commits = append(commits, req)
+					resp := req.ResponseKind().(*kmsg.OffsetCommitResponse)
+					for _, rt := range req.Topics {
+						st := kmsg.NewOffsetCommitResponseTopic()
+						st.Topic = rt.Topic
+						for _, rp := range rt.Partitions {
+							sp := kmsg.NewOffsetCommitResponseTopicPartition()
+							sp.Partition = rp.Partition
+							st.Partitions = append(st.Partitions, sp)
+						}
+						resp.Topics = append(resp.Topics, st)
+					}
+					return resp
+				}
+				return kreq.ResponseKind()
+			},
+				ConsumerGroup("g"),
+				ConsumeTopics("t"),
+				GroupMembership(ConsumerGroupMembership()),
+				MaxVersions(vs),
+				DisableAutoCommit(),
+			)
+			defer cl.Close()
+
+			select {
+			case <-fetched:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for offsets to be fetched for our assignment")
+			}
+
+			var commitErr error
+			cl.CommitOffsetsSync(context.Background(), map[string]map[int32]EpochOffset{"t": {0: {-1, 10}}},
+				func(_ *Client, _ *kmsg.OffsetCommitRequest, _ *kmsg.OffsetCommitResponse, err error) {
+					commitErr = err
+				},
+			)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(fetches) == 0 || len(fetches[0].Groups) != 1 ||
+				fetches[0].Version != 9 ||
+				fetches[0].Groups[0].MemberID == nil || *fetches[0].Groups[0].MemberID != "m" ||
+				fetches[0].Groups[0].MemberEpoch != 3 {
+				t.Errorf("got offset fetches %+v, exp a v9 fetch as member m epoch 3", fetches)
+			}
+			if commitMax < 9 {
+				if commitErr != errConsumerGroupCommitUnsupported || len(commits) != 0 {
+					t.Errorf("got commit err %v and %d commits, exp errConsumerGroupCommitUnsupported and none", commitErr, len(commits))
+				}
+				return
+			}
+			if commitErr != nil {
+				t.Fatalf("unexpected commit err: %v", commitErr)
+			}
+			if len(commits) != 1 || commits[0].Version != 9 || commits[0].Generation != 3 || commits[0].MemberID != "m" {
+				t.Errorf("got commits %+v, exp one v9 commit as member m epoch 3", commits)
+			}
+		})
+	}
+}
+
+func TestAutoGroupMembershipFallback(t *testing.T) {
+	t.Parallel()
+	joined := make(chan struct{})
+	var once sync.Once
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas, p.ISR = []int32{0}, []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.JoinGroupRequest:
+			once.Do(func() { close(joined) })
+			resp := req.ResponseKind().(*kmsg.JoinGroupResponse)
+			resp.ErrorCode = kerr.GroupAuthorizationFailed.Code
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumerGroup("g"),
+		ConsumeTopics("t"),
+		GroupMembership(AutoGroupMembership()), // the default stable versions do not have ConsumerGroupHeartbeat
+	)
+	defer cl.Close()
+
+	select {
+	case <-joined:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the group to fall back to JoinGroup")
+	}
+	if cl.consumer.g.kip848.get() {
+		t.Error("group is still using the consumer group protocol after falling back")
+	}
+}
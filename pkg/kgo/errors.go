@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"time"
 )
 
 func isRetriableBrokerErr(err error) bool {
@@ -86,8 +87,9 @@ func isSkippableBrokerErr(err error) bool {
 	// broker, but we can skip to the next.
 	//
 	// We take anything that returns an OpError that *is not* a context
-	// error deep inside.
-	if err == errUnknownBroker {
+	// error deep inside. A broker with an open circuit breaker will not
+	// be dialed until its cooldown elapses, so we skip it as well.
+	if err == errUnknownBroker || errors.Is(err, ErrBrokerCircuitOpen) {
 		return true
 	}
 	var ne *net.OpError
@@ -132,6 +134,23 @@ var (
 	// assigned a group.
 	errNotGroup = errors.New("invalid group function call when not assigned a group")
 
+	// Returned when releasing partitions with a group that is not
+	// using a cooperative balancer.
+	errReleaseNotCooperative = errors.New("invalid partition release when the group is not cooperative")
+
+	// Returned when releasing partitions with a group that is using the
+	// KIP-848 consumer group protocol, where the broker owns assignments.
+	errReleaseConsumerGroup = errors.New("invalid partition release when the group is using the KIP-848 consumer group protocol")
+
+	// Returned from a KIP-848 group session if the client or the group
+	// coordinator does not support ConsumerGroupHeartbeat and the group
+	// can fall back to the classic protocol.
+	errConsumerGroupUnsupported = errors.New("the client or group coordinator does not support the KIP-848 consumer group protocol")
+
+	// Returned when committing with the KIP-848 consumer group protocol
+	// if OffsetCommit v9 cannot be issued.
+	errConsumerGroupCommitUnsupported = errors.New("committing with the KIP-848 consumer group protocol requires OffsetCommit v9, which the client's max versions or the brokers do not support")
+
 	// Returned when trying to begin a transaction with a client that does
 	// not have a transactional ID.
 	errNotTransactional = errors.New("invalid attempt to begin a transaction with a non-transactional client")
@@ -139,6 +158,10 @@ var (
 	// Returned when trying to produce a record outside of a transaction.
 	errNotInTransaction = errors.New("cannot produce record transactionally if not in a transaction")
 
+	// Returned when committing to an offset store with a client that was
+	// not configured with one.
+	errNoOffsetStore = errors.New("invalid offset store commit when the client has no offset store")
+
 	//////////////
 	// EXTERNAL //
 	//////////////
@@ -159,6 +182,10 @@ var (
 	// AbortBufferedRecords is being called.
 	ErrAborting = errors.New("client is aborting buffered records")
 
+	// ErrBrokerCircuitOpen is returned for requests to a broker whose
+	// connection circuit breaker is open; see BrokerCircuitBreaker.
+	ErrBrokerCircuitOpen = errors.New("broker circuit breaker is open after repeated connection failures")
+
 	// ErrClientClosed is returned in various places when the client's
 	// Close function has been called.
 	//
@@ -192,6 +219,74 @@ func (e *ErrDataLoss) Error() string {
 		e.Topic, e.Partition, e.ConsumedTo, e.ResetTo)
 }
 
+// ErrCorruptBatch is returned in a FetchPartition's Err when a fetched record
+// batch (or message set) has a length or CRC that does not match its
+// contents. No records from the corrupt batch or any later batch in the same
+// fetch response are returned; see HookFetchBatchCorrupt.
+//
+// The client always verifies the length and CRC of every fetched batch,
+// including batches that LazyRecordDecoding leaves undecoded; there is no
+// option to disable verification. The CRC is computed over the still
+// compressed batch, so its cost is small next to decompressing and decoding.
+type ErrCorruptBatch struct {
+	// Topic is the topic of the corrupt batch.
+	Topic string
+	// Partition is the partition of the corrupt batch.
+	Partition int32
+	// Offset is the base offset of the corrupt batch, as encoded in the
+	// batch.
+	Offset int64
+	// Err is why the batch is invalid.
+	Err error
+}
+
+func (e *ErrCorruptBatch) Error() string {
+	return fmt.Sprintf("topic %s partition %d has a corrupt batch at offset %d: %v",
+		e.Topic, e.Partition, e.Offset, e.Err)
+}
+
+func (e *ErrCorruptBatch) Unwrap() error { return e.Err }
+
+// ErrDeadLettered is passed to a produce promise when a record permanently
+// failed and was instead produced to the client's DeadLetterTopic.
+type ErrDeadLettered struct {
+	// DeadLetter is the copy of the record that was produced to the dead
+	// letter topic, with its partition and offset set.
+	DeadLetter *Record
+	// Err is why the original record failed.
+	Err error
+}
+
+func (e *ErrDeadLettered) Error() string {
+	return fmt.Sprintf("record was produced to dead letter topic %s partition %d offset %d after failing: %v",
+		e.DeadLetter.Topic, e.DeadLetter.Partition, e.DeadLetter.Offset, e.Err)
+}
+
+func (e *ErrDeadLettered) Unwrap() error { return e.Err }
+
+// ErrDiscoveryTimeout is returned when a request stops retrying discovery
+// because another retry would exceed the request's RetryTimeoutFn. Discovery
+// is a metadata or FindCoordinator request, or a request that failed because
+// its coordinator is loading, unavailable, or has moved; see
+// DiscoveryBackoffFn.
+//
+// This wraps the last discovery error, so errors.Is can still be used to
+// check for errors such as kerr.CoordinatorLoadInProgress.
+type ErrDiscoveryTimeout struct {
+	// Elapsed is how long the request was tried before giving up.
+	Elapsed time.Duration
+	// Tries is how many times the request was tried.
+	Tries int
+	// Err is the last error that would have been retried.
+	Err error
+}
+
+func (e *ErrDiscoveryTimeout) Error() string {
+	return fmt.Sprintf("discovery timed out after %d tries over %s: %v", e.Tries, e.Elapsed, e.Err)
+}
+
+func (e *ErrDiscoveryTimeout) Unwrap() error { return e.Err }
+
 type errUnknownController struct {
 	id int32
 }
@@ -0,0 +1,167 @@
+package kgo
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// newFakeBrokerClient returns a client whose every dial connects to an
+// in-memory fake broker. The fake broker replies to ApiVersions itself and
+// passes every other request to handle, which must return the response to
+// write. handle is never called concurrently.
+func newFakeBrokerClient(t *testing.T, handle func(kmsg.Request) kmsg.Response, opts ...Opt) *Client {
+	t.Helper()
+	return newFakeBrokerClientConn(t, handle, func(c net.Conn) net.Conn { return c }, opts...)
+}
+
+// newFakeBrokerClientConn is newFakeBrokerClient, but wraps the client side
+// of every connection with wrap.
+func newFakeBrokerClientConn(t *testing.T, handle func(kmsg.Request) kmsg.Response, wrap func(net.Conn) net.Conn, opts ...Opt) *Client {
+	t.Helper()
+	var mu sync.Mutex
+	dial := func(context.Context, string, string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+
+			// Pipes are unbuffered; like a real socket, we buffer
+			// responses so that we keep reading requests even if
+			// the client is not yet reading responses.
+			resps := make(chan []byte, 64)
+			defer close(resps)
+			go func() {
+				var err error
+				for buf := range resps {
+					if err == nil {
+						_, err = server.Write(buf)
+					}
+				}
+			}()
+
+			for {
+				req, corrID, err := readFakeRequest(server)
+				if err != nil {
+					return
+				}
+				var resp kmsg.Response
+				if apiVersions, ok := req.(*kmsg.ApiVersionsRequest); ok {
+					resp = fakeApiVersions(apiVersions)
+				} else {
+					mu.Lock()
+					resp = handle(req)
+					mu.Unlock()
+				}
+				resp.SetVersion(req.GetVersion())
+				resps <- appendFakeResponse(corrID, req, resp)
+			}
+		}()
+		return wrap(client), nil
+	}
+	cl, err := NewClient(append([]Opt{Dialer(dial)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cl
+}
+
+func readFakeRequest(r io.Reader) (kmsg.Request, int32, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, 0, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, 0, err
+	}
+	b := kbin.Reader{Src: buf}
+	key, version, corrID := b.Int16(), b.Int16(), b.Int32()
+	b.NullableString() // client ID
+	req := kmsg.RequestForKey(key)
+	if key == fakeRawKey {
+		req = new(rawMessage)
+	}
+	req.SetVersion(version)
+	if req.IsFlexible() {
+		for n := b.Uvarint(); n > 0; n-- { // header tags
+			b.Uvarint()
+			b.Span(int(b.Uvarint()))
+		}
+	}
+	if err := b.Complete(); err != nil {
+		return nil, 0, err
+	}
+	return req, corrID, req.ReadFrom(b.Src)
+}
+
+func appendFakeResponse(corrID int32, req kmsg.Request, resp kmsg.Response) []byte {
+	buf := make([]byte, 8, 64)
+	binary.BigEndian.PutUint32(buf[4:], uint32(corrID))
+	if req.IsFlexible() && req.Key() != 18 { // ApiVersions responses never have header tags
+		buf = append(buf, 0)
+	}
+	buf = resp.AppendTo(buf)
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	return buf
+}
+
+func fakeApiVersions(*kmsg.ApiVersionsRequest) kmsg.Response {
+	resp := kmsg.NewPtrApiVersionsResponse()
+	for key := int16(0); key < 100; key++ {
+		req := kmsg.RequestForKey(key)
+		if req == nil {
+			continue
+		}
+		k := kmsg.NewApiVersionsResponseApiKey()
+		k.ApiKey = key
+		k.MaxVersion = req.MaxVersion()
+		resp.ApiKeys = append(resp.ApiKeys, k)
+	}
+	k := kmsg.NewApiVersionsResponseApiKey()
+	k.ApiKey = fakeRawKey
+	k.MaxVersion = 1
+	resp.ApiKeys = append(resp.ApiKeys, k)
+
+	supported := kmsg.NewApiVersionsResponseSupportedFeature()
+	supported.Name = "metadata.version"
+	supported.MinVersion = 1
+	supported.MaxVersion = 20
+	resp.SupportedFeatures = append(resp.SupportedFeatures, supported)
+	finalized := kmsg.NewApiVersionsResponseFinalizedFeature()
+	finalized.Name = "metadata.version"
+	finalized.MinVersionLevel = 1
+	finalized.MaxVersionLevel = 14
+	resp.FinalizedFeaturesEpoch = 3
+	resp.FinalizedFeatures = append(resp.FinalizedFeatures, finalized)
+	return resp
+}
+
+// fakeRawKey is an API key that kmsg does not model, which the fake broker
+// supports up to version 1 as a rawMessage.
+const fakeRawKey = kmsg.MaxKey + 10
+
+// rawMessage is both a request and response for fakeRawKey, with an opaque
+// body.
+type rawMessage struct {
+	version int16
+	body    []byte
+}
+
+func (*rawMessage) Key() int16                 { return fakeRawKey }
+func (*rawMessage) MaxVersion() int16          { return 3 }
+func (m *rawMessage) SetVersion(v int16)       { m.version = v }
+func (m *rawMessage) GetVersion() int16        { return m.version }
+func (*rawMessage) IsFlexible() bool           { return false }
+func (m *rawMessage) AppendTo(b []byte) []byte { return append(b, m.body...) }
+func (m *rawMessage) ReadFrom(b []byte) error {
+	m.body = append([]byte(nil), b...)
+	return nil
+}
+func (*rawMessage) ResponseKind() kmsg.Response { return new(rawMessage) }
+func (*rawMessage) RequestKind() kmsg.Request   { return new(rawMessage) }
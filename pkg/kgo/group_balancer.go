@@ -553,6 +553,149 @@ func (*rangeBalancer) Balance(b *ConsumerBalancer, topics map[string]int32) Into
 	return plan
 }
 
+// PreferredPartitionsBalancer returns a group balancer that assigns
+// partitions to the members that prefer them, if possible. Each member
+// specifies the partitions it prefers per topic, which are sent to the group
+// leader in the member's join metadata. This can be used for locality aware
+// assignment, such as preferring partitions whose leaders are in the same rack
+// as the member.
+//
+// Every partition is always assigned if any member is interested in the
+// partition's topic. Preferences are not constraints: a partition that more
+// than one member prefers is assigned to whichever of those members has the
+// fewest partitions so far, and a partition that no member prefers is
+// assigned to the member with the fewest partitions overall. Preferences for
+// topics a member is not consuming, or for partitions that do not exist, are
+// ignored.
+//
+// Suppose there are two members M0 and M1 and one topic t0 with four
+// partitions, and M0 prefers t0p0 and t0p1 while M1 prefers t0p0. The
+// balancing will be
+//
+//     M0: [t0p1, t0p2]
+//     M1: [t0p0, t0p3]
+//
+// All members of a group must use this balancer for it to be chosen. The
+// preferences are encoded in the join metadata's UserData as a
+// kmsg.ConsumerMemberAssignment.
+func PreferredPartitionsBalancer(preferred map[string][]int32) GroupBalancer {
+	var prefs kmsg.ConsumerMemberAssignment
+	for topic, partitions := range preferred {
+		t := kmsg.NewConsumerMemberAssignmentTopic()
+		t.Topic = topic
+		t.Partitions = append([]int32(nil), partitions...)
+		sort.Slice(t.Partitions, func(i, j int) bool { return t.Partitions[i] < t.Partitions[j] })
+		prefs.Topics = append(prefs.Topics, t)
+	}
+	sort.Slice(prefs.Topics, func(i, j int) bool { return prefs.Topics[i].Topic < prefs.Topics[j].Topic })
+	return &preferredBalancer{userdata: prefs.AppendTo(nil)}
+}
+
+type preferredBalancer struct {
+	userdata []byte
+}
+
+func (*preferredBalancer) ProtocolName() string { return "preferred-partitions" }
+func (*preferredBalancer) IsCooperative() bool  { return false }
+func (p *preferredBalancer) JoinGroupMetadata(interests []string, _ map[string][]int32, _ int32) []byte {
+	meta := kmsg.NewConsumerMemberMetadata()
+	meta.Version = 0
+	meta.Topics = interests // input interests are already sorted
+	meta.UserData = p.userdata
+	return meta.AppendTo(nil)
+}
+
+func (*preferredBalancer) ParseSyncAssignment(assignment []byte) (map[string][]int32, error) {
+	return ParseConsumerSyncAssignment(assignment)
+}
+
+func (p *preferredBalancer) MemberBalancer(members []kmsg.JoinGroupResponseMember) (GroupMemberBalancer, map[string]struct{}, error) {
+	b, err := NewConsumerBalancer(p, members)
+	return b, b.MemberTopics(), err
+}
+
+func (*preferredBalancer) Balance(b *ConsumerBalancer, topics map[string]int32) IntoSyncAssignment {
+	type topicPartition struct {
+		topic     string
+		partition int32
+	}
+	var (
+		members    = b.Members()
+		subscribed = make(map[string][]int) // topic => member indices
+		preferring = make(map[topicPartition][]int)
+		counts     = make([]int, len(members))
+	)
+	for i := range members {
+		_, meta := b.MemberAt(i)
+		interested := make(map[string]bool, len(meta.Topics))
+		for _, topic := range meta.Topics {
+			interested[topic] = true
+			subscribed[topic] = append(subscribed[topic], i)
+		}
+		// Invalid or missing preferences are treated as no
+		// preferences; the member is still assigned partitions.
+		var prefs kmsg.ConsumerMemberAssignment
+		if err := prefs.ReadFrom(meta.UserData); err != nil {
+			continue
+		}
+		for _, t := range prefs.Topics {
+			if !interested[t.Topic] {
+				continue
+			}
+			for _, p := range t.Partitions {
+				if p >= 0 && p < topics[t.Topic] {
+					tp := topicPartition{t.Topic, p}
+					preferring[tp] = append(preferring[tp], i)
+				}
+			}
+		}
+	}
+
+	// We assign preferred partitions first so that the balance of
+	// unpreferred partitions accounts for them. Partitions preferred by
+	// fewer members are assigned first, giving contended partitions to
+	// whichever preferring member has the least so far.
+	var preferred, unpreferred []topicPartition
+	for topic := range subscribed {
+		for partition := int32(0); partition < topics[topic]; partition++ {
+			tp := topicPartition{topic, partition}
+			if len(preferring[tp]) > 0 {
+				preferred = append(preferred, tp)
+			} else {
+				unpreferred = append(unpreferred, tp)
+			}
+		}
+	}
+	for _, tps := range [][]topicPartition{preferred, unpreferred} {
+		sort.Slice(tps, func(i, j int) bool {
+			l, r := tps[i], tps[j]
+			if ln, rn := len(preferring[l]), len(preferring[r]); ln != rn {
+				return ln < rn
+			}
+			return l.topic < r.topic || l.topic == r.topic && l.partition < r.partition
+		})
+	}
+
+	plan := b.NewPlan()
+	assign := func(tp topicPartition, candidates []int) {
+		least := candidates[0]
+		for _, idx := range candidates[1:] {
+			if counts[idx] < counts[least] {
+				least = idx
+			}
+		}
+		counts[least]++
+		plan.AddPartition(&members[least], tp.topic, tp.partition)
+	}
+	for _, tp := range preferred {
+		assign(tp, preferring[tp])
+	}
+	for _, tp := range unpreferred {
+		assign(tp, subscribed[tp.topic])
+	}
+	return plan
+}
+
 // StickyBalancer returns a group balancer that ensures minimal partition
 // movement on group changes while also ensuring optimal balancing.
 //
@@ -1,6 +1,7 @@
 package kgo
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -99,3 +100,76 @@ func Test_stickyAdjustCooperative(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestPreferredPartitionsBalancer(t *testing.T) {
+	member := func(id string, topics []string, prefs map[string][]int32) kmsg.JoinGroupResponseMember {
+		m := kmsg.NewJoinGroupResponseMember()
+		m.MemberID = id
+		m.ProtocolMetadata = PreferredPartitionsBalancer(prefs).JoinGroupMetadata(topics, nil, 0)
+		return m
+	}
+
+	for _, test := range []struct {
+		name    string
+		members []kmsg.JoinGroupResponseMember
+		topics  map[string]int32
+		exp     map[string]map[string][]int32
+	}{
+		{
+			name: "contended",
+			members: []kmsg.JoinGroupResponseMember{
+				member("m0", []string{"t0"}, map[string][]int32{"t0": {0, 1}}),
+				member("m1", []string{"t0"}, map[string][]int32{"t0": {0}}),
+			},
+			topics: map[string]int32{"t0": 4},
+			exp: map[string]map[string][]int32{
+				"m0": {"t0": {1, 2}},
+				"m1": {"t0": {0, 3}},
+			},
+		},
+
+		{
+			name: "conflicting prefs still assign everything",
+			members: []kmsg.JoinGroupResponseMember{
+				member("m0", []string{"t0", "t1"}, map[string][]int32{"t0": {0, 1, 2}, "t1": {0}}),
+				member("m1", []string{"t0", "t1"}, map[string][]int32{"t0": {0, 1, 2}, "t1": {0}}),
+				member("m2", []string{"t1"}, nil),
+			},
+			topics: map[string]int32{"t0": 3, "t1": 2},
+			exp: map[string]map[string][]int32{
+				"m0": {"t0": {0, 2}},
+				"m1": {"t0": {1}, "t1": {0}},
+				"m2": {"t1": {1}},
+			},
+		},
+
+		{
+			name: "unknown and unsubscribed prefs ignored",
+			members: []kmsg.JoinGroupResponseMember{
+				member("m0", []string{"t0"}, map[string][]int32{"t0": {5}, "t1": {0}}),
+				member("m1", []string{"t0", "t1"}, map[string][]int32{"t0": {1}}),
+			},
+			topics: map[string]int32{"t0": 2, "t1": 1},
+			exp: map[string]map[string][]int32{
+				"m0": {"t0": {0}},
+				"m1": {"t0": {1}, "t1": {0}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b, _, err := PreferredPartitionsBalancer(nil).MemberBalancer(test.members)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := b.Balance(test.topics).(*BalancePlan).plan
+			for _, topics := range got {
+				for _, partitions := range topics {
+					sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+				}
+			}
+			if diff := cmp.Diff(test.exp, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -53,6 +53,14 @@ type HookBrokerDisconnect interface {
 	OnBrokerDisconnect(meta BrokerMetadata, conn net.Conn)
 }
 
+// HookBrokerCircuitState is called when a broker's connection circuit
+// breaker changes state; see the BrokerCircuitBreaker option.
+type HookBrokerCircuitState interface {
+	// OnBrokerCircuitState is passed the broker metadata and the new
+	// state of the broker's circuit breaker.
+	OnBrokerCircuitState(meta BrokerMetadata, state BrokerCircuitState)
+}
+
 // HookBrokerWrite is called after a write to a broker.
 //
 // Kerberos SASL does not cause write hooks, since it directly writes to the
@@ -167,7 +175,8 @@ type HookBrokerThrottle interface {
 	//
 	// If throttledAfterResponse is false, then Kafka already applied the
 	// throttle. If it is true, the client internally will not send another
-	// request until the throttle deadline has passed.
+	// request on the same connection until the throttle deadline has
+	// passed; requests to other brokers are not delayed.
 	OnBrokerThrottle(meta BrokerMetadata, throttleInterval time.Duration, throttledAfterResponse bool)
 }
 
@@ -229,6 +238,10 @@ type ProduceBatchMetrics struct {
 
 // HookProduceBatchWritten is called whenever a batch is known to be
 // successfully produced.
+//
+// The batch metrics can be used to track the distribution of batch sizes,
+// which is useful when tuning lingering and max record batch bytes. The
+// client's ProduceMetrics method also tracks this distribution.
 type HookProduceBatchWritten interface {
 	// OnProduceBatchWritten is called per successful batch written to a
 	// topic partition
@@ -288,6 +301,21 @@ type HookFetchBatchRead interface {
 	OnFetchBatchRead(meta BrokerMetadata, topic string, partition int32, metrics FetchBatchMetrics)
 }
 
+// HookFetchBatchCorrupt is called when a fetched record batch (or message
+// set) fails validation: its length or CRC does not match its contents.
+//
+// The client validates the CRC of every fetched batch. A corrupt batch is not
+// returned; instead, the partition's FetchPartition.Err is an
+// *ErrCorruptBatch, and the partition is not consumed past the batch until a
+// refetch returns a valid batch. This hook can be used to count or alert on
+// corruption.
+type HookFetchBatchCorrupt interface {
+	// OnFetchBatchCorrupt is passed the broker the batch was fetched
+	// from, the topic and partition of the batch, the base offset of the
+	// batch, and why the batch is invalid.
+	OnFetchBatchCorrupt(meta BrokerMetadata, topic string, partition int32, offset int64, err error)
+}
+
 ///////////////////////////////
 // PRODUCE & CONSUME RECORDS //
 ///////////////////////////////
@@ -298,8 +326,8 @@ type HookFetchBatchRead interface {
 // This hook can be used to write metrics that gather the number of records or
 // bytes buffered, or the hook can be used to write interceptors that modify a
 // record's key / value / headers before being produced. If you just want a
-// metric for the number of records buffered, use the client's
-// BufferedProduceRecords method, as it is faster.
+// metric for the number of records or bytes buffered, use the client's
+// BufferedProduceRecords or BufferedProduceBytes methods, as they are faster.
 //
 // Note that this hook may slow down high-volume producing a bit.
 type HookProduceRecordBuffered interface {
@@ -316,6 +344,10 @@ type HookProduceRecordBuffered interface {
 // As an example, if using HookProduceRecordBuffered for a gauge of how many
 // record bytes are buffered, this hook can be used to decrement the gauge.
 //
+// For the latency from producing to acknowledgement, as well as buffered
+// record and byte gauges, use the client's ProduceMetrics method rather than
+// this hook.
+//
 // Note that this hook may slow down high-volume producing a bit.
 type HookProduceRecordUnbuffered interface {
 	// OnProduceRecordUnbuffered is passed a record that is just about to
@@ -0,0 +1,182 @@
+package kgo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// LazyRecordBatch is a fetched record batch that has not been decompressed or
+// decoded. Batches are only returned if the client is consuming with the
+// LazyRecordDecoding option.
+//
+// The batch fields are read from the batch header and are available without
+// decoding. The batch is decompressed and its records are split the first time
+// Records is called; a batch that is never accessed is never decompressed.
+//
+// A batch references the memory of the fetch response it was read from; that
+// memory is not reused and is kept alive for as long as the batch is.
+type LazyRecordBatch struct {
+	// Topic is the topic this batch is from.
+	Topic string
+	// Partition is the partition this batch is from.
+	Partition int32
+	// FirstOffset is the offset of the first record in the batch. If the
+	// fetch offset was in the middle of the batch, records before the
+	// fetch offset are skipped and not returned from Records.
+	FirstOffset int64
+	// LastOffset is the offset of the last record in the batch.
+	LastOffset int64
+	// NumRecords is the number of records the batch header claims, which
+	// includes any records before the fetch offset.
+	NumRecords int32
+	// LeaderEpoch is the leader epoch of the broker at the time this batch
+	// was written.
+	LeaderEpoch int32
+	// ProducerID is the producer ID that produced this batch.
+	ProducerID int64
+	// ProducerEpoch is the producer epoch that produced this batch.
+	ProducerEpoch int16
+	// FirstSequence is the sequence number of the first record in the
+	// batch, or -1 if the batch was not produced idempotently.
+	FirstSequence int32
+	// Attrs are the attributes of the batch, and of every record in it.
+	Attrs RecordAttrs
+
+	batch        *kmsg.RecordBatch
+	minOffset    int64
+	decompressor *decompressor
+
+	once    sync.Once
+	records []LazyRecord
+	err     error
+}
+
+// LazyRecord is a record within a LazyRecordBatch. The offset and key are
+// split from the batch when the batch is decoded; the value and headers are
+// only read when accessed.
+type LazyRecord struct {
+	// Offset is the offset of this record.
+	Offset int64
+	// Key is the record's key. This references the decompressed batch.
+	Key []byte
+
+	batch *LazyRecordBatch
+	raw   []byte // the full encoded record
+	rest  []byte // the encoded value and headers
+}
+
+// Records decompresses the batch, if necessary, and returns its records. The
+// batch is only decoded once; this can be called concurrently and repeatedly.
+//
+// This returns an error if the batch cannot be decompressed or is malformed.
+func (b *LazyRecordBatch) Records() ([]LazyRecord, error) {
+	b.once.Do(func() { b.records, b.err = b.decode() })
+	return b.records, b.err
+}
+
+func (b *LazyRecordBatch) decode() ([]LazyRecord, error) {
+	raw := b.batch.Records
+	if compression := byte(b.batch.Attributes & 0x0007); compression != 0 {
+		var err error
+		if raw, err = b.decompressor.decompress(raw, compression); err != nil {
+			return nil, fmt.Errorf("unable to decompress batch: %w", err)
+		}
+	}
+
+	rs := make([]LazyRecord, 0, b.NumRecords)
+	for i := int32(0); i < b.NumRecords; i++ {
+		length, used := kbin.Varint(raw)
+		total := used + int(length)
+		if used == 0 || length < 0 || len(raw) < total {
+			return nil, errLazyRecordTruncated
+		}
+
+		r := kbin.Reader{Src: raw[used:total]}
+		r.Int8()   // attributes
+		r.Varint() // timestamp delta
+		offsetDelta := r.Varint()
+		key := r.VarintBytes()
+		rest := r.Src
+
+		// We validate the rest of the record now so that accessing the
+		// value or headers later cannot fail.
+		r.VarintBytes() // value
+		for n := r.VarintArrayLen(); n > 0; n-- {
+			r.VarintBytes() // header key
+			r.VarintBytes() // header value
+		}
+		if err := r.Complete(); err != nil {
+			return nil, errLazyRecordTruncated
+		}
+
+		if offset := b.batch.FirstOffset + int64(offsetDelta); offset >= b.minOffset {
+			rs = append(rs, LazyRecord{
+				Offset: offset,
+				Key:    key,
+				batch:  b,
+				raw:    raw[:total],
+				rest:   rest,
+			})
+		}
+		raw = raw[total:]
+	}
+	return rs, nil
+}
+
+// lastConsumed returns the leader epoch and offset of the final record or lazy
+// batch in the partition, and whether the partition has any.
+func (p *FetchPartition) lastConsumed() (int32, int64, bool) {
+	var (
+		epoch  int32
+		offset int64 = -1
+	)
+	if len(p.Records) > 0 {
+		final := p.Records[len(p.Records)-1]
+		epoch, offset = final.LeaderEpoch, final.Offset
+	}
+	if len(p.LazyBatches) > 0 {
+		if final := p.LazyBatches[len(p.LazyBatches)-1]; final.LastOffset > offset {
+			epoch, offset = final.LeaderEpoch, final.LastOffset
+		}
+	}
+	return epoch, offset, offset >= 0
+}
+
+var errLazyRecordTruncated = errors.New("batch has truncated or malformed records")
+
+// Value returns the record's value. This references the decompressed batch
+// and does not allocate.
+func (r *LazyRecord) Value() []byte {
+	b := kbin.Reader{Src: r.rest}
+	return b.VarintBytes()
+}
+
+// Headers decodes and returns the record's headers.
+func (r *LazyRecord) Headers() []RecordHeader {
+	b := kbin.Reader{Src: r.rest}
+	b.VarintBytes() // value
+	n := b.VarintArrayLen()
+	if n <= 0 {
+		return nil
+	}
+	hs := make([]RecordHeader, 0, n)
+	for ; n > 0; n-- {
+		hs = append(hs, RecordHeader{
+			Key:   b.VarintString(),
+			Value: b.VarintBytes(),
+		})
+	}
+	return hs
+}
+
+// Record fully decodes the record into a Record, as if the record was fetched
+// without LazyRecordDecoding.
+func (r *LazyRecord) Record() *Record {
+	var kr kmsg.Record
+	_ = kr.ReadFrom(r.raw) // validated when splitting the batch
+	return recordToRecord(r.batch.Topic, r.batch.Partition, r.batch.batch, &kr)
+}
@@ -29,10 +29,15 @@ func (m *metawait) signal() {
 // waitmeta returns immediately if metadata was updated within the last second,
 // otherwise this waits for up to wait for a metadata update to complete.
 func (cl *Client) waitmeta(ctx context.Context, wait time.Duration, why string) {
-	now := time.Now()
+	cl.waitmetaAfter(ctx, wait, time.Now().Add(-cl.cfg.metadataMinAge), why)
+}
 
+// waitmetaAfter returns immediately if metadata was updated after the given
+// time, otherwise this triggers an immediate update and waits for up to wait
+// for it to complete.
+func (cl *Client) waitmetaAfter(ctx context.Context, wait time.Duration, after time.Time, why string) {
 	cl.metawait.mu.Lock()
-	if now.Sub(cl.metawait.lastUpdate) < cl.cfg.metadataMinAge {
+	if cl.metawait.lastUpdate.After(after) {
 		cl.metawait.mu.Unlock()
 		return
 	}
@@ -51,7 +56,7 @@ func (cl *Client) waitmeta(ctx context.Context, wait time.Duration, why string)
 		defer cl.metawait.mu.Unlock()
 
 		for !quit {
-			if now.Sub(cl.metawait.lastUpdate) < cl.cfg.metadataMinAge {
+			if cl.metawait.lastUpdate.After(after) {
 				return
 			}
 			cl.metawait.c.Wait()
@@ -95,6 +100,19 @@ func (cl *Client) triggerUpdateMetadataNow(why string) {
 	}
 }
 
+// ForceMetadataRefresh triggers the client to update the metadata that is
+// currently used for producing & consuming.
+//
+// Internally, the client already properly triggers metadata updates whenever a
+// partition is discovered to be out of date (leader moved, epoch is old, etc).
+// However, when partitions are added to a topic through a CreatePartitions
+// request, it may take up to MetadataMaxAge for the new partitions to be
+// discovered. In this case, you may want to forcefully refresh metadata
+// manually to discover these new partitions sooner.
+func (cl *Client) ForceMetadataRefresh() {
+	cl.triggerUpdateMetadataNow("from user ForceMetadataRefresh")
+}
+
 // updateMetadataLoop updates metadata whenever the update ticker ticks,
 // or whenever deliberately triggered.
 func (cl *Client) updateMetadataLoop() {
@@ -102,6 +120,11 @@ func (cl *Client) updateMetadataLoop() {
 	var consecutiveErrors int
 	var lastAt time.Time
 
+	// An immediate update bypasses the min age, but only once per min
+	// age: if many immediate updates are triggered on a flapping cluster,
+	// we do not want to issue a metadata request for every trigger.
+	var bypassedMinAge bool
+
 	ticker := time.NewTicker(cl.cfg.metadataMaxAge)
 	defer ticker.Stop()
 	for {
@@ -121,17 +144,34 @@ func (cl *Client) updateMetadataLoop() {
 		var nowTries int
 	start:
 		nowTries++
+		if nowTries == 1 {
+			switch {
+			case time.Since(lastAt) >= cl.cfg.metadataMinAge:
+				bypassedMinAge = false
+			case now && bypassedMinAge:
+				cl.cfg.logger.Log(LogLevelInfo, "immediate metadata update already bypassed the metadata min age, waiting for the min age to pass")
+				now = false
+			case now:
+				bypassedMinAge = true
+			}
+		}
 		if !now {
 			if wait := cl.cfg.metadataMinAge - time.Since(lastAt); wait > 0 {
+				nowCh := cl.updateMetadataNowCh
+				if bypassedMinAge {
+					nowCh = nil
+				}
 				timer := time.NewTimer(wait)
 				select {
 				case <-cl.ctx.Done():
 					timer.Stop()
 					return
-				case why := <-cl.updateMetadataNowCh:
+				case why := <-nowCh:
 					timer.Stop()
+					bypassedMinAge = true
 					cl.cfg.logger.Log(LogLevelInfo, "immediate metadata update triggered, bypassing normal wait", "why", why)
 				case <-timer.C:
+					bypassedMinAge = false
 				}
 			}
 		}
@@ -190,7 +230,7 @@ func (cl *Client) updateMetadataLoop() {
 		}
 
 		consecutiveErrors++
-		after := time.NewTimer(cl.cfg.retryBackoff(consecutiveErrors))
+		after := time.NewTimer(cl.cfg.discoveryBackoff(consecutiveErrors, err))
 		select {
 		case <-cl.ctx.Done():
 			after.Stop()
@@ -209,6 +249,11 @@ func (cl *Client) updateMetadataLoop() {
 // equally.
 func (cl *Client) updateMetadata() (needsRetry bool, err error, why multiUpdateWhy) {
 	defer cl.metawait.signal()
+	defer func() {
+		if err == nil {
+			cl.storeMetadataSnapshot()
+		}
+	}()
 	defer cl.consumer.doOnMetadataUpdate()
 
 	var (
@@ -416,6 +461,7 @@ func (cl *Client) fetchTopicMetadata(all bool, reqTopics []string) (map[string]*
 					topicID:     topicMeta.TopicID,
 					partition:   partMeta.Partition,
 					keepControl: cl.cfg.keepControl,
+					lazy:        cl.cfg.lazyDecoding,
 					cursorsIdx:  -1,
 
 					cursorOffset: cursorOffset{
@@ -570,7 +616,7 @@ func (cl *Client) mergeTopicPartitions(
 			*newTP = *oldTP
 			newTP.loadErr = err
 			if isProduce {
-				newTP.records.bumpRepeatedLoadErr(newTP.loadErr)
+				newTP.records.bumpLeaderlessLoadErr(newTP.loadErr)
 			}
 			needsRetry = true
 			why.add(topic, int32(part), newTP.loadErr)
@@ -730,3 +776,139 @@ func (m multiUpdateWhy) reason(reason string) string {
 	}
 	return reason + ": " + strings.Join(errorStrings, " ")
 }
+
+// MetadataSnapshot is a point in time copy of the client's view of the
+// cluster, as of the client's most recent successful metadata update.
+type MetadataSnapshot struct {
+	// Brokers are the brokers that were in the metadata response, sorted
+	// by node ID. This does not include seed brokers.
+	Brokers []BrokerMetadata
+
+	// ControllerID is the ID of the controller broker, or -1 if unknown.
+	ControllerID int32
+
+	// Topics contains every topic the client is producing to or
+	// consuming.
+	Topics map[string]MetadataSnapshotTopic
+
+	// LastUpdate is when the metadata update that this snapshot is from
+	// completed. This is the zero time if metadata has not yet loaded.
+	LastUpdate time.Time
+}
+
+// MetadataSnapshotTopic is a topic in a MetadataSnapshot.
+type MetadataSnapshotTopic struct {
+	// IsInternal is whether the topic is an internal Kafka topic.
+	IsInternal bool
+
+	// Err is any error from loading the topic, such as
+	// UNKNOWN_TOPIC_OR_PARTITION for a topic that does not exist. If the
+	// error is retriable, Partitions may contain partitions from a prior
+	// successful load.
+	Err error
+
+	// Partitions contains the topic's partitions, indexed by partition
+	// number.
+	Partitions []MetadataSnapshotPartition
+}
+
+// MetadataSnapshotPartition is a partition in a MetadataSnapshotTopic.
+type MetadataSnapshotPartition struct {
+	// Partition is the partition number.
+	Partition int32
+
+	// Leader is the node ID of the partition's leader.
+	Leader int32
+
+	// LeaderEpoch is the epoch of the partition's leader, or -1 if the
+	// client does not use leader epochs with this cluster.
+	LeaderEpoch int32
+
+	// Err is any error from loading this partition, such as
+	// LEADER_NOT_AVAILABLE. If non-nil, Leader and LeaderEpoch are from
+	// the prior successful load, if any.
+	Err error
+}
+
+// MetadataSnapshot returns a copy of the client's current metadata: the
+// known brokers, the controller, the leaders of every partition the client is
+// producing to or consuming, and when metadata was last refreshed.
+//
+// The snapshot is built by the metadata loop at the end of every successful
+// metadata update, so it is always internally consistent: it is never from
+// the middle of a refresh. The returned value is a copy and can be modified
+// freely. If metadata has not yet loaded, this returns an empty snapshot
+// with a ControllerID of -1.
+//
+// This is useful for debugging and for issuing requests to specific brokers
+// with Broker. Note that the cluster can change at any time, so the snapshot
+// may be stale by the time it is used.
+func (cl *Client) MetadataSnapshot() MetadataSnapshot {
+	s, _ := cl.metaSnapshot.Load().(*MetadataSnapshot)
+	if s == nil {
+		return MetadataSnapshot{ControllerID: unknownControllerID}
+	}
+
+	cp := *s
+	cp.Brokers = append([]BrokerMetadata(nil), s.Brokers...)
+	cp.Topics = make(map[string]MetadataSnapshotTopic, len(s.Topics))
+	for topic, t := range s.Topics {
+		t.Partitions = append([]MetadataSnapshotPartition(nil), t.Partitions...)
+		cp.Topics[topic] = t
+	}
+	return cp
+}
+
+// storeMetadataSnapshot is called only in the metadata loop, after a metadata
+// update has merged all new topic data.
+func (cl *Client) storeMetadataSnapshot() {
+	s := &MetadataSnapshot{
+		Topics:     make(map[string]MetadataSnapshotTopic),
+		LastUpdate: time.Now(),
+	}
+
+	cl.brokersMu.RLock()
+	for _, b := range cl.brokers {
+		s.Brokers = append(s.Brokers, b.meta)
+	}
+	cl.brokersMu.RUnlock()
+
+	cl.controllerIDMu.Lock()
+	s.ControllerID = cl.controllerID
+	cl.controllerIDMu.Unlock()
+
+	var tpsConsumer *topicsPartitions
+	switch c := &cl.consumer; {
+	case c.d != nil:
+		tpsConsumer = c.d.tps
+	case c.g != nil:
+		tpsConsumer = c.g.tps
+	}
+	for _, m := range []map[string]*topicPartitions{
+		cl.producer.topics.load(),
+		tpsConsumer.load(),
+	} {
+		for topic, parts := range m {
+			if _, exists := s.Topics[topic]; exists {
+				continue // producing and consuming the same topic: the data is the same
+			}
+			v := parts.load()
+			t := MetadataSnapshotTopic{
+				IsInternal: v.isInternal,
+				Err:        v.loadErr,
+				Partitions: make([]MetadataSnapshotPartition, 0, len(v.partitions)),
+			}
+			for i, p := range v.partitions {
+				t.Partitions = append(t.Partitions, MetadataSnapshotPartition{
+					Partition:   int32(i),
+					Leader:      p.leader,
+					LeaderEpoch: p.leaderEpoch,
+					Err:         p.loadErr,
+				})
+			}
+			s.Topics[topic] = t
+		}
+	}
+
+	cl.metaSnapshot.Store(s)
+}
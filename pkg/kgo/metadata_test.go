@@ -0,0 +1,150 @@
+package kgo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// An immediate metadata update can bypass the min age once, but repeated
+// immediate updates within the min age must be rate limited.
+func TestImmediateMetadataBypassesMinAgeOnce(t *testing.T) {
+	t.Parallel()
+	const minAge = 400 * time.Millisecond
+	var reqs int32
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		if req, ok := kreq.(*kmsg.MetadataRequest); ok {
+			atomic.AddInt32(&reqs, 1)
+			return fakeMetadataResponse(req, 0)
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	}, MetadataMinAge(minAge))
+	defer cl.Close()
+
+	waitReqs := func(exp int32) {
+		t.Helper()
+		for start := time.Now(); atomic.LoadInt32(&reqs) < exp; {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("timed out waiting for %d metadata requests, saw %d", exp, atomic.LoadInt32(&reqs))
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	cl.ForceMetadataRefresh()
+	waitReqs(1)
+	cl.ForceMetadataRefresh() // bypasses the min age
+	waitReqs(2)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		cl.ForceMetadataRefresh() // all coalesced and waiting for the min age
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&reqs); got != 2 {
+		t.Errorf("got %d metadata requests before the min age passed, exp 2", got)
+	}
+	waitReqs(3)
+	if elapsed := time.Since(start); elapsed < minAge/2 {
+		t.Errorf("third metadata request was issued after %v, exp waiting for the min age", elapsed)
+	}
+	time.Sleep(minAge / 4)
+	if got := atomic.LoadInt32(&reqs); got != 3 {
+		t.Errorf("got %d metadata requests, exp the repeated triggers to coalesce into 3", got)
+	}
+}
+
+// The metadata snapshot reflects the latest metadata update and is a copy.
+func TestMetadataSnapshot(t *testing.T) {
+	t.Parallel()
+	handle := fakeProduceHandler(t, func() int16 { return 0 })
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		kresp := handle(kreq)
+		if resp, ok := kresp.(*kmsg.MetadataResponse); ok {
+			b := kmsg.NewMetadataResponseBroker()
+			b.NodeID, b.Host, b.Port = 1, "127.0.0.1", 9092
+			resp.Brokers = append(resp.Brokers, b)
+			resp.ControllerID = 1
+			growFakePartitions(resp, 2)
+			for i := range resp.Topics {
+				for j := range resp.Topics[i].Partitions {
+					sp := &resp.Topics[i].Partitions[j]
+					sp.Leader = sp.Partition
+					sp.Replicas = []int32{sp.Partition}
+					sp.ISR = []int32{sp.Partition}
+				}
+			}
+		}
+		return kresp
+	}, MetadataMinAge(10*time.Millisecond))
+	defer cl.Close()
+
+	start := time.Now()
+	if err := cl.ProduceSync(context.Background(), &Record{Topic: "foo"}).FirstErr(); err != nil {
+		t.Fatalf("unable to produce: %v", err)
+	}
+
+	s := cl.MetadataSnapshot()
+	if len(s.Brokers) != 2 || s.Brokers[0].NodeID != 0 || s.Brokers[1].NodeID != 1 {
+		t.Errorf("got brokers %+v, exp nodes 0 and 1", s.Brokers)
+	}
+	if s.ControllerID != 1 {
+		t.Errorf("got controller %d, exp 1", s.ControllerID)
+	}
+	if s.LastUpdate.Before(start) {
+		t.Errorf("got last update %v before the first produce at %v", s.LastUpdate, start)
+	}
+	foo, exists := s.Topics["foo"]
+	if !exists || foo.Err != nil || len(foo.Partitions) != 2 {
+		t.Fatalf("got topic foo %+v (exists? %v), exp two loaded partitions", foo, exists)
+	}
+	for i, p := range foo.Partitions {
+		if p.Partition != int32(i) || p.Leader != int32(i) || p.Err != nil {
+			t.Errorf("partition %d: got %+v, exp leader %d", i, p, i)
+		}
+	}
+
+	s.Brokers[0].NodeID = 5
+	s.Topics["foo"].Partitions[0].Leader = 5
+	if s := cl.MetadataSnapshot(); s.Brokers[0].NodeID != 0 || s.Topics["foo"].Partitions[0].Leader != 0 {
+		t.Error("modifying a snapshot modified the client's snapshot")
+	}
+}
+
+// ForceMetadataRefresh discovers partitions added to a topic without waiting
+// for the metadata max age.
+func TestForceMetadataRefreshDiscoversPartitions(t *testing.T) {
+	t.Parallel()
+	var added int32
+	handle := fakeProduceHandler(t, func() int16 { return 0 })
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		kresp := handle(kreq)
+		if resp, ok := kresp.(*kmsg.MetadataResponse); ok && atomic.LoadInt32(&added) == 1 {
+			growFakePartitions(resp, 3)
+		}
+		return kresp
+	},
+		MetadataMinAge(10*time.Millisecond),
+		MetadataMaxAge(time.Hour),
+	)
+	defer cl.Close()
+
+	if err := cl.ProduceSync(context.Background(), &Record{Topic: "foo"}).FirstErr(); err != nil {
+		t.Fatalf("unable to produce: %v", err)
+	}
+	if n := len(cl.MetadataSnapshot().Topics["foo"].Partitions); n != 1 {
+		t.Fatalf("got %d partitions, exp 1", n)
+	}
+
+	atomic.StoreInt32(&added, 1)
+	cl.ForceMetadataRefresh()
+	for start := time.Now(); len(cl.MetadataSnapshot().Topics["foo"].Partitions) != 3; {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timed out waiting for the refresh to discover new partitions")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
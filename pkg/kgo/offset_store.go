@@ -0,0 +1,95 @@
+package kgo
+
+import (
+	"context"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// OffsetStore is an external store of consumed offsets, for use with the
+// ConsumeOffsetStore option when consuming partitions directly.
+//
+// Offsets in the store are the offsets to resume consuming at, i.e., one past
+// the last processed record, matching what Kafka itself stores for groups.
+type OffsetStore interface {
+	// FetchOffsets returns the stored offsets for the requested
+	// partitions. Partitions without stored offsets can be omitted from
+	// the returned map.
+	FetchOffsets(ctx context.Context, partitions map[string][]int32) (map[string]map[int32]EpochOffset, error)
+
+	// CommitOffsets stores the given offsets.
+	CommitOffsets(ctx context.Context, offsets map[string]map[int32]EpochOffset) error
+}
+
+// fetchStoredOffsets fetches offsets for new direct assignments from the
+// configured offset store. This is called without the consumer lock so that
+// a slow store does not block polling, and the store is bounded by the
+// OffsetFetch retry timeout, as fetching offsets from Kafka would be.
+func (d *directConsumer) fetchStoredOffsets(cl *Client, assignments map[string]map[int32]Offset) (map[string]map[int32]EpochOffset, error) {
+	partitions := make(map[string][]int32, len(assignments))
+	for topic, ps := range assignments {
+		for p := range ps {
+			partitions[topic] = append(partitions[topic], p)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(cl.ctx, cl.cfg.retryTimeout(kmsg.OffsetFetch.Int16()))
+	defer cancel()
+	return d.cfg.offsetStore.FetchOffsets(ctx, partitions)
+}
+
+// applyStoredOffsets overrides the offsets in new direct assignments with
+// offsets fetched from the offset store, and must be called with the consumer
+// lock held. If the store failed, this logs the error, forgets the assignments so that
+// they are retried on the next metadata update, and returns nil.
+func (d *directConsumer) applyStoredOffsets(cl *Client, assignments map[string]map[int32]Offset, stored map[string]map[int32]EpochOffset, err error) map[string]map[int32]Offset {
+	if err != nil {
+		d.cfg.logger.Log(LogLevelError, "unable to load offsets from offset store, retrying on the next metadata update", "err", err)
+		for topic, ps := range assignments {
+			for p := range ps {
+				delete(d.using[topic], p)
+			}
+		}
+		cl.triggerUpdateMetadata(false, "retry loading offsets from the offset store")
+		return nil
+	}
+
+	for topic, ps := range stored {
+		for p, eo := range ps {
+			if _, ok := assignments[topic][p]; ok {
+				assignments[topic][p] = NewOffset().At(eo.Offset).WithEpoch(eo.Epoch)
+			}
+		}
+	}
+	return assignments
+}
+
+// CommitRecordsToOffsetStore commits the offsets of the given records to the
+// offset store configured with ConsumeOffsetStore. For each partition, the
+// committed offset is one past the highest offset record in that partition,
+// which is where consuming resumes on restart.
+//
+// This returns an error if the client was not configured with an offset
+// store, or if the store fails to commit.
+func (cl *Client) CommitRecordsToOffsetStore(ctx context.Context, rs ...*Record) error {
+	store := cl.cfg.offsetStore
+	if store == nil {
+		return errNoOffsetStore
+	}
+	if len(rs) == 0 {
+		return nil
+	}
+	offsets := make(map[string]map[int32]EpochOffset)
+	for _, r := range rs {
+		toffsets := offsets[r.Topic]
+		if toffsets == nil {
+			toffsets = make(map[int32]EpochOffset)
+			offsets[r.Topic] = toffsets
+		}
+		next := EpochOffset{r.LeaderEpoch, r.Offset + 1}
+		if current, ok := toffsets[r.Partition]; !ok || current.less(next) {
+			toffsets[r.Partition] = next
+		}
+	}
+	return store.CommitOffsets(ctx, offsets)
+}
@@ -0,0 +1,190 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+type memOffsetStore struct {
+	fetchErr  error
+	fetched   map[string][]int32
+	deadline  bool
+	stored    map[string]map[int32]EpochOffset
+	committed map[string]map[int32]EpochOffset
+}
+
+func (m *memOffsetStore) FetchOffsets(ctx context.Context, partitions map[string][]int32) (map[string]map[int32]EpochOffset, error) {
+	m.fetched = partitions
+	_, m.deadline = ctx.Deadline()
+	return m.stored, m.fetchErr
+}
+
+func (m *memOffsetStore) CommitOffsets(_ context.Context, offsets map[string]map[int32]EpochOffset) error {
+	m.committed = offsets
+	return nil
+}
+
+func TestLoadStoredOffsets(t *testing.T) {
+	store := &memOffsetStore{
+		stored: map[string]map[int32]EpochOffset{
+			"a": {0: {Epoch: 3, Offset: 10}},
+		},
+	}
+	cl, err := NewClient(ConsumeTopics("a"), ConsumeOffsetStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	d := cl.consumer.d
+	reset := NewOffset().AtStart()
+	d.using["a"] = map[int32]struct{}{0: {}, 1: {}}
+	load := func() map[string]map[int32]Offset {
+		assignments := map[string]map[int32]Offset{"a": {0: reset, 1: reset}}
+		stored, err := d.fetchStoredOffsets(cl, assignments)
+		return d.applyStoredOffsets(cl, assignments, stored, err)
+	}
+	got := load()
+	if !store.deadline {
+		t.Error("store was fetched from without a bounded context")
+	}
+	exp := map[string]map[int32]Offset{"a": {0: NewOffset().At(10).WithEpoch(3), 1: reset}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got assignments %v != exp %v", got, exp)
+	}
+
+	// On failure, partitions are forgotten so that they are retried.
+	store.fetchErr = errors.New("unavailable")
+	if got := load(); got != nil {
+		t.Errorf("got assignments %v on store failure, expected none", got)
+	}
+	if len(d.using["a"]) != 0 {
+		t.Errorf("partitions were not forgotten on store failure: %v", d.using["a"])
+	}
+
+	if err := cl.CommitRecordsToOffsetStore(context.Background(),
+		&Record{Topic: "a", Partition: 0, Offset: 12, LeaderEpoch: 3},
+		&Record{Topic: "a", Partition: 0, Offset: 11, LeaderEpoch: 3},
+		&Record{Topic: "a", Partition: 1, Offset: 4, LeaderEpoch: 2},
+	); err != nil {
+		t.Fatal(err)
+	}
+	expCommitted := map[string]map[int32]EpochOffset{"a": {0: {3, 13}, 1: {2, 5}}}
+	if !reflect.DeepEqual(store.committed, expCommitted) {
+		t.Errorf("got committed %v != exp %v", store.committed, expCommitted)
+	}
+}
+
+type blockingOffsetStore struct {
+	entered chan struct{}
+	release chan map[string]map[int32]EpochOffset
+}
+
+func (b *blockingOffsetStore) FetchOffsets(ctx context.Context, _ map[string][]int32) (map[string]map[int32]EpochOffset, error) {
+	b.entered <- struct{}{}
+	select {
+	case stored := <-b.release:
+		return stored, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (*blockingOffsetStore) CommitOffsets(context.Context, map[string]map[int32]EpochOffset) error {
+	return nil
+}
+
+// A slow offset store does not block polling, and consuming starts from the
+// stored offsets once the store returns.
+func TestLoadStoredOffsetsDoesNotBlockPolling(t *testing.T) {
+	t.Parallel()
+	store := &blockingOffsetStore{
+		entered: make(chan struct{}, 10),
+		release: make(chan map[string]map[int32]EpochOffset, 1),
+	}
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				rp := kmsg.NewMetadataResponseTopicPartition()
+				rp.Replicas = []int32{0}
+				rp.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					batch := kmsg.RecordBatch{
+						FirstOffset:   rp.FetchOffset,
+						Magic:         2,
+						ProducerID:    -1,
+						ProducerEpoch: -1,
+						FirstSequence: -1,
+					}
+					batch.SetRecords([]kmsg.Record{{Value: []byte("a")}})
+					sp.RecordBatches = batch.AppendToRecomputed(nil)
+					sp.HighWatermark = rp.FetchOffset + 1
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumeTopics("t"),
+		ConsumeOffsetStore(store),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	select {
+	case <-store.entered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("offset store was never fetched from")
+	}
+
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		cl.PollFetches(ctx)
+	}()
+	select {
+	case <-polled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("polling blocked while the offset store was loading")
+	}
+
+	store.release <- map[string]map[int32]EpochOffset{"t": {0: {Epoch: -1, Offset: 7}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for ctx.Err() == nil {
+		var r *Record
+		cl.PollRecords(ctx, 1).EachRecord(func(rec *Record) { r = rec })
+		if r != nil {
+			if r.Offset != 7 {
+				t.Errorf("got first consumed offset %d != exp stored offset 7", r.Offset)
+			}
+			return
+		}
+	}
+	t.Fatal("timed out polling records")
+}
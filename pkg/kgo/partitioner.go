@@ -30,6 +30,11 @@ type TopicPartitioner interface {
 	RequiresConsistency(*Record) bool
 	// Partition determines, among a set of n partitions, which index should
 	// be chosen to use for the partition for r.
+	//
+	// n is always the latest partition count from the client's metadata.
+	// The count only ever grows: a metadata update that is missing
+	// partitions (from an out of date broker) keeps the prior partitions,
+	// so a key does not flip between partitions while metadata refreshes.
 	Partition(r *Record, n int) int
 }
 
@@ -121,8 +126,11 @@ func (b *basicTopicPartitioner) Partition(r *Record, n int) int { return b.fn(r,
 // ManualPartitioner is a partitioner that simply returns the Partition field
 // that is already set on any record.
 //
-// Any record with an invalid partition will be immediately failed. This
-// partitioner is simply the partitioner that is demonstrated in the
+// Any record with a negative partition will be immediately failed. A record
+// with a partition beyond the partitions the client knows of triggers a
+// metadata refresh (the topic may have had partitions added), and the record
+// is failed only if the partition still does not exist after the refresh.
+// This partitioner is simply the partitioner that is demonstrated in the
 // BasicConsistentPartitioner documentation.
 func ManualPartitioner() Partitioner {
 	return BasicConsistentPartitioner(func(string) func(*Record, int) int {
@@ -0,0 +1,115 @@
+package kgo
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Histogram is a snapshot of a distribution of observed values.
+//
+// Bounds are the inclusive upper bounds of each bucket, in increasing order.
+// Counts has one more entry than Bounds: Counts[i] is the number of values
+// greater than Bounds[i-1] and at most Bounds[i], and the last count is the
+// number of values greater than the last bound. Counts are not cumulative.
+type Histogram struct {
+	Bounds []int64
+	Counts []int64
+
+	Count int64 // Count is the total number of observed values.
+	Sum   int64 // Sum is the sum of all observed values.
+}
+
+// ProduceMetrics is a snapshot of producer metrics, as returned from
+// ProduceMetrics.
+type ProduceMetrics struct {
+	// BufferedRecords is the number of records currently buffered, as
+	// returned from BufferedProduceRecords.
+	BufferedRecords int64
+	// BufferedBytes is the number of bytes currently buffered, as
+	// returned from BufferedProduceBytes.
+	BufferedBytes int64
+
+	// AckLatencyMillis is the distribution of milliseconds from a record
+	// being passed to Produce to its promise being called, for records
+	// that were successfully produced. This includes time spent waiting
+	// for metadata, lingering, and waiting for the broker to respond.
+	AckLatencyMillis Histogram
+
+	// BatchRecords is the distribution of the number of records in each
+	// successfully written batch.
+	BatchRecords Histogram
+	// BatchBytes is the distribution of the uncompressed bytes of each
+	// successfully written batch; see ProduceBatchMetrics.
+	BatchBytes Histogram
+}
+
+// ProduceMetrics returns a snapshot of the client's producer metrics. The
+// metrics are updated with atomic counters while producing, so the snapshot
+// is cheap, but it is not atomic across fields: a record finishing while the
+// snapshot is taken may be reflected in some fields and not others.
+//
+// This is meant to be called periodically to export metrics, and can be used
+// to tune lingering and batch sizes.
+func (cl *Client) ProduceMetrics() ProduceMetrics {
+	p := &cl.producer
+	return ProduceMetrics{
+		BufferedRecords:  atomic.LoadInt64(&p.bufferedRecords),
+		BufferedBytes:    atomic.LoadInt64(&p.bufferedBytes),
+		AckLatencyMillis: p.ackLatency.snapshot(),
+		BatchRecords:     p.batchRecords.snapshot(),
+		BatchBytes:       p.batchBytes.snapshot(),
+	}
+}
+
+// atomicHistogram is a histogram that can be observed into concurrently
+// without locking.
+type atomicHistogram struct {
+	count  int64
+	sum    int64
+	bounds []int64
+	counts []int64
+}
+
+// newExpHistogram returns a histogram with n buckets bounded at powers of two,
+// starting at first.
+func newExpHistogram(first int64, n int) *atomicHistogram {
+	h := &atomicHistogram{
+		bounds: make([]int64, n),
+		counts: make([]int64, n+1),
+	}
+	for i := range h.bounds {
+		h.bounds[i] = first << i
+	}
+	return h
+}
+
+func (h *atomicHistogram) observe(v int64) {
+	i := sort.Search(len(h.bounds), func(i int) bool { return v <= h.bounds[i] })
+	atomic.AddInt64(&h.counts[i], 1)
+	atomic.AddInt64(&h.sum, v)
+	atomic.AddInt64(&h.count, 1)
+}
+
+func (h *atomicHistogram) snapshot() Histogram {
+	s := Histogram{
+		Bounds: append([]int64(nil), h.bounds...),
+		Counts: make([]int64, len(h.counts)),
+		Count:  atomic.LoadInt64(&h.count),
+		Sum:    atomic.LoadInt64(&h.sum),
+	}
+	for i := range h.counts {
+		s.Counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+	return s
+}
+
+func (p *producer) initMetrics() {
+	p.ackLatency = newExpHistogram(1, 16)   // 1ms to ~33s
+	p.batchRecords = newExpHistogram(1, 17) // 1 to 64Ki records
+	p.batchBytes = newExpHistogram(256, 17) // 256B to 16MiB
+}
+
+func (p *producer) observeAck(pr promisedRec) {
+	p.ackLatency.observe(int64(time.Since(pr.at) / time.Millisecond))
+}
@@ -2,7 +2,9 @@ package kgo
 
 import (
 	"bytes"
+	"context"
 	"hash/crc32"
+	"reflect"
 	"testing"
 
 	"github.com/twmb/franz-go/pkg/kbin"
@@ -55,6 +57,202 @@ func TestPromisedNumberedRecordAppendTo(t *testing.T) {
 	}
 }
 
+func TestTombstoneRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		value     []byte
+		tombstone bool
+	}{
+		{nil, true},
+		{[]byte{}, false},
+		{[]byte("v"), false},
+	} {
+		pnrRec := promisedNumberedRecord{
+			promisedRec: promisedRec{
+				Record: &Record{
+					Key:     []byte("key"),
+					Value:   test.value,
+					Headers: []RecordHeader{{"header key", []byte("header value")}},
+				},
+			},
+		}
+
+		// The encoding must match kmsg's, which encodes nil as null.
+		kmsgRec := kmsg.Record{
+			Key:     []byte("key"),
+			Value:   test.value,
+			Headers: []kmsg.Header{{"header key", []byte("header value")}},
+		}
+		raw := pnrRec.appendTo(nil, 0)
+		if exp := kmsgRec.AppendTo(nil); !bytes.Equal(raw, exp) {
+			t.Errorf("value %v: got != exp", test.value)
+			continue
+		}
+
+		var decoded kmsg.Record
+		if err := decoded.ReadFrom(raw); err != nil {
+			t.Errorf("value %v: unable to decode: %v", test.value, err)
+			continue
+		}
+		r := recordToRecord("t", 0, new(kmsg.RecordBatch), &decoded)
+		if got := r.IsTombstone(); got != test.tombstone {
+			t.Errorf("value %v: got tombstone %v != exp %v", test.value, got, test.tombstone)
+		}
+		if (r.Value == nil) != (test.value == nil) {
+			t.Errorf("value %v: nil-ness of decoded value %v was not preserved", test.value, r.Value)
+		}
+		if len(r.Headers) != 1 || r.Headers[0].Key != "header key" || string(r.Headers[0].Value) != "header value" {
+			t.Errorf("value %v: headers not preserved, got %v", test.value, r.Headers)
+		}
+	}
+}
+
+// Kafka has no null headers array: nil and empty headers must encode the same,
+// consumed records without headers have nil headers, and header values keep
+// null vs. empty.
+func TestRecordHeadersRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		name    string
+		headers []RecordHeader
+		exp     []RecordHeader
+	}{
+		{"nil", nil, nil},
+		{"empty", []RecordHeader{}, nil},
+		{
+			"values",
+			[]RecordHeader{{"null", nil}, {"empty", []byte{}}, {"k", []byte("v")}},
+			[]RecordHeader{{"null", nil}, {"empty", []byte{}}, {"k", []byte("v")}},
+		},
+	} {
+		pnrRec := promisedNumberedRecord{
+			promisedRec: promisedRec{
+				Record: &Record{Value: []byte("v"), Headers: test.headers},
+			},
+		}
+		kmsgRec := kmsg.Record{Value: []byte("v")}
+		for _, h := range test.headers {
+			kmsgRec.Headers = append(kmsgRec.Headers, kmsg.Header{Key: h.Key, Value: h.Value})
+		}
+		raw := pnrRec.appendTo(nil, 0)
+		if exp := kmsgRec.AppendTo(nil); !bytes.Equal(raw, exp) {
+			t.Errorf("%s: got != exp", test.name)
+			continue
+		}
+
+		var decoded kmsg.Record
+		if err := decoded.ReadFrom(raw); err != nil {
+			t.Errorf("%s: unable to decode: %v", test.name, err)
+			continue
+		}
+		r := recordToRecord("t", 0, new(kmsg.RecordBatch), &decoded)
+		if !reflect.DeepEqual(r.Headers, test.exp) {
+			t.Errorf("%s: got headers %#v != exp %#v", test.name, r.Headers, test.exp)
+		}
+		for i, h := range r.Headers {
+			if (h.Value == nil) != (test.exp[i].Value == nil) {
+				t.Errorf("%s: nil-ness of header %q value was not preserved", test.name, h.Key)
+			}
+		}
+	}
+
+	// Some writers encode a record without headers as a null (-1) array.
+	// We treat that the same as an empty array.
+	raw := (&kmsg.Record{Value: []byte("v")}).AppendTo(nil)
+	raw[len(raw)-1] = 1 // zig-zag varint -1
+	var decoded kmsg.Record
+	if err := decoded.ReadFrom(raw); err != nil {
+		t.Fatalf("unable to decode null headers: %v", err)
+	}
+	if r := recordToRecord("t", 0, new(kmsg.RecordBatch), &decoded); r.Headers != nil {
+		t.Errorf("got headers %#v from a null headers array, exp nil", r.Headers)
+	}
+}
+
+func TestRecordTimestampType(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		attrs int16
+		expTs int64
+		expTy int8
+	}{
+		{0, 1002, 0},          // CreateTime: first timestamp + delta
+		{0b1000, 5000, 1},     // LogAppendTime: max timestamp
+		{0b1000 | 3, 5000, 1}, // LogAppendTime with compression bits
+	} {
+		batch := &kmsg.RecordBatch{
+			Attributes:     test.attrs,
+			FirstTimestamp: 1000,
+			MaxTimestamp:   5000,
+		}
+		r := recordToRecord("t", 0, batch, &kmsg.Record{TimestampDelta: 2})
+		if got := r.Timestamp.UnixNano() / 1e6; got != test.expTs {
+			t.Errorf("attrs %b: got timestamp %d != exp %d", test.attrs, got, test.expTs)
+		}
+		if got := r.Attrs.TimestampType(); got != test.expTy {
+			t.Errorf("attrs %b: got timestamp type %d != exp %d", test.attrs, got, test.expTy)
+		}
+	}
+	if got := messageAttrsToRecordAttrs(0, true).TimestampType(); got != -1 {
+		t.Errorf("v0 message: got timestamp type %d != exp -1", got)
+	}
+}
+
+type bufferedHook func(*Record)
+
+func (fn bufferedHook) OnProduceRecordBuffered(r *Record) { fn(r) }
+
+func TestTraceparent(t *testing.T) {
+	t.Parallel()
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	shared := []RecordHeader{
+		{"a", []byte("1")},
+		{TraceparentHeader, []byte("old")},
+		{"b", []byte("2")},
+	}
+	r := &Record{Headers: shared}
+	r.SetTraceparent("")
+	if got, _ := r.Traceparent(); got != "old" {
+		t.Errorf("empty traceparent modified the record, got %q", got)
+	}
+	r.SetTraceparent(tp)
+	if got, ok := r.Traceparent(); !ok || got != tp {
+		t.Errorf("got traceparent %q, exp %q", got, tp)
+	}
+	if len(r.Headers) != 3 || r.Headers[0].Key != "a" || r.Headers[1].Key != "b" {
+		t.Errorf("existing headers not preserved, got %v", r.Headers)
+	}
+	if shared[1].Key != TraceparentHeader || string(shared[1].Value) != "old" || shared[2].Key != "b" {
+		t.Errorf("setting the traceparent modified the original headers, got %v", shared)
+	}
+
+	if ctx := ContextWithTraceparent(context.Background(), ""); ctx != context.Background() {
+		t.Error("empty traceparent modified the context")
+	}
+
+	// Producing with a traceparent context sets the header before hooks.
+	got := make(chan *Record, 2)
+	cl, err := NewClient(
+		SeedBrokers("127.0.0.1:1"),
+		DefaultProduceTopic("t"),
+		WithHooks(bufferedHook(func(r *Record) { got <- r })),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	cl.Produce(ContextWithTraceparent(context.Background(), tp), &Record{Value: []byte("v")}, nil)
+	if v, ok := (<-got).Traceparent(); !ok || v != tp {
+		t.Errorf("produced record got traceparent %q, exp %q", v, tp)
+	}
+	cl.Produce(context.Background(), &Record{Value: []byte("v")}, nil)
+	if r := <-got; len(r.Headers) != 0 {
+		t.Errorf("produced record without trace context got headers %v", r.Headers)
+	}
+}
+
 func TestRecBatchAppendTo(t *testing.T) {
 	t.Parallel()
 	// golden, uncompressed
@@ -169,6 +367,12 @@ func TestRecBatchAppendTo(t *testing.T) {
 	fixFields()
 	check()
 
+	// ***Below compression threshold check***
+
+	compressor, _ = newCompressor(CompressionCodec{codec: 2}) // snappy
+	compressor.minBytes = len(kbatch.Records) + 1
+	check() // attrs, length, and crc must be exactly as if uncompressed
+
 	// ***Compressed record batch check***
 
 	compressor, _ = newCompressor(CompressionCodec{codec: 2}) // snappy
@@ -449,3 +653,50 @@ func BenchmarkAppendBatch(b *testing.B) {
 		})
 	}
 }
+
+func TestTombstoneRecordEncoding(t *testing.T) {
+	t.Parallel()
+	encode := func(r *Record) kmsg.Record {
+		pnr := promisedNumberedRecord{promisedRec: promisedRec{Record: r}}
+		var decoded kmsg.Record
+		if err := decoded.ReadFrom(pnr.appendTo(nil, 0)); err != nil {
+			t.Fatalf("unable to decode: %v", err)
+		}
+		return decoded
+	}
+	encodedValueLen := func(r *Record) int32 {
+		decoded := encode(r)
+		if decoded.Value == nil {
+			return -1
+		}
+		return int32(len(decoded.Value))
+	}
+
+	tomb := TombstoneRecord([]byte("k"))
+	if !tomb.IsTombstone() || string(tomb.Key) != "k" {
+		t.Errorf("got key %q tombstone %v, exp key k as a tombstone", tomb.Key, tomb.IsTombstone())
+	}
+	if l := encodedValueLen(tomb); l != -1 {
+		t.Errorf("tombstone value encoded with length %d, exp null (-1)", l)
+	}
+
+	for _, r := range []*Record{StringRecord(""), KeyStringRecord("k", ""), SliceRecord([]byte{})} {
+		if r.IsTombstone() {
+			t.Errorf("empty value record %+v is unexpectedly a tombstone", r)
+		}
+		if l := encodedValueLen(r); l != 0 {
+			t.Errorf("empty value encoded with length %d, exp 0", l)
+		}
+	}
+
+	r := KeyStringRecord("", "v")
+	if r.Key == nil {
+		t.Error("empty key string record has a null key")
+	}
+	if decoded := encode(r); decoded.Key == nil || len(decoded.Key) != 0 {
+		t.Errorf("empty key encoded as %v, exp empty and non-null", decoded.Key)
+	}
+	if decoded := encode(StringRecord("v")); decoded.Key != nil {
+		t.Errorf("keyless record encoded key %v, exp null", decoded.Key)
+	}
+}
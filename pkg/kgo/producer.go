@@ -31,10 +31,25 @@ type producer struct {
 	unknownTopics   map[string]*unknownTopicProduces
 
 	bufferedRecords int64
+	bufferedBytes   int64
+
+	// ackLatency, batchRecords, and batchBytes back ProduceMetrics.
+	ackLatency   *atomicHistogram
+	batchRecords *atomicHistogram
+	batchBytes   *atomicHistogram
+
+	// rateBytes and rateRecords enforce ProduceRateLimit; nil if unlimited.
+	rateBytes   *tokenBucket
+	rateRecords *tokenBucket
 
 	id           atomic.Value
 	producingTxn uint32 // 1 if in txn
 
+	// offsetsAddedToTxn is 1 if AddOffsetsToTxn succeeded in the current
+	// transaction, which begins the transaction within Kafka even if no
+	// records are produced.
+	offsetsAddedToTxn uint32
+
 	// We must have a producer field for flushing; we cannot just have a
 	// field on recBufs that is toggled on flush. If we did, then a new
 	// recBuf could be created and records sent to while we are flushing.
@@ -64,15 +79,33 @@ func (cl *Client) BufferedProduceRecords() int64 {
 	return atomic.LoadInt64(&cl.producer.bufferedRecords)
 }
 
+// BufferedProduceBytes returns the number of bytes currently buffered for
+// producing within the client. This is the sum of the key, value, and header
+// keys and values of each buffered record, and does not include any record
+// batch encoding overhead.
+//
+// This can be used alongside BufferedProduceRecords as a gauge for how much
+// memory is held by records waiting to be produced.
+func (cl *Client) BufferedProduceBytes() int64 {
+	return atomic.LoadInt64(&cl.producer.bufferedBytes)
+}
+
 type unknownTopicProduces struct {
 	buffered []promisedRec
 	wait     chan error
 }
 
+type repartitionProduces struct {
+	buffered []promisedRec
+}
+
 func (p *producer) init(cl *Client) {
 	p.topics = newTopicsPartitions()
 	p.unknownTopics = make(map[string]*unknownTopicProduces)
 	p.waitBuffer = make(chan struct{}, 32)
+	p.rateBytes = newTokenBucket(cl.cfg.rateLimitBytes)
+	p.rateRecords = newTokenBucket(cl.cfg.rateLimitRecords)
+	p.initMetrics()
 	p.idVersion = -1
 	p.id.Store(&producerID{
 		id:    -1,
@@ -144,23 +177,25 @@ func (rs ProduceResults) First() (*Record, error) {
 // for an in depth description of how producing works.
 //
 // This function produces all records in one range loop and waits for them all
-// to be produced before returning.
+// to be produced before returning. The results are in the same order as the
+// input records, and each result has its own error: if some records fail,
+// the records that were successfully produced still have their partition and
+// offset set. If the context is canceled, records that were not yet written
+// fail with the context error, while records already written still wait for
+// their response.
 func (cl *Client) ProduceSync(ctx context.Context, rs ...*Record) ProduceResults {
 	var (
 		wg      sync.WaitGroup
-		mu      sync.Mutex
-		results = make(ProduceResults, 0, len(rs))
-		promise = func(r *Record, err error) {
-			mu.Lock()
-			results = append(results, ProduceResult{r, err})
-			mu.Unlock()
-			wg.Done()
-		}
+		results = make(ProduceResults, len(rs))
 	)
 
 	wg.Add(len(rs))
-	for _, r := range rs {
-		cl.Produce(ctx, r, promise)
+	for i, r := range rs {
+		i := i
+		cl.Produce(ctx, r, func(r *Record, err error) {
+			results[i] = ProduceResult{r, err}
+			wg.Done()
+		})
 	}
 	wg.Wait()
 
@@ -272,9 +307,15 @@ func (f *FirstErrPromise) Err() error {
 // buffered. This may be changed in the future if necessary, however, the only
 // reason for a topic to not load promptly is if it does not exist.
 //
+// If the context carries a traceparent from ContextWithTraceparent, the record's
+// traceparent header is set before the record is buffered.
+//
 // If manual flushing is configured and there are already MaxBufferedRecords
 // buffered, the promise is immediately called with ErrMaxBuffered.
 //
+// If ProduceRateLimit is configured, Produce blocks while the client is over
+// its limit; see ProduceRateLimit for how the context is used while blocked.
+//
 // If the client is transactional and a transaction has not been begun, the
 // promise is immediately called with an error corresponding to not being in
 // a transaction.
@@ -283,6 +324,7 @@ func (cl *Client) Produce(
 	r *Record,
 	promise func(*Record, error),
 ) {
+	at := time.Now()
 	if promise == nil {
 		promise = noPromise
 	}
@@ -296,6 +338,10 @@ func (cl *Client) Produce(
 		}
 	}
 
+	if traceparent, ok := TraceparentFromContext(ctx); ok {
+		r.SetTraceparent(traceparent)
+	}
+
 	p := &cl.producer
 
 	if cl.cfg.txnID != nil && atomic.LoadUint32(&p.producingTxn) != 1 {
@@ -303,14 +349,29 @@ func (cl *Client) Produce(
 		return
 	}
 
+	// We wait for the rate limit before buffering, so that waiting never
+	// holds a buffered slot that finishing records would need. The
+	// transaction could have ended while we waited, so we check again.
+	if !cl.waitProduceRate(ctx, r, promise) {
+		return
+	}
+	if cl.cfg.txnID != nil && atomic.LoadUint32(&p.producingTxn) != 1 {
+		cl.refundProduceRate(r)
+		go promise(r, errNotInTransaction)
+		return
+	}
+
 	// Our record is now "buffered", and past this point will fall into
-	// finishRecordPromise, where we track it is finished.
+	// finishRecordPromise, where we track it is finished. The record has
+	// no sequence number until it is successfully produced.
+	r.BatchFirstSequence = -1
 	if p.hooks != nil {
 		for _, h := range p.hooks.buffered {
 			h.OnProduceRecordBuffered(r)
 		}
 	}
 
+	atomic.AddInt64(&p.bufferedBytes, r.userSize())
 	if atomic.AddInt64(&p.bufferedRecords, 1) > cl.cfg.maxBufferedRecords {
 		// If the client ctx cancels or the produce ctx cancels, we
 		// need to un-count our buffering of this record. We also need
@@ -327,7 +388,7 @@ func (cl *Client) Produce(
 		// promise in a goroutine would lead to a deadlock.
 		drainBuffered := func(err error) {
 			go func() { <-p.waitBuffer }()
-			go cl.finishRecordPromise(promisedRec{ctx, promise, r}, err)
+			go cl.finishRecordPromise(promisedRec{ctx, promise, r, at}, err)
 		}
 		if cl.cfg.manualFlushing {
 			drainBuffered(ErrMaxBuffered)
@@ -344,18 +405,33 @@ func (cl *Client) Produce(
 		}
 	}
 
-	cl.partitionRecord(promisedRec{ctx, promise, r})
+	cl.partitionRecord(promisedRec{ctx, promise, r, at})
 }
 
 func (cl *Client) finishRecordPromise(pr promisedRec, err error) {
 	p := &cl.producer
 
+	// We release our bytes before the unbuffered hooks, which could
+	// modify the record, and before dead lettering, which buffers its
+	// own bytes for the new record.
+	atomic.AddInt64(&p.bufferedBytes, -pr.userSize())
+
 	if p.hooks != nil {
 		for _, h := range p.hooks.unbuffered {
 			h.OnProduceRecordUnbuffered(pr.Record, err)
 		}
 	}
 
+	// If we dead letter the record, the dead letter record takes over our
+	// buffered slot and calls our promise when it finishes.
+	if err != nil && cl.maybeDeadLetter(pr, err) {
+		return
+	}
+
+	if err == nil {
+		p.observeAck(pr)
+	}
+
 	// We call the promise before finishing the record; this allows users
 	// of Flush to know that all buffered records are completely done
 	// before Flush returns.
@@ -371,6 +447,91 @@ func (cl *Client) finishRecordPromise(pr promisedRec, err error) {
 	}
 }
 
+// Headers added to records produced to a DeadLetterTopic.
+const (
+	// DeadLetterTopicHeader is the header key for the topic a dead
+	// lettered record was originally produced to.
+	DeadLetterTopicHeader = "dead-letter-topic"
+	// DeadLetterErrorHeader is the header key for why a dead lettered
+	// record failed.
+	DeadLetterErrorHeader = "dead-letter-error"
+)
+
+// maybeDeadLetter produces a copy of a failed record to the dead letter
+// topic, if one is configured and the failure is a failure of the record
+// itself, returning whether the record was dead lettered.
+//
+// The dead letter record inherits the original record's buffered slot, so we
+// do not wait for room to buffer: waiting could deadlock finishing records.
+//
+// Records can fail while partitioning under producer or topic locks (for
+// example, if a record is partitioned after its topic loads), and the dead
+// letter topic itself may be unknown, so we partition the dead letter record
+// in a goroutine once those locks are released.
+func (cl *Client) maybeDeadLetter(pr promisedRec, err error) bool {
+	dlt := cl.cfg.deadLetterTopic
+	if dlt == "" || pr.Topic == dlt {
+		return false
+	}
+	switch {
+	case errors.Is(err, ErrClientClosed),
+		errors.Is(err, ErrAborting),
+		errors.Is(err, ErrMaxBuffered),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+
+	headers := make([]RecordHeader, 0, len(pr.Headers)+2)
+	headers = append(headers, pr.Headers...)
+	headers = append(headers,
+		RecordHeader{Key: DeadLetterTopicHeader, Value: []byte(pr.Topic)},
+		RecordHeader{Key: DeadLetterErrorHeader, Value: []byte(err.Error())},
+	)
+	dl := &Record{
+		Topic:   dlt,
+		Key:     pr.Key,
+		Value:   pr.Value,
+		Headers: headers,
+	}
+	orig := pr
+	promise := func(dl *Record, dlErr error) {
+		if dlErr != nil {
+			cl.cfg.logger.Log(LogLevelWarn, "unable to produce failed record to the dead letter topic",
+				"topic", orig.Topic,
+				"dead_letter_topic", dlt,
+				"err", err,
+				"dead_letter_err", dlErr,
+			)
+			orig.promise(orig.Record, err)
+			return
+		}
+		orig.promise(orig.Record, &ErrDeadLettered{DeadLetter: dl, Err: err})
+	}
+
+	go func() {
+		p := &cl.producer
+		if p.hooks != nil {
+			for _, h := range p.hooks.buffered {
+				h.OnProduceRecordBuffered(dl)
+			}
+		}
+		atomic.AddInt64(&p.bufferedBytes, dl.userSize())
+
+		// If the client closed, the dead letter record could be
+		// partitioned after buffered records were failed, leaving
+		// it stranded.
+		dlpr := promisedRec{pr.ctx, promise, dl, pr.at}
+		select {
+		case <-cl.ctx.Done():
+			cl.finishRecordPromise(dlpr, ErrClientClosed)
+		default:
+			cl.partitionRecord(dlpr)
+		}
+	}()
+	return true
+}
+
 // partitionRecord loads the partitions for a topic and produce to them. If
 // the topic does not currently exist, the record is buffered in unknownTopics
 // for a metadata update to deal with.
@@ -385,6 +546,14 @@ func (cl *Client) partitionRecord(pr promisedRec) {
 // doPartitionRecord is separate so that metadata updates that load unknown
 // partitions can call this directly.
 func (cl *Client) doPartitionRecord(parts *topicPartitions, partsData *topicPartitionsData, pr promisedRec) {
+	cl.partitionRecordWithData(parts, partsData, pr, true)
+}
+
+// partitionRecordWithData partitions a record using the given partition data.
+// If a consistent partitioner chooses a partition beyond what we know of
+// (i.e., partitions were added and our metadata is stale), and canRefresh is
+// true, we refresh metadata and try once more before failing the record.
+func (cl *Client) partitionRecordWithData(parts *topicPartitions, partsData *topicPartitionsData, pr promisedRec, canRefresh bool) {
 	if partsData.loadErr != nil && !kerr.IsRetriable(partsData.loadErr) {
 		cl.finishRecordPromise(pr, partsData.loadErr)
 		return
@@ -392,6 +561,15 @@ func (cl *Client) doPartitionRecord(parts *topicPartitions, partsData *topicPart
 
 	parts.partsMu.Lock()
 	defer parts.partsMu.Unlock()
+	if canRefresh && parts.repartition != nil {
+		parts.repartition.buffered = append(parts.repartition.buffered, pr)
+		return
+	}
+	cl.lockedPartitionRecord(parts, partsData, pr, canRefresh)
+}
+
+// lockedPartitionRecord partitions a record; this is called with partsMu held.
+func (cl *Client) lockedPartitionRecord(parts *topicPartitions, partsData *topicPartitionsData, pr promisedRec, canRefresh bool) {
 	if parts.partitioner == nil {
 		parts.partitioner = cl.cfg.partitioner.ForTopic(pr.Topic)
 	}
@@ -416,12 +594,20 @@ func (cl *Client) doPartitionRecord(parts *topicPartitions, partsData *topicPart
 	} else {
 		pick = parts.partitioner.Partition(pr.Record, len(mapping))
 	}
+	if pick >= len(mapping) && canRefresh && tlp == nil && parts.partitioner.RequiresConsistency(pr.Record) {
+		parts.repartition = &repartitionProduces{buffered: []promisedRec{pr}}
+		go cl.repartitionAfterMetadata(parts, parts.repartition, pr.Topic, pick, len(mapping))
+		return
+	}
 	if pick < 0 || pick >= len(mapping) {
 		cl.finishRecordPromise(pr, fmt.Errorf("invalid record partitioning choice of %d from %d available", pick, len(mapping)))
 		return
 	}
 
 	partition := mapping[pick]
+	if cl.failUnavailable(partition, pr) {
+		return
+	}
 
 	onNewBatch, _ := parts.partitioner.(TopicPartitionerOnNewBatch)
 	abortOnNewBatch := onNewBatch != nil
@@ -441,10 +627,96 @@ func (cl *Client) doPartitionRecord(parts *topicPartitions, partsData *topicPart
 			return
 		}
 		partition = mapping[pick]
+		if cl.failUnavailable(partition, pr) {
+			return
+		}
 		partition.records.bufferRecord(pr, false) // KIP-480
 	}
 }
 
+// failUnavailable fails the record and returns true if the record's partition
+// has no leader and UnavailablePartitions says to not wait any longer.
+func (cl *Client) failUnavailable(partition *topicPartition, pr promisedRec) bool {
+	if partition.loadErr == nil {
+		return false
+	}
+	recBuf := partition.records
+	recBuf.mu.Lock()
+	expired := recBuf.lockedUnavailableExpired()
+	recBuf.mu.Unlock()
+	if expired {
+		cl.finishRecordPromise(pr, partition.loadErr)
+	}
+	return expired
+}
+
+// repartitionAfterMetadata waits for a metadata update to complete after a
+// record was partitioned beyond the number of partitions we know of, and then
+// partitions every record queued for the topic in the meantime, in order,
+// with the latest partitions.
+//
+// Partitions are never removed from our metadata cache, so the latest data
+// has at least as many partitions as the data the record was first
+// partitioned with: records that hash consistently stay on their partition
+// unless an update actually learns of new partitions.
+func (cl *Client) repartitionAfterMetadata(parts *topicPartitions, repartition *repartitionProduces, topic string, pick, known int) {
+	cl.cfg.logger.Log(LogLevelInfo, "record partitioned beyond the known partitions of its topic, refreshing metadata before retrying",
+		"topic", topic,
+		"partition", pick,
+		"known_partitions", known,
+	)
+
+	wait := cl.cfg.metadataMinAge + cl.cfg.requestTimeoutOverhead
+	cl.waitmetaAfter(cl.ctx, wait, time.Now(), "record partitioned beyond the known partitions of its topic")
+
+	// If our queue was failed while we waited (aborting or closing), the
+	// records are already finished and there is nothing to drain.
+	var closed []promisedRec
+	func() {
+		parts.partsMu.Lock()
+		defer parts.partsMu.Unlock()
+		if parts.repartition != repartition {
+			return
+		}
+		parts.repartition = nil
+
+		if cl.ctx.Err() != nil {
+			closed = repartition.buffered
+			return
+		}
+		partsData := parts.load()
+		for _, pr := range repartition.buffered {
+			if err := pr.ctx.Err(); err != nil {
+				cl.finishRecordPromise(pr, err)
+			} else if partsData.loadErr != nil && !kerr.IsRetriable(partsData.loadErr) {
+				cl.finishRecordPromise(pr, partsData.loadErr)
+			} else {
+				cl.lockedPartitionRecord(parts, partsData, pr, false)
+			}
+		}
+	}()
+	for _, pr := range closed {
+		cl.finishRecordPromise(pr, ErrClientClosed)
+	}
+}
+
+// failRepartitioning fails all records queued waiting for a metadata refresh
+// to repartition them.
+func (cl *Client) failRepartitioning(err error) {
+	var fail []promisedRec
+	for _, parts := range cl.producer.topics.load() {
+		parts.partsMu.Lock()
+		if parts.repartition != nil {
+			fail = append(fail, parts.repartition.buffered...)
+			parts.repartition = nil
+		}
+		parts.partsMu.Unlock()
+	}
+	for _, pr := range fail {
+		cl.finishRecordPromise(pr, err)
+	}
+}
+
 type producerID struct {
 	id    int64
 	epoch int16
@@ -583,7 +855,7 @@ func (cl *Client) doInitProducerID(lastID int64, lastEpoch int16) (*producerID,
 	resp, err := req.RequestWith(cl.ctx, cl)
 	if err != nil {
 		if err == errUnknownRequestKey || err == errBrokerTooOld {
-			cl.cfg.logger.Log(LogLevelInfo, "unable to initialize a producer id because the broker is too old or the client is pinned to an old version, continuing without a producer id")
+			cl.cfg.logger.Log(LogLevelWarn, "unable to initialize a producer id because the broker is too old or the client is pinned to an old version, continuing without a producer id (non-idempotently)")
 			return &producerID{-1, -1, nil}, true
 		}
 		if err == errChosenBrokerDead {
@@ -603,6 +875,14 @@ func (cl *Client) doInitProducerID(lastID int64, lastEpoch int16) (*producerID,
 			cl.cfg.logger.Log(LogLevelInfo, "producer id initialization resulted in retriable error, discarding initialization attempt", "err", err)
 			return &producerID{lastID, lastEpoch, err}, false
 		}
+		// An authorization failure is not something we silently
+		// fall back from: producing non-idempotently would lose the
+		// guarantees the user asked for. We fail every record with
+		// the error and point at how to opt out.
+		if err == kerr.ClusterAuthorizationFailed && cl.cfg.txnID == nil {
+			cl.cfg.logger.Log(LogLevelError, "producer id initialization failed due to missing IDEMPOTENT_WRITE permission on CLUSTER; failing records, use DisableIdempotentWrite to produce without idempotency", "err", err)
+			return &producerID{lastID, lastEpoch, err}, true
+		}
 		cl.cfg.logger.Log(LogLevelInfo, "producer id initialization errored", "err", err)
 		return &producerID{lastID, lastEpoch, err}, true
 	}
@@ -700,6 +980,7 @@ func (cl *Client) waitUnknownTopic(
 	}
 	var tries int
 	var err error
+	start := time.Now()
 	for err == nil {
 		select {
 		case <-cl.ctx.Done():
@@ -716,8 +997,15 @@ func (cl *Client) waitUnknownTopic(
 			if int64(tries) >= cl.cfg.recordRetries {
 				err = fmt.Errorf("no partitions available after attempting to refresh metadata %d times, last err: %w", tries, retriableErr)
 			}
-			if tries > 5 && errors.Is(retriableErr, kerr.UnknownTopicOrPartition) {
-				err = retriableErr
+			if errors.Is(retriableErr, kerr.UnknownTopicOrPartition) {
+				// A topic that was just created may not be known
+				// to every broker yet; we only consider it
+				// nonexistent after the configured window, or
+				// after a few tries by default.
+				if window := cl.cfg.unknownTopicWindow; window > 0 && time.Since(start) >= window ||
+					window <= 0 && tries > 5 {
+					err = retriableErr
+				}
 			}
 		}
 	}
@@ -788,6 +1076,13 @@ func (cl *Client) Flush(ctx context.Context) error {
 	// linger because the producer's flushing atomic int32 is nonzero. We
 	// must wake anything that could be lingering up, after which all sinks
 	// will loop draining.
+	// Records waiting on a metadata refresh to be repartitioned are
+	// drained once the refresh completes; if we are aborting, we fail
+	// them now rather than waiting.
+	if atomic.LoadInt32(&p.aborting) > 0 {
+		cl.failRepartitioning(ErrAborting)
+	}
+
 	if cl.cfg.linger > 0 || cl.cfg.manualFlushing {
 		for _, parts := range p.topics.load() {
 			for _, part := range parts.load().partitions {
@@ -868,6 +1163,7 @@ func (cl *Client) failBufferedRecords(err error) {
 			recBuf.mu.Unlock()
 		}
 	}
+	cl.failRepartitioning(err)
 
 	p.topicsMu.Lock()
 	defer p.topicsMu.Unlock()
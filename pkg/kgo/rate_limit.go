@@ -0,0 +1,116 @@
+package kgo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token bucket that refills at rate tokens per second, up to
+// one second of tokens. A take can overdraw the bucket, in which case the
+// taker waits until the bucket refills back to zero. This allows takes larger
+// than the bucket while still enforcing the rate over time.
+//
+// A nil bucket is unlimited.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// take takes n tokens at now, returning how long the caller must wait before
+// the take is within the rate.
+func (b *tokenBucket) take(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+		b.last = now
+	}
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refund returns n tokens that were taken but not used.
+func (b *tokenBucket) refund(n float64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += n
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+}
+
+// waitProduceRate waits until r is within the produce rate limit, returning
+// false if the context or client quit first (or the context would expire
+// before r is within the limit), in which case the promise is called.
+func (cl *Client) waitProduceRate(ctx context.Context, r *Record, promise func(*Record, error)) bool {
+	p := &cl.producer
+	if p.rateBytes == nil && p.rateRecords == nil {
+		return true
+	}
+
+	now := time.Now()
+	size := float64(r.userSize())
+	wait := p.rateBytes.take(size, now)
+	if recordsWait := p.rateRecords.take(1, now); recordsWait > wait {
+		wait = recordsWait
+	}
+	if wait <= 0 {
+		return true
+	}
+
+	var err error
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(wait)) {
+		err = context.DeadlineExceeded
+	} else {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			return true
+		case <-cl.ctx.Done():
+			err = ErrClientClosed
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		timer.Stop()
+	}
+
+	cl.refundProduceRate(r)
+	go promise(r, err) // see Produce for why we 'go' this
+	return false
+}
+
+// refundProduceRate returns a record's size and count to the rate limit if
+// the record is not produced after waiting for it.
+func (cl *Client) refundProduceRate(r *Record) {
+	p := &cl.producer
+	p.rateBytes.refund(float64(r.userSize()))
+	p.rateRecords.refund(1)
+}
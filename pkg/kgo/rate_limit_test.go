@@ -0,0 +1,117 @@
+package kgo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var unlimited *tokenBucket
+	if wait := unlimited.take(1e9, time.Now()); wait != 0 {
+		t.Errorf("nil bucket: got wait %v, exp 0", wait)
+	}
+
+	b := newTokenBucket(10)
+	now := b.last
+	for _, test := range []struct {
+		after time.Duration // since the start
+		take  float64
+		exp   time.Duration
+	}{
+		{0, 10, 0},                                     // the bucket begins full
+		{0, 5, 500 * time.Millisecond},                 // overdraw to -5
+		{time.Second, 1, 0},                            // refill to 5, take to 4
+		{time.Second, 30, 2600 * time.Millisecond},     // overdraw to -26
+		{100 * time.Second, 10, 0},                     // refill is capped at 10
+		{100 * time.Second, 1, 100 * time.Millisecond}, // so we are at -1
+	} {
+		if wait := b.take(test.take, now.Add(test.after)); wait != test.exp {
+			t.Errorf("take %v after %v: got wait %v, exp %v", test.take, test.after, wait, test.exp)
+		}
+	}
+
+	b.refund(1)
+	if wait := b.take(0, now.Add(100*time.Second)); wait != 0 {
+		t.Errorf("after refund: got wait %v, exp 0", wait)
+	}
+}
+
+func TestProduceRateLimit(t *testing.T) {
+	cl, err := NewClient(
+		SeedBrokers("127.0.0.1:1"), // the first record stays buffered
+		ProduceRateLimit(0, 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 3)
+	promise := func(_ *Record, err error) { errs <- err }
+
+	// The first record is within the limit; the second would have to
+	// wait for a second, past its context deadline.
+	cl.Produce(context.Background(), &Record{Topic: "t"}, promise)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	cl.Produce(ctx, &Record{Topic: "t"}, promise)
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("produce blocked for %v even though its context would expire first", elapsed)
+	}
+	if err := <-errs; err != context.DeadlineExceeded {
+		t.Errorf("got err %v, exp %v", err, context.DeadlineExceeded)
+	}
+
+	// Without a deadline, we block until the context is canceled.
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	cl.Produce(ctx, &Record{Topic: "t"}, promise)
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("got err %v, exp %v", err, context.Canceled)
+	}
+
+	// Records that fail waiting on the limit are never buffered.
+	if buffered := cl.BufferedProduceRecords(); buffered != 1 {
+		t.Errorf("got %d buffered records, exp 1", buffered)
+	}
+
+	cl.Close()
+	if err := <-errs; err != ErrClientClosed {
+		t.Errorf("got err %v for the buffered record, exp %v", err, ErrClientClosed)
+	}
+}
+
+// A transaction can end while a record waits for the rate limit; the record
+// must then fail rather than be buffered outside of the transaction.
+func TestProduceRateLimitTxnEndsWhileWaiting(t *testing.T) {
+	cl, err := NewClient(
+		SeedBrokers("127.0.0.1:1"),
+		TransactionalID("txn"),
+		ProduceRateLimit(0, 10),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	p := &cl.producer
+	atomic.StoreUint32(&p.producingTxn, 1)
+	p.rateRecords.take(10, time.Now()) // the next record waits 100ms
+	time.AfterFunc(20*time.Millisecond, func() { atomic.StoreUint32(&p.producingTxn, 0) })
+
+	errs := make(chan error, 1)
+	cl.Produce(context.Background(), &Record{Topic: "t"}, func(_ *Record, err error) { errs <- err })
+	select {
+	case err := <-errs:
+		if err != errNotInTransaction {
+			t.Errorf("got err %v, exp %v", err, errNotInTransaction)
+		}
+	case <-time.After(time.Second):
+		t.Error("record was buffered after its transaction ended")
+	}
+	if buffered := cl.BufferedProduceRecords(); buffered != 0 {
+		t.Errorf("got %d buffered records, exp 0", buffered)
+	}
+}
@@ -1,6 +1,8 @@
 package kgo
 
 import (
+	"context"
+	"math"
 	"reflect"
 	"time"
 	"unsafe"
@@ -36,14 +38,16 @@ type RecordAttrs struct {
 // The default, 0, means that the timestamp was determined in a client
 // when the record was produced.
 //
-// An alternative is 1, which is when the Timestamp is set in Kafka.
+// An alternative is 1, which is when the Timestamp is set in Kafka
+// (LogAppendTime). This is the case for records consumed from, or produced
+// to, topics configured with log.message.timestamp.type=LogAppendTime.
 //
 // Records pre 0.10.0 did not have timestamps and have value -1.
 func (a RecordAttrs) TimestampType() int8 {
 	if a.attrs&0b1000_0000 != 0 {
 		return -1
 	}
-	return int8(a.attrs & 0b0000_1000)
+	return int8(a.attrs&0b0000_1000) >> 3
 }
 
 // CompressionType signifies with which algorithm this record was compressed.
@@ -72,6 +76,11 @@ type Record struct {
 	// with the same key to go to the same partition.
 	Key []byte
 	// Value is blob of data to write to Kafka.
+	//
+	// A nil value is distinct from an empty value: a nil value is encoded
+	// as a null, which on compacted topics is a tombstone (delete marker)
+	// for the record's key. When consuming, null values are decoded as nil
+	// and empty values as non-nil empty slices.
 	Value []byte
 
 	// Headers are optional key/value pairs that are passed along with
@@ -79,6 +88,11 @@ type Record struct {
 	//
 	// These are purely for producers and consumers; Kafka does not look at
 	// this field and only writes it to disk.
+	//
+	// Kafka's record format has no null headers array: nil and empty
+	// headers are produced identically, and consumed records without
+	// headers always have nil Headers. Individual header values do keep
+	// the distinction between null and empty, the same as Value.
 	Headers []RecordHeader
 
 	// NOTE: if logAppendTime, timestamp is MaxTimestamp, not first + delta
@@ -87,9 +101,20 @@ type Record struct {
 	// Timestamp is the timestamp that will be used for this record.
 	//
 	// Record batches are always written with "CreateTime", meaning that
-	// timestamps are generated by clients rather than brokers.
+	// timestamps are generated by clients rather than brokers, and the
+	// batch timestamp type attribute is always CreateTime. Whether a
+	// record ends up with CreateTime or LogAppendTime is governed solely by
+	// the topic's message.timestamp.type config: a broker ignores the
+	// timestamp type of a produced batch and applies the topic's type, so
+	// a client cannot request LogAppendTime itself. Any client side intent
+	// for a timestamp type is advisory at most.
 	//
-	// This field is always set in Produce.
+	// This field is always set in Produce. If the topic is configured
+	// with LogAppendTime, Kafka overwrites the timestamp when appending
+	// the record; the client updates this field to Kafka's timestamp (and
+	// Attrs to the LogAppendTime timestamp type) before calling the
+	// record's promise. Thus, in a promise, Attrs.TimestampType reports
+	// which timestamp type was actually applied.
 	Timestamp time.Time
 
 	// Topic is the topic that a record is written to.
@@ -126,6 +151,27 @@ type Record struct {
 	// record was written, or -1 if on message sets.
 	LeaderEpoch int32
 
+	// BatchFirstOffset is the offset of the first record in the batch this
+	// record was consumed from. With ProducerID and ProducerEpoch, this
+	// identifies the batch, which can be used to deduplicate or group
+	// transactional records. For records consumed from message sets, this
+	// is the record's own offset.
+	//
+	// For producing, this is set by the client to the offset of the first
+	// record in the batch the record was produced in.
+	BatchFirstOffset int64
+
+	// BatchFirstSequence is the sequence number of the first record in the
+	// batch this record was consumed from, or -1 if the batch was not
+	// produced idempotently or this record is from a message set. See
+	// Sequence for this record's own sequence number.
+	//
+	// For producing, this is set by the client: it is -1 while the record
+	// is buffered, and once the record is successfully produced, it is the
+	// first sequence number of the batch the record was produced in, or -1
+	// if the client is not idempotent.
+	BatchFirstSequence int32
+
 	// Offset is the offset that a record is written as.
 	//
 	// For producing, this is left unset. This will be set by the client as
@@ -135,6 +181,118 @@ type Record struct {
 	Offset int64
 }
 
+// IsTombstone returns whether the record's value is null, which on compacted
+// topics marks the record's key for deletion. An empty, non-nil value is not
+// a tombstone. Tombstones may still have headers.
+func (r *Record) IsTombstone() bool {
+	return r.Value == nil
+}
+
+// userSize returns the number of user provided bytes in the record: the key,
+// value, and header keys and values.
+func (r *Record) userSize() int64 {
+	n := len(r.Key) + len(r.Value)
+	for _, h := range r.Headers {
+		n += len(h.Key) + len(h.Value)
+	}
+	return int64(n)
+}
+
+// Sequence returns the sequence number of a consumed or produced record, which
+// is its batch's BatchFirstSequence plus the record's offset within the batch.
+// Like in Kafka, sequence numbers wrap around to 0 after math.MaxInt32.
+//
+// This returns -1 if the sequence number is not set: if the record was not
+// produced idempotently, or if the record was passed to Produce and has not
+// yet been successfully produced. A record that has been neither produced nor
+// consumed has no sequence number and the return is meaningless.
+func (r *Record) Sequence() int32 {
+	if r.BatchFirstSequence < 0 {
+		return -1
+	}
+	seq := int64(r.BatchFirstSequence) + r.Offset - r.BatchFirstOffset
+	if seq > math.MaxInt32 {
+		seq -= math.MaxInt32 + 1
+	}
+	return int32(seq)
+}
+
+// HeaderValue returns the value of the last header with the given key, and
+// whether the header exists.
+func (r *Record) HeaderValue(key string) ([]byte, bool) {
+	for i := len(r.Headers) - 1; i >= 0; i-- {
+		if r.Headers[i].Key == key {
+			return r.Headers[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetHeader sets the header key to value, replacing any existing headers with
+// the same key. All other headers are kept in their original order.
+//
+// The record's headers are replaced with a new slice, so any slice of headers
+// shared with other records is left untouched.
+func (r *Record) SetHeader(key string, value []byte) {
+	keep := make([]RecordHeader, 0, len(r.Headers)+1)
+	for _, h := range r.Headers {
+		if h.Key != key {
+			keep = append(keep, h)
+		}
+	}
+	r.Headers = append(keep, RecordHeader{Key: key, Value: value})
+}
+
+// TraceparentHeader is the W3C Trace Context header key used to propagate
+// trace context through records.
+const TraceparentHeader = "traceparent"
+
+type traceparentCtxKey struct{}
+
+// ContextWithTraceparent returns a context carrying the given W3C traceparent
+// (e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"). Records
+// produced with this context have the traceparent set as a header before any
+// HookProduceRecordBuffered hooks are called. An empty traceparent returns
+// ctx unmodified.
+//
+// This is meant to be a small bridge for tracing libraries: inject the current
+// span into the produce context, and extract it from consumed records with
+// Record.Traceparent.
+func ContextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, traceparentCtxKey{}, traceparent)
+}
+
+// TraceparentFromContext returns the traceparent stored in ctx by
+// ContextWithTraceparent, if any.
+func TraceparentFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	traceparent, ok := ctx.Value(traceparentCtxKey{}).(string)
+	return traceparent, ok && traceparent != ""
+}
+
+// SetTraceparent sets the record's traceparent header, replacing any existing
+// traceparent header. This is a no-op if traceparent is empty.
+func (r *Record) SetTraceparent(traceparent string) {
+	if traceparent == "" {
+		return
+	}
+	r.SetHeader(TraceparentHeader, []byte(traceparent))
+}
+
+// Traceparent returns the record's traceparent header, if any.
+func (r *Record) Traceparent() (string, bool) {
+	v, ok := r.HeaderValue(TraceparentHeader)
+	if !ok || len(v) == 0 {
+		return "", false
+	}
+	return string(v), true
+}
+
 // AppendFormat appends a record to b given the layout or returns an error if
 // the layout is invalid. This is a one-off shortcut for using
 // NewRecordFormatter. See that function's documentation for the layout
@@ -156,7 +314,15 @@ func (r *Record) AppendFormat(b []byte, layout string) ([]byte, error) {
 // NOTE: It is NOT SAFE to modify the record's value. This function should only
 // be used if you only ever read record fields. This function can safely be used
 // for producing; the client never modifies a record's key nor value fields.
+//
+// An empty value string produces an empty, non-null value; to produce a
+// tombstone, use TombstoneRecord.
 func StringRecord(value string) *Record {
+	if len(value) == 0 {
+		// An empty string may have a nil data pointer, which would
+		// make our value nil and thus a tombstone.
+		return &Record{Value: []byte{}}
+	}
 	var slice []byte
 	slicehdr := (*reflect.SliceHeader)(unsafe.Pointer(&slice))
 	slicehdr.Data = ((*reflect.StringHeader)(unsafe.Pointer(&value))).Data
@@ -175,8 +341,14 @@ func StringRecord(value string) *Record {
 // NOTE: It is NOT SAFE to modify the record's value. This function should only
 // be used if you only ever read record fields. This function can safely be used
 // for producing; the client never modifies a record's key nor value fields.
+//
+// As with values, an empty key string produces an empty, non-null key.
 func KeyStringRecord(key, value string) *Record {
 	r := StringRecord(value)
+	if len(key) == 0 {
+		r.Key = []byte{} // see StringRecord
+		return r
+	}
 
 	keyhdr := (*reflect.SliceHeader)(unsafe.Pointer(&r.Key))
 	keyhdr.Data = ((*reflect.StringHeader)(unsafe.Pointer(&key))).Data
@@ -200,6 +372,17 @@ func KeySliceRecord(key, value []byte) *Record {
 	return &Record{Key: key, Value: value}
 }
 
+// TombstoneRecord returns a Record with the Key field set to the input key and
+// a null Value. On a compacted topic, producing this record deletes key. This
+// function is useful in tandem with the client-level DefaultProduceTopic
+// option; to produce to a different topic, set the Topic field.
+//
+// The key must be non-nil: Kafka rejects records without keys on compacted
+// topics.
+func TombstoneRecord(key []byte) *Record {
+	return &Record{Key: key}
+}
+
 // FetchPartition is a response for a partition in a fetched topic from a
 // broker.
 type FetchPartition struct {
@@ -219,12 +402,44 @@ type FetchPartition struct {
 	// aborted.
 	//
 	// The LastStableOffset will always be at or under the HighWatermark.
+	// Brokers that do not support transactions (fetch versions before 4)
+	// do not return a last stable offset; for these, this is the
+	// HighWatermark.
 	LastStableOffset int64
 	// LogStartOffset is the low watermark of this partition, otherwise
 	// known as the earliest offset in the partition.
+	//
+	// Fetch versions before 5 do not return a log start offset; for these,
+	// this is -1.
 	LogStartOffset int64
+	// PreferredReadReplica is the replica the broker asked the client to
+	// consume this partition from (KIP-392), or -1 if the broker did not
+	// redirect the partition. A redirected partition has no records: the
+	// client moves the partition to the preferred replica and continues
+	// consuming from there.
+	PreferredReadReplica int32
+	// AbortedTransactions contains the transactions that were aborted
+	// within the range of fetched records. This is only returned when
+	// consuming with the ReadCommitted isolation level, and the client
+	// already uses this to skip aborted records.
+	AbortedTransactions []FetchAbortedTransaction
 	// Records contains feched records for this partition.
 	Records []*Record
+	// LazyBatches contains fetched record batches that have not been
+	// decoded, if the client is consuming with LazyRecordDecoding.
+	LazyBatches []*LazyRecordBatch
+
+	// decompressed contains the pooled buffers that Records reference,
+	// returned to the pool in Fetches.Release.
+	decompressed []*[]byte
+}
+
+// FetchAbortedTransaction is an aborted transaction in a fetched partition.
+type FetchAbortedTransaction struct {
+	// ProducerID is the producer ID that aborted the transaction.
+	ProducerID int64
+	// FirstOffset is the offset where the aborted transaction began.
+	FirstOffset int64
 }
 
 // EachRecord calls fn for each record in the partition.
@@ -335,7 +550,7 @@ func (f Fetch) hasErrorsOrRecords() bool {
 		t := &f.Topics[i]
 		for j := range t.Partitions {
 			p := &t.Partitions[j]
-			if p.Err != nil || len(p.Records) > 0 {
+			if p.Err != nil || len(p.Records) > 0 || len(p.LazyBatches) > 0 {
 				return true
 			}
 		}
@@ -513,6 +728,39 @@ func (fs Fetches) Records() []*Record {
 	return rs
 }
 
+// Release returns the buffers that compressed record batches were
+// decompressed into back to the client's internal pool, so that later fetches
+// can decompress into them rather than allocating. Calling Release is
+// optional; the buffers of fetches that are never released are garbage
+// collected as usual. For busy consumers of compressed topics, releasing
+// fetches once they are processed can significantly reduce allocations and
+// GC pressure.
+//
+// Record keys, values, and header values from compressed batches reference
+// these buffers directly. After Release, no record in the fetches may be
+// used, nor may any slice of a record, nor any string that was converted from
+// a record's bytes without copying (for example, with unsafe). Anything that
+// must outlive Release must be copied first.
+//
+// If polling split a partition's records across multiple Fetches, the buffers
+// are released with the Fetches that contains the partition's last records.
+// Calling Release more than once is a no-op.
+func (fs Fetches) Release() {
+	for i := range fs {
+		ts := fs[i].Topics
+		for j := range ts {
+			ps := ts[j].Partitions
+			for k := range ps {
+				p := &ps[k]
+				for _, buf := range p.decompressed {
+					decompressBufs.Put(buf)
+				}
+				p.decompressed = nil
+			}
+		}
+	}
+}
+
 // FetchTopicPartition is similar to FetchTopic, but for an individual
 // partition.
 type FetchTopicPartition struct {
@@ -219,8 +219,8 @@ func TestRecordReader(t *testing.T) {
 			in:     "foo bar biz\nbaz \n biz\n",
 			exp: []*Record{
 				KeyStringRecord("foo", "bar biz"),
-				KeyStringRecord("baz", ""),
-				KeyStringRecord("", "biz"),
+				&Record{Key: []byte("baz")},
+				&Record{Value: []byte("biz")},
 			},
 		},
 
@@ -251,77 +251,77 @@ func TestRecordReader(t *testing.T) {
 		{
 			layout: "%K{ascii}%k",
 			in:     "3foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K%k",
 			in:     "3foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{hex64}%k",
 			in:     "0000000000000003foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{hex32}%k",
 			in:     "00000003foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{hex16}%k",
 			in:     "0003foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{hex8}%k",
 			in:     "03foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{hex4}%k",
 			in:     "3foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{big64}%k",
 			in:     "\x00\x00\x00\x00\x00\x00\x00\x03foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{big32}%k",
 			in:     "\x00\x00\x00\x03foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{big16}%k",
 			in:     "\x00\x03foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{big8}%k",
 			in:     "\x03foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{little64}%k",
 			in:     "\x03\x00\x00\x00\x00\x00\x00\x00foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{little32}%k",
 			in:     "\x03\x00\x00\x00foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{little16}%k",
 			in:     "\x03\x00foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{little8}%k",
 			in:     "\x03foo",
-			exp:    []*Record{KeyStringRecord("foo", "")},
+			exp:    []*Record{&Record{Key: []byte("foo")}},
 		},
 		{
 			layout: "%K{3}%kgap%V{3}%v",
@@ -0,0 +1,234 @@
+package kgo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// RequestCapture is a single request and its response (or error), as
+// captured by a Recorder.
+type RequestCapture struct {
+	// Broker is the broker the request was issued to.
+	Broker BrokerMetadata
+
+	// Key and Version are the key and version of the request.
+	Key     int16
+	Version int16
+	// CorrelationID is the correlation ID the request was written with.
+	CorrelationID int32
+	// BytesWritten is the size of the written request, in bytes.
+	BytesWritten int
+
+	// Request is a copy of the request that was issued, decoded from
+	// what was written, and Response is a copy of the response, if any.
+	// Request is nil if the request failed before being written. Secrets
+	// in requests and responses (SASL auth bytes, delegation token HMACs,
+	// SCRAM salted passwords) are redacted.
+	Request  kmsg.Request
+	Response kmsg.Response
+	// Err is any error for the request.
+	Err error
+
+	// Issued is when the request was enqueued to be written to the
+	// broker, and Duration is how long it took to receive the response
+	// (or error) after that.
+	Issued   time.Time
+	Duration time.Duration
+}
+
+// Recorder keeps the most recent requests issued to brokers and their
+// responses, up to a total size in bytes, which can be used to diagnose
+// protocol issues without always on verbose logging. Use the WithRecorder
+// option to capture requests into a recorder.
+//
+// Internal SASL authentication exchanges are not captured.
+type Recorder struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	captures []sizedCapture
+}
+
+type sizedCapture struct {
+	RequestCapture
+	size int
+}
+
+// NewRecorder returns a recorder that keeps the most recent captures whose
+// encoded requests and responses total at most maxBytes. The most recent
+// capture is always kept, even if it alone is larger than maxBytes.
+func NewRecorder(maxBytes int) *Recorder {
+	return &Recorder{maxBytes: maxBytes}
+}
+
+// Captures returns the recorded captures, oldest first.
+func (r *Recorder) Captures() []RequestCapture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	captures := make([]RequestCapture, 0, len(r.captures))
+	for _, c := range r.captures {
+		captures = append(captures, c.RequestCapture)
+	}
+	return captures
+}
+
+// Reset clears all captures.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.captures = nil
+	r.bytes = 0
+}
+
+func (r *Recorder) add(c RequestCapture, size int) {
+	c.Request = redactRequest(c.Request)
+	c.Response = redactResponse(c.Response)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.captures = append(r.captures, sizedCapture{c, size})
+	r.bytes += size
+	for len(r.captures) > 1 && r.bytes > r.maxBytes {
+		r.bytes -= r.captures[0].size
+		r.captures[0] = sizedCapture{}
+		r.captures = r.captures[1:]
+	}
+}
+
+// WithRecorder captures every request issued to brokers, and its response,
+// into r. See Recorder for more details.
+func WithRecorder(r *Recorder) Opt {
+	return clientOpt{func(cfg *cfg) { cfg.recorder = r }}
+}
+
+// recordWritten returns where to save a copy of the written request for the
+// client's recorder, or nil if there is no recorder.
+func (p *preparedReq) recordWritten() *[]byte {
+	if p.cxn.cl.cfg.recorder == nil {
+		return nil
+	}
+	return &p.written
+}
+
+// recordPromise wraps the promise of a written request to capture the
+// request and response into the client's recorder, if there is one.
+//
+// We capture copies: the request is decoded from the written bytes, which
+// also captures the client's internal produce and fetch requests as the
+// kmsg requests they were written as, and the response is copied by
+// encoding and decoding it before the promise receives it.
+func (cxn *brokerCxn) recordPromise(req kmsg.Request, written []byte, corrID int32, bytesWritten int, issued time.Time, promise func(kmsg.Response, error)) func(kmsg.Response, error) {
+	r := cxn.cl.cfg.recorder
+	if r == nil {
+		return promise
+	}
+	meta := cxn.b.meta
+	return func(resp kmsg.Response, err error) {
+		c := RequestCapture{
+			Broker:        meta,
+			Key:           req.Key(),
+			Version:       req.GetVersion(),
+			CorrelationID: corrID,
+			BytesWritten:  bytesWritten,
+			Err:           err,
+			Issued:        issued,
+			Duration:      time.Since(issued),
+		}
+		size := len(written)
+		if written != nil {
+			c.Request, _ = readWrittenRequest(written)
+		}
+		if resp != nil {
+			encoded := resp.AppendTo(nil)
+			size += len(encoded)
+			c.Response = req.ResponseKind()
+			c.Response.SetVersion(resp.GetVersion())
+			if c.Response.ReadFrom(encoded) != nil {
+				c.Response = nil
+			}
+		}
+		r.add(c, size)
+		promise(resp, err)
+	}
+}
+
+// readWrittenRequest decodes a new request from the full bytes it was written
+// with: the size prefix, the request header, and the request body.
+func readWrittenRequest(written []byte) (kmsg.Request, error) {
+	b := kbin.Reader{Src: written}
+	b.Int32() // size
+	key, version := b.Int16(), b.Int16()
+	b.Int32() // correlation ID
+	req := kmsg.RequestForKey(key)
+	if req == nil {
+		return nil, fmt.Errorf("unknown request key %d", key)
+	}
+	req.SetVersion(version)
+	if key != 7 || version != 0 { // see kmsg.RequestFormatter.AppendRequest
+		b.NullableString() // client ID
+		if req.IsFlexible() {
+			kmsg.SkipTags(&b)
+		}
+	}
+	if err := b.Complete(); err != nil {
+		return nil, err
+	}
+	if err := req.ReadFrom(b.Src); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// redactRequest returns a copy of requests containing secrets, with the
+// secrets removed.
+func redactRequest(req kmsg.Request) kmsg.Request {
+	switch r := req.(type) {
+	case *kmsg.SASLAuthenticateRequest:
+		c := *r
+		c.SASLAuthBytes = nil
+		return &c
+	case *kmsg.RenewDelegationTokenRequest:
+		c := *r
+		c.HMAC = nil
+		return &c
+	case *kmsg.ExpireDelegationTokenRequest:
+		c := *r
+		c.HMAC = nil
+		return &c
+	case *kmsg.AlterUserSCRAMCredentialsRequest:
+		c := *r
+		c.Upsertions = append(c.Upsertions[:0:0], c.Upsertions...)
+		for i := range c.Upsertions {
+			c.Upsertions[i].SaltedPassword = nil
+		}
+		return &c
+	}
+	return req
+}
+
+// redactResponse returns a copy of responses containing secrets, with the
+// secrets removed.
+func redactResponse(resp kmsg.Response) kmsg.Response {
+	switch r := resp.(type) {
+	case *kmsg.SASLAuthenticateResponse:
+		c := *r
+		c.SASLAuthBytes = nil
+		return &c
+	case *kmsg.CreateDelegationTokenResponse:
+		c := *r
+		c.HMAC = nil
+		return &c
+	case *kmsg.DescribeDelegationTokenResponse:
+		c := *r
+		c.TokenDetails = append(c.TokenDetails[:0:0], c.TokenDetails...)
+		for i := range c.TokenDetails {
+			c.TokenDetails[i].HMAC = nil
+		}
+		return &c
+	}
+	return resp
+}
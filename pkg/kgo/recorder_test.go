@@ -0,0 +1,139 @@
+package kgo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestRecorder(t *testing.T) {
+	unexpected := make(chan kmsg.Request, 10)
+	handle := func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.SASLAuthenticateRequest:
+			resp := kmsg.NewPtrSASLAuthenticateResponse()
+			resp.SASLAuthBytes = []byte("server secret")
+			return resp
+		}
+		select {
+		case unexpected <- kreq:
+		default:
+		}
+		return kreq.ResponseKind()
+	}
+
+	// Issuing one metadata request to learn its capture size lets us size
+	// the recorder below to hold exactly two metadata captures.
+	sizer := NewRecorder(0)
+	cl := newFakeBrokerClient(t, handle, WithRecorder(sizer))
+	if _, err := cl.Request(context.Background(), kmsg.NewPtrMetadataRequest()); err != nil {
+		t.Fatal(err)
+	}
+	cl.Close()
+	metaSize := 0
+	for _, c := range sizer.Captures() {
+		if c.Key == 3 {
+			metaSize = c.BytesWritten + len(c.Response.AppendTo(nil))
+		}
+	}
+	if metaSize == 0 {
+		t.Fatal("metadata request was not captured")
+	}
+
+	rec := NewRecorder(2 * metaSize)
+	cl = newFakeBrokerClient(t, handle, WithRecorder(rec))
+	defer cl.Close()
+
+	ctx := context.Background()
+	meta := kmsg.NewPtrMetadataRequest()
+	meta.Topics = append(meta.Topics, kmsg.NewMetadataRequestTopic())
+	meta.Topics[0].Topic = kmsg.StringPtr("foo")
+	if _, err := cl.Request(ctx, meta); err != nil {
+		t.Fatal(err)
+	}
+	meta.Topics[0].Topic = kmsg.StringPtr("bar") // the capture is a copy
+
+	captures := rec.Captures()
+	last := captures[len(captures)-1]
+	if last.Key != 3 {
+		t.Fatalf("got last capture key %d != exp 3", last.Key)
+	}
+	captured, ok := last.Request.(*kmsg.MetadataRequest)
+	if !ok || captured == meta || len(captured.Topics) != 1 || *captured.Topics[0].Topic != "foo" {
+		t.Errorf("got captured request %v, exp a copy of the metadata request for foo", last.Request)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cl.Request(ctx, kmsg.NewPtrMetadataRequest()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if captures := rec.Captures(); len(captures) != 2 || captures[0].Key != 3 || captures[1].Key != 3 {
+		t.Errorf("got %d captures, exp the last 2 metadata requests within the byte limit", len(captures))
+	}
+
+	// A capture larger than the limit is still kept, alone.
+	auth := kmsg.NewPtrSASLAuthenticateRequest()
+	auth.SASLAuthBytes = make([]byte, 2*metaSize)
+	if _, err := cl.Broker(0).Request(ctx, auth); err != nil {
+		t.Fatal(err)
+	}
+
+	captures = rec.Captures()
+	if len(captures) != 1 {
+		t.Fatalf("got %d captures != exp 1", len(captures))
+	}
+	c := captures[0]
+	if c.Key != 36 || c.Err != nil || c.Response == nil || c.BytesWritten <= 2*metaSize {
+		t.Errorf("got key %d, err %v, response %v, bytes written %d", c.Key, c.Err, c.Response, c.BytesWritten)
+	}
+	if len(c.Request.(*kmsg.SASLAuthenticateRequest).SASLAuthBytes) != 0 || len(c.Response.(*kmsg.SASLAuthenticateResponse).SASLAuthBytes) != 0 {
+		t.Error("sasl auth bytes were not redacted")
+	}
+	if len(auth.SASLAuthBytes) != 2*metaSize {
+		t.Error("redacting modified the issued request")
+	}
+
+	rec.Reset()
+	if captures := rec.Captures(); len(captures) != 0 {
+		t.Errorf("got %d captures after reset", len(captures))
+	}
+
+	select {
+	case kreq := <-unexpected:
+		t.Errorf("unexpected request %T", kreq)
+	default:
+	}
+}
+
+// The client's internal produce requests are captured as the kmsg requests
+// they were written as.
+func TestRecorderProduce(t *testing.T) {
+	t.Parallel()
+	rec := NewRecorder(1 << 20)
+	cl := fakeProduceClient(t, func() int16 { return 0 }, DefaultProduceTopic("foo"), WithRecorder(rec))
+	defer cl.Close()
+
+	if err := cl.ProduceSync(context.Background(), StringRecord("v")).FirstErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	var produce *kmsg.ProduceRequest
+	for _, c := range rec.Captures() {
+		if req, ok := c.Request.(*kmsg.ProduceRequest); ok {
+			produce = req
+			if _, ok := c.Response.(*kmsg.ProduceResponse); !ok {
+				t.Errorf("got produce response %T, exp *kmsg.ProduceResponse", c.Response)
+			}
+		}
+	}
+	if produce == nil {
+		t.Fatal("produce request was not captured")
+	}
+	if len(produce.Topics) != 1 || produce.Topics[0].Topic != "foo" || len(produce.Topics[0].Partitions) != 1 || len(produce.Topics[0].Partitions[0].Records) == 0 {
+		t.Errorf("got captured produce request %+v, exp one batch to foo", produce)
+	}
+}
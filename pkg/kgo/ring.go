@@ -84,6 +84,17 @@ func (r *ringReq) push(pr promisedReq) (first, dead bool) {
 	return r.l == 1, false
 }
 
+// peekNext returns the request queued after the current head, if any.
+func (r *ringReq) peekNext() (promisedReq, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.l < 2 {
+		return promisedReq{}, false
+	}
+	return r.elems[(r.head+1)&mask7], true
+}
+
 func (r *ringReq) dropPeek() (next promisedReq, more, dead bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -146,6 +157,13 @@ func (r *ringResp) push(pr promisedResp) (first, dead bool) {
 	return r.l == 1, false
 }
 
+// inflight returns whether any responses are still awaited.
+func (r *ringResp) inflight() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.l > 0
+}
+
 func (r *ringResp) dropPeek() (next promisedResp, more, dead bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -62,7 +62,11 @@ func (cl *Client) newSink(nodeID int32) *sink {
 		nodeID:         nodeID,
 		produceVersion: -1,
 	}
-	s.inflightSem.Store(make(chan struct{}, 1))
+	inflight := 1
+	if cl.cfg.disableIdempotency {
+		inflight = cl.cfg.maxBrokerInflight
+	}
+	s.inflightSem.Store(make(chan struct{}, inflight))
 	return s
 }
 
@@ -100,7 +104,7 @@ func (s *sink) createReq(id int64, epoch int16) (*produceRequest, *kmsg.AddParti
 		recBufsIdx = (recBufsIdx + 1) % len(s.recBufs)
 
 		recBuf.mu.Lock()
-		if recBuf.failing || len(recBuf.batches) == recBuf.batchDrainIdx || recBuf.inflightOnSink != nil && recBuf.inflightOnSink != s {
+		if recBuf.failing || len(recBuf.batches) == recBuf.batchDrainIdx || recBuf.inflightOnSink != nil && recBuf.inflightOnSink != s || recBuf.atInflightLimit() {
 			recBuf.mu.Unlock()
 			continue
 		}
@@ -524,7 +528,7 @@ func (s *sink) handleReqRespNoack(b *bytes.Buffer, debug bool, req *produceReque
 				if debug {
 					fmt.Fprintf(b, "%d{0=>%d}, ", partition, len(batch.records))
 				}
-				s.cl.finishBatch(batch.recBatch, req.producerID, req.producerEpoch, partition, 0, nil)
+				s.cl.finishBatch(batch.recBatch, req.producerID, req.producerEpoch, partition, 0, -1, nil)
 			} else if debug {
 				fmt.Fprintf(b, "%d{skipped}, ", partition)
 			}
@@ -546,7 +550,7 @@ func (s *sink) handleReqResp(br *broker, req *produceRequest, resp kmsg.Response
 	}
 	s.firstRespCheck(req.idempotent(), req.version)
 	atomic.StoreUint32(&s.consecutiveFailures, 0)
-	defer req.metrics.hook(&s.cl.cfg, br) // defer to end so that non-written batches are removed
+	defer req.metrics.hook(s.cl, br) // defer to end so that non-written batches are removed
 
 	var b *bytes.Buffer
 	debug := s.cl.cfg.logger.Level() >= LogLevelDebug
@@ -599,6 +603,7 @@ func (s *sink) handleReqResp(br *broker, req *produceRequest, resp kmsg.Response
 				req.producerID,
 				req.producerEpoch,
 				rPartition.BaseOffset,
+				rPartition.LogAppendTime,
 				rPartition.ErrorCode,
 			)
 			if retry {
@@ -638,6 +643,7 @@ func (s *sink) handleReqRespBatch(
 	producerID int64,
 	producerEpoch int16,
 	baseOffset int64,
+	logAppendTime int64,
 	errorCode int16,
 ) (retry, didProduce bool) {
 	batch.owner.mu.Lock()
@@ -737,7 +743,7 @@ func (s *sink) handleReqRespBatch(
 			)
 			s.cl.failProducerID(producerID, producerEpoch, err)
 
-			s.cl.finishBatch(batch.recBatch, producerID, producerEpoch, partition, baseOffset, err)
+			s.cl.finishBatch(batch.recBatch, producerID, producerEpoch, partition, baseOffset, logAppendTime, err)
 			if debug {
 				fmt.Fprintf(b, "fatal@%d,%d(%s)}, ", baseOffset, nrec, err)
 			}
@@ -795,7 +801,7 @@ func (s *sink) handleReqRespBatch(
 				"max_retries_reached", batch.tries >= s.cl.cfg.recordRetries,
 			)
 		}
-		s.cl.finishBatch(batch.recBatch, producerID, producerEpoch, partition, baseOffset, err)
+		s.cl.finishBatch(batch.recBatch, producerID, producerEpoch, partition, baseOffset, logAppendTime, err)
 		didProduce = err == nil
 		if debug {
 			if err != nil {
@@ -813,7 +819,7 @@ func (s *sink) handleReqRespBatch(
 //
 // This is safe even if the owning recBuf migrated sinks, since we are
 // finishing based off the status of an inflight req from the original sink.
-func (cl *Client) finishBatch(batch *recBatch, producerID int64, producerEpoch int16, partition int32, baseOffset int64, err error) {
+func (cl *Client) finishBatch(batch *recBatch, producerID int64, producerEpoch int16, partition int32, baseOffset, logAppendTime int64, err error) {
 	recBuf := batch.owner
 
 	if err != nil {
@@ -826,6 +832,10 @@ func (cl *Client) finishBatch(batch *recBatch, producerID int64, producerEpoch i
 
 	// We know the batch made it to Kafka successfully without error.
 	// We remove this batch and finish all records appropriately.
+	firstSeq := int32(-1)
+	if producerID >= 0 {
+		firstSeq = recBuf.batch0Seq
+	}
 	finished := len(batch.records)
 	recBuf.batch0Seq += int32(finished)
 	atomic.AddInt64(&recBuf.buffered, -int64(finished))
@@ -840,6 +850,8 @@ func (cl *Client) finishBatch(batch *recBatch, producerID int64, producerEpoch i
 
 	for i, pnr := range records {
 		pnr.Offset = baseOffset + int64(i)
+		pnr.BatchFirstOffset = baseOffset
+		pnr.BatchFirstSequence = firstSeq
 		pnr.Partition = partition
 		pnr.ProducerID = producerID
 		pnr.ProducerEpoch = producerEpoch
@@ -851,6 +863,13 @@ func (cl *Client) finishBatch(batch *recBatch, producerID int64, producerEpoch i
 		// attrs to our own RecordAttrs.
 		pnr.Attrs = RecordAttrs{uint8(attrs)}
 
+		// If the topic uses LogAppendTime, Kafka replaced our
+		// timestamp with its own. -1 means CreateTime.
+		if logAppendTime >= 0 {
+			pnr.Timestamp = timeFromMillis(logAppendTime)
+			pnr.Attrs.attrs |= 0b0000_1000
+		}
+
 		cl.finishRecordPromise(pnr.promisedRec, err)
 		records[i] = noPNR
 	}
@@ -1036,6 +1055,11 @@ type recBuf struct {
 	//
 	// It is always cleared on metadata update.
 	failing bool
+
+	// unavailableSince is when we first saw this partition without a
+	// leader, for UnavailablePartitions. It is zero while the partition
+	// has a leader, and is cleared alongside failing.
+	unavailableSince time.Time
 }
 
 // bufferRecord usually buffers a record, but does not if abortOnNewBatch is
@@ -1149,6 +1173,20 @@ func (recBuf *recBuf) unlingerAndManuallyDrain() {
 func (recBuf *recBuf) bumpRepeatedLoadErr(err error) {
 	recBuf.mu.Lock()
 	defer recBuf.mu.Unlock()
+	recBuf.lockedBumpRepeatedLoadErr(err, false)
+}
+
+// bumpLeaderlessLoadErr is bumpRepeatedLoadErr for a partition that metadata
+// returned without a leader. This additionally fails all records if the
+// partition has been without a leader for longer than UnavailablePartitions
+// allows.
+func (recBuf *recBuf) bumpLeaderlessLoadErr(err error) {
+	recBuf.mu.Lock()
+	defer recBuf.mu.Unlock()
+	recBuf.lockedBumpRepeatedLoadErr(err, recBuf.lockedUnavailableExpired())
+}
+
+func (recBuf *recBuf) lockedBumpRepeatedLoadErr(err error, unavailable bool) {
 	if len(recBuf.batches) == 0 {
 		return
 	}
@@ -1156,11 +1194,22 @@ func (recBuf *recBuf) bumpRepeatedLoadErr(err error) {
 	batch0 := recBuf.batches[0]
 	batch0.tries++
 	failErr := batch0.maybeFailErr(&recBuf.cl.cfg)
-	if (!recBuf.cl.idempotent() || batch0.canFailFromLoadErrs) && (failErr != nil || !isRetriableBrokerErr(err) && !isDialErr(err) && !kerr.IsRetriable(err)) {
+	if (!recBuf.cl.idempotent() || batch0.canFailFromLoadErrs) && (failErr != nil || unavailable || !isRetriableBrokerErr(err) && !isDialErr(err) && !kerr.IsRetriable(err)) {
 		recBuf.failAllRecords(err)
 	}
 }
 
+// lockedUnavailableExpired marks this partition as without a leader if it
+// was not already, and returns whether records for it should now be failed
+// per UnavailablePartitions.
+func (recBuf *recBuf) lockedUnavailableExpired() bool {
+	now := time.Now()
+	if recBuf.unavailableSince.IsZero() {
+		recBuf.unavailableSince = now
+	}
+	return recBuf.cl.cfg.unavailable.expired(recBuf.unavailableSince, now)
+}
+
 // failAllRecords fails all buffered records in this recBuf.
 // This is used anywhere where we have to fail and remove an entire batch,
 // if we just removed the one batch, the seq num chain would be broken.
@@ -1205,6 +1254,7 @@ func (recBuf *recBuf) clearFailing() {
 	defer recBuf.mu.Unlock()
 
 	recBuf.failing = false
+	recBuf.unavailableSince = time.Time{}
 	if len(recBuf.batches) != recBuf.batchDrainIdx {
 		recBuf.sink.maybeDrain()
 	}
@@ -1221,6 +1271,8 @@ type promisedRec struct {
 	ctx     context.Context
 	promise func(*Record, error)
 	*Record
+
+	at time.Time // when the record was passed to Produce, for ProduceMetrics
 }
 
 // promisedNumberedRecord ties a promised record to its calculated numbers.
@@ -1364,17 +1416,33 @@ func (b *recBatch) isTimedOut(limit time.Duration) bool {
 // still inflight.
 func (b *recBatch) decInflight() {
 	recBuf := b.owner
+	wasAtLimit := recBuf.atInflightLimit()
 	recBuf.inflight--
 	if recBuf.inflight != 0 {
+		// If we were skipped while draining because we were at our
+		// inflight limit, we need to trigger our sink to drain what
+		// it skipped.
+		if wasAtLimit && recBuf.inflightOnSink == recBuf.sink && recBuf.batchDrainIdx != len(recBuf.batches) {
+			recBuf.sink.maybeDrain()
+		}
 		return
 	}
 	oldSink := recBuf.inflightOnSink
 	recBuf.inflightOnSink = nil
-	if oldSink != recBuf.sink && recBuf.batchDrainIdx != len(recBuf.batches) {
+	if (oldSink != recBuf.sink || wasAtLimit) && recBuf.batchDrainIdx != len(recBuf.batches) {
 		recBuf.sink.maybeDrain()
 	}
 }
 
+// atInflightLimit returns whether this recBuf has as many batches in flight
+// as are allowed when idempotency is disabled. Without idempotency, Kafka
+// cannot reject a batch that lands before an earlier retried batch, so by
+// default we only allow one batch per partition in flight to preserve
+// ordering. This is called under the recBuf's mu.
+func (recBuf *recBuf) atInflightLimit() bool {
+	return recBuf.cl.cfg.disableIdempotency && int(recBuf.inflight) >= recBuf.cl.cfg.maxPartInflight
+}
+
 ////////////////////
 // produceRequest //
 ////////////////////
@@ -1414,12 +1482,18 @@ type produceRequest struct {
 
 type produceMetrics map[string]map[int32]ProduceBatchMetrics
 
-func (p produceMetrics) hook(cfg *cfg, br *broker) {
+func (p produceMetrics) hook(cl *Client, br *broker) {
 	if len(p) == 0 {
 		return
 	}
+	for _, partitions := range p {
+		for _, metrics := range partitions {
+			cl.producer.batchRecords.observe(int64(metrics.NumRecords))
+			cl.producer.batchBytes.observe(int64(metrics.UncompressedBytes))
+		}
+	}
 	var hooks []HookProduceBatchWritten
-	cfg.hooks.each(func(h Hook) {
+	cl.cfg.hooks.each(func(h Hook) {
 		if h, ok := h.(HookProduceBatchWritten); ok {
 			hooks = append(hooks, h)
 		}
@@ -1888,6 +1962,9 @@ func (r seqRecBatch) appendTo(
 	dst = kbin.AppendInt32(dst, 0) // reserved crc
 
 	attrsAt := len(dst) // in case compression adjusting
+
+	// We never set bit 4 (LogAppendTime): brokers apply the topic's
+	// timestamp type regardless of what we send.
 	r.attrs = 0
 	if transactional {
 		r.attrs |= 0x0010 // bit 5 is the "is transactional" bit
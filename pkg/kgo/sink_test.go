@@ -0,0 +1,1162 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Without idempotency, Kafka cannot reject a batch that is written before an
+// earlier batch that is being retried. This ensures that, even with many
+// produce requests allowed in flight, a partition's second batch is not
+// produced until its first batch is successfully retried.
+func TestProduceRetryPreservesPartitionOrder(t *testing.T) {
+	var (
+		firstProduce = make(chan struct{})
+		failed       bool
+		written      []string
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+
+		case *kmsg.ProduceRequest:
+			resp := req.ResponseKind().(*kmsg.ProduceResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewProduceResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewProduceResponseTopicPartition()
+					sp.Partition = rp.Partition
+					if !failed {
+						// Hold the first request long enough that
+						// a second batch could be issued, then
+						// fail it with a retriable error.
+						failed = true
+						close(firstProduce)
+						time.Sleep(100 * time.Millisecond)
+						sp.ErrorCode = kerr.NotEnoughReplicas.Code
+					} else {
+						sp.BaseOffset = int64(len(written))
+						written = append(written, fakeBatchValues(t, rp.Records)...)
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		DefaultProduceTopic("t"),
+		DisableIdempotentWrite(),
+		RequiredAcks(LeaderAck()),
+		MaxProduceRequestsInflightPerBroker(5),
+		ProducerBatchCompression(NoCompression()),
+		MetadataMinAge(10*time.Millisecond),
+		RetryBackoffFn(func(int) time.Duration { return 10 * time.Millisecond }),
+	)
+	defer cl.Close()
+
+	ctx := context.Background()
+	errs := make(chan error, 2)
+	promise := func(_ *Record, err error) { errs <- err }
+	cl.Produce(ctx, StringRecord("first"), promise)
+	<-firstProduce
+	cl.Produce(ctx, StringRecord("second"), promise)
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected produce error: %v", err)
+		}
+	}
+	if exp := []string{"first", "second"}; !reflect.DeepEqual(written, exp) {
+		t.Errorf("got written %v, exp %v", written, exp)
+	}
+}
+
+// fakeBatchValues returns the record values of an uncompressed record batch.
+func fakeBatchValues(t *testing.T, raw []byte) []string {
+	t.Helper()
+	var batch kmsg.RecordBatch
+	if err := batch.ReadFrom(raw); err != nil {
+		t.Errorf("unable to read record batch: %v", err)
+		return nil
+	}
+	var vs []string
+	for _, r := range readRawRecords(int(batch.NumRecords), batch.Records) {
+		vs = append(vs, string(r.Value))
+	}
+	return vs
+}
+
+// Records for different topics led by the same broker are produced in one
+// request.
+func TestProduceCoalescesTopicsPerBroker(t *testing.T) {
+	t.Parallel()
+	var (
+		hold    int32
+		release = make(chan struct{})
+		mu      sync.Mutex
+		reqs    [][]string
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+
+		case *kmsg.InitProducerIDRequest:
+			return req.ResponseKind()
+
+		case *kmsg.ProduceRequest:
+			if atomic.CompareAndSwapInt32(&hold, 1, 0) {
+				<-release
+			}
+			resp := req.ResponseKind().(*kmsg.ProduceResponse)
+			var topics []string
+			for _, rt := range req.Topics {
+				topics = append(topics, rt.Topic)
+				st := kmsg.NewProduceResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewProduceResponseTopicPartition()
+					sp.Partition = rp.Partition
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			sort.Strings(topics)
+			mu.Lock()
+			reqs = append(reqs, topics)
+			mu.Unlock()
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx := context.Background()
+	if err := cl.ProduceSync(ctx, &Record{Topic: "a"}, &Record{Topic: "b"}).FirstErr(); err != nil {
+		t.Fatalf("unable to load topics: %v", err)
+	}
+
+	// With our first produce blocked, the next records for both topics
+	// buffer and must be drained together.
+	atomic.StoreInt32(&hold, 1)
+	errs := make(chan error, 3)
+	promise := func(_ *Record, err error) { errs <- err }
+	cl.Produce(ctx, &Record{Topic: "a"}, promise)
+	for atomic.LoadInt32(&hold) == 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cl.Produce(ctx, &Record{Topic: "a"}, promise)
+	cl.Produce(ctx, &Record{Topic: "b"}, promise)
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected produce error: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if last := reqs[len(reqs)-1]; !reflect.DeepEqual(last, []string{"a", "b"}) {
+		t.Errorf("got final produce request topics %v, exp [a b]", last)
+	}
+}
+
+// Records that permanently fail are produced to the dead letter topic with
+// headers describing the failure, and records that fail in the dead letter
+// topic are not dead lettered again.
+func TestDeadLetterTopic(t *testing.T) {
+	t.Parallel()
+	var (
+		mu         sync.Mutex
+		failDLQ    bool
+		dlqHeaders []kmsg.Header
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+
+		case *kmsg.InitProducerIDRequest:
+			return req.ResponseKind()
+
+		case *kmsg.ProduceRequest:
+			mu.Lock()
+			defer mu.Unlock()
+			resp := req.ResponseKind().(*kmsg.ProduceResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewProduceResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewProduceResponseTopicPartition()
+					sp.Partition = rp.Partition
+					switch {
+					case rt.Topic != "dlq" || failDLQ:
+						sp.ErrorCode = kerr.InvalidRecord.Code
+					default:
+						var batch kmsg.RecordBatch
+						if err := batch.ReadFrom(rp.Records); err != nil {
+							t.Errorf("unable to read record batch: %v", err)
+							break
+						}
+						for _, r := range readRawRecords(int(batch.NumRecords), batch.Records) {
+							dlqHeaders = append(dlqHeaders, r.Headers...)
+						}
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		DeadLetterTopic("dlq"),
+		ProducerBatchCompression(NoCompression()),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx := context.Background()
+	r := &Record{Topic: "a", Value: []byte("v"), Headers: []RecordHeader{{Key: "k", Value: []byte("v")}}}
+	_, err := cl.ProduceSync(ctx, r).First()
+	var dl *ErrDeadLettered
+	if !errors.As(err, &dl) {
+		t.Fatalf("got err %v, exp ErrDeadLettered", err)
+	}
+	if !errors.Is(err, kerr.InvalidRecord) {
+		t.Errorf("got err %v, exp wrapping %v", err, kerr.InvalidRecord)
+	}
+	if dl.DeadLetter.Topic != "dlq" || string(dl.DeadLetter.Value) != "v" {
+		t.Errorf("got dead letter record topic %q value %q, exp dlq v", dl.DeadLetter.Topic, dl.DeadLetter.Value)
+	}
+
+	mu.Lock()
+	exp := []kmsg.Header{
+		{Key: "k", Value: []byte("v")},
+		{Key: DeadLetterTopicHeader, Value: []byte("a")},
+		{Key: DeadLetterErrorHeader, Value: []byte(kerr.InvalidRecord.Error())},
+	}
+	if !reflect.DeepEqual(dlqHeaders, exp) {
+		t.Errorf("got dead letter headers %v, exp %v", dlqHeaders, exp)
+	}
+	failDLQ = true
+	mu.Unlock()
+
+	if _, err := cl.ProduceSync(ctx, &Record{Topic: "a"}).First(); err != kerr.InvalidRecord {
+		t.Errorf("got err %v with a failing dead letter topic, exp %v", err, kerr.InvalidRecord)
+	}
+	if _, err := cl.ProduceSync(ctx, &Record{Topic: "dlq"}).First(); err != kerr.InvalidRecord {
+		t.Errorf("got err %v producing directly to the dead letter topic, exp %v", err, kerr.InvalidRecord)
+	}
+	if err := cl.Flush(ctx); err != nil {
+		t.Fatalf("unable to flush: %v", err)
+	}
+	if n := cl.BufferedProduceRecords(); n != 0 {
+		t.Errorf("got %d buffered records after all promises, exp 0", n)
+	}
+	if n := cl.BufferedProduceBytes(); n != 0 {
+		t.Errorf("got %d buffered bytes after all promises, exp 0", n)
+	}
+}
+
+// fakeProduceClient returns a client whose fake broker has one partition per
+// topic and replies to every produce with the error code from produceErr.
+func fakeProduceClient(t *testing.T, produceErr func() int16, opts ...Opt) *Client {
+	return newFakeBrokerClient(t, fakeProduceHandler(t, produceErr), append([]Opt{MetadataMinAge(10 * time.Millisecond)}, opts...)...)
+}
+
+// fakeProduceHandler is the fake broker handler for fakeProduceClient, for
+// tests that need to wrap it to reply differently to some requests.
+func fakeProduceHandler(t *testing.T, produceErr func() int16) func(kmsg.Request) kmsg.Response {
+	return func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+
+		case *kmsg.InitProducerIDRequest:
+			return req.ResponseKind()
+
+		case *kmsg.ProduceRequest:
+			resp := req.ResponseKind().(*kmsg.ProduceResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewProduceResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewProduceResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.ErrorCode = produceErr()
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	}
+}
+
+// With acks=all, NOT_ENOUGH_REPLICAS errors are retried, and records fail
+// with the error once retries are exhausted.
+func TestProduceNotEnoughReplicas(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		err     *kerr.Error
+		succeed int32 // produce attempt that succeeds, 0 for never
+	}{
+		{kerr.NotEnoughReplicas, 3},
+		{kerr.NotEnoughReplicasAfterAppend, 2},
+		{kerr.NotEnoughReplicas, 0},
+		{kerr.NotEnoughReplicasAfterAppend, 0},
+	} {
+		var attempts int32
+		cl := fakeProduceClient(t, func() int16 {
+			if n := atomic.AddInt32(&attempts, 1); n == test.succeed {
+				return 0
+			}
+			return test.err.Code
+		},
+			DefaultProduceTopic("t"),
+			RecordRetries(3),
+			RetryBackoffFn(func(int) time.Duration { return time.Millisecond }),
+		)
+
+		_, err := cl.ProduceSync(context.Background(), StringRecord("v")).First()
+		switch {
+		case test.succeed != 0 && err != nil:
+			t.Errorf("%v: got err %v, exp success after retries", test.err, err)
+		case test.succeed != 0 && atomic.LoadInt32(&attempts) != test.succeed:
+			t.Errorf("%v: got %d attempts, exp %d", test.err, atomic.LoadInt32(&attempts), test.succeed)
+		case test.succeed == 0 && !errors.Is(err, test.err):
+			t.Errorf("%v: got err %v once retries were exhausted, exp %v", test.err, err, test.err)
+		}
+		cl.Close()
+	}
+}
+
+// With acks=0, the client does not wait for responses, so even records the
+// broker would reject succeed.
+func TestProduceNoAck(t *testing.T) {
+	t.Parallel()
+	cl := fakeProduceClient(t, func() int16 { return kerr.NotEnoughReplicas.Code },
+		DefaultProduceTopic("t"),
+		RequiredAcks(NoAck()),
+		DisableIdempotentWrite(),
+	)
+	defer cl.Close()
+
+	rs := make([]*Record, 10)
+	for i := range rs {
+		rs[i] = StringRecord("v")
+	}
+	if err := cl.ProduceSync(context.Background(), rs...).FirstErr(); err != nil {
+		t.Fatalf("got err %v with no acks, exp nil", err)
+	}
+}
+
+// If InitProducerID fails with CLUSTER_AUTHORIZATION_FAILED, records fail
+// rather than being produced non-idempotently. DisableIdempotentWrite opts
+// out of initializing a producer ID entirely.
+func TestProduceIdempotentClusterAuthFailed(t *testing.T) {
+	t.Parallel()
+	for _, disable := range []bool{false, true} {
+		var inits, produces int32
+		opts := []Opt{
+			DefaultProduceTopic("t"),
+			MetadataMinAge(10 * time.Millisecond),
+		}
+		if disable {
+			opts = append(opts, DisableIdempotentWrite())
+		}
+		handle := fakeProduceHandler(t, func() int16 {
+			atomic.AddInt32(&produces, 1)
+			return 0
+		})
+		cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+			if req, ok := kreq.(*kmsg.InitProducerIDRequest); ok {
+				atomic.AddInt32(&inits, 1)
+				resp := req.ResponseKind().(*kmsg.InitProducerIDResponse)
+				resp.ErrorCode = kerr.ClusterAuthorizationFailed.Code
+				return resp
+			}
+			return handle(kreq)
+		}, opts...)
+
+		err := cl.ProduceSync(context.Background(), StringRecord("v")).FirstErr()
+		cl.Close()
+
+		if disable {
+			if err != nil {
+				t.Errorf("disabled: got err %v, exp nil", err)
+			}
+			if n := atomic.LoadInt32(&inits); n != 0 {
+				t.Errorf("disabled: got %d InitProducerID requests, exp 0", n)
+			}
+			continue
+		}
+		if !errors.Is(err, kerr.ClusterAuthorizationFailed) {
+			t.Errorf("got err %v, exp %v", err, kerr.ClusterAuthorizationFailed)
+		}
+		if n := atomic.LoadInt32(&produces); n != 0 {
+			t.Errorf("got %d produce requests, exp 0", n)
+		}
+	}
+}
+
+type warnLogger struct{ warns int }
+
+func (*warnLogger) Level() LogLevel { return LogLevelWarn }
+func (l *warnLogger) Log(level LogLevel, _ string, _ ...interface{}) {
+	if level == LogLevelWarn {
+		l.warns++
+	}
+}
+
+// A record delivery timeout shorter than the produce request timeout is
+// allowed, but warned about unless producing without acks.
+func TestRecordDeliveryTimeoutAtLeastProduceTimeout(t *testing.T) {
+	for _, test := range []struct {
+		opts    []Opt
+		expWarn bool
+	}{
+		{[]Opt{RecordDeliveryTimeout(5 * time.Second)}, true}, // default produce timeout is 10s
+		{[]Opt{RecordDeliveryTimeout(10 * time.Second)}, false},
+		{[]Opt{RecordDeliveryTimeout(5 * time.Second), ProduceRequestTimeout(5 * time.Second)}, false},
+		{[]Opt{RecordDeliveryTimeout(5 * time.Second), RequiredAcks(NoAck()), DisableIdempotentWrite()}, false},
+	} {
+		l := new(warnLogger)
+		cl, err := NewClient(append(test.opts, WithLogger(l))...)
+		if err != nil {
+			t.Errorf("got err %v, exp nil", err)
+			continue
+		}
+		cl.Close()
+		if gotWarn := l.warns > 0; gotWarn != test.expWarn {
+			t.Errorf("got %d warnings, exp warning? %v", l.warns, test.expWarn)
+		}
+	}
+}
+
+func TestBufferedProduceBytes(t *testing.T) {
+	t.Parallel()
+	cl := fakeProduceClient(t, func() int16 { return 0 },
+		DefaultProduceTopic("t"),
+		ManualFlushing(),
+	)
+	defer cl.Close()
+
+	var exp int64
+	for i := 0; i < 10; i++ {
+		r := &Record{
+			Key:     []byte("key"),
+			Value:   []byte("value"),
+			Headers: []RecordHeader{{Key: "h", Value: []byte("v")}},
+		}
+		exp += 10
+		cl.Produce(context.Background(), r, nil)
+	}
+	if got := cl.BufferedProduceBytes(); got != exp {
+		t.Errorf("got %d buffered bytes, exp %d", got, exp)
+	}
+	if err := cl.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := cl.BufferedProduceBytes(); got != 0 {
+		t.Errorf("got %d buffered bytes after flush, exp 0", got)
+	}
+}
+
+func TestProduceMetrics(t *testing.T) {
+	t.Parallel()
+	var fail int32
+	cl := fakeProduceClient(t, func() int16 {
+		if atomic.LoadInt32(&fail) == 1 {
+			return kerr.InvalidRecord.Code
+		}
+		return 0
+	},
+		DefaultProduceTopic("t"),
+		ManualFlushing(),
+	)
+	defer cl.Close()
+
+	for i := 0; i < 10; i++ {
+		cl.Produce(context.Background(), StringRecord("value"), nil)
+	}
+	m := cl.ProduceMetrics()
+	if m.BufferedRecords != 10 || m.BufferedBytes != 50 {
+		t.Errorf("got %d buffered records and %d bytes, exp 10 and 50", m.BufferedRecords, m.BufferedBytes)
+	}
+	if err := cl.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Failed records are not part of the ack latency.
+	atomic.StoreInt32(&fail, 1)
+	cl.Produce(context.Background(), StringRecord("value"), nil)
+	if err := cl.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	m = cl.ProduceMetrics()
+	if m.BufferedRecords != 0 || m.BufferedBytes != 0 {
+		t.Errorf("got %d buffered records and %d bytes after flushing, exp 0", m.BufferedRecords, m.BufferedBytes)
+	}
+	for _, test := range []struct {
+		name     string
+		h        Histogram
+		expCount int64
+		expIn    int64 // if non-negative, a value in the bucket we expect every observation in
+	}{
+		{"ack latency", m.AckLatencyMillis, 10, -1},
+		{"batch records", m.BatchRecords, 1, 10},
+		{"batch bytes", m.BatchBytes, 1, m.BatchBytes.Sum},
+	} {
+		var n int64
+		for _, c := range test.h.Counts {
+			n += c
+		}
+		if test.h.Count != test.expCount || n != test.expCount || len(test.h.Counts) != len(test.h.Bounds)+1 {
+			t.Errorf("%s: got count %d, bucket counts %v for %d bounds, exp count %d", test.name, test.h.Count, test.h.Counts, len(test.h.Bounds), test.expCount)
+			continue
+		}
+		if test.expIn < 0 {
+			continue
+		}
+		i := sort.Search(len(test.h.Bounds), func(i int) bool { return test.expIn <= test.h.Bounds[i] })
+		if test.h.Counts[i] != test.expCount {
+			t.Errorf("%s: got bucket counts %v, exp all %d in bucket %d", test.name, test.h.Counts, test.expCount, i)
+		}
+	}
+	if m.BatchRecords.Sum != 10 {
+		t.Errorf("got %d batched records, exp 10", m.BatchRecords.Sum)
+	}
+}
+
+// Produced records have the sequence number they were written with if the
+// client is idempotent, and -1 otherwise or if they failed.
+func TestProducedRecordSequence(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		name string
+		opts []Opt
+		exp  []int32
+	}{
+		{"idempotent", nil, []int32{0, 1, 2, 3, 4}},
+		{"not idempotent", []Opt{DisableIdempotentWrite()}, []int32{-1, -1, -1, -1, -1}},
+	} {
+		var fail int32
+		cl := fakeProduceClient(t, func() int16 {
+			if atomic.LoadInt32(&fail) == 1 {
+				return kerr.InvalidRecord.Code
+			}
+			return 0
+		}, append([]Opt{DefaultProduceTopic("t")}, test.opts...)...)
+
+		var got []int32
+		for _, n := range []int{3, 2} {
+			rs := make([]*Record, n)
+			for i := range rs {
+				rs[i] = StringRecord("v")
+			}
+			if err := cl.ProduceSync(context.Background(), rs...).FirstErr(); err != nil {
+				t.Fatalf("%s: unable to produce: %v", test.name, err)
+			}
+			for _, r := range rs {
+				got = append(got, r.Sequence())
+			}
+		}
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("%s: got sequences %v != exp %v", test.name, got, test.exp)
+		}
+
+		atomic.StoreInt32(&fail, 1)
+		r := StringRecord("v")
+		if err := cl.ProduceSync(context.Background(), r).FirstErr(); err == nil {
+			t.Errorf("%s: unexpected produce success", test.name)
+		}
+		if seq := r.Sequence(); seq != -1 {
+			t.Errorf("%s: got sequence %d for failed record != exp -1", test.name, seq)
+		}
+		cl.Close()
+	}
+}
+
+// ProduceSync returns results in input order even if records finish out of
+// order, and a failure of one record does not fail the others.
+func TestProduceSyncResultOrder(t *testing.T) {
+	t.Parallel()
+	var slowTries int32
+	handle := fakeProduceHandler(t, func() int16 { return 0 })
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		kresp := handle(kreq)
+		if resp, ok := kresp.(*kmsg.ProduceResponse); ok {
+			for i := range resp.Topics {
+				st := &resp.Topics[i]
+				for j := range st.Partitions {
+					sp := &st.Partitions[j]
+					switch st.Topic {
+					case "slow":
+						if atomic.AddInt32(&slowTries, 1) < 3 {
+							sp.ErrorCode = kerr.NotEnoughReplicas.Code
+						}
+					case "bad":
+						sp.ErrorCode = kerr.InvalidRecord.Code
+					}
+				}
+			}
+		}
+		return kresp
+	},
+		MetadataMinAge(10*time.Millisecond),
+		RetryBackoffFn(func(int) time.Duration { return time.Millisecond }),
+	)
+	defer cl.Close()
+
+	rs := []*Record{
+		{Topic: "slow", Value: []byte("0")},
+		{Topic: "fast", Value: []byte("1")},
+		{Topic: "bad", Value: []byte("2")},
+		{Topic: "fast", Value: []byte("3")},
+	}
+	results := cl.ProduceSync(context.Background(), rs...)
+	if len(results) != len(rs) {
+		t.Fatalf("got %d results, exp %d", len(results), len(rs))
+	}
+	for i, r := range results {
+		if r.Record != rs[i] {
+			t.Errorf("result %d: got record %q, exp %q", i, r.Record.Value, rs[i].Value)
+		}
+		if expBad := i == 2; (r.Err != nil) != expBad {
+			t.Errorf("result %d: got err %v, exp err? %v", i, r.Err, expBad)
+		}
+	}
+}
+
+// growFakePartitions grows every topic in a fakeProduceHandler metadata
+// response to n partitions.
+func growFakePartitions(resp *kmsg.MetadataResponse, n int32) {
+	for i := range resp.Topics {
+		ps := &resp.Topics[i].Partitions
+		for p := int32(len(*ps)); p < n; p++ {
+			sp := (*ps)[0]
+			sp.Partition = p
+			*ps = append(*ps, sp)
+		}
+	}
+}
+
+// A record manually partitioned beyond the partitions we know of triggers a
+// metadata refresh, and is produced if the refresh learns of the partition.
+func TestProducePartitionBeyondKnownRefreshesMetadata(t *testing.T) {
+	t.Parallel()
+	var metas int32
+	handle := fakeProduceHandler(t, func() int16 { return 0 })
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		kresp := handle(kreq)
+		if resp, ok := kresp.(*kmsg.MetadataResponse); ok && atomic.AddInt32(&metas, 1) > 1 {
+			growFakePartitions(resp, 2) // partitions were added after our first load
+		}
+		return kresp
+	},
+		RecordPartitioner(ManualPartitioner()),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	if err := cl.ProduceSync(context.Background(), &Record{Topic: "foo", Partition: 0}).FirstErr(); err != nil {
+		t.Fatalf("unable to produce to known partition: %v", err)
+	}
+
+	r, err := cl.ProduceSync(context.Background(), &Record{Topic: "foo", Partition: 1}).First()
+	if err != nil {
+		t.Fatalf("unable to produce to newly added partition: %v", err)
+	}
+	if r.Partition != 1 {
+		t.Errorf("got partition %d, exp 1", r.Partition)
+	}
+
+	// A partition that never exists still fails after the refresh.
+	before := atomic.LoadInt32(&metas)
+	if err := cl.ProduceSync(context.Background(), &Record{Topic: "foo", Partition: 5}).FirstErr(); err == nil {
+		t.Error("unexpected success producing to a partition that does not exist")
+	}
+	if after := atomic.LoadInt32(&metas); after == before {
+		t.Error("expected a metadata refresh before failing the record")
+	}
+}
+
+// Records to a topic that briefly loads with UNKNOWN_TOPIC_OR_PARTITION are
+// produced if the topic loads within UnknownTopicRetryWindow, while records to
+// a topic that never loads fail once the window passes.
+func TestUnknownTopicRetryWindow(t *testing.T) {
+	t.Parallel()
+	var newLoads int32
+	produce := fakeProduceHandler(t, func() int16 { return 0 })
+	handle := func(kreq kmsg.Request) kmsg.Response {
+		kresp := produce(kreq)
+		if resp, ok := kresp.(*kmsg.MetadataResponse); ok {
+			for i := range resp.Topics {
+				st := &resp.Topics[i]
+				propagating := *st.Topic == "new" && atomic.AddInt32(&newLoads, 1) <= 10
+				if *st.Topic == "missing" || propagating {
+					st.ErrorCode = kerr.UnknownTopicOrPartition.Code
+					st.Partitions = nil
+				}
+			}
+		}
+		return kresp
+	}
+
+	cl := newFakeBrokerClient(t, handle,
+		MetadataMinAge(10*time.Millisecond),
+		UnknownTopicRetryWindow(time.Minute),
+	)
+	defer cl.Close()
+	if err := cl.ProduceSync(context.Background(), &Record{Topic: "new"}).FirstErr(); err != nil {
+		t.Errorf("unable to produce to a propagating topic: %v", err)
+	}
+
+	cl = newFakeBrokerClient(t, handle,
+		MetadataMinAge(10*time.Millisecond),
+		UnknownTopicRetryWindow(200*time.Millisecond),
+	)
+	defer cl.Close()
+	start := time.Now()
+	err := cl.ProduceSync(context.Background(), &Record{Topic: "missing"}).FirstErr()
+	if !errors.Is(err, kerr.UnknownTopicOrPartition) {
+		t.Errorf("got err %v producing to a missing topic, exp %v", err, kerr.UnknownTopicOrPartition)
+	}
+	if since := time.Since(start); since < 200*time.Millisecond {
+		t.Errorf("missing topic failed after %v, before the window", since)
+	}
+
+	if _, err := NewClient(UnknownTopicRetryWindow(-time.Second)); err == nil {
+		t.Error("unexpected success with a negative unknown topic retry window")
+	}
+}
+
+// Produced batches are always CreateTime; if the broker applies LogAppendTime,
+// the promised records reflect the broker's timestamp and timestamp type.
+func TestProduceLogAppendTime(t *testing.T) {
+	t.Parallel()
+	const appendMillis = 1234567890123
+	var badAttrs int32
+	handle := fakeProduceHandler(t, func() int16 { return 0 })
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		req, ok := kreq.(*kmsg.ProduceRequest)
+		if !ok {
+			return handle(kreq)
+		}
+		for _, rt := range req.Topics {
+			for _, rp := range rt.Partitions {
+				var batch kmsg.RecordBatch
+				if err := batch.ReadFrom(rp.Records); err != nil || batch.Attributes&0b1000 != 0 {
+					atomic.AddInt32(&badAttrs, 1)
+				}
+			}
+		}
+		resp := handle(req).(*kmsg.ProduceResponse)
+		for i := range resp.Topics {
+			if resp.Topics[i].Topic == "append" {
+				for j := range resp.Topics[i].Partitions {
+					resp.Topics[i].Partitions[j].LogAppendTime = appendMillis
+				}
+			}
+		}
+		return resp
+	}, MetadataMinAge(10*time.Millisecond))
+	defer cl.Close()
+
+	start := time.Now().Truncate(time.Millisecond)
+	results := cl.ProduceSync(context.Background(), &Record{Topic: "append"}, &Record{Topic: "create"})
+	if err := results.FirstErr(); err != nil {
+		t.Fatalf("unable to produce: %v", err)
+	}
+	if atomic.LoadInt32(&badAttrs) != 0 {
+		t.Error("produced a batch that was not CreateTime")
+	}
+
+	appended, created := results[0].Record, results[1].Record
+	if !appended.Timestamp.Equal(timeFromMillis(appendMillis)) || appended.Attrs.TimestampType() != 1 {
+		t.Errorf("got LogAppendTime record timestamp %v type %d, exp %v type 1",
+			appended.Timestamp, appended.Attrs.TimestampType(), timeFromMillis(appendMillis))
+	}
+	if created.Timestamp.Before(start) || created.Attrs.TimestampType() != 0 {
+		t.Errorf("got CreateTime record timestamp %v type %d, exp at least %v type 0",
+			created.Timestamp, created.Attrs.TimestampType(), start)
+	}
+}
+
+// Records to a partition without a leader wait for a leader by default, can
+// wait up to a timeout, or can fail immediately.
+func TestUnavailablePartitions(t *testing.T) {
+	t.Parallel()
+	handle := func(leaderAfter int32) func(kmsg.Request) kmsg.Response {
+		var metas int32
+		return func(kreq kmsg.Request) kmsg.Response {
+			switch req := kreq.(type) {
+			case *kmsg.MetadataRequest:
+				resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+				leaderless := leaderAfter < 0 || atomic.AddInt32(&metas, 1) <= leaderAfter
+				for i := range resp.Topics {
+					for p := int32(0); p < 2; p++ {
+						sp := kmsg.NewMetadataResponseTopicPartition()
+						sp.Partition = p
+						sp.Replicas = []int32{0}
+						sp.ISR = []int32{0}
+						if p == 1 && leaderless {
+							sp.ErrorCode = kerr.LeaderNotAvailable.Code
+							sp.Leader = -1
+						}
+						resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, sp)
+					}
+				}
+				return resp
+
+			case *kmsg.InitProducerIDRequest:
+				return req.ResponseKind()
+
+			case *kmsg.ProduceRequest:
+				leaderless := leaderAfter < 0 || atomic.LoadInt32(&metas) <= leaderAfter
+				resp := req.ResponseKind().(*kmsg.ProduceResponse)
+				for _, rt := range req.Topics {
+					st := kmsg.NewProduceResponseTopic()
+					st.Topic = rt.Topic
+					for _, rp := range rt.Partitions {
+						sp := kmsg.NewProduceResponseTopicPartition()
+						sp.Partition = rp.Partition
+						if rp.Partition == 1 && leaderless {
+							sp.ErrorCode = kerr.NotLeaderForPartition.Code
+						}
+						st.Partitions = append(st.Partitions, sp)
+					}
+					resp.Topics = append(resp.Topics, st)
+				}
+				return resp
+			}
+			t.Errorf("unexpected request %T", kreq)
+			return kreq.ResponseKind()
+		}
+	}
+
+	for _, test := range []struct {
+		name        string
+		behavior    UnavailablePartitionBehavior
+		leaderAfter int32 // metadata loads before partition 1 has a leader, or -1 for never
+		expErr      error
+		minElapsed  time.Duration
+		maxElapsed  time.Duration
+	}{
+		{"fail", FailWithoutLeader(), -1, kerr.LeaderNotAvailable, 0, time.Second},
+		{"wait timeout", WaitForLeader(200 * time.Millisecond), -1, kerr.LeaderNotAvailable, 200 * time.Millisecond, 5 * time.Second},
+		{"wait", WaitForLeader(0), 5, nil, 0, 5 * time.Second},
+		{"wait within timeout", WaitForLeader(time.Minute), 5, nil, 0, 5 * time.Second},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			// A batch for a leaderless partition is first sent to a
+			// placeholder seed sink; once attempted, an idempotent
+			// batch can no longer be failed from load errors.
+			cl := newFakeBrokerClient(t, handle(test.leaderAfter),
+				DisableIdempotentWrite(),
+				RecordPartitioner(ManualPartitioner()),
+				MetadataMinAge(10*time.Millisecond),
+				UnavailablePartitions(test.behavior),
+			)
+			defer cl.Close()
+
+			if err := cl.ProduceSync(context.Background(), &Record{Topic: "foo", Partition: 0}).FirstErr(); err != nil {
+				t.Fatalf("unable to produce to partition with a leader: %v", err)
+			}
+
+			start := time.Now()
+			err := cl.ProduceSync(context.Background(), &Record{Topic: "foo", Partition: 1}).FirstErr()
+			elapsed := time.Since(start)
+			if !errors.Is(err, test.expErr) && !(err == nil && test.expErr == nil) {
+				t.Errorf("got err %v, exp %v", err, test.expErr)
+			}
+			if elapsed < test.minElapsed || elapsed > test.maxElapsed {
+				t.Errorf("finished after %v, exp between %v and %v", elapsed, test.minElapsed, test.maxElapsed)
+			}
+		})
+	}
+
+	if _, err := NewClient(UnavailablePartitions(WaitForLeader(-time.Second))); err == nil {
+		t.Error("unexpected success with a negative unavailable partition wait timeout")
+	}
+}
+
+// Retriable producer ID and topic metadata errors do not mean a partition is
+// without a leader, and do not fail records even with FailWithoutLeader.
+func TestUnavailablePartitionsTransientErrors(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		name string
+		fail func(kmsg.Request) kmsg.Response
+		opts []Opt
+	}{
+		{
+			name: "init producer id",
+			fail: func(kreq kmsg.Request) kmsg.Response {
+				req, ok := kreq.(*kmsg.InitProducerIDRequest)
+				if !ok {
+					return nil
+				}
+				resp := req.ResponseKind().(*kmsg.InitProducerIDResponse)
+				resp.ErrorCode = kerr.CoordinatorLoadInProgress.Code
+				return resp
+			},
+			// Without request retries, each retriable error fails
+			// loading the producer ID.
+			opts: []Opt{RequestRetries(0)},
+		},
+		{
+			// Non-idempotent batches can be failed after they are
+			// produced, and the produce error below triggers a
+			// metadata refresh before the batch is retried.
+			name: "metadata",
+			fail: func(kreq kmsg.Request) kmsg.Response {
+				switch req := kreq.(type) {
+				case *kmsg.ProduceRequest:
+					resp := req.ResponseKind().(*kmsg.ProduceResponse)
+					for _, rt := range req.Topics {
+						st := kmsg.NewProduceResponseTopic()
+						st.Topic = rt.Topic
+						for _, rp := range rt.Partitions {
+							sp := kmsg.NewProduceResponseTopicPartition()
+							sp.Partition = rp.Partition
+							sp.ErrorCode = kerr.NotLeaderForPartition.Code
+							st.Partitions = append(st.Partitions, sp)
+						}
+						resp.Topics = append(resp.Topics, st)
+					}
+					return resp
+				case *kmsg.MetadataRequest:
+					resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+					for i := range resp.Topics {
+						resp.Topics[i].ErrorCode = kerr.LeaderNotAvailable.Code
+					}
+					return resp
+				}
+				return nil
+			},
+			opts: []Opt{DisableIdempotentWrite()},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				topicLoaded int32
+				fails       int32
+				handle      = fakeProduceHandler(t, func() int16 { return 0 })
+			)
+			cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+				// The first metadata load always succeeds, so
+				// that the producer knows the topic.
+				if _, ok := kreq.(*kmsg.MetadataRequest); ok && atomic.CompareAndSwapInt32(&topicLoaded, 0, 1) {
+					return handle(kreq)
+				}
+				if atomic.LoadInt32(&fails) < 3 {
+					if resp := test.fail(kreq); resp != nil {
+						if _, ok := kreq.(*kmsg.ProduceRequest); !ok {
+							atomic.AddInt32(&fails, 1)
+						}
+						return resp
+					}
+				}
+				return handle(kreq)
+			}, append([]Opt{
+				DefaultProduceTopic("foo"),
+				MetadataMinAge(10 * time.Millisecond),
+				UnavailablePartitions(FailWithoutLeader()),
+			}, test.opts...)...)
+			defer cl.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := cl.ProduceSync(ctx, StringRecord("v")).FirstErr(); err != nil {
+				t.Fatalf("unable to produce: %v", err)
+			}
+			if n := atomic.LoadInt32(&fails); n != 3 {
+				t.Errorf("got %d transient failures, exp 3", n)
+			}
+		})
+	}
+}
+
+// A record failing while its topic's partitions are first loaded fails under
+// the producer's unknown topic lock; dead lettering it to a topic that is
+// also unknown must not deadlock.
+func TestDeadLetterTopicFromPartitioning(t *testing.T) {
+	t.Parallel()
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				p := kmsg.NewMetadataResponseTopicPartition()
+				p.Replicas = []int32{0}
+				p.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, p)
+			}
+			return resp
+		case *kmsg.InitProducerIDRequest:
+			return req.ResponseKind()
+		case *kmsg.ProduceRequest:
+			resp := req.ResponseKind().(*kmsg.ProduceResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewProduceResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewProduceResponseTopicPartition()
+					sp.Partition = rp.Partition
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		DeadLetterTopic("dlq"),
+		RecordPartitioner(BasicConsistentPartitioner(func(topic string) func(*Record, int) int {
+			return func(*Record, int) int {
+				if topic == "a" {
+					return -1
+				}
+				return 0
+			}
+		})),
+	)
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := cl.ProduceSync(ctx, &Record{Topic: "a", Value: []byte("v")}).First()
+	var dl *ErrDeadLettered
+	if !errors.As(err, &dl) || dl.DeadLetter.Topic != "dlq" {
+		t.Fatalf("got err %v, exp the record dead lettered to dlq", err)
+	}
+}
+
+// Records partitioned beyond the known partitions queue in order behind a
+// single metadata refresh, and aborting fails them without waiting for it.
+func TestProducePartitionBeyondKnownQueuesInOrder(t *testing.T) {
+	t.Parallel()
+	var (
+		metas   int32
+		release = make(chan struct{})
+	)
+	handle := fakeProduceHandler(t, func() int16 { return 0 })
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		kresp := handle(kreq)
+		if resp, ok := kresp.(*kmsg.MetadataResponse); ok && atomic.AddInt32(&metas, 1) > 1 {
+			<-release // hold the refresh until records are queued
+			growFakePartitions(resp, 2)
+		}
+		return kresp
+	},
+		RecordPartitioner(ManualPartitioner()),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	if err := cl.ProduceSync(context.Background(), &Record{Topic: "foo", Partition: 0}).FirstErr(); err != nil {
+		t.Fatalf("unable to produce to known partition: %v", err)
+	}
+
+	// While the refresh is held, aborting fails queued records rather
+	// than waiting for the refresh.
+	var aborted int32
+	cl.Produce(context.Background(), &Record{Topic: "foo", Partition: 1}, func(_ *Record, err error) {
+		if errors.Is(err, ErrAborting) {
+			atomic.AddInt32(&aborted, 1)
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := cl.AbortBufferedRecords(ctx); err != nil {
+		t.Fatalf("unable to abort records queued for repartitioning: %v", err)
+	}
+	if atomic.LoadInt32(&aborted) != 1 {
+		t.Fatal("queued record was not failed with ErrAborting")
+	}
+
+	const n = 50
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+	for i := 0; i < n; i++ {
+		i := i
+		cl.Produce(context.Background(), &Record{Topic: "foo", Partition: 1}, func(r *Record, err error) {
+			if err != nil {
+				t.Errorf("record %d: unexpected err: %v", i, err)
+			}
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+		})
+	}
+	close(release)
+	if err := cl.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("records finished out of order: %v", got)
+		}
+	}
+	if len(got) != n {
+		t.Errorf("got %d finished records, exp %d", len(got), n)
+	}
+}
@@ -97,16 +97,19 @@ type cursor struct {
 	partition int32
 
 	keepControl bool // whether to keep control records
+	lazy        bool // whether to keep record batches undecoded
 
 	cursorsIdx int // updated under source mutex
 
-	// The source we are currently on. This is modified in two scenarios:
+	// The source we are currently on. This is modified in three scenarios:
 	//
 	//  * by metadata when the consumer session is completely stopped
 	//
 	//  * by a fetch when handling a fetch response that returned preferred
 	//  replicas
 	//
+	//  * by unsetting the cursor, which moves it back to the leader
+	//
 	// This is additionally read within a session when cursor is
 	// transitioning from used to usable.
 	source *source
@@ -173,6 +176,28 @@ func (c *cursor) unset() {
 		offset:            -1,
 		lastConsumedEpoch: -1,
 	})
+	c.moveToLeader()
+}
+
+// moveToLeader moves a cursor that was moved to a preferred read replica back
+// to the partition leader. A preferred replica is chosen for the rack of the
+// consumer that fetched; if the partition is reassigned, whoever consumes it
+// next must fetch from the leader to learn its own preferred replica. This is
+// called exclusively after sources are stopped.
+func (c *cursor) moveToLeader() {
+	if c.source.nodeID == c.leader {
+		return
+	}
+	cl := c.source.cl
+	cl.sinksAndSourcesMu.Lock()
+	sns, exists := cl.sinksAndSources[c.leader]
+	cl.sinksAndSourcesMu.Unlock()
+	if !exists {
+		return // metadata always creates the leader source; this is defensive
+	}
+	c.source.removeCursor(c)
+	c.source = sns.source
+	c.source.addCursor(c)
 }
 
 // usable returns whether a cursor can be used for building a fetch request.
@@ -280,6 +305,7 @@ type bufferedFetch struct {
 
 	doneFetch   chan<- struct{} // when unbuffered, we send down this
 	usedOffsets usedOffsets     // what the offsets will be next if this fetch is used
+	bytes       int64           // raw record batch bytes, released when unbuffered
 }
 
 func (s *source) hook(f *Fetch, buffered, polled bool) {
@@ -319,7 +345,11 @@ func (s *source) hook(f *Fetch, buffered, polled bool) {
 	for i := range f.Topics {
 		t := &f.Topics[i]
 		for j := range t.Partitions {
-			nrecs += len(t.Partitions[j].Records)
+			p := &t.Partitions[j]
+			nrecs += len(p.Records)
+			for _, b := range p.LazyBatches {
+				nrecs += int(b.NumRecords)
+			}
 		}
 	}
 	if buffered {
@@ -366,29 +396,47 @@ func (s *source) takeNBuffered(n int) (Fetch, int, bool) {
 			rt.Partitions = append(rt.Partitions, *p)
 			rp := &rt.Partitions[len(rt.Partitions)-1]
 
+			// We do not split lazy batches; if the partition has any,
+			// we take the entire partition.
 			take := n
-			if take > len(p.Records) {
+			if take > len(p.Records) || len(p.LazyBatches) > 0 {
 				take = len(p.Records)
 			}
 
 			rp.Records = p.Records[:take:take]
 			p.Records = p.Records[take:]
+			if len(p.Records) > 0 {
+				// Records left behind still reference the
+				// partition's decompressed buffers; the last
+				// take of the partition carries them.
+				rp.decompressed = nil
+			}
+			for _, b := range p.LazyBatches {
+				take += int(b.NumRecords)
+			}
+			p.LazyBatches = nil
 
 			n -= take
 			taken += take
 
-			pCursor := tCursors[p.Partition]
+			pCursor, tracked := tCursors[p.Partition]
 
 			if len(p.Records) == 0 {
 				t.Partitions = t.Partitions[1:]
 
+				// A partition redirected to a preferred replica
+				// has no records and is not tracked: its cursor
+				// already moved to the replica's source.
+				if !tracked {
+					continue
+				}
 				pCursor.from.setOffset(pCursor.cursorOffset)
 				pCursor.from.allowUsable()
 				delete(tCursors, p.Partition)
 				if len(tCursors) == 0 {
 					delete(b.usedOffsets, t.Topic)
 				}
-				break
+				continue
 			}
 
 			lastReturnedRecord := rp.Records[len(rp.Records)-1]
@@ -416,6 +464,7 @@ func (s *source) takeBufferedFn(polled bool, offsetFn func(usedOffsets)) Fetch {
 	r := s.buffered
 	s.buffered = bufferedFetch{}
 	offsetFn(r.usedOffsets)
+	atomic.AddInt64(&s.cl.consumer.bufferedBytes, -r.bytes)
 	r.doneFetch <- struct{}{}
 	close(s.sem)
 
@@ -721,11 +770,23 @@ func (s *source) fetch(consumerSession *consumerSession, doneFetch chan<- struct
 	}
 
 	if fetch.hasErrorsOrRecords() {
+		var bytes int64
+		for i := range resp.Topics {
+			for j := range resp.Topics[i].Partitions {
+				bytes += int64(len(resp.Topics[i].Partitions[j].RecordBatches))
+			}
+		}
+		if max := s.cl.cfg.maxBytesTotal; max > 0 && bytes > max {
+			s.cl.cfg.logger.Log(LogLevelWarn, "buffering fetch that is larger than the fetch max bytes total, consuming may use more memory than configured", "broker", logID(s.nodeID), "bytes", bytes, "fetch_max_bytes_total", max)
+		}
+		atomic.AddInt64(&s.cl.consumer.bufferedBytes, bytes)
+
 		buffered = true
 		s.buffered = bufferedFetch{
 			fetch:       fetch,
 			doneFetch:   doneFetch,
 			usedOffsets: req.usedOffsets,
+			bytes:       bytes,
 		}
 		s.sem = make(chan struct{})
 		s.hook(&fetch, true, false) // buffered, not polled
@@ -750,6 +811,7 @@ func (s *source) handleReqResp(br *broker, req *fetchRequest, resp *kmsg.FetchRe
 		preferreds    []cursorOffsetPreferred
 		updateMeta    bool
 		updateWhy     multiUpdateWhy
+		decodes       []decodeTopic
 
 		kip320 = s.cl.supportsOffsetForLeaderEpoch()
 	)
@@ -774,10 +836,7 @@ func (s *source) handleReqResp(br *broker, req *fetchRequest, resp *kmsg.FetchRe
 			continue
 		}
 
-		fetchTopic := FetchTopic{
-			Topic:      topic,
-			Partitions: make([]FetchPartition, 0, len(rt.Partitions)),
-		}
+		dt := decodeTopic{topic: topic}
 
 		for i := range rt.Partitions {
 			rp := &rt.Partitions[i]
@@ -795,15 +854,49 @@ func (s *source) handleReqResp(br *broker, req *fetchRequest, resp *kmsg.FetchRe
 			// If we are fetching from the replica already, Kafka replies with a -1
 			// preferred read replica. If Kafka replies with a preferred replica,
 			// it sends no records.
+			//
+			// We still return the partition so that users can see the
+			// watermarks and the redirect.
 			if preferred := rp.PreferredReadReplica; resp.Version >= 11 && preferred >= 0 {
 				preferreds = append(preferreds, cursorOffsetPreferred{
 					*partOffset,
 					preferred,
 				})
+				dt.partitions = append(dt.partitions, decodePartition{
+					partOffset: partOffset,
+					rp:         rp,
+					fp:         newFetchPartition(resp.Version, rp),
+					redirected: true,
+				})
 				continue
 			}
 
-			fp := partOffset.processRespPartition(br, resp.Version, rp, s.cl.decompressor, s.cl.cfg.hooks)
+			dt.partitions = append(dt.partitions, decodePartition{
+				partOffset: partOffset,
+				rp:         rp,
+			})
+		}
+		if len(dt.partitions) > 0 {
+			decodes = append(decodes, dt)
+		}
+	}
+
+	// Decoding (and decompressing) is the expensive part of handling a
+	// response. We decode all partitions before handling any errors so
+	// that decoding can be spread across goroutines.
+	s.decodePartitions(br, resp.Version, decodes)
+
+	for _, dt := range decodes {
+		topic := dt.topic
+		fetchTopic := FetchTopic{
+			Topic:      topic,
+			Partitions: make([]FetchPartition, 0, len(dt.partitions)),
+		}
+
+		for i := range dt.partitions {
+			fp := dt.partitions[i].fp
+			partition := fp.Partition
+			partOffset := dt.partitions[i].partOffset
 			if fp.Err != nil {
 				updateMeta = true
 				updateWhy.add(topic, partition, fp.Err)
@@ -915,16 +1008,94 @@ func (s *source) handleReqResp(br *broker, req *fetchRequest, resp *kmsg.FetchRe
 	return f, reloadOffsets, preferreds, updateMeta, updateWhy.reason("fetch had inner topic errors")
 }
 
+// decodeTopic and decodePartition track partitions of a fetch response to be
+// decoded, and the decoded result.
+type (
+	decodeTopic struct {
+		topic      string
+		partitions []decodePartition
+	}
+	decodePartition struct {
+		partOffset *cursorOffsetNext
+		rp         *kmsg.FetchResponseTopicPartition
+		fp         FetchPartition
+		redirected bool // if true, fp is already set and there is nothing to decode
+	}
+)
+
+// decodePartitions decodes all partitions in the fetch response. If the client
+// is configured with MaxConcurrentDecodes, partitions are decoded
+// concurrently, limited client wide; otherwise, partitions are decoded
+// serially in this goroutine. Every partition is decoded by a single
+// goroutine, so records within a partition remain in order.
+func (s *source) decodePartitions(br *broker, version int16, decodes []decodeTopic) {
+	decode := func(d *decodePartition) {
+		if d.redirected {
+			return
+		}
+		d.fp = d.partOffset.processRespPartition(br, version, d.rp, s.cl.decompressor, s.cl.cfg.hooks)
+	}
+	sem := s.cl.decodeSem
+	if sem == nil {
+		for i := range decodes {
+			for j := range decodes[i].partitions {
+				decode(&decodes[i].partitions[j])
+			}
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range decodes {
+		for j := range decodes[i].partitions {
+			d := &decodes[i].partitions[j]
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				decode(d)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// newFetchPartition returns a FetchPartition with no records for the given
+// response partition, defaulting fields the response version does not have.
+func newFetchPartition(version int16, rp *kmsg.FetchResponseTopicPartition) FetchPartition {
+	fp := FetchPartition{
+		Partition:            rp.Partition,
+		Err:                  kerr.ErrorForCode(rp.ErrorCode),
+		HighWatermark:        rp.HighWatermark,
+		LastStableOffset:     rp.LastStableOffset,
+		LogStartOffset:       rp.LogStartOffset,
+		PreferredReadReplica: -1,
+	}
+	if version < 4 {
+		fp.LastStableOffset = fp.HighWatermark // no transactions, everything is stable
+	}
+	if version < 5 {
+		fp.LogStartOffset = -1
+	}
+	if version >= 11 {
+		fp.PreferredReadReplica = rp.PreferredReadReplica
+	}
+	if len(rp.AbortedTransactions) > 0 {
+		fp.AbortedTransactions = make([]FetchAbortedTransaction, 0, len(rp.AbortedTransactions))
+		for _, abort := range rp.AbortedTransactions {
+			fp.AbortedTransactions = append(fp.AbortedTransactions, FetchAbortedTransaction{
+				ProducerID:  abort.ProducerID,
+				FirstOffset: abort.FirstOffset,
+			})
+		}
+	}
+	return fp
+}
+
 // processRespPartition processes all records in all potentially compressed
 // batches (or message sets).
 func (o *cursorOffsetNext) processRespPartition(br *broker, version int16, rp *kmsg.FetchResponseTopicPartition, decompressor *decompressor, hooks hooks) FetchPartition {
-	fp := FetchPartition{
-		Partition:        rp.Partition,
-		Err:              kerr.ErrorForCode(rp.ErrorCode),
-		HighWatermark:    rp.HighWatermark,
-		LastStableOffset: rp.LastStableOffset,
-		LogStartOffset:   rp.LogStartOffset,
-	}
+	fp := newFetchPartition(version, rp)
 
 	aborter := buildAborter(rp)
 
@@ -951,21 +1122,33 @@ func (o *cursorOffsetNext) processRespPartition(br *broker, version int16, rp *k
 		crcTable    *crc32.Table
 		crcAt       int
 
-		check = func() bool {
+		corrupt = func(offset int64, err error) bool {
+			fp.Err = &ErrCorruptBatch{
+				Topic:     o.from.topic,
+				Partition: o.from.partition,
+				Offset:    offset,
+				Err:       err,
+			}
+			hooks.each(func(h Hook) {
+				if h, ok := h.(HookFetchBatchCorrupt); ok {
+					h.OnFetchBatchCorrupt(br.meta, o.from.topic, o.from.partition, offset, err)
+				}
+			})
+			return false
+		}
+
+		check = func(offset int64) bool {
 			// If we call into check, we know we have a valid
 			// length, so we should be at least able to parse our
 			// top level struct and validate the length and CRC.
 			if err := r.ReadFrom(in[:length]); err != nil {
-				fp.Err = fmt.Errorf("unable to read %s, not enough data", kind)
-				return false
+				return corrupt(offset, fmt.Errorf("unable to read %s, not enough data", kind))
 			}
 			if length := int32(len(in[12:length])); length != *lengthField {
-				fp.Err = fmt.Errorf("encoded length %d does not match read length %d", *lengthField, length)
-				return false
+				return corrupt(offset, fmt.Errorf("encoded length %d does not match read length %d", *lengthField, length))
 			}
 			if crcCalc := int32(crc32.Checksum(in[crcAt:length], crcTable)); crcCalc != *crcField {
-				fp.Err = fmt.Errorf("encoded crc %x does not match calculated crc %x", *crcField, crcCalc)
-				return false
+				return corrupt(offset, fmt.Errorf("encoded crc %x does not match calculated crc %x", *crcField, crcCalc))
 			}
 			return true
 		}
@@ -1013,7 +1196,7 @@ func (o *cursorOffsetNext) processRespPartition(br *broker, version int16, rp *k
 			return fp
 		}
 
-		if !check() {
+		if !check(offset) {
 			break
 		}
 
@@ -1035,7 +1218,11 @@ func (o *cursorOffsetNext) processRespPartition(br *broker, version int16, rp *k
 		case *kmsg.RecordBatch:
 			m.CompressedBytes = len(t.Records) // for record batches, we only track the record batch length
 			m.CompressionType = uint8(t.Attributes) & 0b0000_0111
-			m.NumRecords, m.UncompressedBytes = o.processRecordBatch(&fp, t, aborter, decompressor)
+			if o.from.lazy {
+				m.NumRecords, m.UncompressedBytes = o.processLazyRecordBatch(&fp, t, aborter, decompressor)
+			} else {
+				m.NumRecords, m.UncompressedBytes = o.processRecordBatch(&fp, t, aborter, decompressor)
+			}
 		}
 
 		if m.UncompressedBytes == 0 {
@@ -1051,6 +1238,45 @@ func (o *cursorOffsetNext) processRespPartition(br *broker, version int16, rp *k
 	return fp
 }
 
+var partitionRecordsDecompressor = newDecompressor()
+
+// PartitionRecords decodes the records in a raw fetch response partition into
+// a FetchPartition, for use when issuing fetch requests directly rather than
+// consuming with the client.
+//
+// A partition can contain v0 or v1 message sets, v2 record batches, or a mix
+// of them; the format of each entry is detected from its magic byte. Compressed
+// messages and batches are decompressed, record offsets are absolute, and
+// LogAppendTime timestamps are applied. Records before fetchOffset, which
+// Kafka can return when the requested offset is in the middle of a batch, are
+// skipped. Control records and records from aborted transactions are dropped.
+//
+// A partial trailing message or batch, which Kafka returns when a fetch
+// reaches its byte limits, is ignored. The returned partition's Err is set if
+// the partition had an error code or if data is corrupt; records decoded
+// before corrupt data are still returned.
+//
+// The version must be the version of the fetch response that rp is from; it
+// determines which of the partition's offset fields are valid, and the
+// returned partition defaults those that are not (as the client does when
+// consuming).
+func PartitionRecords(topic string, fetchOffset int64, version int16, rp *kmsg.FetchResponseTopicPartition) FetchPartition {
+	fp, _ := partitionRecords(topic, fetchOffset, version, rp)
+	return fp
+}
+
+// partitionRecords is PartitionRecords, but also returns the offset to fetch
+// next, which can be past the last returned record if trailing records were
+// skipped (control records, aborted records, or compacted offsets).
+func partitionRecords(topic string, fetchOffset int64, version int16, rp *kmsg.FetchResponseTopicPartition) (FetchPartition, int64) {
+	o := cursorOffsetNext{
+		cursorOffset: cursorOffset{offset: fetchOffset},
+		from:         &cursor{topic: topic, partition: rp.Partition},
+	}
+	fp := o.processRespPartition(nil, version, rp, partitionRecordsDecompressor, nil)
+	return fp, o.offset
+}
+
 type aborter map[int64][]int64
 
 func buildAborter(rp *kmsg.FetchResponseTopicPartition) aborter {
@@ -1129,12 +1355,29 @@ func (o *cursorOffsetNext) processRecordBatch(
 		return 0, 0
 	}
 
+	// Compressed batches are decompressed into a pooled buffer. If we
+	// keep any record, the records reference the buffer and it is
+	// returned to the pool only once the user releases the fetch.
 	rawRecords := batch.Records
+	var pooled *[]byte
 	if compression := byte(batch.Attributes & 0x0007); compression != 0 {
+		pooled = decompressBufs.Get().(*[]byte)
 		var err error
-		if rawRecords, err = decompressor.decompress(rawRecords, compression); err != nil {
+		if rawRecords, err = decompressor.decompressTo(*pooled, rawRecords, compression); err != nil {
+			decompressBufs.Put(pooled)
 			return 0, 0 // truncated batch
 		}
+		*pooled = rawRecords
+	}
+	if pooled != nil {
+		kept := len(fp.Records)
+		defer func() {
+			if len(fp.Records) > kept {
+				fp.decompressed = append(fp.decompressed, pooled)
+			} else {
+				decompressBufs.Put(pooled)
+			}
+		}()
 	}
 
 	uncompressedBytes := len(rawRecords)
@@ -1182,6 +1425,46 @@ func (o *cursorOffsetNext) processRecordBatch(
 	return len(krecords), uncompressedBytes
 }
 
+// processLazyRecordBatch keeps a record batch undecoded, advancing past it.
+// The uncompressed size of a lazy batch is unknown, so this returns 0 for it.
+func (o *cursorOffsetNext) processLazyRecordBatch(
+	fp *FetchPartition,
+	batch *kmsg.RecordBatch,
+	aborter aborter,
+	decompressor *decompressor,
+) (int, int) {
+	// Control batches are small, are not returned, and must be decoded
+	// to track aborted transactions, so we always process them eagerly.
+	if batch.Magic != 2 || batch.Attributes&0b0010_0000 != 0 {
+		return o.processRecordBatch(fp, batch, aborter, decompressor)
+	}
+	lastOffset := batch.FirstOffset + int64(batch.LastOffsetDelta)
+	if lastOffset < o.offset {
+		return 0, 0
+	}
+	if !aborter.shouldAbortBatch(batch) {
+		fp.LazyBatches = append(fp.LazyBatches, &LazyRecordBatch{
+			Topic:         o.from.topic,
+			Partition:     fp.Partition,
+			FirstOffset:   batch.FirstOffset,
+			LastOffset:    lastOffset,
+			NumRecords:    batch.NumRecords,
+			LeaderEpoch:   batch.PartitionLeaderEpoch,
+			ProducerID:    batch.ProducerID,
+			ProducerEpoch: batch.ProducerEpoch,
+			FirstSequence: batch.FirstSequence,
+			Attrs:         RecordAttrs{uint8(batch.Attributes)},
+
+			batch:        batch,
+			minOffset:    o.offset,
+			decompressor: decompressor,
+		})
+	}
+	o.offset = lastOffset + 1
+	o.lastConsumedEpoch = batch.PartitionLeaderEpoch
+	return int(batch.NumRecords), 0
+}
+
 // Processes an outer v1 message. There could be no inner message, which makes
 // this easy, but if not, we decompress and process each inner message as
 // either v0 or v1. We only expect the inner message to be v1, but technically
@@ -1270,6 +1553,12 @@ out:
 		case *kmsg.MessageV1:
 			innerMessage.Offset = firstOffset + int64(i)
 			innerMessage.Attributes |= int8(compression)
+			// With LogAppendTime, Kafka only sets the timestamp on
+			// the outer message; inner messages use it as well.
+			if message.Attributes&0b1000 != 0 {
+				innerMessage.Attributes |= 0b1000
+				innerMessage.Timestamp = message.Timestamp
+			}
 			if !o.processV1Message(fp, innerMessage) {
 				return i, uncompressedBytes
 			}
@@ -1411,19 +1700,32 @@ func recordToRecord(
 	batch *kmsg.RecordBatch,
 	record *kmsg.Record,
 ) *Record {
-	h := make([]RecordHeader, 0, len(record.Headers))
-	for _, kv := range record.Headers {
-		h = append(h, RecordHeader{
-			Key:   kv.Key,
-			Value: kv.Value,
-		})
+	// The record format has no null headers array, so a record without
+	// headers is always decoded with nil headers.
+	var h []RecordHeader
+	if len(record.Headers) > 0 {
+		h = make([]RecordHeader, 0, len(record.Headers))
+		for _, kv := range record.Headers {
+			h = append(h, RecordHeader{
+				Key:   kv.Key,
+				Value: kv.Value,
+			})
+		}
+	}
+
+	// With LogAppendTime, Kafka sets only the batch's MaxTimestamp to the
+	// time it appended the batch, and record timestamp deltas are left
+	// as the producer wrote them.
+	timestamp := batch.FirstTimestamp + int64(record.TimestampDelta)
+	if batch.Attributes&0b1000 != 0 {
+		timestamp = batch.MaxTimestamp
 	}
 
 	return &Record{
 		Key:           record.Key,
 		Value:         record.Value,
 		Headers:       h,
-		Timestamp:     timeFromMillis(batch.FirstTimestamp + int64(record.TimestampDelta)),
+		Timestamp:     timeFromMillis(timestamp),
 		Topic:         topic,
 		Partition:     partition,
 		Attrs:         RecordAttrs{uint8(batch.Attributes)},
@@ -1431,6 +1733,9 @@ func recordToRecord(
 		ProducerEpoch: batch.ProducerEpoch,
 		LeaderEpoch:   batch.PartitionLeaderEpoch,
 		Offset:        batch.FirstOffset + int64(record.OffsetDelta),
+
+		BatchFirstOffset:   batch.FirstOffset,
+		BatchFirstSequence: batch.FirstSequence,
 	}
 }
 
@@ -1457,6 +1762,9 @@ func v0MessageToRecord(
 		ProducerEpoch: -1,
 		LeaderEpoch:   -1,
 		Offset:        message.Offset,
+
+		BatchFirstOffset:   message.Offset,
+		BatchFirstSequence: -1,
 	}
 }
 
@@ -1476,6 +1784,9 @@ func v1MessageToRecord(
 		ProducerEpoch: -1,
 		LeaderEpoch:   -1,
 		Offset:        message.Offset,
+
+		BatchFirstOffset:   message.Offset,
+		BatchFirstSequence: -1,
 	}
 }
 
@@ -1545,6 +1856,15 @@ func (f *fetchRequest) AppendTo(dst []byte) []byte {
 	req.SessionEpoch = f.session.epoch
 	req.Rack = f.rack
 
+	// A request at epoch 0 is a full fetch request that creates a new
+	// session, so it must contain every partition and forget nothing. The
+	// session may still have partitions from a prior request if the broker
+	// replied without creating a session (for example, because its session
+	// cache is full), so we clear them.
+	if f.session.epoch == 0 {
+		f.session.used = nil
+	}
+
 	// We track which partitions we add in this request; any partitions
 	// missing that are already in the session get added to forgotten
 	// topics at the end.
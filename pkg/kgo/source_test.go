@@ -0,0 +1,1355 @@
+package kgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestTakeNBuffered(t *testing.T) {
+	cl, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	s := cl.newSource(1)
+	s.sem = make(chan struct{})
+	c0 := &cursor{topic: "a", partition: 0, source: s}
+	c1 := &cursor{topic: "a", partition: 1, source: s}
+
+	records := func(p int32, offsets ...int64) []*Record {
+		var rs []*Record
+		for _, o := range offsets {
+			rs = append(rs, &Record{Topic: "a", Partition: p, Offset: o, LeaderEpoch: 2})
+		}
+		return rs
+	}
+	s.buffered = bufferedFetch{
+		fetch: Fetch{Topics: []FetchTopic{{
+			Topic: "a",
+			Partitions: []FetchPartition{
+				{Partition: 0, Records: records(0, 0, 1, 2)},
+				{Partition: 1, Records: records(1, 10, 11), decompressed: []*[]byte{new([]byte)}},
+			},
+		}}},
+		doneFetch: make(chan struct{}, 1),
+		usedOffsets: usedOffsets{"a": {
+			0: {cursorOffset: cursorOffset{offset: 3, lastConsumedEpoch: 2}, from: c0},
+			1: {cursorOffset: cursorOffset{offset: 12, lastConsumedEpoch: 2}, from: c1},
+		}},
+	}
+
+	// Taking four records drains partition 0 and takes one record from
+	// partition 1. Both partitions must be returned in the same topic, and
+	// partition 1 must only advance past the record we returned.
+	f, taken, drained := s.takeNBuffered(4)
+	if taken != 4 || drained {
+		t.Fatalf("got taken %d, drained %v; exp 4, false", taken, drained)
+	}
+	if len(f.Topics) != 1 || len(f.Topics[0].Partitions) != 2 {
+		t.Fatalf("got %d topics, exp 1 topic with 2 partitions", len(f.Topics))
+	}
+	if ps := f.Topics[0].Partitions; len(ps[0].Records) != 3 || len(ps[1].Records) != 1 || ps[1].Records[0].Offset != 10 {
+		t.Fatalf("unexpected partitions returned: %v", ps)
+	}
+	if c0.offset != 3 || c1.offset != 11 || c1.lastConsumedEpoch != 2 {
+		t.Fatalf("got cursor offsets %d and %d, exp 3 and 11", c0.offset, c1.offset)
+	}
+	// Records left in partition 1 still use its decompressed buffer,
+	// which must stay with the partition until its last take.
+	if n := len(f.Topics[0].Partitions[1].decompressed); n != 0 {
+		t.Fatalf("got %d decompressed buffers in a partial take, exp 0", n)
+	}
+
+	f, taken, drained = s.takeNBuffered(10)
+	if taken != 1 || !drained {
+		t.Fatalf("got taken %d, drained %v; exp 1, true", taken, drained)
+	}
+	if rs := f.Topics[0].Partitions[0].Records; len(rs) != 1 || rs[0].Offset != 11 {
+		t.Fatalf("unexpected records returned: %v", rs)
+	}
+	if n := len(f.Topics[0].Partitions[0].decompressed); n != 1 {
+		t.Fatalf("got %d decompressed buffers in the last take, exp 1", n)
+	}
+	if c1.offset != 12 {
+		t.Fatalf("got cursor offset %d, exp 12", c1.offset)
+	}
+}
+
+// appendMessage appends a message set message, fixing its size and CRC.
+func appendMessage(dst []byte, m interface{ AppendTo([]byte) []byte }) []byte {
+	start := len(dst)
+	dst = m.AppendTo(dst)
+	msg := dst[start:]
+	binary.BigEndian.PutUint32(msg[8:], uint32(len(msg)-12))
+	binary.BigEndian.PutUint32(msg[12:], crc32.ChecksumIEEE(msg[16:]))
+	return dst
+}
+
+func TestPartitionRecords(t *testing.T) {
+	t.Parallel()
+	var raw []byte
+	raw = appendMessage(raw, &kmsg.MessageV0{Offset: 0, Value: []byte("v0 skipped")})
+	raw = appendMessage(raw, &kmsg.MessageV0{Offset: 1, Value: []byte("v0")})
+	raw = appendMessage(raw, &kmsg.MessageV1{Offset: 2, Magic: 1, Timestamp: 1000, Value: []byte("v1")})
+
+	batch := kmsg.RecordBatch{
+		FirstOffset:          3,
+		PartitionLeaderEpoch: 4,
+		Magic:                2,
+		FirstTimestamp:       2000,
+		ProducerID:           -1,
+		ProducerEpoch:        -1,
+		FirstSequence:        -1,
+	}
+	batch.SetRecords([]kmsg.Record{
+		{OffsetDelta: 0, TimestampDelta: 1, Value: []byte("v2 a")},
+		{OffsetDelta: 1, TimestampDelta: 2, Value: []byte("v2 b")},
+	})
+	raw = batch.AppendToRecomputed(raw)
+
+	// A partial trailing batch is ignored.
+	partial := batch
+	partial.FirstOffset = 5
+	raw = append(raw, partial.AppendToRecomputed(nil)[:30]...)
+
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.Partition = 3
+	rp.HighWatermark = 10
+	rp.LastStableOffset = 8
+	rp.LogStartOffset = 1
+	rp.PreferredReadReplica = 2
+	rp.RecordBatches = raw
+
+	// Offset fields are kept or defaulted per the response version.
+	if fp := PartitionRecords("t", 1, 3, &rp); fp.LastStableOffset != 10 || fp.LogStartOffset != -1 || fp.PreferredReadReplica != -1 {
+		t.Errorf("v3: got last stable %d, log start %d, read replica %d, exp 10, -1, -1", fp.LastStableOffset, fp.LogStartOffset, fp.PreferredReadReplica)
+	}
+	fp := PartitionRecords("t", 1, 11, &rp)
+	if fp.Err != nil {
+		t.Fatalf("unexpected err: %v", fp.Err)
+	}
+	if fp.HighWatermark != 10 || fp.LastStableOffset != 8 || fp.LogStartOffset != 1 || fp.PreferredReadReplica != 2 {
+		t.Errorf("v11: got high watermark %d, last stable %d, log start %d, read replica %d, exp 10, 8, 1, 2", fp.HighWatermark, fp.LastStableOffset, fp.LogStartOffset, fp.PreferredReadReplica)
+	}
+	exp := []struct {
+		value  string
+		offset int64
+		ts     int64
+		tsType int8
+	}{
+		{"v0", 1, 0, -1},
+		{"v1", 2, 1000, 0},
+		{"v2 a", 3, 2001, 0},
+		{"v2 b", 4, 2002, 0},
+	}
+	if len(fp.Records) != len(exp) {
+		t.Fatalf("got %d records != exp %d", len(fp.Records), len(exp))
+	}
+	for i, r := range fp.Records {
+		e := exp[i]
+		if string(r.Value) != e.value || r.Offset != e.offset || r.Topic != "t" || r.Partition != 3 || r.Attrs.TimestampType() != e.tsType {
+			t.Errorf("record %d: got %q@%d (%s/%d, ts type %d), exp %q@%d", i, r.Value, r.Offset, r.Topic, r.Partition, r.Attrs.TimestampType(), e.value, e.offset)
+		}
+		if e.tsType >= 0 && r.Timestamp.UnixNano()/1e6 != e.ts {
+			t.Errorf("record %d: got timestamp %d != exp %d", i, r.Timestamp.UnixNano()/1e6, e.ts)
+		}
+	}
+}
+
+func TestPartitionRecordsNextOffset(t *testing.T) {
+	t.Parallel()
+	batch := kmsg.RecordBatch{
+		FirstOffset:   3,
+		Magic:         2,
+		ProducerID:    -1,
+		ProducerEpoch: -1,
+		FirstSequence: -1,
+	}
+	batch.SetRecords([]kmsg.Record{
+		{OffsetDelta: 0, Value: []byte("a")},
+		{OffsetDelta: 1, Value: []byte("b")},
+	})
+	raw := batch.AppendToRecomputed(nil)
+
+	// A trailing control batch is skipped, but still advances the next
+	// offset to fetch so that we do not refetch it forever.
+	control := batch
+	control.FirstOffset = 5
+	control.Attributes = 0x20
+	control.SetRecords([]kmsg.Record{{OffsetDelta: 0, Key: []byte{0, 0, 0, 1}}})
+	raw = control.AppendToRecomputed(raw)
+
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.RecordBatches = raw
+
+	fp, next := partitionRecords("t", 3, 11, &rp)
+	if fp.Err != nil {
+		t.Fatalf("unexpected err: %v", fp.Err)
+	}
+	if len(fp.Records) != 2 {
+		t.Fatalf("got %d records != exp 2", len(fp.Records))
+	}
+	if next != 6 {
+		t.Errorf("got next offset %d != exp 6", next)
+	}
+}
+
+// Consumed records carry the batch they came from, and control batches are
+// identifiable when kept.
+func TestRecordBatchContext(t *testing.T) {
+	t.Parallel()
+	var raw []byte
+	raw = appendMessage(raw, &kmsg.MessageV1{Offset: 0, Magic: 1, Value: []byte("v1")})
+
+	batch := kmsg.RecordBatch{
+		FirstOffset:          1,
+		PartitionLeaderEpoch: 5,
+		Magic:                2,
+		Attributes:           0x10, // transactional
+		ProducerID:           7,
+		ProducerEpoch:        2,
+		FirstSequence:        math.MaxInt32 - 1,
+	}
+	batch.SetRecords([]kmsg.Record{
+		{OffsetDelta: 0, Value: []byte("a")},
+		{OffsetDelta: 1, Value: []byte("b")},
+		{OffsetDelta: 2, Value: []byte("c")},
+	})
+	raw = batch.AppendToRecomputed(raw)
+
+	control := batch
+	control.FirstOffset = 4
+	control.PartitionLeaderEpoch = 6
+	control.Attributes = 0x30 // transactional, control
+	control.FirstSequence = -1
+	control.SetRecords([]kmsg.Record{{OffsetDelta: 0, Key: []byte{0, 0, 0, 1}}})
+	raw = control.AppendToRecomputed(raw)
+
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.RecordBatches = raw
+
+	o := cursorOffsetNext{from: &cursor{topic: "t", keepControl: true}}
+	fp := o.processRespPartition(nil, 0, &rp, partitionRecordsDecompressor, nil)
+	if fp.Err != nil {
+		t.Fatalf("unexpected err: %v", fp.Err)
+	}
+
+	exp := []struct {
+		firstOffset int64
+		firstSeq    int32
+		seq         int32
+		txn         bool
+		control     bool
+		epoch       int32
+	}{
+		{0, -1, -1, false, false, -1},
+		{1, math.MaxInt32 - 1, math.MaxInt32 - 1, true, false, 5},
+		{1, math.MaxInt32 - 1, math.MaxInt32, true, false, 5},
+		{1, math.MaxInt32 - 1, 0, true, false, 5},
+		{4, -1, -1, true, true, 6},
+	}
+	if len(fp.Records) != len(exp) {
+		t.Fatalf("got %d records != exp %d", len(fp.Records), len(exp))
+	}
+	for i, r := range fp.Records {
+		e := exp[i]
+		if r.BatchFirstOffset != e.firstOffset || r.BatchFirstSequence != e.firstSeq || r.Sequence() != e.seq {
+			t.Errorf("record %d: got batch first offset %d, first sequence %d, sequence %d, exp %d, %d, %d",
+				i, r.BatchFirstOffset, r.BatchFirstSequence, r.Sequence(), e.firstOffset, e.firstSeq, e.seq)
+		}
+		if r.LeaderEpoch != e.epoch {
+			t.Errorf("record %d: got leader epoch %d, exp %d", i, r.LeaderEpoch, e.epoch)
+		}
+		if r.Attrs.IsTransactional() != e.txn || r.Attrs.IsControl() != e.control {
+			t.Errorf("record %d: got transactional %v control %v, exp %v %v",
+				i, r.Attrs.IsTransactional(), r.Attrs.IsControl(), e.txn, e.control)
+		}
+	}
+}
+
+func TestDecodePartitionsConcurrently(t *testing.T) {
+	cl, err := NewClient(MaxConcurrentDecodes(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+	s := cl.newSource(1)
+
+	const nparts, nrecs = 8, 50
+	var decodes []decodeTopic
+	for _, topic := range []string{"a", "b"} {
+		dt := decodeTopic{topic: topic}
+		for p := int32(0); p < nparts; p++ {
+			batch := kmsg.RecordBatch{
+				Magic:         2,
+				ProducerID:    -1,
+				ProducerEpoch: -1,
+				FirstSequence: -1,
+			}
+			var rs []kmsg.Record
+			for i := 0; i < nrecs; i++ {
+				rs = append(rs, kmsg.Record{OffsetDelta: int32(i), Value: []byte{byte(p)}})
+			}
+			batch.SetRecords(rs)
+			rp := kmsg.NewFetchResponseTopicPartition()
+			rp.Partition = p
+			rp.HighWatermark = nrecs
+			rp.RecordBatches = batch.AppendToRecomputed(nil)
+			dt.partitions = append(dt.partitions, decodePartition{
+				partOffset: &cursorOffsetNext{from: &cursor{topic: topic, partition: p}},
+				rp:         &rp,
+			})
+		}
+		decodes = append(decodes, dt)
+	}
+
+	s.decodePartitions(nil, 12, decodes)
+
+	for _, dt := range decodes {
+		for _, d := range dt.partitions {
+			if d.fp.Err != nil || d.fp.Partition != d.rp.Partition || len(d.fp.Records) != nrecs {
+				t.Fatalf("%s[%d]: got err %v, partition %d, %d records", dt.topic, d.rp.Partition, d.fp.Err, d.fp.Partition, len(d.fp.Records))
+			}
+			for i, r := range d.fp.Records {
+				if r.Offset != int64(i) || r.Topic != dt.topic || r.Value[0] != byte(d.rp.Partition) {
+					t.Errorf("%s[%d]: record %d out of order or misplaced: %s[%d]@%d", dt.topic, d.rp.Partition, i, r.Topic, r.Partition, r.Offset)
+				}
+			}
+			if d.partOffset.offset != nrecs {
+				t.Errorf("%s[%d]: got next offset %d != exp %d", dt.topic, d.rp.Partition, d.partOffset.offset, nrecs)
+			}
+		}
+	}
+}
+
+// Records from one partition are delivered in offset order across many
+// polls, even when partitions are decoded concurrently.
+func TestConsumeConcurrentDecodePreservesPartitionOrder(t *testing.T) {
+	t.Parallel()
+	const (
+		nparts     = 8
+		perBatch   = 5
+		perFetch   = 3 // batches per partition per fetch
+		perPartExp = 300
+	)
+	offsets := make(map[int32]Offset)
+	for p := int32(0); p < nparts; p++ {
+		offsets[p] = NewOffset().At(0)
+	}
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				for p := int32(0); p < nparts; p++ {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Replicas = []int32{0}
+					rp.ISR = []int32{0}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+				}
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					offset := rp.FetchOffset
+					for b := 0; b < perFetch; b++ {
+						batch := kmsg.RecordBatch{
+							FirstOffset:   offset,
+							Magic:         2,
+							ProducerID:    -1,
+							ProducerEpoch: -1,
+							FirstSequence: -1,
+						}
+						var rs []kmsg.Record
+						for i := 0; i < perBatch; i++ {
+							rs = append(rs, kmsg.Record{OffsetDelta: int32(i), Value: []byte{byte(rp.Partition)}})
+						}
+						batch.SetRecords(rs)
+						sp.RecordBatches = batch.AppendToRecomputed(sp.RecordBatches)
+						offset += perBatch
+					}
+					sp.HighWatermark = offset
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": offsets}),
+		MaxConcurrentDecodes(4),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	next := make(map[int32]int64)
+	var done int
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for done < nparts && ctx.Err() == nil {
+		fs := cl.PollRecords(ctx, 7) // small polls split buffered fetches
+		fs.EachRecord(func(r *Record) {
+			if r.Offset != next[r.Partition] || r.Value[0] != byte(r.Partition) {
+				t.Fatalf("partition %d: got offset %d, exp %d", r.Partition, r.Offset, next[r.Partition])
+			}
+			if next[r.Partition]++; next[r.Partition] == perPartExp {
+				done++
+			}
+		})
+	}
+	if done < nparts {
+		t.Fatalf("only %d of %d partitions reached %d records: %v", done, nparts, perPartExp, next)
+	}
+}
+
+type corruptHook struct {
+	node      int32
+	partition int32
+	offset    int64
+}
+
+func (h *corruptHook) OnFetchBatchCorrupt(meta BrokerMetadata, _ string, partition int32, offset int64, _ error) {
+	h.node, h.partition, h.offset = meta.NodeID, partition, offset
+}
+
+func TestCorruptBatch(t *testing.T) {
+	t.Parallel()
+	batch := kmsg.RecordBatch{
+		FirstOffset:   3,
+		Magic:         2,
+		ProducerID:    -1,
+		ProducerEpoch: -1,
+		FirstSequence: -1,
+	}
+	batch.SetRecords([]kmsg.Record{
+		{OffsetDelta: 0, Value: []byte("a")},
+		{OffsetDelta: 1, Value: []byte("b")},
+	})
+	raw := batch.AppendToRecomputed(nil)
+	batch.FirstOffset = 5
+	corruptAt := len(raw) + 61 // within the records of the second batch
+	raw = batch.AppendToRecomputed(raw)
+	raw[corruptAt] ^= 0xff
+
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.Partition = 2
+	rp.RecordBatches = raw
+
+	o := cursorOffsetNext{
+		cursorOffset: cursorOffset{offset: 3},
+		from:         &cursor{topic: "t", partition: 2},
+	}
+	hook := new(corruptHook)
+	br := &broker{meta: BrokerMetadata{NodeID: 7}}
+	fp := o.processRespPartition(br, 0, &rp, partitionRecordsDecompressor, hooks{hook})
+
+	if len(fp.Records) != 2 {
+		t.Errorf("got %d records != exp 2 from the valid batch", len(fp.Records))
+	}
+	var ce *ErrCorruptBatch
+	if !errors.As(fp.Err, &ce) || ce.Topic != "t" || ce.Partition != 2 || ce.Offset != 5 {
+		t.Fatalf("got err %v, exp corrupt batch at offset 5", fp.Err)
+	}
+	if o.offset != 5 {
+		t.Errorf("got next offset %d != exp 5, the corrupt batch must be refetched", o.offset)
+	}
+	if *hook != (corruptHook{7, 2, 5}) {
+		t.Errorf("got hook call %+v, exp {7 2 5}", *hook)
+	}
+}
+
+// A cursor moved to a preferred read replica must not stay there once it is
+// unassigned: the preferred replica was chosen for the rack of the previous
+// assignment, and whoever is assigned the partition next must ask the leader.
+func TestUnsetCursorMovesToLeader(t *testing.T) {
+	t.Parallel()
+	cl, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	leader, follower := cl.newSource(0), cl.newSource(1)
+	cl.sinksAndSourcesMu.Lock()
+	cl.sinksAndSources[0] = sinkAndSource{sink: cl.newSink(0), source: leader}
+	cl.sinksAndSources[1] = sinkAndSource{sink: cl.newSink(1), source: follower}
+	cl.sinksAndSourcesMu.Unlock()
+
+	c := &cursor{
+		topic:              "t",
+		source:             follower,
+		topicPartitionData: topicPartitionData{leader: 0},
+	}
+	follower.addCursor(c)
+
+	c.unset()
+
+	if c.source != leader {
+		t.Errorf("got cursor source %d, exp leader 0", c.source.nodeID)
+	}
+	if len(follower.cursors) != 0 {
+		t.Errorf("got %d cursors left on the preferred replica, exp 0", len(follower.cursors))
+	}
+	if len(leader.cursors) != 1 || leader.cursors[0] != c {
+		t.Errorf("cursor was not added to the leader source")
+	}
+}
+
+func TestFetchSessionRequest(t *testing.T) {
+	t.Parallel()
+	newReq := func(session fetchSession) *fetchRequest {
+		f := &fetchRequest{version: 12, session: session}
+		for _, p := range []int32{0, 1} {
+			f.addCursor(&cursor{
+				topic:        "t",
+				partition:    p,
+				cursorOffset: cursorOffset{offset: 10},
+			})
+		}
+		return f
+	}
+	read := func(f *fetchRequest) (sent []int32, forgotten []int32) {
+		t.Helper()
+		req := kmsg.NewPtrFetchRequest()
+		req.Version = f.version
+		if err := req.ReadFrom(f.AppendTo(nil)); err != nil {
+			t.Fatalf("unable to read fetch request: %v", err)
+		}
+		for _, rt := range req.Topics {
+			for _, rp := range rt.Partitions {
+				sent = append(sent, rp.Partition)
+			}
+		}
+		for _, rt := range req.ForgottenTopics {
+			forgotten = append(forgotten, rt.Partitions...)
+		}
+		return sent, forgotten
+	}
+	used := func() map[string]map[int32]fetchSessionOffsetEpoch {
+		return map[string]map[int32]fetchSessionOffsetEpoch{"t": {
+			0: {offset: 10},
+			1: {offset: 5},
+			2: {offset: 10},
+		}}
+	}
+
+	// Incremental: unchanged partition 0 is omitted, partition 2 that we
+	// no longer fetch is forgotten.
+	sent, forgotten := read(newReq(fetchSession{id: 3, epoch: 2, used: used()}))
+	if !reflect.DeepEqual(sent, []int32{1}) || !reflect.DeepEqual(forgotten, []int32{2}) {
+		t.Errorf("incremental request: got sent %v forgotten %v, exp sent [1] forgotten [2]", sent, forgotten)
+	}
+
+	// Full: a broker that did not create a session leaves us at epoch 0
+	// with partitions from our prior request; we must send everything.
+	sent, forgotten = read(newReq(fetchSession{epoch: 0, used: used()}))
+	if !reflect.DeepEqual(sent, []int32{0, 1}) || len(forgotten) != 0 {
+		t.Errorf("full request: got sent %v forgotten %v, exp sent [0 1] forgotten []", sent, forgotten)
+	}
+}
+
+// All partitions led by a broker are fetched in one request to that broker,
+// rather than one request per partition.
+func TestFetchGroupsPartitionsByBroker(t *testing.T) {
+	t.Parallel()
+	fetched := make(chan []int32, 1)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				for p := int32(0); p < 3; p++ {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Replicas = []int32{0}
+					rp.ISR = []int32{0}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+				}
+			}
+			return resp
+		case *kmsg.FetchRequest:
+			var ps []int32
+			for _, rt := range req.Topics {
+				for _, rp := range rt.Partitions {
+					ps = append(ps, rp.Partition)
+				}
+			}
+			select {
+			case fetched <- ps:
+			default:
+			}
+			return req.ResponseKind()
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": {
+			0: NewOffset().At(0),
+			1: NewOffset().At(0),
+			2: NewOffset().At(0),
+		}}),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go cl.PollFetches(ctx)
+
+	select {
+	case ps := <-fetched:
+		sort.Slice(ps, func(i, j int) bool { return ps[i] < ps[j] })
+		if exp := []int32{0, 1, 2}; !reflect.DeepEqual(ps, exp) {
+			t.Errorf("got fetched partitions %v, exp %v in one request", ps, exp)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a fetch")
+	}
+}
+
+// Fetch partitions carry the response's offsets, defaulting the offsets that
+// older response versions do not have.
+func TestNewFetchPartition(t *testing.T) {
+	t.Parallel()
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.Partition = 3
+	rp.HighWatermark = 10
+	rp.LastStableOffset = 8
+	rp.LogStartOffset = 2
+	rp.PreferredReadReplica = 1
+	abort := kmsg.NewFetchResponseTopicPartitionAbortedTransaction()
+	abort.ProducerID = 5
+	abort.FirstOffset = 7
+	rp.AbortedTransactions = append(rp.AbortedTransactions, abort)
+
+	for _, test := range []struct {
+		version int16
+		lso     int64
+		lsoff   int64
+		pref    int32
+	}{
+		{3, 10, -1, -1},
+		{4, 8, -1, -1},
+		{5, 8, 2, -1},
+		{11, 8, 2, 1},
+	} {
+		fp := newFetchPartition(test.version, &rp)
+		exp := FetchPartition{
+			Partition:            3,
+			HighWatermark:        10,
+			LastStableOffset:     test.lso,
+			LogStartOffset:       test.lsoff,
+			PreferredReadReplica: test.pref,
+			AbortedTransactions:  []FetchAbortedTransaction{{ProducerID: 5, FirstOffset: 7}},
+		}
+		if !reflect.DeepEqual(fp, exp) {
+			t.Errorf("v%d: got %+v, exp %+v", test.version, fp, exp)
+		}
+	}
+}
+
+// A fetch response with some erroring partitions still delivers records from
+// the healthy partitions. Erroring partitions are retried on their own after
+// a metadata refresh, and OFFSET_OUT_OF_RANGE resets only its partition.
+func TestFetchIsolatesPartitionErrors(t *testing.T) {
+	t.Parallel()
+	var (
+		mu          sync.Mutex
+		notLeader   = true
+		fetched     bool
+		listed      []int32
+		metaUpdates int
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			if fetched {
+				metaUpdates++
+			}
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				for p := int32(0); p < 3; p++ {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Replicas = []int32{0}
+					rp.ISR = []int32{0}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+				}
+			}
+			return resp
+
+		case *kmsg.ListOffsetsRequest:
+			resp := req.ResponseKind().(*kmsg.ListOffsetsResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewListOffsetsResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					if fetched {
+						listed = append(listed, rp.Partition)
+					}
+					sp := kmsg.NewListOffsetsResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.Offset = 5
+					sp.LeaderEpoch = -1
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			fetched = true
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					switch {
+					case rp.Partition == 1 && notLeader:
+						notLeader = false
+						sp.ErrorCode = kerr.NotLeaderForPartition.Code
+					case rp.Partition == 2 && rp.FetchOffset < 5:
+						sp.ErrorCode = kerr.OffsetOutOfRange.Code
+					default:
+						batch := kmsg.RecordBatch{
+							FirstOffset:   rp.FetchOffset,
+							Magic:         2,
+							ProducerID:    -1,
+							ProducerEpoch: -1,
+							FirstSequence: -1,
+						}
+						batch.SetRecords([]kmsg.Record{{Value: []byte(fmt.Sprint(rp.Partition))}})
+						sp.RecordBatches = batch.AppendToRecomputed(nil)
+						sp.HighWatermark = rp.FetchOffset + 1
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": {
+			0: NewOffset().At(0),
+			1: NewOffset().At(0),
+			2: NewOffset().At(0),
+		}}),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	firstOffsets := make(map[int32]int64)
+	for len(firstOffsets) < 3 && ctx.Err() == nil {
+		fs := cl.PollFetches(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if errs := fs.Errors(); len(errs) > 0 {
+			t.Fatalf("got unexpected poll errors %v", errs)
+		}
+		fs.EachRecord(func(r *Record) {
+			if _, exists := firstOffsets[r.Partition]; !exists {
+				firstOffsets[r.Partition] = r.Offset
+			}
+		})
+	}
+	if exp := map[int32]int64{0: 0, 1: 0, 2: 5}; !reflect.DeepEqual(firstOffsets, exp) {
+		t.Fatalf("got first consumed offsets %v, exp %v", firstOffsets, exp)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(listed, []int32{2}) {
+		t.Errorf("got listed partitions %v, exp only the out of range partition [2]", listed)
+	}
+	if metaUpdates == 0 {
+		t.Error("metadata was not refreshed after partition errors")
+	}
+}
+
+// appendSnappyBatch appends a snappy compressed record batch with one record
+// per value, with keys "k0", "k1", etc.
+func appendSnappyBatch(t testing.TB, dst []byte, firstOffset int64, values ...[]byte) []byte {
+	t.Helper()
+	batch := kmsg.RecordBatch{
+		FirstOffset:   firstOffset,
+		Magic:         2,
+		ProducerID:    -1,
+		ProducerEpoch: -1,
+		FirstSequence: -1,
+	}
+	var rs []kmsg.Record
+	for i, v := range values {
+		rs = append(rs, kmsg.Record{
+			OffsetDelta: int32(i),
+			Key:         []byte(fmt.Sprintf("k%d", i)),
+			Value:       v,
+			Headers:     []kmsg.Header{{Key: "h", Value: []byte{byte(i)}}},
+		})
+	}
+	batch.SetRecords(rs)
+
+	c, err := newCompressor(SnappyCompression())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := sliceWriters.Get().(*sliceWriter)
+	defer sliceWriters.Put(w)
+	compressed, codec := c.compress(w, batch.Records, 0)
+	batch.Records = append([]byte(nil), compressed...)
+	batch.Attributes = int16(codec)
+	return batch.AppendToRecomputed(dst)
+}
+
+func TestLazyRecordDecoding(t *testing.T) {
+	t.Parallel()
+	raw := appendSnappyBatch(t, nil, 3, []byte("a"), []byte("b"), []byte("c"))
+
+	// Control batches are still processed eagerly and are not returned.
+	control := kmsg.RecordBatch{
+		FirstOffset:   6,
+		Attributes:    0x20,
+		Magic:         2,
+		ProducerID:    -1,
+		ProducerEpoch: -1,
+		FirstSequence: -1,
+	}
+	control.SetRecords([]kmsg.Record{{OffsetDelta: 0, Key: []byte{0, 0, 0, 1}}})
+	raw = control.AppendToRecomputed(raw)
+
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.Partition = 2
+	rp.RecordBatches = raw
+
+	o := cursorOffsetNext{
+		cursorOffset: cursorOffset{offset: 4},
+		from:         &cursor{topic: "t", partition: 2, lazy: true},
+	}
+	fp := o.processRespPartition(nil, 0, &rp, partitionRecordsDecompressor, nil)
+	if fp.Err != nil {
+		t.Fatalf("unexpected err: %v", fp.Err)
+	}
+	if len(fp.Records) != 0 || len(fp.LazyBatches) != 1 {
+		t.Fatalf("got %d records and %d lazy batches, exp 0 and 1", len(fp.Records), len(fp.LazyBatches))
+	}
+	if o.offset != 7 {
+		t.Errorf("got next offset %d != exp 7", o.offset)
+	}
+
+	b := fp.LazyBatches[0]
+	if b.Topic != "t" || b.Partition != 2 || b.FirstOffset != 3 || b.LastOffset != 5 || b.NumRecords != 3 {
+		t.Errorf("got batch %s[%d] offsets %d-%d with %d records, exp t[2] 3-5 with 3", b.Topic, b.Partition, b.FirstOffset, b.LastOffset, b.NumRecords)
+	}
+	lazy, err := b.Records()
+	if err != nil {
+		t.Fatalf("unable to decode lazy batch: %v", err)
+	}
+
+	// Lazy records must match what we decode eagerly.
+	eager := PartitionRecords("t", 4, 11, &rp).Records
+	if len(lazy) != 2 || len(eager) != 2 {
+		t.Fatalf("got %d lazy records and %d eager records, exp 2", len(lazy), len(eager))
+	}
+	for i := range lazy {
+		l, e := &lazy[i], eager[i]
+		if l.Offset != e.Offset || !bytes.Equal(l.Key, e.Key) || !bytes.Equal(l.Value(), e.Value) || !reflect.DeepEqual(l.Headers(), e.Headers) {
+			t.Errorf("record %d: got %d %q=%q %v, exp %d %q=%q %v", i, l.Offset, l.Key, l.Value(), l.Headers(), e.Offset, e.Key, e.Value, e.Headers)
+		}
+		if r := l.Record(); !reflect.DeepEqual(r, e) {
+			t.Errorf("record %d: got materialized %+v, exp %+v", i, r, e)
+		}
+	}
+}
+
+func TestReleaseDecompressedBuffers(t *testing.T) {
+	t.Parallel()
+	raw := appendSnappyBatch(t, nil, 0, []byte("a"), []byte("b"))
+	raw = appendSnappyBatch(t, raw, 2, []byte("c"), []byte("d"))
+
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.RecordBatches = raw
+
+	// Nothing is kept from the first batch, so its buffer goes straight
+	// back to the pool; the second batch's records reference its buffer.
+	o := cursorOffsetNext{
+		cursorOffset: cursorOffset{offset: 2},
+		from:         &cursor{topic: "t"},
+	}
+	fp := o.processRespPartition(nil, 0, &rp, partitionRecordsDecompressor, nil)
+	if fp.Err != nil {
+		t.Fatalf("unexpected err: %v", fp.Err)
+	}
+	if len(fp.Records) != 2 || string(fp.Records[0].Value) != "c" || string(fp.Records[1].Value) != "d" {
+		t.Fatalf("got %d records, exp c and d", len(fp.Records))
+	}
+	if len(fp.decompressed) != 1 {
+		t.Fatalf("got %d decompressed buffers, exp 1", len(fp.decompressed))
+	}
+	buf := *fp.decompressed[0]
+	copy(buf[bytes.LastIndexByte(buf, 'c'):], "x")
+	if string(fp.Records[0].Value) != "x" {
+		t.Error("records do not reference the decompressed buffer")
+	}
+
+	fs := Fetches{{Topics: []FetchTopic{{Topic: "t", Partitions: []FetchPartition{fp}}}}}
+	fs.Release()
+	if n := len(fs[0].Topics[0].Partitions[0].decompressed); n != 0 {
+		t.Errorf("got %d decompressed buffers after release, exp 0", n)
+	}
+	fs.Release() // no-op
+}
+
+// Releasing fetches lets compressed batches be decompressed into reused
+// buffers rather than freshly allocated ones.
+func BenchmarkDecompressRelease(b *testing.B) {
+	value := bytes.Repeat([]byte("v"), 1<<10)
+	var raw []byte
+	for i := 0; i < 10; i++ {
+		values := make([][]byte, 100)
+		for j := range values {
+			values[j] = value
+		}
+		raw = appendSnappyBatch(b, raw, int64(i*100), values...)
+	}
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.RecordBatches = raw
+
+	for _, release := range []bool{false, true} {
+		name := "no_release"
+		if release {
+			name = "release"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				o := cursorOffsetNext{from: &cursor{topic: "t"}}
+				fp := o.processRespPartition(nil, 0, &rp, partitionRecordsDecompressor, nil)
+				if len(fp.Records) != 1000 {
+					b.Fatalf("got %d records, exp 1000", len(fp.Records))
+				}
+				if release {
+					Fetches{{Topics: []FetchTopic{{Topic: "t", Partitions: []FetchPartition{fp}}}}}.Release()
+				}
+			}
+		})
+	}
+}
+
+// Consumers that filter most records by key benefit from lazy decoding, since
+// values and headers of filtered records are never allocated.
+func BenchmarkFilterByKey(b *testing.B) {
+	value := bytes.Repeat([]byte("v"), 1<<10)
+	var raw []byte
+	for i := 0; i < 10; i++ {
+		values := make([][]byte, 100)
+		for j := range values {
+			values[j] = value
+		}
+		raw = appendSnappyBatch(b, raw, int64(i*100), values...)
+	}
+	rp := kmsg.NewFetchResponseTopicPartition()
+	rp.RecordBatches = raw
+	want := []byte("k42")
+
+	for _, lazy := range []bool{false, true} {
+		name := "eager"
+		if lazy {
+			name = "lazy"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				o := cursorOffsetNext{from: &cursor{topic: "t", lazy: lazy}}
+				fp := o.processRespPartition(nil, 0, &rp, partitionRecordsDecompressor, nil)
+				var n int
+				for _, r := range fp.Records {
+					if bytes.Equal(r.Key, want) {
+						n += len(r.Value)
+					}
+				}
+				for _, batch := range fp.LazyBatches {
+					rs, _ := batch.Records()
+					for j := range rs {
+						if bytes.Equal(rs[j].Key, want) {
+							n += len(rs[j].Value())
+						}
+					}
+				}
+				if n != 10*len(value) {
+					b.Fatalf("got %d filtered bytes, exp %d", n, 10*len(value))
+				}
+			}
+		})
+	}
+}
+
+// Fetch responses can carry follower-only tagged fields (KIP-595); consumers
+// decode and ignore them, consuming records as usual.
+func TestFetchIgnoresFollowerFields(t *testing.T) {
+	t.Parallel()
+	var maxVersion int16
+	var mu sync.Mutex
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			for i := range resp.Topics {
+				rp := kmsg.NewMetadataResponseTopicPartition()
+				rp.Replicas = []int32{0}
+				rp.ISR = []int32{0}
+				resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			mu.Lock()
+			if req.Version > maxVersion {
+				maxVersion = req.Version
+			}
+			mu.Unlock()
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.DivergingEpoch.Epoch = 3
+					sp.DivergingEpoch.EndOffset = 100
+					sp.SnapshotID.Epoch = 2
+					sp.SnapshotID.EndOffset = 50
+					sp.CurrentLeader.LeaderID = 0
+					sp.CurrentLeader.LeaderEpoch = 3
+					batch := kmsg.RecordBatch{
+						FirstOffset:   rp.FetchOffset,
+						Magic:         2,
+						ProducerID:    -1,
+						ProducerEpoch: -1,
+						FirstSequence: -1,
+					}
+					batch.SetRecords([]kmsg.Record{{Value: []byte("v")}})
+					sp.RecordBatches = batch.AppendToRecomputed(nil)
+					sp.HighWatermark = rp.FetchOffset + 1
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": {0: NewOffset().At(0)}}),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var next int64
+	for next < 3 && ctx.Err() == nil {
+		fs := cl.PollFetches(ctx)
+		fs.EachError(func(_ string, _ int32, err error) {
+			if err != context.DeadlineExceeded {
+				t.Fatalf("unexpected fetch error: %v", err)
+			}
+		})
+		fs.EachRecord(func(r *Record) {
+			if r.Offset != next {
+				t.Fatalf("got offset %d, exp %d", r.Offset, next)
+			}
+			next++
+		})
+	}
+	if next < 3 {
+		t.Fatalf("consumed %d records, exp 3", next)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if maxVersion < 12 {
+		t.Errorf("fetched with max version %d, exp >= 12 to exercise tagged fields", maxVersion)
+	}
+}
+
+// With FetchMaxBytesTotal, a buffered fetch over the limit blocks fetches from
+// other brokers until it is polled, and a single fetch larger than the limit
+// is still buffered.
+func TestFetchMaxBytesTotal(t *testing.T) {
+	t.Parallel()
+	var (
+		mu        sync.Mutex
+		p1Fetches int
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			b := resp.Brokers[0]
+			b.NodeID = 1
+			resp.Brokers = append(resp.Brokers, b)
+			for i := range resp.Topics {
+				for p := int32(0); p < 2; p++ {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Leader = p
+					rp.Replicas = []int32{p}
+					rp.ISR = []int32{p}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+				}
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.HighWatermark = rp.FetchOffset
+					if rp.Partition == 1 { // partition 1 never has data
+						mu.Lock()
+						p1Fetches++
+						mu.Unlock()
+					} else {
+						batch := kmsg.RecordBatch{
+							FirstOffset:   rp.FetchOffset,
+							Magic:         2,
+							ProducerID:    -1,
+							ProducerEpoch: -1,
+							FirstSequence: -1,
+						}
+						batch.SetRecords([]kmsg.Record{{Value: []byte("v")}})
+						sp.RecordBatches = batch.AppendToRecomputed(nil)
+						sp.HighWatermark++
+					}
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": {0: NewOffset().At(0), 1: NewOffset().At(0)}}),
+		FetchMaxBytesTotal(1),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	loadP1 := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return p1Fetches
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for cl.BufferedFetchRecords() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for a buffered fetch")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := cl.BufferedFetchBytes(); n <= 1 {
+		t.Fatalf("got %d buffered bytes, exp a fetch larger than the limit", n)
+	}
+
+	// Any in flight fetch for partition 1 finishes quickly; after that,
+	// partition 1 must not be fetched while partition 0 is buffered.
+	time.Sleep(50 * time.Millisecond)
+	before := loadP1()
+	time.Sleep(100 * time.Millisecond)
+	if after := loadP1(); after != before {
+		t.Fatalf("partition 1 fetched %d times while over the bytes limit, exp 0", after-before)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if n := len(cl.PollRecords(ctx, 1).Records()); n != 1 {
+		t.Fatalf("got %d records, exp 1", n)
+	}
+	for loadP1() == before {
+		if ctx.Err() != nil {
+			t.Fatal("partition 1 was not fetched after draining the buffered fetch")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Brokers return whole batches, so a fetch starting in the middle of a batch
+// returns records before the fetch offset; these must be skipped, or records
+// already consumed would be delivered again.
+func TestProcessSkipsRecordsBeforeFetchOffset(t *testing.T) {
+	t.Parallel()
+
+	uncompressed := kmsg.RecordBatch{
+		FirstOffset:   10,
+		Magic:         2,
+		ProducerID:    -1,
+		ProducerEpoch: -1,
+		FirstSequence: -1,
+	}
+	uncompressed.SetRecords([]kmsg.Record{
+		{OffsetDelta: 0, Value: []byte("a")},
+		{OffsetDelta: 1, Value: []byte("b")},
+		{OffsetDelta: 2, Value: []byte("c")},
+	})
+
+	var v1 []byte
+	for i := int64(10); i < 13; i++ {
+		v1 = appendMessage(v1, &kmsg.MessageV1{Offset: i, Magic: 1, Value: []byte{byte('a' + i - 10)}})
+	}
+
+	for _, test := range []struct {
+		name string
+		raw  []byte
+	}{
+		{"uncompressed batch", uncompressed.AppendToRecomputed(nil)},
+		{"compressed batch", appendSnappyBatch(t, nil, 10, []byte("a"), []byte("b"), []byte("c"))},
+		{"v1 messages", v1},
+	} {
+		for _, start := range []int64{10, 11, 12, 13} {
+			rp := kmsg.NewFetchResponseTopicPartition()
+			rp.RecordBatches = test.raw
+
+			o := cursorOffsetNext{
+				cursorOffset: cursorOffset{offset: start},
+				from:         &cursor{topic: "t"},
+			}
+			fp := o.processRespPartition(nil, 0, &rp, partitionRecordsDecompressor, nil)
+			if fp.Err != nil {
+				t.Fatalf("%s from %d: unexpected err: %v", test.name, start, fp.Err)
+			}
+			if exp := int(13 - start); len(fp.Records) != exp {
+				t.Errorf("%s from %d: got %d records, exp %d", test.name, start, len(fp.Records), exp)
+			}
+			for i, r := range fp.Records {
+				if exp := start + int64(i); r.Offset != exp {
+					t.Errorf("%s from %d: record %d: got offset %d, exp %d", test.name, start, i, r.Offset, exp)
+				}
+			}
+			if o.offset != 13 {
+				t.Errorf("%s from %d: got next offset %d, exp 13", test.name, start, o.offset)
+			}
+		}
+	}
+}
+
+// Polling a limited number of records from a fetch that redirected a
+// partition to a preferred replica skips the redirected partition, whose
+// cursor has already moved to the replica.
+func TestPollRecordsWithPreferredReplicaRedirect(t *testing.T) {
+	t.Parallel()
+	var redirected int32
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			resp := fakeMetadataResponse(req, 0).(*kmsg.MetadataResponse)
+			b := kmsg.NewMetadataResponseBroker()
+			b.NodeID, b.Host, b.Port = 1, "127.0.0.1", 9093
+			resp.Brokers = append(resp.Brokers, b)
+			for i := range resp.Topics {
+				for p := int32(0); p < 2; p++ {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Replicas = []int32{0, 1}
+					rp.ISR = []int32{0, 1}
+					resp.Topics[i].Partitions = append(resp.Topics[i].Partitions, rp)
+				}
+			}
+			return resp
+
+		case *kmsg.FetchRequest:
+			resp := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic = rt.Topic
+				st.TopicID = rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.HighWatermark = rp.FetchOffset
+					if rp.Partition == 1 && atomic.CompareAndSwapInt32(&redirected, 0, 1) {
+						sp.PreferredReadReplica = 1
+						st.Partitions = append([]kmsg.FetchResponseTopicPartition{sp}, st.Partitions...)
+						continue
+					}
+					batch := kmsg.RecordBatch{
+						FirstOffset:   rp.FetchOffset,
+						Magic:         2,
+						ProducerID:    -1,
+						ProducerEpoch: -1,
+						FirstSequence: -1,
+					}
+					batch.SetRecords([]kmsg.Record{{Value: []byte("a")}, {OffsetDelta: 1, Value: []byte("b")}})
+					sp.RecordBatches = batch.AppendToRecomputed(nil)
+					sp.HighWatermark = rp.FetchOffset + 2
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		}
+		return kreq.ResponseKind()
+	},
+		ConsumePartitions(map[string]map[int32]Offset{"t": {
+			0: NewOffset().At(0),
+			1: NewOffset().At(0),
+		}}),
+		FetchMaxWait(10*time.Millisecond),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	got := make(map[int32]int)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for (got[0] < 4 || got[1] < 2) && ctx.Err() == nil {
+		cl.PollRecords(ctx, 1).EachRecord(func(r *Record) { got[r.Partition]++ })
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("timed out polling records, got per partition counts %v", got)
+	}
+	if atomic.LoadInt32(&redirected) == 0 {
+		t.Error("partition 1 was never redirected")
+	}
+}
@@ -0,0 +1,206 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// PartitionMetadata is the metadata for a single partition of a topic, as
+// returned from TopicMetadata.
+type PartitionMetadata struct {
+	Partition       int32   // Partition is the partition number.
+	Leader          int32   // Leader is the broker leading this partition, or -1 if there is no leader.
+	LeaderEpoch     int32   // LeaderEpoch is the epoch of the leader, or -1 if unknown.
+	Replicas        []int32 // Replicas are the brokers replicating this partition.
+	ISR             []int32 // ISR are the brokers in sync with the leader.
+	OfflineReplicas []int32 // OfflineReplicas are the replicas that are offline.
+	Err             error   // Err is any partition level error, such as LEADER_NOT_AVAILABLE.
+}
+
+// TopicMetadata is the metadata for a single topic, as returned from
+// TopicMetadata.
+type TopicMetadata struct {
+	Topic      string
+	ID         [16]byte // ID is the topic ID, if the broker supports topic IDs.
+	IsInternal bool     // IsInternal is whether the topic is an internal topic.
+
+	// Exists is whether the topic exists. This is false if Kafka replied
+	// UNKNOWN_TOPIC_OR_PARTITION, or if existence could not be determined
+	// (e.g., TOPIC_AUTHORIZATION_FAILED); see Err.
+	Exists bool
+	// Created is true if the topic did not exist and the broker created
+	// the topic while handling this request. This is only possible if
+	// the client was configured with AllowAutoTopicCreation.
+	//
+	// Brokers before Kafka 0.11 (metadata v4) cannot be asked to not
+	// auto create topics: they create missing topics on any metadata
+	// request if the broker enables auto creation. Against these
+	// brokers, creation cannot be detected and Created is always false.
+	Created bool
+	// Err is any topic level error, such as UNKNOWN_TOPIC_OR_PARTITION
+	// or TOPIC_AUTHORIZATION_FAILED.
+	Err error
+
+	// Partitions contains the topic's partitions, sorted by partition.
+	Partitions []PartitionMetadata
+}
+
+// NumPartitions returns the number of partitions in the topic.
+func (t TopicMetadata) NumPartitions() int { return len(t.Partitions) }
+
+type cachedTopicMetadata struct {
+	at   time.Time
+	meta TopicMetadata
+}
+
+// TopicMetadata returns metadata for the requested topics, including whether
+// each topic exists, its partitions, and the leader, replicas, and ISR of each
+// partition. This is useful for validating a topic before producing to it
+// (for example, validating a manually chosen partition).
+//
+// Results for topics that exist are cached for the client's MetadataMinAge;
+// if forceRefresh is true, the cache is bypassed and metadata is always
+// requested. Topics that do not exist or have errors are never cached, and
+// expired results are evicted when new results are cached.
+//
+// If the client allows auto topic creation, topics that do not exist are
+// created by this function and returned with Created set to true. Otherwise,
+// missing topics are returned with Exists false and Err set to
+// UNKNOWN_TOPIC_OR_PARTITION.
+//
+// This returns an error only if the metadata request itself fails; topic
+// errors are returned per topic.
+func (cl *Client) TopicMetadata(ctx context.Context, forceRefresh bool, topics ...string) (map[string]TopicMetadata, error) {
+	metas := make(map[string]TopicMetadata, len(topics))
+	var need []string
+
+	cl.topicMetaMu.Lock()
+	for _, t := range topics {
+		if cached, ok := cl.topicMeta[t]; ok && !forceRefresh && time.Since(cached.at) < cl.cfg.metadataMinAge {
+			metas[t] = cached.meta
+		} else {
+			need = append(need, t)
+		}
+	}
+	cl.topicMetaMu.Unlock()
+
+	if len(need) == 0 {
+		return metas, nil
+	}
+
+	// We always request without auto creation first so that we can
+	// distinguish topics that existed from topics that we create.
+	fetched, version, err := cl.requestTopicMetadata(ctx, false, need)
+	if err != nil {
+		return nil, err
+	}
+	// Before v4, the broker ignores AllowAutoTopicCreation and already
+	// auto created (or did not) in the request above; a second request
+	// would only report a topic someone else created as Created.
+	if cl.cfg.allowAutoTopicCreation && version >= 4 {
+		var missing []string
+		for _, t := range need {
+			if !fetched[t].Exists {
+				missing = append(missing, t)
+			}
+		}
+		if len(missing) > 0 {
+			created, _, err := cl.requestTopicMetadata(ctx, true, missing)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range missing {
+				meta := created[t]
+				meta.Created = meta.Exists
+				fetched[t] = meta
+			}
+		}
+	}
+
+	now := time.Now()
+	cl.topicMetaMu.Lock()
+	defer cl.topicMetaMu.Unlock()
+	if cl.topicMeta == nil {
+		cl.topicMeta = make(map[string]cachedTopicMetadata)
+	}
+	// Cached entries are only used for MetadataMinAge, so we evict
+	// anything older whenever we store; this bounds the cache to topics
+	// that were recently requested.
+	for t, cached := range cl.topicMeta {
+		if now.Sub(cached.at) >= cl.cfg.metadataMinAge {
+			delete(cl.topicMeta, t)
+		}
+	}
+	for _, t := range need {
+		meta := fetched[t]
+		metas[t] = meta
+		if meta.Exists && meta.Err == nil {
+			cached := meta
+			cached.Created = false // only the first call created the topic
+			cl.topicMeta[t] = cachedTopicMetadata{now, cached}
+		} else {
+			delete(cl.topicMeta, t)
+		}
+	}
+	return metas, nil
+}
+
+// requestTopicMetadata issues a metadata request for the given topics and
+// converts the response, returning it along with the version of the request
+// that was issued. Every requested topic is in the returned map.
+func (cl *Client) requestTopicMetadata(ctx context.Context, allowAutoCreate bool, topics []string) (map[string]TopicMetadata, int16, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	req.AllowAutoTopicCreation = allowAutoCreate
+	for _, t := range topics {
+		rt := kmsg.NewMetadataRequestTopic()
+		rt.Topic = kmsg.StringPtr(t)
+		req.Topics = append(req.Topics, rt)
+	}
+	resp, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	metas := make(map[string]TopicMetadata, len(topics))
+	for _, rt := range resp.Topics {
+		if rt.Topic == nil {
+			continue
+		}
+		meta := TopicMetadata{
+			Topic:      *rt.Topic,
+			ID:         rt.TopicID,
+			IsInternal: rt.IsInternal,
+			Err:        kerr.ErrorForCode(rt.ErrorCode),
+		}
+		// LEADER_NOT_AVAILABLE is returned for topics that exist
+		// but are still being created (or were just auto created).
+		meta.Exists = meta.Err == nil || errors.Is(meta.Err, kerr.LeaderNotAvailable) || len(rt.Partitions) > 0
+		for _, rp := range rt.Partitions {
+			meta.Partitions = append(meta.Partitions, PartitionMetadata{
+				Partition:       rp.Partition,
+				Leader:          rp.Leader,
+				LeaderEpoch:     rp.LeaderEpoch,
+				Replicas:        rp.Replicas,
+				ISR:             rp.ISR,
+				OfflineReplicas: rp.OfflineReplicas,
+				Err:             kerr.ErrorForCode(rp.ErrorCode),
+			})
+		}
+		sort.Slice(meta.Partitions, func(i, j int) bool { return meta.Partitions[i].Partition < meta.Partitions[j].Partition })
+		metas[meta.Topic] = meta
+	}
+	for _, t := range topics {
+		if _, ok := metas[t]; !ok {
+			metas[t] = TopicMetadata{
+				Topic: t,
+				Err:   kerr.UnknownTopicOrPartition,
+			}
+		}
+	}
+	return metas, resp.Version, nil
+}
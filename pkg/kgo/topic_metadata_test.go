@@ -0,0 +1,218 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/kversion"
+)
+
+func fakeMetadataResponse(req *kmsg.MetadataRequest, topicErr int16) kmsg.Response {
+	resp := kmsg.NewPtrMetadataResponse()
+	b := kmsg.NewMetadataResponseBroker()
+	b.Host = "127.0.0.1"
+	b.Port = 9092
+	resp.Brokers = append(resp.Brokers, b)
+	for _, rt := range req.Topics {
+		t := kmsg.NewMetadataResponseTopic()
+		t.Topic = rt.Topic
+		t.ErrorCode = topicErr
+		resp.Topics = append(resp.Topics, t)
+	}
+	return resp
+}
+
+func TestMetadataDoesNotAutoCreate(t *testing.T) {
+	var reqs []*kmsg.MetadataRequest
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		req := kreq.(*kmsg.MetadataRequest)
+		reqs = append(reqs, req)
+		return fakeMetadataResponse(req, kerr.UnknownTopicOrPartition.Code)
+	})
+	defer cl.Close()
+
+	metas, err := cl.TopicMetadata(context.Background(), false, "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := metas["missing"]
+	if meta.Exists || meta.Created || !errors.Is(meta.Err, kerr.UnknownTopicOrPartition) {
+		t.Errorf("got exists %v, created %v, err %v; expected a missing topic", meta.Exists, meta.Created, meta.Err)
+	}
+
+	if _, err := cl.fetchTopicMetadata(false, []string{"missing"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reqs) != 2 {
+		t.Fatalf("got %d metadata requests != exp 2", len(reqs))
+	}
+	for i, req := range reqs {
+		if req.AllowAutoTopicCreation {
+			t.Errorf("metadata request %d unexpectedly allowed auto topic creation", i)
+		}
+	}
+}
+
+func TestTopicMetadataAutoCreated(t *testing.T) {
+	var reqs []*kmsg.MetadataRequest
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		req := kreq.(*kmsg.MetadataRequest)
+		reqs = append(reqs, req)
+		if req.AllowAutoTopicCreation {
+			return fakeMetadataResponse(req, kerr.LeaderNotAvailable.Code)
+		}
+		return fakeMetadataResponse(req, kerr.UnknownTopicOrPartition.Code)
+	}, AllowAutoTopicCreation())
+	defer cl.Close()
+
+	metas, err := cl.TopicMetadata(context.Background(), false, "created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := metas["created"]; !meta.Exists || !meta.Created {
+		t.Errorf("got exists %v, created %v, expected an auto created topic", meta.Exists, meta.Created)
+	}
+	if len(reqs) != 2 || reqs[0].AllowAutoTopicCreation || !reqs[1].AllowAutoTopicCreation {
+		t.Errorf("expected one metadata request without and then one with auto topic creation, got %d requests", len(reqs))
+	}
+}
+
+func TestTopicMetadataCacheEvicts(t *testing.T) {
+	var reqs int
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		reqs++
+		return fakeMetadataResponse(kreq.(*kmsg.MetadataRequest), 0)
+	})
+	defer cl.Close()
+
+	cl.topicMeta = map[string]cachedTopicMetadata{
+		"expired": {at: time.Now().Add(-time.Hour)},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cl.TopicMetadata(context.Background(), false, "t"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reqs != 1 {
+		t.Errorf("got %d metadata requests, exp 1 with the second served from cache", reqs)
+	}
+	if _, ok := cl.topicMeta["expired"]; ok || len(cl.topicMeta) != 1 {
+		t.Errorf("got cached topics %v, exp only t", cl.topicMeta)
+	}
+}
+
+func TestTopicMetadataPreV4NeverCreated(t *testing.T) {
+	// Before v4, the broker auto creates on any metadata request if it
+	// allows auto creation. A topic missing from the first response was
+	// not created by the broker, and a second request must not report a
+	// topic created by someone else in the meantime as Created.
+	var reqs int
+	vs := kversion.Tip()
+	vs.SetMaxKeyVersion(kmsg.Metadata.Int16(), 3)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		reqs++
+		if reqs == 1 {
+			return fakeMetadataResponse(kreq.(*kmsg.MetadataRequest), kerr.UnknownTopicOrPartition.Code)
+		}
+		return fakeMetadataResponse(kreq.(*kmsg.MetadataRequest), kerr.LeaderNotAvailable.Code)
+	}, AllowAutoTopicCreation(), MaxVersions(vs))
+	defer cl.Close()
+
+	metas, err := cl.TopicMetadata(context.Background(), false, "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := metas["t"]; meta.Exists || meta.Created {
+		t.Errorf("got exists %v, created %v, exp a missing topic", meta.Exists, meta.Created)
+	}
+	if reqs != 1 {
+		t.Errorf("got %d metadata requests != exp 1", reqs)
+	}
+}
+
+func TestTopicMetadata(t *testing.T) {
+	var reqs int
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		reqs++
+		resp := fakeMetadataResponse(kreq.(*kmsg.MetadataRequest), 0).(*kmsg.MetadataResponse)
+		topics := resp.Topics[:0]
+		for _, rt := range resp.Topics {
+			switch *rt.Topic {
+			case "t":
+				rt.TopicID = [16]byte{1}
+				for _, p := range []int32{1, 0} {
+					rp := kmsg.NewMetadataResponseTopicPartition()
+					rp.Partition = p
+					rp.Leader = p
+					rp.Replicas = []int32{0, 1}
+					rp.ISR = []int32{p}
+					if p == 1 {
+						rp.ErrorCode = kerr.ReplicaNotAvailable.Code
+					}
+					rt.Partitions = append(rt.Partitions, rp)
+				}
+			case "denied":
+				rt.ErrorCode = kerr.TopicAuthorizationFailed.Code
+			case "missing":
+				continue // not in the response at all
+			}
+			topics = append(topics, rt)
+		}
+		resp.Topics = topics
+		return resp
+	})
+	defer cl.Close()
+
+	metas, err := cl.TopicMetadata(context.Background(), false, "t", "denied", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]TopicMetadata{
+		"t": {
+			Topic:  "t",
+			ID:     [16]byte{1},
+			Exists: true,
+			Partitions: []PartitionMetadata{
+				{Partition: 0, Leader: 0, Replicas: []int32{0, 1}, ISR: []int32{0}},
+				{Partition: 1, Leader: 1, Replicas: []int32{0, 1}, ISR: []int32{1}, Err: kerr.ReplicaNotAvailable},
+			},
+		},
+		"denied":  {Topic: "denied", Err: kerr.TopicAuthorizationFailed},
+		"missing": {Topic: "missing", Err: kerr.UnknownTopicOrPartition},
+	}
+	for _, meta := range metas {
+		for i := range meta.Partitions {
+			meta.Partitions[i].LeaderEpoch = 0 // defaults to -1 in the response
+		}
+	}
+	if !reflect.DeepEqual(metas, exp) {
+		t.Errorf("got %v != exp %v", metas, exp)
+	}
+	if n := metas["t"].NumPartitions(); n != 2 {
+		t.Errorf("got %d partitions != exp 2", n)
+	}
+
+	// Only the existing topic is cached; a forced refresh bypasses it.
+	for _, test := range []struct {
+		force bool
+		topic string
+		exp   int
+	}{
+		{false, "t", 1},
+		{false, "denied", 2},
+		{true, "t", 3},
+	} {
+		if _, err := cl.TopicMetadata(context.Background(), test.force, test.topic); err != nil {
+			t.Fatal(err)
+		}
+		if reqs != test.exp {
+			t.Errorf("force %v, %s: got %d metadata requests != exp %d", test.force, test.topic, reqs, test.exp)
+		}
+	}
+}
@@ -140,6 +140,12 @@ type topicPartitions struct {
 	partsMu     sync.Mutex
 	partitioner TopicPartitioner
 	lb          *leastBackupInput // for partitioning if the partitioner is a LoadTopicPartitioner
+
+	// repartition, if non-nil, buffers records in order while we wait for
+	// a metadata refresh because a consistent partitioner chose a
+	// partition beyond those we know of. All records for the topic queue
+	// behind it until the refresh completes, preserving ordering.
+	repartition *repartitionProduces
 }
 
 func (t *topicPartitions) load() *topicPartitionsData { return t.v.Load().(*topicPartitionsData) }
@@ -439,7 +439,9 @@ func (cl *Client) AbortBufferedRecords(ctx context.Context) error {
 // Flush and CommitOffsetsForTransaction must be called before this function;
 // this function does not flush and does not itself ensure that all buffered
 // records are flushed. If no record yet has caused a partition to be added to
-// the transaction, this function does nothing and returns nil. Alternatively,
+// the transaction and no offsets were added to the transaction, then the
+// transaction never began within Kafka: this function sends no EndTxn and
+// returns nil, making an empty transaction a cheap no-op. Alternatively,
 // AbortBufferedRecords should be called before aborting a transaction to
 // ensure that any buffered records not yet flushed will not be a part of a new
 // transaction.
@@ -470,8 +472,12 @@ func (cl *Client) EndTransaction(ctx context.Context, commit TransactionEndTry)
 	// If we consumed at all but did not produce, the transaction ending
 	// issues AddOffsetsToTxn, which internally adds a __consumer_offsets
 	// partition to the transaction. Thus, if we added offsets, then we
-	// also produced.
+	// also produced. This is also the case for offsets sent with
+	// SendOffsetsToTransaction, which may not be for our own group.
 	var anyAdded bool
+	if atomic.SwapUint32(&cl.producer.offsetsAddedToTxn, 0) == 1 {
+		anyAdded = true
+	}
 	if g := cl.consumer.g; g != nil {
 		if g.offsetsAddedToTxn {
 			g.offsetsAddedToTxn = false
@@ -710,10 +716,96 @@ func (cl *Client) addOffsetsToTxn(ctx context.Context, group string) error {
 	if errors.As(err, &ke) && !ke.Retriable {
 		cl.failProducerID(id, epoch, err)
 	}
+	if err == nil {
+		atomic.StoreUint32(&cl.producer.offsetsAddedToTxn, 1)
+	}
 
 	return err
 }
 
+// SendOffsetsToTransaction commits offsets for a group as part of the current
+// transaction: the offsets become visible if the transaction is committed and
+// are discarded if it is aborted. This ties the group to the transaction with
+// AddOffsetsToTxn, and then issues TxnOffsetCommit to the group's coordinator,
+// which may be a different broker than the transaction coordinator. Both
+// requests are fenced by the client's producer ID and epoch.
+//
+// If this client is consuming in the given group, the commit is also fenced
+// by the client's group member ID and generation: if the group rebalanced
+// since offsets were consumed, this fails with ILLEGAL_GENERATION (or a
+// similar group error) and the transaction should be aborted. Otherwise, the
+// commit is fenced only by the producer epoch.
+//
+// This returns the first error encountered, wrapped with the topic and
+// partition if the error is for a single partition. A fencing error fails the
+// producer ID, so the transaction must be aborted.
+//
+// Most users should prefer GroupTransactSession, which handles committing
+// offsets and the edge cases of rebalances. This function is for consuming
+// and producing with different clients, or for managing transactions
+// manually. This must not be used for the group that this client consumes
+// with GroupTransactSession.
+func (cl *Client) SendOffsetsToTransaction(ctx context.Context, group string, offsets map[string]map[int32]EpochOffset) error {
+	if cl.cfg.txnID == nil {
+		return errNotTransactional
+	}
+	cl.producer.txnMu.Lock()
+	inTxn := cl.producer.inTxn
+	cl.producer.txnMu.Unlock()
+	if !inTxn {
+		return errNotInTransaction
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	if err := cl.addOffsetsToTxn(ctx, group); err != nil {
+		return err
+	}
+
+	id, epoch, _ := cl.producerID() // loaded in addOffsetsToTxn
+	req := kmsg.NewPtrTxnOffsetCommitRequest()
+	req.TransactionalID = *cl.cfg.txnID
+	req.Group = group
+	req.ProducerID = id
+	req.ProducerEpoch = epoch
+	req.Generation = -1
+	if g := cl.consumer.g; g != nil && g.cfg.group == group {
+		req.MemberID, req.Generation = g.memberGen()
+		req.InstanceID = g.cfg.instanceID
+	}
+	for topic, partitions := range offsets {
+		reqTopic := kmsg.NewTxnOffsetCommitRequestTopic()
+		reqTopic.Topic = topic
+		for partition, eo := range partitions {
+			reqPartition := kmsg.NewTxnOffsetCommitRequestTopicPartition()
+			reqPartition.Partition = partition
+			reqPartition.Offset = eo.Offset
+			reqPartition.LeaderEpoch = eo.Epoch
+			reqTopic.Partitions = append(reqTopic.Partitions, reqPartition)
+		}
+		req.Topics = append(req.Topics, reqTopic)
+	}
+
+	resp, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return err
+	}
+	for _, t := range resp.Topics {
+		for _, p := range t.Partitions {
+			err := kerr.ErrorForCode(p.ErrorCode)
+			if err == nil {
+				continue
+			}
+			if err == kerr.ProducerFenced || err == kerr.InvalidProducerEpoch {
+				cl.failProducerID(id, epoch, err)
+			}
+			return fmt.Errorf("topic %s partition %d: %w", t.Topic, p.Partition, err)
+		}
+	}
+	return nil
+}
+
 // commitTxn is ALMOST EXACTLY THE SAME as commit, but changed for txn types
 // and we avoid updateCommitted. We avoid updating because we manually
 // SetOffsets when ending the transaction.
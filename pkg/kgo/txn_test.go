@@ -3,6 +3,7 @@ package kgo
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -10,6 +11,9 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 // This test is identical to TestGroupETL but based around transactions.
@@ -251,3 +255,186 @@ func (c *testConsumer) transact(txnsBeforeQuit int) {
 		c.mu.Unlock()
 	}
 }
+
+// Offsets sent to a transaction are tied to the group with AddOffsetsToTxn and
+// committed to the group coordinator, fenced by the producer ID and epoch.
+func TestSendOffsetsToTransaction(t *testing.T) {
+	t.Parallel()
+	var (
+		mu        sync.Mutex
+		added     []string
+		commit    *kmsg.TxnOffsetCommitRequest
+		commitErr int16
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.InitProducerIDRequest:
+			resp := req.ResponseKind().(*kmsg.InitProducerIDResponse)
+			resp.ProducerID, resp.ProducerEpoch = 5, 1
+			return resp
+		case *kmsg.AddOffsetsToTxnRequest:
+			mu.Lock()
+			added = append(added, req.Group)
+			mu.Unlock()
+			return req.ResponseKind()
+		case *kmsg.TxnOffsetCommitRequest:
+			mu.Lock()
+			defer mu.Unlock()
+			commit = req
+			resp := req.ResponseKind().(*kmsg.TxnOffsetCommitResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewTxnOffsetCommitResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewTxnOffsetCommitResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.ErrorCode = commitErr
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		case *kmsg.EndTxnRequest:
+			return req.ResponseKind()
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		TransactionalID("txn"),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+
+	ctx := context.Background()
+	offsets := map[string]map[int32]EpochOffset{"t": {0: {Epoch: 2, Offset: 10}}}
+	if err := cl.SendOffsetsToTransaction(ctx, "g", offsets); err != errNotInTransaction {
+		t.Errorf("got err %v outside of a transaction, exp %v", err, errNotInTransaction)
+	}
+
+	if err := cl.BeginTransaction(); err != nil {
+		t.Fatalf("unable to begin transaction: %v", err)
+	}
+	if err := cl.SendOffsetsToTransaction(ctx, "g", offsets); err != nil {
+		t.Fatalf("unable to send offsets: %v", err)
+	}
+
+	mu.Lock()
+	if len(added) != 1 || added[0] != "g" {
+		t.Errorf("got AddOffsetsToTxn groups %v, exp [g]", added)
+	}
+	if commit.Group != "g" || commit.ProducerID != 5 || commit.ProducerEpoch != 1 || commit.Generation != -1 || commit.MemberID != "" {
+		t.Errorf("got commit group %s producer %d/%d member %q generation %d, exp g 5/1 \"\" -1",
+			commit.Group, commit.ProducerID, commit.ProducerEpoch, commit.MemberID, commit.Generation)
+	}
+	if len(commit.Topics) != 1 || len(commit.Topics[0].Partitions) != 1 ||
+		commit.Topics[0].Partitions[0].Offset != 10 || commit.Topics[0].Partitions[0].LeaderEpoch != 2 {
+		t.Errorf("got committed topics %+v, exp t[0]=10 at epoch 2", commit.Topics)
+	}
+	commitErr = kerr.IllegalGeneration.Code
+	mu.Unlock()
+
+	if err := cl.SendOffsetsToTransaction(ctx, "g", offsets); !errors.Is(err, kerr.IllegalGeneration) {
+		t.Errorf("got err %v, exp %v", err, kerr.IllegalGeneration)
+	}
+}
+
+// An empty transaction ends without any request, while a transaction that
+// only sent offsets still issues EndTxn to commit the offsets.
+func TestEndTransactionEmptyAndOffsetsOnly(t *testing.T) {
+	t.Parallel()
+	var (
+		mu     sync.Mutex
+		reqs   []int16
+		endTxn *kmsg.EndTxnRequest
+	)
+	cl := newFakeBrokerClient(t, func(kreq kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, kreq.Key())
+		switch req := kreq.(type) {
+		case *kmsg.MetadataRequest:
+			return fakeMetadataResponse(req, 0)
+		case *kmsg.FindCoordinatorRequest:
+			resp := req.ResponseKind().(*kmsg.FindCoordinatorResponse)
+			for _, k := range req.CoordinatorKeys {
+				c := kmsg.NewFindCoordinatorResponseCoordinator()
+				c.Key, c.Host, c.Port = k, "127.0.0.1", 9092
+				resp.Coordinators = append(resp.Coordinators, c)
+			}
+			return resp
+		case *kmsg.InitProducerIDRequest:
+			return req.ResponseKind()
+		case *kmsg.AddOffsetsToTxnRequest:
+			return req.ResponseKind()
+		case *kmsg.TxnOffsetCommitRequest:
+			resp := req.ResponseKind().(*kmsg.TxnOffsetCommitResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewTxnOffsetCommitResponseTopic()
+				st.Topic = rt.Topic
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewTxnOffsetCommitResponseTopicPartition()
+					sp.Partition = rp.Partition
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+			return resp
+		case *kmsg.EndTxnRequest:
+			endTxn = req
+			return req.ResponseKind()
+		}
+		t.Errorf("unexpected request %T", kreq)
+		return kreq.ResponseKind()
+	},
+		TransactionalID("txn"),
+		MetadataMinAge(10*time.Millisecond),
+	)
+	defer cl.Close()
+	ctx := context.Background()
+
+	if err := cl.BeginTransaction(); err != nil {
+		t.Fatalf("unable to begin transaction: %v", err)
+	}
+	if err := cl.EndTransaction(ctx, TryCommit); err != nil {
+		t.Fatalf("unable to end empty transaction: %v", err)
+	}
+	mu.Lock()
+	for _, key := range reqs {
+		switch key {
+		case 0, 24, 26: // Produce, AddPartitionsToTxn, EndTxn
+			t.Errorf("unexpected request key %d for an empty transaction", key)
+		}
+	}
+	mu.Unlock()
+
+	if err := cl.BeginTransaction(); err != nil {
+		t.Fatalf("unable to begin transaction: %v", err)
+	}
+	offsets := map[string]map[int32]EpochOffset{"t": {0: {Epoch: -1, Offset: 3}}}
+	if err := cl.SendOffsetsToTransaction(ctx, "g", offsets); err != nil {
+		t.Fatalf("unable to send offsets: %v", err)
+	}
+	if err := cl.EndTransaction(ctx, TryCommit); err != nil {
+		t.Fatalf("unable to end offsets only transaction: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if endTxn == nil || !endTxn.Commit {
+		t.Errorf("got EndTxn %+v, exp a commit", endTxn)
+	}
+	for _, key := range reqs {
+		if key == 0 {
+			t.Error("unexpected produce request in an offsets only transaction")
+		}
+	}
+}
@@ -29,11 +29,21 @@
 //
 // Most of this package is generated, but a few things are manual. What is
 // manual: all interfaces, the RequestFormatter, record / message / record
-// batch reading, and sticky member metadata serialization.
+// batch reading, record batch field recomputation, produce response record
+// offset mapping, __consumer_offsets record dispatching, and sticky member
+// metadata serialization.
+//
+// Serialization is deterministic: AppendTo produces identical bytes for
+// identical inputs. No AppendTo encodes by iterating a map; unknown tags,
+// the only map-backed field, are encoded in increasing tag order.
 package kmsg
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"sort"
 
 	"github.com/twmb/franz-go/pkg/kmsg/internal/kbin"
 )
@@ -82,7 +92,11 @@ type Request interface {
 	AppendTo([]byte) []byte
 	// ReadFrom parses all of the input slice into the response type.
 	//
-	// This should return an error if too little data is input.
+	// This should return an error if too little data is input. Trailing
+	// bytes after the known fields are not checked; all generated
+	// requests and responses also have ReadFromLenient, which decodes
+	// the same way but returns how many bytes were consumed so that
+	// trailing bytes can be detected.
 	ReadFrom([]byte) error
 	// ResponseKind returns an empty Response that is expected for
 	// this message request.
@@ -153,6 +167,45 @@ type ThrottleResponse interface {
 	Throttle() (int32, bool)
 }
 
+// ResponseError is a non-zero error code found somewhere in a response.
+//
+// The kerr package can be used to turn the code into an error.
+type ResponseError struct {
+	// Code is the non-zero error code.
+	Code int16
+	// Message is the error message that accompanies the code, if the
+	// response has one.
+	Message *string
+	// Field is the path to the error code field within the response,
+	// e.g. "Topics.Partitions.ErrorCode" or "ErrorCode" for a top level
+	// code.
+	Field string
+	// Topic is the topic the error code is for, or empty if the code is
+	// not scoped to a topic.
+	Topic string
+	// Partition is the partition the error code is for, or -1 if the code
+	// is not scoped to a partition.
+	Partition int32
+}
+
+// ErrorsResponse represents a response that contains error codes.
+//
+// All responses with an ErrorCode field, at the top level or in any nested
+// struct, implement this interface.
+type ErrorsResponse interface {
+	// Errors returns every non-zero error code in the response, in the
+	// order the codes appear in the response. Top level codes are
+	// returned as well as nested ones.
+	Errors() []ResponseError
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 // TimeoutRequest represents a request that can have a TimeoutMillis field.
 type TimeoutRequest interface {
 	// Timeout returns the request's timeout millis value.
@@ -232,6 +285,41 @@ func (f *RequestFormatter) AppendRequest(
 	return dst
 }
 
+// ReadResponse reads a full message response to the given request, returning
+// the decoded response. The input must be the full message: the four byte
+// length prefix, the response header, and the response body. This is the
+// counterpart to AppendRequest.
+//
+// The response is allocated with the request's ResponseKind and has its
+// version set to the request's version. The correlation ID in the response
+// header is not validated; callers that pipeline requests can read it from
+// in[4:8]. For flexible versions, tagged fields in the response header are
+// skipped, with the exception of ApiVersions, which never has a flexible
+// response header.
+func ReadResponse(r Request, in []byte) (Response, error) {
+	if len(in) < 8 {
+		return nil, kbin.ErrNotEnoughData
+	}
+	if size := int(binary.BigEndian.Uint32(in)); size != len(in)-4 {
+		return nil, fmt.Errorf("invalid response length %d for %d bytes of input", size, len(in)-4)
+	}
+	body := in[8:] // skip length and correlation ID
+	if r.IsFlexible() && r.Key() != 18 {
+		b := kbin.Reader{Src: body}
+		SkipTags(&b)
+		if err := b.Complete(); err != nil {
+			return nil, err
+		}
+		body = b.Src
+	}
+	resp := r.ResponseKind()
+	resp.SetVersion(r.GetVersion())
+	if err := resp.ReadFrom(body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // StringPtr is a helper to return a pointer to a string.
 func StringPtr(in string) *string {
 	return &in
@@ -293,6 +381,135 @@ func (s *StickyMemberMetadata) AppendTo(dst []byte) []byte {
 	return dst
 }
 
+// SetRecords encodes rs as the uncompressed Records of the batch and
+// recomputes every field that is derived from the records: each record's
+// Length, the batch's NumRecords, LastOffsetDelta, and MaxTimestamp, and the
+// compression bits of Attributes, which are cleared.
+//
+// This is meant for decode-mutate-encode cycles: after decoding and
+// modifying the records of a batch, SetRecords followed by AppendToRecomputed
+// produces a valid batch. If the records were decompressed to be decoded,
+// they are not recompressed.
+func (v *RecordBatch) SetRecords(rs []Record) {
+	var records []byte
+	var maxTimestampDelta int64
+	v.LastOffsetDelta = 0
+	for i := range rs {
+		r := &rs[i]
+
+		// The length is a varint of everything following it. We
+		// encode with a zero length, which is one byte, to learn the
+		// body length.
+		r.Length = 0
+		body := r.AppendTo(nil)[1:]
+		r.Length = int32(len(body))
+		records = kbin.AppendVarint(records, r.Length)
+		records = append(records, body...)
+
+		if r.OffsetDelta > v.LastOffsetDelta {
+			v.LastOffsetDelta = r.OffsetDelta
+		}
+		if d := int64(r.TimestampDelta); d > maxTimestampDelta {
+			maxTimestampDelta = d
+		}
+	}
+	v.Records = records
+	v.NumRecords = int32(len(rs))
+	v.MaxTimestamp = v.FirstTimestamp + maxTimestampDelta
+	v.Attributes &^= 0b0111
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// AppendToRecomputed appends the batch to dst, recomputing the Length and CRC
+// fields from the encoded batch rather than using the stored values. The
+// recomputed values are also saved in the batch.
+//
+// The stored Records are encoded as is; if records were modified, use
+// SetRecords first to recompute the fields derived from the records.
+func (v *RecordBatch) AppendToRecomputed(dst []byte) []byte {
+	start := len(dst)
+	dst = v.AppendTo(dst)
+	batch := dst[start:]
+
+	// FirstOffset (8) and Length (4) are not included in the length, and
+	// the CRC covers everything after the CRC field: after FirstOffset,
+	// Length, PartitionLeaderEpoch (4), Magic (1), and CRC (4).
+	v.Length = int32(len(batch) - 12)
+	kbin.AppendInt32(batch[8:8], v.Length)
+	v.CRC = int32(crc32.Checksum(batch[21:], crc32c))
+	kbin.AppendInt32(batch[17:17], v.CRC)
+	return dst
+}
+
+// ProducedRecord is the result for a single record sent in a ProduceRequest,
+// as returned from ProduceResponse.RecordOffsets.
+type ProducedRecord struct {
+	// Offset is the offset the record was written at, or -1 if the
+	// partition's produce failed.
+	Offset int64
+
+	// ErrorCode is the error code for the partition the record was
+	// produced to. Records are written or failed as a whole per
+	// partition.
+	ErrorCode int16
+
+	// ErrorMessage, if non-nil, is the error message for this record. If
+	// the partition has per-record errors (KIP-467), this is the record's
+	// own error message; otherwise, this is the partition's error message.
+	ErrorMessage *string
+}
+
+// RecordOffsets maps each partition's response back to the individual records
+// that were sent in the produce request. The counts argument is the number of
+// records that were sent per topic and partition; the returned slices contain
+// one ProducedRecord per record, in the order the records were sent.
+//
+// A successful partition assigns BaseOffset+i to the i'th record. Partitions
+// that errored have every record's Offset set to -1 and the partition's error
+// code set. Partitions in counts that are missing from the response are
+// missing from the returned map, and partitions in the response that are not
+// in counts are skipped.
+func (v *ProduceResponse) RecordOffsets(counts map[string]map[int32]int) map[string]map[int32][]ProducedRecord {
+	offsets := make(map[string]map[int32][]ProducedRecord)
+	for i := range v.Topics {
+		t := &v.Topics[i]
+		tcounts, ok := counts[t.Topic]
+		if !ok {
+			continue
+		}
+		for j := range t.Partitions {
+			p := &t.Partitions[j]
+			n, ok := tcounts[p.Partition]
+			if !ok {
+				continue
+			}
+			rs := make([]ProducedRecord, n)
+			for k := range rs {
+				r := &rs[k]
+				r.Offset = -1
+				r.ErrorCode = p.ErrorCode
+				r.ErrorMessage = p.ErrorMessage
+				if p.ErrorCode == 0 {
+					r.Offset = p.BaseOffset + int64(k)
+				}
+			}
+			for _, e := range p.ErrorRecords {
+				if e.RelativeOffset >= 0 && int(e.RelativeOffset) < n && e.ErrorMessage != nil {
+					rs[e.RelativeOffset].ErrorMessage = e.ErrorMessage
+				}
+			}
+			tOffsets := offsets[t.Topic]
+			if tOffsets == nil {
+				tOffsets = make(map[int32][]ProducedRecord)
+				offsets[t.Topic] = tOffsets
+			}
+			tOffsets[p.Partition] = rs
+		}
+	}
+	return offsets
+}
+
 // TagReader has is a type that has the ability to skip tags.
 //
 // This is effectively a trimmed version of the kbin.Reader, with the purpose
@@ -354,10 +571,18 @@ type Tags struct {
 // Len returns the number of keyvals in Tags.
 func (t *Tags) Len() int { return len(t.keyvals) }
 
-// Each calls fn for each key and val in the tags.
+// Each calls fn for each key and val in the tags, in increasing key order.
 func (t *Tags) Each(fn func(uint32, []byte)) {
-	for key, val := range t.keyvals {
-		fn(key, val)
+	if len(t.keyvals) == 0 {
+		return
+	}
+	keys := make([]uint32, 0, len(t.keyvals))
+	for key := range t.keyvals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		fn(key, t.keyvals[key])
 	}
 }
 
@@ -372,7 +597,8 @@ func (t *Tags) Set(key uint32, val []byte) {
 	t.keyvals[key] = val
 }
 
-// AppendEach appends each keyval in tags to dst and returns the updated dst.
+// AppendEach appends each keyval in tags to dst, in increasing key order, and
+// returns the updated dst.
 func (t *Tags) AppendEach(dst []byte) []byte {
 	t.Each(func(key uint32, val []byte) {
 		dst = kbin.AppendUvarint(dst, key)
@@ -381,3 +607,61 @@ func (t *Tags) AppendEach(dst []byte) []byte {
 	})
 	return dst
 }
+
+// ConsumerOffsetsRecord is a decoded record from Kafka's internal
+// __consumer_offsets topic; see ReadConsumerOffsetsRecord.
+//
+// Exactly one of OffsetCommitKey or GroupMetadataKey is non-nil. If the
+// record is a tombstone (a nil value), the corresponding value is nil: an
+// offset commit tombstone means the commit was deleted, and a group metadata
+// tombstone means the group was deleted.
+type ConsumerOffsetsRecord struct {
+	OffsetCommitKey   *OffsetCommitKey
+	OffsetCommitValue *OffsetCommitValue
+
+	GroupMetadataKey   *GroupMetadataKey
+	GroupMetadataValue *GroupMetadataValue
+}
+
+// ReadConsumerOffsetsRecord decodes the key and value of a record from Kafka's
+// internal __consumer_offsets topic. The key version determines the record
+// type: versions 0 and 1 are offset commits, and version 2 is group metadata.
+//
+// The format of this topic is internal to Kafka and changes between Kafka
+// versions. Values are decoded according to their own version field, and
+// every value version Kafka has written through 3.5 is supported. A key or
+// value version newer than this package knows may fail to decode, and newer
+// fields in a flexible value are kept in the value's UnknownTags.
+func ReadConsumerOffsetsRecord(key, value []byte) (ConsumerOffsetsRecord, error) {
+	var r ConsumerOffsetsRecord
+	if len(key) < 2 {
+		return r, fmt.Errorf("invalid __consumer_offsets key length %d", len(key))
+	}
+	switch version := int16(binary.BigEndian.Uint16(key)); version {
+	case 0, 1:
+		r.OffsetCommitKey = new(OffsetCommitKey)
+		if err := r.OffsetCommitKey.ReadFrom(key); err != nil {
+			return r, fmt.Errorf("unable to decode offset commit key: %w", err)
+		}
+		if value != nil {
+			r.OffsetCommitValue = new(OffsetCommitValue)
+			if err := r.OffsetCommitValue.ReadFrom(value); err != nil {
+				return r, fmt.Errorf("unable to decode offset commit value: %w", err)
+			}
+		}
+	case 2:
+		r.GroupMetadataKey = new(GroupMetadataKey)
+		if err := r.GroupMetadataKey.ReadFrom(key); err != nil {
+			return r, fmt.Errorf("unable to decode group metadata key: %w", err)
+		}
+		if value != nil {
+			r.GroupMetadataValue = new(GroupMetadataValue)
+			if err := r.GroupMetadataValue.ReadFrom(value); err != nil {
+				return r, fmt.Errorf("unable to decode group metadata value: %w", err)
+			}
+		}
+	default:
+		return r, fmt.Errorf("unknown __consumer_offsets key version %d", version)
+	}
+	return r, nil
+}
@@ -0,0 +1,318 @@
+package kmsg
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kmsg/internal/kbin"
+)
+
+func TestReadConsumerOffsetsRecord(t *testing.T) {
+	key := NewOffsetCommitKey()
+	key.Version = 1
+	key.Group, key.Topic, key.Partition = "g", "t", 3
+	rawKey := key.AppendTo(nil)
+
+	for _, version := range []int16{0, 1, 2, 3, 4} {
+		value := NewOffsetCommitValue()
+		value.Version = version
+		value.Offset = 10
+		if version >= 3 {
+			value.LeaderEpoch = 5
+		}
+		value.Metadata = "meta"
+		value.CommitTimestamp = 1000
+		if version == 1 {
+			value.ExpireTimestamp = 2000
+		}
+		r, err := ReadConsumerOffsetsRecord(rawKey, value.AppendTo(nil))
+		if err != nil {
+			t.Fatalf("v%d: unexpected err: %v", version, err)
+		}
+		if r.GroupMetadataKey != nil || !reflect.DeepEqual(*r.OffsetCommitKey, key) || !reflect.DeepEqual(*r.OffsetCommitValue, value) {
+			t.Errorf("v%d: got %+v, %+v != exp %+v, %+v", version, r.OffsetCommitKey, r.OffsetCommitValue, key, value)
+		}
+	}
+
+	r, err := ReadConsumerOffsetsRecord(rawKey, nil)
+	if err != nil || r.OffsetCommitKey == nil || r.OffsetCommitValue != nil {
+		t.Errorf("tombstone: got %+v, err %v, expected a key and no value", r, err)
+	}
+
+	gkey := NewGroupMetadataKey()
+	gkey.Version = 2
+	gkey.Group = "g"
+	for _, version := range []int16{0, 1, 2, 3, 4} {
+		value := NewGroupMetadataValue()
+		value.Version = version
+		value.ProtocolType = "consumer"
+		value.Generation = 4
+		value.Protocol = StringPtr("sticky")
+		value.Leader = StringPtr("m")
+		member := NewGroupMetadataValueMember()
+		member.MemberID = "m"
+		member.ClientID = "c"
+		member.ClientHost = "h"
+		member.SessionTimeoutMillis = 10
+		member.Subscription = []byte{1}
+		member.Assignment = []byte{2}
+		value.Members = append(value.Members, member)
+		r, err := ReadConsumerOffsetsRecord(gkey.AppendTo(nil), value.AppendTo(nil))
+		if err != nil {
+			t.Fatalf("group v%d: unexpected err: %v", version, err)
+		}
+		if r.OffsetCommitKey != nil || !reflect.DeepEqual(*r.GroupMetadataKey, gkey) || !reflect.DeepEqual(*r.GroupMetadataValue, value) {
+			t.Errorf("group v%d: got %+v != exp %+v", version, r.GroupMetadataValue, value)
+		}
+	}
+
+	if _, err := ReadConsumerOffsetsRecord([]byte{0, 9}, nil); err == nil {
+		t.Error("expected error for unknown key version")
+	}
+}
+
+func TestReadResponse(t *testing.T) {
+	appendResponse := func(corrID int32, headerTags []byte, resp Response) []byte {
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint32(buf[4:], uint32(corrID))
+		buf = append(buf, headerTags...)
+		buf = resp.AppendTo(buf)
+		binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+		return buf
+	}
+
+	for _, test := range []struct {
+		name       string
+		version    int16
+		headerTags []byte
+	}{
+		{"v1", 1, nil},
+		{"flexible", 9, []byte{0}},
+		{"flexible with header tags", 9, []byte{1, 5, 2, 0xaa, 0xbb}},
+	} {
+		req := NewPtrMetadataRequest()
+		req.Version = test.version
+		exp := NewPtrMetadataResponse()
+		exp.Version = test.version
+		b := NewMetadataResponseBroker()
+		b.NodeID, b.Host, b.Port = 1, "host", 9092
+		exp.Brokers = append(exp.Brokers, b)
+		exp.ControllerID = 1
+
+		resp, err := ReadResponse(req, appendResponse(7, test.headerTags, exp))
+		if err != nil {
+			t.Fatalf("%s: unable to read: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(resp, exp) {
+			t.Errorf("%s: got %+v != exp %+v", test.name, resp, exp)
+		}
+	}
+
+	// ApiVersions never has a flexible response header.
+	req := NewPtrApiVersionsRequest()
+	req.Version = 3
+	exp := NewPtrApiVersionsResponse()
+	exp.Version = 3
+	k := NewApiVersionsResponseApiKey()
+	k.ApiKey, k.MaxVersion = 3, 12
+	exp.ApiKeys = append(exp.ApiKeys, k)
+	resp, err := ReadResponse(req, appendResponse(7, nil, exp))
+	if err != nil {
+		t.Fatalf("api versions: unable to read: %v", err)
+	}
+	if !reflect.DeepEqual(resp, exp) {
+		t.Errorf("api versions: got %+v != exp %+v", resp, exp)
+	}
+
+	// The length prefix must match the input.
+	raw := appendResponse(7, nil, exp)
+	if _, err := ReadResponse(req, raw[:len(raw)-1]); err == nil {
+		t.Error("expected error for a truncated response")
+	}
+	if _, err := ReadResponse(req, raw[:7]); err == nil {
+		t.Error("expected error for a response shorter than its header")
+	}
+}
+
+func TestResponseErrors(t *testing.T) {
+	errorsOf := func(resp Response) []ResponseError {
+		t.Helper()
+		er, ok := resp.(ErrorsResponse)
+		if !ok {
+			t.Fatalf("%T does not implement ErrorsResponse", resp)
+		}
+		return er.Errors()
+	}
+
+	// Top level codes and nested group codes are not scoped to a
+	// partition; partition codes keep their topic and partition.
+	fetch := NewPtrOffsetFetchResponse()
+	fetch.ErrorCode = 15
+	g := NewOffsetFetchResponseGroup()
+	g.Group = "g"
+	g.ErrorCode = 16
+	gt := NewOffsetFetchResponseGroupTopic()
+	gt.Topic = "t"
+	for p := int32(0); p < 2; p++ {
+		gp := NewOffsetFetchResponseGroupTopicPartition()
+		gp.Partition = p
+		gp.ErrorCode = int16(p) * 3 // partition 0 has no error
+		gt.Partitions = append(gt.Partitions, gp)
+	}
+	g.Topics = append(g.Topics, gt)
+	fetch.Groups = append(fetch.Groups, g)
+
+	exp := []ResponseError{
+		{Code: 15, Field: "ErrorCode", Partition: -1},
+		{Code: 3, Field: "Groups.Topics.Partitions.ErrorCode", Topic: "t", Partition: 1},
+		{Code: 16, Field: "Groups.ErrorCode", Partition: -1},
+	}
+	if got := errorsOf(fetch); !reflect.DeepEqual(got, exp) {
+		t.Errorf("offset fetch: got %+v != exp %+v", got, exp)
+	}
+
+	// Nullable topics are reported as empty.
+	meta := NewPtrMetadataResponse()
+	for _, topic := range []*string{StringPtr("t"), nil} {
+		mt := NewMetadataResponseTopic()
+		mt.Topic = topic
+		mt.ErrorCode = 5
+		mp := NewMetadataResponseTopicPartition()
+		mp.Partition = 2
+		mp.ErrorCode = 6
+		mt.Partitions = append(mt.Partitions, mp)
+		meta.Topics = append(meta.Topics, mt)
+	}
+	exp = []ResponseError{
+		{Code: 5, Field: "Topics.ErrorCode", Topic: "t", Partition: -1},
+		{Code: 6, Field: "Topics.Partitions.ErrorCode", Topic: "t", Partition: 2},
+		{Code: 5, Field: "Topics.ErrorCode", Partition: -1},
+		{Code: 6, Field: "Topics.Partitions.ErrorCode", Partition: 2},
+	}
+	if got := errorsOf(meta); !reflect.DeepEqual(got, exp) {
+		t.Errorf("metadata: got %+v != exp %+v", got, exp)
+	}
+
+	// Error messages accompany their codes.
+	create := NewPtrCreateTopicsResponse()
+	ct := NewCreateTopicsResponseTopic()
+	ct.Topic = "t"
+	ct.ErrorCode = 36
+	ct.ErrorMessage = StringPtr("exists")
+	create.Topics = append(create.Topics, ct)
+	got := errorsOf(create)
+	if len(got) != 1 || got[0].Message == nil || *got[0].Message != "exists" || got[0].Code != 36 || got[0].Topic != "t" || got[0].Partition != -1 {
+		t.Errorf("create topics: got %+v, expected one topic error with a message", got)
+	}
+
+	if got := errorsOf(NewPtrMetadataResponse()); got != nil {
+		t.Errorf("got errors %+v for a response without errors", got)
+	}
+}
+
+func TestRecordBatchRecompute(t *testing.T) {
+	decodeRecords := func(raw []byte) []Record {
+		var rs []Record
+		for len(raw) > 0 {
+			l, n := kbin.Varint(raw)
+			var r Record
+			if err := r.ReadFrom(raw[:n+int(l)]); err != nil {
+				t.Fatalf("unable to decode record: %v", err)
+			}
+			rs = append(rs, r)
+			raw = raw[n+int(l):]
+		}
+		return rs
+	}
+
+	batch := NewRecordBatch()
+	batch.Magic = 2
+	batch.FirstTimestamp = 20
+	batch.Attributes = 0x0012 // transactional, "snappy" (cleared in SetRecords)
+	batch.SetRecords([]Record{
+		{TimestampDelta: 2, OffsetDelta: 0, Key: []byte("k1"), Value: []byte("v1")},
+		{TimestampDelta: 1, OffsetDelta: 1, Key: []byte("k2"), Value: []byte("v2")},
+	})
+	raw := batch.AppendToRecomputed(nil)
+
+	// Decode, mutate, and re-encode.
+	var decoded RecordBatch
+	if err := decoded.ReadFrom(raw); err != nil {
+		t.Fatalf("unable to decode batch: %v", err)
+	}
+	rs := decodeRecords(decoded.Records)
+	rs[1].Value = []byte("a much longer value than before")
+	rs[1].TimestampDelta = 9
+	rs = append(rs, Record{TimestampDelta: 3, OffsetDelta: 2, Value: []byte("v3")})
+	decoded.SetRecords(rs)
+	raw = decoded.AppendToRecomputed(nil)
+
+	var final RecordBatch
+	if err := final.ReadFrom(raw); err != nil {
+		t.Fatalf("unable to decode mutated batch: %v", err)
+	}
+	if exp := int32(len(raw) - 12); final.Length != exp {
+		t.Errorf("got length %d != exp %d", final.Length, exp)
+	}
+	if exp := int32(crc32.Checksum(raw[21:], crc32.MakeTable(crc32.Castagnoli))); final.CRC != exp {
+		t.Errorf("got crc %d != exp %d", final.CRC, exp)
+	}
+	if final.NumRecords != 3 || final.LastOffsetDelta != 2 || final.MaxTimestamp != 29 {
+		t.Errorf("got num records %d, last offset delta %d, max timestamp %d; exp 3, 2, 29", final.NumRecords, final.LastOffsetDelta, final.MaxTimestamp)
+	}
+	if final.Attributes != 0x0010 {
+		t.Errorf("got attributes %x != exp %x", final.Attributes, 0x0010)
+	}
+	finalRs := decodeRecords(final.Records)
+	if len(finalRs) != 3 || string(finalRs[1].Value) != "a much longer value than before" {
+		t.Errorf("mutated records did not round trip: %v", finalRs)
+	}
+}
+
+func TestProduceResponseRecordOffsets(t *testing.T) {
+	badRecord := "bad record"
+	badBatch := "bad batch"
+
+	resp := NewProduceResponse()
+	rt := NewProduceResponseTopic()
+	rt.Topic = "t"
+	for _, p := range []struct {
+		partition int32
+		base      int64
+		code      int16
+		msg       *string
+		errRecs   []ProduceResponseTopicPartitionErrorRecord
+	}{
+		{0, 100, 0, nil, nil},
+		{1, -1, 2, &badBatch, []ProduceResponseTopicPartitionErrorRecord{{RelativeOffset: 1, ErrorMessage: &badRecord}}},
+		{9, 5, 0, nil, nil}, // not in counts
+	} {
+		rp := NewProduceResponseTopicPartition()
+		rp.Partition = p.partition
+		rp.BaseOffset = p.base
+		rp.ErrorCode = p.code
+		rp.ErrorMessage = p.msg
+		rp.ErrorRecords = p.errRecs
+		rt.Partitions = append(rt.Partitions, rp)
+	}
+	resp.Topics = append(resp.Topics, rt)
+
+	got := resp.RecordOffsets(map[string]map[int32]int{
+		"t":       {0: 3, 1: 2, 2: 1},
+		"missing": {0: 1},
+	})
+	exp := map[string]map[int32][]ProducedRecord{
+		"t": {
+			0: {{Offset: 100}, {Offset: 101}, {Offset: 102}},
+			1: {
+				{Offset: -1, ErrorCode: 2, ErrorMessage: &badBatch},
+				{Offset: -1, ErrorCode: 2, ErrorMessage: &badRecord},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v != exp %v", got, exp)
+	}
+}
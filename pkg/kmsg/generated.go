@@ -13,7 +13,7 @@ import (
 
 // MaxKey is the maximum key used for any messages in this package.
 // Note that this value will change as Kafka adds more messages.
-const MaxKey = 67
+const MaxKey = 69
 
 // MessageV0 is the message format Kafka used prior to 0.10.
 //
@@ -490,7 +490,12 @@ type RecordBatch struct {
 	// PartitionLeaderEpoch is the leader epoch of the broker at the time
 	// this batch was written. Kafka uses this for cluster communication,
 	// but clients can also use this to better aid truncation detection.
-	// See KIP-320. Producers should set this to -1.
+	// See KIP-320. Producers should set this to -1; the broker sets the
+	// epoch when it writes the batch.
+	//
+	// This field is not covered by the CRC, so a relay that re-produces
+	// fetched batches can reset it to -1 (or preserve it) without
+	// recomputing the CRC.
 	PartitionLeaderEpoch int32
 
 	// Magic is the current "magic" number of this message format.
@@ -804,6 +809,9 @@ func NewOffsetCommitKey() OffsetCommitKey {
 //
 // KAFKA-7437 commit 9f7267dd2f, proposed in KIP-320 and included in 2.1.0
 // released version 3.
+//
+// KAFKA-14462, proposed in KIP-915 and included in 3.5.0 released version 4,
+// which is flexible (supports tagged fields).
 type OffsetCommitValue struct {
 	// Version is which encoding version this value is using.
 	Version int16
@@ -823,11 +831,17 @@ type OffsetCommitValue struct {
 	// ExpireTimestamp, introduced in v1 and dropped in v2 with KIP-111,
 	// is when this commit expires.
 	ExpireTimestamp int64 // v1+
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags // v4+
+
 }
 
 func (v *OffsetCommitValue) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
+	isFlexible := version >= 4
+	_ = isFlexible
 	{
 		v := v.Version
 		dst = kbin.AppendInt16(dst, v)
@@ -842,7 +856,11 @@ func (v *OffsetCommitValue) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Metadata
-		dst = kbin.AppendString(dst, v)
+		if isFlexible {
+			dst = kbin.AppendCompactString(dst, v)
+		} else {
+			dst = kbin.AppendString(dst, v)
+		}
 	}
 	{
 		v := v.CommitTimestamp
@@ -852,6 +870,10 @@ func (v *OffsetCommitValue) AppendTo(dst []byte) []byte {
 		v := v.ExpireTimestamp
 		dst = kbin.AppendInt64(dst, v)
 	}
+	if isFlexible {
+		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+		dst = v.UnknownTags.AppendEach(dst)
+	}
 	return dst
 }
 
@@ -861,6 +883,8 @@ func (v *OffsetCommitValue) ReadFrom(src []byte) error {
 	v.Version = b.Int16()
 	version := v.Version
 	_ = version
+	isFlexible := version >= 4
+	_ = isFlexible
 	s := v
 	{
 		v := b.Int64()
@@ -871,7 +895,12 @@ func (v *OffsetCommitValue) ReadFrom(src []byte) error {
 		s.LeaderEpoch = v
 	}
 	{
-		v := b.String()
+		var v string
+		if isFlexible {
+			v = b.CompactString()
+		} else {
+			v = b.String()
+		}
 		s.Metadata = v
 	}
 	{
@@ -882,8 +911,12 @@ func (v *OffsetCommitValue) ReadFrom(src []byte) error {
 		v := b.Int64()
 		s.ExpireTimestamp = v
 	}
+	if isFlexible {
+		s.UnknownTags = internalReadTags(&b)
+	}
 	return b.Complete()
 }
+func (v *OffsetCommitValue) IsFlexible() bool { return v.Version >= 4 }
 
 // Default sets any default fields. Calling this allows for future compatibility
 // if new fields are added to OffsetCommitValue.
@@ -976,6 +1009,10 @@ type GroupMetadataValueMember struct {
 
 	// Assignment is what the leader assigned this group member.
 	Assignment []byte
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags // v4+
+
 }
 
 // Default sets any default fields. Calling this allows for future compatibility
@@ -1004,6 +1041,9 @@ func NewGroupMetadataValueMember() GroupMetadataValueMember {
 //
 // KAFKA-7862 commit 0f995ba6be, proposed in KIP-345 and included in 2.3.0
 // released version 3.
+//
+// KAFKA-14462, proposed in KIP-915 and included in 3.5.0 released version 4,
+// which is flexible (supports tagged fields).
 type GroupMetadataValue struct {
 	// Version is the version of this value.
 	Version int16
@@ -1028,18 +1068,28 @@ type GroupMetadataValue struct {
 
 	// Members are the group members.
 	Members []GroupMetadataValueMember
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags // v4+
+
 }
 
 func (v *GroupMetadataValue) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
+	isFlexible := version >= 4
+	_ = isFlexible
 	{
 		v := v.Version
 		dst = kbin.AppendInt16(dst, v)
 	}
 	{
 		v := v.ProtocolType
-		dst = kbin.AppendString(dst, v)
+		if isFlexible {
+			dst = kbin.AppendCompactString(dst, v)
+		} else {
+			dst = kbin.AppendString(dst, v)
+		}
 	}
 	{
 		v := v.Generation
@@ -1047,11 +1097,19 @@ func (v *GroupMetadataValue) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Protocol
-		dst = kbin.AppendNullableString(dst, v)
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
 	}
 	{
 		v := v.Leader
-		dst = kbin.AppendNullableString(dst, v)
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
 	}
 	if version >= 2 {
 		v := v.CurrentStateTimestamp
@@ -1059,24 +1117,40 @@ func (v *GroupMetadataValue) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Members
-		dst = kbin.AppendArrayLen(dst, len(v))
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
 				v := v.MemberID
-				dst = kbin.AppendString(dst, v)
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
 			}
 			if version >= 3 {
 				v := v.InstanceID
-				dst = kbin.AppendNullableString(dst, v)
+				if isFlexible {
+					dst = kbin.AppendCompactNullableString(dst, v)
+				} else {
+					dst = kbin.AppendNullableString(dst, v)
+				}
 			}
 			{
 				v := v.ClientID
-				dst = kbin.AppendString(dst, v)
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
 			}
 			{
 				v := v.ClientHost
-				dst = kbin.AppendString(dst, v)
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
 			}
 			if version >= 1 {
 				v := v.RebalanceTimeoutMillis
@@ -1088,14 +1162,30 @@ func (v *GroupMetadataValue) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Subscription
-				dst = kbin.AppendBytes(dst, v)
+				if isFlexible {
+					dst = kbin.AppendCompactBytes(dst, v)
+				} else {
+					dst = kbin.AppendBytes(dst, v)
+				}
 			}
 			{
 				v := v.Assignment
-				dst = kbin.AppendBytes(dst, v)
+				if isFlexible {
+					dst = kbin.AppendCompactBytes(dst, v)
+				} else {
+					dst = kbin.AppendBytes(dst, v)
+				}
+			}
+			if isFlexible {
+				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+				dst = v.UnknownTags.AppendEach(dst)
 			}
 		}
 	}
+	if isFlexible {
+		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+		dst = v.UnknownTags.AppendEach(dst)
+	}
 	return dst
 }
 
@@ -1105,9 +1195,16 @@ func (v *GroupMetadataValue) ReadFrom(src []byte) error {
 	v.Version = b.Int16()
 	version := v.Version
 	_ = version
+	isFlexible := version >= 4
+	_ = isFlexible
 	s := v
 	{
-		v := b.String()
+		var v string
+		if isFlexible {
+			v = b.CompactString()
+		} else {
+			v = b.String()
+		}
 		s.ProtocolType = v
 	}
 	{
@@ -1115,11 +1212,21 @@ func (v *GroupMetadataValue) ReadFrom(src []byte) error {
 		s.Generation = v
 	}
 	{
-		v := b.NullableString()
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
 		s.Protocol = v
 	}
 	{
-		v := b.NullableString()
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
 		s.Leader = v
 	}
 	if version >= 2 {
@@ -1130,7 +1237,7 @@ func (v *GroupMetadataValue) ReadFrom(src []byte) error {
 		v := s.Members
 		a := v
 		var l int32
-		l = b.ArrayLen()
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -1142,19 +1249,39 @@ func (v *GroupMetadataValue) ReadFrom(src []byte) error {
 			v.Default()
 			s := v
 			{
-				v := b.String()
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
 				s.MemberID = v
 			}
 			if version >= 3 {
-				v := b.NullableString()
+				var v *string
+				if isFlexible {
+					v = b.CompactNullableString()
+				} else {
+					v = b.NullableString()
+				}
 				s.InstanceID = v
 			}
 			{
-				v := b.String()
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
 				s.ClientID = v
 			}
 			{
-				v := b.String()
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
 				s.ClientHost = v
 			}
 			if version >= 1 {
@@ -1166,19 +1293,36 @@ func (v *GroupMetadataValue) ReadFrom(src []byte) error {
 				s.SessionTimeoutMillis = v
 			}
 			{
-				v := b.Bytes()
+				var v []byte
+				if isFlexible {
+					v = b.CompactBytes()
+				} else {
+					v = b.Bytes()
+				}
 				s.Subscription = v
 			}
 			{
-				v := b.Bytes()
+				var v []byte
+				if isFlexible {
+					v = b.CompactBytes()
+				} else {
+					v = b.Bytes()
+				}
 				s.Assignment = v
 			}
+			if isFlexible {
+				s.UnknownTags = internalReadTags(&b)
+			}
 		}
 		v = a
 		s.Members = v
 	}
+	if isFlexible {
+		s.UnknownTags = internalReadTags(&b)
+	}
 	return b.Complete()
 }
+func (v *GroupMetadataValue) IsFlexible() bool { return v.Version >= 4 }
 
 // Default sets any default fields. Calling this allows for future compatibility
 // if new fields are added to GroupMetadataValue.
@@ -2390,11 +2534,7 @@ func (v *LeaderChangeMessage) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Voters
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -2409,11 +2549,7 @@ func (v *LeaderChangeMessage) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.GrantingVoters
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -2450,11 +2586,7 @@ func (v *LeaderChangeMessage) ReadFrom(src []byte) error {
 		v := s.Voters
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -2480,11 +2612,7 @@ func (v *LeaderChangeMessage) ReadFrom(src []byte) error {
 		v := s.GrantingVoters
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -2661,11 +2789,7 @@ func (v *ProduceRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -2678,11 +2802,7 @@ func (v *ProduceRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -2717,6 +2837,23 @@ func (v *ProduceRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ProduceRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ProduceRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ProduceRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -2745,11 +2882,7 @@ func (v *ProduceRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -2773,11 +2906,7 @@ func (v *ProduceRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -2818,6 +2947,9 @@ func (v *ProduceRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -3052,6 +3184,19 @@ func (v *ProduceResponse) GetVersion() int16        { return v.Version }
 func (v *ProduceResponse) IsFlexible() bool         { return v.Version >= 9 }
 func (v *ProduceResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 6 }
 func (v *ProduceResponse) RequestKind() Request     { return &ProduceRequest{Version: v.Version} }
+func (v *ProduceResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Message: v2.ErrorMessage, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *ProduceResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -3060,11 +3205,7 @@ func (v *ProduceResponse) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -3077,11 +3218,7 @@ func (v *ProduceResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -3106,11 +3243,7 @@ func (v *ProduceResponse) AppendTo(dst []byte) []byte {
 					}
 					if version >= 8 {
 						v := v.ErrorRecords
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -3163,6 +3296,23 @@ func (v *ProduceResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ProduceResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ProduceResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ProduceResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -3174,11 +3324,7 @@ func (v *ProduceResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -3202,11 +3348,7 @@ func (v *ProduceResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -3241,11 +3383,7 @@ func (v *ProduceResponse) ReadFrom(src []byte) error {
 						v := s.ErrorRecords
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -3306,6 +3444,9 @@ func (v *ProduceResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -3578,11 +3719,7 @@ func (v *FetchRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			if version >= 0 && version <= 12 {
@@ -3599,11 +3736,7 @@ func (v *FetchRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -3644,11 +3777,7 @@ func (v *FetchRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 7 {
 		v := v.ForgottenTopics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			if version >= 7 && version <= 12 {
@@ -3665,11 +3794,7 @@ func (v *FetchRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -3723,6 +3848,23 @@ func (v *FetchRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *FetchRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *FetchRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *FetchRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -3762,11 +3904,7 @@ func (v *FetchRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -3794,11 +3932,7 @@ func (v *FetchRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -3851,11 +3985,7 @@ func (v *FetchRequest) ReadFrom(src []byte) error {
 		v := s.ForgottenTopics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -3883,11 +4013,7 @@ func (v *FetchRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -3937,6 +4063,9 @@ func (v *FetchRequest) ReadFrom(src []byte) error {
 			}
 		}
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -4140,6 +4269,8 @@ type FetchResponseTopicPartition struct {
 	// In case divergence is detected based on the LastFetchedEpoch and
 	// FetchOffset in the request, this field indicates the largest epoch and
 	// its end offset such that subsequent records are known to diverge.
+	// This is only returned to followers (KIP-595); consumers detect
+	// truncation with OffsetForLeaderEpoch and can ignore this field.
 	DivergingEpoch FetchResponseTopicPartitionDivergingEpoch // tag 0
 
 	// CurrentLeader is the currently known leader ID and epoch for this
@@ -4148,7 +4279,8 @@ type FetchResponseTopicPartition struct {
 
 	// In the case of fetching an offset less than the LogStartOffset, this
 	// is the end offset and epoch that should be used in the FetchSnapshot
-	// request.
+	// request. This is only returned to KRaft followers fetching the
+	// metadata log; consumers can ignore this field.
 	SnapshotID FetchResponseTopicPartitionSnapshotID // tag 2
 
 	// AbortedTransactions is an array of aborted transactions within the
@@ -4289,6 +4421,22 @@ func (v *FetchResponse) GetVersion() int16        { return v.Version }
 func (v *FetchResponse) IsFlexible() bool         { return v.Version >= 12 }
 func (v *FetchResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 8 }
 func (v *FetchResponse) RequestKind() Request     { return &FetchRequest{Version: v.Version} }
+func (v *FetchResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *FetchResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -4309,11 +4457,7 @@ func (v *FetchResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			if version >= 0 && version <= 12 {
@@ -4330,11 +4474,7 @@ func (v *FetchResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -4359,11 +4499,7 @@ func (v *FetchResponse) AppendTo(dst []byte) []byte {
 					}
 					if version >= 4 {
 						v := v.AbortedTransactions
-						if isFlexible {
-							dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-						} else {
-							dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-						}
+						dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -4513,6 +4649,23 @@ func (v *FetchResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *FetchResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *FetchResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *FetchResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -4536,11 +4689,7 @@ func (v *FetchResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -4568,11 +4717,7 @@ func (v *FetchResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -4607,11 +4752,7 @@ func (v *FetchResponse) ReadFrom(src []byte) error {
 						v := s.AbortedTransactions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if version < 0 || l == 0 {
 							a = []FetchResponseTopicPartitionAbortedTransaction{}
 						}
@@ -4732,6 +4873,9 @@ func (v *FetchResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -4909,11 +5053,7 @@ func (v *ListOffsetsRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -4926,11 +5066,7 @@ func (v *ListOffsetsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -4969,6 +5105,23 @@ func (v *ListOffsetsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListOffsetsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListOffsetsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListOffsetsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -4988,11 +5141,7 @@ func (v *ListOffsetsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -5016,11 +5165,7 @@ func (v *ListOffsetsRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -5064,6 +5209,9 @@ func (v *ListOffsetsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -5231,6 +5379,19 @@ func (v *ListOffsetsResponse) GetVersion() int16        { return v.Version }
 func (v *ListOffsetsResponse) IsFlexible() bool         { return v.Version >= 6 }
 func (v *ListOffsetsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 3 }
 func (v *ListOffsetsResponse) RequestKind() Request     { return &ListOffsetsRequest{Version: v.Version} }
+func (v *ListOffsetsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *ListOffsetsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -5243,11 +5404,7 @@ func (v *ListOffsetsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -5260,11 +5417,7 @@ func (v *ListOffsetsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -5277,11 +5430,7 @@ func (v *ListOffsetsResponse) AppendTo(dst []byte) []byte {
 					}
 					if version >= 0 && version <= 0 {
 						v := v.OldStyleOffsets
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt64(dst, v)
@@ -5319,6 +5468,23 @@ func (v *ListOffsetsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListOffsetsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListOffsetsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListOffsetsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -5334,11 +5500,7 @@ func (v *ListOffsetsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -5362,11 +5524,7 @@ func (v *ListOffsetsResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -5389,11 +5547,7 @@ func (v *ListOffsetsResponse) ReadFrom(src []byte) error {
 						v := s.OldStyleOffsets
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -5436,6 +5590,9 @@ func (v *ListOffsetsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -5545,17 +5702,9 @@ func (v *MetadataRequest) AppendTo(dst []byte) []byte {
 	{
 		v := v.Topics
 		if version > 1 {
-			if isFlexible {
-				dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-			} else {
-				dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-			}
+			dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		} else {
-			if isFlexible {
-				dst = kbin.AppendCompactArrayLen(dst, len(v))
-			} else {
-				dst = kbin.AppendArrayLen(dst, len(v))
-			}
+			dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		}
 		for i := range v {
 			v := &v[i]
@@ -5612,6 +5761,23 @@ func (v *MetadataRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *MetadataRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *MetadataRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *MetadataRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -5623,11 +5789,7 @@ func (v *MetadataRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 1 || l == 0 {
 			a = []MetadataRequestTopic{}
 		}
@@ -5686,6 +5848,9 @@ func (v *MetadataRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -5902,6 +6067,22 @@ func (v *MetadataResponse) GetVersion() int16        { return v.Version }
 func (v *MetadataResponse) IsFlexible() bool         { return v.Version >= 9 }
 func (v *MetadataResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 6 }
 func (v *MetadataResponse) RequestKind() Request     { return &MetadataRequest{Version: v.Version} }
+func (v *MetadataResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Topic: derefString(v1.Topic), Field: "Topics.ErrorCode", Partition: -1})
+		}
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: derefString(v1.Topic), Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -5914,11 +6095,7 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Brokers
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -5965,11 +6142,7 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -5994,11 +6167,7 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -6019,11 +6188,7 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6031,11 +6196,7 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.ISR
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6043,11 +6204,7 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 					}
 					if version >= 5 {
 						v := v.OfflineReplicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6081,6 +6238,23 @@ func (v *MetadataResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *MetadataResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *MetadataResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *MetadataResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -6096,11 +6270,7 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 		v := s.Brokers
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -6161,11 +6331,7 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -6201,11 +6367,7 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -6236,11 +6398,7 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -6258,11 +6416,7 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 						v := s.ISR
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -6280,11 +6434,7 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 						v := s.OfflineReplicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -6323,6 +6473,9 @@ func (v *MetadataResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -6374,6 +6527,10 @@ type LeaderAndISRRequestTopicPartition struct {
 
 	IsNew bool // v1+
 
+	// LeaderRecoveryState is whether the leader is recovering from an unclean
+	// leader election (1) or not (0), introduced in KIP-704.
+	LeaderRecoveryState int8 // v6+
+
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags // v4+
 
@@ -6478,7 +6635,8 @@ func NewLeaderAndISRRequestLiveLeader() LeaderAndISRRequestLiveLeader {
 //
 // Kafka 1.0.0 introduced version 1. Kafka 2.2.0 introduced version 2, proposed
 // in KIP-380, which changed the layout of the struct to be more memory
-// efficient. Kafka 2.4.0 introduced version 3 with KIP-455.
+// efficient. Kafka 2.4.0 introduced version 3 with KIP-455. Kafka 3.2.0
+// introduced version 6 with KIP-704.
 type LeaderAndISRRequest struct {
 	// Version is the version of this message used with a Kafka broker.
 	Version int16
@@ -6504,7 +6662,7 @@ type LeaderAndISRRequest struct {
 }
 
 func (*LeaderAndISRRequest) Key() int16                 { return 4 }
-func (*LeaderAndISRRequest) MaxVersion() int16          { return 5 }
+func (*LeaderAndISRRequest) MaxVersion() int16          { return 6 }
 func (v *LeaderAndISRRequest) SetVersion(version int16) { v.Version = version }
 func (v *LeaderAndISRRequest) GetVersion() int16        { return v.Version }
 func (v *LeaderAndISRRequest) IsFlexible() bool         { return v.Version >= 4 }
@@ -6544,11 +6702,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 0 && version <= 1 {
 		v := v.PartitionStates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			if version >= 0 && version <= 1 {
@@ -6577,11 +6731,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.ISR
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -6593,11 +6743,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Replicas
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -6605,11 +6751,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 			}
 			if version >= 3 {
 				v := v.AddingReplicas
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -6617,11 +6759,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 			}
 			if version >= 3 {
 				v := v.RemovingReplicas
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -6631,6 +6769,10 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 				v := v.IsNew
 				dst = kbin.AppendBool(dst, v)
 			}
+			if version >= 6 {
+				v := v.LeaderRecoveryState
+				dst = kbin.AppendInt8(dst, v)
+			}
 			if isFlexible {
 				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 				dst = v.UnknownTags.AppendEach(dst)
@@ -6639,11 +6781,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 2 {
 		v := v.TopicStates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -6660,11 +6798,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.PartitionStates
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					if version >= 0 && version <= 1 {
@@ -6693,11 +6827,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.ISR
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6709,11 +6839,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6721,11 +6847,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 					}
 					if version >= 3 {
 						v := v.AddingReplicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6733,11 +6855,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 					}
 					if version >= 3 {
 						v := v.RemovingReplicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -6747,6 +6865,10 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 						v := v.IsNew
 						dst = kbin.AppendBool(dst, v)
 					}
+					if version >= 6 {
+						v := v.LeaderRecoveryState
+						dst = kbin.AppendInt8(dst, v)
+					}
 					if isFlexible {
 						dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 						dst = v.UnknownTags.AppendEach(dst)
@@ -6761,11 +6883,7 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.LiveLeaders
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -6798,6 +6916,23 @@ func (v *LeaderAndISRRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *LeaderAndISRRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *LeaderAndISRRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -6825,11 +6960,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 		v := s.PartitionStates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -6869,11 +7000,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 				v := s.ISR
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -6895,11 +7022,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 				v := s.Replicas
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -6917,11 +7040,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 				v := s.AddingReplicas
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -6939,11 +7058,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 				v := s.RemovingReplicas
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -6961,6 +7076,10 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 				v := b.Bool()
 				s.IsNew = v
 			}
+			if version >= 6 {
+				v := b.Int8()
+				s.LeaderRecoveryState = v
+			}
 			if isFlexible {
 				s.UnknownTags = internalReadTags(&b)
 			}
@@ -6972,11 +7091,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 		v := s.TopicStates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -7004,11 +7119,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 				v := s.PartitionStates
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -7048,11 +7159,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 						v := s.ISR
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -7074,11 +7181,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -7096,11 +7199,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 						v := s.AddingReplicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -7118,11 +7217,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 						v := s.RemovingReplicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -7140,6 +7235,10 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 						v := b.Bool()
 						s.IsNew = v
 					}
+					if version >= 6 {
+						v := b.Int8()
+						s.LeaderRecoveryState = v
+					}
 					if isFlexible {
 						s.UnknownTags = internalReadTags(&b)
 					}
@@ -7158,11 +7257,7 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 		v := s.LiveLeaders
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -7200,6 +7295,9 @@ func (v *LeaderAndISRRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -7265,11 +7363,33 @@ type LeaderAndISRResponse struct {
 }
 
 func (*LeaderAndISRResponse) Key() int16                 { return 4 }
-func (*LeaderAndISRResponse) MaxVersion() int16          { return 5 }
+func (*LeaderAndISRResponse) MaxVersion() int16          { return 6 }
 func (v *LeaderAndISRResponse) SetVersion(version int16) { v.Version = version }
 func (v *LeaderAndISRResponse) GetVersion() int16        { return v.Version }
 func (v *LeaderAndISRResponse) IsFlexible() bool         { return v.Version >= 4 }
 func (v *LeaderAndISRResponse) RequestKind() Request     { return &LeaderAndISRRequest{Version: v.Version} }
+func (v *LeaderAndISRResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Partitions {
+		v1 := &v.Partitions[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Topic: v1.Topic, Field: "Partitions.ErrorCode", Partition: v1.Partition})
+		}
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v2.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *LeaderAndISRResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -7282,11 +7402,7 @@ func (v *LeaderAndISRResponse) AppendTo(dst []byte) []byte {
 	}
 	if version >= 0 && version <= 4 {
 		v := v.Partitions
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			if version >= 0 && version <= 4 {
@@ -7313,11 +7429,7 @@ func (v *LeaderAndISRResponse) AppendTo(dst []byte) []byte {
 	}
 	if version >= 5 {
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -7326,11 +7438,7 @@ func (v *LeaderAndISRResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					if version >= 0 && version <= 4 {
@@ -7369,6 +7477,23 @@ func (v *LeaderAndISRResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *LeaderAndISRResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *LeaderAndISRResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *LeaderAndISRResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -7384,11 +7509,7 @@ func (v *LeaderAndISRResponse) ReadFrom(src []byte) error {
 		v := s.Partitions
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -7427,11 +7548,7 @@ func (v *LeaderAndISRResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -7450,11 +7567,7 @@ func (v *LeaderAndISRResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -7499,6 +7612,9 @@ func (v *LeaderAndISRResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -7646,11 +7762,7 @@ func (v *StopReplicaRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -7667,11 +7779,7 @@ func (v *StopReplicaRequest) AppendTo(dst []byte) []byte {
 			}
 			if version >= 1 && version <= 2 {
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -7679,11 +7787,7 @@ func (v *StopReplicaRequest) AppendTo(dst []byte) []byte {
 			}
 			if version >= 3 {
 				v := v.PartitionStates
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -7718,6 +7822,23 @@ func (v *StopReplicaRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *StopReplicaRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *StopReplicaRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *StopReplicaRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -7745,11 +7866,7 @@ func (v *StopReplicaRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -7777,11 +7894,7 @@ func (v *StopReplicaRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -7799,11 +7912,7 @@ func (v *StopReplicaRequest) ReadFrom(src []byte) error {
 				v := s.PartitionStates
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -7843,6 +7952,9 @@ func (v *StopReplicaRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -7914,6 +8026,19 @@ func (v *StopReplicaResponse) SetVersion(version int16) { v.Version = version }
 func (v *StopReplicaResponse) GetVersion() int16        { return v.Version }
 func (v *StopReplicaResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *StopReplicaResponse) RequestKind() Request     { return &StopReplicaRequest{Version: v.Version} }
+func (v *StopReplicaResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Partitions {
+		v1 := &v.Partitions[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Topic: v1.Topic, Field: "Partitions.ErrorCode", Partition: v1.Partition})
+		}
+	}
+	return errs
+}
 
 func (v *StopReplicaResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -7926,11 +8051,7 @@ func (v *StopReplicaResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Partitions
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -7963,6 +8084,23 @@ func (v *StopReplicaResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *StopReplicaResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *StopReplicaResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *StopReplicaResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -7978,11 +8116,7 @@ func (v *StopReplicaResponse) ReadFrom(src []byte) error {
 		v := s.Partitions
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -8020,6 +8154,9 @@ func (v *StopReplicaResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -8061,7 +8198,7 @@ type UpdateMetadataRequestTopicPartition struct {
 
 	Replicas []int32
 
-	OfflineReplicas []int32
+	OfflineReplicas []int32 // v4+
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags // v6+
@@ -8231,11 +8368,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 0 && version <= 4 {
 		v := v.PartitionStates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			if version >= 0 && version <= 4 {
@@ -8264,11 +8397,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.ISR
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -8280,23 +8409,15 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Replicas
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
 				}
 			}
-			{
+			if version >= 4 {
 				v := v.OfflineReplicas
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -8310,11 +8431,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 5 {
 		v := v.TopicStates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -8331,11 +8448,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.PartitionStates
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					if version >= 0 && version <= 4 {
@@ -8364,11 +8477,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.ISR
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -8380,23 +8489,15 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
 						}
 					}
-					{
+					if version >= 4 {
 						v := v.OfflineReplicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -8416,11 +8517,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.LiveBrokers
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -8441,11 +8538,7 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 			}
 			if version >= 1 {
 				v := v.Endpoints
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -8500,6 +8593,23 @@ func (v *UpdateMetadataRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *UpdateMetadataRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *UpdateMetadataRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -8523,11 +8633,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 		v := s.PartitionStates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -8567,11 +8673,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 				v := s.ISR
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -8593,11 +8695,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 				v := s.Replicas
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -8611,15 +8709,11 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 				v = a
 				s.Replicas = v
 			}
-			{
+			if version >= 4 {
 				v := s.OfflineReplicas
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -8644,11 +8738,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 		v := s.TopicStates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -8676,11 +8766,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 				v := s.PartitionStates
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -8720,11 +8806,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 						v := s.ISR
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -8746,11 +8828,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -8764,15 +8842,11 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 						v = a
 						s.Replicas = v
 					}
-					{
+					if version >= 4 {
 						v := s.OfflineReplicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -8804,11 +8878,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 		v := s.LiveBrokers
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -8840,11 +8910,7 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 				v := s.Endpoints
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -8907,6 +8973,9 @@ func (v *UpdateMetadataRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -8953,6 +9022,14 @@ func (v *UpdateMetadataResponse) RequestKind() Request {
 	return &UpdateMetadataRequest{Version: v.Version}
 }
 
+func (v *UpdateMetadataResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *UpdateMetadataResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -8970,6 +9047,23 @@ func (v *UpdateMetadataResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *UpdateMetadataResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *UpdateMetadataResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *UpdateMetadataResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -8984,6 +9078,9 @@ func (v *UpdateMetadataResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -9072,6 +9169,23 @@ func (v *ControlledShutdownRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ControlledShutdownRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ControlledShutdownRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ControlledShutdownRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -9090,6 +9204,9 @@ func (v *ControlledShutdownRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -9161,6 +9278,14 @@ func (v *ControlledShutdownResponse) RequestKind() Request {
 	return &ControlledShutdownRequest{Version: v.Version}
 }
 
+func (v *ControlledShutdownResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *ControlledShutdownResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -9172,11 +9297,7 @@ func (v *ControlledShutdownResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.PartitionsRemaining
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -9205,6 +9326,23 @@ func (v *ControlledShutdownResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ControlledShutdownResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ControlledShutdownResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ControlledShutdownResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -9220,11 +9358,7 @@ func (v *ControlledShutdownResponse) ReadFrom(src []byte) error {
 		v := s.PartitionsRemaining
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -9258,6 +9392,9 @@ func (v *ControlledShutdownResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -9357,6 +9494,9 @@ func NewOffsetCommitRequestTopic() OffsetCommitRequestTopic {
 
 // OffsetCommitRequest commits offsets for consumed topics / partitions in
 // a group.
+//
+// Version 9 is the first version that can be used with the consumer group
+// protocol introduced in KIP-848; it is otherwise the same as version 8.
 type OffsetCommitRequest struct {
 	// Version is the version of this message used with a Kafka broker.
 	Version int16
@@ -9367,6 +9507,9 @@ type OffsetCommitRequest struct {
 	// Generation being -1 and group being empty means the group is being used
 	// to store offsets only. No generation validation, no rebalancing.
 	//
+	// For groups using the KIP-848 consumer group protocol (v9+), this is the
+	// member epoch rather than the generation.
+	//
 	// This field has a default of -1.
 	Generation int32 // v1+
 
@@ -9402,7 +9545,7 @@ type OffsetCommitRequest struct {
 }
 
 func (*OffsetCommitRequest) Key() int16                   { return 8 }
-func (*OffsetCommitRequest) MaxVersion() int16            { return 8 }
+func (*OffsetCommitRequest) MaxVersion() int16            { return 9 }
 func (v *OffsetCommitRequest) SetVersion(version int16)   { v.Version = version }
 func (v *OffsetCommitRequest) GetVersion() int16          { return v.Version }
 func (v *OffsetCommitRequest) IsFlexible() bool           { return v.Version >= 8 }
@@ -9459,11 +9602,7 @@ func (v *OffsetCommitRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -9476,11 +9615,7 @@ func (v *OffsetCommitRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -9527,6 +9662,23 @@ func (v *OffsetCommitRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetCommitRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetCommitRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetCommitRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -9573,11 +9725,7 @@ func (v *OffsetCommitRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -9601,11 +9749,7 @@ func (v *OffsetCommitRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -9658,6 +9802,9 @@ func (v *OffsetCommitRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -9721,6 +9868,9 @@ type OffsetCommitResponseTopicPartition struct {
 	//
 	// INVALID_COMMIT_OFFSET_SIZE is returned if the offset commit results in
 	// a record batch that is too large (likely due to large metadata).
+	//
+	// STALE_MEMBER_EPOCH is returned for v9+ if the request's member epoch
+	// is older than the member's current epoch in a consumer group.
 	ErrorCode int16
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -9789,12 +9939,25 @@ type OffsetCommitResponse struct {
 }
 
 func (*OffsetCommitResponse) Key() int16                 { return 8 }
-func (*OffsetCommitResponse) MaxVersion() int16          { return 8 }
+func (*OffsetCommitResponse) MaxVersion() int16          { return 9 }
 func (v *OffsetCommitResponse) SetVersion(version int16) { v.Version = version }
 func (v *OffsetCommitResponse) GetVersion() int16        { return v.Version }
 func (v *OffsetCommitResponse) IsFlexible() bool         { return v.Version >= 8 }
 func (v *OffsetCommitResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 4 }
 func (v *OffsetCommitResponse) RequestKind() Request     { return &OffsetCommitRequest{Version: v.Version} }
+func (v *OffsetCommitResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *OffsetCommitResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -9807,11 +9970,7 @@ func (v *OffsetCommitResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -9824,11 +9983,7 @@ func (v *OffsetCommitResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -9859,6 +10014,23 @@ func (v *OffsetCommitResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetCommitResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetCommitResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetCommitResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -9874,11 +10046,7 @@ func (v *OffsetCommitResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -9902,11 +10070,7 @@ func (v *OffsetCommitResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -9942,6 +10106,9 @@ func (v *OffsetCommitResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -10017,6 +10184,17 @@ func NewOffsetFetchRequestGroupTopic() OffsetFetchRequestGroupTopic {
 type OffsetFetchRequestGroup struct {
 	Group string
 
+	// MemberID, for groups using the KIP-848 consumer group protocol, is the
+	// ID of the member fetching offsets. If set, the broker validates the
+	// member and its epoch.
+	MemberID *string // v9+
+
+	// MemberEpoch is the member epoch of MemberID, or -1 if MemberID is not
+	// set.
+	//
+	// This field has a default of -1.
+	MemberEpoch int32 // v9+
+
 	Topics []OffsetFetchRequestGroupTopic
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -10027,6 +10205,7 @@ type OffsetFetchRequestGroup struct {
 // Default sets any default fields. Calling this allows for future compatibility
 // if new fields are added to OffsetFetchRequestGroup.
 func (v *OffsetFetchRequestGroup) Default() {
+	v.MemberEpoch = -1
 }
 
 // NewOffsetFetchRequestGroup returns a default OffsetFetchRequestGroup
@@ -10069,7 +10248,7 @@ type OffsetFetchRequest struct {
 }
 
 func (*OffsetFetchRequest) Key() int16                   { return 9 }
-func (*OffsetFetchRequest) MaxVersion() int16            { return 8 }
+func (*OffsetFetchRequest) MaxVersion() int16            { return 9 }
 func (v *OffsetFetchRequest) SetVersion(version int16)   { v.Version = version }
 func (v *OffsetFetchRequest) GetVersion() int16          { return v.Version }
 func (v *OffsetFetchRequest) IsFlexible() bool           { return v.Version >= 6 }
@@ -10101,17 +10280,9 @@ func (v *OffsetFetchRequest) AppendTo(dst []byte) []byte {
 	if version >= 0 && version <= 7 {
 		v := v.Topics
 		if version > 2 {
-			if isFlexible {
-				dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-			} else {
-				dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-			}
+			dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		} else {
-			if isFlexible {
-				dst = kbin.AppendCompactArrayLen(dst, len(v))
-			} else {
-				dst = kbin.AppendArrayLen(dst, len(v))
-			}
+			dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		}
 		for i := range v {
 			v := &v[i]
@@ -10125,11 +10296,7 @@ func (v *OffsetFetchRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -10143,11 +10310,7 @@ func (v *OffsetFetchRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 8 {
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -10158,13 +10321,21 @@ func (v *OffsetFetchRequest) AppendTo(dst []byte) []byte {
 					dst = kbin.AppendString(dst, v)
 				}
 			}
-			{
-				v := v.Topics
+			if version >= 9 {
+				v := v.MemberID
 				if isFlexible {
-					dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
+					dst = kbin.AppendCompactNullableString(dst, v)
 				} else {
-					dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
+					dst = kbin.AppendNullableString(dst, v)
 				}
+			}
+			if version >= 9 {
+				v := v.MemberEpoch
+				dst = kbin.AppendInt32(dst, v)
+			}
+			{
+				v := v.Topics
+				dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -10177,11 +10348,7 @@ func (v *OffsetFetchRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -10211,6 +10378,23 @@ func (v *OffsetFetchRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetFetchRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetFetchRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -10231,11 +10415,7 @@ func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 2 || l == 0 {
 			a = []OffsetFetchRequestTopic{}
 		}
@@ -10262,11 +10442,7 @@ func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -10291,11 +10467,7 @@ func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -10315,15 +10487,24 @@ func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
 				}
 				s.Group = v
 			}
+			if version >= 9 {
+				var v *string
+				if isFlexible {
+					v = b.CompactNullableString()
+				} else {
+					v = b.NullableString()
+				}
+				s.MemberID = v
+			}
+			if version >= 9 {
+				v := b.Int32()
+				s.MemberEpoch = v
+			}
 			{
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if version < 0 || l == 0 {
 					a = []OffsetFetchRequestGroupTopic{}
 				}
@@ -10350,11 +10531,7 @@ func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -10389,6 +10566,9 @@ func (v *OffsetFetchRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -10453,6 +10633,9 @@ type OffsetFetchResponseTopicPartition struct {
 	//
 	// UNSTABLE_OFFSET_COMMIT is returned for v7+ if the request set RequireStable.
 	// See KIP-447 for more details.
+	//
+	// STALE_MEMBER_EPOCH is returned for v9+ if the request's member epoch
+	// is older than the member's current epoch in a consumer group.
 	ErrorCode int16
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -10610,12 +10793,43 @@ type OffsetFetchResponse struct {
 }
 
 func (*OffsetFetchResponse) Key() int16                 { return 9 }
-func (*OffsetFetchResponse) MaxVersion() int16          { return 8 }
+func (*OffsetFetchResponse) MaxVersion() int16          { return 9 }
 func (v *OffsetFetchResponse) SetVersion(version int16) { v.Version = version }
 func (v *OffsetFetchResponse) GetVersion() int16        { return v.Version }
 func (v *OffsetFetchResponse) IsFlexible() bool         { return v.Version >= 6 }
 func (v *OffsetFetchResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 4 }
 func (v *OffsetFetchResponse) RequestKind() Request     { return &OffsetFetchRequest{Version: v.Version} }
+func (v *OffsetFetchResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Groups {
+		v1 := &v.Groups[i]
+		for i := range v1.Topics {
+			v2 := &v1.Topics[i]
+			for i := range v2.Partitions {
+				v3 := &v2.Partitions[i]
+				if v3.ErrorCode != 0 {
+					errs = append(errs, ResponseError{Code: v3.ErrorCode, Topic: v2.Topic, Field: "Groups.Topics.Partitions.ErrorCode", Partition: v3.Partition})
+				}
+			}
+		}
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Field: "Groups.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -10628,11 +10842,7 @@ func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 	}
 	if version >= 0 && version <= 7 {
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -10645,11 +10855,7 @@ func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -10694,11 +10900,7 @@ func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 	}
 	if version >= 8 {
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -10711,11 +10913,7 @@ func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Topics
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -10728,11 +10926,7 @@ func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -10789,6 +10983,23 @@ func (v *OffsetFetchResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetFetchResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetFetchResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -10804,11 +11015,7 @@ func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -10832,11 +11039,7 @@ func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -10894,11 +11097,7 @@ func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -10922,11 +11121,7 @@ func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -10950,11 +11145,7 @@ func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -11018,6 +11209,9 @@ func (v *OffsetFetchResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -11105,11 +11299,7 @@ func (v *FindCoordinatorRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 4 {
 		v := v.CoordinatorKeys
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -11127,6 +11317,23 @@ func (v *FindCoordinatorRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *FindCoordinatorRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *FindCoordinatorRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *FindCoordinatorRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -11151,11 +11358,7 @@ func (v *FindCoordinatorRequest) ReadFrom(src []byte) error {
 		v := s.CoordinatorKeys
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -11177,6 +11380,9 @@ func (v *FindCoordinatorRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -11293,6 +11499,20 @@ func (v *FindCoordinatorResponse) RequestKind() Request {
 	return &FindCoordinatorRequest{Version: v.Version}
 }
 
+func (v *FindCoordinatorResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Coordinators {
+		v1 := &v.Coordinators[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Coordinators.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *FindCoordinatorResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -11332,11 +11552,7 @@ func (v *FindCoordinatorResponse) AppendTo(dst []byte) []byte {
 	}
 	if version >= 4 {
 		v := v.Coordinators
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -11389,6 +11605,23 @@ func (v *FindCoordinatorResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *FindCoordinatorResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *FindCoordinatorResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *FindCoordinatorResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -11434,11 +11667,7 @@ func (v *FindCoordinatorResponse) ReadFrom(src []byte) error {
 		v := s.Coordinators
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -11498,6 +11727,9 @@ func (v *FindCoordinatorResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -11691,11 +11923,7 @@ func (v *JoinGroupRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Protocols
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -11728,6 +11956,23 @@ func (v *JoinGroupRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *JoinGroupRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *JoinGroupRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *JoinGroupRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -11783,11 +12028,7 @@ func (v *JoinGroupRequest) ReadFrom(src []byte) error {
 		v := s.Protocols
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -11826,6 +12067,9 @@ func (v *JoinGroupRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -11970,6 +12214,13 @@ func (v *JoinGroupResponse) GetVersion() int16        { return v.Version }
 func (v *JoinGroupResponse) IsFlexible() bool         { return v.Version >= 6 }
 func (v *JoinGroupResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 3 }
 func (v *JoinGroupResponse) RequestKind() Request     { return &JoinGroupRequest{Version: v.Version} }
+func (v *JoinGroupResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *JoinGroupResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -12037,11 +12288,7 @@ func (v *JoinGroupResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Members
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -12082,6 +12329,23 @@ func (v *JoinGroupResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *JoinGroupResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *JoinGroupResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *JoinGroupResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -12151,11 +12415,7 @@ func (v *JoinGroupResponse) ReadFrom(src []byte) error {
 		v := s.Members
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -12203,6 +12463,9 @@ func (v *JoinGroupResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -12309,6 +12572,23 @@ func (v *HeartbeatRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *HeartbeatRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *HeartbeatRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *HeartbeatRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -12350,6 +12630,9 @@ func (v *HeartbeatRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -12420,6 +12703,13 @@ func (v *HeartbeatResponse) GetVersion() int16        { return v.Version }
 func (v *HeartbeatResponse) IsFlexible() bool         { return v.Version >= 4 }
 func (v *HeartbeatResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 2 }
 func (v *HeartbeatResponse) RequestKind() Request     { return &HeartbeatRequest{Version: v.Version} }
+func (v *HeartbeatResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *HeartbeatResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -12442,6 +12732,23 @@ func (v *HeartbeatResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *HeartbeatResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *HeartbeatResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *HeartbeatResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -12460,6 +12767,9 @@ func (v *HeartbeatResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -12570,11 +12880,7 @@ func (v *LeaveGroupRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 3 {
 		v := v.Members
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -12607,6 +12913,23 @@ func (v *LeaveGroupRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *LeaveGroupRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *LeaveGroupRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *LeaveGroupRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -12636,11 +12959,7 @@ func (v *LeaveGroupRequest) ReadFrom(src []byte) error {
 		v := s.Members
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -12679,6 +12998,9 @@ func (v *LeaveGroupRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -12776,6 +13098,19 @@ func (v *LeaveGroupResponse) GetVersion() int16        { return v.Version }
 func (v *LeaveGroupResponse) IsFlexible() bool         { return v.Version >= 4 }
 func (v *LeaveGroupResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 2 }
 func (v *LeaveGroupResponse) RequestKind() Request     { return &LeaveGroupRequest{Version: v.Version} }
+func (v *LeaveGroupResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Members {
+		v1 := &v.Members[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Field: "Members.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *LeaveGroupResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -12792,11 +13127,7 @@ func (v *LeaveGroupResponse) AppendTo(dst []byte) []byte {
 	}
 	if version >= 3 {
 		v := v.Members
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -12833,6 +13164,23 @@ func (v *LeaveGroupResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *LeaveGroupResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *LeaveGroupResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *LeaveGroupResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -12852,11 +13200,7 @@ func (v *LeaveGroupResponse) ReadFrom(src []byte) error {
 		v := s.Members
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -12899,6 +13243,9 @@ func (v *LeaveGroupResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -13053,11 +13400,7 @@ func (v *SyncGroupRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.GroupAssignment
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -13090,6 +13433,23 @@ func (v *SyncGroupRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *SyncGroupRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *SyncGroupRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *SyncGroupRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -13150,11 +13510,7 @@ func (v *SyncGroupRequest) ReadFrom(src []byte) error {
 		v := s.GroupAssignment
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -13193,6 +13549,9 @@ func (v *SyncGroupRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -13275,6 +13634,13 @@ func (v *SyncGroupResponse) GetVersion() int16        { return v.Version }
 func (v *SyncGroupResponse) IsFlexible() bool         { return v.Version >= 4 }
 func (v *SyncGroupResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 2 }
 func (v *SyncGroupResponse) RequestKind() Request     { return &SyncGroupRequest{Version: v.Version} }
+func (v *SyncGroupResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *SyncGroupResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -13321,6 +13687,23 @@ func (v *SyncGroupResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *SyncGroupResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *SyncGroupResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *SyncGroupResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -13366,6 +13749,9 @@ func (v *SyncGroupResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -13434,11 +13820,7 @@ func (v *DescribeGroupsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -13460,6 +13842,23 @@ func (v *DescribeGroupsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeGroupsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeGroupsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeGroupsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -13471,11 +13870,7 @@ func (v *DescribeGroupsRequest) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -13501,6 +13896,9 @@ func (v *DescribeGroupsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -13655,6 +14053,17 @@ func (v *DescribeGroupsResponse) RequestKind() Request {
 	return &DescribeGroupsRequest{Version: v.Version}
 }
 
+func (v *DescribeGroupsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Groups {
+		v1 := &v.Groups[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Field: "Groups.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *DescribeGroupsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -13666,11 +14075,7 @@ func (v *DescribeGroupsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -13711,11 +14116,7 @@ func (v *DescribeGroupsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Members
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -13790,6 +14191,23 @@ func (v *DescribeGroupsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeGroupsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeGroupsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeGroupsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -13805,11 +14223,7 @@ func (v *DescribeGroupsResponse) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -13864,11 +14278,7 @@ func (v *DescribeGroupsResponse) ReadFrom(src []byte) error {
 				v := s.Members
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -13954,6 +14364,9 @@ func (v *DescribeGroupsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -13991,13 +14404,18 @@ type ListGroupsRequest struct {
 	// "Dead", or "Empty". If empty, all groups are returned.
 	StatesFilter []string // v4+
 
+	// TypesFilter, proposed in KIP-848 and introduced in Kafka 3.8.0, allows
+	// filtering groups by type, where a type is either "classic" or
+	// "consumer". If empty, all groups are returned.
+	TypesFilter []string // v5+
+
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags // v3+
 
 }
 
 func (*ListGroupsRequest) Key() int16                 { return 16 }
-func (*ListGroupsRequest) MaxVersion() int16          { return 4 }
+func (*ListGroupsRequest) MaxVersion() int16          { return 5 }
 func (v *ListGroupsRequest) SetVersion(version int16) { v.Version = version }
 func (v *ListGroupsRequest) GetVersion() int16        { return v.Version }
 func (v *ListGroupsRequest) IsFlexible() bool         { return v.Version >= 3 }
@@ -14019,11 +14437,19 @@ func (v *ListGroupsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	if version >= 4 {
 		v := v.StatesFilter
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+		for i := range v {
+			v := v[i]
+			if isFlexible {
+				dst = kbin.AppendCompactString(dst, v)
+			} else {
+				dst = kbin.AppendString(dst, v)
+			}
 		}
+	}
+	if version >= 5 {
+		v := v.TypesFilter
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -14041,6 +14467,23 @@ func (v *ListGroupsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListGroupsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListGroupsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListGroupsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -14052,11 +14495,7 @@ func (v *ListGroupsRequest) ReadFrom(src []byte) error {
 		v := s.StatesFilter
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -14075,9 +14514,35 @@ func (v *ListGroupsRequest) ReadFrom(src []byte) error {
 		v = a
 		s.StatesFilter = v
 	}
+	if version >= 5 {
+		v := s.TypesFilter
+		a := v
+		var l int32
+		l = b.FlexibleArrayLen(isFlexible)
+		if !b.Ok() {
+			return b.Complete()
+		}
+		if l > 0 {
+			a = make([]string, l)
+		}
+		for i := int32(0); i < l; i++ {
+			var v string
+			if isFlexible {
+				v = b.CompactString()
+			} else {
+				v = b.String()
+			}
+			a[i] = v
+		}
+		v = a
+		s.TypesFilter = v
+	}
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -14112,6 +14577,9 @@ type ListGroupsResponseGroup struct {
 	// The group state.
 	GroupState string // v4+
 
+	// The group type, "classic" or "consumer".
+	GroupType string // v5+
+
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags // v3+
 
@@ -14159,12 +14627,19 @@ type ListGroupsResponse struct {
 }
 
 func (*ListGroupsResponse) Key() int16                 { return 16 }
-func (*ListGroupsResponse) MaxVersion() int16          { return 4 }
+func (*ListGroupsResponse) MaxVersion() int16          { return 5 }
 func (v *ListGroupsResponse) SetVersion(version int16) { v.Version = version }
 func (v *ListGroupsResponse) GetVersion() int16        { return v.Version }
 func (v *ListGroupsResponse) IsFlexible() bool         { return v.Version >= 3 }
 func (v *ListGroupsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 2 }
 func (v *ListGroupsResponse) RequestKind() Request     { return &ListGroupsRequest{Version: v.Version} }
+func (v *ListGroupsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *ListGroupsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -14181,11 +14656,7 @@ func (v *ListGroupsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -14212,6 +14683,14 @@ func (v *ListGroupsResponse) AppendTo(dst []byte) []byte {
 					dst = kbin.AppendString(dst, v)
 				}
 			}
+			if version >= 5 {
+				v := v.GroupType
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
+			}
 			if isFlexible {
 				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 				dst = v.UnknownTags.AppendEach(dst)
@@ -14226,6 +14705,23 @@ func (v *ListGroupsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListGroupsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListGroupsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListGroupsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -14245,11 +14741,7 @@ func (v *ListGroupsResponse) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -14287,6 +14779,15 @@ func (v *ListGroupsResponse) ReadFrom(src []byte) error {
 				}
 				s.GroupState = v
 			}
+			if version >= 5 {
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
+				s.GroupType = v
+			}
 			if isFlexible {
 				s.UnknownTags = internalReadTags(&b)
 			}
@@ -14297,6 +14798,9 @@ func (v *ListGroupsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -14367,6 +14871,23 @@ func (v *SASLHandshakeRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *SASLHandshakeRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *SASLHandshakeRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *SASLHandshakeRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -14376,6 +14897,9 @@ func (v *SASLHandshakeRequest) ReadFrom(src []byte) error {
 		v := b.String()
 		s.Mechanism = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -14424,6 +14948,14 @@ func (v *SASLHandshakeResponse) RequestKind() Request {
 	return &SASLHandshakeRequest{Version: v.Version}
 }
 
+func (v *SASLHandshakeResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *SASLHandshakeResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -14443,6 +14975,23 @@ func (v *SASLHandshakeResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *SASLHandshakeResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *SASLHandshakeResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *SASLHandshakeResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -14470,6 +15019,9 @@ func (v *SASLHandshakeResponse) ReadFrom(src []byte) error {
 		v = a
 		s.SupportedMechanisms = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -14576,6 +15128,23 @@ func (v *ApiVersionsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ApiVersionsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ApiVersionsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ApiVersionsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -14604,6 +15173,9 @@ func (v *ApiVersionsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -14762,6 +15334,13 @@ func (v *ApiVersionsResponse) GetVersion() int16        { return v.Version }
 func (v *ApiVersionsResponse) IsFlexible() bool         { return v.Version >= 3 }
 func (v *ApiVersionsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 2 }
 func (v *ApiVersionsResponse) RequestKind() Request     { return &ApiVersionsRequest{Version: v.Version} }
+func (v *ApiVersionsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *ApiVersionsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -14774,11 +15353,7 @@ func (v *ApiVersionsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.ApiKeys
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -14824,11 +15399,7 @@ func (v *ApiVersionsResponse) AppendTo(dst []byte) []byte {
 					sized := false
 					lenAt := len(dst)
 				fSupportedFeatures:
-					if isFlexible {
-						dst = kbin.AppendCompactArrayLen(dst, len(v))
-					} else {
-						dst = kbin.AppendArrayLen(dst, len(v))
-					}
+					dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 					for i := range v {
 						v := &v[i]
 						{
@@ -14872,11 +15443,7 @@ func (v *ApiVersionsResponse) AppendTo(dst []byte) []byte {
 					sized := false
 					lenAt := len(dst)
 				fFinalizedFeatures:
-					if isFlexible {
-						dst = kbin.AppendCompactArrayLen(dst, len(v))
-					} else {
-						dst = kbin.AppendArrayLen(dst, len(v))
-					}
+					dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 					for i := range v {
 						v := &v[i]
 						{
@@ -14914,6 +15481,23 @@ func (v *ApiVersionsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ApiVersionsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ApiVersionsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ApiVersionsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -14929,11 +15513,7 @@ func (v *ApiVersionsResponse) ReadFrom(src []byte) error {
 		v := s.ApiKeys
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -14977,11 +15557,7 @@ func (v *ApiVersionsResponse) ReadFrom(src []byte) error {
 				v := s.SupportedFeatures
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -15030,11 +15606,7 @@ func (v *ApiVersionsResponse) ReadFrom(src []byte) error {
 				v := s.FinalizedFeatures
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -15074,6 +15646,9 @@ func (v *ApiVersionsResponse) ReadFrom(src []byte) error {
 			}
 		}
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -15247,11 +15822,7 @@ func (v *CreateTopicsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -15272,11 +15843,7 @@ func (v *CreateTopicsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.ReplicaAssignment
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -15285,11 +15852,7 @@ func (v *CreateTopicsRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -15303,11 +15866,7 @@ func (v *CreateTopicsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Configs
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -15354,6 +15913,23 @@ func (v *CreateTopicsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreateTopicsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreateTopicsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreateTopicsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -15365,11 +15941,7 @@ func (v *CreateTopicsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -15401,11 +15973,7 @@ func (v *CreateTopicsRequest) ReadFrom(src []byte) error {
 				v := s.ReplicaAssignment
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -15424,11 +15992,7 @@ func (v *CreateTopicsRequest) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -15453,11 +16017,7 @@ func (v *CreateTopicsRequest) ReadFrom(src []byte) error {
 				v := s.Configs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -15511,6 +16071,9 @@ func (v *CreateTopicsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -15686,6 +16249,19 @@ func (v *CreateTopicsResponse) GetVersion() int16        { return v.Version }
 func (v *CreateTopicsResponse) IsFlexible() bool         { return v.Version >= 5 }
 func (v *CreateTopicsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 3 }
 func (v *CreateTopicsResponse) RequestKind() Request     { return &CreateTopicsRequest{Version: v.Version} }
+func (v *CreateTopicsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Topic: v1.Topic, Field: "Topics.ErrorCode", Partition: -1})
+		}
+		if v1.ConfigErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ConfigErrorCode, Topic: v1.Topic, Field: "Topics.ConfigErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *CreateTopicsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -15698,11 +16274,7 @@ func (v *CreateTopicsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -15739,11 +16311,7 @@ func (v *CreateTopicsResponse) AppendTo(dst []byte) []byte {
 			}
 			if version >= 5 {
 				v := v.Configs
-				if isFlexible {
-					dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-				} else {
-					dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-				}
+				dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -15809,6 +16377,23 @@ func (v *CreateTopicsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreateTopicsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreateTopicsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreateTopicsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -15824,11 +16409,7 @@ func (v *CreateTopicsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -15877,11 +16458,7 @@ func (v *CreateTopicsResponse) ReadFrom(src []byte) error {
 				v := s.Configs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if version < 0 || l == 0 {
 					a = []CreateTopicsResponseTopicConfig{}
 				}
@@ -15954,6 +16531,9 @@ func (v *CreateTopicsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -16052,11 +16632,7 @@ func (v *DeleteTopicsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	if version >= 0 && version <= 5 {
 		v := v.TopicNames
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -16068,11 +16644,7 @@ func (v *DeleteTopicsRequest) AppendTo(dst []byte) []byte {
 	}
 	if version >= 6 {
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -16105,6 +16677,23 @@ func (v *DeleteTopicsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteTopicsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteTopicsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteTopicsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -16116,11 +16705,7 @@ func (v *DeleteTopicsRequest) ReadFrom(src []byte) error {
 		v := s.TopicNames
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -16143,11 +16728,7 @@ func (v *DeleteTopicsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -16185,6 +16766,9 @@ func (v *DeleteTopicsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -16292,6 +16876,16 @@ func (v *DeleteTopicsResponse) GetVersion() int16        { return v.Version }
 func (v *DeleteTopicsResponse) IsFlexible() bool         { return v.Version >= 4 }
 func (v *DeleteTopicsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 2 }
 func (v *DeleteTopicsResponse) RequestKind() Request     { return &DeleteTopicsRequest{Version: v.Version} }
+func (v *DeleteTopicsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Topic: derefString(v1.Topic), Field: "Topics.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *DeleteTopicsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -16304,11 +16898,7 @@ func (v *DeleteTopicsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -16364,6 +16954,23 @@ func (v *DeleteTopicsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteTopicsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteTopicsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteTopicsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -16379,11 +16986,7 @@ func (v *DeleteTopicsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -16440,6 +17043,9 @@ func (v *DeleteTopicsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -16575,11 +17181,7 @@ func (v *DeleteRecordsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -16592,11 +17194,7 @@ func (v *DeleteRecordsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -16631,6 +17229,23 @@ func (v *DeleteRecordsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteRecordsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteRecordsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteRecordsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -16642,11 +17257,7 @@ func (v *DeleteRecordsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -16670,11 +17281,7 @@ func (v *DeleteRecordsRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -16714,6 +17321,9 @@ func (v *DeleteRecordsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -16843,6 +17453,20 @@ func (v *DeleteRecordsResponse) RequestKind() Request {
 	return &DeleteRecordsRequest{Version: v.Version}
 }
 
+func (v *DeleteRecordsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *DeleteRecordsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -16854,11 +17478,7 @@ func (v *DeleteRecordsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -16871,11 +17491,7 @@ func (v *DeleteRecordsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -16910,6 +17526,23 @@ func (v *DeleteRecordsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteRecordsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteRecordsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteRecordsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -16925,11 +17558,7 @@ func (v *DeleteRecordsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -16953,11 +17582,7 @@ func (v *DeleteRecordsResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -16997,6 +17622,9 @@ func (v *DeleteRecordsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -17111,6 +17739,23 @@ func (v *InitProducerIDRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *InitProducerIDRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *InitProducerIDRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *InitProducerIDRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -17142,6 +17787,9 @@ func (v *InitProducerIDRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -17227,6 +17875,14 @@ func (v *InitProducerIDResponse) RequestKind() Request {
 	return &InitProducerIDRequest{Version: v.Version}
 }
 
+func (v *InitProducerIDResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *InitProducerIDResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -17256,6 +17912,23 @@ func (v *InitProducerIDResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *InitProducerIDResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *InitProducerIDResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *InitProducerIDResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -17282,6 +17955,9 @@ func (v *InitProducerIDResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -17420,11 +18096,7 @@ func (v *OffsetForLeaderEpochRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -17437,11 +18109,7 @@ func (v *OffsetForLeaderEpochRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -17476,6 +18144,23 @@ func (v *OffsetForLeaderEpochRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetForLeaderEpochRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetForLeaderEpochRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetForLeaderEpochRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -17491,11 +18176,7 @@ func (v *OffsetForLeaderEpochRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -17519,11 +18200,7 @@ func (v *OffsetForLeaderEpochRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -17563,6 +18240,9 @@ func (v *OffsetForLeaderEpochRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -17716,6 +18396,20 @@ func (v *OffsetForLeaderEpochResponse) RequestKind() Request {
 	return &OffsetForLeaderEpochRequest{Version: v.Version}
 }
 
+func (v *OffsetForLeaderEpochResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *OffsetForLeaderEpochResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -17727,11 +18421,7 @@ func (v *OffsetForLeaderEpochResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -17744,11 +18434,7 @@ func (v *OffsetForLeaderEpochResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -17787,6 +18473,23 @@ func (v *OffsetForLeaderEpochResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetForLeaderEpochResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetForLeaderEpochResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetForLeaderEpochResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -17802,11 +18505,7 @@ func (v *OffsetForLeaderEpochResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -17830,11 +18529,7 @@ func (v *OffsetForLeaderEpochResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -17878,6 +18573,9 @@ func (v *OffsetForLeaderEpochResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -17997,11 +18695,7 @@ func (v *AddPartitionsToTxnRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -18014,11 +18708,7 @@ func (v *AddPartitionsToTxnRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -18038,6 +18728,23 @@ func (v *AddPartitionsToTxnRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AddPartitionsToTxnRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AddPartitionsToTxnRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AddPartitionsToTxnRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -18066,11 +18773,7 @@ func (v *AddPartitionsToTxnRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -18094,11 +18797,7 @@ func (v *AddPartitionsToTxnRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -18122,6 +18821,9 @@ func (v *AddPartitionsToTxnRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -18261,6 +18963,20 @@ func (v *AddPartitionsToTxnResponse) RequestKind() Request {
 	return &AddPartitionsToTxnRequest{Version: v.Version}
 }
 
+func (v *AddPartitionsToTxnResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *AddPartitionsToTxnResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -18272,11 +18988,7 @@ func (v *AddPartitionsToTxnResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -18289,11 +19001,7 @@ func (v *AddPartitionsToTxnResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -18324,6 +19032,23 @@ func (v *AddPartitionsToTxnResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AddPartitionsToTxnResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AddPartitionsToTxnResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AddPartitionsToTxnResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -18339,11 +19064,7 @@ func (v *AddPartitionsToTxnResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -18367,11 +19088,7 @@ func (v *AddPartitionsToTxnResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -18407,6 +19124,9 @@ func (v *AddPartitionsToTxnResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -18518,6 +19238,23 @@ func (v *AddOffsetsToTxnRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AddOffsetsToTxnRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AddOffsetsToTxnRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AddOffsetsToTxnRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -18554,6 +19291,9 @@ func (v *AddOffsetsToTxnRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -18618,6 +19358,14 @@ func (v *AddOffsetsToTxnResponse) RequestKind() Request {
 	return &AddOffsetsToTxnRequest{Version: v.Version}
 }
 
+func (v *AddOffsetsToTxnResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *AddOffsetsToTxnResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -18639,6 +19387,23 @@ func (v *AddOffsetsToTxnResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AddOffsetsToTxnResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AddOffsetsToTxnResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AddOffsetsToTxnResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -18657,6 +19422,9 @@ func (v *AddOffsetsToTxnResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -18756,6 +19524,23 @@ func (v *EndTxnRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *EndTxnRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *EndTxnRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *EndTxnRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -18787,6 +19572,9 @@ func (v *EndTxnRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -18858,6 +19646,13 @@ func (v *EndTxnResponse) GetVersion() int16        { return v.Version }
 func (v *EndTxnResponse) IsFlexible() bool         { return v.Version >= 3 }
 func (v *EndTxnResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 1 }
 func (v *EndTxnResponse) RequestKind() Request     { return &EndTxnRequest{Version: v.Version} }
+func (v *EndTxnResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *EndTxnResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -18880,6 +19675,23 @@ func (v *EndTxnResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *EndTxnResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *EndTxnResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *EndTxnResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -18898,6 +19710,9 @@ func (v *EndTxnResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -18923,8 +19738,10 @@ func NewEndTxnResponse() EndTxnResponse {
 }
 
 type WriteTxnMarkersRequestMarkerTopic struct {
+	// Topic is a topic to write markers to.
 	Topic string
 
+	// Partitions are the partitions in the topic to write markers to.
 	Partitions []int32
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -18946,14 +19763,23 @@ func NewWriteTxnMarkersRequestMarkerTopic() WriteTxnMarkersRequestMarkerTopic {
 }
 
 type WriteTxnMarkersRequestMarker struct {
+	// ProducerID is the producer ID of the transaction to write markers
+	// for.
 	ProducerID int64
 
+	// ProducerEpoch is the epoch of the producer ID.
 	ProducerEpoch int16
 
+	// Committed is true to write commit markers, false to write abort
+	// markers.
 	Committed bool
 
+	// Topics contains the topics and partitions to write markers to.
 	Topics []WriteTxnMarkersRequestMarkerTopic
 
+	// CoordinatorEpoch is the epoch of the transaction coordinator that is
+	// writing the markers. Partition leaders reject markers from an older
+	// coordinator epoch than one they have already seen.
 	CoordinatorEpoch int32
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -18975,12 +19801,20 @@ func NewWriteTxnMarkersRequestMarker() WriteTxnMarkersRequestMarker {
 }
 
 // WriteTxnMarkersRequest is a broker-to-broker request that Kafka uses to
-// finish transactions. Since this is specifically for inter-broker
-// communication, this is left undocumented.
+// finish transactions: the transaction coordinator sends this to the leaders
+// of every partition in a transaction to write commit or abort markers.
+//
+// This is an advanced request that is normally only sent by brokers. It can
+// be useful for recovery tooling, such as tooling that resolves hanging
+// transactions by writing an abort marker for a producer that will never
+// finish its transaction. Writing markers requires CLUSTER_ACTION on the
+// cluster. The client splits this request and sends each partition's markers
+// to the partition leader.
 type WriteTxnMarkersRequest struct {
 	// Version is the version of this message used with a Kafka broker.
 	Version int16
 
+	// Markers contains the markers to write.
 	Markers []WriteTxnMarkersRequestMarker
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -19013,11 +19847,7 @@ func (v *WriteTxnMarkersRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Markers
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -19034,11 +19864,7 @@ func (v *WriteTxnMarkersRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Topics
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -19051,11 +19877,7 @@ func (v *WriteTxnMarkersRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -19085,6 +19907,23 @@ func (v *WriteTxnMarkersRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *WriteTxnMarkersRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *WriteTxnMarkersRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *WriteTxnMarkersRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -19096,11 +19935,7 @@ func (v *WriteTxnMarkersRequest) ReadFrom(src []byte) error {
 		v := s.Markers
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -19127,11 +19962,7 @@ func (v *WriteTxnMarkersRequest) ReadFrom(src []byte) error {
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -19155,11 +19986,7 @@ func (v *WriteTxnMarkersRequest) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -19194,6 +20021,9 @@ func (v *WriteTxnMarkersRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -19219,8 +20049,19 @@ func NewWriteTxnMarkersRequest() WriteTxnMarkersRequest {
 }
 
 type WriteTxnMarkersResponseMarkerTopicPartition struct {
+	// Partition is the partition this result is for.
 	Partition int32
 
+	// ErrorCode is any error for writing the marker to this partition.
+	//
+	// CLUSTER_AUTHORIZATION_FAILED is returned if the client is not
+	// authorized for CLUSTER_ACTION on the cluster.
+	//
+	// NOT_LEADER_FOR_PARTITION is returned if the broker is not the
+	// leader of the partition.
+	//
+	// TRANSACTION_COORDINATOR_FENCED is returned if the coordinator
+	// epoch is older than one the leader has already seen.
 	ErrorCode int16
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -19242,8 +20083,10 @@ func NewWriteTxnMarkersResponseMarkerTopicPartition() WriteTxnMarkersResponseMar
 }
 
 type WriteTxnMarkersResponseMarkerTopic struct {
+	// Topic is the topic this result is for.
 	Topic string
 
+	// Partitions contains results for each partition.
 	Partitions []WriteTxnMarkersResponseMarkerTopicPartition
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -19265,8 +20108,10 @@ func NewWriteTxnMarkersResponseMarkerTopic() WriteTxnMarkersResponseMarkerTopic
 }
 
 type WriteTxnMarkersResponseMarker struct {
+	// ProducerID is the producer ID this result is for.
 	ProducerID int64
 
+	// Topics contains results for each topic.
 	Topics []WriteTxnMarkersResponseMarkerTopic
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -19292,6 +20137,7 @@ type WriteTxnMarkersResponse struct {
 	// Version is the version of this message used with a Kafka broker.
 	Version int16
 
+	// Markers contains results for each marker, by producer ID.
 	Markers []WriteTxnMarkersResponseMarker
 
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
@@ -19308,6 +20154,23 @@ func (v *WriteTxnMarkersResponse) RequestKind() Request {
 	return &WriteTxnMarkersRequest{Version: v.Version}
 }
 
+func (v *WriteTxnMarkersResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Markers {
+		v1 := &v.Markers[i]
+		for i := range v1.Topics {
+			v2 := &v1.Topics[i]
+			for i := range v2.Partitions {
+				v3 := &v2.Partitions[i]
+				if v3.ErrorCode != 0 {
+					errs = append(errs, ResponseError{Code: v3.ErrorCode, Topic: v2.Topic, Field: "Markers.Topics.Partitions.ErrorCode", Partition: v3.Partition})
+				}
+			}
+		}
+	}
+	return errs
+}
+
 func (v *WriteTxnMarkersResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -19315,11 +20178,7 @@ func (v *WriteTxnMarkersResponse) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Markers
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -19328,11 +20187,7 @@ func (v *WriteTxnMarkersResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Topics
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -19345,11 +20200,7 @@ func (v *WriteTxnMarkersResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -19386,6 +20237,23 @@ func (v *WriteTxnMarkersResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *WriteTxnMarkersResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *WriteTxnMarkersResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *WriteTxnMarkersResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -19397,11 +20265,7 @@ func (v *WriteTxnMarkersResponse) ReadFrom(src []byte) error {
 		v := s.Markers
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -19420,11 +20284,7 @@ func (v *WriteTxnMarkersResponse) ReadFrom(src []byte) error {
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -19448,11 +20308,7 @@ func (v *WriteTxnMarkersResponse) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -19495,6 +20351,9 @@ func (v *WriteTxnMarkersResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -19696,11 +20555,7 @@ func (v *TxnOffsetCommitRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -19713,11 +20568,7 @@ func (v *TxnOffsetCommitRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -19760,6 +20611,23 @@ func (v *TxnOffsetCommitRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *TxnOffsetCommitRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *TxnOffsetCommitRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *TxnOffsetCommitRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -19819,11 +20687,7 @@ func (v *TxnOffsetCommitRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -19847,11 +20711,7 @@ func (v *TxnOffsetCommitRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -19900,6 +20760,9 @@ func (v *TxnOffsetCommitRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -20044,6 +20907,20 @@ func (v *TxnOffsetCommitResponse) RequestKind() Request {
 	return &TxnOffsetCommitRequest{Version: v.Version}
 }
 
+func (v *TxnOffsetCommitResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *TxnOffsetCommitResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -20055,11 +20932,7 @@ func (v *TxnOffsetCommitResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -20072,11 +20945,7 @@ func (v *TxnOffsetCommitResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -20107,6 +20976,23 @@ func (v *TxnOffsetCommitResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *TxnOffsetCommitResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *TxnOffsetCommitResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *TxnOffsetCommitResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -20122,11 +21008,7 @@ func (v *TxnOffsetCommitResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -20150,11 +21032,7 @@ func (v *TxnOffsetCommitResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -20190,6 +21068,9 @@ func (v *TxnOffsetCommitResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -20341,6 +21222,23 @@ func (v *DescribeACLsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeACLsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeACLsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeACLsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -20414,6 +21312,9 @@ func (v *DescribeACLsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -20543,6 +21444,13 @@ func (v *DescribeACLsResponse) GetVersion() int16        { return v.Version }
 func (v *DescribeACLsResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *DescribeACLsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 1 }
 func (v *DescribeACLsResponse) RequestKind() Request     { return &DescribeACLsRequest{Version: v.Version} }
+func (v *DescribeACLsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *DescribeACLsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -20567,11 +21475,7 @@ func (v *DescribeACLsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -20598,11 +21502,7 @@ func (v *DescribeACLsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.ACLs
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -20655,6 +21555,23 @@ func (v *DescribeACLsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeACLsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeACLsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeACLsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -20683,11 +21600,7 @@ func (v *DescribeACLsResponse) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -20729,11 +21642,7 @@ func (v *DescribeACLsResponse) ReadFrom(src []byte) error {
 				v := s.ACLs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -20797,6 +21706,9 @@ func (v *DescribeACLsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -20912,11 +21824,7 @@ func (v *CreateACLsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Creations
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -20985,6 +21893,23 @@ func (v *CreateACLsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreateACLsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreateACLsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreateACLsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -20996,11 +21921,7 @@ func (v *CreateACLsRequest) ReadFrom(src []byte) error {
 		v := s.Creations
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -21084,6 +22005,9 @@ func (v *CreateACLsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -21161,6 +22085,16 @@ func (v *CreateACLsResponse) GetVersion() int16        { return v.Version }
 func (v *CreateACLsResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *CreateACLsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 1 }
 func (v *CreateACLsResponse) RequestKind() Request     { return &CreateACLsRequest{Version: v.Version} }
+func (v *CreateACLsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Results {
+		v1 := &v.Results[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Results.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *CreateACLsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -21173,11 +22107,7 @@ func (v *CreateACLsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Results
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -21206,6 +22136,23 @@ func (v *CreateACLsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreateACLsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreateACLsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreateACLsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -21221,11 +22168,7 @@ func (v *CreateACLsResponse) ReadFrom(src []byte) error {
 		v := s.Results
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -21259,6 +22202,9 @@ func (v *CreateACLsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -21356,11 +22302,7 @@ func (v *DeleteACLsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Filters
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -21429,6 +22371,23 @@ func (v *DeleteACLsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteACLsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteACLsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteACLsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -21440,11 +22399,7 @@ func (v *DeleteACLsRequest) ReadFrom(src []byte) error {
 		v := s.Filters
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -21528,6 +22483,9 @@ func (v *DeleteACLsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -21649,6 +22607,22 @@ func (v *DeleteACLsResponse) GetVersion() int16        { return v.Version }
 func (v *DeleteACLsResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *DeleteACLsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 1 }
 func (v *DeleteACLsResponse) RequestKind() Request     { return &DeleteACLsRequest{Version: v.Version} }
+func (v *DeleteACLsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Results {
+		v1 := &v.Results[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Results.ErrorCode", Partition: -1})
+		}
+		for i := range v1.MatchingACLs {
+			v2 := &v1.MatchingACLs[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Message: v2.ErrorMessage, Field: "Results.MatchingACLs.ErrorCode", Partition: -1})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *DeleteACLsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -21661,11 +22635,7 @@ func (v *DeleteACLsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Results
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -21682,11 +22652,7 @@ func (v *DeleteACLsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.MatchingACLs
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -21773,6 +22739,23 @@ func (v *DeleteACLsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteACLsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteACLsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteACLsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -21788,11 +22771,7 @@ func (v *DeleteACLsResponse) ReadFrom(src []byte) error {
 		v := s.Results
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -21820,11 +22799,7 @@ func (v *DeleteACLsResponse) ReadFrom(src []byte) error {
 				v := s.MatchingACLs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -21928,6 +22903,9 @@ func (v *DeleteACLsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -22035,11 +23013,7 @@ func (v *DescribeConfigsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -22059,11 +23033,7 @@ func (v *DescribeConfigsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.ConfigNames
-				if isFlexible {
-					dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-				} else {
-					dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-				}
+				dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 				for i := range v {
 					v := v[i]
 					if isFlexible {
@@ -22095,6 +23065,23 @@ func (v *DescribeConfigsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeConfigsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeConfigsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeConfigsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -22106,11 +23093,7 @@ func (v *DescribeConfigsRequest) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -22143,11 +23126,7 @@ func (v *DescribeConfigsRequest) ReadFrom(src []byte) error {
 				v := s.ConfigNames
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if version < 0 || l == 0 {
 					a = []string{}
 				}
@@ -22187,6 +23166,9 @@ func (v *DescribeConfigsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -22374,6 +23356,17 @@ func (v *DescribeConfigsResponse) RequestKind() Request {
 	return &DescribeConfigsRequest{Version: v.Version}
 }
 
+func (v *DescribeConfigsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Resources {
+		v1 := &v.Resources[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Resources.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *DescribeConfigsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -22385,11 +23378,7 @@ func (v *DescribeConfigsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -22421,11 +23410,7 @@ func (v *DescribeConfigsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Configs
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -22465,11 +23450,7 @@ func (v *DescribeConfigsResponse) AppendTo(dst []byte) []byte {
 					}
 					if version >= 1 {
 						v := v.ConfigSynonyms
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -22536,6 +23517,23 @@ func (v *DescribeConfigsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeConfigsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeConfigsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeConfigsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -22551,11 +23549,7 @@ func (v *DescribeConfigsResponse) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -22601,11 +23595,7 @@ func (v *DescribeConfigsResponse) ReadFrom(src []byte) error {
 				v := s.Configs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -22659,11 +23649,7 @@ func (v *DescribeConfigsResponse) ReadFrom(src []byte) error {
 						v := s.ConfigSynonyms
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -22743,6 +23729,9 @@ func (v *DescribeConfigsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -22885,11 +23874,7 @@ func (v *AlterConfigsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -22909,11 +23894,7 @@ func (v *AlterConfigsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Configs
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -22956,6 +23937,23 @@ func (v *AlterConfigsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterConfigsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterConfigsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterConfigsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -22967,11 +23965,7 @@ func (v *AlterConfigsRequest) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -23004,11 +23998,7 @@ func (v *AlterConfigsRequest) ReadFrom(src []byte) error {
 				v := s.Configs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -23058,6 +24048,9 @@ func (v *AlterConfigsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -23155,6 +24148,16 @@ func (v *AlterConfigsResponse) GetVersion() int16        { return v.Version }
 func (v *AlterConfigsResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *AlterConfigsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 1 }
 func (v *AlterConfigsResponse) RequestKind() Request     { return &AlterConfigsRequest{Version: v.Version} }
+func (v *AlterConfigsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Resources {
+		v1 := &v.Resources[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Resources.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *AlterConfigsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -23167,11 +24170,7 @@ func (v *AlterConfigsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -23215,6 +24214,23 @@ func (v *AlterConfigsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterConfigsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterConfigsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterConfigsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -23230,11 +24246,7 @@ func (v *AlterConfigsResponse) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -23286,6 +24298,9 @@ func (v *AlterConfigsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -23402,11 +24417,7 @@ func (v *AlterReplicaLogDirsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Dirs
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -23419,11 +24430,7 @@ func (v *AlterReplicaLogDirsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Topics
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -23436,11 +24443,7 @@ func (v *AlterReplicaLogDirsRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -23466,6 +24469,23 @@ func (v *AlterReplicaLogDirsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterReplicaLogDirsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterReplicaLogDirsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterReplicaLogDirsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -23477,11 +24497,7 @@ func (v *AlterReplicaLogDirsRequest) ReadFrom(src []byte) error {
 		v := s.Dirs
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -23505,11 +24521,7 @@ func (v *AlterReplicaLogDirsRequest) ReadFrom(src []byte) error {
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -23533,11 +24545,7 @@ func (v *AlterReplicaLogDirsRequest) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -23568,6 +24576,9 @@ func (v *AlterReplicaLogDirsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -23688,6 +24699,20 @@ func (v *AlterReplicaLogDirsResponse) RequestKind() Request {
 	return &AlterReplicaLogDirsRequest{Version: v.Version}
 }
 
+func (v *AlterReplicaLogDirsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *AlterReplicaLogDirsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -23699,11 +24724,7 @@ func (v *AlterReplicaLogDirsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -23716,11 +24737,7 @@ func (v *AlterReplicaLogDirsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -23751,6 +24768,23 @@ func (v *AlterReplicaLogDirsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterReplicaLogDirsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterReplicaLogDirsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterReplicaLogDirsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -23766,11 +24800,7 @@ func (v *AlterReplicaLogDirsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -23794,11 +24824,7 @@ func (v *AlterReplicaLogDirsResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -23834,6 +24860,9 @@ func (v *AlterReplicaLogDirsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -23923,11 +24952,7 @@ func (v *DescribeLogDirsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-		} else {
-			dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-		}
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -23940,11 +24965,7 @@ func (v *DescribeLogDirsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -23964,6 +24985,23 @@ func (v *DescribeLogDirsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeLogDirsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeLogDirsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeLogDirsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -23975,11 +25013,7 @@ func (v *DescribeLogDirsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 0 || l == 0 {
 			a = []DescribeLogDirsRequestTopic{}
 		}
@@ -24006,11 +25040,7 @@ func (v *DescribeLogDirsRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -24034,6 +25064,9 @@ func (v *DescribeLogDirsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -24188,6 +25221,17 @@ func (v *DescribeLogDirsResponse) RequestKind() Request {
 	return &DescribeLogDirsRequest{Version: v.Version}
 }
 
+func (v *DescribeLogDirsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Dirs {
+		v1 := &v.Dirs[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Field: "Dirs.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *DescribeLogDirsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -24199,11 +25243,7 @@ func (v *DescribeLogDirsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Dirs
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -24220,11 +25260,7 @@ func (v *DescribeLogDirsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Topics
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -24237,11 +25273,7 @@ func (v *DescribeLogDirsResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -24286,6 +25318,23 @@ func (v *DescribeLogDirsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeLogDirsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeLogDirsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeLogDirsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -24301,11 +25350,7 @@ func (v *DescribeLogDirsResponse) ReadFrom(src []byte) error {
 		v := s.Dirs
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -24333,11 +25378,7 @@ func (v *DescribeLogDirsResponse) ReadFrom(src []byte) error {
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -24361,11 +25402,7 @@ func (v *DescribeLogDirsResponse) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -24416,6 +25453,9 @@ func (v *DescribeLogDirsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -24497,6 +25537,23 @@ func (v *SASLAuthenticateRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *SASLAuthenticateRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *SASLAuthenticateRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *SASLAuthenticateRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -24516,6 +25573,9 @@ func (v *SASLAuthenticateRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -24574,6 +25634,14 @@ func (v *SASLAuthenticateResponse) RequestKind() Request {
 	return &SASLAuthenticateRequest{Version: v.Version}
 }
 
+func (v *SASLAuthenticateResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *SASLAuthenticateResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -24611,6 +25679,23 @@ func (v *SASLAuthenticateResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *SASLAuthenticateResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *SASLAuthenticateResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *SASLAuthenticateResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -24647,6 +25732,9 @@ func (v *SASLAuthenticateResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -24784,11 +25872,7 @@ func (v *CreatePartitionsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -24805,20 +25889,12 @@ func (v *CreatePartitionsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Assignment
-				if isFlexible {
-					dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-				} else {
-					dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-				}
+				dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -24852,6 +25928,23 @@ func (v *CreatePartitionsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreatePartitionsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreatePartitionsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreatePartitionsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -24863,11 +25956,7 @@ func (v *CreatePartitionsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -24895,11 +25984,7 @@ func (v *CreatePartitionsRequest) ReadFrom(src []byte) error {
 				v := s.Assignment
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if version < 0 || l == 0 {
 					a = []CreatePartitionsRequestTopicAssignment{}
 				}
@@ -24917,11 +26002,7 @@ func (v *CreatePartitionsRequest) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -24960,6 +26041,9 @@ func (v *CreatePartitionsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25066,6 +26150,17 @@ func (v *CreatePartitionsResponse) RequestKind() Request {
 	return &CreatePartitionsRequest{Version: v.Version}
 }
 
+func (v *CreatePartitionsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Topic: v1.Topic, Field: "Topics.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *CreatePartitionsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -25077,11 +26172,7 @@ func (v *CreatePartitionsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -25118,6 +26209,23 @@ func (v *CreatePartitionsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreatePartitionsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreatePartitionsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreatePartitionsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -25133,11 +26241,7 @@ func (v *CreatePartitionsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -25180,6 +26284,9 @@ func (v *CreatePartitionsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25284,11 +26391,7 @@ func (v *CreateDelegationTokenRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Renewers
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -25325,6 +26428,23 @@ func (v *CreateDelegationTokenRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreateDelegationTokenRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreateDelegationTokenRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreateDelegationTokenRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -25336,11 +26456,7 @@ func (v *CreateDelegationTokenRequest) ReadFrom(src []byte) error {
 		v := s.Renewers
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -25383,6 +26499,9 @@ func (v *CreateDelegationTokenRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25470,6 +26589,14 @@ func (v *CreateDelegationTokenResponse) RequestKind() Request {
 	return &CreateDelegationTokenRequest{Version: v.Version}
 }
 
+func (v *CreateDelegationTokenResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *CreateDelegationTokenResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -25535,6 +26662,23 @@ func (v *CreateDelegationTokenResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *CreateDelegationTokenResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *CreateDelegationTokenResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *CreateDelegationTokenResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -25601,6 +26745,9 @@ func (v *CreateDelegationTokenResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25687,6 +26834,23 @@ func (v *RenewDelegationTokenRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *RenewDelegationTokenRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *RenewDelegationTokenRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *RenewDelegationTokenRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -25710,6 +26874,9 @@ func (v *RenewDelegationTokenRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25773,6 +26940,14 @@ func (v *RenewDelegationTokenResponse) RequestKind() Request {
 	return &RenewDelegationTokenRequest{Version: v.Version}
 }
 
+func (v *RenewDelegationTokenResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *RenewDelegationTokenResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -25798,6 +26973,23 @@ func (v *RenewDelegationTokenResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *RenewDelegationTokenResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *RenewDelegationTokenResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *RenewDelegationTokenResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -25820,6 +27012,9 @@ func (v *RenewDelegationTokenResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25912,6 +27107,23 @@ func (v *ExpireDelegationTokenRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ExpireDelegationTokenRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ExpireDelegationTokenRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ExpireDelegationTokenRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -25935,6 +27147,9 @@ func (v *ExpireDelegationTokenRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -25997,6 +27212,14 @@ func (v *ExpireDelegationTokenResponse) RequestKind() Request {
 	return &ExpireDelegationTokenRequest{Version: v.Version}
 }
 
+func (v *ExpireDelegationTokenResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *ExpireDelegationTokenResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -26022,6 +27245,23 @@ func (v *ExpireDelegationTokenResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ExpireDelegationTokenResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ExpireDelegationTokenResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ExpireDelegationTokenResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -26044,6 +27284,9 @@ func (v *ExpireDelegationTokenResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -26135,11 +27378,7 @@ func (v *DescribeDelegationTokenRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Owners
-		if isFlexible {
-			dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-		} else {
-			dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-		}
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -26172,6 +27411,23 @@ func (v *DescribeDelegationTokenRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeDelegationTokenRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeDelegationTokenRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeDelegationTokenRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -26183,11 +27439,7 @@ func (v *DescribeDelegationTokenRequest) ReadFrom(src []byte) error {
 		v := s.Owners
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 0 || l == 0 {
 			a = []DescribeDelegationTokenRequestOwner{}
 		}
@@ -26229,6 +27481,9 @@ func (v *DescribeDelegationTokenRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -26358,6 +27613,14 @@ func (v *DescribeDelegationTokenResponse) RequestKind() Request {
 	return &DescribeDelegationTokenRequest{Version: v.Version}
 }
 
+func (v *DescribeDelegationTokenResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *DescribeDelegationTokenResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -26369,11 +27632,7 @@ func (v *DescribeDelegationTokenResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.TokenDetails
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -26422,11 +27681,7 @@ func (v *DescribeDelegationTokenResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Renewers
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -26469,6 +27724,23 @@ func (v *DescribeDelegationTokenResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeDelegationTokenResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeDelegationTokenResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeDelegationTokenResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -26484,11 +27756,7 @@ func (v *DescribeDelegationTokenResponse) ReadFrom(src []byte) error {
 		v := s.TokenDetails
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -26551,11 +27819,7 @@ func (v *DescribeDelegationTokenResponse) ReadFrom(src []byte) error {
 				v := s.Renewers
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -26605,6 +27869,9 @@ func (v *DescribeDelegationTokenResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -26670,11 +27937,7 @@ func (v *DeleteGroupsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -26692,6 +27955,23 @@ func (v *DeleteGroupsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteGroupsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteGroupsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteGroupsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -26703,11 +27983,7 @@ func (v *DeleteGroupsRequest) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -26729,6 +28005,9 @@ func (v *DeleteGroupsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -26819,6 +28098,16 @@ func (v *DeleteGroupsResponse) GetVersion() int16        { return v.Version }
 func (v *DeleteGroupsResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *DeleteGroupsResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 1 }
 func (v *DeleteGroupsResponse) RequestKind() Request     { return &DeleteGroupsRequest{Version: v.Version} }
+func (v *DeleteGroupsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Groups {
+		v1 := &v.Groups[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Field: "Groups.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
 
 func (v *DeleteGroupsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -26831,11 +28120,7 @@ func (v *DeleteGroupsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Groups
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -26864,6 +28149,23 @@ func (v *DeleteGroupsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DeleteGroupsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DeleteGroupsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DeleteGroupsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -26879,11 +28181,7 @@ func (v *DeleteGroupsResponse) ReadFrom(src []byte) error {
 		v := s.Groups
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -26917,6 +28215,9 @@ func (v *DeleteGroupsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -27029,11 +28330,7 @@ func (v *ElectLeadersRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-		} else {
-			dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-		}
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -27046,11 +28343,7 @@ func (v *ElectLeadersRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -27074,6 +28367,23 @@ func (v *ElectLeadersRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ElectLeadersRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ElectLeadersRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ElectLeadersRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -27089,11 +28399,7 @@ func (v *ElectLeadersRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 0 || l == 0 {
 			a = []ElectLeadersRequestTopic{}
 		}
@@ -27120,11 +28426,7 @@ func (v *ElectLeadersRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -27152,6 +28454,9 @@ func (v *ElectLeadersRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -27276,6 +28581,22 @@ func (v *ElectLeadersResponse) GetVersion() int16        { return v.Version }
 func (v *ElectLeadersResponse) IsFlexible() bool         { return v.Version >= 2 }
 func (v *ElectLeadersResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 0 }
 func (v *ElectLeadersResponse) RequestKind() Request     { return &ElectLeadersRequest{Version: v.Version} }
+func (v *ElectLeadersResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Message: v2.ErrorMessage, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *ElectLeadersResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -27292,11 +28613,7 @@ func (v *ElectLeadersResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -27309,11 +28626,7 @@ func (v *ElectLeadersResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -27352,6 +28665,23 @@ func (v *ElectLeadersResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ElectLeadersResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ElectLeadersResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ElectLeadersResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -27371,11 +28701,7 @@ func (v *ElectLeadersResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -27399,11 +28725,7 @@ func (v *ElectLeadersResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -27448,6 +28770,9 @@ func (v *ElectLeadersResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -27598,11 +28923,7 @@ func (v *IncrementalAlterConfigsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -27622,11 +28943,7 @@ func (v *IncrementalAlterConfigsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Configs
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -27676,6 +28993,23 @@ func (v *IncrementalAlterConfigsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *IncrementalAlterConfigsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *IncrementalAlterConfigsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *IncrementalAlterConfigsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -27687,11 +29021,7 @@ func (v *IncrementalAlterConfigsRequest) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -27724,11 +29054,7 @@ func (v *IncrementalAlterConfigsRequest) ReadFrom(src []byte) error {
 				v := s.Configs
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -27787,6 +29113,9 @@ func (v *IncrementalAlterConfigsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -27887,6 +29216,17 @@ func (v *IncrementalAlterConfigsResponse) RequestKind() Request {
 	return &IncrementalAlterConfigsRequest{Version: v.Version}
 }
 
+func (v *IncrementalAlterConfigsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Resources {
+		v1 := &v.Resources[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Resources.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *IncrementalAlterConfigsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -27898,11 +29238,7 @@ func (v *IncrementalAlterConfigsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Resources
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -27946,6 +29282,23 @@ func (v *IncrementalAlterConfigsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *IncrementalAlterConfigsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *IncrementalAlterConfigsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *IncrementalAlterConfigsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -27961,11 +29314,7 @@ func (v *IncrementalAlterConfigsResponse) ReadFrom(src []byte) error {
 		v := s.Resources
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -28017,6 +29366,9 @@ func (v *IncrementalAlterConfigsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -28144,11 +29496,7 @@ func (v *AlterPartitionAssignmentsRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -28161,11 +29509,7 @@ func (v *AlterPartitionAssignmentsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -28174,11 +29518,7 @@ func (v *AlterPartitionAssignmentsRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-						} else {
-							dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-						}
+						dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -28204,6 +29544,23 @@ func (v *AlterPartitionAssignmentsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterPartitionAssignmentsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterPartitionAssignmentsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterPartitionAssignmentsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -28219,11 +29576,7 @@ func (v *AlterPartitionAssignmentsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -28247,11 +29600,7 @@ func (v *AlterPartitionAssignmentsRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -28270,11 +29619,7 @@ func (v *AlterPartitionAssignmentsRequest) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if version < 0 || l == 0 {
 							a = []int32{}
 						}
@@ -28308,6 +29653,9 @@ func (v *AlterPartitionAssignmentsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -28433,6 +29781,23 @@ func (v *AlterPartitionAssignmentsResponse) RequestKind() Request {
 	return &AlterPartitionAssignmentsRequest{Version: v.Version}
 }
 
+func (v *AlterPartitionAssignmentsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Message: v2.ErrorMessage, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *AlterPartitionAssignmentsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -28456,11 +29821,7 @@ func (v *AlterPartitionAssignmentsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -28473,11 +29834,7 @@ func (v *AlterPartitionAssignmentsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -28516,6 +29873,23 @@ func (v *AlterPartitionAssignmentsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterPartitionAssignmentsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterPartitionAssignmentsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterPartitionAssignmentsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -28544,11 +29918,7 @@ func (v *AlterPartitionAssignmentsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -28572,11 +29942,7 @@ func (v *AlterPartitionAssignmentsResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -28621,6 +29987,9 @@ func (v *AlterPartitionAssignmentsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -28724,11 +30093,7 @@ func (v *ListPartitionReassignmentsRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-		} else {
-			dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-		}
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -28741,11 +30106,7 @@ func (v *ListPartitionReassignmentsRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -28765,6 +30126,23 @@ func (v *ListPartitionReassignmentsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListPartitionReassignmentsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListPartitionReassignmentsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListPartitionReassignmentsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -28780,11 +30158,7 @@ func (v *ListPartitionReassignmentsRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 0 || l == 0 {
 			a = []ListPartitionReassignmentsRequestTopic{}
 		}
@@ -28811,11 +30185,7 @@ func (v *ListPartitionReassignmentsRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -28839,6 +30209,9 @@ func (v *ListPartitionReassignmentsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -28961,6 +30334,14 @@ func (v *ListPartitionReassignmentsResponse) RequestKind() Request {
 	return &ListPartitionReassignmentsRequest{Version: v.Version}
 }
 
+func (v *ListPartitionReassignmentsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -28984,11 +30365,7 @@ func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -29001,11 +30378,7 @@ func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -29014,11 +30387,7 @@ func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Replicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -29026,11 +30395,7 @@ func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.AddingReplicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -29038,11 +30403,7 @@ func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.RemovingReplicas
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -29068,6 +30429,23 @@ func (v *ListPartitionReassignmentsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListPartitionReassignmentsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListPartitionReassignmentsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -29096,11 +30474,7 @@ func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -29124,11 +30498,7 @@ func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -29147,11 +30517,7 @@ func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
 						v := s.Replicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -29169,11 +30535,7 @@ func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
 						v := s.AddingReplicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -29191,11 +30553,7 @@ func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
 						v := s.RemovingReplicas
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -29226,6 +30584,9 @@ func (v *ListPartitionReassignmentsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -29357,6 +30718,23 @@ func (v *OffsetDeleteRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetDeleteRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetDeleteRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetDeleteRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -29412,6 +30790,9 @@ func (v *OffsetDeleteRequest) ReadFrom(src []byte) error {
 		v = a
 		s.Topics = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -29523,6 +30904,22 @@ func (v *OffsetDeleteResponse) GetVersion() int16        { return v.Version }
 func (v *OffsetDeleteResponse) IsFlexible() bool         { return false }
 func (v *OffsetDeleteResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 0 }
 func (v *OffsetDeleteResponse) RequestKind() Request     { return &OffsetDeleteRequest{Version: v.Version} }
+func (v *OffsetDeleteResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *OffsetDeleteResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -29565,6 +30962,23 @@ func (v *OffsetDeleteResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *OffsetDeleteResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *OffsetDeleteResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *OffsetDeleteResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -29628,6 +31042,9 @@ func (v *OffsetDeleteResponse) ReadFrom(src []byte) error {
 		v = a
 		s.Topics = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -29708,6 +31125,7 @@ func (*DescribeClientQuotasRequest) MaxVersion() int16          { return 1 }
 func (v *DescribeClientQuotasRequest) SetVersion(version int16) { v.Version = version }
 func (v *DescribeClientQuotasRequest) GetVersion() int16        { return v.Version }
 func (v *DescribeClientQuotasRequest) IsFlexible() bool         { return v.Version >= 1 }
+func (v *DescribeClientQuotasRequest) IsAdminRequest()          {}
 func (v *DescribeClientQuotasRequest) ResponseKind() Response {
 	return &DescribeClientQuotasResponse{Version: v.Version}
 }
@@ -29728,11 +31146,7 @@ func (v *DescribeClientQuotasRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Components
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -29776,6 +31190,23 @@ func (v *DescribeClientQuotasRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeClientQuotasRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeClientQuotasRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeClientQuotasRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -29787,11 +31218,7 @@ func (v *DescribeClientQuotasRequest) ReadFrom(src []byte) error {
 		v := s.Components
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -29843,6 +31270,9 @@ func (v *DescribeClientQuotasRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -29978,6 +31408,14 @@ func (v *DescribeClientQuotasResponse) RequestKind() Request {
 	return &DescribeClientQuotasRequest{Version: v.Version}
 }
 
+func (v *DescribeClientQuotasResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *DescribeClientQuotasResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -30001,20 +31439,12 @@ func (v *DescribeClientQuotasResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Entries
-		if isFlexible {
-			dst = kbin.AppendCompactNullableArrayLen(dst, len(v), v == nil)
-		} else {
-			dst = kbin.AppendNullableArrayLen(dst, len(v), v == nil)
-		}
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
 				v := v.Entity
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -30041,11 +31471,7 @@ func (v *DescribeClientQuotasResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Values
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -30080,6 +31506,23 @@ func (v *DescribeClientQuotasResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeClientQuotasResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeClientQuotasResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeClientQuotasResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -30108,11 +31551,7 @@ func (v *DescribeClientQuotasResponse) ReadFrom(src []byte) error {
 		v := s.Entries
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if version < 0 || l == 0 {
 			a = []DescribeClientQuotasResponseEntry{}
 		}
@@ -30130,11 +31569,7 @@ func (v *DescribeClientQuotasResponse) ReadFrom(src []byte) error {
 				v := s.Entity
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -30174,11 +31609,7 @@ func (v *DescribeClientQuotasResponse) ReadFrom(src []byte) error {
 				v := s.Values
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -30219,6 +31650,9 @@ func (v *DescribeClientQuotasResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -30344,6 +31778,7 @@ func (*AlterClientQuotasRequest) MaxVersion() int16          { return 1 }
 func (v *AlterClientQuotasRequest) SetVersion(version int16) { v.Version = version }
 func (v *AlterClientQuotasRequest) GetVersion() int16        { return v.Version }
 func (v *AlterClientQuotasRequest) IsFlexible() bool         { return v.Version >= 1 }
+func (v *AlterClientQuotasRequest) IsAdminRequest()          {}
 func (v *AlterClientQuotasRequest) ResponseKind() Response {
 	return &AlterClientQuotasResponse{Version: v.Version}
 }
@@ -30364,20 +31799,12 @@ func (v *AlterClientQuotasRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Entries
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
 				v := v.Entity
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -30404,11 +31831,7 @@ func (v *AlterClientQuotasRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Ops
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -30451,6 +31874,23 @@ func (v *AlterClientQuotasRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterClientQuotasRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterClientQuotasRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterClientQuotasRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -30462,11 +31902,7 @@ func (v *AlterClientQuotasRequest) ReadFrom(src []byte) error {
 		v := s.Entries
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -30481,11 +31917,7 @@ func (v *AlterClientQuotasRequest) ReadFrom(src []byte) error {
 				v := s.Entity
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -30525,11 +31957,7 @@ func (v *AlterClientQuotasRequest) ReadFrom(src []byte) error {
 				v := s.Ops
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -30578,6 +32006,9 @@ func (v *AlterClientQuotasRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -30682,6 +32113,17 @@ func (v *AlterClientQuotasResponse) RequestKind() Request {
 	return &AlterClientQuotasRequest{Version: v.Version}
 }
 
+func (v *AlterClientQuotasResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Entries {
+		v1 := &v.Entries[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Entries.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *AlterClientQuotasResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -30693,11 +32135,7 @@ func (v *AlterClientQuotasResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Entries
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -30714,11 +32152,7 @@ func (v *AlterClientQuotasResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Entity
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -30757,6 +32191,23 @@ func (v *AlterClientQuotasResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterClientQuotasResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterClientQuotasResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterClientQuotasResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -30772,11 +32223,7 @@ func (v *AlterClientQuotasResponse) ReadFrom(src []byte) error {
 		v := s.Entries
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -30804,11 +32251,7 @@ func (v *AlterClientQuotasResponse) ReadFrom(src []byte) error {
 				v := s.Entity
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -30854,6 +32297,9 @@ func (v *AlterClientQuotasResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -30942,11 +32388,7 @@ func (v *DescribeUserSCRAMCredentialsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Users
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -30971,6 +32413,23 @@ func (v *DescribeUserSCRAMCredentialsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeUserSCRAMCredentialsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeUserSCRAMCredentialsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -30982,11 +32441,7 @@ func (v *DescribeUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
 		v := s.Users
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -31016,6 +32471,9 @@ func (v *DescribeUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -31131,6 +32589,20 @@ func (v *DescribeUserSCRAMCredentialsResponse) RequestKind() Request {
 	return &DescribeUserSCRAMCredentialsRequest{Version: v.Version}
 }
 
+func (v *DescribeUserSCRAMCredentialsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Results {
+		v1 := &v.Results[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Results.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *DescribeUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -31154,11 +32626,7 @@ func (v *DescribeUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Results
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -31183,11 +32651,7 @@ func (v *DescribeUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.CredentialInfos
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -31218,6 +32682,23 @@ func (v *DescribeUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeUserSCRAMCredentialsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeUserSCRAMCredentialsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -31246,11 +32727,7 @@ func (v *DescribeUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
 		v := s.Results
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -31287,11 +32764,7 @@ func (v *DescribeUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
 				v := s.CredentialInfos
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -31327,6 +32800,9 @@ func (v *DescribeUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -31457,11 +32933,7 @@ func (v *AlterUserSCRAMCredentialsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Deletions
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -31484,11 +32956,7 @@ func (v *AlterUserSCRAMCredentialsRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Upsertions
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -31537,6 +33005,23 @@ func (v *AlterUserSCRAMCredentialsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterUserSCRAMCredentialsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterUserSCRAMCredentialsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -31548,11 +33033,7 @@ func (v *AlterUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
 		v := s.Deletions
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -31587,11 +33068,7 @@ func (v *AlterUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
 		v := s.Upsertions
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -31647,6 +33124,9 @@ func (v *AlterUserSCRAMCredentialsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -31728,6 +33208,17 @@ func (v *AlterUserSCRAMCredentialsResponse) RequestKind() Request {
 	return &AlterUserSCRAMCredentialsRequest{Version: v.Version}
 }
 
+func (v *AlterUserSCRAMCredentialsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Results {
+		v1 := &v.Results[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Results.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *AlterUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -31739,11 +33230,7 @@ func (v *AlterUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Results
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -31780,6 +33267,23 @@ func (v *AlterUserSCRAMCredentialsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterUserSCRAMCredentialsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterUserSCRAMCredentialsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -31795,11 +33299,7 @@ func (v *AlterUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
 		v := s.Results
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -31842,6 +33342,9 @@ func (v *AlterUserSCRAMCredentialsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -31969,11 +33472,7 @@ func (v *VoteRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -31986,11 +33485,7 @@ func (v *VoteRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -32033,6 +33528,23 @@ func (v *VoteRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *VoteRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *VoteRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *VoteRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -32053,11 +33565,7 @@ func (v *VoteRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -32081,11 +33589,7 @@ func (v *VoteRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -32133,6 +33637,9 @@ func (v *VoteRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -32225,6 +33732,22 @@ func (v *VoteResponse) SetVersion(version int16) { v.Version = version }
 func (v *VoteResponse) GetVersion() int16        { return v.Version }
 func (v *VoteResponse) IsFlexible() bool         { return v.Version >= 0 }
 func (v *VoteResponse) RequestKind() Request     { return &VoteRequest{Version: v.Version} }
+func (v *VoteResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *VoteResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -32237,11 +33760,7 @@ func (v *VoteResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -32254,11 +33773,7 @@ func (v *VoteResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -32297,6 +33812,23 @@ func (v *VoteResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *VoteResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *VoteResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *VoteResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -32312,11 +33844,7 @@ func (v *VoteResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -32340,11 +33868,7 @@ func (v *VoteResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -32388,6 +33912,9 @@ func (v *VoteResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -32529,6 +34056,23 @@ func (v *BeginQuorumEpochRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *BeginQuorumEpochRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *BeginQuorumEpochRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *BeginQuorumEpochRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -32592,6 +34136,9 @@ func (v *BeginQuorumEpochRequest) ReadFrom(src []byte) error {
 		v = a
 		s.Topics = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -32678,6 +34225,23 @@ func (v *BeginQuorumEpochResponse) RequestKind() Request {
 	return &BeginQuorumEpochRequest{Version: v.Version}
 }
 
+func (v *BeginQuorumEpochResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *BeginQuorumEpochResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -32723,6 +34287,23 @@ func (v *BeginQuorumEpochResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *BeginQuorumEpochResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *BeginQuorumEpochResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *BeginQuorumEpochResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -32790,6 +34371,9 @@ func (v *BeginQuorumEpochResponse) ReadFrom(src []byte) error {
 		v = a
 		s.Topics = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -32942,6 +34526,23 @@ func (v *EndQuorumEpochRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *EndQuorumEpochRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *EndQuorumEpochRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *EndQuorumEpochRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -33023,6 +34624,9 @@ func (v *EndQuorumEpochRequest) ReadFrom(src []byte) error {
 		v = a
 		s.Topics = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -33109,6 +34713,23 @@ func (v *EndQuorumEpochResponse) RequestKind() Request {
 	return &EndQuorumEpochRequest{Version: v.Version}
 }
 
+func (v *EndQuorumEpochResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *EndQuorumEpochResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -33154,6 +34775,23 @@ func (v *EndQuorumEpochResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *EndQuorumEpochResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *EndQuorumEpochResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *EndQuorumEpochResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -33221,6 +34859,9 @@ func (v *EndQuorumEpochResponse) ReadFrom(src []byte) error {
 		v = a
 		s.Topics = v
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -33350,11 +34991,7 @@ func (v *DescribeQuorumRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -33367,11 +35004,7 @@ func (v *DescribeQuorumRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -33398,6 +35031,23 @@ func (v *DescribeQuorumRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeQuorumRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeQuorumRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeQuorumRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -33409,11 +35059,7 @@ func (v *DescribeQuorumRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -33437,11 +35083,7 @@ func (v *DescribeQuorumRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -33473,6 +35115,9 @@ func (v *DescribeQuorumRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -33574,6 +35219,23 @@ func (v *DescribeQuorumResponse) RequestKind() Request {
 	return &DescribeQuorumRequest{Version: v.Version}
 }
 
+func (v *DescribeQuorumResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -33585,11 +35247,7 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -33602,11 +35260,7 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -33631,11 +35285,7 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.CurrentVoters
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -33654,11 +35304,7 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Observers
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -33695,6 +35341,23 @@ func (v *DescribeQuorumResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeQuorumResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeQuorumResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -33710,11 +35373,7 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -33738,11 +35397,7 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -33777,11 +35432,7 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 						v := s.CurrentVoters
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -33811,11 +35462,7 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 						v := s.Observers
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -33858,6 +35505,9 @@ func (v *DescribeQuorumResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -33985,11 +35635,7 @@ func (v *AlterISRRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -34002,11 +35648,7 @@ func (v *AlterISRRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -34019,11 +35661,7 @@ func (v *AlterISRRequest) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.NewISR
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -34053,6 +35691,23 @@ func (v *AlterISRRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterISRRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterISRRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterISRRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -34072,11 +35727,7 @@ func (v *AlterISRRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -34100,11 +35751,7 @@ func (v *AlterISRRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -34127,11 +35774,7 @@ func (v *AlterISRRequest) ReadFrom(src []byte) error {
 						v := s.NewISR
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -34166,6 +35809,9 @@ func (v *AlterISRRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -34270,6 +35916,22 @@ func (v *AlterISRResponse) GetVersion() int16        { return v.Version }
 func (v *AlterISRResponse) IsFlexible() bool         { return v.Version >= 0 }
 func (v *AlterISRResponse) Throttle() (int32, bool)  { return v.ThrottleMillis, v.Version >= 0 }
 func (v *AlterISRResponse) RequestKind() Request     { return &AlterISRRequest{Version: v.Version} }
+func (v *AlterISRResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
 
 func (v *AlterISRResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -34286,11 +35948,7 @@ func (v *AlterISRResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -34303,11 +35961,7 @@ func (v *AlterISRResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -34328,11 +35982,7 @@ func (v *AlterISRResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.ISR
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -34362,6 +36012,23 @@ func (v *AlterISRResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AlterISRResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AlterISRResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AlterISRResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -34381,11 +36048,7 @@ func (v *AlterISRResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -34409,11 +36072,7 @@ func (v *AlterISRResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -34444,11 +36103,7 @@ func (v *AlterISRResponse) ReadFrom(src []byte) error {
 						v := s.ISR
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -34483,6 +36138,9 @@ func (v *AlterISRResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -34520,8 +36178,18 @@ type UpdateFeaturesRequestFeatureUpdate struct {
 	// downgraded/deleted. The downgrade request will fail if the new maximum
 	// version level is a value that's not lower than the existing maximum
 	// finalized version level.
+	//
+	// This is replaced by UpgradeType in v1+.
 	AllowDowngrade bool
 
+	// UpgradeType, introduced in KIP-778 with Kafka 3.2, determines how the
+	// feature may be changed: 1 for an upgrade only, 2 for a safe downgrade
+	// that loses no metadata, and 3 for an unsafe downgrade that may lose
+	// metadata.
+	//
+	// This field has a default of 1.
+	UpgradeType int8 // v1+
+
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags
 }
@@ -34529,6 +36197,7 @@ type UpdateFeaturesRequestFeatureUpdate struct {
 // Default sets any default fields. Calling this allows for future compatibility
 // if new fields are added to UpdateFeaturesRequestFeatureUpdate.
 func (v *UpdateFeaturesRequestFeatureUpdate) Default() {
+	v.UpgradeType = 1
 }
 
 // NewUpdateFeaturesRequestFeatureUpdate returns a default UpdateFeaturesRequestFeatureUpdate
@@ -34555,12 +36224,16 @@ type UpdateFeaturesRequest struct {
 	// The list of updates to finalized features.
 	FeatureUpdates []UpdateFeaturesRequestFeatureUpdate
 
+	// ValidateOnly, introduced in KIP-778 with Kafka 3.2, validates the
+	// updates without performing them.
+	ValidateOnly bool // v1+
+
 	// UnknownTags are tags Kafka sent that we do not know the purpose of.
 	UnknownTags Tags
 }
 
 func (*UpdateFeaturesRequest) Key() int16                 { return 57 }
-func (*UpdateFeaturesRequest) MaxVersion() int16          { return 0 }
+func (*UpdateFeaturesRequest) MaxVersion() int16          { return 1 }
 func (v *UpdateFeaturesRequest) SetVersion(version int16) { v.Version = version }
 func (v *UpdateFeaturesRequest) GetVersion() int16        { return v.Version }
 func (v *UpdateFeaturesRequest) IsFlexible() bool         { return v.Version >= 0 }
@@ -34590,11 +36263,7 @@ func (v *UpdateFeaturesRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.FeatureUpdates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -34609,16 +36278,24 @@ func (v *UpdateFeaturesRequest) AppendTo(dst []byte) []byte {
 				v := v.MaxVersionLevel
 				dst = kbin.AppendInt16(dst, v)
 			}
-			{
+			if version >= 0 && version <= 0 {
 				v := v.AllowDowngrade
 				dst = kbin.AppendBool(dst, v)
 			}
+			if version >= 1 {
+				v := v.UpgradeType
+				dst = kbin.AppendInt8(dst, v)
+			}
 			if isFlexible {
 				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 				dst = v.UnknownTags.AppendEach(dst)
 			}
 		}
 	}
+	if version >= 1 {
+		v := v.ValidateOnly
+		dst = kbin.AppendBool(dst, v)
+	}
 	if isFlexible {
 		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
 		dst = v.UnknownTags.AppendEach(dst)
@@ -34627,6 +36304,23 @@ func (v *UpdateFeaturesRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *UpdateFeaturesRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *UpdateFeaturesRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *UpdateFeaturesRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -34642,11 +36336,7 @@ func (v *UpdateFeaturesRequest) ReadFrom(src []byte) error {
 		v := s.FeatureUpdates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -34670,10 +36360,14 @@ func (v *UpdateFeaturesRequest) ReadFrom(src []byte) error {
 				v := b.Int16()
 				s.MaxVersionLevel = v
 			}
-			{
+			if version >= 0 && version <= 0 {
 				v := b.Bool()
 				s.AllowDowngrade = v
 			}
+			if version >= 1 {
+				v := b.Int8()
+				s.UpgradeType = v
+			}
 			if isFlexible {
 				s.UnknownTags = internalReadTags(&b)
 			}
@@ -34681,9 +36375,16 @@ func (v *UpdateFeaturesRequest) ReadFrom(src []byte) error {
 		v = a
 		s.FeatureUpdates = v
 	}
+	if version >= 1 {
+		v := b.Bool()
+		s.ValidateOnly = v
+	}
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -34758,7 +36459,7 @@ type UpdateFeaturesResponse struct {
 }
 
 func (*UpdateFeaturesResponse) Key() int16                 { return 57 }
-func (*UpdateFeaturesResponse) MaxVersion() int16          { return 0 }
+func (*UpdateFeaturesResponse) MaxVersion() int16          { return 1 }
 func (v *UpdateFeaturesResponse) SetVersion(version int16) { v.Version = version }
 func (v *UpdateFeaturesResponse) GetVersion() int16        { return v.Version }
 func (v *UpdateFeaturesResponse) IsFlexible() bool         { return v.Version >= 0 }
@@ -34767,6 +36468,20 @@ func (v *UpdateFeaturesResponse) RequestKind() Request {
 	return &UpdateFeaturesRequest{Version: v.Version}
 }
 
+func (v *UpdateFeaturesResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Results {
+		v1 := &v.Results[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Results.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *UpdateFeaturesResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -34790,11 +36505,7 @@ func (v *UpdateFeaturesResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Results
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -34831,6 +36542,23 @@ func (v *UpdateFeaturesResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *UpdateFeaturesResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *UpdateFeaturesResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *UpdateFeaturesResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -34859,11 +36587,7 @@ func (v *UpdateFeaturesResponse) ReadFrom(src []byte) error {
 		v := s.Results
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -34906,6 +36630,9 @@ func (v *UpdateFeaturesResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -35003,6 +36730,23 @@ func (v *EnvelopeRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *EnvelopeRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *EnvelopeRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *EnvelopeRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -35040,6 +36784,9 @@ func (v *EnvelopeRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -35088,6 +36835,13 @@ func (v *EnvelopeResponse) SetVersion(version int16) { v.Version = version }
 func (v *EnvelopeResponse) GetVersion() int16        { return v.Version }
 func (v *EnvelopeResponse) IsFlexible() bool         { return v.Version >= 0 }
 func (v *EnvelopeResponse) RequestKind() Request     { return &EnvelopeRequest{Version: v.Version} }
+func (v *EnvelopeResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
 
 func (v *EnvelopeResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
@@ -35114,6 +36868,23 @@ func (v *EnvelopeResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *EnvelopeResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *EnvelopeResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *EnvelopeResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -35137,6 +36908,9 @@ func (v *EnvelopeResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -35301,11 +37075,7 @@ func (v *FetchSnapshotRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -35318,11 +37088,7 @@ func (v *FetchSnapshotRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -35398,6 +37164,23 @@ func (v *FetchSnapshotRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *FetchSnapshotRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *FetchSnapshotRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *FetchSnapshotRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -35417,11 +37200,7 @@ func (v *FetchSnapshotRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -35445,11 +37224,7 @@ func (v *FetchSnapshotRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -35522,6 +37297,9 @@ func (v *FetchSnapshotRequest) ReadFrom(src []byte) error {
 			}
 		}
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -35694,6 +37472,23 @@ func (v *FetchSnapshotResponse) RequestKind() Request {
 	return &FetchSnapshotRequest{Version: v.Version}
 }
 
+func (v *FetchSnapshotResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *FetchSnapshotResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -35709,11 +37504,7 @@ func (v *FetchSnapshotResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -35726,11 +37517,7 @@ func (v *FetchSnapshotResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -35829,6 +37616,23 @@ func (v *FetchSnapshotResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *FetchSnapshotResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *FetchSnapshotResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *FetchSnapshotResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -35848,11 +37652,7 @@ func (v *FetchSnapshotResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -35876,11 +37676,7 @@ func (v *FetchSnapshotResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -35973,6 +37769,9 @@ func (v *FetchSnapshotResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -36047,6 +37846,23 @@ func (v *DescribeClusterRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeClusterRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeClusterRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeClusterRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -36061,6 +37877,9 @@ func (v *DescribeClusterRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -36160,6 +37979,14 @@ func (v *DescribeClusterResponse) RequestKind() Request {
 	return &DescribeClusterRequest{Version: v.Version}
 }
 
+func (v *DescribeClusterResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *DescribeClusterResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -36195,11 +38022,7 @@ func (v *DescribeClusterResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Brokers
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -36244,6 +38067,23 @@ func (v *DescribeClusterResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeClusterResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeClusterResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeClusterResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -36285,11 +38125,7 @@ func (v *DescribeClusterResponse) ReadFrom(src []byte) error {
 		v := s.Brokers
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -36340,6 +38176,9 @@ func (v *DescribeClusterResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -36431,11 +38270,7 @@ func (v *DescribeProducersRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -36448,11 +38283,7 @@ func (v *DescribeProducersRequest) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := v[i]
 					dst = kbin.AppendInt32(dst, v)
@@ -36472,6 +38303,23 @@ func (v *DescribeProducersRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeProducersRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeProducersRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeProducersRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -36483,11 +38331,7 @@ func (v *DescribeProducersRequest) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -36511,11 +38355,7 @@ func (v *DescribeProducersRequest) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -36539,6 +38379,9 @@ func (v *DescribeProducersRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -36693,6 +38536,20 @@ func (v *DescribeProducersResponse) RequestKind() Request {
 	return &DescribeProducersRequest{Version: v.Version}
 }
 
+func (v *DescribeProducersResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Topics {
+		v1 := &v.Topics[i]
+		for i := range v1.Partitions {
+			v2 := &v1.Partitions[i]
+			if v2.ErrorCode != 0 {
+				errs = append(errs, ResponseError{Code: v2.ErrorCode, Message: v2.ErrorMessage, Topic: v1.Topic, Field: "Topics.Partitions.ErrorCode", Partition: v2.Partition})
+			}
+		}
+	}
+	return errs
+}
+
 func (v *DescribeProducersResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -36704,11 +38561,7 @@ func (v *DescribeProducersResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Topics
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -36721,11 +38574,7 @@ func (v *DescribeProducersResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Partitions
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -36746,11 +38595,7 @@ func (v *DescribeProducersResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.ActiveProducers
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := &v[i]
 							{
@@ -36803,6 +38648,23 @@ func (v *DescribeProducersResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeProducersResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeProducersResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeProducersResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -36818,11 +38680,7 @@ func (v *DescribeProducersResponse) ReadFrom(src []byte) error {
 		v := s.Topics
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -36846,11 +38704,7 @@ func (v *DescribeProducersResponse) ReadFrom(src []byte) error {
 				v := s.Partitions
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -36882,11 +38736,7 @@ func (v *DescribeProducersResponse) ReadFrom(src []byte) error {
 						v := s.ActiveProducers
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -36945,6 +38795,9 @@ func (v *DescribeProducersResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37095,11 +38948,7 @@ func (v *BrokerRegistrationRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Listeners
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -37134,11 +38983,7 @@ func (v *BrokerRegistrationRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.Features
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -37179,6 +39024,23 @@ func (v *BrokerRegistrationRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *BrokerRegistrationRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *BrokerRegistrationRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *BrokerRegistrationRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37207,11 +39069,7 @@ func (v *BrokerRegistrationRequest) ReadFrom(src []byte) error {
 		v := s.Listeners
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -37259,11 +39117,7 @@ func (v *BrokerRegistrationRequest) ReadFrom(src []byte) error {
 		v := s.Features
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -37310,6 +39164,9 @@ func (v *BrokerRegistrationRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37368,6 +39225,14 @@ func (v *BrokerRegistrationResponse) RequestKind() Request {
 	return &BrokerRegistrationRequest{Version: v.Version}
 }
 
+func (v *BrokerRegistrationResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *BrokerRegistrationResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -37393,6 +39258,23 @@ func (v *BrokerRegistrationResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *BrokerRegistrationResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *BrokerRegistrationResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *BrokerRegistrationResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37415,6 +39297,9 @@ func (v *BrokerRegistrationResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37518,6 +39403,23 @@ func (v *BrokerHeartbeatRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *BrokerHeartbeatRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *BrokerHeartbeatRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *BrokerHeartbeatRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37548,6 +39450,9 @@ func (v *BrokerHeartbeatRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37610,6 +39515,14 @@ func (v *BrokerHeartbeatResponse) RequestKind() Request {
 	return &BrokerHeartbeatRequest{Version: v.Version}
 }
 
+func (v *BrokerHeartbeatResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *BrokerHeartbeatResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -37643,6 +39556,23 @@ func (v *BrokerHeartbeatResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *BrokerHeartbeatResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *BrokerHeartbeatResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *BrokerHeartbeatResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37673,6 +39603,9 @@ func (v *BrokerHeartbeatResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37746,6 +39679,23 @@ func (v *UnregisterBrokerRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *UnregisterBrokerRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *UnregisterBrokerRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *UnregisterBrokerRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37760,6 +39710,9 @@ func (v *UnregisterBrokerRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37813,6 +39766,14 @@ func (v *UnregisterBrokerResponse) RequestKind() Request {
 	return &UnregisterBrokerRequest{Version: v.Version}
 }
 
+func (v *UnregisterBrokerResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *UnregisterBrokerResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -37842,6 +39803,23 @@ func (v *UnregisterBrokerResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *UnregisterBrokerResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *UnregisterBrokerResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *UnregisterBrokerResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37869,6 +39847,9 @@ func (v *UnregisterBrokerResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -37931,11 +39912,7 @@ func (v *DescribeTransactionsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.TransactionalIDs
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -37953,6 +39930,23 @@ func (v *DescribeTransactionsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeTransactionsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeTransactionsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeTransactionsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -37964,11 +39958,7 @@ func (v *DescribeTransactionsRequest) ReadFrom(src []byte) error {
 		v := s.TransactionalIDs
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -37990,6 +39980,9 @@ func (v *DescribeTransactionsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -38122,6 +40115,17 @@ func (v *DescribeTransactionsResponse) RequestKind() Request {
 	return &DescribeTransactionsRequest{Version: v.Version}
 }
 
+func (v *DescribeTransactionsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.TransactionStates {
+		v1 := &v.TransactionStates[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Field: "TransactionStates.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
 func (v *DescribeTransactionsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -38133,11 +40137,7 @@ func (v *DescribeTransactionsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.TransactionStates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -38178,11 +40178,7 @@ func (v *DescribeTransactionsResponse) AppendTo(dst []byte) []byte {
 			}
 			{
 				v := v.Topics
-				if isFlexible {
-					dst = kbin.AppendCompactArrayLen(dst, len(v))
-				} else {
-					dst = kbin.AppendArrayLen(dst, len(v))
-				}
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 				for i := range v {
 					v := &v[i]
 					{
@@ -38195,11 +40191,7 @@ func (v *DescribeTransactionsResponse) AppendTo(dst []byte) []byte {
 					}
 					{
 						v := v.Partitions
-						if isFlexible {
-							dst = kbin.AppendCompactArrayLen(dst, len(v))
-						} else {
-							dst = kbin.AppendArrayLen(dst, len(v))
-						}
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 						for i := range v {
 							v := v[i]
 							dst = kbin.AppendInt32(dst, v)
@@ -38225,6 +40217,23 @@ func (v *DescribeTransactionsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *DescribeTransactionsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *DescribeTransactionsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *DescribeTransactionsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -38240,11 +40249,7 @@ func (v *DescribeTransactionsResponse) ReadFrom(src []byte) error {
 		v := s.TransactionStates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -38297,11 +40302,7 @@ func (v *DescribeTransactionsResponse) ReadFrom(src []byte) error {
 				v := s.Topics
 				a := v
 				var l int32
-				if isFlexible {
-					l = b.CompactArrayLen()
-				} else {
-					l = b.ArrayLen()
-				}
+				l = b.FlexibleArrayLen(isFlexible)
 				if !b.Ok() {
 					return b.Complete()
 				}
@@ -38325,11 +40326,7 @@ func (v *DescribeTransactionsResponse) ReadFrom(src []byte) error {
 						v := s.Partitions
 						a := v
 						var l int32
-						if isFlexible {
-							l = b.CompactArrayLen()
-						} else {
-							l = b.ArrayLen()
-						}
+						l = b.FlexibleArrayLen(isFlexible)
 						if !b.Ok() {
 							return b.Complete()
 						}
@@ -38360,6 +40357,9 @@ func (v *DescribeTransactionsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -38430,11 +40430,7 @@ func (v *ListTransactionsRequest) AppendTo(dst []byte) []byte {
 	_ = isFlexible
 	{
 		v := v.StateFilters
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -38446,11 +40442,7 @@ func (v *ListTransactionsRequest) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.ProducerIDFilters
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			dst = kbin.AppendInt64(dst, v)
@@ -38464,6 +40456,23 @@ func (v *ListTransactionsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListTransactionsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListTransactionsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListTransactionsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -38475,11 +40484,7 @@ func (v *ListTransactionsRequest) ReadFrom(src []byte) error {
 		v := s.StateFilters
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -38502,11 +40507,7 @@ func (v *ListTransactionsRequest) ReadFrom(src []byte) error {
 		v := s.ProducerIDFilters
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -38523,6 +40524,9 @@ func (v *ListTransactionsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -38614,6 +40618,14 @@ func (v *ListTransactionsResponse) RequestKind() Request {
 	return &ListTransactionsRequest{Version: v.Version}
 }
 
+func (v *ListTransactionsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *ListTransactionsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -38629,11 +40641,7 @@ func (v *ListTransactionsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.UnknownStateFilters
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := v[i]
 			if isFlexible {
@@ -38645,11 +40653,7 @@ func (v *ListTransactionsResponse) AppendTo(dst []byte) []byte {
 	}
 	{
 		v := v.TransactionStates
-		if isFlexible {
-			dst = kbin.AppendCompactArrayLen(dst, len(v))
-		} else {
-			dst = kbin.AppendArrayLen(dst, len(v))
-		}
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
 		for i := range v {
 			v := &v[i]
 			{
@@ -38686,6 +40690,23 @@ func (v *ListTransactionsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *ListTransactionsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ListTransactionsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ListTransactionsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -38705,11 +40726,7 @@ func (v *ListTransactionsResponse) ReadFrom(src []byte) error {
 		v := s.UnknownStateFilters
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -38732,11 +40749,7 @@ func (v *ListTransactionsResponse) ReadFrom(src []byte) error {
 		v := s.TransactionStates
 		a := v
 		var l int32
-		if isFlexible {
-			l = b.CompactArrayLen()
-		} else {
-			l = b.ArrayLen()
-		}
+		l = b.FlexibleArrayLen(isFlexible)
 		if !b.Ok() {
 			return b.Complete()
 		}
@@ -38779,6 +40792,9 @@ func (v *ListTransactionsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -38862,6 +40878,23 @@ func (v *AllocateProducerIDsRequest) AppendTo(dst []byte) []byte {
 }
 
 func (v *AllocateProducerIDsRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AllocateProducerIDsRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AllocateProducerIDsRequest) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -38880,6 +40913,9 @@ func (v *AllocateProducerIDsRequest) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -38940,6 +40976,14 @@ func (v *AllocateProducerIDsResponse) RequestKind() Request {
 	return &AllocateProducerIDsRequest{Version: v.Version}
 }
 
+func (v *AllocateProducerIDsResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
 func (v *AllocateProducerIDsResponse) AppendTo(dst []byte) []byte {
 	version := v.Version
 	_ = version
@@ -38969,6 +41013,23 @@ func (v *AllocateProducerIDsResponse) AppendTo(dst []byte) []byte {
 }
 
 func (v *AllocateProducerIDsResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *AllocateProducerIDsResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *AllocateProducerIDsResponse) readFrom(src []byte, rest *[]byte) error {
 	v.Default()
 	b := kbin.Reader{Src: src}
 	version := v.Version
@@ -38995,6 +41056,9 @@ func (v *AllocateProducerIDsResponse) ReadFrom(src []byte) error {
 	if isFlexible {
 		s.UnknownTags = internalReadTags(&b)
 	}
+	if rest != nil {
+		*rest = b.Src
+	}
 	return b.Complete()
 }
 
@@ -39019,385 +41083,2061 @@ func NewAllocateProducerIDsResponse() AllocateProducerIDsResponse {
 	return v
 }
 
-// RequestForKey returns the request corresponding to the given request key
-// or nil if the key is unknown.
-func RequestForKey(key int16) Request {
-	switch key {
-	default:
-		return nil
-	case 0:
-		return NewPtrProduceRequest()
-	case 1:
-		return NewPtrFetchRequest()
-	case 2:
-		return NewPtrListOffsetsRequest()
-	case 3:
-		return NewPtrMetadataRequest()
-	case 4:
-		return NewPtrLeaderAndISRRequest()
-	case 5:
-		return NewPtrStopReplicaRequest()
-	case 6:
-		return NewPtrUpdateMetadataRequest()
-	case 7:
-		return NewPtrControlledShutdownRequest()
-	case 8:
-		return NewPtrOffsetCommitRequest()
-	case 9:
-		return NewPtrOffsetFetchRequest()
-	case 10:
-		return NewPtrFindCoordinatorRequest()
-	case 11:
-		return NewPtrJoinGroupRequest()
-	case 12:
-		return NewPtrHeartbeatRequest()
-	case 13:
-		return NewPtrLeaveGroupRequest()
-	case 14:
-		return NewPtrSyncGroupRequest()
-	case 15:
-		return NewPtrDescribeGroupsRequest()
-	case 16:
-		return NewPtrListGroupsRequest()
-	case 17:
-		return NewPtrSASLHandshakeRequest()
-	case 18:
-		return NewPtrApiVersionsRequest()
-	case 19:
-		return NewPtrCreateTopicsRequest()
-	case 20:
-		return NewPtrDeleteTopicsRequest()
-	case 21:
-		return NewPtrDeleteRecordsRequest()
-	case 22:
-		return NewPtrInitProducerIDRequest()
-	case 23:
-		return NewPtrOffsetForLeaderEpochRequest()
-	case 24:
-		return NewPtrAddPartitionsToTxnRequest()
-	case 25:
-		return NewPtrAddOffsetsToTxnRequest()
-	case 26:
-		return NewPtrEndTxnRequest()
-	case 27:
-		return NewPtrWriteTxnMarkersRequest()
-	case 28:
-		return NewPtrTxnOffsetCommitRequest()
-	case 29:
-		return NewPtrDescribeACLsRequest()
-	case 30:
-		return NewPtrCreateACLsRequest()
-	case 31:
-		return NewPtrDeleteACLsRequest()
-	case 32:
-		return NewPtrDescribeConfigsRequest()
-	case 33:
-		return NewPtrAlterConfigsRequest()
-	case 34:
-		return NewPtrAlterReplicaLogDirsRequest()
-	case 35:
-		return NewPtrDescribeLogDirsRequest()
-	case 36:
-		return NewPtrSASLAuthenticateRequest()
-	case 37:
-		return NewPtrCreatePartitionsRequest()
-	case 38:
-		return NewPtrCreateDelegationTokenRequest()
-	case 39:
-		return NewPtrRenewDelegationTokenRequest()
-	case 40:
-		return NewPtrExpireDelegationTokenRequest()
-	case 41:
-		return NewPtrDescribeDelegationTokenRequest()
-	case 42:
-		return NewPtrDeleteGroupsRequest()
-	case 43:
-		return NewPtrElectLeadersRequest()
-	case 44:
-		return NewPtrIncrementalAlterConfigsRequest()
-	case 45:
-		return NewPtrAlterPartitionAssignmentsRequest()
-	case 46:
-		return NewPtrListPartitionReassignmentsRequest()
-	case 47:
-		return NewPtrOffsetDeleteRequest()
-	case 48:
-		return NewPtrDescribeClientQuotasRequest()
-	case 49:
-		return NewPtrAlterClientQuotasRequest()
-	case 50:
-		return NewPtrDescribeUserSCRAMCredentialsRequest()
-	case 51:
-		return NewPtrAlterUserSCRAMCredentialsRequest()
-	case 52:
-		return NewPtrVoteRequest()
-	case 53:
-		return NewPtrBeginQuorumEpochRequest()
-	case 54:
-		return NewPtrEndQuorumEpochRequest()
-	case 55:
-		return NewPtrDescribeQuorumRequest()
-	case 56:
-		return NewPtrAlterISRRequest()
-	case 57:
-		return NewPtrUpdateFeaturesRequest()
-	case 58:
-		return NewPtrEnvelopeRequest()
-	case 59:
-		return NewPtrFetchSnapshotRequest()
-	case 60:
-		return NewPtrDescribeClusterRequest()
-	case 61:
-		return NewPtrDescribeProducersRequest()
-	case 62:
-		return NewPtrBrokerRegistrationRequest()
-	case 63:
-		return NewPtrBrokerHeartbeatRequest()
-	case 64:
-		return NewPtrUnregisterBrokerRequest()
-	case 65:
-		return NewPtrDescribeTransactionsRequest()
-	case 66:
-		return NewPtrListTransactionsRequest()
-	case 67:
-		return NewPtrAllocateProducerIDsRequest()
-	}
+type ConsumerGroupHeartbeatRequestTopic struct {
+	// TopicID is the ID of the topic.
+	TopicID [16]byte
+
+	// Partitions are the partitions of this topic owned by the member.
+	Partitions []int32
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
 }
 
-// ResponseForKey returns the response corresponding to the given request key
-// or nil if the key is unknown.
-func ResponseForKey(key int16) Response {
-	switch key {
-	default:
-		return nil
-	case 0:
-		return NewPtrProduceResponse()
-	case 1:
-		return NewPtrFetchResponse()
-	case 2:
-		return NewPtrListOffsetsResponse()
-	case 3:
-		return NewPtrMetadataResponse()
-	case 4:
-		return NewPtrLeaderAndISRResponse()
-	case 5:
-		return NewPtrStopReplicaResponse()
-	case 6:
-		return NewPtrUpdateMetadataResponse()
-	case 7:
-		return NewPtrControlledShutdownResponse()
-	case 8:
-		return NewPtrOffsetCommitResponse()
-	case 9:
-		return NewPtrOffsetFetchResponse()
-	case 10:
-		return NewPtrFindCoordinatorResponse()
-	case 11:
-		return NewPtrJoinGroupResponse()
-	case 12:
-		return NewPtrHeartbeatResponse()
-	case 13:
-		return NewPtrLeaveGroupResponse()
-	case 14:
-		return NewPtrSyncGroupResponse()
-	case 15:
-		return NewPtrDescribeGroupsResponse()
-	case 16:
-		return NewPtrListGroupsResponse()
-	case 17:
-		return NewPtrSASLHandshakeResponse()
-	case 18:
-		return NewPtrApiVersionsResponse()
-	case 19:
-		return NewPtrCreateTopicsResponse()
-	case 20:
-		return NewPtrDeleteTopicsResponse()
-	case 21:
-		return NewPtrDeleteRecordsResponse()
-	case 22:
-		return NewPtrInitProducerIDResponse()
-	case 23:
-		return NewPtrOffsetForLeaderEpochResponse()
-	case 24:
-		return NewPtrAddPartitionsToTxnResponse()
-	case 25:
-		return NewPtrAddOffsetsToTxnResponse()
-	case 26:
-		return NewPtrEndTxnResponse()
-	case 27:
-		return NewPtrWriteTxnMarkersResponse()
-	case 28:
-		return NewPtrTxnOffsetCommitResponse()
-	case 29:
-		return NewPtrDescribeACLsResponse()
-	case 30:
-		return NewPtrCreateACLsResponse()
-	case 31:
-		return NewPtrDeleteACLsResponse()
-	case 32:
-		return NewPtrDescribeConfigsResponse()
-	case 33:
-		return NewPtrAlterConfigsResponse()
-	case 34:
-		return NewPtrAlterReplicaLogDirsResponse()
-	case 35:
-		return NewPtrDescribeLogDirsResponse()
-	case 36:
-		return NewPtrSASLAuthenticateResponse()
-	case 37:
-		return NewPtrCreatePartitionsResponse()
-	case 38:
-		return NewPtrCreateDelegationTokenResponse()
-	case 39:
-		return NewPtrRenewDelegationTokenResponse()
-	case 40:
-		return NewPtrExpireDelegationTokenResponse()
-	case 41:
-		return NewPtrDescribeDelegationTokenResponse()
-	case 42:
-		return NewPtrDeleteGroupsResponse()
-	case 43:
-		return NewPtrElectLeadersResponse()
-	case 44:
-		return NewPtrIncrementalAlterConfigsResponse()
-	case 45:
-		return NewPtrAlterPartitionAssignmentsResponse()
-	case 46:
-		return NewPtrListPartitionReassignmentsResponse()
-	case 47:
-		return NewPtrOffsetDeleteResponse()
-	case 48:
-		return NewPtrDescribeClientQuotasResponse()
-	case 49:
-		return NewPtrAlterClientQuotasResponse()
-	case 50:
-		return NewPtrDescribeUserSCRAMCredentialsResponse()
-	case 51:
-		return NewPtrAlterUserSCRAMCredentialsResponse()
-	case 52:
-		return NewPtrVoteResponse()
-	case 53:
-		return NewPtrBeginQuorumEpochResponse()
-	case 54:
-		return NewPtrEndQuorumEpochResponse()
-	case 55:
-		return NewPtrDescribeQuorumResponse()
-	case 56:
-		return NewPtrAlterISRResponse()
-	case 57:
-		return NewPtrUpdateFeaturesResponse()
-	case 58:
-		return NewPtrEnvelopeResponse()
-	case 59:
-		return NewPtrFetchSnapshotResponse()
-	case 60:
-		return NewPtrDescribeClusterResponse()
-	case 61:
-		return NewPtrDescribeProducersResponse()
-	case 62:
-		return NewPtrBrokerRegistrationResponse()
-	case 63:
-		return NewPtrBrokerHeartbeatResponse()
-	case 64:
-		return NewPtrUnregisterBrokerResponse()
-	case 65:
-		return NewPtrDescribeTransactionsResponse()
-	case 66:
-		return NewPtrListTransactionsResponse()
-	case 67:
-		return NewPtrAllocateProducerIDsResponse()
-	}
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupHeartbeatRequestTopic.
+func (v *ConsumerGroupHeartbeatRequestTopic) Default() {
 }
 
-// NameForKey returns the name (e.g., "Fetch") corresponding to a given request key
-// or "" if the key is unknown.
-func NameForKey(key int16) string {
-	switch key {
-	default:
-		return "Unknown"
-	case 0:
-		return "Produce"
-	case 1:
-		return "Fetch"
-	case 2:
-		return "ListOffsets"
-	case 3:
-		return "Metadata"
-	case 4:
-		return "LeaderAndISR"
-	case 5:
-		return "StopReplica"
-	case 6:
-		return "UpdateMetadata"
-	case 7:
-		return "ControlledShutdown"
-	case 8:
-		return "OffsetCommit"
-	case 9:
-		return "OffsetFetch"
-	case 10:
-		return "FindCoordinator"
-	case 11:
-		return "JoinGroup"
-	case 12:
-		return "Heartbeat"
-	case 13:
-		return "LeaveGroup"
-	case 14:
-		return "SyncGroup"
-	case 15:
-		return "DescribeGroups"
-	case 16:
-		return "ListGroups"
-	case 17:
-		return "SASLHandshake"
-	case 18:
-		return "ApiVersions"
-	case 19:
-		return "CreateTopics"
-	case 20:
-		return "DeleteTopics"
-	case 21:
-		return "DeleteRecords"
-	case 22:
-		return "InitProducerID"
-	case 23:
-		return "OffsetForLeaderEpoch"
-	case 24:
-		return "AddPartitionsToTxn"
-	case 25:
-		return "AddOffsetsToTxn"
-	case 26:
-		return "EndTxn"
-	case 27:
-		return "WriteTxnMarkers"
-	case 28:
-		return "TxnOffsetCommit"
-	case 29:
-		return "DescribeACLs"
-	case 30:
-		return "CreateACLs"
-	case 31:
-		return "DeleteACLs"
-	case 32:
-		return "DescribeConfigs"
-	case 33:
-		return "AlterConfigs"
-	case 34:
-		return "AlterReplicaLogDirs"
-	case 35:
-		return "DescribeLogDirs"
-	case 36:
-		return "SASLAuthenticate"
-	case 37:
-		return "CreatePartitions"
-	case 38:
-		return "CreateDelegationToken"
-	case 39:
-		return "RenewDelegationToken"
-	case 40:
-		return "ExpireDelegationToken"
-	case 41:
+// NewConsumerGroupHeartbeatRequestTopic returns a default ConsumerGroupHeartbeatRequestTopic
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupHeartbeatRequestTopic() ConsumerGroupHeartbeatRequestTopic {
+	var v ConsumerGroupHeartbeatRequestTopic
+	v.Default()
+	return v
+}
+
+// ConsumerGroupHeartbeatRequest, introduced for KIP-848, is the single
+// request a member of a group using the new consumer group protocol uses to
+// join, heartbeat in, and leave the group. Assignments are computed by the
+// broker and returned in heartbeat responses; the member acknowledges an
+// assignment by sending the partitions it owns in a subsequent heartbeat.
+//
+// Many fields only need to be sent when they change (or when joining); a
+// null value (or -1) means the field is unchanged since the last heartbeat.
+type ConsumerGroupHeartbeatRequest struct {
+	// Version is the version of this message used with a Kafka broker.
+	Version int16
+
+	// Group is the group to join, heartbeat in, or leave.
+	Group string
+
+	// MemberID is the member ID returned from the broker in the first
+	// heartbeat response, or the empty string when joining.
+	MemberID string
+
+	// MemberEpoch is the current epoch of the member: 0 to join the group, -1
+	// to leave the group, -2 to leave the group if using a static InstanceID,
+	// and otherwise the latest epoch returned from the broker.
+	MemberEpoch int32
+
+	// InstanceID is the instance ID of this member in the group, if any
+	// (KIP-345).
+	InstanceID *string
+
+	// RackID is the rack of this member, if any. This only needs to be sent
+	// when joining or when the rack changes.
+	RackID *string
+
+	// RebalanceTimeoutMillis is the maximum time the broker waits for the
+	// member to revoke partitions, or -1 if unchanged.
+	//
+	// This field has a default of -1.
+	RebalanceTimeoutMillis int32
+
+	// SubscribedTopicNames are the topics the member is subscribed to. This
+	// only needs to be sent when joining or when the subscription changes.
+	SubscribedTopicNames []string
+
+	// ServerAssignor is the server side assignor to use, or null to use the
+	// broker's default. This only needs to be sent when joining or when the
+	// assignor changes.
+	ServerAssignor *string
+
+	// Topics are the partitions currently owned by this member. This must be
+	// sent when joining, and otherwise only when the owned partitions change,
+	// i.e. to acknowledge a new assignment.
+	Topics []ConsumerGroupHeartbeatRequestTopic
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+func (*ConsumerGroupHeartbeatRequest) Key() int16                   { return 68 }
+func (*ConsumerGroupHeartbeatRequest) MaxVersion() int16            { return 0 }
+func (v *ConsumerGroupHeartbeatRequest) SetVersion(version int16)   { v.Version = version }
+func (v *ConsumerGroupHeartbeatRequest) GetVersion() int16          { return v.Version }
+func (v *ConsumerGroupHeartbeatRequest) IsFlexible() bool           { return v.Version >= 0 }
+func (v *ConsumerGroupHeartbeatRequest) IsGroupCoordinatorRequest() {}
+func (v *ConsumerGroupHeartbeatRequest) ResponseKind() Response {
+	return &ConsumerGroupHeartbeatResponse{Version: v.Version}
+}
+
+// RequestWith is requests v on r and returns the response or an error.
+// For sharded requests, the response may be merged and still return an error.
+// It is better to rely on client.RequestSharded than to rely on proper merging behavior.
+func (v *ConsumerGroupHeartbeatRequest) RequestWith(ctx context.Context, r Requestor) (*ConsumerGroupHeartbeatResponse, error) {
+	kresp, err := r.Request(ctx, v)
+	resp, _ := kresp.(*ConsumerGroupHeartbeatResponse)
+	return resp, err
+}
+
+func (v *ConsumerGroupHeartbeatRequest) AppendTo(dst []byte) []byte {
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	{
+		v := v.Group
+		if isFlexible {
+			dst = kbin.AppendCompactString(dst, v)
+		} else {
+			dst = kbin.AppendString(dst, v)
+		}
+	}
+	{
+		v := v.MemberID
+		if isFlexible {
+			dst = kbin.AppendCompactString(dst, v)
+		} else {
+			dst = kbin.AppendString(dst, v)
+		}
+	}
+	{
+		v := v.MemberEpoch
+		dst = kbin.AppendInt32(dst, v)
+	}
+	{
+		v := v.InstanceID
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
+	}
+	{
+		v := v.RackID
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
+	}
+	{
+		v := v.RebalanceTimeoutMillis
+		dst = kbin.AppendInt32(dst, v)
+	}
+	{
+		v := v.SubscribedTopicNames
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
+		for i := range v {
+			v := v[i]
+			if isFlexible {
+				dst = kbin.AppendCompactString(dst, v)
+			} else {
+				dst = kbin.AppendString(dst, v)
+			}
+		}
+	}
+	{
+		v := v.ServerAssignor
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
+	}
+	{
+		v := v.Topics
+		dst = kbin.AppendFlexibleNullableArrayLen(dst, len(v), v == nil, isFlexible)
+		for i := range v {
+			v := &v[i]
+			{
+				v := v.TopicID
+				dst = kbin.AppendUuid(dst, v)
+			}
+			{
+				v := v.Partitions
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+				for i := range v {
+					v := v[i]
+					dst = kbin.AppendInt32(dst, v)
+				}
+			}
+			if isFlexible {
+				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+				dst = v.UnknownTags.AppendEach(dst)
+			}
+		}
+	}
+	if isFlexible {
+		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+		dst = v.UnknownTags.AppendEach(dst)
+	}
+	return dst
+}
+
+func (v *ConsumerGroupHeartbeatRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ConsumerGroupHeartbeatRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ConsumerGroupHeartbeatRequest) readFrom(src []byte, rest *[]byte) error {
+	v.Default()
+	b := kbin.Reader{Src: src}
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	s := v
+	{
+		var v string
+		if isFlexible {
+			v = b.CompactString()
+		} else {
+			v = b.String()
+		}
+		s.Group = v
+	}
+	{
+		var v string
+		if isFlexible {
+			v = b.CompactString()
+		} else {
+			v = b.String()
+		}
+		s.MemberID = v
+	}
+	{
+		v := b.Int32()
+		s.MemberEpoch = v
+	}
+	{
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
+		s.InstanceID = v
+	}
+	{
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
+		s.RackID = v
+	}
+	{
+		v := b.Int32()
+		s.RebalanceTimeoutMillis = v
+	}
+	{
+		v := s.SubscribedTopicNames
+		a := v
+		var l int32
+		l = b.FlexibleArrayLen(isFlexible)
+		if version < 0 || l == 0 {
+			a = []string{}
+		}
+		if !b.Ok() {
+			return b.Complete()
+		}
+		if l > 0 {
+			a = make([]string, l)
+		}
+		for i := int32(0); i < l; i++ {
+			var v string
+			if isFlexible {
+				v = b.CompactString()
+			} else {
+				v = b.String()
+			}
+			a[i] = v
+		}
+		v = a
+		s.SubscribedTopicNames = v
+	}
+	{
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
+		s.ServerAssignor = v
+	}
+	{
+		v := s.Topics
+		a := v
+		var l int32
+		l = b.FlexibleArrayLen(isFlexible)
+		if version < 0 || l == 0 {
+			a = []ConsumerGroupHeartbeatRequestTopic{}
+		}
+		if !b.Ok() {
+			return b.Complete()
+		}
+		if l > 0 {
+			a = make([]ConsumerGroupHeartbeatRequestTopic, l)
+		}
+		for i := int32(0); i < l; i++ {
+			v := &a[i]
+			v.Default()
+			s := v
+			{
+				v := b.Uuid()
+				s.TopicID = v
+			}
+			{
+				v := s.Partitions
+				a := v
+				var l int32
+				l = b.FlexibleArrayLen(isFlexible)
+				if !b.Ok() {
+					return b.Complete()
+				}
+				if l > 0 {
+					a = make([]int32, l)
+				}
+				for i := int32(0); i < l; i++ {
+					v := b.Int32()
+					a[i] = v
+				}
+				v = a
+				s.Partitions = v
+			}
+			if isFlexible {
+				s.UnknownTags = internalReadTags(&b)
+			}
+		}
+		v = a
+		s.Topics = v
+	}
+	if isFlexible {
+		s.UnknownTags = internalReadTags(&b)
+	}
+	if rest != nil {
+		*rest = b.Src
+	}
+	return b.Complete()
+}
+
+// NewPtrConsumerGroupHeartbeatRequest returns a pointer to a default ConsumerGroupHeartbeatRequest
+// This is a shortcut for creating a new(struct) and calling Default yourself.
+func NewPtrConsumerGroupHeartbeatRequest() *ConsumerGroupHeartbeatRequest {
+	var v ConsumerGroupHeartbeatRequest
+	v.Default()
+	return &v
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupHeartbeatRequest.
+func (v *ConsumerGroupHeartbeatRequest) Default() {
+	v.RebalanceTimeoutMillis = -1
+}
+
+// NewConsumerGroupHeartbeatRequest returns a default ConsumerGroupHeartbeatRequest
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupHeartbeatRequest() ConsumerGroupHeartbeatRequest {
+	var v ConsumerGroupHeartbeatRequest
+	v.Default()
+	return v
+}
+
+type ConsumerGroupHeartbeatResponseAssignmentTopic struct {
+	// TopicID is the ID of the topic.
+	TopicID [16]byte
+
+	// Partitions are the assigned partitions of this topic.
+	Partitions []int32
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupHeartbeatResponseAssignmentTopic.
+func (v *ConsumerGroupHeartbeatResponseAssignmentTopic) Default() {
+}
+
+// NewConsumerGroupHeartbeatResponseAssignmentTopic returns a default ConsumerGroupHeartbeatResponseAssignmentTopic
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupHeartbeatResponseAssignmentTopic() ConsumerGroupHeartbeatResponseAssignmentTopic {
+	var v ConsumerGroupHeartbeatResponseAssignmentTopic
+	v.Default()
+	return v
+}
+
+type ConsumerGroupHeartbeatResponseAssignment struct {
+	// Topics are the assigned partitions per topic.
+	Topics []ConsumerGroupHeartbeatResponseAssignmentTopic
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupHeartbeatResponseAssignment.
+func (v *ConsumerGroupHeartbeatResponseAssignment) Default() {
+}
+
+// NewConsumerGroupHeartbeatResponseAssignment returns a default ConsumerGroupHeartbeatResponseAssignment
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupHeartbeatResponseAssignment() ConsumerGroupHeartbeatResponseAssignment {
+	var v ConsumerGroupHeartbeatResponseAssignment
+	v.Default()
+	return v
+}
+
+// ConsumerGroupHeartbeatResponse is returned from a ConsumerGroupHeartbeatRequest.
+type ConsumerGroupHeartbeatResponse struct {
+	// Version is the version of this message used with a Kafka broker.
+	Version int16
+
+	// ThrottleMillis is how long of a throttle Kafka will apply to the client
+	// after responding to this request.
+	ThrottleMillis int32
+
+	// ErrorCode is the error for this heartbeat, or 0 if there was no error.
+	//
+	// GROUP_AUTHORIZATION_FAILED is returned if the client is not authorized
+	// to the group.
+	//
+	// NOT_COORDINATOR, COORDINATOR_NOT_AVAILABLE, and
+	// COORDINATOR_LOAD_IN_PROGRESS are returned for the standard coordinator
+	// reasons.
+	//
+	// INVALID_REQUEST is returned if a required field is missing.
+	//
+	// UNKNOWN_MEMBER_ID is returned if the member is not a part of the group.
+	//
+	// FENCED_MEMBER_EPOCH is returned if the member epoch is fenced by the
+	// group coordinator; the member must rejoin with epoch 0.
+	//
+	// UNRELEASED_INSTANCE_ID is returned if the InstanceID is still in use by
+	// another member.
+	//
+	// UNSUPPORTED_ASSIGNOR is returned if the ServerAssignor is unknown.
+	//
+	// GROUP_MAX_SIZE_REACHED is returned if the group is full.
+	ErrorCode int16
+
+	// ErrorMessage is an informative message if the heartbeat failed.
+	ErrorMessage *string
+
+	// MemberID is the member ID this member should use going forward, if
+	// the broker generated one.
+	MemberID *string
+
+	// MemberEpoch is the current epoch of this member.
+	MemberEpoch int32
+
+	// HeartbeatIntervalMillis is how long the member should wait before
+	// sending the next heartbeat.
+	HeartbeatIntervalMillis int32
+
+	// Assignment is the full assignment for this member, or null if the
+	// assignment has not changed since the last heartbeat.
+	Assignment *ConsumerGroupHeartbeatResponseAssignment
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+func (*ConsumerGroupHeartbeatResponse) Key() int16                 { return 68 }
+func (*ConsumerGroupHeartbeatResponse) MaxVersion() int16          { return 0 }
+func (v *ConsumerGroupHeartbeatResponse) SetVersion(version int16) { v.Version = version }
+func (v *ConsumerGroupHeartbeatResponse) GetVersion() int16        { return v.Version }
+func (v *ConsumerGroupHeartbeatResponse) IsFlexible() bool         { return v.Version >= 0 }
+func (v *ConsumerGroupHeartbeatResponse) Throttle() (int32, bool) {
+	return v.ThrottleMillis, v.Version >= 0
+}
+
+func (v *ConsumerGroupHeartbeatResponse) RequestKind() Request {
+	return &ConsumerGroupHeartbeatRequest{Version: v.Version}
+}
+
+func (v *ConsumerGroupHeartbeatResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	if v.ErrorCode != 0 {
+		errs = append(errs, ResponseError{Code: v.ErrorCode, Message: v.ErrorMessage, Field: "ErrorCode", Partition: -1})
+	}
+	return errs
+}
+
+func (v *ConsumerGroupHeartbeatResponse) AppendTo(dst []byte) []byte {
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	{
+		v := v.ThrottleMillis
+		dst = kbin.AppendInt32(dst, v)
+	}
+	{
+		v := v.ErrorCode
+		dst = kbin.AppendInt16(dst, v)
+	}
+	{
+		v := v.ErrorMessage
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
+	}
+	{
+		v := v.MemberID
+		if isFlexible {
+			dst = kbin.AppendCompactNullableString(dst, v)
+		} else {
+			dst = kbin.AppendNullableString(dst, v)
+		}
+	}
+	{
+		v := v.MemberEpoch
+		dst = kbin.AppendInt32(dst, v)
+	}
+	{
+		v := v.HeartbeatIntervalMillis
+		dst = kbin.AppendInt32(dst, v)
+	}
+	{
+		v := v.Assignment
+		if v == nil {
+			dst = append(dst, 255)
+		} else {
+			dst = append(dst, 1)
+			{
+				v := v.Topics
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+				for i := range v {
+					v := &v[i]
+					{
+						v := v.TopicID
+						dst = kbin.AppendUuid(dst, v)
+					}
+					{
+						v := v.Partitions
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+						for i := range v {
+							v := v[i]
+							dst = kbin.AppendInt32(dst, v)
+						}
+					}
+					if isFlexible {
+						dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+						dst = v.UnknownTags.AppendEach(dst)
+					}
+				}
+			}
+			if isFlexible {
+				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+				dst = v.UnknownTags.AppendEach(dst)
+			}
+		}
+	}
+	if isFlexible {
+		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+		dst = v.UnknownTags.AppendEach(dst)
+	}
+	return dst
+}
+
+func (v *ConsumerGroupHeartbeatResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ConsumerGroupHeartbeatResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ConsumerGroupHeartbeatResponse) readFrom(src []byte, rest *[]byte) error {
+	v.Default()
+	b := kbin.Reader{Src: src}
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	s := v
+	{
+		v := b.Int32()
+		s.ThrottleMillis = v
+	}
+	{
+		v := b.Int16()
+		s.ErrorCode = v
+	}
+	{
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
+		s.ErrorMessage = v
+	}
+	{
+		var v *string
+		if isFlexible {
+			v = b.CompactNullableString()
+		} else {
+			v = b.NullableString()
+		}
+		s.MemberID = v
+	}
+	{
+		v := b.Int32()
+		s.MemberEpoch = v
+	}
+	{
+		v := b.Int32()
+		s.HeartbeatIntervalMillis = v
+	}
+	{
+		var v *ConsumerGroupHeartbeatResponseAssignment
+		if present := b.Int8(); present != -1 && b.Ok() {
+			v = new(ConsumerGroupHeartbeatResponseAssignment)
+			v.Default()
+			{
+				s := v
+				{
+					v := s.Topics
+					a := v
+					var l int32
+					l = b.FlexibleArrayLen(isFlexible)
+					if !b.Ok() {
+						return b.Complete()
+					}
+					if l > 0 {
+						a = make([]ConsumerGroupHeartbeatResponseAssignmentTopic, l)
+					}
+					for i := int32(0); i < l; i++ {
+						v := &a[i]
+						v.Default()
+						s := v
+						{
+							v := b.Uuid()
+							s.TopicID = v
+						}
+						{
+							v := s.Partitions
+							a := v
+							var l int32
+							l = b.FlexibleArrayLen(isFlexible)
+							if !b.Ok() {
+								return b.Complete()
+							}
+							if l > 0 {
+								a = make([]int32, l)
+							}
+							for i := int32(0); i < l; i++ {
+								v := b.Int32()
+								a[i] = v
+							}
+							v = a
+							s.Partitions = v
+						}
+						if isFlexible {
+							s.UnknownTags = internalReadTags(&b)
+						}
+					}
+					v = a
+					s.Topics = v
+				}
+				if isFlexible {
+					s.UnknownTags = internalReadTags(&b)
+				}
+			}
+		}
+		s.Assignment = v
+	}
+	if isFlexible {
+		s.UnknownTags = internalReadTags(&b)
+	}
+	if rest != nil {
+		*rest = b.Src
+	}
+	return b.Complete()
+}
+
+// NewPtrConsumerGroupHeartbeatResponse returns a pointer to a default ConsumerGroupHeartbeatResponse
+// This is a shortcut for creating a new(struct) and calling Default yourself.
+func NewPtrConsumerGroupHeartbeatResponse() *ConsumerGroupHeartbeatResponse {
+	var v ConsumerGroupHeartbeatResponse
+	v.Default()
+	return &v
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupHeartbeatResponse.
+func (v *ConsumerGroupHeartbeatResponse) Default() {
+}
+
+// NewConsumerGroupHeartbeatResponse returns a default ConsumerGroupHeartbeatResponse
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupHeartbeatResponse() ConsumerGroupHeartbeatResponse {
+	var v ConsumerGroupHeartbeatResponse
+	v.Default()
+	return v
+}
+
+type ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition struct {
+	// TopicID is the ID of the topic.
+	TopicID [16]byte
+
+	// Topic is the name of the topic.
+	Topic string
+
+	// Partitions are the assigned partitions.
+	Partitions []int32
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition.
+func (v *ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition) Default() {
+}
+
+// NewConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition returns a default ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition() ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition {
+	var v ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition
+	v.Default()
+	return v
+}
+
+// Assignment contains the topic partitions assigned to a member, used in
+// ConsumerGroupDescribeResponse.
+type ConsumerGroupDescribeResponseGroupMemberAssignment struct {
+	// TopicPartitions contains the assigned topic partitions.
+	TopicPartitions []ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupDescribeResponseGroupMemberAssignment.
+func (v *ConsumerGroupDescribeResponseGroupMemberAssignment) Default() {
+}
+
+// NewConsumerGroupDescribeResponseGroupMemberAssignment returns a default ConsumerGroupDescribeResponseGroupMemberAssignment
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupDescribeResponseGroupMemberAssignment() ConsumerGroupDescribeResponseGroupMemberAssignment {
+	var v ConsumerGroupDescribeResponseGroupMemberAssignment
+	v.Default()
+	return v
+}
+
+// ConsumerGroupDescribeRequest, introduced for KIP-848, describes groups that
+// use the new consumer group protocol. Groups using the classic protocol
+// must be described with DescribeGroupsRequest; describing a classic group
+// with this request returns GROUP_ID_NOT_FOUND.
+type ConsumerGroupDescribeRequest struct {
+	// Version is the version of this message used with a Kafka broker.
+	Version int16
+
+	// Groups are the IDs of the groups to describe.
+	Groups []string
+
+	// IncludeAuthorizedOperations specifies whether to include a bitfield of
+	// AclOperations this client can perform on the groups. See KIP-430 for
+	// more details.
+	IncludeAuthorizedOperations bool
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+func (*ConsumerGroupDescribeRequest) Key() int16                   { return 69 }
+func (*ConsumerGroupDescribeRequest) MaxVersion() int16            { return 0 }
+func (v *ConsumerGroupDescribeRequest) SetVersion(version int16)   { v.Version = version }
+func (v *ConsumerGroupDescribeRequest) GetVersion() int16          { return v.Version }
+func (v *ConsumerGroupDescribeRequest) IsFlexible() bool           { return v.Version >= 0 }
+func (v *ConsumerGroupDescribeRequest) IsGroupCoordinatorRequest() {}
+func (v *ConsumerGroupDescribeRequest) ResponseKind() Response {
+	return &ConsumerGroupDescribeResponse{Version: v.Version}
+}
+
+// RequestWith is requests v on r and returns the response or an error.
+// For sharded requests, the response may be merged and still return an error.
+// It is better to rely on client.RequestSharded than to rely on proper merging behavior.
+func (v *ConsumerGroupDescribeRequest) RequestWith(ctx context.Context, r Requestor) (*ConsumerGroupDescribeResponse, error) {
+	kresp, err := r.Request(ctx, v)
+	resp, _ := kresp.(*ConsumerGroupDescribeResponse)
+	return resp, err
+}
+
+func (v *ConsumerGroupDescribeRequest) AppendTo(dst []byte) []byte {
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	{
+		v := v.Groups
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+		for i := range v {
+			v := v[i]
+			if isFlexible {
+				dst = kbin.AppendCompactString(dst, v)
+			} else {
+				dst = kbin.AppendString(dst, v)
+			}
+		}
+	}
+	{
+		v := v.IncludeAuthorizedOperations
+		dst = kbin.AppendBool(dst, v)
+	}
+	if isFlexible {
+		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+		dst = v.UnknownTags.AppendEach(dst)
+	}
+	return dst
+}
+
+func (v *ConsumerGroupDescribeRequest) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ConsumerGroupDescribeRequest) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ConsumerGroupDescribeRequest) readFrom(src []byte, rest *[]byte) error {
+	v.Default()
+	b := kbin.Reader{Src: src}
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	s := v
+	{
+		v := s.Groups
+		a := v
+		var l int32
+		l = b.FlexibleArrayLen(isFlexible)
+		if !b.Ok() {
+			return b.Complete()
+		}
+		if l > 0 {
+			a = make([]string, l)
+		}
+		for i := int32(0); i < l; i++ {
+			var v string
+			if isFlexible {
+				v = b.CompactString()
+			} else {
+				v = b.String()
+			}
+			a[i] = v
+		}
+		v = a
+		s.Groups = v
+	}
+	{
+		v := b.Bool()
+		s.IncludeAuthorizedOperations = v
+	}
+	if isFlexible {
+		s.UnknownTags = internalReadTags(&b)
+	}
+	if rest != nil {
+		*rest = b.Src
+	}
+	return b.Complete()
+}
+
+// NewPtrConsumerGroupDescribeRequest returns a pointer to a default ConsumerGroupDescribeRequest
+// This is a shortcut for creating a new(struct) and calling Default yourself.
+func NewPtrConsumerGroupDescribeRequest() *ConsumerGroupDescribeRequest {
+	var v ConsumerGroupDescribeRequest
+	v.Default()
+	return &v
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupDescribeRequest.
+func (v *ConsumerGroupDescribeRequest) Default() {
+}
+
+// NewConsumerGroupDescribeRequest returns a default ConsumerGroupDescribeRequest
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupDescribeRequest() ConsumerGroupDescribeRequest {
+	var v ConsumerGroupDescribeRequest
+	v.Default()
+	return v
+}
+
+type ConsumerGroupDescribeResponseGroupMember struct {
+	// MemberID is the ID of the member.
+	MemberID string
+
+	// InstanceID is the instance ID of the member, if any (KIP-345).
+	InstanceID *string
+
+	// RackID is the rack of the member, if any.
+	RackID *string
+
+	// MemberEpoch is the current epoch of the member.
+	MemberEpoch int32
+
+	// ClientID is the client ID used by the member.
+	ClientID string
+
+	// ClientHost is the host the member is running on.
+	ClientHost string
+
+	// SubscribedTopicNames are the topic names the member is subscribed
+	// to.
+	SubscribedTopicNames []string
+
+	// SubscribedTopicRegex is the regular expression the member uses to
+	// subscribe, if any.
+	SubscribedTopicRegex *string
+
+	// Assignment is the member's current assignment.
+	Assignment ConsumerGroupDescribeResponseGroupMemberAssignment
+
+	// TargetAssignment is the assignment the member is converging to.
+	TargetAssignment ConsumerGroupDescribeResponseGroupMemberAssignment
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupDescribeResponseGroupMember.
+func (v *ConsumerGroupDescribeResponseGroupMember) Default() {
+	{
+		v := &v.Assignment
+		_ = v
+	}
+	{
+		v := &v.TargetAssignment
+		_ = v
+	}
+}
+
+// NewConsumerGroupDescribeResponseGroupMember returns a default ConsumerGroupDescribeResponseGroupMember
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupDescribeResponseGroupMember() ConsumerGroupDescribeResponseGroupMember {
+	var v ConsumerGroupDescribeResponseGroupMember
+	v.Default()
+	return v
+}
+
+type ConsumerGroupDescribeResponseGroup struct {
+	// ErrorCode is the error code for this group, or 0 if there was no
+	// error.
+	//
+	// GROUP_AUTHORIZATION_FAILED is returned if the client is not authorized
+	// to describe the group.
+	//
+	// INVALID_GROUP_ID is returned if the group ID is invalid.
+	//
+	// GROUP_ID_NOT_FOUND is returned if the group does not exist or is not
+	// a group using the new consumer group protocol.
+	//
+	// COORDINATOR_NOT_AVAILABLE, COORDINATOR_LOAD_IN_PROGRESS, and
+	// NOT_COORDINATOR are returned for the standard coordinator reasons.
+	ErrorCode int16
+
+	// ErrorMessage is an informative message if the describe failed.
+	ErrorMessage *string
+
+	// Group is the ID of the group.
+	Group string
+
+	// State is the group state, e.g. Empty, Assigning, Reconciling, Stable,
+	// or Dead.
+	State string
+
+	// Epoch is the group epoch.
+	Epoch int32
+
+	// AssignmentEpoch is the epoch of the target assignment.
+	AssignmentEpoch int32
+
+	// AssignorName is the name of the server side assignor used by the
+	// group.
+	AssignorName string
+
+	// Members contains the members of the group.
+	Members []ConsumerGroupDescribeResponseGroupMember
+
+	// AuthorizedOperations is a bitfield containing which operations the
+	// client is allowed to perform on this group. This is only returned if
+	// requested.
+	//
+	// This field has a default of -2147483648.
+	AuthorizedOperations int32
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupDescribeResponseGroup.
+func (v *ConsumerGroupDescribeResponseGroup) Default() {
+	v.AuthorizedOperations = -2147483648
+}
+
+// NewConsumerGroupDescribeResponseGroup returns a default ConsumerGroupDescribeResponseGroup
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupDescribeResponseGroup() ConsumerGroupDescribeResponseGroup {
+	var v ConsumerGroupDescribeResponseGroup
+	v.Default()
+	return v
+}
+
+// ConsumerGroupDescribeResponse is returned from a ConsumerGroupDescribeRequest.
+type ConsumerGroupDescribeResponse struct {
+	// Version is the version of this message used with a Kafka broker.
+	Version int16
+
+	// ThrottleMillis is how long of a throttle Kafka will apply to the client
+	// after responding to this request.
+	ThrottleMillis int32
+
+	// Groups contains each described group.
+	Groups []ConsumerGroupDescribeResponseGroup
+
+	// UnknownTags are tags Kafka sent that we do not know the purpose of.
+	UnknownTags Tags
+}
+
+func (*ConsumerGroupDescribeResponse) Key() int16                 { return 69 }
+func (*ConsumerGroupDescribeResponse) MaxVersion() int16          { return 0 }
+func (v *ConsumerGroupDescribeResponse) SetVersion(version int16) { v.Version = version }
+func (v *ConsumerGroupDescribeResponse) GetVersion() int16        { return v.Version }
+func (v *ConsumerGroupDescribeResponse) IsFlexible() bool         { return v.Version >= 0 }
+func (v *ConsumerGroupDescribeResponse) Throttle() (int32, bool) {
+	return v.ThrottleMillis, v.Version >= 0
+}
+
+func (v *ConsumerGroupDescribeResponse) RequestKind() Request {
+	return &ConsumerGroupDescribeRequest{Version: v.Version}
+}
+
+func (v *ConsumerGroupDescribeResponse) Errors() []ResponseError {
+	var errs []ResponseError
+	for i := range v.Groups {
+		v1 := &v.Groups[i]
+		if v1.ErrorCode != 0 {
+			errs = append(errs, ResponseError{Code: v1.ErrorCode, Message: v1.ErrorMessage, Field: "Groups.ErrorCode", Partition: -1})
+		}
+	}
+	return errs
+}
+
+func (v *ConsumerGroupDescribeResponse) AppendTo(dst []byte) []byte {
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	{
+		v := v.ThrottleMillis
+		dst = kbin.AppendInt32(dst, v)
+	}
+	{
+		v := v.Groups
+		dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+		for i := range v {
+			v := &v[i]
+			{
+				v := v.ErrorCode
+				dst = kbin.AppendInt16(dst, v)
+			}
+			{
+				v := v.ErrorMessage
+				if isFlexible {
+					dst = kbin.AppendCompactNullableString(dst, v)
+				} else {
+					dst = kbin.AppendNullableString(dst, v)
+				}
+			}
+			{
+				v := v.Group
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
+			}
+			{
+				v := v.State
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
+			}
+			{
+				v := v.Epoch
+				dst = kbin.AppendInt32(dst, v)
+			}
+			{
+				v := v.AssignmentEpoch
+				dst = kbin.AppendInt32(dst, v)
+			}
+			{
+				v := v.AssignorName
+				if isFlexible {
+					dst = kbin.AppendCompactString(dst, v)
+				} else {
+					dst = kbin.AppendString(dst, v)
+				}
+			}
+			{
+				v := v.Members
+				dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+				for i := range v {
+					v := &v[i]
+					{
+						v := v.MemberID
+						if isFlexible {
+							dst = kbin.AppendCompactString(dst, v)
+						} else {
+							dst = kbin.AppendString(dst, v)
+						}
+					}
+					{
+						v := v.InstanceID
+						if isFlexible {
+							dst = kbin.AppendCompactNullableString(dst, v)
+						} else {
+							dst = kbin.AppendNullableString(dst, v)
+						}
+					}
+					{
+						v := v.RackID
+						if isFlexible {
+							dst = kbin.AppendCompactNullableString(dst, v)
+						} else {
+							dst = kbin.AppendNullableString(dst, v)
+						}
+					}
+					{
+						v := v.MemberEpoch
+						dst = kbin.AppendInt32(dst, v)
+					}
+					{
+						v := v.ClientID
+						if isFlexible {
+							dst = kbin.AppendCompactString(dst, v)
+						} else {
+							dst = kbin.AppendString(dst, v)
+						}
+					}
+					{
+						v := v.ClientHost
+						if isFlexible {
+							dst = kbin.AppendCompactString(dst, v)
+						} else {
+							dst = kbin.AppendString(dst, v)
+						}
+					}
+					{
+						v := v.SubscribedTopicNames
+						dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+						for i := range v {
+							v := v[i]
+							if isFlexible {
+								dst = kbin.AppendCompactString(dst, v)
+							} else {
+								dst = kbin.AppendString(dst, v)
+							}
+						}
+					}
+					{
+						v := v.SubscribedTopicRegex
+						if isFlexible {
+							dst = kbin.AppendCompactNullableString(dst, v)
+						} else {
+							dst = kbin.AppendNullableString(dst, v)
+						}
+					}
+					{
+						v := &v.Assignment
+						{
+							v := v.TopicPartitions
+							dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+							for i := range v {
+								v := &v[i]
+								{
+									v := v.TopicID
+									dst = kbin.AppendUuid(dst, v)
+								}
+								{
+									v := v.Topic
+									if isFlexible {
+										dst = kbin.AppendCompactString(dst, v)
+									} else {
+										dst = kbin.AppendString(dst, v)
+									}
+								}
+								{
+									v := v.Partitions
+									dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+									for i := range v {
+										v := v[i]
+										dst = kbin.AppendInt32(dst, v)
+									}
+								}
+								if isFlexible {
+									dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+									dst = v.UnknownTags.AppendEach(dst)
+								}
+							}
+						}
+						if isFlexible {
+							dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+							dst = v.UnknownTags.AppendEach(dst)
+						}
+					}
+					{
+						v := &v.TargetAssignment
+						{
+							v := v.TopicPartitions
+							dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+							for i := range v {
+								v := &v[i]
+								{
+									v := v.TopicID
+									dst = kbin.AppendUuid(dst, v)
+								}
+								{
+									v := v.Topic
+									if isFlexible {
+										dst = kbin.AppendCompactString(dst, v)
+									} else {
+										dst = kbin.AppendString(dst, v)
+									}
+								}
+								{
+									v := v.Partitions
+									dst = kbin.AppendFlexibleArrayLen(dst, len(v), isFlexible)
+									for i := range v {
+										v := v[i]
+										dst = kbin.AppendInt32(dst, v)
+									}
+								}
+								if isFlexible {
+									dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+									dst = v.UnknownTags.AppendEach(dst)
+								}
+							}
+						}
+						if isFlexible {
+							dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+							dst = v.UnknownTags.AppendEach(dst)
+						}
+					}
+					if isFlexible {
+						dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+						dst = v.UnknownTags.AppendEach(dst)
+					}
+				}
+			}
+			{
+				v := v.AuthorizedOperations
+				dst = kbin.AppendInt32(dst, v)
+			}
+			if isFlexible {
+				dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+				dst = v.UnknownTags.AppendEach(dst)
+			}
+		}
+	}
+	if isFlexible {
+		dst = kbin.AppendUvarint(dst, 0+uint32(v.UnknownTags.Len()))
+		dst = v.UnknownTags.AppendEach(dst)
+	}
+	return dst
+}
+
+func (v *ConsumerGroupDescribeResponse) ReadFrom(src []byte) error {
+	return v.readFrom(src, nil)
+}
+
+// ReadFromLenient parses src into v and returns the number of bytes
+// consumed. As with ReadFrom, trailing bytes after the known fields are
+// not an error; unlike ReadFrom, the caller can compare the returned count
+// against len(src) to detect them. Too little data is an error, in which
+// case this returns 0.
+func (v *ConsumerGroupDescribeResponse) ReadFromLenient(src []byte) (int, error) {
+	var rest []byte
+	if err := v.readFrom(src, &rest); err != nil {
+		return 0, err
+	}
+	return len(src) - len(rest), nil
+}
+
+func (v *ConsumerGroupDescribeResponse) readFrom(src []byte, rest *[]byte) error {
+	v.Default()
+	b := kbin.Reader{Src: src}
+	version := v.Version
+	_ = version
+	isFlexible := version >= 0
+	_ = isFlexible
+	s := v
+	{
+		v := b.Int32()
+		s.ThrottleMillis = v
+	}
+	{
+		v := s.Groups
+		a := v
+		var l int32
+		l = b.FlexibleArrayLen(isFlexible)
+		if !b.Ok() {
+			return b.Complete()
+		}
+		if l > 0 {
+			a = make([]ConsumerGroupDescribeResponseGroup, l)
+		}
+		for i := int32(0); i < l; i++ {
+			v := &a[i]
+			v.Default()
+			s := v
+			{
+				v := b.Int16()
+				s.ErrorCode = v
+			}
+			{
+				var v *string
+				if isFlexible {
+					v = b.CompactNullableString()
+				} else {
+					v = b.NullableString()
+				}
+				s.ErrorMessage = v
+			}
+			{
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
+				s.Group = v
+			}
+			{
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
+				s.State = v
+			}
+			{
+				v := b.Int32()
+				s.Epoch = v
+			}
+			{
+				v := b.Int32()
+				s.AssignmentEpoch = v
+			}
+			{
+				var v string
+				if isFlexible {
+					v = b.CompactString()
+				} else {
+					v = b.String()
+				}
+				s.AssignorName = v
+			}
+			{
+				v := s.Members
+				a := v
+				var l int32
+				l = b.FlexibleArrayLen(isFlexible)
+				if !b.Ok() {
+					return b.Complete()
+				}
+				if l > 0 {
+					a = make([]ConsumerGroupDescribeResponseGroupMember, l)
+				}
+				for i := int32(0); i < l; i++ {
+					v := &a[i]
+					v.Default()
+					s := v
+					{
+						var v string
+						if isFlexible {
+							v = b.CompactString()
+						} else {
+							v = b.String()
+						}
+						s.MemberID = v
+					}
+					{
+						var v *string
+						if isFlexible {
+							v = b.CompactNullableString()
+						} else {
+							v = b.NullableString()
+						}
+						s.InstanceID = v
+					}
+					{
+						var v *string
+						if isFlexible {
+							v = b.CompactNullableString()
+						} else {
+							v = b.NullableString()
+						}
+						s.RackID = v
+					}
+					{
+						v := b.Int32()
+						s.MemberEpoch = v
+					}
+					{
+						var v string
+						if isFlexible {
+							v = b.CompactString()
+						} else {
+							v = b.String()
+						}
+						s.ClientID = v
+					}
+					{
+						var v string
+						if isFlexible {
+							v = b.CompactString()
+						} else {
+							v = b.String()
+						}
+						s.ClientHost = v
+					}
+					{
+						v := s.SubscribedTopicNames
+						a := v
+						var l int32
+						l = b.FlexibleArrayLen(isFlexible)
+						if !b.Ok() {
+							return b.Complete()
+						}
+						if l > 0 {
+							a = make([]string, l)
+						}
+						for i := int32(0); i < l; i++ {
+							var v string
+							if isFlexible {
+								v = b.CompactString()
+							} else {
+								v = b.String()
+							}
+							a[i] = v
+						}
+						v = a
+						s.SubscribedTopicNames = v
+					}
+					{
+						var v *string
+						if isFlexible {
+							v = b.CompactNullableString()
+						} else {
+							v = b.NullableString()
+						}
+						s.SubscribedTopicRegex = v
+					}
+					{
+						v := &s.Assignment
+						v.Default()
+						s := v
+						{
+							v := s.TopicPartitions
+							a := v
+							var l int32
+							l = b.FlexibleArrayLen(isFlexible)
+							if !b.Ok() {
+								return b.Complete()
+							}
+							if l > 0 {
+								a = make([]ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition, l)
+							}
+							for i := int32(0); i < l; i++ {
+								v := &a[i]
+								v.Default()
+								s := v
+								{
+									v := b.Uuid()
+									s.TopicID = v
+								}
+								{
+									var v string
+									if isFlexible {
+										v = b.CompactString()
+									} else {
+										v = b.String()
+									}
+									s.Topic = v
+								}
+								{
+									v := s.Partitions
+									a := v
+									var l int32
+									l = b.FlexibleArrayLen(isFlexible)
+									if !b.Ok() {
+										return b.Complete()
+									}
+									if l > 0 {
+										a = make([]int32, l)
+									}
+									for i := int32(0); i < l; i++ {
+										v := b.Int32()
+										a[i] = v
+									}
+									v = a
+									s.Partitions = v
+								}
+								if isFlexible {
+									s.UnknownTags = internalReadTags(&b)
+								}
+							}
+							v = a
+							s.TopicPartitions = v
+						}
+						if isFlexible {
+							s.UnknownTags = internalReadTags(&b)
+						}
+					}
+					{
+						v := &s.TargetAssignment
+						v.Default()
+						s := v
+						{
+							v := s.TopicPartitions
+							a := v
+							var l int32
+							l = b.FlexibleArrayLen(isFlexible)
+							if !b.Ok() {
+								return b.Complete()
+							}
+							if l > 0 {
+								a = make([]ConsumerGroupDescribeResponseGroupMemberAssignmentTopicPartition, l)
+							}
+							for i := int32(0); i < l; i++ {
+								v := &a[i]
+								v.Default()
+								s := v
+								{
+									v := b.Uuid()
+									s.TopicID = v
+								}
+								{
+									var v string
+									if isFlexible {
+										v = b.CompactString()
+									} else {
+										v = b.String()
+									}
+									s.Topic = v
+								}
+								{
+									v := s.Partitions
+									a := v
+									var l int32
+									l = b.FlexibleArrayLen(isFlexible)
+									if !b.Ok() {
+										return b.Complete()
+									}
+									if l > 0 {
+										a = make([]int32, l)
+									}
+									for i := int32(0); i < l; i++ {
+										v := b.Int32()
+										a[i] = v
+									}
+									v = a
+									s.Partitions = v
+								}
+								if isFlexible {
+									s.UnknownTags = internalReadTags(&b)
+								}
+							}
+							v = a
+							s.TopicPartitions = v
+						}
+						if isFlexible {
+							s.UnknownTags = internalReadTags(&b)
+						}
+					}
+					if isFlexible {
+						s.UnknownTags = internalReadTags(&b)
+					}
+				}
+				v = a
+				s.Members = v
+			}
+			{
+				v := b.Int32()
+				s.AuthorizedOperations = v
+			}
+			if isFlexible {
+				s.UnknownTags = internalReadTags(&b)
+			}
+		}
+		v = a
+		s.Groups = v
+	}
+	if isFlexible {
+		s.UnknownTags = internalReadTags(&b)
+	}
+	if rest != nil {
+		*rest = b.Src
+	}
+	return b.Complete()
+}
+
+// NewPtrConsumerGroupDescribeResponse returns a pointer to a default ConsumerGroupDescribeResponse
+// This is a shortcut for creating a new(struct) and calling Default yourself.
+func NewPtrConsumerGroupDescribeResponse() *ConsumerGroupDescribeResponse {
+	var v ConsumerGroupDescribeResponse
+	v.Default()
+	return &v
+}
+
+// Default sets any default fields. Calling this allows for future compatibility
+// if new fields are added to ConsumerGroupDescribeResponse.
+func (v *ConsumerGroupDescribeResponse) Default() {
+}
+
+// NewConsumerGroupDescribeResponse returns a default ConsumerGroupDescribeResponse
+// This is a shortcut for creating a struct and calling Default yourself.
+func NewConsumerGroupDescribeResponse() ConsumerGroupDescribeResponse {
+	var v ConsumerGroupDescribeResponse
+	v.Default()
+	return v
+}
+
+// RequestForKey returns the request corresponding to the given request key
+// or nil if the key is unknown.
+func RequestForKey(key int16) Request {
+	switch key {
+	default:
+		return nil
+	case 0:
+		return NewPtrProduceRequest()
+	case 1:
+		return NewPtrFetchRequest()
+	case 2:
+		return NewPtrListOffsetsRequest()
+	case 3:
+		return NewPtrMetadataRequest()
+	case 4:
+		return NewPtrLeaderAndISRRequest()
+	case 5:
+		return NewPtrStopReplicaRequest()
+	case 6:
+		return NewPtrUpdateMetadataRequest()
+	case 7:
+		return NewPtrControlledShutdownRequest()
+	case 8:
+		return NewPtrOffsetCommitRequest()
+	case 9:
+		return NewPtrOffsetFetchRequest()
+	case 10:
+		return NewPtrFindCoordinatorRequest()
+	case 11:
+		return NewPtrJoinGroupRequest()
+	case 12:
+		return NewPtrHeartbeatRequest()
+	case 13:
+		return NewPtrLeaveGroupRequest()
+	case 14:
+		return NewPtrSyncGroupRequest()
+	case 15:
+		return NewPtrDescribeGroupsRequest()
+	case 16:
+		return NewPtrListGroupsRequest()
+	case 17:
+		return NewPtrSASLHandshakeRequest()
+	case 18:
+		return NewPtrApiVersionsRequest()
+	case 19:
+		return NewPtrCreateTopicsRequest()
+	case 20:
+		return NewPtrDeleteTopicsRequest()
+	case 21:
+		return NewPtrDeleteRecordsRequest()
+	case 22:
+		return NewPtrInitProducerIDRequest()
+	case 23:
+		return NewPtrOffsetForLeaderEpochRequest()
+	case 24:
+		return NewPtrAddPartitionsToTxnRequest()
+	case 25:
+		return NewPtrAddOffsetsToTxnRequest()
+	case 26:
+		return NewPtrEndTxnRequest()
+	case 27:
+		return NewPtrWriteTxnMarkersRequest()
+	case 28:
+		return NewPtrTxnOffsetCommitRequest()
+	case 29:
+		return NewPtrDescribeACLsRequest()
+	case 30:
+		return NewPtrCreateACLsRequest()
+	case 31:
+		return NewPtrDeleteACLsRequest()
+	case 32:
+		return NewPtrDescribeConfigsRequest()
+	case 33:
+		return NewPtrAlterConfigsRequest()
+	case 34:
+		return NewPtrAlterReplicaLogDirsRequest()
+	case 35:
+		return NewPtrDescribeLogDirsRequest()
+	case 36:
+		return NewPtrSASLAuthenticateRequest()
+	case 37:
+		return NewPtrCreatePartitionsRequest()
+	case 38:
+		return NewPtrCreateDelegationTokenRequest()
+	case 39:
+		return NewPtrRenewDelegationTokenRequest()
+	case 40:
+		return NewPtrExpireDelegationTokenRequest()
+	case 41:
+		return NewPtrDescribeDelegationTokenRequest()
+	case 42:
+		return NewPtrDeleteGroupsRequest()
+	case 43:
+		return NewPtrElectLeadersRequest()
+	case 44:
+		return NewPtrIncrementalAlterConfigsRequest()
+	case 45:
+		return NewPtrAlterPartitionAssignmentsRequest()
+	case 46:
+		return NewPtrListPartitionReassignmentsRequest()
+	case 47:
+		return NewPtrOffsetDeleteRequest()
+	case 48:
+		return NewPtrDescribeClientQuotasRequest()
+	case 49:
+		return NewPtrAlterClientQuotasRequest()
+	case 50:
+		return NewPtrDescribeUserSCRAMCredentialsRequest()
+	case 51:
+		return NewPtrAlterUserSCRAMCredentialsRequest()
+	case 52:
+		return NewPtrVoteRequest()
+	case 53:
+		return NewPtrBeginQuorumEpochRequest()
+	case 54:
+		return NewPtrEndQuorumEpochRequest()
+	case 55:
+		return NewPtrDescribeQuorumRequest()
+	case 56:
+		return NewPtrAlterISRRequest()
+	case 57:
+		return NewPtrUpdateFeaturesRequest()
+	case 58:
+		return NewPtrEnvelopeRequest()
+	case 59:
+		return NewPtrFetchSnapshotRequest()
+	case 60:
+		return NewPtrDescribeClusterRequest()
+	case 61:
+		return NewPtrDescribeProducersRequest()
+	case 62:
+		return NewPtrBrokerRegistrationRequest()
+	case 63:
+		return NewPtrBrokerHeartbeatRequest()
+	case 64:
+		return NewPtrUnregisterBrokerRequest()
+	case 65:
+		return NewPtrDescribeTransactionsRequest()
+	case 66:
+		return NewPtrListTransactionsRequest()
+	case 67:
+		return NewPtrAllocateProducerIDsRequest()
+	case 68:
+		return NewPtrConsumerGroupHeartbeatRequest()
+	case 69:
+		return NewPtrConsumerGroupDescribeRequest()
+	}
+}
+
+// ResponseForKey returns the response corresponding to the given request key
+// or nil if the key is unknown.
+func ResponseForKey(key int16) Response {
+	switch key {
+	default:
+		return nil
+	case 0:
+		return NewPtrProduceResponse()
+	case 1:
+		return NewPtrFetchResponse()
+	case 2:
+		return NewPtrListOffsetsResponse()
+	case 3:
+		return NewPtrMetadataResponse()
+	case 4:
+		return NewPtrLeaderAndISRResponse()
+	case 5:
+		return NewPtrStopReplicaResponse()
+	case 6:
+		return NewPtrUpdateMetadataResponse()
+	case 7:
+		return NewPtrControlledShutdownResponse()
+	case 8:
+		return NewPtrOffsetCommitResponse()
+	case 9:
+		return NewPtrOffsetFetchResponse()
+	case 10:
+		return NewPtrFindCoordinatorResponse()
+	case 11:
+		return NewPtrJoinGroupResponse()
+	case 12:
+		return NewPtrHeartbeatResponse()
+	case 13:
+		return NewPtrLeaveGroupResponse()
+	case 14:
+		return NewPtrSyncGroupResponse()
+	case 15:
+		return NewPtrDescribeGroupsResponse()
+	case 16:
+		return NewPtrListGroupsResponse()
+	case 17:
+		return NewPtrSASLHandshakeResponse()
+	case 18:
+		return NewPtrApiVersionsResponse()
+	case 19:
+		return NewPtrCreateTopicsResponse()
+	case 20:
+		return NewPtrDeleteTopicsResponse()
+	case 21:
+		return NewPtrDeleteRecordsResponse()
+	case 22:
+		return NewPtrInitProducerIDResponse()
+	case 23:
+		return NewPtrOffsetForLeaderEpochResponse()
+	case 24:
+		return NewPtrAddPartitionsToTxnResponse()
+	case 25:
+		return NewPtrAddOffsetsToTxnResponse()
+	case 26:
+		return NewPtrEndTxnResponse()
+	case 27:
+		return NewPtrWriteTxnMarkersResponse()
+	case 28:
+		return NewPtrTxnOffsetCommitResponse()
+	case 29:
+		return NewPtrDescribeACLsResponse()
+	case 30:
+		return NewPtrCreateACLsResponse()
+	case 31:
+		return NewPtrDeleteACLsResponse()
+	case 32:
+		return NewPtrDescribeConfigsResponse()
+	case 33:
+		return NewPtrAlterConfigsResponse()
+	case 34:
+		return NewPtrAlterReplicaLogDirsResponse()
+	case 35:
+		return NewPtrDescribeLogDirsResponse()
+	case 36:
+		return NewPtrSASLAuthenticateResponse()
+	case 37:
+		return NewPtrCreatePartitionsResponse()
+	case 38:
+		return NewPtrCreateDelegationTokenResponse()
+	case 39:
+		return NewPtrRenewDelegationTokenResponse()
+	case 40:
+		return NewPtrExpireDelegationTokenResponse()
+	case 41:
+		return NewPtrDescribeDelegationTokenResponse()
+	case 42:
+		return NewPtrDeleteGroupsResponse()
+	case 43:
+		return NewPtrElectLeadersResponse()
+	case 44:
+		return NewPtrIncrementalAlterConfigsResponse()
+	case 45:
+		return NewPtrAlterPartitionAssignmentsResponse()
+	case 46:
+		return NewPtrListPartitionReassignmentsResponse()
+	case 47:
+		return NewPtrOffsetDeleteResponse()
+	case 48:
+		return NewPtrDescribeClientQuotasResponse()
+	case 49:
+		return NewPtrAlterClientQuotasResponse()
+	case 50:
+		return NewPtrDescribeUserSCRAMCredentialsResponse()
+	case 51:
+		return NewPtrAlterUserSCRAMCredentialsResponse()
+	case 52:
+		return NewPtrVoteResponse()
+	case 53:
+		return NewPtrBeginQuorumEpochResponse()
+	case 54:
+		return NewPtrEndQuorumEpochResponse()
+	case 55:
+		return NewPtrDescribeQuorumResponse()
+	case 56:
+		return NewPtrAlterISRResponse()
+	case 57:
+		return NewPtrUpdateFeaturesResponse()
+	case 58:
+		return NewPtrEnvelopeResponse()
+	case 59:
+		return NewPtrFetchSnapshotResponse()
+	case 60:
+		return NewPtrDescribeClusterResponse()
+	case 61:
+		return NewPtrDescribeProducersResponse()
+	case 62:
+		return NewPtrBrokerRegistrationResponse()
+	case 63:
+		return NewPtrBrokerHeartbeatResponse()
+	case 64:
+		return NewPtrUnregisterBrokerResponse()
+	case 65:
+		return NewPtrDescribeTransactionsResponse()
+	case 66:
+		return NewPtrListTransactionsResponse()
+	case 67:
+		return NewPtrAllocateProducerIDsResponse()
+	case 68:
+		return NewPtrConsumerGroupHeartbeatResponse()
+	case 69:
+		return NewPtrConsumerGroupDescribeResponse()
+	}
+}
+
+// NameForKey returns the name (e.g., "Fetch") corresponding to a given request key
+// or "" if the key is unknown.
+func NameForKey(key int16) string {
+	switch key {
+	default:
+		return "Unknown"
+	case 0:
+		return "Produce"
+	case 1:
+		return "Fetch"
+	case 2:
+		return "ListOffsets"
+	case 3:
+		return "Metadata"
+	case 4:
+		return "LeaderAndISR"
+	case 5:
+		return "StopReplica"
+	case 6:
+		return "UpdateMetadata"
+	case 7:
+		return "ControlledShutdown"
+	case 8:
+		return "OffsetCommit"
+	case 9:
+		return "OffsetFetch"
+	case 10:
+		return "FindCoordinator"
+	case 11:
+		return "JoinGroup"
+	case 12:
+		return "Heartbeat"
+	case 13:
+		return "LeaveGroup"
+	case 14:
+		return "SyncGroup"
+	case 15:
+		return "DescribeGroups"
+	case 16:
+		return "ListGroups"
+	case 17:
+		return "SASLHandshake"
+	case 18:
+		return "ApiVersions"
+	case 19:
+		return "CreateTopics"
+	case 20:
+		return "DeleteTopics"
+	case 21:
+		return "DeleteRecords"
+	case 22:
+		return "InitProducerID"
+	case 23:
+		return "OffsetForLeaderEpoch"
+	case 24:
+		return "AddPartitionsToTxn"
+	case 25:
+		return "AddOffsetsToTxn"
+	case 26:
+		return "EndTxn"
+	case 27:
+		return "WriteTxnMarkers"
+	case 28:
+		return "TxnOffsetCommit"
+	case 29:
+		return "DescribeACLs"
+	case 30:
+		return "CreateACLs"
+	case 31:
+		return "DeleteACLs"
+	case 32:
+		return "DescribeConfigs"
+	case 33:
+		return "AlterConfigs"
+	case 34:
+		return "AlterReplicaLogDirs"
+	case 35:
+		return "DescribeLogDirs"
+	case 36:
+		return "SASLAuthenticate"
+	case 37:
+		return "CreatePartitions"
+	case 38:
+		return "CreateDelegationToken"
+	case 39:
+		return "RenewDelegationToken"
+	case 40:
+		return "ExpireDelegationToken"
+	case 41:
 		return "DescribeDelegationToken"
 	case 42:
 		return "DeleteGroups"
@@ -39451,6 +43191,10 @@ func NameForKey(key int16) string {
 		return "ListTransactions"
 	case 67:
 		return "AllocateProducerIDs"
+	case 68:
+		return "ConsumerGroupHeartbeat"
+	case 69:
+		return "ConsumerGroupDescribe"
 	}
 }
 
@@ -39526,6 +43270,8 @@ const (
 	DescribeTransactions         Key = 65
 	ListTransactions             Key = 66
 	AllocateProducerIDs          Key = 67
+	ConsumerGroupHeartbeat       Key = 68
+	ConsumerGroupDescribe        Key = 69
 )
 
 // Name returns the name for this key.
@@ -39540,6 +43286,91 @@ func (k Key) Response() Response { return ResponseForKey(int16(k)) }
 // Int16 is an alias for int16(k).
 func (k Key) Int16() int16 { return int16(k) }
 
+// APIKey describes a request key that this package supports.
+type APIKey struct {
+	Key        int16  // Key is the request key.
+	Name       string // Name is the name of the request, e.g. "Fetch".
+	MinVersion int16  // MinVersion is the minimum version this package supports, which is always 0.
+	MaxVersion int16  // MaxVersion is the maximum version this package supports.
+}
+
+// APIKeys returns every request key that this package supports, in key order.
+// The returned slice is new on every call and can be freely modified.
+func APIKeys() []APIKey {
+	return []APIKey{
+		{0, "Produce", 0, 9},
+		{1, "Fetch", 0, 13},
+		{2, "ListOffsets", 0, 7},
+		{3, "Metadata", 0, 12},
+		{4, "LeaderAndISR", 0, 6},
+		{5, "StopReplica", 0, 3},
+		{6, "UpdateMetadata", 0, 7},
+		{7, "ControlledShutdown", 0, 3},
+		{8, "OffsetCommit", 0, 9},
+		{9, "OffsetFetch", 0, 9},
+		{10, "FindCoordinator", 0, 4},
+		{11, "JoinGroup", 0, 7},
+		{12, "Heartbeat", 0, 4},
+		{13, "LeaveGroup", 0, 4},
+		{14, "SyncGroup", 0, 5},
+		{15, "DescribeGroups", 0, 5},
+		{16, "ListGroups", 0, 5},
+		{17, "SASLHandshake", 0, 1},
+		{18, "ApiVersions", 0, 3},
+		{19, "CreateTopics", 0, 7},
+		{20, "DeleteTopics", 0, 6},
+		{21, "DeleteRecords", 0, 2},
+		{22, "InitProducerID", 0, 4},
+		{23, "OffsetForLeaderEpoch", 0, 4},
+		{24, "AddPartitionsToTxn", 0, 3},
+		{25, "AddOffsetsToTxn", 0, 3},
+		{26, "EndTxn", 0, 3},
+		{27, "WriteTxnMarkers", 0, 1},
+		{28, "TxnOffsetCommit", 0, 3},
+		{29, "DescribeACLs", 0, 2},
+		{30, "CreateACLs", 0, 2},
+		{31, "DeleteACLs", 0, 2},
+		{32, "DescribeConfigs", 0, 4},
+		{33, "AlterConfigs", 0, 2},
+		{34, "AlterReplicaLogDirs", 0, 2},
+		{35, "DescribeLogDirs", 0, 2},
+		{36, "SASLAuthenticate", 0, 2},
+		{37, "CreatePartitions", 0, 3},
+		{38, "CreateDelegationToken", 0, 2},
+		{39, "RenewDelegationToken", 0, 2},
+		{40, "ExpireDelegationToken", 0, 2},
+		{41, "DescribeDelegationToken", 0, 2},
+		{42, "DeleteGroups", 0, 2},
+		{43, "ElectLeaders", 0, 2},
+		{44, "IncrementalAlterConfigs", 0, 1},
+		{45, "AlterPartitionAssignments", 0, 0},
+		{46, "ListPartitionReassignments", 0, 0},
+		{47, "OffsetDelete", 0, 0},
+		{48, "DescribeClientQuotas", 0, 1},
+		{49, "AlterClientQuotas", 0, 1},
+		{50, "DescribeUserSCRAMCredentials", 0, 0},
+		{51, "AlterUserSCRAMCredentials", 0, 0},
+		{52, "Vote", 0, 0},
+		{53, "BeginQuorumEpoch", 0, 0},
+		{54, "EndQuorumEpoch", 0, 0},
+		{55, "DescribeQuorum", 0, 0},
+		{56, "AlterISR", 0, 0},
+		{57, "UpdateFeatures", 0, 1},
+		{58, "Envelope", 0, 0},
+		{59, "FetchSnapshot", 0, 0},
+		{60, "DescribeCluster", 0, 0},
+		{61, "DescribeProducers", 0, 0},
+		{62, "BrokerRegistration", 0, 0},
+		{63, "BrokerHeartbeat", 0, 0},
+		{64, "UnregisterBroker", 0, 0},
+		{65, "DescribeTransactions", 0, 0},
+		{66, "ListTransactions", 0, 0},
+		{67, "AllocateProducerIDs", 0, 0},
+		{68, "ConsumerGroupHeartbeat", 0, 0},
+		{69, "ConsumerGroupDescribe", 0, 0},
+	}
+}
+
 // A type of config.
 //
 // Possible values and their meanings:
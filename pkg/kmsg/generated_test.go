@@ -0,0 +1,342 @@
+package kmsg
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// The nested partition states of LeaderAndISR, UpdateMetadata, and
+// StopReplica have fields that only exist in some versions; every version must
+// round trip exactly what it encodes, and must not encode fields from other
+// versions. WriteTxnMarkers nests topics within markers and must round trip
+// every level in both the non-flexible and flexible encodings.
+
+func TestLeaderAndISRRequestRoundTrip(t *testing.T) {
+	for version := int16(0); version <= (*LeaderAndISRRequest)(nil).MaxVersion(); version++ {
+		p := NewLeaderAndISRRequestTopicPartition()
+		p.Partition = 1
+		p.ControllerEpoch = 2
+		p.Leader = 3
+		p.LeaderEpoch = 4
+		p.ISR = []int32{3, 5}
+		p.ZKVersion = 6
+		p.Replicas = []int32{3, 5, 7}
+		if version <= 1 {
+			p.Topic = "t"
+		}
+		if version >= 1 {
+			p.IsNew = true
+		}
+		if version >= 3 {
+			p.AddingReplicas = []int32{7}
+			p.RemovingReplicas = []int32{9}
+		}
+		if version >= 6 {
+			p.LeaderRecoveryState = 1
+		}
+
+		req := NewLeaderAndISRRequest()
+		req.Version = version
+		req.ControllerID = 3
+		req.ControllerEpoch = 2
+		if version >= 2 {
+			req.BrokerEpoch = 10
+		}
+		if version >= 5 {
+			req.Type = 1
+		}
+		if version <= 1 {
+			req.PartitionStates = append(req.PartitionStates, p)
+		} else {
+			ts := NewLeaderAndISRRequestTopicState()
+			ts.Topic = "t"
+			if version >= 5 {
+				ts.TopicID = [16]byte{1}
+			}
+			ts.PartitionStates = append(ts.PartitionStates, p)
+			req.TopicStates = append(req.TopicStates, ts)
+		}
+		l := NewLeaderAndISRRequestLiveLeader()
+		l.BrokerID, l.Host, l.Port = 3, "host", 9092
+		req.LiveLeaders = append(req.LiveLeaders, l)
+
+		raw := req.AppendTo(nil)
+		if version < 6 {
+			p := &req.PartitionStates
+			if version >= 2 {
+				p = &req.TopicStates[0].PartitionStates
+			}
+			(*p)[0].LeaderRecoveryState = 1
+			if !reflect.DeepEqual(req.AppendTo(nil), raw) {
+				t.Errorf("v%d: LeaderRecoveryState was encoded", version)
+			}
+			(*p)[0].LeaderRecoveryState = 0
+		}
+
+		got := NewLeaderAndISRRequest()
+		got.Version = version
+		if err := got.ReadFrom(raw); err != nil {
+			t.Fatalf("v%d: unable to decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, req) {
+			t.Errorf("v%d: got %+v != exp %+v", version, got, req)
+		}
+	}
+}
+
+func TestUpdateMetadataRequestRoundTrip(t *testing.T) {
+	for version := int16(0); version <= (*UpdateMetadataRequest)(nil).MaxVersion(); version++ {
+		p := NewUpdateMetadataRequestTopicPartition()
+		p.Partition = 1
+		p.ControllerEpoch = 2
+		p.Leader = 3
+		p.LeaderEpoch = 4
+		p.ISR = []int32{3, 5}
+		p.ZKVersion = 6
+		p.Replicas = []int32{3, 5, 7}
+		if version <= 4 {
+			p.Topic = "t"
+		}
+		if version >= 4 {
+			p.OfflineReplicas = []int32{7}
+		}
+
+		req := NewUpdateMetadataRequest()
+		req.Version = version
+		req.ControllerID = 3
+		req.ControllerEpoch = 2
+		if version >= 5 {
+			req.BrokerEpoch = 10
+		}
+		if version <= 4 {
+			req.PartitionStates = append(req.PartitionStates, p)
+		} else {
+			ts := NewUpdateMetadataRequestTopicState()
+			ts.Topic = "t"
+			if version >= 7 {
+				ts.TopicID = [16]byte{1}
+			}
+			ts.PartitionStates = append(ts.PartitionStates, p)
+			req.TopicStates = append(req.TopicStates, ts)
+		}
+		b := NewUpdateMetadataRequestLiveBroker()
+		b.ID = 3
+		if version == 0 {
+			b.Host, b.Port = "host", 9092
+		} else {
+			e := NewUpdateMetadataRequestLiveBrokerEndpoint()
+			e.Host, e.Port, e.SecurityProtocol = "host", 9092, 1
+			if version >= 3 {
+				e.ListenerName = "PLAINTEXT"
+			}
+			b.Endpoints = append(b.Endpoints, e)
+		}
+		if version >= 2 {
+			b.Rack = StringPtr("rack")
+		}
+		req.LiveBrokers = append(req.LiveBrokers, b)
+
+		raw := req.AppendTo(nil)
+		if version < 4 {
+			req.PartitionStates[0].OfflineReplicas = []int32{7}
+			if !reflect.DeepEqual(req.AppendTo(nil), raw) {
+				t.Errorf("v%d: OfflineReplicas was encoded", version)
+			}
+			req.PartitionStates[0].OfflineReplicas = nil
+		}
+
+		got := NewUpdateMetadataRequest()
+		got.Version = version
+		if err := got.ReadFrom(raw); err != nil {
+			t.Fatalf("v%d: unable to decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, req) {
+			t.Errorf("v%d: got %+v != exp %+v", version, got, req)
+		}
+	}
+}
+
+func TestStopReplicaRoundTrip(t *testing.T) {
+	for version := int16(0); version <= (*StopReplicaRequest)(nil).MaxVersion(); version++ {
+		req := NewStopReplicaRequest()
+		req.Version = version
+		req.ControllerID = 3
+		req.ControllerEpoch = 2
+		if version >= 1 {
+			req.BrokerEpoch = 10
+		}
+		if version <= 2 {
+			req.DeletePartitions = true
+		}
+		for _, topic := range []string{"t", "u"} {
+			rt := NewStopReplicaRequestTopic()
+			rt.Topic = topic
+			switch {
+			case version == 0:
+				rt.Partition = 1
+			case version <= 2:
+				rt.Partitions = []int32{1, 2}
+			default:
+				for _, p := range []int32{1, 2} {
+					ps := NewStopReplicaRequestTopicPartitionState()
+					ps.Partition = p
+					ps.LeaderEpoch = 4
+					ps.Delete = p == 2
+					rt.PartitionStates = append(rt.PartitionStates, ps)
+				}
+			}
+			req.Topics = append(req.Topics, rt)
+		}
+
+		got := NewStopReplicaRequest()
+		got.Version = version
+		if err := got.ReadFrom(req.AppendTo(nil)); err != nil {
+			t.Fatalf("v%d: unable to decode request: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, req) {
+			t.Errorf("v%d: got request %+v != exp %+v", version, got, req)
+		}
+
+		resp := NewStopReplicaResponse()
+		resp.Version = version
+		resp.ErrorCode = 5
+		for _, topic := range []string{"t", "u"} {
+			rp := NewStopReplicaResponsePartition()
+			rp.Topic = topic
+			rp.Partition = 1
+			rp.ErrorCode = 6
+			resp.Partitions = append(resp.Partitions, rp)
+		}
+
+		gotResp := NewStopReplicaResponse()
+		gotResp.Version = version
+		if err := gotResp.ReadFrom(resp.AppendTo(nil)); err != nil {
+			t.Fatalf("v%d: unable to decode response: %v", version, err)
+		}
+		if !reflect.DeepEqual(gotResp, resp) {
+			t.Errorf("v%d: got response %+v != exp %+v", version, gotResp, resp)
+		}
+	}
+}
+
+func TestWriteTxnMarkersRoundTrip(t *testing.T) {
+	for version := int16(0); version <= (*WriteTxnMarkersRequest)(nil).MaxVersion(); version++ {
+		req := NewWriteTxnMarkersRequest()
+		req.Version = version
+		for _, pid := range []int64{7, 8} {
+			m := NewWriteTxnMarkersRequestMarker()
+			m.ProducerID = pid
+			m.ProducerEpoch = 3
+			m.Committed = pid == 7
+			m.CoordinatorEpoch = 9
+			for _, topic := range []string{"t", "u"} {
+				mt := NewWriteTxnMarkersRequestMarkerTopic()
+				mt.Topic = topic
+				mt.Partitions = []int32{0, 2}
+				m.Topics = append(m.Topics, mt)
+			}
+			req.Markers = append(req.Markers, m)
+		}
+
+		got := NewWriteTxnMarkersRequest()
+		got.Version = version
+		if err := got.ReadFrom(req.AppendTo(nil)); err != nil {
+			t.Fatalf("v%d: unable to decode request: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, req) {
+			t.Errorf("v%d: got request %+v != exp %+v", version, got, req)
+		}
+
+		resp := NewWriteTxnMarkersResponse()
+		resp.Version = version
+		for _, pid := range []int64{7, 8} {
+			m := NewWriteTxnMarkersResponseMarker()
+			m.ProducerID = pid
+			for _, topic := range []string{"t", "u"} {
+				mt := NewWriteTxnMarkersResponseMarkerTopic()
+				mt.Topic = topic
+				for _, p := range []int32{0, 2} {
+					mp := NewWriteTxnMarkersResponseMarkerTopicPartition()
+					mp.Partition = p
+					mp.ErrorCode = int16(p) * 3
+					mt.Partitions = append(mt.Partitions, mp)
+				}
+				m.Topics = append(m.Topics, mt)
+			}
+			resp.Markers = append(resp.Markers, m)
+		}
+
+		gotResp := NewWriteTxnMarkersResponse()
+		gotResp.Version = version
+		if err := gotResp.ReadFrom(resp.AppendTo(nil)); err != nil {
+			t.Fatalf("v%d: unable to decode response: %v", version, err)
+		}
+		if !reflect.DeepEqual(gotResp, resp) {
+			t.Errorf("v%d: got response %+v != exp %+v", version, gotResp, resp)
+		}
+	}
+}
+
+func TestAppendToDeterministic(t *testing.T) {
+	req := NewApiVersionsRequest()
+	req.Version = 3
+	req.ClientSoftwareName = "kgo"
+	req.ClientSoftwareVersion = "1"
+	for i := uint32(0); i < 16; i++ {
+		req.UnknownTags.Set(100+i*7%16, []byte{byte(i)})
+	}
+
+	exp := req.AppendTo(nil)
+	for i := 0; i < 100; i++ {
+		if got := req.AppendTo(nil); !bytes.Equal(got, exp) {
+			t.Fatalf("AppendTo iteration %d: got %x != exp %x", i, got, exp)
+		}
+	}
+
+	var keys []uint32
+	req.UnknownTags.Each(func(key uint32, _ []byte) { keys = append(keys, key) })
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Fatalf("tags not in increasing order: %v", keys)
+		}
+	}
+
+	var decoded ApiVersionsRequest
+	decoded.Version = 3
+	if err := decoded.ReadFrom(exp); err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	if got := decoded.AppendTo(nil); !bytes.Equal(got, exp) {
+		t.Errorf("re-encoded decoded request: got %x != exp %x", got, exp)
+	}
+}
+
+// ReadFromLenient and ReadFrom both accept trailing bytes; only
+// ReadFromLenient reports where the known fields end.
+func TestReadFromLenient(t *testing.T) {
+	resp := NewFindCoordinatorResponse()
+	resp.Version = 3
+	resp.NodeID = 2
+	resp.Host = "host"
+	resp.Port = 9092
+	raw := resp.AppendTo(nil)
+
+	padded := append(append([]byte(nil), raw...), 0, 0, 0)
+	var got FindCoordinatorResponse
+	got.Version = 3
+	n, err := got.ReadFromLenient(padded)
+	if err != nil || n != len(raw) {
+		t.Errorf("padded: got %d, %v; exp %d, nil", n, err, len(raw))
+	}
+	if got.NodeID != 2 || got.Host != "host" || got.Port != 9092 {
+		t.Errorf("padded: got %+v, decoded fields do not match", got)
+	}
+	if err := got.ReadFrom(padded); err != nil {
+		t.Errorf("padded: ReadFrom got unexpected error %v", err)
+	}
+
+	if n, err := got.ReadFromLenient(raw[:len(raw)-1]); err == nil || n != 0 {
+		t.Errorf("truncated: got %d, %v; exp 0, non-nil error", n, err)
+	}
+}
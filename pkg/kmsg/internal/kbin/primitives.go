@@ -180,6 +180,47 @@ func AppendUvarint(dst []byte, u uint32) []byte {
 	return dst
 }
 
+// VarlongLen returns how long i would be if it were varlong encoded.
+func VarlongLen(i int64) int {
+	u := uint64(i)<<1 ^ uint64(i>>63)
+	return UvarlongLen(u)
+}
+
+// UvarlongLen returns how long u would be if it were uvarlong encoded.
+func UvarlongLen(u uint64) int {
+	if u == 0 {
+		return 1
+	}
+	return (bits.Len64(u) + 6) / 7
+}
+
+// Varlong is a 64 bit varint decoder. The return semantics are the same as
+// binary.Varint.
+func Varlong(in []byte) (int64, int) {
+	x, n := Uvarlong(in)
+	return int64((x >> 1) ^ -(x & 1)), n
+}
+
+// Uvarlong is a 64 bit uvarint decoder. The return semantics are the same as
+// binary.Uvarint.
+func Uvarlong(in []byte) (uint64, int) {
+	return binary.Uvarint(in)
+}
+
+// AppendVarlong appends a varlong encoded i to dst.
+func AppendVarlong(dst []byte, i int64) []byte {
+	return AppendUvarlong(dst, uint64(i)<<1^uint64(i>>63))
+}
+
+// AppendUvarlong appends a uvarlong encoded u to dst.
+func AppendUvarlong(dst []byte, u uint64) []byte {
+	for u >= 0x80 {
+		dst = append(dst, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(dst, byte(u))
+}
+
 // AppendString appends a string to dst prefixed with its int16 length.
 func AppendString(dst []byte, s string) []byte {
 	dst = AppendInt16(dst, int16(len(s)))
@@ -304,6 +345,25 @@ func AppendCompactNullableArrayLen(dst []byte, l int, isNil bool) []byte {
 	return AppendUvarint(dst, 1+uint32(l))
 }
 
+// AppendFlexibleArrayLen appends the length of an array to dst, as a compact
+// (uvarint length + 1) length if isFlexible is true, or as an int32 otherwise.
+func AppendFlexibleArrayLen(dst []byte, l int, isFlexible bool) []byte {
+	if isFlexible {
+		return AppendCompactArrayLen(dst, l)
+	}
+	return AppendArrayLen(dst, l)
+}
+
+// AppendFlexibleNullableArrayLen appends the length of a nullable array to
+// dst, as a compact length if isFlexible is true, or as an int32 otherwise. If
+// isNil is true, this appends the null length: 0 if compact, -1 if not.
+func AppendFlexibleNullableArrayLen(dst []byte, l int, isNil, isFlexible bool) []byte {
+	if isFlexible {
+		return AppendCompactNullableArrayLen(dst, l, isNil)
+	}
+	return AppendNullableArrayLen(dst, l, isNil)
+}
+
 // Reader is used to decode Kafka messages.
 //
 // For all functions on Reader, if the reader has been invalidated, functions
@@ -438,6 +498,30 @@ func (b *Reader) Uvarint() uint32 {
 	return val
 }
 
+// Varlong returns a varlong int64 from the reader.
+func (b *Reader) Varlong() int64 {
+	val, n := Varlong(b.Src)
+	if n <= 0 {
+		b.bad = true
+		b.Src = nil
+		return 0
+	}
+	b.Src = b.Src[n:]
+	return val
+}
+
+// Uvarlong returns a uvarlong encoded uint64 from the reader.
+func (b *Reader) Uvarlong() uint64 {
+	val, n := Uvarlong(b.Src)
+	if n <= 0 {
+		b.bad = true
+		b.Src = nil
+		return 0
+	}
+	b.Src = b.Src[n:]
+	return val
+}
+
 // Span returns l bytes from the reader.
 func (b *Reader) Span(l int) []byte {
 	if len(b.Src) < l || l < 0 {
@@ -572,6 +656,15 @@ func (b *Reader) CompactArrayLen() int32 {
 	return r
 }
 
+// FlexibleArrayLen returns a Kafka compact array length from the reader if
+// isFlexible is true, or a Kafka array length otherwise.
+func (b *Reader) FlexibleArrayLen(isFlexible bool) int32 {
+	if isFlexible {
+		return b.CompactArrayLen()
+	}
+	return b.ArrayLen()
+}
+
 // VarintBytes returns a Kafka encoded varint array from the reader, returning
 // nil as appropriate.
 func (b *Reader) VarintBytes() []byte {
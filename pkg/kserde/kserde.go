@@ -0,0 +1,258 @@
+// Package kserde provides an optional layer to serialize and deserialize
+// record keys and values.
+//
+// The kgo client works entirely in []byte. Applications usually work in typed
+// keys and values (strings, JSON, Avro, protobuf, ...), and converting to and
+// from bytes on every produce and consume is boilerplate. A Serde converts a
+// value to bytes and back, and Serdes ties a key Serde and a value Serde
+// together to encode records before producing and decode records after
+// fetching.
+//
+// This package ships a string serde and a raw bytes serde. Any other format,
+// including formats tied to a schema registry, can be plugged in by
+// implementing the Serde interface (or using SerdeFns). The sr subpackage
+// handles the Confluent Schema Registry wire format around another serde.
+package kserde
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Serde encodes values to bytes and decodes bytes back to values.
+type Serde interface {
+	// Encode encodes v, returning an error if v is not of a type the
+	// serde understands or if encoding fails.
+	Encode(v interface{}) ([]byte, error)
+	// Decode decodes b. b is the fetched record's key or value and is
+	// not reused by the client, so Decode may return values that alias
+	// it. A nil b is a null key or value, such as a tombstone.
+	Decode(b []byte) (interface{}, error)
+}
+
+// SerdeFns is a Serde built from an encode and decode function.
+type SerdeFns struct {
+	EncodeFn func(interface{}) ([]byte, error)
+	DecodeFn func([]byte) (interface{}, error)
+}
+
+// Encode calls EncodeFn.
+func (s SerdeFns) Encode(v interface{}) ([]byte, error) { return s.EncodeFn(v) }
+
+// Decode calls DecodeFn.
+func (s SerdeFns) Decode(b []byte) (interface{}, error) { return s.DecodeFn(b) }
+
+// StringSerde encodes strings (or []byte) and decodes to strings. A nil value
+// encodes to nil, and nil bytes (a null key or a tombstone) decode to a nil
+// interface rather than an empty string, so that the null is preserved.
+func StringSerde() Serde { return stringSerde{} }
+
+type stringSerde struct{}
+
+func (stringSerde) Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("string serde: unable to encode type %T", v)
+	}
+}
+
+func (stringSerde) Decode(b []byte) (interface{}, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return string(b), nil
+}
+
+// BytesSerde passes []byte through unmodified: a []byte value encodes to
+// itself, and decoding returns the input bytes without copying. A nil value
+// encodes to nil.
+func BytesSerde() Serde { return bytesSerde{} }
+
+type bytesSerde struct{}
+
+func (bytesSerde) Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("bytes serde: unable to encode type %T", v)
+	}
+}
+
+func (bytesSerde) Decode(b []byte) (interface{}, error) { return b, nil }
+
+// Record is a record with a decoded key and value.
+type Record struct {
+	// Key is the decoded key, or, when producing, the key to encode.
+	Key interface{}
+	// Value is the decoded value, or, when producing, the value to
+	// encode.
+	Value interface{}
+
+	// Record is the underlying record. When producing, the topic,
+	// partition, headers, and so on can be set here; Key and Value in the
+	// underlying record are overwritten with the encoded Key and Value.
+	// When decoding, this is the fetched record.
+	*kgo.Record
+}
+
+// DecodeError is the error passed to OnDecodeError (or returned from Decode)
+// when a record fails to decode.
+type DecodeError struct {
+	// Record is the record that failed to decode.
+	Record *kgo.Record
+	// IsKey is true if the key failed to decode, false if the value did.
+	IsKey bool
+	// Err is the error returned from the Serde.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	what := "value"
+	if e.IsKey {
+		what = "key"
+	}
+	return fmt.Sprintf("unable to decode record %s in topic %s partition %d at offset %d: %v",
+		what, e.Record.Topic, e.Record.Partition, e.Record.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// Serdes encodes and decodes record keys and values.
+//
+// If Key is nil, keys are passed through as raw bytes; the same is true of
+// Value. The zero Serdes thus works entirely in []byte.
+type Serdes struct {
+	// Key is the serde to use for record keys.
+	Key Serde
+	// Value is the serde to use for record values.
+	Value Serde
+
+	// OnDecodeError, if non-nil, is called for every record that fails to
+	// decode in DecodeFetches, and the record is not included in the
+	// returned records. If nil, DecodeFetches stops decoding at the first
+	// failure and returns the error. Records are never dropped silently.
+	OnDecodeError func(*DecodeError)
+}
+
+func (s *Serdes) keySerde() Serde {
+	if s.Key != nil {
+		return s.Key
+	}
+	return bytesSerde{}
+}
+
+func (s *Serdes) valueSerde() Serde {
+	if s.Value != nil {
+		return s.Value
+	}
+	return bytesSerde{}
+}
+
+// Encode encodes the record's Key and Value into the underlying record,
+// creating the underlying record if it is nil, and returns the underlying
+// record.
+func (s *Serdes) Encode(r *Record) (*kgo.Record, error) {
+	k, err := s.keySerde().Encode(r.Key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode record key: %w", err)
+	}
+	v, err := s.valueSerde().Encode(r.Value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode record value: %w", err)
+	}
+	if r.Record == nil {
+		r.Record = new(kgo.Record)
+	}
+	r.Record.Key = k
+	r.Record.Value = v
+	return r.Record, nil
+}
+
+// Decode decodes the key and value of a fetched record. If decoding fails,
+// this returns a *DecodeError.
+func (s *Serdes) Decode(kr *kgo.Record) (*Record, error) {
+	k, err := s.keySerde().Decode(kr.Key)
+	if err != nil {
+		return nil, &DecodeError{Record: kr, IsKey: true, Err: err}
+	}
+	v, err := s.valueSerde().Decode(kr.Value)
+	if err != nil {
+		return nil, &DecodeError{Record: kr, Err: err}
+	}
+	return &Record{Key: k, Value: v, Record: kr}, nil
+}
+
+// DecodeFetches decodes all records in the fetches, in order. Records that
+// fail to decode are passed to OnDecodeError; see the documentation on that
+// field for what happens if it is nil.
+func (s *Serdes) DecodeFetches(fs kgo.Fetches) ([]*Record, error) {
+	var rs []*Record
+	for iter := fs.RecordIter(); !iter.Done(); {
+		r, err := s.Decode(iter.Next())
+		if err != nil {
+			if s.OnDecodeError == nil {
+				return rs, err
+			}
+			s.OnDecodeError(err.(*DecodeError))
+			continue
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// Produce encodes r and produces it with the client. If encoding fails, the
+// promise is called immediately with the encoding error. The promise, if
+// non-nil, is called with r once the record is produced.
+func (s *Serdes) Produce(ctx context.Context, cl *kgo.Client, r *Record, promise func(*Record, error)) {
+	kr, err := s.Encode(r)
+	if err != nil {
+		if promise != nil {
+			promise(r, err)
+		}
+		return
+	}
+	cl.Produce(ctx, kr, func(_ *kgo.Record, err error) {
+		if promise != nil {
+			promise(r, err)
+		}
+	})
+}
+
+// ProduceSync encodes and synchronously produces all records, returning the
+// first error encountered, if any. Records that fail to encode are not
+// produced; all other records are still produced.
+func (s *Serdes) ProduceSync(ctx context.Context, cl *kgo.Client, rs ...*Record) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	wg.Add(len(rs))
+	for _, r := range rs {
+		s.Produce(ctx, cl, r, func(_ *Record, err error) {
+			defer wg.Done()
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return firstErr
+}
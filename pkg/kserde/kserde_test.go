@@ -0,0 +1,117 @@
+package kserde
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestSerdesRoundTrip(t *testing.T) {
+	type value struct {
+		N int `json:"n"`
+	}
+	jsonSerde := SerdeFns{
+		EncodeFn: func(v interface{}) ([]byte, error) { return json.Marshal(v) },
+		DecodeFn: func(b []byte) (interface{}, error) {
+			var v value
+			err := json.Unmarshal(b, &v)
+			return v, err
+		},
+	}
+	s := &Serdes{Key: StringSerde(), Value: jsonSerde}
+
+	kr, err := s.Encode(&Record{Key: "k", Value: value{3}, Record: &kgo.Record{Topic: "t"}})
+	if err != nil {
+		t.Fatalf("unexpected encode err: %v", err)
+	}
+	if kr.Topic != "t" || string(kr.Key) != "k" || string(kr.Value) != `{"n":3}` {
+		t.Errorf("got encoded %s/%s/%s, exp t/k/{\"n\":3}", kr.Topic, kr.Key, kr.Value)
+	}
+
+	r, err := s.Decode(kr)
+	if err != nil {
+		t.Fatalf("unexpected decode err: %v", err)
+	}
+	if r.Key != "k" || !reflect.DeepEqual(r.Value, value{3}) || r.Record != kr {
+		t.Errorf("got decoded %v/%v, exp k/{3}", r.Key, r.Value)
+	}
+
+	if _, err := s.Encode(&Record{Key: 1}); err == nil {
+		t.Error("unexpected success encoding an int with the string serde")
+	}
+
+	// A null key and a tombstone value round trip as nil.
+	strs := &Serdes{Key: StringSerde(), Value: StringSerde()}
+	kr, err = strs.Encode(&Record{})
+	if err != nil || kr.Key != nil || kr.Value != nil {
+		t.Fatalf("got tombstone encode %v/%v (err %v), exp nil/nil", kr.Key, kr.Value, err)
+	}
+	if r, err = strs.Decode(kr); err != nil || r.Key != nil || r.Value != nil {
+		t.Errorf("got tombstone decode %#v/%#v (err %v), exp nil/nil", r.Key, r.Value, err)
+	}
+	if v, _ := StringSerde().Decode([]byte{}); v != "" {
+		t.Errorf("got empty string decode %#v, exp \"\"", v)
+	}
+
+	var zero Serdes
+	b := []byte("raw")
+	kr, err = zero.Encode(&Record{Value: b})
+	if err != nil || !reflect.DeepEqual(kr.Value, b) || kr.Key != nil {
+		t.Errorf("got raw encode %v/%v (err %v), exp nil/raw", kr.Key, kr.Value, err)
+	}
+	r, err = zero.Decode(kr)
+	if err != nil || r.Key.([]byte) != nil || !reflect.DeepEqual(r.Value, b) {
+		t.Errorf("got raw decode %v/%v (err %v), exp nil/raw", r.Key, r.Value, err)
+	}
+}
+
+func TestSerdesDecodeFetchesErrors(t *testing.T) {
+	bad := errors.New("bad")
+	s := &Serdes{Value: SerdeFns{
+		EncodeFn: func(interface{}) ([]byte, error) { return nil, nil },
+		DecodeFn: func(b []byte) (interface{}, error) {
+			if string(b) == "bad" {
+				return nil, bad
+			}
+			return string(b), nil
+		},
+	}}
+	fs := kgo.Fetches{{Topics: []kgo.FetchTopic{{
+		Topic: "t",
+		Partitions: []kgo.FetchPartition{{
+			Partition: 0,
+			Records: []*kgo.Record{
+				{Topic: "t", Value: []byte("a"), Offset: 0},
+				{Topic: "t", Value: []byte("bad"), Offset: 1},
+				{Topic: "t", Value: []byte("c"), Offset: 2},
+			},
+		}},
+	}}}}
+
+	// Without a callback, decoding stops at the first failure.
+	rs, err := s.DecodeFetches(fs)
+	var de *DecodeError
+	if !errors.As(err, &de) || de.IsKey || de.Record.Offset != 1 || !errors.Is(err, bad) {
+		t.Errorf("got err %v, exp value decode error at offset 1", err)
+	}
+	if len(rs) != 1 || rs[0].Value != "a" {
+		t.Errorf("got %d records decoded before the failure, exp 1", len(rs))
+	}
+
+	// With a callback, failures are routed to it and decoding continues.
+	var failed []int64
+	s.OnDecodeError = func(de *DecodeError) { failed = append(failed, de.Record.Offset) }
+	rs, err = s.DecodeFetches(fs)
+	if err != nil {
+		t.Errorf("unexpected err with a callback: %v", err)
+	}
+	if len(rs) != 2 || rs[0].Value != "a" || rs[1].Value != "c" {
+		t.Errorf("got %d records, exp a and c", len(rs))
+	}
+	if !reflect.DeepEqual(failed, []int64{1}) {
+		t.Errorf("got failed offsets %v, exp [1]", failed)
+	}
+}
@@ -0,0 +1,130 @@
+// Package sr encodes and decodes the Confluent Schema Registry wire format.
+//
+// Schema Registry aware producers prefix each serialized key or value with a
+// magic byte (0) and the 4 byte big endian ID of the schema the payload was
+// written with. This package attaches and strips that header without any
+// dependency on a registry client, and provides a kserde.Serde that wraps
+// another serde in the header.
+package sr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kserde"
+)
+
+// MagicByte is the first byte of a Schema Registry encoded payload.
+const MagicByte = 0
+
+// HeaderSize is the size of the wire format header: the magic byte followed
+// by a 4 byte schema ID.
+const HeaderSize = 5
+
+// ErrNotRegistryEncoded is returned when decoding a payload that is too short
+// to contain the wire format header or does not begin with the magic byte.
+// Such a payload was likely not written by a Schema Registry aware producer.
+var ErrNotRegistryEncoded = errors.New("payload is not schema registry encoded: missing magic byte and schema id")
+
+// AppendEncode appends the wire format header for the given schema ID to
+// dst, followed by payload, and returns the extended slice.
+func AppendEncode(dst []byte, id int32, payload []byte) []byte {
+	dst = append(dst, MagicByte, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(dst[len(dst)-4:], uint32(id))
+	return append(dst, payload...)
+}
+
+// Encode returns payload prefixed with the wire format header for the given
+// schema ID.
+func Encode(id int32, payload []byte) []byte {
+	return AppendEncode(make([]byte, 0, HeaderSize+len(payload)), id, payload)
+}
+
+// Decode strips the wire format header from b, returning the schema ID and
+// the payload following the header. The payload aliases b.
+//
+// If b does not begin with the header, this returns ErrNotRegistryEncoded.
+func Decode(b []byte) (id int32, payload []byte, err error) {
+	if len(b) < HeaderSize || b[0] != MagicByte {
+		return 0, nil, ErrNotRegistryEncoded
+	}
+	return int32(binary.BigEndian.Uint32(b[1:])), b[HeaderSize:], nil
+}
+
+// SchemaID returns the schema ID of a wire format encoded payload, and
+// whether the payload was encoded with the wire format header.
+func SchemaID(b []byte) (int32, bool) {
+	id, _, err := Decode(b)
+	return id, err == nil
+}
+
+// Value is a decoded value along with the schema ID it was encoded with.
+type Value struct {
+	// ID is the schema ID from the wire format header, or -1 if the
+	// payload did not have the header (see Serde.AllowUnframed).
+	ID int32
+	// V is the value decoded by the inner serde.
+	V interface{}
+}
+
+// Serde is a kserde.Serde that wraps an inner serde in the wire format
+// header. Encoding encodes with the inner serde and prefixes the result with
+// ID. Decoding strips the header and decodes the payload with the inner
+// serde, returning a Value that carries the schema ID.
+//
+// Looking up schemas from a registry is left to the inner serde: a
+// registry-backed serde can use SchemaID on raw payloads, or Encode and
+// Decode directly, to pick the schema for an ID.
+type Serde struct {
+	// ID is the schema ID to encode with.
+	ID int32
+
+	// Serde encodes and decodes the payload inside the header.
+	Serde kserde.Serde
+
+	// AllowUnframed, if true, decodes payloads that lack the wire format
+	// header (non-registry data) with the inner serde directly, returning
+	// a Value with an ID of -1. If false, such payloads fail to decode
+	// with ErrNotRegistryEncoded.
+	AllowUnframed bool
+}
+
+// Encode encodes v with the inner serde and prefixes the wire format header.
+// If v is a Value, the value's ID is used rather than s.ID. A nil value
+// (a tombstone) encodes to nil.
+func (s *Serde) Encode(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	id := s.ID
+	if sv, ok := v.(Value); ok {
+		id, v = sv.ID, sv.V
+	}
+	b, err := s.Serde.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(id, b), nil
+}
+
+// Decode strips the wire format header and decodes the payload with the
+// inner serde, returning a Value. A nil payload (a tombstone) decodes to a
+// nil interface.
+func (s *Serde) Decode(b []byte) (interface{}, error) {
+	if b == nil {
+		return nil, nil
+	}
+	id, payload, err := Decode(b)
+	if err != nil {
+		if !s.AllowUnframed {
+			return nil, err
+		}
+		id, payload = -1, b
+	}
+	v, err := s.Serde.Decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("schema id %d: %w", id, err)
+	}
+	return Value{ID: id, V: v}, nil
+}
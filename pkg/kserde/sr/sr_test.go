@@ -0,0 +1,59 @@
+package sr
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kserde"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	b := Encode(0x01020304, []byte("payload"))
+	if exp := append([]byte{0, 1, 2, 3, 4}, "payload"...); !bytes.Equal(b, exp) {
+		t.Errorf("got %v, exp %v", b, exp)
+	}
+	id, payload, err := Decode(b)
+	if err != nil || id != 0x01020304 || string(payload) != "payload" {
+		t.Errorf("got %d/%s/%v, exp %d/payload/nil", id, payload, err, 0x01020304)
+	}
+	if id, ok := SchemaID(b); !ok || id != 0x01020304 {
+		t.Errorf("got schema id %d/%v, exp %d/true", id, ok, 0x01020304)
+	}
+
+	for _, bad := range [][]byte{nil, {0, 0, 0, 1}, []byte("plain json")} {
+		if _, _, err := Decode(bad); err != ErrNotRegistryEncoded {
+			t.Errorf("decoding %q: got err %v, exp %v", bad, err, ErrNotRegistryEncoded)
+		}
+		if _, ok := SchemaID(bad); ok {
+			t.Errorf("decoding %q: unexpected schema id", bad)
+		}
+	}
+}
+
+func TestSerde(t *testing.T) {
+	s := &Serde{ID: 7, Serde: kserde.StringSerde()}
+	b, err := s.Encode("v")
+	if err != nil || !bytes.Equal(b, []byte{0, 0, 0, 0, 7, 'v'}) {
+		t.Fatalf("got %v (err %v), exp header for 7 and v", b, err)
+	}
+	if b, _ := s.Encode(Value{ID: 9, V: "v"}); !bytes.Equal(b, []byte{0, 0, 0, 0, 9, 'v'}) {
+		t.Errorf("got %v, exp header for 9 and v", b)
+	}
+	v, err := s.Decode(b)
+	if err != nil || v != (Value{ID: 7, V: "v"}) {
+		t.Errorf("got %v (err %v), exp {7 v}", v, err)
+	}
+
+	if _, err := s.Decode([]byte("plain")); !errors.Is(err, ErrNotRegistryEncoded) {
+		t.Errorf("got err %v decoding unframed data, exp %v", err, ErrNotRegistryEncoded)
+	}
+	s.AllowUnframed = true
+	if v, err := s.Decode([]byte("plain")); err != nil || v != (Value{ID: -1, V: "plain"}) {
+		t.Errorf("got %v (err %v) decoding unframed data, exp {-1 plain}", v, err)
+	}
+
+	if v, err := s.Decode(nil); err != nil || v != nil {
+		t.Errorf("got %v (err %v) decoding a tombstone, exp nil", v, err)
+	}
+}
@@ -884,5 +884,23 @@ var maxTip = nextMax(max300, func(v listenerKeys) listenerKeys {
 	// KAFKA-10744 1d22b0d70686aef5689b775ea2ea7610a37f3e8c KIP-516
 	v[3].inc() // 12 metadata
 
+	// KIP-704
+	v[4].inc() // 6 leader and isr
+
+	// KIP-778
+	v[57].inc() // 1 update features
+
+	// KIP-848
+	v[8].inc()  // 9 offset commit
+	v[9].inc()  // 9 offset fetch
+	v[16].inc() // 5 list groups
+
+	// KIP-848: the new consumer group protocol is only supported on KRaft
+	// brokers.
+	v = append(v,
+		k(rBroker), // 68 consumer group heartbeat
+		k(rBroker), // 69 consumer group describe
+	)
+
 	return v
 })
@@ -1,4 +1,5 @@
-// Package scram provides Kerberos v5 sasl authentication.
+// Package kerberos provides Kerberos v5 sasl authentication (GSSAPI), as
+// specified in RFC4752.
 package kerberos
 
 import (
@@ -20,7 +21,10 @@ import (
 // Auth contains a Kerberos client and the service name that we will use to get
 // a ticket for.
 type Auth struct {
-	// Client is a Kerberos client.
+	// Client is a Kerberos client. A client can be created from a keytab
+	// with client.NewWithKeytab, from a credentials cache with
+	// client.NewFromCCache, or from a password with
+	// client.NewWithPassword.
 	Client *client.Client
 
 	// Service is the service name we will get a ticket for.
@@ -79,7 +83,7 @@ func (k k) Authenticate(ctx context.Context, host string) (sasl.Session, []byte,
 		return nil, nil, err
 	}
 
-	if strings.IndexByte(host, ':') != 0 {
+	if strings.IndexByte(host, ':') >= 0 {
 		if host, _, err = net.SplitHostPort(host); err != nil {
 			return nil, nil, err
 		}
@@ -128,6 +132,10 @@ func (k k) Authenticate(ctx context.Context, host string) (sasl.Session, []byte,
 	return &session{0, c, encKey}, append(gssHeader, apr...), nil
 }
 
+// secLayerNone is the RFC4752 bit for no security layer; Kafka relies on TLS
+// for confidentiality rather than GSSAPI wrapping.
+const secLayerNone = 1
+
 type session struct {
 	step   int
 	client *wrapped
@@ -147,7 +155,18 @@ func (s *session) Challenge(resp []byte) (bool, []byte, error) {
 		if !isValid {
 			return false, nil, err
 		}
-		response, err := gssapi.NewInitiatorWrapToken(challenge.Payload, s.encKey)
+
+		// RFC4752 section 3.1: the payload is one byte of supported
+		// security layers followed by three bytes of the maximum
+		// message size. We support only the "no security layer"
+		// layer, which requires a maximum message size of zero.
+		if len(challenge.Payload) != 4 {
+			return false, nil, fmt.Errorf("invalid security layer challenge length %d, expected 4", len(challenge.Payload))
+		}
+		if challenge.Payload[0]&secLayerNone == 0 {
+			return false, nil, fmt.Errorf("server does not support the no security layer, supported layers bitmask: %#x", challenge.Payload[0])
+		}
+		response, err := gssapi.NewInitiatorWrapToken([]byte{secLayerNone, 0, 0, 0}, s.encKey)
 		if err != nil {
 			return false, nil, err
 		}
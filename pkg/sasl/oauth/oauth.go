@@ -4,7 +4,7 @@ package oauth
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/twmb/franz-go/pkg/sasl"
@@ -40,6 +40,13 @@ func (a Auth) AsMechanism() sasl.Mechanism {
 
 // Oauth returns an OAUTHBEARER sasl mechanism that will call authFn whenever
 // authentication is needed. The returned Auth is used for a single session.
+//
+// authFn is called for every new connection and every reauthentication. If
+// the broker has a limited session lifetime (KIP-368), the client
+// reauthenticates before the lifetime expires, so authFn can return a fresh
+// token before the broker would otherwise close the connection. authFn should
+// cache tokens and only fetch a new token from the identity provider when the
+// cached one is near expiry.
 func Oauth(authFn func(context.Context) (Auth, error)) sasl.Mechanism {
 	return oauth(authFn)
 }
@@ -91,7 +98,10 @@ type session struct{}
 
 func (session) Challenge(resp []byte) (bool, []byte, error) {
 	if len(resp) != 0 {
-		return false, nil, errors.New("unexpected data in oauth response")
+		// If authentication fails, the broker replies with a JSON
+		// error message (RFC7628 section 3.2.2), which we return
+		// as is so the reason for the failure is not lost.
+		return false, nil, fmt.Errorf("oauth authentication failed, broker replied with: %s", resp)
 	}
 	return true, nil, nil
 }
@@ -49,7 +49,10 @@ type Auth struct {
 	// IsToken, if true, suffixes the "tokenauth=true" extra attribute to
 	// the initial authentication message.
 	//
-	// Set this to true if the user and pass are from a delegation token.
+	// Set this to true if the user and pass are from a delegation token:
+	// the user is the token ID, and the pass is the base64 encoded token
+	// HMAC. The kadm package's DelegationToken.ScramAuth returns such an
+	// Auth.
 	IsToken bool
 
 	_internal struct{} // require explicit field initalization